@@ -3,12 +3,14 @@
 package addons
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/kemilad/karpx/internal/awscli"
+	"github.com/kemilad/karpx/internal/backend"
 )
 
 // Status represents the install state of an add-on on a cluster.
@@ -189,15 +191,6 @@ func ByID(id string) (Addon, bool) {
 	return Addon{}, false
 }
 
-// helmRelease is the minimal shape returned by `helm list -o json`.
-type helmRelease struct {
-	Name       string `json:"name"`
-	Namespace  string `json:"namespace"`
-	Status     string `json:"status"`
-	Chart      string `json:"chart"`      // e.g. "kube-prometheus-stack-65.1.1"
-	AppVersion string `json:"app_version"`
-}
-
 // addonChartName extracts the chart name from an Addon.Chart value.
 // "prometheus-community/kube-prometheus-stack" → "kube-prometheus-stack"
 func addonChartName(chart string) string {
@@ -226,18 +219,10 @@ func IsReleaseInstalled(kubeCtx, releaseName string) bool {
 
 // isReleaseInstalled reports whether a named Helm release is currently deployed.
 func isReleaseInstalled(kubeCtx, releaseName string) bool {
-	args := []string{"list", "--all-namespaces", "--output", "json"}
-	if kubeCtx != "" {
-		args = append(args, "--kube-context", kubeCtx)
-	}
-	out, err := exec.Command("helm", args...).Output()
+	releases, err := backend.Current().Helm.Releases(kubeCtx)
 	if err != nil {
 		return false
 	}
-	var releases []helmRelease
-	if err := json.Unmarshal(out, &releases); err != nil {
-		return false
-	}
 	for _, r := range releases {
 		if r.Name == releaseName {
 			return true
@@ -292,39 +277,24 @@ func vpcIDFromCluster(region, clusterName string) string {
 	if region == "" || clusterName == "" {
 		return ""
 	}
-	args := []string{
-		"eks", "describe-cluster",
-		"--region", region,
+	out, err := awscli.Text(region, "eks", "describe-cluster",
 		"--name", clusterName,
 		"--query", "cluster.resourcesVpcConfig.vpcId",
-		"--output", "text",
-	}
-	out, err := exec.Command("aws", args...).Output()
+	)
 	if err != nil {
 		return ""
 	}
-	return strings.TrimSpace(string(out))
+	return out
 }
 
 // Detect queries helm to determine whether an add-on is installed in a cluster.
 func Detect(kubeCtx string, a Addon) Entry {
 	e := Entry{Addon: a, Status: StatusNotInstalled}
 
-	args := []string{"list", "--all-namespaces", "--output", "json"}
-	if kubeCtx != "" {
-		args = append(args, "--kube-context", kubeCtx)
-	}
-	out, err := exec.Command("helm", args...).Output()
+	releases, err := backend.Current().Helm.Releases(kubeCtx)
 	if err != nil {
 		e.Status = StatusError
-		e.Error = "helm list failed: " + err.Error()
-		return e
-	}
-
-	var releases []helmRelease
-	if err := json.Unmarshal(out, &releases); err != nil {
-		e.Status = StatusError
-		e.Error = "failed to parse helm output"
+		e.Error = err.Error()
 		return e
 	}
 
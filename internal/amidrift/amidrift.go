@@ -0,0 +1,335 @@
+// Package amidrift finds Karpenter nodes running an AMI other than the one
+// their EC2NodeClass selects today, and EC2NodeClasses pinned to an outdated
+// AMI, and remediates either.
+//
+// Two kinds of drift are reported per EC2NodeClass:
+//
+//	stale nodes  NodeClaims whose imageID is not among the AMIs the
+//	             EC2NodeClass resolves (status.amis). Karpenter marks them
+//	             Drifted, but budgets or do-not-disrupt pods can hold them.
+//	pinned AMI   amiSelectorTerms pin an alias version (al2023@v20240807)
+//	             older than the latest EKS-optimized release for the cluster
+//	             version, so new nodes launch outdated too.
+//
+// Remediation is Repin (point the alias at the latest release, so Karpenter
+// drifts nodes within the NodePool budgets) or Rotate (replace stale
+// NodeClaims one at a time, each drained gracefully by Karpenter).
+package amidrift
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kemilad/karpx/internal/awscli"
+)
+
+// NodeAMI is one NodeClaim and the AMI it was launched from.
+type NodeAMI struct {
+	NodeClaim string `json:"nodeclaim"`
+	Node      string `json:"node,omitempty"`
+	ImageID   string `json:"image_id"`
+	Drifted   bool   `json:"drifted"` // Karpenter's Drifted condition is set
+}
+
+// ClassDrift is the drift found for one EC2NodeClass.
+type ClassDrift struct {
+	Name          string    `json:"name"`
+	Selector      string    `json:"selector"`        // amiSelectorTerms, summarised
+	Alias         string    `json:"alias,omitempty"` // alias term, e.g. al2023@v20240807
+	Resolved      []string  `json:"resolved"`        // status.amis
+	Nodes         int       `json:"nodes"`           // NodeClaims of this class
+	Stale         []NodeAMI `json:"stale,omitempty"` // NodeClaims on an AMI not in Resolved
+	LatestVersion string    `json:"latest_version,omitempty"`
+	LatestAMI     string    `json:"latest_ami,omitempty"`
+	Outdated      bool      `json:"outdated"` // alias pins a version older than LatestVersion
+}
+
+// Drifted reports whether anything needs remediation.
+func (c ClassDrift) Drifted() bool { return len(c.Stale) > 0 || c.Outdated }
+
+// LatestAlias is the alias the class would be repinned to.
+func (c ClassDrift) LatestAlias() string {
+	family, _, _ := strings.Cut(c.Alias, "@")
+	return family + "@" + c.LatestVersion
+}
+
+// Check reads every EC2NodeClass and NodeClaim and reports drift. region
+// and k8sVersion are used to look up the latest EKS-optimized release;
+// when region is "" pinned aliases are not checked.
+func Check(kubeCtx, region, k8sVersion string) ([]ClassDrift, error) {
+	var classes struct {
+		Items []nodeClass `json:"items"`
+	}
+	if err := kubectlJSON(kubeCtx, &classes, "get", "ec2nodeclasses.karpenter.k8s.aws", "-o", "json"); err != nil {
+		return nil, err
+	}
+	var claims struct {
+		Items []nodeClaim `json:"items"`
+	}
+	if err := kubectlJSON(kubeCtx, &claims, "get", "nodeclaims.karpenter.sh", "-o", "json"); err != nil {
+		return nil, err
+	}
+
+	latest := map[string][2]string{} // family → {version, ami}
+	var out []ClassDrift
+	for _, nc := range classes.Items {
+		d := ClassDrift{Name: nc.Metadata.Name, Selector: nc.selector(), Alias: nc.alias()}
+		resolved := map[string]bool{}
+		for _, a := range nc.Status.AMIs {
+			d.Resolved = append(d.Resolved, a.ID)
+			resolved[a.ID] = true
+		}
+		for _, c := range claims.Items {
+			if c.Spec.NodeClassRef.Name != d.Name {
+				continue
+			}
+			d.Nodes++
+			// A claim still launching has no imageID yet.
+			if c.Status.ImageID != "" && len(resolved) > 0 && !resolved[c.Status.ImageID] {
+				d.Stale = append(d.Stale, NodeAMI{
+					NodeClaim: c.Metadata.Name,
+					Node:      c.Status.NodeName,
+					ImageID:   c.Status.ImageID,
+					Drifted:   c.condition("Drifted"),
+				})
+			}
+		}
+		sort.Slice(d.Stale, func(i, j int) bool { return d.Stale[i].NodeClaim < d.Stale[j].NodeClaim })
+
+		family, pinned, _ := strings.Cut(d.Alias, "@")
+		if region != "" && pinned != "" && pinned != "latest" {
+			l, ok := latest[family]
+			if !ok {
+				v, ami, err := latestRelease(region, family, k8sVersion)
+				if err != nil {
+					return nil, err
+				}
+				l = [2]string{v, ami}
+				latest[family] = l
+			}
+			d.LatestVersion, d.LatestAMI = l[0], l[1]
+			d.Outdated = d.LatestVersion != "" && olderRelease(pinned, d.LatestVersion)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// Repin replaces the alias term of the EC2NodeClass with alias. Karpenter
+// then marks the nodes launched from the old AMI as drifted and replaces
+// them within the NodePool disruption budgets.
+func Repin(kubeCtx, class, alias string) error {
+	var nc nodeClass
+	if err := kubectlJSON(kubeCtx, &nc, "get", "ec2nodeclasses.karpenter.k8s.aws", class, "-o", "json"); err != nil {
+		return err
+	}
+	terms := nc.Spec.AMISelectorTerms
+	found := false
+	for _, t := range terms {
+		if _, ok := t["alias"]; ok {
+			t["alias"] = alias
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("EC2NodeClass %s has no alias in amiSelectorTerms", class)
+	}
+	patch, err := json.Marshal(map[string]any{"spec": map[string]any{"amiSelectorTerms": terms}})
+	if err != nil {
+		return err
+	}
+	_, err = kubectl(kubeCtx, "patch", "ec2nodeclasses.karpenter.k8s.aws", class, "--type", "merge", "-p", string(patch))
+	return err
+}
+
+// Rotate deletes the NodeClaims one at a time. Karpenter cordons and drains
+// each node, honouring PodDisruptionBudgets, and launches a replacement from
+// the current AMI before the next one is touched. report is called before
+// and after each NodeClaim.
+func Rotate(kubeCtx string, nodes []NodeAMI, timeout time.Duration, report func(n NodeAMI, done bool, err error)) error {
+	for _, n := range nodes {
+		report(n, false, nil)
+		if _, err := kubectl(kubeCtx, "delete", "nodeclaims.karpenter.sh", n.NodeClaim, "--wait=false"); err != nil {
+			report(n, true, err)
+			return err
+		}
+		_, err := kubectl(kubeCtx, "wait", "--for=delete", "nodeclaims.karpenter.sh/"+n.NodeClaim, "--timeout", timeout.String())
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			err = fmt.Errorf("%s did not terminate within %s — check for pods blocking the drain: %w", n.NodeClaim, timeout, err)
+			report(n, true, err)
+			return err
+		}
+		report(n, true, nil)
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Latest EKS-optimized releases
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ssmParameters are the public SSM parameters holding the latest AMI of each
+// alias family for Kubernetes %s.
+var ssmParameters = map[string]string{
+	"al2023":       "/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id",
+	"al2":          "/aws/service/eks/optimized-ami/%s/amazon-linux-2/recommended/image_id",
+	"bottlerocket": "/aws/service/bottlerocket/aws-k8s-%s/x86_64/latest/image_id",
+}
+
+// releaseVersion is the alias version embedded in an AMI name, e.g.
+// "amazon-eks-node-al2023-x86_64-standard-1.30-v20240807" → "v20240807",
+// "bottlerocket-aws-k8s-1.30-x86_64-v1.20.5-a3e8bda1" → "v1.20.5".
+var releaseVersion = regexp.MustCompile(`-(v\d+(?:\.\d+)*)(?:-|$)`)
+
+// latestRelease returns the alias version and AMI ID of the newest release
+// of family for the Kubernetes minor of k8sVersion.
+func latestRelease(region, family, k8sVersion string) (string, string, error) {
+	param, ok := ssmParameters[family]
+	if !ok {
+		return "", "", nil
+	}
+	parts := strings.SplitN(k8sVersion, ".", 3)
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("invalid Kubernetes version %q", k8sVersion)
+	}
+	ami, err := awscli.Text(region, "ssm", "get-parameter", "--name", fmt.Sprintf(param, parts[0]+"."+parts[1]),
+		"--query", "Parameter.Value")
+	if err != nil {
+		return "", "", fmt.Errorf("latest %s AMI: %w", family, err)
+	}
+	name, err := awscli.Text(region, "ec2", "describe-images", "--image-ids", ami, "--query", "Images[0].Name")
+	if err != nil {
+		return "", "", fmt.Errorf("describe %s: %w", ami, err)
+	}
+	m := releaseVersion.FindAllStringSubmatch(name, -1)
+	if len(m) == 0 {
+		return "", ami, nil
+	}
+	return m[len(m)-1][1], ami, nil
+}
+
+// olderRelease compares alias versions: dates (v20240807) or dotted
+// releases (v1.20.5).
+func olderRelease(a, b string) bool {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if len(pa[i]) != len(pb[i]) {
+			return len(pa[i]) < len(pb[i])
+		}
+		if pa[i] != pb[i] {
+			return pa[i] < pb[i]
+		}
+	}
+	return len(pa) < len(pb)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Karpenter objects
+// ─────────────────────────────────────────────────────────────────────────────
+
+type nodeClass struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		AMISelectorTerms []map[string]any `json:"amiSelectorTerms"`
+	} `json:"spec"`
+	Status struct {
+		AMIs []struct {
+			ID string `json:"id"`
+		} `json:"amis"`
+	} `json:"status"`
+}
+
+func (nc nodeClass) alias() string {
+	for _, t := range nc.Spec.AMISelectorTerms {
+		if a, ok := t["alias"].(string); ok {
+			return a
+		}
+	}
+	return ""
+}
+
+// selector summarises amiSelectorTerms, e.g. "alias al2023@latest" or
+// "id ami-0abc, name my-ami-*".
+func (nc nodeClass) selector() string {
+	var parts []string
+	for _, t := range nc.Spec.AMISelectorTerms {
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if s, ok := t[k].(string); ok {
+				parts = append(parts, k+" "+s)
+			} else {
+				parts = append(parts, k)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return "—"
+	}
+	return strings.Join(parts, ", ")
+}
+
+type nodeClaim struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeClassRef struct {
+			Name string `json:"name"`
+		} `json:"nodeClassRef"`
+	} `json:"spec"`
+	Status struct {
+		ImageID    string `json:"imageID"`
+		NodeName   string `json:"nodeName"`
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+func (c nodeClaim) condition(t string) bool {
+	for _, cond := range c.Status.Conditions {
+		if cond.Type == t {
+			return cond.Status == "True"
+		}
+	}
+	return false
+}
+
+func kubectlJSON(kubeCtx string, v any, args ...string) error {
+	out, err := kubectl(kubeCtx, args...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(out, v); err != nil {
+		return fmt.Errorf("parse kubectl %s output: %w", strings.Join(args[:2], " "), err)
+	}
+	return nil
+}
+
+func kubectl(kubeCtx string, args ...string) ([]byte, error) {
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	cmd := exec.Command("kubectl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl %s %s: %w: %s", args[0], args[1], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,213 @@
+// Package awscli runs the AWS CLI on behalf of karpx commands.
+//
+// karpx deliberately shells out to `aws` rather than linking the AWS SDK so
+// that credentials, profiles, SSO sessions, and proxies configured for the CLI
+// work unchanged.
+//
+// An expired SSO session is recognised from the CLI's error output. Commands
+// get one chance to re-authenticate through the Reauth hook (the CLI asks to
+// run `aws sso login`) and are retried once; otherwise the error says which
+// login command to run instead of repeating the CLI's token error.
+package awscli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+var (
+	profile string
+
+	reauthMu    sync.Mutex
+	reauthTried bool
+	reauthOK    bool
+)
+
+// Reauth, when set, is called the first time a command fails because the SSO
+// session expired. It should refresh the session (see Login) and report
+// whether that worked. Only one attempt is made per process; concurrent
+// callers wait for it.
+var Reauth func(profile string) bool
+
+// SetProfile selects the AWS CLI profile for every command. It also sets
+// AWS_PROFILE so kubectl and helm, whose EKS credentials come from
+// `aws eks get-token`, use the same profile.
+func SetProfile(name string) {
+	profile = name
+	if name != "" {
+		os.Setenv("AWS_PROFILE", name)
+	}
+}
+
+// Profile returns the profile set with SetProfile, or AWS_PROFILE.
+func Profile() string {
+	if profile != "" {
+		return profile
+	}
+	return os.Getenv("AWS_PROFILE")
+}
+
+// Command builds an `aws` invocation, appending --region when region is set
+// and --profile when one was selected.
+func Command(region string, args ...string) *exec.Cmd {
+	if region != "" {
+		args = append(args, "--region", region)
+	}
+	if profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	return exec.Command("aws", args...)
+}
+
+// Login runs `aws sso login` for the active profile attached to the
+// terminal, so the device-code prompt and browser hand-off reach the user.
+// Its output goes to stderr: stdout may be carrying JSON or a manifest.
+func Login() error {
+	args := []string{"sso", "login"}
+	if p := Profile(); p != "" {
+		args = append(args, "--profile", p)
+	}
+	cmd := exec.Command("aws", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	return cmd.Run()
+}
+
+// ErrSessionExpired is returned (wrapped) when the SSO session or the
+// temporary credentials behind the profile have expired.
+var ErrSessionExpired = errors.New("AWS session expired")
+
+// expiredMarkers are fragments of the CLI's messages for an expired SSO
+// token or temporary credentials.
+var expiredMarkers = []string{
+	"Token has expired and refresh failed",
+	"The SSO session associated with this profile has expired",
+	"Error loading SSO Token",
+	"Error when retrieving token from sso",
+	"UnauthorizedSSOTokenError",
+	"ExpiredToken",
+	"RequestExpired",
+}
+
+func sessionExpired(msg string) bool {
+	for _, m := range expiredMarkers {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// expiredError replaces the CLI's token error with the command that fixes it.
+func expiredError() error {
+	login := "aws sso login"
+	if p := Profile(); p != "" {
+		login += " --profile " + p
+	}
+	return fmt.Errorf("%w — run `%s` and try again", ErrSessionExpired, login)
+}
+
+// retryAfterReauth reports whether a command that failed with an expired
+// session should be run again.
+func retryAfterReauth() bool {
+	reauthMu.Lock()
+	defer reauthMu.Unlock()
+	if !reauthTried {
+		reauthTried = true
+		reauthOK = Reauth != nil && Reauth(Profile())
+	}
+	return reauthOK
+}
+
+// output runs the command, re-authenticating once on an expired session.
+func output(combined bool, region string, args ...string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		cmd := Command(region, args...)
+		var out []byte
+		var err error
+		if combined {
+			out, err = cmd.CombinedOutput()
+		} else {
+			out, err = cmd.Output()
+		}
+		if err == nil {
+			return out, nil
+		}
+		msg := string(out)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			msg += string(exitErr.Stderr)
+		}
+		if !sessionExpired(msg) {
+			return out, err
+		}
+		if attempt > 0 || !retryAfterReauth() {
+			return out, expiredError()
+		}
+	}
+}
+
+// JSON runs the AWS CLI with --output json and decodes stdout into v.
+func JSON(v any, region string, args ...string) error {
+	args = append(args, "--output", "json")
+	out, err := output(false, region, args...)
+	if err != nil {
+		return fmt.Errorf("aws %s: %w", strings.Join(args[:min(2, len(args))], " "), stderrOf(err))
+	}
+	if err := json.Unmarshal(out, v); err != nil {
+		return fmt.Errorf("parse aws %s output: %w", strings.Join(args[:min(2, len(args))], " "), err)
+	}
+	return nil
+}
+
+// Run executes the AWS CLI and returns combined output on failure.
+func Run(region string, args ...string) error {
+	out, err := output(true, region, args...)
+	if errors.Is(err, ErrSessionExpired) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Text runs the AWS CLI with --output text and returns trimmed stdout.
+func Text(region string, args ...string) (string, error) {
+	args = append(args, "--output", "text")
+	out, err := output(false, region, args...)
+	if err != nil {
+		return "", stderrOf(err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Available reports whether the aws binary is on PATH.
+func Available() bool {
+	_, err := exec.LookPath("aws")
+	return err == nil
+}
+
+// RegionFromContext extracts the AWS region from an EKS kubeconfig context ARN
+// in any partition.
+// "arn:<partition>:eks:<region>:<account>:cluster/<name>" → "<region>"
+// Returns "" if the context is not an EKS ARN.
+func RegionFromContext(kubeCtx string) string {
+	parts := strings.Split(kubeCtx, ":")
+	if len(parts) >= 6 && parts[0] == "arn" && parts[2] == "eks" {
+		return parts[3]
+	}
+	return ""
+}
+
+func stderrOf(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
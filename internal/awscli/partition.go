@@ -0,0 +1,94 @@
+package awscli
+
+import "strings"
+
+// AWS partitions. Each has its own ARNs, endpoints and accounts; credentials
+// for one do not work in another.
+const (
+	PartitionAWS      = "aws"        // commercial regions
+	PartitionChina    = "aws-cn"     // cn-north-1, cn-northwest-1
+	PartitionGovCloud = "aws-us-gov" // us-gov-west-1, us-gov-east-1
+)
+
+// Partition returns the partition of region; "" is the commercial partition.
+func Partition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionChina
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionGovCloud
+	}
+	return PartitionAWS
+}
+
+// ARNPartition returns the partition of an ARN, or "" when s is not an ARN
+// in a known partition.
+func ARNPartition(s string) string {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 3 || parts[0] != "arn" {
+		return ""
+	}
+	switch parts[1] {
+	case PartitionAWS, PartitionChina, PartitionGovCloud:
+		return parts[1]
+	}
+	return ""
+}
+
+// IsARN reports whether s is an ARN of service in any partition, e.g.
+// IsARN("arn:aws-us-gov:eks:us-gov-west-1:123456789012:cluster/x", "eks").
+func IsARN(s, service string) bool {
+	parts := strings.SplitN(s, ":", 4)
+	return len(parts) == 4 && ARNPartition(s) != "" && parts[2] == service
+}
+
+// ARN builds an ARN in the partition of region. Global services such as IAM
+// take region "" and then need partition set explicitly; otherwise it is
+// derived from region.
+func ARN(partition, service, region, account, resource string) string {
+	if partition == "" {
+		partition = Partition(region)
+	}
+	return "arn:" + partition + ":" + service + ":" + region + ":" + account + ":" + resource
+}
+
+// DNSSuffix is the domain of the partition's endpoints and service principals.
+func DNSSuffix(partition string) string {
+	if partition == PartitionChina {
+		return "amazonaws.com.cn"
+	}
+	return "amazonaws.com"
+}
+
+// PricingRegion is the region serving the Price List API for region's
+// partition, or "" where there is none (GovCloud).
+func PricingRegion(region string) string {
+	switch Partition(region) {
+	case PartitionChina:
+		return "cn-northwest-1"
+	case PartitionGovCloud:
+		return ""
+	}
+	return "us-east-1"
+}
+
+// Currency is the currency the Price List API quotes in for region.
+func Currency(region string) string {
+	if Partition(region) == PartitionChina {
+		return "CNY"
+	}
+	return "USD"
+}
+
+// ECRRegistry is the private ECR registry of account in region, e.g.
+// "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn".
+func ECRRegistry(account, region string) string {
+	return account + ".dkr.ecr." + region + "." + DNSSuffix(Partition(region))
+}
+
+// PublicECRReachable reports whether public.ecr.aws, where Karpenter
+// publishes its chart and images, can be pulled from region. It is not
+// served in the China regions.
+func PublicECRReachable(region string) bool {
+	return Partition(region) != PartitionChina
+}
@@ -0,0 +1,229 @@
+// Package azure automates Karpenter setup on AKS through the Azure CLI.
+//
+// Like internal/awscli, it shells out to `az` instead of linking the Azure SDK
+// so that `az login` sessions, subscriptions, and proxies configured for the
+// CLI work unchanged.
+package azure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Cluster is the subset of `az aks show` that the install flow needs.
+type Cluster struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	ResourceGroup     string `json:"resourceGroup"`
+	Location          string `json:"location"`
+	NodeResourceGroup string `json:"nodeResourceGroup"`
+	FQDN              string `json:"fqdn"`
+	KubernetesVersion string `json:"kubernetesVersion"`
+
+	OIDCIssuerProfile struct {
+		Enabled   bool   `json:"enabled"`
+		IssuerURL string `json:"issuerUrl"`
+	} `json:"oidcIssuerProfile"`
+	SecurityProfile struct {
+		WorkloadIdentity *struct {
+			Enabled bool `json:"enabled"`
+		} `json:"workloadIdentity"`
+	} `json:"securityProfile"`
+	NetworkProfile struct {
+		NetworkPlugin     string `json:"networkPlugin"`
+		NetworkPluginMode string `json:"networkPluginMode"`
+		NetworkDataplane  string `json:"networkDataplane"`
+		NetworkPolicy     string `json:"networkPolicy"`
+	} `json:"networkProfile"`
+	NodeProvisioningProfile *struct {
+		Mode string `json:"mode"`
+	} `json:"nodeProvisioningProfile"`
+	AgentPoolProfiles []struct {
+		Name              string `json:"name"`
+		OSType            string `json:"osType"`
+		EnableAutoScaling bool   `json:"enableAutoScaling"`
+		VnetSubnetID      string `json:"vnetSubnetId"`
+	} `json:"agentPoolProfiles"`
+	ServicePrincipalProfile *struct {
+		ClientID string `json:"clientId"`
+	} `json:"servicePrincipalProfile"`
+	LinuxProfile *struct {
+		SSH struct {
+			PublicKeys []struct {
+				KeyData string `json:"keyData"`
+			} `json:"publicKeys"`
+		} `json:"ssh"`
+	} `json:"linuxProfile"`
+	IdentityProfile map[string]struct {
+		ClientID   string `json:"clientId"`
+		ObjectID   string `json:"objectId"`
+		ResourceID string `json:"resourceId"`
+	} `json:"identityProfile"`
+}
+
+// SubscriptionID returns the subscription the cluster lives in.
+// "/subscriptions/<id>/resourcegroups/…" → "<id>"
+func (c *Cluster) SubscriptionID() string {
+	parts := strings.Split(c.ID, "/")
+	if len(parts) > 2 && strings.EqualFold(parts[1], "subscriptions") {
+		return parts[2]
+	}
+	return ""
+}
+
+// NodeResourceGroupID is the ARM scope of the MC_ resource group, where
+// Karpenter creates VMs, NICs, and disks.
+func (c *Cluster) NodeResourceGroupID() string {
+	return "/subscriptions/" + c.SubscriptionID() + "/resourceGroups/" + c.NodeResourceGroup
+}
+
+// WorkloadIdentityEnabled reports whether both the OIDC issuer and the
+// workload identity webhook are enabled — the controller authenticates
+// through a federated credential and needs both.
+func (c *Cluster) WorkloadIdentityEnabled() bool {
+	return c.OIDCIssuerProfile.Enabled && c.OIDCIssuerProfile.IssuerURL != "" &&
+		c.SecurityProfile.WorkloadIdentity != nil && c.SecurityProfile.WorkloadIdentity.Enabled
+}
+
+// NAPEnabled reports whether Node Auto Provisioning (managed Karpenter) is on.
+func (c *Cluster) NAPEnabled() bool {
+	return c.NodeProvisioningProfile != nil && strings.EqualFold(c.NodeProvisioningProfile.Mode, "Auto")
+}
+
+// SubnetID returns the VNet subnet of the first node pool, or "" for clusters
+// using the AKS-managed VNet.
+func (c *Cluster) SubnetID() string {
+	for _, p := range c.AgentPoolProfiles {
+		if p.VnetSubnetID != "" {
+			return p.VnetSubnetID
+		}
+	}
+	return ""
+}
+
+// SSHPublicKey returns the cluster's admin SSH key, which self-hosted
+// Karpenter puts on the nodes it launches.
+func (c *Cluster) SSHPublicKey() string {
+	if c.LinuxProfile != nil && len(c.LinuxProfile.SSH.PublicKeys) > 0 {
+		return strings.TrimSpace(c.LinuxProfile.SSH.PublicKeys[0].KeyData)
+	}
+	return ""
+}
+
+// KubeletClientID returns the client ID of the kubelet managed identity.
+func (c *Cluster) KubeletClientID() string {
+	return c.IdentityProfile["kubeletidentity"].ClientID
+}
+
+// Available reports whether the az binary is on PATH.
+func Available() bool {
+	_, err := exec.LookPath("az")
+	return err == nil
+}
+
+// FindCluster looks up an AKS cluster by name. When resourceGroup is empty,
+// every resource group in the active subscription is searched.
+func FindCluster(name, resourceGroup string) (*Cluster, error) {
+	if !Available() {
+		return nil, fmt.Errorf("az CLI not found — install it from https://learn.microsoft.com/cli/azure/install-azure-cli")
+	}
+	if resourceGroup != "" {
+		var c Cluster
+		if err := azJSON(&c, "aks", "show", "--name", name, "--resource-group", resourceGroup); err != nil {
+			return nil, err
+		}
+		return &c, nil
+	}
+
+	var list []Cluster
+	if err := azJSON(&list, "aks", "list"); err != nil {
+		return nil, err
+	}
+	var matches []Cluster
+	for _, c := range list {
+		if c.Name == name {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("AKS cluster %q not found in the active subscription (check `az account show`)", name)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("%d AKS clusters are named %q — pass --resource-group", len(matches), name)
+}
+
+// Subscriptions returns the IDs of every enabled subscription the logged-in
+// account can see.
+func Subscriptions() ([]string, error) {
+	var subs []struct {
+		ID    string `json:"id"`
+		State string `json:"state"`
+	}
+	if err := azJSON(&subs, "account", "list"); err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, s := range subs {
+		if s.State == "" || s.State == "Enabled" {
+			out = append(out, s.ID)
+		}
+	}
+	return out, nil
+}
+
+// ListClusters returns every AKS cluster in subscription.
+func ListClusters(subscription string) ([]Cluster, error) {
+	var list []Cluster
+	if err := azJSON(&list, "aks", "list", "--subscription", subscription); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetCredentials merges the cluster into the local kubeconfig
+// (az aks get-credentials). The context is named after the cluster.
+func GetCredentials(c *Cluster) error {
+	return azRun("aks", "get-credentials",
+		"--name", c.Name,
+		"--resource-group", c.ResourceGroup,
+		"--subscription", c.SubscriptionID())
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CLI helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// azJSON runs the Azure CLI with --output json and decodes stdout into v.
+func azJSON(v any, args ...string) error {
+	args = append(args, "--output", "json")
+	out, err := exec.Command("az", args...).Output()
+	if err != nil {
+		return fmt.Errorf("az %s: %w", strings.Join(args[:min(2, len(args))], " "), stderrOf(err))
+	}
+	if err := json.Unmarshal(out, v); err != nil {
+		return fmt.Errorf("parse az %s output: %w", strings.Join(args[:min(2, len(args))], " "), err)
+	}
+	return nil
+}
+
+// azRun executes the Azure CLI and returns combined output on failure.
+func azRun(args ...string) error {
+	out, err := exec.Command("az", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("az %s: %w: %s", strings.Join(args[:min(2, len(args))], " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func stderrOf(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,229 @@
+package azure
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Defaults used by the upstream karpenter-provider-azure setup scripts.
+const (
+	DefaultIdentityName   = "karpentermsi"
+	DefaultServiceAccount = "karpenter-sa"
+	federatedCredential   = "KARPENTER_FID"
+)
+
+// controllerRoles are assigned to the controller identity on the node
+// resource group — the minimum needed to create VMs, NICs, and to attach the
+// kubelet identity to new nodes.
+var controllerRoles = []string{
+	"Virtual Machine Contributor",
+	"Network Contributor",
+	"Managed Identity Operator",
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Node Auto Provisioning (managed Karpenter)
+// ─────────────────────────────────────────────────────────────────────────────
+
+// NAPBlockers returns the reasons Node Auto Provisioning cannot be enabled on
+// the cluster in place. An empty result means `EnableNAP` should succeed.
+func NAPBlockers(c *Cluster) []string {
+	var out []string
+	np := c.NetworkProfile
+	if !strings.EqualFold(np.NetworkPlugin, "azure") || !strings.EqualFold(np.NetworkPluginMode, "overlay") {
+		out = append(out, fmt.Sprintf("network plugin is %s%s — NAP requires Azure CNI Overlay",
+			orNone(np.NetworkPlugin), modeSuffix(np.NetworkPluginMode)))
+	}
+	if !strings.EqualFold(np.NetworkDataplane, "cilium") {
+		out = append(out, fmt.Sprintf("network dataplane is %s — NAP requires Cilium", orNone(np.NetworkDataplane)))
+	}
+	if c.ServicePrincipalProfile != nil && c.ServicePrincipalProfile.ClientID != "" &&
+		!strings.EqualFold(c.ServicePrincipalProfile.ClientID, "msi") {
+		out = append(out, "cluster uses a service principal — NAP requires a managed identity")
+	}
+	for _, p := range c.AgentPoolProfiles {
+		if p.EnableAutoScaling {
+			out = append(out, fmt.Sprintf("node pool %q has the cluster autoscaler enabled — disable it first", p.Name))
+		}
+		if strings.EqualFold(p.OSType, "Windows") {
+			out = append(out, fmt.Sprintf("node pool %q is Windows — NAP supports Linux only", p.Name))
+		}
+	}
+	return out
+}
+
+// EnableNAP switches the cluster to node provisioning mode Auto. AKS then
+// runs and upgrades Karpenter itself; no chart is installed in the cluster.
+func EnableNAP(c *Cluster) error {
+	return azRun("aks", "update",
+		"--name", c.Name,
+		"--resource-group", c.ResourceGroup,
+		"--node-provisioning-mode", "Auto")
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Self-hosted provider
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Identity is a user-assigned managed identity.
+type Identity struct {
+	ID          string `json:"id"`
+	ClientID    string `json:"clientId"`
+	PrincipalID string `json:"principalId"`
+}
+
+// EnableWorkloadIdentity turns on the OIDC issuer and workload identity and
+// returns the refreshed cluster. It is a no-op when both are already enabled.
+func EnableWorkloadIdentity(c *Cluster) (*Cluster, error) {
+	if c.WorkloadIdentityEnabled() {
+		return c, nil
+	}
+	var updated Cluster
+	if err := azJSON(&updated, "aks", "update",
+		"--name", c.Name,
+		"--resource-group", c.ResourceGroup,
+		"--enable-oidc-issuer",
+		"--enable-workload-identity"); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// EnsureIdentity creates (or returns the existing) user-assigned identity for
+// the controller in the cluster's resource group.
+func EnsureIdentity(c *Cluster, name string) (*Identity, error) {
+	var id Identity
+	if err := azJSON(&id, "identity", "create",
+		"--name", name,
+		"--resource-group", c.ResourceGroup,
+		"--location", c.Location); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// AssignRoles grants the controller identity the roles it needs on the node
+// resource group. Existing assignments are left alone.
+func AssignRoles(c *Cluster, id *Identity) error {
+	for _, role := range controllerRoles {
+		// --assignee-object-id skips the Graph lookup, which may not see a
+		// freshly created identity yet.
+		err := azRun("role", "assignment", "create",
+			"--assignee-object-id", id.PrincipalID,
+			"--assignee-principal-type", "ServicePrincipal",
+			"--role", role,
+			"--scope", c.NodeResourceGroupID())
+		if err != nil && !strings.Contains(err.Error(), "RoleAssignmentExists") {
+			return fmt.Errorf("assign %q: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// EnsureFederatedCredential trusts the cluster's OIDC issuer to exchange
+// tokens of namespace/serviceAccount for the identity.
+func EnsureFederatedCredential(c *Cluster, identityName, namespace, serviceAccount string) error {
+	return azRun("identity", "federated-credential", "create",
+		"--name", federatedCredential,
+		"--identity-name", identityName,
+		"--resource-group", c.ResourceGroup,
+		"--issuer", c.OIDCIssuerProfile.IssuerURL,
+		"--subject", "system:serviceaccount:"+namespace+":"+serviceAccount,
+		"--audience", "api://AzureADTokenExchange")
+}
+
+// BootstrapToken returns a "<id>.<secret>" kubelet bootstrap token from
+// kube-system, which new nodes use to join the cluster.
+func BootstrapToken(kubeCtx string) (string, error) {
+	args := []string{"get", "secrets", "-n", "kube-system",
+		"--field-selector", "type=bootstrap.kubernetes.io/token", "-o", "json"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("list bootstrap tokens: %w", stderrOf(err))
+	}
+	var list struct {
+		Items []struct {
+			Data map[string]string `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return "", fmt.Errorf("parse bootstrap tokens: %w", err)
+	}
+	for _, s := range list.Items {
+		id, err1 := base64.StdEncoding.DecodeString(s.Data["token-id"])
+		secret, err2 := base64.StdEncoding.DecodeString(s.Data["token-secret"])
+		if err1 == nil && err2 == nil && len(id) > 0 && len(secret) > 0 {
+			return string(id) + "." + string(secret), nil
+		}
+	}
+	return "", fmt.Errorf("no bootstrap token found in kube-system")
+}
+
+// ValuesParams holds everything needed to render the provider chart values.
+type ValuesParams struct {
+	Cluster        *Cluster
+	Identity       *Identity
+	ServiceAccount string
+	BootstrapToken string
+	SSHPublicKey   string
+}
+
+// Values renders Helm values for the self-hosted provider chart, mirroring
+// the upstream karpenter-values-template.yaml.
+func Values(p ValuesParams) ([]byte, error) {
+	c := p.Cluster
+	np := c.NetworkProfile
+	env := []map[string]string{
+		{"name": "ARM_SUBSCRIPTION_ID", "value": c.SubscriptionID()},
+		{"name": "LOCATION", "value": c.Location},
+		{"name": "AZURE_NODE_RESOURCE_GROUP", "value": c.NodeResourceGroup},
+		{"name": "KUBELET_IDENTITY_CLIENT_ID", "value": c.KubeletClientID()},
+		{"name": "KUBELET_BOOTSTRAP_TOKEN", "value": p.BootstrapToken},
+		{"name": "SSH_PUBLIC_KEY", "value": p.SSHPublicKey},
+		{"name": "NETWORK_PLUGIN", "value": np.NetworkPlugin},
+		{"name": "NETWORK_PLUGIN_MODE", "value": np.NetworkPluginMode},
+		{"name": "NETWORK_POLICY", "value": np.NetworkPolicy},
+		{"name": "VNET_SUBNET_ID", "value": c.SubnetID()},
+	}
+	values := map[string]any{
+		"settings": map[string]any{
+			"clusterName":     c.Name,
+			"clusterEndpoint": "https://" + c.FQDN,
+		},
+		"serviceAccount": map[string]any{
+			"name": p.ServiceAccount,
+			"annotations": map[string]string{
+				"azure.workload.identity/client-id": p.Identity.ClientID,
+			},
+		},
+		"podLabels": map[string]string{
+			"azure.workload.identity/use": "true",
+		},
+		"controller": map[string]any{
+			"env": env,
+		},
+	}
+	return yaml.Marshal(values)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func modeSuffix(mode string) string {
+	if mode == "" {
+		return ""
+	}
+	return " (" + mode + ")"
+}
@@ -0,0 +1,158 @@
+// Package backend is the seam between the dashboards and the clusters they
+// show. The TUI, the web dashboard and package status read clusters through
+// the Clients set here rather than calling kubectl, helm and GitHub
+// directly, so they can run against something other than real clusters:
+// package demo supplies fake ones for --demo, and tests can supply their own.
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/kemilad/karpx/internal/compat"
+	"github.com/kemilad/karpx/internal/helm"
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// Kube reads kubeconfig contexts and the clusters behind them.
+type Kube interface {
+	// Contexts returns every kubeconfig context name.
+	Contexts() []string
+	// ContextName resolves "" to the current context's name.
+	ContextName(kubeCtx string) string
+	DetectProvider(kubeCtx string) kube.Provider
+	ServerVersion(kubeCtx string) (string, error)
+	CoreAddons(kubeCtx string) (*kube.CoreAddons, error)
+	// Kubectl runs kubectl against kubeCtx and returns stdout. A command
+	// that ran and failed returns an *exec.ExitError carrying stderr.
+	Kubectl(ctx context.Context, kubeCtx string, args ...string) ([]byte, error)
+}
+
+// Release is one Helm release, as `helm list --output json` reports it.
+type Release struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Status     string `json:"status"`
+	Chart      string `json:"chart"` // e.g. "kube-prometheus-stack-65.1.1"
+	AppVersion string `json:"app_version"`
+}
+
+// Helm reads Helm releases.
+type Helm interface {
+	DetectKarpenter(kubeCtx string) (*helm.Info, error)
+	Releases(kubeCtx string) ([]Release, error)
+}
+
+// Compat finds the release list and compatibility matrix of a provider.
+type Compat interface {
+	Source(provider kube.Provider) (compat.Source, bool)
+}
+
+// Clients are the implementations in use.
+type Clients struct {
+	Kube   Kube
+	Helm   Helm
+	Compat Compat
+	// Demo marks fake clusters: views must not offer to change anything.
+	Demo bool
+}
+
+var (
+	mu      sync.RWMutex
+	current = Live()
+)
+
+// Use replaces the clients. Call it before any view starts.
+func Use(c Clients) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = c
+}
+
+// Current returns the clients in use.
+func Current() Clients {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Live returns the clients that talk to real clusters.
+func Live() Clients {
+	return Clients{Kube: liveKube{}, Helm: liveHelm{}, Compat: liveCompat{}}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Live implementation
+// ─────────────────────────────────────────────────────────────────────────────
+
+type liveKube struct{}
+
+func (liveKube) Contexts() []string {
+	out, err := exec.Command("kubectl", "config", "get-contexts",
+		"-o", "name").Output()
+	if err != nil {
+		return nil
+	}
+	var ctxs []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			ctxs = append(ctxs, line)
+		}
+	}
+	return ctxs
+}
+
+func (liveKube) ContextName(kubeCtx string) string { return kube.ContextName(kubeCtx) }
+
+func (liveKube) DetectProvider(kubeCtx string) kube.Provider { return kube.DetectProvider(kubeCtx) }
+
+func (liveKube) ServerVersion(kubeCtx string) (string, error) { return kube.GetServerVersion(kubeCtx) }
+
+func (liveKube) CoreAddons(kubeCtx string) (*kube.CoreAddons, error) {
+	return kube.DetectCoreAddons(kubeCtx)
+}
+
+func (liveKube) Kubectl(ctx context.Context, kubeCtx string, args ...string) ([]byte, error) {
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	return exec.CommandContext(ctx, "kubectl", args...).Output()
+}
+
+type liveHelm struct{}
+
+func (liveHelm) DetectKarpenter(kubeCtx string) (*helm.Info, error) {
+	return helm.DetectKarpenter(kubeCtx)
+}
+
+func (liveHelm) Releases(kubeCtx string) ([]Release, error) {
+	args := []string{"list", "--all-namespaces", "--output", "json"}
+	if kubeCtx != "" {
+		args = append(args, "--kube-context", kubeCtx)
+	}
+	out, err := exec.Command("helm", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm list failed: %w", err)
+	}
+	var releases []Release
+	if err := json.Unmarshal(out, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse helm output")
+	}
+	return releases, nil
+}
+
+type liveCompat struct{}
+
+// Source is GitHub and the embedded matrix for AWS, and whatever a plugin
+// registered for its provider.
+func (liveCompat) Source(provider kube.Provider) (compat.Source, bool) {
+	if provider == kube.ProviderAWS {
+		return compat.Upstream, true
+	}
+	return compat.Lookup(string(provider))
+}
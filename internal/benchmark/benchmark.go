@@ -0,0 +1,325 @@
+// Package benchmark measures how quickly Karpenter turns pending pods into
+// running ones. A burst of pause pods pinned to one NodePool is created in a
+// throwaway namespace and timed from creation through scheduling, node
+// readiness and container start, so Karpenter versions and NodePool settings
+// can be compared on the same cluster.
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// DefaultNamespace holds the benchmark pods; it is deleted afterwards.
+const DefaultNamespace = "karpx-benchmark"
+
+// pauseImage is tiny and cached on most nodes, so image pulls barely skew
+// the timings.
+const pauseImage = "registry.k8s.io/pause:3.9"
+
+// pollInterval is how often pod and node status is read.
+const pollInterval = 2 * time.Second
+
+// Params holds all inputs for Run.
+type Params struct {
+	KubeCtx   string
+	NodePool  string // pods select it with karpenter.sh/nodepool
+	Pods      int
+	CPU       string // per-pod request, e.g. "1"
+	Memory    string // per-pod request, e.g. "1Gi"
+	Namespace string // default DefaultNamespace
+	Timeout   time.Duration
+}
+
+// PodTiming is how long one pod took from creation to each milestone. A zero
+// duration means the milestone was not reached before the timeout.
+type PodTiming struct {
+	Name         string        `json:"name"`
+	Node         string        `json:"node,omitempty"`
+	InstanceType string        `json:"instance_type,omitempty"`
+	NewNode      bool          `json:"new_node"` // the node was launched during the run
+	Scheduled    time.Duration `json:"scheduled"`
+	NodeReady    time.Duration `json:"node_ready"` // new nodes only
+	Running      time.Duration `json:"running"`
+}
+
+// Percentiles summarise one milestone across the pods that reached it.
+type Percentiles struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	Max   time.Duration `json:"max"`
+}
+
+// Result is the outcome of a benchmark run.
+type Result struct {
+	NodePool      string         `json:"nodepool"`
+	Started       time.Time      `json:"started"`
+	Pods          []PodTiming    `json:"pods"`
+	NewNodes      int            `json:"new_nodes"`
+	InstanceTypes map[string]int `json:"instance_types"` // instance type → new nodes
+	CapacityTypes map[string]int `json:"capacity_types"` // spot / on-demand → new nodes
+	TimedOut      int            `json:"timed_out"`      // pods not running at the timeout
+	Scheduled     Percentiles    `json:"scheduled"`
+	NodeReady     Percentiles    `json:"node_ready"`
+	Running       Percentiles    `json:"running"`
+}
+
+// Run creates p.Pods pending pods on p.NodePool and waits until all of them
+// run or p.Timeout passes. progress is called after every poll. The
+// namespace is left in place; call Cleanup afterwards.
+func Run(p Params, progress func(running, total int)) (*Result, error) {
+	if p.Namespace == "" {
+		p.Namespace = DefaultNamespace
+	}
+	if p.Pods < 1 {
+		return nil, fmt.Errorf("--pods must be at least 1")
+	}
+	cpu, err := resource.ParseQuantity(p.CPU)
+	if err != nil {
+		return nil, fmt.Errorf("--cpu: %w", err)
+	}
+	mem, err := resource.ParseQuantity(p.Memory)
+	if err != nil {
+		return nil, fmt.Errorf("--memory: %w", err)
+	}
+	if err := nodePoolExists(p.KubeCtx, p.NodePool); err != nil {
+		return nil, err
+	}
+	cs, err := clientset(p.KubeCtx)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.TODO()
+
+	// Nodes that exist before the burst are not counted as launched by it.
+	before := map[string]bool{}
+	nodes, err := cs.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list nodes: %w", err)
+	}
+	for _, n := range nodes.Items {
+		before[n.Name] = true
+	}
+
+	if _, err := cs.CoreV1().Namespaces().Get(ctx, p.Namespace, metav1.GetOptions{}); err == nil {
+		return nil, fmt.Errorf("namespace %s already exists — a previous run was not cleaned up (karpx benchmark --cleanup)", p.Namespace)
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("check namespace %q: %w", p.Namespace, err)
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   p.Namespace,
+		Labels: map[string]string{"app.kubernetes.io/managed-by": "karpx"},
+	}}
+	if _, err := cs.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("create namespace %q: %w", p.Namespace, err)
+	}
+
+	res := &Result{NodePool: p.NodePool, Started: time.Now(), InstanceTypes: map[string]int{}, CapacityTypes: map[string]int{}}
+	if _, err := cs.AppsV1().Deployments(p.Namespace).Create(ctx, deployment(p, cpu, mem), metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("create benchmark pods: %w", err)
+	}
+
+	deadline := res.Started.Add(p.Timeout)
+	var pods []corev1.Pod
+	nodeInfo := map[string]*corev1.Node{}
+	for {
+		time.Sleep(pollInterval)
+		list, err := cs.CoreV1().Pods(p.Namespace).List(ctx, metav1.ListOptions{LabelSelector: "app=karpx-benchmark"})
+		if err != nil {
+			return nil, fmt.Errorf("list pods: %w", err)
+		}
+		pods = list.Items
+		running := 0
+		fetched := map[string]bool{}
+		for i := range pods {
+			pod := &pods[i]
+			if pod.Status.Phase == corev1.PodRunning {
+				running++
+			}
+			// A node is re-read until it is Ready; its Ready time is final then.
+			n := pod.Spec.NodeName
+			if n == "" || fetched[n] || (nodeInfo[n] != nil && nodeReady(nodeInfo[n])) {
+				continue
+			}
+			fetched[n] = true
+			if node, err := cs.CoreV1().Nodes().Get(ctx, n, metav1.GetOptions{}); err == nil {
+				nodeInfo[n] = node
+			}
+		}
+		if progress != nil {
+			progress(running, p.Pods)
+		}
+		if running >= p.Pods || time.Now().After(deadline) {
+			break
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := range pods {
+		t := timing(&pods[i], nodeInfo, before)
+		res.Pods = append(res.Pods, t)
+		if t.Running == 0 {
+			res.TimedOut++
+		}
+		if t.NewNode && !seen[t.Node] {
+			seen[t.Node] = true
+			res.NewNodes++
+			res.InstanceTypes[t.InstanceType]++
+			res.CapacityTypes[nodeInfo[t.Node].Labels["karpenter.sh/capacity-type"]]++
+		}
+	}
+	if missing := p.Pods - len(pods); missing > 0 {
+		res.TimedOut += missing
+	}
+	sort.Slice(res.Pods, func(i, j int) bool { return res.Pods[i].Name < res.Pods[j].Name })
+	res.Scheduled = summarise(res.Pods, func(t PodTiming) time.Duration { return t.Scheduled })
+	res.NodeReady = summarise(res.Pods, func(t PodTiming) time.Duration { return t.NodeReady })
+	res.Running = summarise(res.Pods, func(t PodTiming) time.Duration { return t.Running })
+	return res, nil
+}
+
+// Cleanup deletes the benchmark namespace and its pods. Karpenter then
+// consolidates the nodes the run launched.
+func Cleanup(kubeCtx, namespace string) error {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	cs, err := clientset(kubeCtx)
+	if err != nil {
+		return err
+	}
+	err = cs.CoreV1().Namespaces().Delete(context.TODO(), namespace, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("delete namespace %q: %w", namespace, err)
+	}
+	return nil
+}
+
+// deployment is the benchmark workload: p.Pods pause containers pinned to
+// the NodePool. Every taint is tolerated so tainted pools can be measured.
+func deployment(p Params, cpu, mem resource.Quantity) *appsv1.Deployment {
+	replicas := int32(p.Pods)
+	labels := map[string]string{"app": "karpx-benchmark"}
+	grace := int64(0)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "karpx-benchmark", Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					NodeSelector:                  map[string]string{"karpenter.sh/nodepool": p.NodePool},
+					Tolerations:                   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					TerminationGracePeriodSeconds: &grace,
+					Containers: []corev1.Container{{
+						Name:  "pause",
+						Image: pauseImage,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: cpu, corev1.ResourceMemory: mem},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// timing measures pod from its creation to each milestone.
+func timing(pod *corev1.Pod, nodes map[string]*corev1.Node, before map[string]bool) PodTiming {
+	created := pod.CreationTimestamp.Time
+	t := PodTiming{Name: pod.Name, Node: pod.Spec.NodeName}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionTrue {
+			t.Scheduled = c.LastTransitionTime.Sub(created)
+		}
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Running != nil {
+			t.Running = cs.State.Running.StartedAt.Sub(created)
+		}
+	}
+	if node := nodes[t.Node]; node != nil {
+		t.InstanceType = node.Labels["node.kubernetes.io/instance-type"]
+		t.NewNode = !before[node.Name]
+		if t.NewNode {
+			for _, c := range node.Status.Conditions {
+				if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+					t.NodeReady = c.LastTransitionTime.Sub(created)
+				}
+			}
+		}
+	}
+	return t
+}
+
+func nodeReady(n *corev1.Node) bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// summarise returns nearest-rank percentiles of the non-zero values of get.
+func summarise(pods []PodTiming, get func(PodTiming) time.Duration) Percentiles {
+	var ds []time.Duration
+	for _, t := range pods {
+		if d := get(t); d > 0 {
+			ds = append(ds, d)
+		}
+	}
+	if len(ds) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	rank := func(p float64) time.Duration {
+		i := int(p*float64(len(ds))+0.999999) - 1
+		if i < 0 {
+			i = 0
+		}
+		return ds[i]
+	}
+	return Percentiles{Count: len(ds), P50: rank(0.50), P95: rank(0.95), Max: ds[len(ds)-1]}
+}
+
+// nodePoolExists fails early when the NodePool is missing, which would
+// otherwise leave every pod pending until the timeout.
+func nodePoolExists(kubeCtx, name string) error {
+	args := []string{"get", "nodepools.karpenter.sh", name, "-o", "name"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	if out, err := exec.Command("kubectl", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("NodePool %s: %s", name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func clientset(kubeCtx string) (*kubernetes.Clientset, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	return kubernetes.NewForConfig(restCfg)
+}
@@ -0,0 +1,340 @@
+// Package cleanup finds cloud resources Karpenter created for a cluster that no
+// longer belong to any Node or NodeClaim — typically left behind by a botched
+// uninstall, a deleted cluster, or a controller that was killed mid-launch.
+//
+// Only AWS is supported today. Three resource kinds are checked:
+//
+//	EC2 instances      tagged kubernetes.io/cluster/<name>=owned and
+//	                   karpenter.sh/nodepool (or the legacy provisioner-name)
+//	launch templates   tagged karpenter.k8s.aws/cluster=<name>
+//	ENIs               tagged cluster.k8s.amazonaws.com/name=<name> and
+//	                   left in the "available" (detached) state
+//
+// "In use" is read from the kube context, so Find first checks that the
+// context is the named cluster: otherwise every live instance of the named
+// cluster would look orphaned.
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kemilad/karpx/internal/awscli"
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// Kind identifies the type of an orphaned resource.
+type Kind string
+
+const (
+	KindInstance       Kind = "ec2-instance"
+	KindLaunchTemplate Kind = "launch-template"
+	KindENI            Kind = "network-interface"
+)
+
+// Resource is a single orphaned cloud resource.
+type Resource struct {
+	Kind    Kind
+	ID      string
+	Name    string // instance type, launch template name, or ENI description
+	Detail  string // extra context shown in the listing (state, node pool, …)
+	Created time.Time
+}
+
+// Params holds all inputs for Find.
+type Params struct {
+	KubeCtx     string
+	ClusterName string
+	Region      string
+	// MinAge skips resources younger than this so instances that are still
+	// joining the cluster are never reported as orphaned.
+	MinAge time.Duration
+}
+
+// Find lists every orphaned resource for the cluster, ordered instances →
+// launch templates → ENIs (the order they must be deleted in).
+func Find(p Params) ([]Resource, error) {
+	if p.ClusterName == "" {
+		return nil, fmt.Errorf("cluster name is required")
+	}
+	if !awscli.Available() {
+		return nil, fmt.Errorf("aws CLI not found — install it from https://aws.amazon.com/cli/")
+	}
+
+	known, err := clusterInstanceIDs(p.KubeCtx)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-p.MinAge)
+
+	var out []Resource
+
+	// ── EC2 instances ─────────────────────────────────────────────────────
+	instances, err := karpenterInstances(p.Region, p.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	if err := sameCluster(p, known, instances); err != nil {
+		return nil, err
+	}
+	ltInUse := map[string]bool{}
+	for _, in := range instances {
+		if known[in.ID] || in.Launch.After(cutoff) {
+			if lt := in.tag("aws:ec2launchtemplate:id"); lt != "" {
+				ltInUse[lt] = true
+			}
+			continue
+		}
+		pool := in.tag("karpenter.sh/nodepool")
+		if pool == "" {
+			pool = in.tag("karpenter.sh/provisioner-name")
+		}
+		out = append(out, Resource{
+			Kind:    KindInstance,
+			ID:      in.ID,
+			Name:    in.Type,
+			Detail:  fmt.Sprintf("%s, nodepool=%s", in.State.Name, dashIfEmpty(pool)),
+			Created: in.Launch,
+		})
+	}
+
+	// ── Launch templates ──────────────────────────────────────────────────
+	var lts struct {
+		LaunchTemplates []struct {
+			ID         string    `json:"LaunchTemplateId"`
+			Name       string    `json:"LaunchTemplateName"`
+			CreateTime time.Time `json:"CreateTime"`
+		} `json:"LaunchTemplates"`
+	}
+	if err := awscli.JSON(&lts, p.Region,
+		"ec2", "describe-launch-templates",
+		"--filters", "Name=tag:karpenter.k8s.aws/cluster,Values="+p.ClusterName,
+	); err != nil {
+		return nil, err
+	}
+	for _, lt := range lts.LaunchTemplates {
+		if ltInUse[lt.ID] || lt.CreateTime.After(cutoff) {
+			continue
+		}
+		out = append(out, Resource{
+			Kind:    KindLaunchTemplate,
+			ID:      lt.ID,
+			Name:    lt.Name,
+			Detail:  "not used by any live node",
+			Created: lt.CreateTime,
+		})
+	}
+
+	// ── Detached ENIs ─────────────────────────────────────────────────────
+	var enis struct {
+		NetworkInterfaces []struct {
+			ID          string `json:"NetworkInterfaceId"`
+			Description string `json:"Description"`
+			TagSet      []tag  `json:"TagSet"`
+		} `json:"NetworkInterfaces"`
+	}
+	if err := awscli.JSON(&enis, p.Region,
+		"ec2", "describe-network-interfaces",
+		"--filters",
+		"Name=tag:cluster.k8s.amazonaws.com/name,Values="+p.ClusterName,
+		"Name=status,Values=available",
+	); err != nil {
+		return nil, err
+	}
+	for _, eni := range enis.NetworkInterfaces {
+		owner := tagValue(eni.TagSet, "node.k8s.amazonaws.com/instance_id")
+		if owner != "" && known[owner] {
+			continue // warm-pool ENI of a live node — the VPC CNI will attach it
+		}
+		// The VPC CNI tags the ENIs it creates with their creation time;
+		// older CNI versions do not, and those ENIs are listed regardless.
+		created, _ := time.Parse(time.RFC3339, tagValue(eni.TagSet, "node.k8s.amazonaws.com/createdAt"))
+		if created.After(cutoff) {
+			continue
+		}
+		out = append(out, Resource{
+			Kind:    KindENI,
+			ID:      eni.ID,
+			Name:    eni.Description,
+			Detail:  "detached, last owner " + dashIfEmpty(owner),
+			Created: created,
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return kindOrder(out[i].Kind) < kindOrder(out[j].Kind) })
+	return out, nil
+}
+
+// sameCluster checks that p.KubeCtx is the EKS cluster p.ClusterName: its
+// API server is the cluster's endpoint or the context is named after the
+// cluster ARN. When the cluster cannot be described, at least one of the
+// context's nodes must be among the cluster's instances.
+func sameCluster(p Params, known map[string]bool, instances []instance) error {
+	var desc struct {
+		Cluster struct {
+			Arn      string `json:"arn"`
+			Endpoint string `json:"endpoint"`
+		} `json:"cluster"`
+	}
+	if err := awscli.JSON(&desc, p.Region, "eks", "describe-cluster", "--name", p.ClusterName); err == nil {
+		server := kube.ServerURL(p.KubeCtx)
+		if (server != "" && kube.ServerHost(server) == kube.ServerHost(desc.Cluster.Endpoint)) ||
+			kube.ContextName(p.KubeCtx) == desc.Cluster.Arn {
+			return nil
+		}
+		return fmt.Errorf("context %s (%s) is not EKS cluster %s (%s) — refusing to treat that cluster's instances as orphaned",
+			kube.ContextName(p.KubeCtx), dashIfEmpty(server), p.ClusterName, desc.Cluster.Endpoint)
+	}
+	for _, in := range instances {
+		if known[in.ID] {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot confirm that context %s is EKS cluster %s: eks:DescribeCluster failed and none of its nodes are instances of that cluster",
+		kube.ContextName(p.KubeCtx), p.ClusterName)
+}
+
+// Delete removes a single orphaned resource.
+func Delete(region string, r Resource) error {
+	switch r.Kind {
+	case KindInstance:
+		return awscli.Run(region, "ec2", "terminate-instances", "--instance-ids", r.ID)
+	case KindLaunchTemplate:
+		return awscli.Run(region, "ec2", "delete-launch-template", "--launch-template-id", r.ID)
+	case KindENI:
+		return awscli.Run(region, "ec2", "delete-network-interface", "--network-interface-id", r.ID)
+	}
+	return fmt.Errorf("unknown resource kind %q", r.Kind)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+type tag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+func tagValue(tags []tag, key string) string {
+	for _, t := range tags {
+		if t.Key == key {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+type instance struct {
+	ID     string    `json:"InstanceId"`
+	Type   string    `json:"InstanceType"`
+	Launch time.Time `json:"LaunchTime"`
+	State  struct {
+		Name string `json:"Name"`
+	} `json:"State"`
+	Tags []tag `json:"Tags"`
+}
+
+func (i instance) tag(key string) string { return tagValue(i.Tags, key) }
+
+func karpenterInstances(region, clusterName string) ([]instance, error) {
+	var resp struct {
+		Reservations []struct {
+			Instances []instance `json:"Instances"`
+		} `json:"Reservations"`
+	}
+	if err := awscli.JSON(&resp, region,
+		"ec2", "describe-instances",
+		"--filters",
+		"Name=tag:kubernetes.io/cluster/"+clusterName+",Values=owned",
+		"Name=tag-key,Values=karpenter.sh/nodepool,karpenter.sh/provisioner-name",
+		"Name=instance-state-name,Values=pending,running,stopping,stopped",
+	); err != nil {
+		return nil, err
+	}
+	var out []instance
+	for _, r := range resp.Reservations {
+		out = append(out, r.Instances...)
+	}
+	return out, nil
+}
+
+// clusterInstanceIDs returns the EC2 instance IDs referenced by every Node and
+// NodeClaim in the cluster. A NodeClaim may exist before its Node registers,
+// so both are needed to avoid reporting an in-flight launch.
+func clusterInstanceIDs(kubeCtx string) (map[string]bool, error) {
+	ids := map[string]bool{}
+
+	nodes, err := kubectlJSONPath(kubeCtx, "nodes", "{range .items[*]}{.spec.providerID}{\"\\n\"}{end}")
+	if err != nil {
+		return nil, fmt.Errorf("list nodes: %w", err)
+	}
+	// NodeClaims only exist on Karpenter v1beta1+; a missing CRD is not an error.
+	claims, err := kubectlJSONPath(kubeCtx, "nodeclaims.karpenter.sh", "{range .items[*]}{.status.providerID}{\"\\n\"}{end}")
+	if err != nil && !crdMissing(err) {
+		return nil, fmt.Errorf("list NodeClaims: %w", err)
+	}
+
+	for _, line := range append(nodes, claims...) {
+		if id := instanceIDFromProviderID(line); id != "" {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+func kubectlJSONPath(kubeCtx, resource, path string) ([]string, error) {
+	args := []string{"get", resource, "-o", "jsonpath=" + path}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSpace(string(out)), "\n"), nil
+}
+
+// crdMissing reports whether a kubectl error says the resource type does not
+// exist in the cluster.
+func crdMissing(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	stderr := string(exitErr.Stderr)
+	return strings.Contains(stderr, "the server doesn't have a resource type") ||
+		strings.Contains(stderr, "no matches for kind")
+}
+
+// instanceIDFromProviderID extracts "i-0abc…" from "aws:///us-east-1a/i-0abc…".
+func instanceIDFromProviderID(providerID string) string {
+	providerID = strings.TrimSpace(providerID)
+	i := strings.LastIndex(providerID, "/")
+	id := providerID[i+1:]
+	if strings.HasPrefix(id, "i-") {
+		return id
+	}
+	return ""
+}
+
+func kindOrder(k Kind) int {
+	switch k {
+	case KindInstance:
+		return 0
+	case KindLaunchTemplate:
+		return 1
+	}
+	return 2
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
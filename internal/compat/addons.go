@@ -0,0 +1,158 @@
+package compat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// EKS core add-ons
+// Sources: https://docs.aws.amazon.com/eks/latest/userguide/managing-vpc-cni.html
+//          https://docs.aws.amazon.com/eks/latest/userguide/managing-coredns.html
+//          https://kubernetes.io/releases/version-skew-policy/#kube-proxy
+// Keep these tables in sync when new Kubernetes minors or instance families ship.
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Addons are the core add-on versions of a cluster (no leading "v"; "" when
+// the add-on was not found).
+type Addons struct {
+	VPCCNI           string
+	CoreDNS          string
+	KubeProxy        string
+	PrefixDelegation bool
+	IPv6             bool
+	InstanceFamilies []string // families of the running nodes
+}
+
+// AddonIssue is one known-bad combination of an add-on with Kubernetes,
+// Karpenter, the CNI mode or an instance family.
+type AddonIssue struct {
+	Addon    string `json:"addon"`
+	Version  string `json:"version"`
+	Required string `json:"required,omitempty"` // minimum version that fixes it
+	Blocking bool   `json:"blocking"`           // breaks nodes or pods, not just unsupported
+	Message  string `json:"message"`
+}
+
+// coreDNSMin is the oldest CoreDNS EKS supports on each Kubernetes minor.
+var coreDNSMin = map[string]string{
+	"1.26": "1.9.3",
+	"1.27": "1.10.1",
+	"1.28": "1.10.1",
+	"1.29": "1.11.1",
+	"1.30": "1.11.1",
+	"1.31": "1.11.3",
+	"1.32": "1.11.4",
+	"1.33": "1.12.1",
+}
+
+// VPC CNI releases that introduced the address modes karpx sizes for.
+const (
+	vpcCNIPrefixMin = "1.9.0"
+	vpcCNIIPv6Min   = "1.10.1"
+)
+
+// kubeProxySkew is how many minors kube-proxy may lag the control plane.
+const kubeProxySkew = 3
+
+// familyRule gives the first VPC CNI and Karpenter releases that know the ENI
+// limits of an instance family. Older releases fall back to a default that
+// over- or under-counts pod addresses on those nodes.
+type familyRule struct {
+	Families    []string
+	VPCCNI      string
+	Karpenter   string
+	Recommended bool // karpx nodes recommends these families
+}
+
+var familyRules = []familyRule{
+	{[]string{"c7g", "m7g", "r7g"}, "1.11.4", "", true},
+	{[]string{"c7i", "m7i", "r7i"}, "1.15.0", "", true},
+	{[]string{"c7i-flex", "m7i-flex"}, "1.16.0", "0.37.0", true},
+	{[]string{"c8g", "m8g", "r8g", "x8g"}, "1.18.3", "1.0.0", false},
+	{[]string{"c8i", "m8i", "r8i"}, "1.20.0", "1.6.0", false},
+}
+
+// CheckAddons returns the known-bad combinations of a's add-ons with the
+// Kubernetes version and the Karpenter version (installed or target; "" to
+// skip the Karpenter checks).
+func CheckAddons(a Addons, k8sVersion, karpVersion string) []AddonIssue {
+	var out []AddonIssue
+	k8s, err := semver.NewVersion(normalise(k8sVersion))
+	if err != nil {
+		return nil
+	}
+	minor := fmt.Sprintf("%d.%d", k8s.Major(), k8s.Minor())
+
+	// kube-proxy follows the control plane within the skew policy.
+	if kp, err := semver.NewVersion(normalise(a.KubeProxy)); err == nil {
+		lag := int(k8s.Minor()) - int(kp.Minor())
+		switch {
+		case kp.Major() != k8s.Major() || lag < 0:
+			out = append(out, AddonIssue{"kube-proxy", a.KubeProxy, minor, true,
+				fmt.Sprintf("newer than the control plane (%s) — kube-proxy must never lead it", minor)})
+		case lag > kubeProxySkew:
+			out = append(out, AddonIssue{"kube-proxy", a.KubeProxy, minor, true,
+				fmt.Sprintf("%d minors behind Kubernetes %s — outside the supported skew of %d", lag, minor, kubeProxySkew)})
+		case lag > 0:
+			out = append(out, AddonIssue{"kube-proxy", a.KubeProxy, minor, false,
+				fmt.Sprintf("behind Kubernetes %s — EKS expects kube-proxy on the control-plane minor", minor)})
+		}
+	}
+
+	if need := coreDNSMin[minor]; need != "" && older(a.CoreDNS, need) {
+		out = append(out, AddonIssue{"coredns", a.CoreDNS, need, false,
+			fmt.Sprintf("older than the minimum EKS supports on Kubernetes %s", minor)})
+	}
+
+	if a.PrefixDelegation && older(a.VPCCNI, vpcCNIPrefixMin) {
+		out = append(out, AddonIssue{"vpc-cni", a.VPCCNI, vpcCNIPrefixMin, true,
+			"ENABLE_PREFIX_DELEGATION is set but this release does not support prefix delegation"})
+	}
+	if a.IPv6 && older(a.VPCCNI, vpcCNIIPv6Min) {
+		out = append(out, AddonIssue{"vpc-cni", a.VPCCNI, vpcCNIIPv6Min, true,
+			"ENABLE_IPv6 is set but this release does not support IPv6 clusters"})
+	}
+
+	inUse := map[string]bool{}
+	for _, f := range a.InstanceFamilies {
+		inUse[f] = true
+	}
+	for _, r := range familyRules {
+		var used []string
+		for _, f := range r.Families {
+			if inUse[f] {
+				used = append(used, f)
+			}
+		}
+		if len(used) == 0 && !r.Recommended {
+			continue
+		}
+		where := "recommended by karpx nodes"
+		families := strings.Join(r.Families, "/")
+		if len(used) > 0 {
+			where, families = "in use", strings.Join(used, "/")
+		}
+		if older(a.VPCCNI, r.VPCCNI) {
+			out = append(out, AddonIssue{"vpc-cni", a.VPCCNI, r.VPCCNI, len(used) > 0,
+				fmt.Sprintf("does not know the ENI limits of %s (%s) — pods can fail to get an IP", families, where)})
+		}
+		if r.Karpenter != "" && older(karpVersion, r.Karpenter) {
+			out = append(out, AddonIssue{"karpenter", strings.TrimPrefix(karpVersion, "v"), r.Karpenter, false,
+				fmt.Sprintf("does not know the pod limits of %s (%s) — it may overpack those nodes", families, where)})
+		}
+	}
+	return out
+}
+
+// older reports whether version v is known and older than min. EKS build
+// suffixes ("v1.11.3-eksbuild.2") are not semver pre-releases and are ignored.
+func older(v, min string) bool {
+	if v == "" {
+		return false
+	}
+	v, _, _ = strings.Cut(v, "-")
+	return Newer(normalise(min), normalise(v))
+}
@@ -15,9 +15,12 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
+
+	"github.com/kemilad/karpx/internal/outbound"
 )
 
 const releasesURL = "https://api.github.com/repos/aws/karpenter-provider-aws/releases?per_page=50"
@@ -28,13 +31,18 @@ const releasesURL = "https://api.github.com/repos/aws/karpenter-provider-aws/rel
 // Keep this table in sync with upstream when new Karpenter minor lines ship.
 // ─────────────────────────────────────────────────────────────────────────────
 
-type k8sRange struct {
-	karpenterConstraint string // semver constraint on Karpenter version
-	k8sMin              string // minimum supported Kubernetes (inclusive)
-	k8sMax              string // maximum supported Kubernetes (inclusive, .99 patch wildcard)
+// Rule gives the Kubernetes range supported by a set of Karpenter versions.
+type Rule struct {
+	Karpenter string `json:"karpenter"` // semver constraint on Karpenter version
+	K8sMin    string `json:"k8sMin"`    // minimum supported Kubernetes (inclusive)
+	K8sMax    string `json:"k8sMax"`    // maximum supported Kubernetes (inclusive; "1.31" covers every patch)
 }
 
-var compatMatrix = []k8sRange{
+// Matrix is a compatibility table. The first rule whose constraint matches
+// a Karpenter version decides its Kubernetes range.
+type Matrix []Rule
+
+var compatMatrix = Matrix{
 	// Karpenter 1.4.x+ — k8s 1.29 – 1.33
 	{">= 1.4.0, < 2.0.0", "1.29.0", "1.33.99"},
 	// Karpenter 1.2.x – 1.3.x — k8s 1.29 – 1.32
@@ -49,6 +57,45 @@ var compatMatrix = []k8sRange{
 	{">= 0.33.0, < 0.35.0", "1.26.0", "1.28.99"},
 }
 
+// ─────────────────────────────────────────────────────────────────────────────
+// Provider sources
+// Providers added by plugins bring their own release list and matrix.
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Source returns a provider's stable releases (no leading "v") and its
+// matrix. The matrix is returned even when listing the releases fails.
+type Source func() (releases []string, m Matrix, err error)
+
+// Upstream is the source for the AWS provider: GitHub releases and the
+// embedded matrix.
+func Upstream() ([]string, Matrix, error) {
+	releases, err := FetchAvailableVersions()
+	return releases, compatMatrix, err
+}
+
+// Embedded returns the embedded AWS matrix.
+func Embedded() Matrix { return compatMatrix }
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = map[string]Source{}
+)
+
+// Register makes src the compatibility source for provider.
+func Register(provider string, src Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sources[provider] = src
+}
+
+// Lookup returns the source registered for provider.
+func Lookup(provider string) (Source, bool) {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	src, ok := sources[provider]
+	return src, ok
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // GitHub releases
 // ─────────────────────────────────────────────────────────────────────────────
@@ -62,7 +109,7 @@ type ghRelease struct {
 // FetchAvailableVersions fetches all stable Karpenter release tags from GitHub
 // and returns them as bare semver strings (no leading "v"), newest first.
 func FetchAvailableVersions() ([]string, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := outbound.Client(10 * time.Second)
 
 	req, err := http.NewRequest("GET", releasesURL, nil)
 	if err != nil {
@@ -115,13 +162,18 @@ func FetchAvailableVersions() ([]string, error) {
 // IsCompatible reports whether a Karpenter version is compatible with the
 // given Kubernetes version using the embedded compatibility matrix.
 func IsCompatible(karpVersion, k8sVersion string) bool {
+	return compatMatrix.IsCompatible(karpVersion, k8sVersion)
+}
+
+// IsCompatible reports whether m lists karpVersion as supporting k8sVersion.
+func (m Matrix) IsCompatible(karpVersion, k8sVersion string) bool {
 	kv, err1 := semver.NewVersion(strings.TrimPrefix(karpVersion, "v"))
 	k8sv, err2 := semver.NewVersion(normalise(k8sVersion))
 	if err1 != nil || err2 != nil {
 		return false
 	}
-	for _, rule := range compatMatrix {
-		c, err := semver.NewConstraint(rule.karpenterConstraint)
+	for _, rule := range m {
+		c, err := semver.NewConstraint(rule.Karpenter)
 		if err != nil {
 			continue
 		}
@@ -129,9 +181,7 @@ func IsCompatible(karpVersion, k8sVersion string) bool {
 			continue
 		}
 		// This rule covers the Karpenter version; verify Kubernetes is in range.
-		k8sMin, _ := semver.NewVersion(rule.k8sMin)
-		k8sMax, _ := semver.NewVersion(rule.k8sMax)
-		return k8sv.Compare(k8sMin) >= 0 && k8sv.Compare(k8sMax) <= 0
+		return rule.covers(k8sv)
 	}
 	return false // no rule matched — unknown karpenter version
 }
@@ -139,9 +189,14 @@ func IsCompatible(karpVersion, k8sVersion string) bool {
 // FilterCompatible returns the subset of `available` versions that are
 // compatible with k8sVersion, sorted descending (latest first).
 func FilterCompatible(k8sVersion string, available []string) []string {
+	return compatMatrix.FilterCompatible(k8sVersion, available)
+}
+
+// FilterCompatible is the package-level FilterCompatible against m.
+func (m Matrix) FilterCompatible(k8sVersion string, available []string) []string {
 	var out []string
 	for _, v := range available {
-		if IsCompatible(v, k8sVersion) {
+		if m.IsCompatible(v, k8sVersion) {
 			out = append(out, v)
 		}
 	}
@@ -169,6 +224,43 @@ func LatestCompatible(k8sVersion string) (latest string, all []string, err error
 	return compatible[0], compatible, nil
 }
 
+// InLine reports whether version belongs to the minor line ("1.2" or "v1.2").
+func InLine(version, line string) bool {
+	v, err1 := semver.NewVersion(strings.TrimPrefix(version, "v"))
+	l, err2 := semver.NewVersion(normalise(line))
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return v.Major() == l.Major() && v.Minor() == l.Minor()
+}
+
+// Newer reports whether version a is newer than b. Unparseable versions are
+// never newer.
+func Newer(a, b string) bool {
+	va, err1 := semver.NewVersion(strings.TrimPrefix(a, "v"))
+	vb, err2 := semver.NewVersion(strings.TrimPrefix(b, "v"))
+	return err1 == nil && err2 == nil && va.GreaterThan(vb)
+}
+
+// LatestInLine returns the newest of versions in the given minor line, or ""
+// when the line has no release among them.
+func LatestInLine(versions []string, line string) string {
+	var best *semver.Version
+	for _, s := range versions {
+		v, err := semver.NewVersion(s)
+		if err != nil || !InLine(s, line) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best = v
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Original()
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Utilities
 // ─────────────────────────────────────────────────────────────────────────────
@@ -178,18 +270,21 @@ func LatestCompatible(k8sVersion string) (latest string, all []string, err error
 // (no network requests required).
 // Returns "" if no rule in the matrix covers the given Kubernetes version.
 func MinCompatibleKarpenter(k8sVersion string) string {
+	return compatMatrix.MinKarpenter(k8sVersion)
+}
+
+// MinKarpenter is MinCompatibleKarpenter against m.
+func (m Matrix) MinKarpenter(k8sVersion string) string {
 	k8sv, err := semver.NewVersion(normalise(k8sVersion))
 	if err != nil {
 		return ""
 	}
 	var minVer *semver.Version
-	for _, rule := range compatMatrix {
-		k8sMin, _ := semver.NewVersion(rule.k8sMin)
-		k8sMax, _ := semver.NewVersion(rule.k8sMax)
-		if k8sv.Compare(k8sMin) < 0 || k8sv.Compare(k8sMax) > 0 {
+	for _, rule := range m {
+		if !rule.covers(k8sv) {
 			continue
 		}
-		lb := lowerBoundOf(rule.karpenterConstraint)
+		lb := lowerBoundOf(rule.Karpenter)
 		if lb == "" {
 			continue
 		}
@@ -207,6 +302,21 @@ func MinCompatibleKarpenter(k8sVersion string) string {
 	return minVer.Original()
 }
 
+// covers reports whether k8sv is within the rule's Kubernetes range. A
+// major.minor maximum includes every patch of that minor.
+func (r Rule) covers(k8sv *semver.Version) bool {
+	k8sMin, err1 := semver.NewVersion(normalise(r.K8sMin))
+	k8sMax, err2 := semver.NewVersion(normalise(r.K8sMax))
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	if strings.Count(strings.TrimPrefix(r.K8sMax, "v"), ".") < 2 {
+		return k8sv.Compare(k8sMin) >= 0 && (k8sv.Major() < k8sMax.Major() ||
+			k8sv.Major() == k8sMax.Major() && k8sv.Minor() <= k8sMax.Minor())
+	}
+	return k8sv.Compare(k8sMin) >= 0 && k8sv.Compare(k8sMax) <= 0
+}
+
 // lowerBoundOf parses a semver constraint like ">= 0.37.0, < 1.0.0" and
 // returns the lower bound value ("0.37.0").
 func lowerBoundOf(constraint string) string {
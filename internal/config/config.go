@@ -0,0 +1,300 @@
+// Package config loads user defaults for karpx from a YAML file, so settings
+// a team always passes (growth planning, version policy, …) do not have to be
+// repeated on every command line. Flags always override the file.
+//
+// The file is ~/.karpx/config.yaml, or the path in $KARPX_CONFIG:
+//
+//	sizing:
+//	  growthFactor: 1.3      # plan for 30% more demand than today
+//	  headroomPercent: 20    # keep 20% of every node free
+//	policy:
+//	  pins:                  # kubeconfig context (or glob) → Karpenter minor line
+//	    prod-*: "1.1"
+//	    staging-eu: "1.2"
+//	tags:                    # kubeconfig context (or glob) → labels
+//	  prod-*: {environment: prod}
+//	  prod-eu-1: {team: payments, region: eu-west-1}
+//	hooks:                   # kubeconfig context (or glob) → commands around operations
+//	  prod-*:
+//	    preUpgrade:
+//	      - velero backup create karpx-$KARPX_CONTEXT --wait
+//	    postUpgrade:
+//	      - run: ./smoke-test.sh
+//	        timeout: 15m
+//	network:                 # for corporate proxies; the environment wins
+//	  httpsProxy: http://proxy.corp:3128
+//	  noProxy: .internal,.eks.amazonaws.com
+//	  caBundle: /etc/pki/corp-root.pem
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the contents of the config file. Zero values mean "not set".
+type Config struct {
+	Sizing  Sizing  `json:"sizing"`
+	Policy  Policy  `json:"policy"`
+	Tags    Tags    `json:"tags"`
+	Hooks   Hooks   `json:"hooks"`
+	Network Network `json:"network"`
+}
+
+// Sizing holds defaults for the node recommendation engine.
+type Sizing struct {
+	GrowthFactor    float64 `json:"growthFactor"`
+	HeadroomPercent float64 `json:"headroomPercent"`
+}
+
+// Network holds proxy and CA settings for outbound connections. HTTPS_PROXY,
+// NO_PROXY and KARPX_CA_BUNDLE in the environment take precedence.
+type Network struct {
+	HTTPSProxy string `json:"httpsProxy"`
+	NoProxy    string `json:"noProxy"`
+	CABundle   string `json:"caBundle"` // PEM file of extra trusted CAs
+}
+
+// Policy declares which Karpenter minor line each cluster is meant to run, so
+// a cluster deliberately kept behind is not reported as needing an upgrade.
+type Policy struct {
+	Pins map[string]string `json:"pins"`
+}
+
+// Pin returns the minor line pinned for a kubeconfig context, or "" when no
+// pin applies. An exact context name wins over globs; among globs (see match)
+// the longest pattern wins so "prod-eu-*" can override "prod-*".
+func (p Policy) Pin(context string) string {
+	if line, ok := p.Pins[context]; ok {
+		return line
+	}
+	var best, line string
+	for pattern, l := range p.Pins {
+		if match(pattern, context) && len(pattern) > len(best) {
+			best, line = pattern, l
+		}
+	}
+	return line
+}
+
+// match reports whether a pin, tag or hook key matches a kubeconfig
+// context. Keys are globs in which "*" also matches "/", and a key matches
+// either the whole context or the cluster name after its last "/", so
+// "prod-*" and "*prod*" both match the EKS context
+// "arn:aws:eks:eu-west-1:123456789012:cluster/prod-eu".
+func match(pattern, context string) bool {
+	if glob(pattern, context) {
+		return true
+	}
+	if i := strings.LastIndex(context, "/"); i >= 0 {
+		return glob(pattern, context[i+1:])
+	}
+	return false
+}
+
+// glob is path.Match with "/" treated as an ordinary character.
+func glob(pattern, name string) bool {
+	ok, _ := path.Match(strings.ReplaceAll(pattern, "/", "\x00"), strings.ReplaceAll(name, "/", "\x00"))
+	return ok
+}
+
+// Tags labels clusters (team, environment, region, …) so multi-cluster
+// commands and views can be filtered with a Selector. Keys are context names
+// or globs.
+type Tags map[string]map[string]string
+
+// For returns the tags of a kubeconfig context. Every matching entry applies;
+// where two set the same key, the longer pattern wins and an exact context
+// name wins over any glob.
+func (t Tags) For(context string) map[string]string {
+	var patterns []string
+	for pattern := range t {
+		if pattern == context {
+			continue
+		}
+		if match(pattern, context) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool { return len(patterns[i]) < len(patterns[j]) })
+	if _, ok := t[context]; ok {
+		patterns = append(patterns, context)
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	out := map[string]string{}
+	for _, p := range patterns {
+		for k, v := range t[p] {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// Hooks maps a kubeconfig context or glob to the commands run around karpx
+// operations on it. Like pins, an exact context name wins over globs and
+// among globs the longest pattern wins.
+type Hooks map[string]HookSet
+
+// HookSet lists the commands run before and after each operation. A failing
+// pre hook aborts the operation.
+type HookSet struct {
+	PreInstall  []Hook `json:"preInstall"`
+	PostInstall []Hook `json:"postInstall"`
+	PreUpgrade  []Hook `json:"preUpgrade"`
+	PostUpgrade []Hook `json:"postUpgrade"`
+	PreApply    []Hook `json:"preApply"`
+	PostApply   []Hook `json:"postApply"`
+}
+
+// Hook is one shell command. In the file it is either the command itself or
+// an object with run, timeout and continueOnError.
+type Hook struct {
+	Run             string `json:"run"`
+	Timeout         string `json:"timeout,omitempty"`         // e.g. "10m"; default 5m
+	ContinueOnError bool   `json:"continueOnError,omitempty"` // warn instead of failing
+}
+
+func (h *Hook) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &h.Run); err == nil {
+		return nil
+	}
+	type plain Hook
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*plain)(h))
+}
+
+// For returns the hooks that apply to a kubeconfig context.
+func (h Hooks) For(context string) HookSet {
+	if set, ok := h[context]; ok {
+		return set
+	}
+	var best string
+	var set HookSet
+	for pattern, s := range h {
+		if match(pattern, context) && len(pattern) > len(best) {
+			best, set = pattern, s
+		}
+	}
+	return set
+}
+
+// Requirement is one term of a Selector.
+type Requirement struct {
+	Key   string
+	Op    string // "=", "!=" or "exists"
+	Value string
+}
+
+// Selector filters clusters by tag; every requirement must hold. The zero
+// Selector matches everything.
+type Selector []Requirement
+
+// ParseSelector parses --tag values: "key=value", "key!=value", or a bare
+// "key" meaning the tag is set. Each value may hold several terms separated
+// by commas.
+func ParseSelector(exprs []string) (Selector, error) {
+	var sel Selector
+	for _, expr := range exprs {
+		for _, term := range strings.Split(expr, ",") {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				continue
+			}
+			var r Requirement
+			switch {
+			case strings.Contains(term, "!="):
+				k, v, _ := strings.Cut(term, "!=")
+				r = Requirement{Key: k, Op: "!=", Value: v}
+			case strings.Contains(term, "="):
+				k, v, _ := strings.Cut(term, "=")
+				r = Requirement{Key: k, Op: "=", Value: v}
+			default:
+				r = Requirement{Key: term, Op: "exists"}
+			}
+			r.Key = strings.TrimSpace(r.Key)
+			r.Value = strings.TrimSpace(r.Value)
+			if r.Key == "" {
+				return nil, fmt.Errorf("invalid tag selector %q: missing key", term)
+			}
+			sel = append(sel, r)
+		}
+	}
+	return sel, nil
+}
+
+// Matches reports whether tags satisfy every requirement.
+func (s Selector) Matches(tags map[string]string) bool {
+	for _, r := range s {
+		v, ok := tags[r.Key]
+		switch r.Op {
+		case "=":
+			if !ok || v != r.Value {
+				return false
+			}
+		case "!=":
+			if ok && v == r.Value {
+				return false
+			}
+		default:
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String renders the selector in --tag syntax.
+func (s Selector) String() string {
+	terms := make([]string, len(s))
+	for i, r := range s {
+		switch r.Op {
+		case "exists":
+			terms[i] = r.Key
+		default:
+			terms[i] = r.Key + r.Op + r.Value
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// Path returns the config file location.
+func Path() string {
+	if p := os.Getenv("KARPX_CONFIG"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".karpx/config.yaml"
+	}
+	return filepath.Join(home, ".karpx", "config.yaml")
+}
+
+// Load reads the config file. A missing file is not an error — it returns
+// an empty Config.
+func Load() (*Config, error) {
+	path := Path()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
@@ -0,0 +1,443 @@
+// Package convert translates legacy Karpenter v1alpha5 resources into their
+// v1 equivalents:
+//
+//	Provisioner      (karpenter.sh/v1alpha5)        → NodePool     (karpenter.sh/v1)
+//	AWSNodeTemplate  (karpenter.k8s.aws/v1alpha1)   → EC2NodeClass (karpenter.k8s.aws/v1)
+//
+// Anything without a direct mapping is reported as a Note instead of being
+// silently dropped, so the output can be reviewed before it is applied.
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kemilad/karpx/internal/manifest"
+)
+
+// Note flags a field that could not be converted automatically or whose
+// behaviour changes in v1.
+type Note struct {
+	Kind   string // source kind
+	Name   string
+	Field  string
+	Text   string
+	Target string // kind of the converted object the note is written above
+}
+
+// Options tune the conversion.
+type Options struct {
+	// NodeRole is the node IAM role for NodeClasses whose source had no
+	// instanceProfile (the global aws.defaultInstanceProfile applied).
+	NodeRole string
+}
+
+// RolePlaceholder is the spec.role written when neither the source nor
+// Options names a node role. It is not a valid role name, so applying the
+// output unchanged fails instead of launching nodes with the wrong role.
+const RolePlaceholder = "TODO-set-node-role"
+
+// Result holds the converted objects and any notes.
+type Result struct {
+	Objects []manifest.Object
+	Notes   []Note
+}
+
+// Convert translates every Provisioner and AWSNodeTemplate in objs. Other
+// kinds are ignored. Inline Provisioner spec.provider blocks become an
+// EC2NodeClass named after the Provisioner.
+func Convert(objs []manifest.Object, opts Options) Result {
+	var r Result
+	note := func(o manifest.Object, field, text string) {
+		r.Notes = append(r.Notes, Note{Kind: o.Kind(), Name: o.Name(), Field: field, Text: text, Target: kindPair(o.Kind())})
+	}
+	// Notes on an inline spec.provider belong above the EC2NodeClass made
+	// from it, not the NodePool.
+	classNote := func(o manifest.Object, field, text string) {
+		r.Notes = append(r.Notes, Note{Kind: o.Kind(), Name: o.Name(), Field: field, Text: text, Target: "EC2NodeClass"})
+	}
+
+	// Kubelet configuration lives on the Provisioner in v1alpha5 but on the
+	// NodeClass in v1 — collect it per template so it can be moved across.
+	kubeletFor := map[string]any{}
+	kubeletFrom := map[string]string{}
+
+	var pools, classes []manifest.Object
+	for _, o := range objs {
+		if o.Kind() != "Provisioner" {
+			continue
+		}
+		np, inline := provisionerToNodePool(o, note)
+		pools = append(pools, np)
+
+		ref := o.String("spec", "providerRef", "name")
+		if inline != nil {
+			ref = o.Name()
+			classes = append(classes, templateToNodeClass(o, inline, opts, classNote))
+		}
+		if k := o.Map("spec", "kubeletConfiguration"); k != nil && ref != "" {
+			if prev, ok := kubeletFrom[ref]; ok && !sameJSON(kubeletFor[ref], k) {
+				note(o, "spec.kubeletConfiguration",
+					fmt.Sprintf("differs from Provisioner %s which uses the same node template — split the EC2NodeClass per Provisioner", prev))
+				continue
+			}
+			kubeletFor[ref] = k
+			kubeletFrom[ref] = o.Name()
+		}
+	}
+	for _, o := range objs {
+		if o.Kind() != "AWSNodeTemplate" {
+			continue
+		}
+		classes = append(classes, templateToNodeClass(o, o.Map("spec"), opts, classNote))
+	}
+
+	for _, nc := range classes {
+		if k, ok := kubeletFor[nc.Name()]; ok {
+			nc.Map("spec")["kubelet"] = k
+		}
+	}
+
+	r.Objects = append(pools, classes...)
+	return r
+}
+
+// YAML renders the converted objects as a multi-document YAML stream. Notes
+// are emitted as comments above the object they belong to.
+func (r Result) YAML() (string, error) {
+	var b strings.Builder
+	for i, o := range r.Objects {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		for _, n := range r.Notes {
+			if n.Name == o.Name() && n.Target == o.Kind() {
+				fmt.Fprintf(&b, "# ⚠ %s: %s\n", n.Field, n.Text)
+			}
+		}
+		out, err := yaml.Marshal(map[string]any(o))
+		if err != nil {
+			return "", fmt.Errorf("marshal %s/%s: %w", o.Kind(), o.Name(), err)
+		}
+		b.Write(out)
+	}
+	return b.String(), nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Provisioner → NodePool
+// ─────────────────────────────────────────────────────────────────────────────
+
+func provisionerToNodePool(p manifest.Object, note func(manifest.Object, string, string)) (manifest.Object, map[string]any) {
+	spec := p.Map("spec")
+	if spec == nil {
+		spec = map[string]any{}
+	}
+	handled := map[string]bool{}
+	take := func(k string) any { handled[k] = true; return spec[k] }
+
+	tmplMeta := map[string]any{}
+	if v := take("labels"); v != nil {
+		tmplMeta["labels"] = v
+	}
+	if v := take("annotations"); v != nil {
+		tmplMeta["annotations"] = v
+	}
+
+	tmplSpec := map[string]any{}
+	if v, ok := take("requirements").([]any); ok {
+		tmplSpec["requirements"] = renameRequirementKeys(v)
+	}
+	for _, k := range []string{"taints", "startupTaints"} {
+		if v := take(k); v != nil {
+			tmplSpec[k] = v
+		}
+	}
+
+	// v1alpha5 nodes never expired unless ttlSecondsUntilExpired was set;
+	// v1 defaults to 720h, so state "Never" explicitly to keep behaviour.
+	tmplSpec["expireAfter"] = "Never"
+	if v := take("ttlSecondsUntilExpired"); v != nil {
+		tmplSpec["expireAfter"] = seconds(v)
+	}
+
+	inline, _ := take("provider").(map[string]any)
+	classRef := p.Name()
+	if ref, ok := take("providerRef").(map[string]any); ok {
+		if n, _ := ref["name"].(string); n != "" {
+			classRef = n
+		}
+	} else if inline == nil {
+		note(p, "spec.providerRef", "no providerRef or inline provider — nodeClassRef points at an EC2NodeClass named after the Provisioner, create it")
+	}
+	tmplSpec["nodeClassRef"] = map[string]any{
+		"group": "karpenter.k8s.aws",
+		"kind":  "EC2NodeClass",
+		"name":  classRef,
+	}
+
+	// ── Disruption ────────────────────────────────────────────────────────
+	disruption := map[string]any{}
+	consolidation, _ := take("consolidation").(map[string]any)
+	ttlEmpty := take("ttlSecondsAfterEmpty")
+	switch {
+	case consolidation != nil && consolidation["enabled"] == true:
+		disruption["consolidationPolicy"] = "WhenEmptyOrUnderutilized"
+		disruption["consolidateAfter"] = "0s"
+		if ttlEmpty != nil {
+			note(p, "spec.ttlSecondsAfterEmpty", "ignored — consolidation already removes empty nodes")
+		}
+	case ttlEmpty != nil:
+		disruption["consolidationPolicy"] = "WhenEmpty"
+		disruption["consolidateAfter"] = seconds(ttlEmpty)
+	default:
+		// Neither set: v1alpha5 never removed nodes on its own.
+		disruption["consolidationPolicy"] = "WhenEmpty"
+		disruption["consolidateAfter"] = "Never"
+	}
+
+	np := map[string]any{
+		"template":   map[string]any{"spec": tmplSpec},
+		"disruption": disruption,
+	}
+	if len(tmplMeta) > 0 {
+		np["template"].(map[string]any)["metadata"] = tmplMeta
+	}
+	if v := take("limits"); v != nil {
+		if m, ok := v.(map[string]any); ok && m["resources"] != nil {
+			np["limits"] = m["resources"]
+		}
+	}
+	if v := take("weight"); v != nil {
+		np["weight"] = v
+	}
+	take("kubeletConfiguration") // moved to the NodeClass by Convert
+
+	for _, k := range sortedKeys(spec) {
+		if !handled[k] {
+			note(p, "spec."+k, "has no v1 equivalent — not converted")
+		}
+	}
+
+	return manifest.Object{
+		"apiVersion": "karpenter.sh/v1",
+		"kind":       "NodePool",
+		"metadata":   convertedMeta(p),
+		"spec":       np,
+	}, inline
+}
+
+// renameRequirementKeys maps v1alpha5 well-known label keys to their v1 names.
+func renameRequirementKeys(reqs []any) []any {
+	out := make([]any, 0, len(reqs))
+	for _, r := range reqs {
+		m, ok := r.(map[string]any)
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+		if m["key"] == "karpenter.sh/provisioner-name" {
+			m["key"] = "karpenter.sh/nodepool"
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// AWSNodeTemplate → EC2NodeClass
+// ─────────────────────────────────────────────────────────────────────────────
+
+func templateToNodeClass(src manifest.Object, spec map[string]any, opts Options, note func(manifest.Object, string, string)) manifest.Object {
+	if spec == nil {
+		spec = map[string]any{}
+	}
+	field := "spec."
+	if src.Kind() == "Provisioner" {
+		field = "spec.provider."
+	}
+	handled := map[string]bool{}
+	take := func(k string) any { handled[k] = true; return spec[k] }
+
+	nc := map[string]any{}
+
+	if sel, ok := take("subnetSelector").(map[string]any); ok {
+		nc["subnetSelectorTerms"] = selectorTerms(sel)
+	} else {
+		note(src, field+"subnetSelector", "missing — add subnetSelectorTerms (e.g. tags: {karpenter.sh/discovery: <cluster>})")
+	}
+	if sel, ok := take("securityGroupSelector").(map[string]any); ok {
+		nc["securityGroupSelectorTerms"] = selectorTerms(sel)
+	} else {
+		note(src, field+"securityGroupSelector", "missing — add securityGroupSelectorTerms")
+	}
+
+	family, _ := take("amiFamily").(string)
+	if family == "" {
+		family = "AL2"
+	}
+	if sel, ok := take("amiSelector").(map[string]any); ok {
+		nc["amiSelectorTerms"] = selectorTerms(sel)
+		nc["amiFamily"] = family
+	} else {
+		switch family {
+		case "AL2":
+			nc["amiSelectorTerms"] = []any{map[string]any{"alias": "al2@latest"}}
+		case "AL2023":
+			nc["amiSelectorTerms"] = []any{map[string]any{"alias": "al2023@latest"}}
+		case "Bottlerocket":
+			nc["amiSelectorTerms"] = []any{map[string]any{"alias": "bottlerocket@latest"}}
+		case "Windows2019", "Windows2022":
+			nc["amiSelectorTerms"] = []any{map[string]any{"alias": strings.ToLower(family) + "@latest"}}
+		default:
+			nc["amiFamily"] = family
+			note(src, field+"amiFamily", family+" has no v1 alias — add amiSelectorTerms selecting your AMIs explicitly")
+		}
+		if family == "AL2" || family == "Bottlerocket" || strings.HasPrefix(family, "Windows") {
+			note(src, field+"amiSelectorTerms", "uses @latest — pin a version (e.g. al2@v20240807) before production")
+		}
+	}
+	if family == "Ubuntu" {
+		note(src, field+"amiFamily", "the Ubuntu AMI family was removed in v1 — switch to AL2023 or Bottlerocket")
+	}
+
+	switch v, _ := take("instanceProfile").(string); {
+	case v != "":
+		nc["instanceProfile"] = v
+	case opts.NodeRole != "":
+		nc["role"] = opts.NodeRole
+	default:
+		nc["role"] = RolePlaceholder
+		note(src, field+"instanceProfile", "TODO: not set (the global aws.defaultInstanceProfile applied) — replace spec.role "+
+			RolePlaceholder+" with the node IAM role of that instance profile, or rerun with --node-role")
+	}
+
+	for _, k := range []string{"tags", "metadataOptions", "blockDeviceMappings", "detailedMonitoring", "userData"} {
+		if v := take(k); v != nil {
+			nc[k] = v
+		}
+	}
+	if _, ok := take("launchTemplate").(string); ok {
+		note(src, field+"launchTemplate", "custom launch templates are not supported in v1 — move the settings into the EC2NodeClass")
+	}
+	if _, ok := take("context").(string); ok {
+		note(src, field+"context", "EC2 fleet context has no v1 equivalent — remove it")
+	}
+
+	for _, k := range sortedKeys(spec) {
+		if !handled[k] {
+			note(src, field+k, "has no v1 equivalent — not converted")
+		}
+	}
+
+	meta := convertedMeta(src)
+	if src.Kind() == "Provisioner" {
+		meta = map[string]any{"name": src.Name()}
+	}
+	return manifest.Object{
+		"apiVersion": "karpenter.k8s.aws/v1",
+		"kind":       "EC2NodeClass",
+		"metadata":   meta,
+		"spec":       nc,
+	}
+}
+
+// selectorTerms converts a v1alpha5 selector map to v1 selector terms.
+// "aws-ids"/"aws::ids" become one id term per ID, "aws::name" (with optional
+// "aws::owners") becomes a name term, and every other key is a tag match.
+func selectorTerms(sel map[string]any) []any {
+	var terms []any
+	tags := map[string]any{}
+	for _, k := range sortedKeys(sel) {
+		v := sel[k]
+		switch k {
+		case "aws-ids", "aws::ids":
+			for _, id := range strings.Split(fmt.Sprint(v), ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					terms = append(terms, map[string]any{"id": id})
+				}
+			}
+		case "aws::name":
+			term := map[string]any{"name": v}
+			if owner, ok := sel["aws::owners"]; ok {
+				term["owner"] = owner
+			}
+			terms = append(terms, term)
+		case "aws::owners":
+			// folded into the name term above
+		default:
+			tags[k] = v
+		}
+	}
+	if len(tags) > 0 {
+		terms = append(terms, map[string]any{"tags": tags})
+	}
+	return terms
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// convertedMeta keeps name, labels and annotations; server-set fields
+// (uid, resourceVersion, managedFields, …) are dropped.
+func convertedMeta(o manifest.Object) map[string]any {
+	meta := map[string]any{"name": o.Name()}
+	if l := o.Map("metadata", "labels"); len(l) > 0 {
+		meta["labels"] = l
+	}
+	if a := o.Map("metadata", "annotations"); len(a) > 0 {
+		kept := map[string]any{}
+		for k, v := range a {
+			if k != "kubectl.kubernetes.io/last-applied-configuration" {
+				kept[k] = v
+			}
+		}
+		if len(kept) > 0 {
+			meta["annotations"] = kept
+		}
+	}
+	return meta
+}
+
+// seconds renders a v1alpha5 TTL (integer seconds) as a v1 duration.
+func seconds(v any) string {
+	switch n := v.(type) {
+	case float64:
+		return fmt.Sprintf("%ds", int64(n))
+	case int64:
+		return fmt.Sprintf("%ds", n)
+	case int:
+		return fmt.Sprintf("%ds", n)
+	}
+	return fmt.Sprint(v) + "s"
+}
+
+// kindPair maps a source kind to the kind it converts into.
+func kindPair(kind string) string {
+	switch kind {
+	case "Provisioner":
+		return "NodePool"
+	case "AWSNodeTemplate":
+		return "EC2NodeClass"
+	}
+	return kind
+}
+
+func sameJSON(a, b any) bool {
+	ja, _ := yaml.Marshal(a)
+	jb, _ := yaml.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,387 @@
+// Package demo is a backend of fake clusters for `karpx --demo`: a spread of
+// providers, Kubernetes and Karpenter versions, one cluster in every state
+// the dashboards can show, and NodePools, NodeClasses and interruption
+// events to browse. Nothing is read from a kubeconfig or the network.
+package demo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kemilad/karpx/internal/backend"
+	"github.com/kemilad/karpx/internal/compat"
+	"github.com/kemilad/karpx/internal/helm"
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// releases are the Karpenter releases the demo offers, newest first.
+var releases = []string{
+	"1.5.0", "1.4.0", "1.3.3", "1.2.1", "1.1.2", "1.0.8",
+	"0.37.7", "0.36.2", "0.35.4",
+}
+
+// azureReleases and azureMatrix stand in for the Azure provider plugin.
+var (
+	azureReleases = []string{"1.5.0", "1.4.0", "1.3.0"}
+	azureMatrix   = compat.Matrix{{Karpenter: ">= 1.0.0, < 2.0.0", K8sMin: "1.29.0", K8sMax: "1.33.99"}}
+)
+
+// NodePool is a fake NodePool.
+type NodePool struct {
+	Name     string
+	Mode     string // karpx.io/generated-mode annotation
+	CPU      string
+	Memory   string
+	NotReady string // Ready condition message; "" = Ready
+}
+
+// NodeClass is a fake EC2NodeClass.
+type NodeClass struct {
+	Name     string
+	Role     string
+	NotReady string
+}
+
+// Interruption is a fake interruption event on a node.
+type Interruption struct {
+	Reason  string
+	Node    string
+	Message string
+	Ago     time.Duration
+	Pods    []string
+}
+
+// Cluster is one fake kubeconfig context.
+type Cluster struct {
+	Context     string
+	Provider    kube.Provider
+	K8sVersion  string
+	Karpenter   *helm.Info
+	Addons      *kube.CoreAddons
+	Releases    []backend.Release
+	NodePools   []NodePool
+	NodeClasses []NodeClass
+	Events      []Interruption
+	Unreachable bool
+}
+
+func arn(region, name string) string {
+	return "arn:aws:eks:" + region + ":123456789012:cluster/" + name
+}
+
+func karpenter(version string) *helm.Info {
+	return &helm.Info{Installed: true, ReleaseName: "karpenter", Version: version,
+		Namespace: "kube-system", Chart: "karpenter-" + version}
+}
+
+func release(name, ns, chart, app string) backend.Release {
+	return backend.Release{Name: name, Namespace: ns, Status: "deployed", Chart: chart, AppVersion: app}
+}
+
+// Clusters are the fake clusters, in kubeconfig order. The first is the
+// current context.
+var Clusters = []Cluster{
+	{
+		Context: arn("us-east-1", "prod-payments"), Provider: kube.ProviderAWS, K8sVersion: "1.31.4",
+		Karpenter: karpenter("1.2.1"),
+		Addons: &kube.CoreAddons{VPCCNI: "v1.19.0-eksbuild.1", CoreDNS: "v1.11.3-eksbuild.2",
+			KubeProxy: "v1.31.2-eksbuild.3", CNI: kube.CNIPrefix, InstanceFamilies: []string{"m6i", "c6i", "r6i"}},
+		Releases: []backend.Release{
+			release("karpenter", "kube-system", "karpenter-1.2.1", "1.2.1"),
+			release("kube-prometheus-stack", "monitoring", "kube-prometheus-stack-65.1.1", "v0.77.1"),
+			release("cert-manager", "cert-manager", "cert-manager-v1.16.1", "v1.16.1"),
+		},
+		NodePools: []NodePool{
+			{Name: "default", Mode: "balanced", CPU: "1000", Memory: "4000Gi"},
+			{Name: "spot-batch", Mode: "cost", CPU: "400", Memory: "1600Gi"},
+			{Name: "gpu", Mode: "performance", CPU: "256", Memory: "2048Gi"},
+		},
+		NodeClasses: []NodeClass{
+			{Name: "default", Role: "KarpenterNodeRole-prod-payments"},
+			{Name: "gpu", Role: "KarpenterNodeRole-prod-payments",
+				NotReady: "AMISelector did not match any AMIs"},
+		},
+		Events: []Interruption{
+			{Reason: "SpotInterrupted", Node: "ip-10-0-42-17.ec2.internal", Ago: 4 * time.Minute,
+				Message: "Spot instance i-0a1b2c3d4e5f60718 will be interrupted in 2 minutes",
+				Pods:    []string{"payments/api-7d9f8c6b5-x2k4p", "payments/worker-5c8d7f9b6-q8m2n"}},
+			{Reason: "SpotRebalanceRecommendation", Node: "ip-10-0-51-203.ec2.internal", Ago: 11 * time.Minute,
+				Message: "Rebalance recommendation for instance i-0f9e8d7c6b5a40312",
+				Pods:    []string{"batch/report-28814400-hk9zt"}},
+			{Reason: "InstanceTerminating", Node: "ip-10-0-17-88.ec2.internal", Ago: 47 * time.Minute,
+				Message: "Instance i-07766554433221100 is terminating"},
+		},
+	},
+	{
+		Context: arn("eu-west-1", "staging"), Provider: kube.ProviderAWS, K8sVersion: "1.32.1",
+		Karpenter: karpenter("1.5.0"),
+		Addons: &kube.CoreAddons{VPCCNI: "v1.19.2-eksbuild.1", CoreDNS: "v1.11.4-eksbuild.2",
+			KubeProxy: "v1.32.0-eksbuild.2", CNI: kube.CNIVPC, InstanceFamilies: []string{"m7g", "c7g"}},
+		Releases: []backend.Release{
+			release("karpenter", "kube-system", "karpenter-1.5.0", "1.5.0"),
+			release("cert-manager", "cert-manager", "cert-manager-v1.16.1", "v1.16.1"),
+		},
+		NodePools: []NodePool{
+			{Name: "default", Mode: "cost", CPU: "200", Memory: "800Gi"},
+		},
+		NodeClasses: []NodeClass{{Name: "default", Role: "KarpenterNodeRole-staging"}},
+	},
+	{
+		// Karpenter 0.36 supports Kubernetes up to 1.29: incompatible, with
+		// a VPC CNI too old for the cluster.
+		Context: arn("us-west-2", "legacy-batch"), Provider: kube.ProviderAWS, K8sVersion: "1.30.6",
+		Karpenter: karpenter("0.36.2"),
+		Addons: &kube.CoreAddons{VPCCNI: "v1.15.1-eksbuild.1", CoreDNS: "v1.10.1-eksbuild.4",
+			KubeProxy: "v1.29.0-eksbuild.1", CNI: kube.CNIVPC, InstanceFamilies: []string{"m5", "c5"}},
+		Releases: []backend.Release{release("karpenter", "kube-system", "karpenter-0.36.2", "0.36.2")},
+		NodePools: []NodePool{
+			{Name: "batch", Mode: "cost", CPU: "2000", Memory: "8000Gi"},
+		},
+		NodeClasses: []NodeClass{{Name: "batch", Role: "KarpenterNodeRole-legacy-batch"}},
+		Events: []Interruption{
+			{Reason: "SpotInterrupted", Node: "ip-10-2-8-141.us-west-2.compute.internal", Ago: 2 * time.Hour,
+				Message: "Spot instance i-0c3d4e5f607182930 will be interrupted in 2 minutes"},
+		},
+	},
+	{
+		Context: arn("ap-southeast-2", "analytics"), Provider: kube.ProviderAWS, K8sVersion: "1.32.0",
+		Karpenter: &helm.Info{},
+		Addons: &kube.CoreAddons{VPCCNI: "v1.19.2-eksbuild.1", CoreDNS: "v1.11.4-eksbuild.2",
+			KubeProxy: "v1.32.0-eksbuild.2", CNI: kube.CNIVPC},
+		Releases: []backend.Release{
+			release("kube-prometheus-stack", "monitoring", "kube-prometheus-stack-65.1.1", "v0.77.1"),
+		},
+	},
+	{
+		Context: arn("us-east-2", "sandbox-auto"), Provider: kube.ProviderAWS, K8sVersion: "1.33.1",
+		Karpenter: &helm.Info{Installed: true, Managed: helm.ManagedEKSAutoMode},
+		Addons:    &kube.CoreAddons{CNI: kube.CNIVPC},
+		NodePools: []NodePool{
+			{Name: "general-purpose", CPU: "1000", Memory: "1000Gi"},
+			{Name: "system", CPU: "1000", Memory: "1000Gi"},
+		},
+	},
+	{
+		Context: "aks-data-platform", Provider: kube.ProviderAzure, K8sVersion: "1.31.2",
+		Karpenter: karpenter("1.4.0"),
+		Releases:  []backend.Release{release("karpenter", "kube-system", "karpenter-1.4.0", "1.4.0")},
+		NodePools: []NodePool{{Name: "default", Mode: "balanced", CPU: "500", Memory: "2000Gi"}},
+	},
+	{
+		Context: "gke-ml-training", Provider: kube.ProviderGCP, K8sVersion: "1.30.5",
+		Karpenter: &helm.Info{},
+	},
+	{
+		Context: "kind-local", Provider: kube.ProviderUnknown, Unreachable: true,
+	},
+}
+
+// Clients returns the demo backend.
+func Clients() backend.Clients {
+	return backend.Clients{Kube: fakeKube{}, Helm: fakeHelm{}, Compat: fakeCompat{}, Demo: true}
+}
+
+// lookup finds the cluster of kubeCtx; "" is the current context.
+func lookup(kubeCtx string) (*Cluster, error) {
+	if kubeCtx == "" {
+		return &Clusters[0], nil
+	}
+	for i := range Clusters {
+		if Clusters[i].Context == kubeCtx {
+			return &Clusters[i], nil
+		}
+	}
+	return nil, fmt.Errorf("context %q does not exist", kubeCtx)
+}
+
+type fakeKube struct{}
+
+func (fakeKube) Contexts() []string {
+	out := make([]string, len(Clusters))
+	for i, c := range Clusters {
+		out[i] = c.Context
+	}
+	return out
+}
+
+func (fakeKube) ContextName(kubeCtx string) string {
+	if c, err := lookup(kubeCtx); err == nil {
+		return c.Context
+	}
+	return kubeCtx
+}
+
+func (fakeKube) DetectProvider(kubeCtx string) kube.Provider {
+	if c, err := lookup(kubeCtx); err == nil {
+		return c.Provider
+	}
+	return kube.ProviderUnknown
+}
+
+func (fakeKube) ServerVersion(kubeCtx string) (string, error) {
+	c, err := lookup(kubeCtx)
+	if err != nil {
+		return "", err
+	}
+	if c.Unreachable {
+		return "", fmt.Errorf("dial tcp 127.0.0.1:6443: connect: connection refused")
+	}
+	return c.K8sVersion, nil
+}
+
+func (fakeKube) CoreAddons(kubeCtx string) (*kube.CoreAddons, error) {
+	c, err := lookup(kubeCtx)
+	if err != nil {
+		return nil, err
+	}
+	if c.Addons == nil {
+		return &kube.CoreAddons{}, nil
+	}
+	return c.Addons, nil
+}
+
+// Kubectl answers the `kubectl get` calls the dashboards make. Other
+// resources are empty lists; other verbs fail.
+func (fakeKube) Kubectl(_ context.Context, kubeCtx string, args ...string) ([]byte, error) {
+	c, err := lookup(kubeCtx)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 2 || args[0] != "get" {
+		return nil, fmt.Errorf("kubectl %v is not available in demo mode", args)
+	}
+	switch args[1] {
+	case "nodepools.karpenter.sh":
+		return nodePools(c.NodePools)
+	case "ec2nodeclasses.karpenter.k8s.aws":
+		return nodeClasses(c.NodeClasses)
+	case "events":
+		return events(c.Events)
+	case "pods":
+		return pods(c, args), nil
+	}
+	return []byte(`{"items":[]}`), nil
+}
+
+type obj map[string]any
+
+func list(items []obj) ([]byte, error) {
+	if items == nil {
+		items = []obj{}
+	}
+	return json.Marshal(obj{"apiVersion": "v1", "kind": "List", "items": items})
+}
+
+func ready(notReady string) []obj {
+	if notReady == "" {
+		return []obj{{"type": "Ready", "status": "True"}}
+	}
+	return []obj{{"type": "Ready", "status": "False", "reason": "NotReady", "message": notReady}}
+}
+
+func nodePools(nps []NodePool) ([]byte, error) {
+	var items []obj
+	for _, np := range nps {
+		meta := obj{"name": np.Name}
+		if np.Mode != "" {
+			meta["annotations"] = obj{"karpx.io/generated-mode": np.Mode}
+		}
+		items = append(items, obj{
+			"apiVersion": "karpenter.sh/v1",
+			"kind":       "NodePool",
+			"metadata":   meta,
+			"spec":       obj{"limits": obj{"cpu": np.CPU, "memory": np.Memory}},
+			"status":     obj{"conditions": ready(np.NotReady)},
+		})
+	}
+	return list(items)
+}
+
+func nodeClasses(ncs []NodeClass) ([]byte, error) {
+	var items []obj
+	for _, nc := range ncs {
+		items = append(items, obj{
+			"apiVersion": "karpenter.k8s.aws/v1",
+			"kind":       "EC2NodeClass",
+			"metadata":   obj{"name": nc.Name},
+			"spec":       obj{"role": nc.Role},
+			"status":     obj{"conditions": ready(nc.NotReady)},
+		})
+	}
+	return list(items)
+}
+
+// events dates each interruption relative to now, so the feed looks live.
+func events(evs []Interruption) ([]byte, error) {
+	now := time.Now()
+	var items []obj
+	for i, ev := range evs {
+		items = append(items, obj{
+			"metadata":       obj{"uid": fmt.Sprintf("demo-%s-%d", ev.Node, i)},
+			"reason":         ev.Reason,
+			"message":        ev.Message,
+			"lastTimestamp":  now.Add(-ev.Ago).UTC().Format(time.RFC3339),
+			"involvedObject": obj{"kind": "Node", "name": ev.Node},
+		})
+	}
+	return list(items)
+}
+
+// pods prints namespace/name lines for the node in the field selector, as
+// the jsonpath output of `kubectl get pods` does.
+func pods(c *Cluster, args []string) []byte {
+	var out []byte
+	for _, a := range args {
+		sel, ok := strings.CutPrefix(a, "spec.nodeName=")
+		if !ok {
+			continue
+		}
+		node, _, _ := strings.Cut(sel, ",")
+		for _, ev := range c.Events {
+			if ev.Node == node {
+				for _, p := range ev.Pods {
+					out = append(out, p+"\n"...)
+				}
+				return out
+			}
+		}
+	}
+	return out
+}
+
+type fakeHelm struct{}
+
+func (fakeHelm) DetectKarpenter(kubeCtx string) (*helm.Info, error) {
+	c, err := lookup(kubeCtx)
+	if err != nil {
+		return nil, err
+	}
+	if c.Karpenter == nil {
+		return &helm.Info{}, nil
+	}
+	info := *c.Karpenter
+	return &info, nil
+}
+
+func (fakeHelm) Releases(kubeCtx string) ([]backend.Release, error) {
+	c, err := lookup(kubeCtx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Releases, nil
+}
+
+type fakeCompat struct{}
+
+func (fakeCompat) Source(provider kube.Provider) (compat.Source, bool) {
+	switch provider {
+	case kube.ProviderAWS:
+		return func() ([]string, compat.Matrix, error) { return releases, compat.Embedded(), nil }, true
+	case kube.ProviderAzure:
+		return func() ([]string, compat.Matrix, error) { return azureReleases, azureMatrix, nil }, true
+	}
+	return nil, false
+}
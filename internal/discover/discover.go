@@ -0,0 +1,290 @@
+// Package discover lists managed Kubernetes clusters straight from the cloud
+// provider APIs — EKS, AKS, and GKE — so clusters that are not in the local
+// kubeconfig yet can be found, checked for Karpenter, and added.
+package discover
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kemilad/karpx/internal/awscli"
+	"github.com/kemilad/karpx/internal/azure"
+	"github.com/kemilad/karpx/internal/gcp"
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// Cluster is one cluster found through a cloud API.
+type Cluster struct {
+	Provider kube.Provider `json:"provider"`
+	Account  string        `json:"account"`  // AWS account, Azure subscription, or GCP project
+	Location string        `json:"location"` // region or zone
+	Name     string        `json:"name"`
+	Version  string        `json:"k8s_version"`
+	Endpoint string        `json:"endpoint"`
+
+	// Context is the kubeconfig context pointing at this cluster, or "" when
+	// the cluster is not in the kubeconfig.
+	Context string `json:"context,omitempty"`
+
+	azure *azure.Cluster
+	gcp   *gcp.Cluster
+}
+
+// Params holds all inputs for Find.
+type Params struct {
+	Providers []kube.Provider
+	Regions   []string // AWS regions (default: every enabled region)
+	Accounts  []string // Azure subscriptions / GCP projects (default: all visible)
+}
+
+// Find lists clusters for every requested provider concurrently. A provider
+// whose CLI is missing or not logged in contributes an error, not a failure
+// of the whole search.
+func Find(p Params) ([]Cluster, []error) {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		out  []Cluster
+		errs []error
+	)
+	collect := func(cs []Cluster, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		out = append(out, cs...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, prov := range p.Providers {
+		wg.Add(1)
+		go func(prov kube.Provider) {
+			defer wg.Done()
+			switch prov {
+			case kube.ProviderAWS:
+				collect(findAWS(p.Regions))
+			case kube.ProviderAzure:
+				collect(findAzure(p.Accounts))
+			case kube.ProviderGCP:
+				collect(findGCP(p.Accounts))
+			default:
+				collect(nil, fmt.Errorf("unsupported provider %q", prov))
+			}
+		}(prov)
+	}
+	wg.Wait()
+
+	// Match to existing kubeconfig entries by API server host.
+	byServer := kube.ContextsByServer()
+	for i := range out {
+		out[i].Context = byServer[kube.ServerHost(out[i].Endpoint)]
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Name < b.Name
+	})
+	return out, errs
+}
+
+// AddToKubeconfig writes a kubeconfig entry for c with the provider's own
+// CLI (the update-kubeconfig / get-credentials equivalent) and returns the
+// context name it created.
+func AddToKubeconfig(c Cluster) (string, error) {
+	var err error
+	switch c.Provider {
+	case kube.ProviderAWS:
+		err = awscli.Run(c.Location, "eks", "update-kubeconfig", "--name", c.Name)
+	case kube.ProviderAzure:
+		err = azure.GetCredentials(c.azure)
+	case kube.ProviderGCP:
+		err = gcp.GetCredentials(c.gcp)
+	default:
+		return "", fmt.Errorf("unsupported provider %q", c.Provider)
+	}
+	if err != nil {
+		return "", err
+	}
+	ctx := kube.ContextsByServer()[kube.ServerHost(c.Endpoint)]
+	if ctx == "" {
+		return "", fmt.Errorf("kubeconfig updated but no context points at %s", c.Endpoint)
+	}
+	return ctx, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Providers
+// ─────────────────────────────────────────────────────────────────────────────
+
+func findAWS(regions []string) ([]Cluster, error) {
+	if !awscli.Available() {
+		return nil, fmt.Errorf("aws: CLI not found")
+	}
+	if len(regions) == 0 {
+		var resp struct {
+			Regions []struct {
+				RegionName string `json:"RegionName"`
+			} `json:"Regions"`
+		}
+		if err := awscli.JSON(&resp, "", "ec2", "describe-regions"); err != nil {
+			return nil, fmt.Errorf("aws: %w", err)
+		}
+		for _, r := range resp.Regions {
+			regions = append(regions, r.RegionName)
+		}
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		out  []Cluster
+		errs []string
+	)
+	sem := make(chan struct{}, 8)
+	for _, region := range regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			cs, err := awsRegion(region)
+			mu.Lock()
+			defer mu.Unlock()
+			out = append(out, cs...)
+			if err != nil {
+				errs = append(errs, region+": "+err.Error())
+			}
+		}(region)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return out, fmt.Errorf("aws: %s", strings.Join(errs, "; "))
+	}
+	return out, nil
+}
+
+func awsRegion(region string) ([]Cluster, error) {
+	var list struct {
+		Clusters []string `json:"clusters"`
+	}
+	if err := awscli.JSON(&list, region, "eks", "list-clusters"); err != nil {
+		return nil, err
+	}
+	var out []Cluster
+	for _, name := range list.Clusters {
+		var desc struct {
+			Cluster struct {
+				Arn      string `json:"arn"`
+				Version  string `json:"version"`
+				Endpoint string `json:"endpoint"`
+			} `json:"cluster"`
+		}
+		if err := awscli.JSON(&desc, region, "eks", "describe-cluster", "--name", name); err != nil {
+			return out, err
+		}
+		account := ""
+		if parts := strings.Split(desc.Cluster.Arn, ":"); len(parts) > 4 {
+			account = parts[4]
+		}
+		out = append(out, Cluster{
+			Provider: kube.ProviderAWS,
+			Account:  account,
+			Location: region,
+			Name:     name,
+			Version:  desc.Cluster.Version,
+			Endpoint: desc.Cluster.Endpoint,
+		})
+	}
+	return out, nil
+}
+
+func findAzure(subscriptions []string) ([]Cluster, error) {
+	if !azure.Available() {
+		return nil, fmt.Errorf("azure: CLI not found")
+	}
+	if len(subscriptions) == 0 {
+		subs, err := azure.Subscriptions()
+		if err != nil {
+			return nil, fmt.Errorf("azure: %w", err)
+		}
+		subscriptions = subs
+	}
+	var out []Cluster
+	var errs []string
+	for _, sub := range subscriptions {
+		list, err := azure.ListClusters(sub)
+		if err != nil {
+			errs = append(errs, sub+": "+err.Error())
+			continue
+		}
+		for i := range list {
+			c := &list[i]
+			out = append(out, Cluster{
+				Provider: kube.ProviderAzure,
+				Account:  c.SubscriptionID(),
+				Location: c.Location,
+				Name:     c.Name,
+				Version:  c.KubernetesVersion,
+				Endpoint: c.FQDN,
+				azure:    c,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return out, fmt.Errorf("azure: %s", strings.Join(errs, "; "))
+	}
+	return out, nil
+}
+
+func findGCP(projects []string) ([]Cluster, error) {
+	if !gcp.Available() {
+		return nil, fmt.Errorf("gcp: CLI not found")
+	}
+	if len(projects) == 0 {
+		ps, err := gcp.Projects()
+		if err != nil {
+			return nil, fmt.Errorf("gcp: %w", err)
+		}
+		projects = ps
+	}
+	var out []Cluster
+	var errs []string
+	for _, project := range projects {
+		list, err := gcp.ListClusters(project)
+		if err != nil {
+			// Projects without the Container API enabled are common — skip quietly.
+			if strings.Contains(err.Error(), "SERVICE_DISABLED") || strings.Contains(err.Error(), "has not been used") {
+				continue
+			}
+			errs = append(errs, project+": "+err.Error())
+			continue
+		}
+		for i := range list {
+			c := &list[i]
+			out = append(out, Cluster{
+				Provider: kube.ProviderGCP,
+				Account:  project,
+				Location: c.Location,
+				Name:     c.Name,
+				Version:  c.CurrentMasterVersion,
+				Endpoint: c.Endpoint,
+				gcp:      c,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return out, fmt.Errorf("gcp: %s", strings.Join(errs, "; "))
+	}
+	return out, nil
+}
@@ -0,0 +1,532 @@
+// Package explain enumerates the instance types a NodePool can launch: every
+// EC2 instance type offered in the region, intersected with the NodePool
+// requirements (families, sizes, architectures, capacity types, zones) the
+// way Karpenter evaluates its well-known labels.
+//
+// Debugging "why did Karpenter pick a c6a.48xlarge" starts with knowing the
+// candidate set; Karpenter launches the cheapest candidate that fits the
+// pending pods.
+package explain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kemilad/karpx/internal/awscli"
+	"github.com/kemilad/karpx/internal/manifest"
+	"github.com/kemilad/karpx/internal/pricing"
+)
+
+// Requirement is one entry of spec.template.spec.requirements.
+type Requirement struct {
+	Key       string   `json:"key"`
+	Operator  string   `json:"operator"`
+	Values    []string `json:"values,omitempty"`
+	MinValues int      `json:"min_values,omitempty"`
+	// Excluded counts the instance types this requirement alone rules out.
+	Excluded int `json:"excluded"`
+	// Unsupported is set for keys not evaluated against instance types
+	// (custom labels, network bandwidth), which never exclude anything.
+	Unsupported bool `json:"unsupported,omitempty"`
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case "Exists", "DoesNotExist":
+		return r.Key + " " + r.Operator
+	}
+	return fmt.Sprintf("%s %s %s", r.Key, r.Operator, strings.Join(r.Values, ","))
+}
+
+// InstanceType is one EC2 instance type with the labels Karpenter derives
+// for it.
+type InstanceType struct {
+	pricing.Price
+	Arch          string            `json:"arch"`
+	CapacityTypes []string          `json:"capacity_types"`
+	Zones         []string          `json:"zones"`
+	GPUs          int               `json:"gpus,omitempty"`
+	Labels        map[string]string `json:"-"`
+}
+
+// Result is the candidate set of one NodePool.
+type Result struct {
+	NodePool      string         `json:"nodepool"`
+	NodeClass     string         `json:"nodeclass,omitempty"`
+	Region        string         `json:"region"`
+	Requirements  []Requirement  `json:"requirements"`
+	CapacityTypes []string       `json:"capacity_types"`
+	Zones         []string       `json:"zones"`
+	Offered       int            `json:"offered"` // instance types offered in the region
+	Total         int            `json:"total"`   // candidates before any --top cut
+	Candidates    []InstanceType `json:"candidates"`
+	Priced        bool           `json:"priced"`
+}
+
+// Params holds all inputs for Run.
+type Params struct {
+	KubeCtx  string
+	Region   string
+	NodePool string
+	Prices   bool // look up on-demand and spot prices of the candidates
+}
+
+// Run reads the NodePool (and the subnet zones of its EC2NodeClass), lists
+// the instance types offered in the region and keeps those every
+// requirement allows. Candidates are sorted cheapest first when priced,
+// otherwise by size.
+func Run(p Params) (*Result, error) {
+	if !awscli.Available() {
+		return nil, fmt.Errorf("aws CLI not found — install it from https://aws.amazon.com/cli/")
+	}
+	pools, err := manifest.ListCluster(p.KubeCtx, "nodepools.karpenter.sh", false)
+	if err != nil {
+		return nil, err
+	}
+	var np *manifest.Object
+	for i := range pools {
+		if pools[i].Name() == p.NodePool {
+			np = &pools[i]
+			break
+		}
+	}
+	if np == nil {
+		return nil, fmt.Errorf("NodePool %q not found", p.NodePool)
+	}
+
+	r := &Result{
+		NodePool:     p.NodePool,
+		NodeClass:    np.String("spec", "template", "spec", "nodeClassRef", "name"),
+		Region:       p.Region,
+		Requirements: Requirements(*np),
+	}
+	subnetZones := nodeClassZones(p.KubeCtx, r.NodeClass)
+
+	types, err := Catalog(p.Region)
+	if err != nil {
+		return nil, err
+	}
+	r.Offered = len(types)
+
+	r.CapacityTypes = CapacityTypes(r.Requirements)
+	for i := range r.Requirements {
+		req := &r.Requirements[i]
+		req.Unsupported = !supportedKeys[req.Key]
+		for _, it := range types {
+			if !req.Unsupported && !it.matches(*req, subnetZones) {
+				req.Excluded++
+			}
+		}
+	}
+	r.Candidates = Match(r.Requirements, types, subnetZones)
+	zones := map[string]bool{}
+	for _, it := range r.Candidates {
+		for _, z := range it.Zones {
+			zones[z] = true
+		}
+	}
+	r.Total = len(r.Candidates)
+	for z := range zones {
+		r.Zones = append(r.Zones, z)
+	}
+	sort.Strings(r.Zones)
+
+	if p.Prices && len(r.Candidates) > 0 {
+		prices := make([]pricing.Price, len(r.Candidates))
+		for i, c := range r.Candidates {
+			prices[i] = c.Price
+		}
+		if err := pricing.Fill(p.Region, prices); err != nil {
+			return nil, err
+		}
+		for i := range r.Candidates {
+			r.Candidates[i].Price = prices[i]
+		}
+		r.Priced = true
+	}
+	sort.SliceStable(r.Candidates, func(i, j int) bool {
+		a, b := r.Candidates[i], r.Candidates[j]
+		if r.Priced {
+			pa, pb := a.Cheapest(r.CapacityTypes), b.Cheapest(r.CapacityTypes)
+			if pa != pb {
+				// Unknown prices sort last.
+				return pb == 0 || (pa != 0 && pa < pb)
+			}
+		}
+		if a.VCPU != b.VCPU {
+			return a.VCPU < b.VCPU
+		}
+		if a.MemoryGiB != b.MemoryGiB {
+			return a.MemoryGiB < b.MemoryGiB
+		}
+		return a.InstanceType < b.InstanceType
+	})
+	return r, nil
+}
+
+// Match returns the instance types that satisfy every supported requirement
+// in at least one allowed capacity type and zone, with Zones narrowed to the
+// usable ones. subnetZones, when set, limits zones to the EC2NodeClass
+// subnets.
+func Match(reqs []Requirement, types []InstanceType, subnetZones []string) []InstanceType {
+	capacity := CapacityTypes(reqs)
+	var out []InstanceType
+	for _, it := range types {
+		ok := true
+		for _, req := range reqs {
+			if supportedKeys[req.Key] && !it.matches(req, subnetZones) {
+				ok = false
+				break
+			}
+		}
+		if !ok || len(intersect(it.CapacityTypes, capacity)) == 0 {
+			continue
+		}
+		it.Zones = usableZones(it, reqs, subnetZones)
+		if len(it.Zones) > 0 {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// CapacityTypes returns the capacity types the requirements allow. Karpenter
+// launches on-demand only when none is required.
+func CapacityTypes(reqs []Requirement) []string {
+	out := []string{"on-demand", "spot"}
+	constrained := false
+	for _, r := range reqs {
+		if r.Key == "karpenter.sh/capacity-type" {
+			out, constrained = allowed(r, out), true
+		}
+	}
+	if !constrained {
+		return []string{"on-demand"}
+	}
+	return out
+}
+
+// Cheapest returns the lowest known hourly price among the capacity types,
+// or 0 when none is known.
+func (it InstanceType) Cheapest(capacityTypes []string) float64 {
+	best := 0.0
+	for _, ct := range capacityTypes {
+		if !contains(it.CapacityTypes, ct) {
+			continue
+		}
+		v := it.OnDemand
+		if ct == "spot" {
+			v = it.Spot
+		}
+		if v > 0 && (best == 0 || v < best) {
+			best = v
+		}
+	}
+	return best
+}
+
+// Requirements returns the requirements of a NodePool in spec order.
+func Requirements(np manifest.Object) []Requirement {
+	var out []Requirement
+	for _, raw := range np.Slice("spec", "template", "spec", "requirements") {
+		m, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		r := Requirement{}
+		r.Key, _ = m["key"].(string)
+		r.Operator, _ = m["operator"].(string)
+		if mv, ok := m["minValues"].(float64); ok {
+			r.MinValues = int(mv)
+		}
+		vals, _ := m["values"].([]any)
+		for _, v := range vals {
+			if s, ok := v.(string); ok {
+				r.Values = append(r.Values, s)
+			}
+		}
+		if r.Key != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Catalog lists every instance type offered in at least one zone of region,
+// with its Karpenter labels and the zones that offer it.
+func Catalog(region string) ([]InstanceType, error) {
+	var offerings struct {
+		InstanceTypeOfferings []struct {
+			InstanceType string `json:"InstanceType"`
+			Location     string `json:"Location"`
+		} `json:"InstanceTypeOfferings"`
+	}
+	if err := awscli.JSON(&offerings, region, "ec2", "describe-instance-type-offerings",
+		"--location-type", "availability-zone"); err != nil {
+		return nil, err
+	}
+	offered := map[string][]string{}
+	for _, o := range offerings.InstanceTypeOfferings {
+		offered[o.InstanceType] = append(offered[o.InstanceType], o.Location)
+	}
+
+	var resp struct {
+		InstanceTypes []struct {
+			InstanceType  string   `json:"InstanceType"`
+			Hypervisor    string   `json:"Hypervisor"`
+			UsageClasses  []string `json:"SupportedUsageClasses"`
+			ProcessorInfo struct {
+				SupportedArchitectures []string `json:"SupportedArchitectures"`
+			} `json:"ProcessorInfo"`
+			VCpuInfo struct {
+				DefaultVCpus int `json:"DefaultVCpus"`
+			} `json:"VCpuInfo"`
+			MemoryInfo struct {
+				SizeInMiB int `json:"SizeInMiB"`
+			} `json:"MemoryInfo"`
+			GpuInfo struct {
+				Gpus []struct {
+					Name         string `json:"Name"`
+					Manufacturer string `json:"Manufacturer"`
+					Count        int    `json:"Count"`
+				} `json:"Gpus"`
+			} `json:"GpuInfo"`
+			InstanceStorageInfo struct {
+				TotalSizeInGB int `json:"TotalSizeInGB"`
+			} `json:"InstanceStorageInfo"`
+			NetworkInfo struct {
+				EncryptionInTransitSupported bool `json:"EncryptionInTransitSupported"`
+			} `json:"NetworkInfo"`
+		} `json:"InstanceTypes"`
+	}
+	if err := awscli.JSON(&resp, region, "ec2", "describe-instance-types"); err != nil {
+		return nil, err
+	}
+
+	var out []InstanceType
+	for _, t := range resp.InstanceTypes {
+		zones := offered[t.InstanceType]
+		if len(zones) == 0 {
+			continue
+		}
+		sort.Strings(zones)
+		family, size, _ := strings.Cut(t.InstanceType, ".")
+		category, generation := splitFamily(family)
+		arch := ""
+		for _, a := range t.ProcessorInfo.SupportedArchitectures {
+			switch a {
+			case "x86_64":
+				arch = "amd64"
+			case "arm64":
+				arch = "arm64"
+			}
+		}
+		if arch == "" {
+			continue // i386-only and Mac instances cannot join the cluster
+		}
+		var capacity []string
+		for _, u := range t.UsageClasses {
+			if u == "on-demand" || u == "spot" {
+				capacity = append(capacity, u)
+			}
+		}
+		it := InstanceType{
+			Price: pricing.Price{
+				InstanceType: t.InstanceType,
+				Family:       family,
+				VCPU:         t.VCpuInfo.DefaultVCpus,
+				MemoryGiB:    float64(t.MemoryInfo.SizeInMiB) / 1024,
+			},
+			Arch:          arch,
+			CapacityTypes: capacity,
+			Zones:         zones,
+			Labels: map[string]string{
+				"node.kubernetes.io/instance-type":                           t.InstanceType,
+				"kubernetes.io/arch":                                         arch,
+				"kubernetes.io/os":                                           "linux",
+				"karpenter.k8s.aws/instance-family":                          family,
+				"karpenter.k8s.aws/instance-category":                        category,
+				"karpenter.k8s.aws/instance-size":                            size,
+				"karpenter.k8s.aws/instance-cpu":                             strconv.Itoa(t.VCpuInfo.DefaultVCpus),
+				"karpenter.k8s.aws/instance-memory":                          strconv.Itoa(t.MemoryInfo.SizeInMiB),
+				"karpenter.k8s.aws/instance-encryption-in-transit-supported": strconv.FormatBool(t.NetworkInfo.EncryptionInTransitSupported),
+			},
+		}
+		if generation != "" {
+			it.Labels["karpenter.k8s.aws/instance-generation"] = generation
+		}
+		if t.Hypervisor != "" {
+			it.Labels["karpenter.k8s.aws/instance-hypervisor"] = t.Hypervisor
+		}
+		if t.InstanceStorageInfo.TotalSizeInGB > 0 {
+			it.Labels["karpenter.k8s.aws/instance-local-nvme"] = strconv.Itoa(t.InstanceStorageInfo.TotalSizeInGB)
+		}
+		for _, g := range t.GpuInfo.Gpus {
+			it.GPUs += g.Count
+			it.Labels["karpenter.k8s.aws/instance-gpu-name"] = strings.ToLower(g.Name)
+			it.Labels["karpenter.k8s.aws/instance-gpu-manufacturer"] = strings.ToLower(g.Manufacturer)
+		}
+		if it.GPUs > 0 {
+			it.Labels["karpenter.k8s.aws/instance-gpu-count"] = strconv.Itoa(it.GPUs)
+		}
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+// supportedKeys are the requirement keys evaluated against instance types:
+// the labels Catalog derives, plus zone and capacity type, which are matched
+// against offerings instead.
+var supportedKeys = map[string]bool{
+	"topology.kubernetes.io/zone":                                true,
+	"karpenter.sh/capacity-type":                                 true,
+	"node.kubernetes.io/instance-type":                           true,
+	"kubernetes.io/arch":                                         true,
+	"kubernetes.io/os":                                           true,
+	"karpenter.k8s.aws/instance-family":                          true,
+	"karpenter.k8s.aws/instance-category":                        true,
+	"karpenter.k8s.aws/instance-generation":                      true,
+	"karpenter.k8s.aws/instance-size":                            true,
+	"karpenter.k8s.aws/instance-cpu":                             true,
+	"karpenter.k8s.aws/instance-memory":                          true,
+	"karpenter.k8s.aws/instance-gpu-count":                       true,
+	"karpenter.k8s.aws/instance-gpu-name":                        true,
+	"karpenter.k8s.aws/instance-gpu-manufacturer":                true,
+	"karpenter.k8s.aws/instance-hypervisor":                      true,
+	"karpenter.k8s.aws/instance-local-nvme":                      true,
+	"karpenter.k8s.aws/instance-encryption-in-transit-supported": true,
+}
+
+// Supported reports whether requirements on key are evaluated against
+// instance types and offerings.
+func Supported(key string) bool { return supportedKeys[key] }
+
+// matches evaluates one requirement against the instance type.
+func (it InstanceType) matches(r Requirement, subnetZones []string) bool {
+	switch r.Key {
+	case "karpenter.sh/capacity-type":
+		return len(allowed(r, it.CapacityTypes)) > 0
+	case "topology.kubernetes.io/zone":
+		return len(usableZones(it, []Requirement{r}, subnetZones)) > 0
+	}
+	v, ok := it.Labels[r.Key]
+	return r.Allows(v, ok)
+}
+
+// Allows applies the node selector operator to a label value; ok is false
+// when the label is not set. A missing label satisfies only NotIn and
+// DoesNotExist, as in Kubernetes.
+func (r Requirement) Allows(v string, ok bool) bool {
+	switch r.Operator {
+	case "In":
+		return ok && contains(r.Values, v)
+	case "NotIn":
+		return !ok || !contains(r.Values, v)
+	case "Exists":
+		return ok
+	case "DoesNotExist":
+		return !ok
+	case "Gt", "Lt":
+		if !ok || len(r.Values) != 1 {
+			return false
+		}
+		have, err1 := strconv.Atoi(v)
+		want, err2 := strconv.Atoi(r.Values[0])
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if r.Operator == "Gt" {
+			return have > want
+		}
+		return have < want
+	}
+	return false
+}
+
+// allowed returns the values of universe the requirement allows.
+func allowed(r Requirement, universe []string) []string {
+	var out []string
+	for _, v := range universe {
+		if r.Allows(v, true) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// usableZones are the zones offering the instance type that the zone
+// requirements and the EC2NodeClass subnets allow.
+func usableZones(it InstanceType, reqs []Requirement, subnetZones []string) []string {
+	zones := it.Zones
+	if len(subnetZones) > 0 {
+		zones = intersect(zones, subnetZones)
+	}
+	for _, r := range reqs {
+		if r.Key == "topology.kubernetes.io/zone" {
+			zones = allowed(r, zones)
+		}
+	}
+	return zones
+}
+
+// nodeClassZones returns the zones of the subnets the EC2NodeClass
+// resolved, or nil when they are unknown.
+func nodeClassZones(kubeCtx, name string) []string {
+	if name == "" {
+		return nil
+	}
+	classes, err := manifest.ListCluster(kubeCtx, "ec2nodeclasses.karpenter.k8s.aws", false)
+	if err != nil {
+		return nil
+	}
+	var zones []string
+	for _, nc := range classes {
+		if nc.Name() != name {
+			continue
+		}
+		for _, s := range nc.Slice("status", "subnets") {
+			m, _ := s.(map[string]any)
+			if z, _ := m["zone"].(string); z != "" && !contains(zones, z) {
+				zones = append(zones, z)
+			}
+		}
+	}
+	return zones
+}
+
+// splitFamily splits an instance family into Karpenter's category and
+// generation labels: "c6a" → "c", "6"; "inf2" → "inf", "2".
+func splitFamily(family string) (category, generation string) {
+	i := strings.IndexFunc(family, unicode.IsDigit)
+	if i < 0 {
+		return family, ""
+	}
+	category = family[:i]
+	j := i
+	for j < len(family) && unicode.IsDigit(rune(family[j])) {
+		j++
+	}
+	return category, family[i:j]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func intersect(a, b []string) []string {
+	var out []string
+	for _, v := range a {
+		if contains(b, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,249 @@
+// Package featuregates lists and toggles Karpenter feature gates.
+//
+// Gates are chart values under settings.featureGates, rendered into the
+// controller's FEATURE_GATES variable. Each exists only in a range of
+// releases: a gate the installed chart does not know is silently ignored,
+// so every change is checked against the installed version first. The
+// release numbers are those of the AWS provider.
+package featuregates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Gate is one Karpenter feature gate.
+type Gate struct {
+	Name      string `json:"name"`              // as in FEATURE_GATES, e.g. "SpotToSpotConsolidation"
+	Key       string `json:"key"`               // chart value under settings.featureGates
+	Since     string `json:"since"`             // first release with the gate
+	Removed   string `json:"removed,omitempty"` // first release without it
+	DefaultOn string `json:"-"`                 // first release where it defaults to on; "" = off
+	Stage     string `json:"stage"`             // alpha | beta
+	Note      string `json:"note,omitempty"`
+}
+
+// Gates are the feature gates karpx knows, oldest first.
+var Gates = []Gate{
+	{Name: "Drift", Key: "drift", Since: "0.21.0", Removed: "1.0.0", DefaultOn: "0.33.0", Stage: "beta",
+		Note: "replaces nodes whose NodePool or NodeClass changed; always on since v1"},
+	{Name: "SpotToSpotConsolidation", Key: "spotToSpotConsolidation", Since: "0.34.0", Stage: "alpha",
+		Note: "single-node spot replacement needs at least 15 instance types in the NodePool"},
+	{Name: "NodeRepair", Key: "nodeRepair", Since: "1.1.0", Stage: "alpha",
+		Note: "replaces nodes whose kubelet or node-monitoring conditions stay unhealthy"},
+	{Name: "ReservedCapacity", Key: "reservedCapacity", Since: "1.3.0", Stage: "alpha",
+		Note: "launches into On-Demand Capacity Reservations selected by the EC2NodeClass"},
+	{Name: "NodeOverlay", Key: "nodeOverlay", Since: "1.7.0", Stage: "alpha",
+		Note: "NodeOverlay objects adjust instance type prices and capacity"},
+}
+
+// Lookup finds a gate by its FEATURE_GATES name or chart key, in any case.
+func Lookup(name string) (Gate, bool) {
+	for _, g := range Gates {
+		if strings.EqualFold(g.Name, name) || strings.EqualFold(g.Key, name) {
+			return g, true
+		}
+	}
+	return Gate{}, false
+}
+
+// Supports reports whether version has the gate.
+func (g Gate) Supports(version string) bool { return g.Check(version) == nil }
+
+// Check returns why version does not have the gate, or nil.
+func (g Gate) Check(version string) error {
+	v, err := semver.NewVersion(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return fmt.Errorf("unknown Karpenter version %q", version)
+	}
+	if v.LessThan(semver.MustParse(g.Since)) {
+		return fmt.Errorf("%s needs Karpenter v%s or later (installed v%s)", g.Name, g.Since, v)
+	}
+	if g.Removed != "" && !v.LessThan(semver.MustParse(g.Removed)) {
+		return fmt.Errorf("%s was removed in v%s: %s", g.Name, g.Removed, g.Note)
+	}
+	return nil
+}
+
+// Default is the gate's value when unset in version.
+func (g Gate) Default(version string) bool {
+	if g.DefaultOn == "" {
+		return false
+	}
+	v, err := semver.NewVersion(strings.TrimPrefix(version, "v"))
+	return err == nil && !v.LessThan(semver.MustParse(g.DefaultOn))
+}
+
+// State is a gate as deployed.
+type State struct {
+	Gate
+	Supported bool `json:"supported"`
+	Enabled   bool `json:"enabled"`
+	Explicit  bool `json:"explicit"` // set in the values, not the chart default
+}
+
+// Release identifies the deployed controller.
+type Release struct {
+	KubeCtx    string
+	Namespace  string
+	Name       string // Helm release; also the Deployment name
+	Version    string // installed Karpenter version
+	Manifests  bool   // installed as plain manifests; no Helm release
+	Deployment string // controller Deployment; defaults to Name
+}
+
+// Current returns every known gate with its deployed value: from the Helm
+// release's values, or the controller's FEATURE_GATES for manifest installs.
+// Gates the version does not support are included with Supported false.
+func Current(r Release) ([]State, error) {
+	set, err := deployed(r)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]State, 0, len(Gates))
+	for _, g := range Gates {
+		st := State{Gate: g, Supported: g.Supports(r.Version), Enabled: g.Default(r.Version)}
+		if v, ok := set[g.Key]; ok {
+			st.Enabled, st.Explicit = v, true
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// deployed returns the gates set on the release, by chart key.
+func deployed(r Release) (map[string]bool, error) {
+	if r.Manifests {
+		return fromEnv(r)
+	}
+	args := []string{"get", "values", r.Name, "--namespace", r.Namespace, "--output", "json"}
+	if r.KubeCtx != "" {
+		args = append(args, "--kube-context", r.KubeCtx)
+	}
+	out, err := exec.Command("helm", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm get values: %w", err)
+	}
+	var vals struct {
+		Settings struct {
+			FeatureGates map[string]any `json:"featureGates"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal(out, &vals); err != nil {
+		return nil, fmt.Errorf("parse deployed values: %w", err)
+	}
+	set := map[string]bool{}
+	for k, v := range vals.Settings.FeatureGates {
+		if b, err := strconv.ParseBool(fmt.Sprint(v)); err == nil {
+			set[k] = b
+		}
+	}
+	return set, nil
+}
+
+// fromEnv parses FEATURE_GATES ("Drift=true,NodeRepair=false") from the
+// controller Deployment.
+func fromEnv(r Release) (map[string]bool, error) {
+	dep := r.Deployment
+	if dep == "" {
+		dep = r.Name
+	}
+	args := []string{"get", "deployment", dep, "--namespace", r.Namespace,
+		"-o", `jsonpath={.spec.template.spec.containers[*].env[?(@.name=="FEATURE_GATES")].value}`}
+	if r.KubeCtx != "" {
+		args = append(args, "--context", r.KubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("get Deployment %s/%s: %w", r.Namespace, dep, err)
+	}
+	set := map[string]bool{}
+	for _, kv := range strings.Split(strings.TrimSpace(string(out)), ",") {
+		name, val, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		g, known := Lookup(strings.TrimSpace(name))
+		b, err := strconv.ParseBool(strings.TrimSpace(val))
+		if known && err == nil {
+			set[g.Key] = b
+		}
+	}
+	return set, nil
+}
+
+// Parse reads NAME=true|false arguments and validates each against version.
+func Parse(args []string, version string) (map[Gate]bool, error) {
+	out := map[Gate]bool{}
+	for _, a := range args {
+		name, val, ok := strings.Cut(a, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want NAME=true or NAME=false", a)
+		}
+		g, known := Lookup(name)
+		if !known {
+			return nil, fmt.Errorf("unknown feature gate %q (known: %s)", name, names())
+		}
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not true or false", g.Name, val)
+		}
+		if err := g.Check(version); err != nil {
+			return nil, err
+		}
+		out[g] = b
+	}
+	return out, nil
+}
+
+func names() string {
+	var n []string
+	for _, g := range Gates {
+		n = append(n, g.Name)
+	}
+	return strings.Join(n, ", ")
+}
+
+// Settable returns why Set cannot change r in place, or nil.
+func (r Release) Settable() error {
+	if r.Manifests {
+		return fmt.Errorf("Karpenter was installed as manifests; re-render it with --set settings.featureGates.<gate>=<value>")
+	}
+	return nil
+}
+
+// Set upgrades the Helm release in place at its installed version, changing
+// only the given gates (--reuse-values keeps everything else).
+func Set(r Release, chart string, gates map[Gate]bool) error {
+	if err := r.Settable(); err != nil {
+		return err
+	}
+	args := []string{"upgrade", r.Name, chart,
+		"--namespace", r.Namespace,
+		"--version", strings.TrimPrefix(r.Version, "v"),
+		"--reuse-values",
+		"--wait", "--timeout", "5m",
+	}
+	keys := make([]string, 0, len(gates))
+	for g, v := range gates {
+		keys = append(keys, fmt.Sprintf("settings.featureGates.%s=%t", g.Key, v))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--set", k)
+	}
+	if r.KubeCtx != "" {
+		args = append(args, "--kube-context", r.KubeCtx)
+	}
+	out, err := exec.Command("helm", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("helm upgrade: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package fleet reads a declarative list of clusters — fleet.yaml — and
+// compares each cluster's Karpenter installation with the version the file
+// says it should run. It backs `karpx fleet status|detect|upgrade`.
+//
+// The file is ./fleet.yaml, the path in $KARPX_FLEET, or -f:
+//
+//	clusters:
+//	  - name: dev-eu             # display name (default: the context)
+//	    context: dev-eu
+//	    provider: aws            # optional; detected when omitted
+//	    environment: dev
+//	    channel: latest          # newest release compatible with the cluster
+//	  - context: staging-eu
+//	    environment: staging
+//	    channel: "1.2"           # newest patch of a minor line
+//	  - context: prod-eu
+//	    environment: prod
+//	    version: "1.1.3"         # exact version
+//
+// An optional rollout section stages `fleet upgrade` by environment; see
+// Rollout.
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kemilad/karpx/internal/compat"
+	"github.com/kemilad/karpx/internal/status"
+)
+
+// DefaultPath is the fleet file read when neither -f nor $KARPX_FLEET is set.
+const DefaultPath = "fleet.yaml"
+
+// File is the contents of fleet.yaml.
+type File struct {
+	Clusters []Cluster `json:"clusters"`
+	Rollout  *Rollout  `json:"rollout,omitempty"`
+}
+
+// Cluster is one fleet member and the version it is meant to run.
+type Cluster struct {
+	Name        string `json:"name,omitempty"`
+	Context     string `json:"context"`
+	Provider    string `json:"provider,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Version     string `json:"version,omitempty"` // exact desired version
+	Channel     string `json:"channel,omitempty"` // "latest" or a minor line, e.g. "1.2"
+}
+
+// DisplayName returns Name, or the context when no name is set.
+func (c Cluster) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Context
+}
+
+// Path returns the fleet file location: explicit, $KARPX_FLEET, or
+// DefaultPath.
+func Path(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if p := os.Getenv("KARPX_FLEET"); p != "" {
+		return p
+	}
+	return DefaultPath
+}
+
+// Load reads and validates the fleet file at path.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("fleet file %s not found (set -f or $KARPX_FLEET)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
+
+func (f *File) validate() error {
+	if len(f.Clusters) == 0 {
+		return fmt.Errorf("no clusters listed")
+	}
+	seen := map[string]bool{}
+	for i, c := range f.Clusters {
+		where := fmt.Sprintf("clusters[%d]", i)
+		if c.Context == "" {
+			return fmt.Errorf("%s: context is required", where)
+		}
+		if seen[c.Context] {
+			return fmt.Errorf("%s: context %q listed twice", where, c.Context)
+		}
+		seen[c.Context] = true
+		if c.Version != "" && c.Channel != "" {
+			return fmt.Errorf("%s (%s): set version or channel, not both", where, c.Context)
+		}
+		switch c.Provider {
+		case "", "aws", "azure", "gcp":
+		default:
+			return fmt.Errorf("%s (%s): provider must be aws, azure or gcp, got %q", where, c.Context, c.Provider)
+		}
+	}
+	if f.Rollout != nil {
+		return f.Rollout.validate()
+	}
+	return nil
+}
+
+// Select returns the clusters in environment env, or all of them when env
+// is "".
+func (f *File) Select(env string) []Cluster {
+	if env == "" {
+		return f.Clusters
+	}
+	var out []Cluster
+	for _, c := range f.Clusters {
+		if c.Environment == env {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// State summarises how a cluster compares with its fleet entry.
+type State string
+
+const (
+	StateOK           State = "ok"            // runs the desired version
+	StateBehind       State = "behind"        // an upgrade to Desired is due
+	StateAhead        State = "ahead"         // runs a newer version than desired
+	StateNotInstalled State = "not-installed" // Karpenter is missing
+	StateUnknown      State = "unknown"       // installed version or desired version unknown
+	StateUnreachable  State = "unreachable"   // detection failed
+)
+
+// Result is the evaluation of one fleet member.
+type Result struct {
+	Cluster Cluster        `json:"cluster"`
+	Status  status.Cluster `json:"status"`
+	Desired string         `json:"desired,omitempty"`
+	State   State          `json:"state"`
+	Note    string         `json:"note,omitempty"`
+}
+
+// Evaluate inspects every cluster concurrently and resolves its desired
+// version. Results keep the input order.
+func Evaluate(clusters []Cluster) []Result {
+	contexts := make([]string, len(clusters))
+	for i, c := range clusters {
+		contexts[i] = c.Context
+	}
+	statuses := status.Check(contexts)
+
+	// One release listing serves every channel in the fleet.
+	var available []string
+	var fetchErr error
+	for _, c := range clusters {
+		if c.Version == "" {
+			available, fetchErr = compat.FetchAvailableVersions()
+			break
+		}
+	}
+
+	results := make([]Result, len(clusters))
+	for i, c := range clusters {
+		st := statuses[i]
+		r := Result{Cluster: c, Status: st}
+		if c.Provider != "" && st.Provider != "" && st.Provider != "unknown" && st.Provider != c.Provider {
+			r.Note = fmt.Sprintf("fleet file says %s, detected %s", c.Provider, st.Provider)
+		}
+		r.Desired, r.State = resolve(c, st, available, fetchErr, &r.Note)
+		results[i] = r
+	}
+	return results
+}
+
+// resolve works out the desired version and the cluster's state.
+func resolve(c Cluster, st status.Cluster, available []string, fetchErr error, note *string) (string, State) {
+	if st.Error != "" {
+		return "", StateUnreachable
+	}
+
+	desired := strings.TrimPrefix(c.Version, "v")
+	if desired == "" {
+		if fetchErr != nil {
+			addNote(note, "could not list releases: "+fetchErr.Error())
+			return "", StateUnknown
+		}
+		compatible := compat.FilterCompatible(st.K8sVersion, available)
+		switch channel := strings.TrimPrefix(c.Channel, "v"); channel {
+		case "", "latest":
+			if len(compatible) > 0 {
+				desired = compatible[0]
+			}
+		default:
+			desired = compat.LatestInLine(compatible, channel)
+		}
+		if desired == "" {
+			addNote(note, fmt.Sprintf("no release compatible with Kubernetes %s on channel %q", st.K8sVersion, c.Channel))
+			return "", StateUnknown
+		}
+	}
+
+	switch {
+	case !st.KarpenterInstalled:
+		return desired, StateNotInstalled
+	case st.KarpenterVersion == "":
+		return desired, StateUnknown
+	case st.KarpenterVersion == desired:
+		return desired, StateOK
+	case compat.Newer(st.KarpenterVersion, desired):
+		return desired, StateAhead
+	}
+	return desired, StateBehind
+}
+
+func addNote(note *string, s string) {
+	if *note != "" {
+		*note += "; "
+	}
+	*note += s
+}
@@ -0,0 +1,207 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Rollout orders fleet upgrades into stages. Each stage's clusters are
+// upgraded, then watched for the bake time; a failed upgrade or a
+// regression signal halts the rollout before the next stage starts.
+//
+//	rollout:
+//	  stages:
+//	    - name: dev
+//	      environments: [dev]
+//	      bake: 30m
+//	    - name: staging
+//	      environments: [staging]
+//	      bake: 2h
+//	    - name: prod
+//	      environments: [prod]
+//	  signals:
+//	    maxControllerRestarts: 0   # new restarts tolerated per cluster
+//	    maxProvisioningErrors: 3   # new Warning events on NodeClaims/NodePools
+type Rollout struct {
+	Stages  []Stage `json:"stages"`
+	Signals Signals `json:"signals"`
+}
+
+// Stage is one step of a rollout, selecting clusters by environment.
+type Stage struct {
+	Name         string   `json:"name"`
+	Environments []string `json:"environments"`
+	Bake         Duration `json:"bake,omitempty"`
+}
+
+// Signals are the regression thresholds checked while a stage bakes.
+type Signals struct {
+	MaxControllerRestarts int `json:"maxControllerRestarts"`
+	MaxProvisioningErrors int `json:"maxProvisioningErrors"`
+}
+
+// Duration is a time.Duration written as "30m" or "2h" in the file.
+type Duration struct{ time.Duration }
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) { return json.Marshal(d.String()) }
+
+func (r *Rollout) validate() error {
+	if len(r.Stages) == 0 {
+		return fmt.Errorf("rollout: no stages")
+	}
+	owner := map[string]string{}
+	for i, s := range r.Stages {
+		if s.Name == "" {
+			return fmt.Errorf("rollout.stages[%d]: name is required", i)
+		}
+		if len(s.Environments) == 0 {
+			return fmt.Errorf("rollout.stages[%d] (%s): environments is required", i, s.Name)
+		}
+		for _, e := range s.Environments {
+			if prev, ok := owner[e]; ok {
+				return fmt.Errorf("rollout: environment %q is in stages %s and %s", e, prev, s.Name)
+			}
+			owner[e] = s.Name
+		}
+	}
+	return nil
+}
+
+// StagePlan is a stage with the results due for upgrade in it.
+type StagePlan struct {
+	Stage    Stage
+	Clusters []Result
+}
+
+// Assign splits due results into the rollout's stages, in stage order.
+// Results whose environment is in no stage are returned as unassigned.
+func (r *Rollout) Assign(due []Result) (plans []StagePlan, unassigned []Result) {
+	stageOf := map[string]int{}
+	plans = make([]StagePlan, len(r.Stages))
+	for i, s := range r.Stages {
+		plans[i].Stage = s
+		for _, e := range s.Environments {
+			stageOf[e] = i
+		}
+	}
+	for _, res := range due {
+		i, ok := stageOf[res.Cluster.Environment]
+		if !ok {
+			unassigned = append(unassigned, res)
+			continue
+		}
+		plans[i].Clusters = append(plans[i].Clusters, res)
+	}
+	return plans, unassigned
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Regression signals
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Health is a point-in-time sample of a cluster's regression signals.
+type Health struct {
+	At       time.Time
+	Restarts int // summed container restarts of the Karpenter controller pods
+}
+
+// SampleHealth reads the controller's restart count. Take the baseline
+// after the upgrade: the rollout replaces the pods, resetting their counts.
+func SampleHealth(kubeCtx, namespace string) (Health, error) {
+	h := Health{At: time.Now()}
+	args := []string{"get", "pods", "-n", namespace,
+		"-l", "app.kubernetes.io/name=karpenter",
+		"-o", `jsonpath={range .items[*].status.containerStatuses[*]}{.restartCount}{"\n"}{end}`}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return h, fmt.Errorf("read controller pods: %w", err)
+	}
+	for _, line := range strings.Fields(string(out)) {
+		var n int
+		fmt.Sscan(line, &n)
+		h.Restarts += n
+	}
+	return h, nil
+}
+
+// provisioningKinds are the objects Karpenter reports launch and
+// registration failures on.
+var provisioningKinds = map[string]bool{
+	"NodeClaim": true, "NodePool": true,
+	"EC2NodeClass": true, "AKSNodeClass": true, "GCENodeClass": true,
+}
+
+// provisioningErrors counts Warning events on Karpenter objects since t.
+func provisioningErrors(kubeCtx string, since time.Time) (int, error) {
+	args := []string{"get", "events", "-A", "--field-selector", "type=Warning", "-o", "json"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("read events: %w", err)
+	}
+	var list struct {
+		Items []struct {
+			LastTimestamp  time.Time `json:"lastTimestamp"`
+			EventTime      time.Time `json:"eventTime"`
+			InvolvedObject struct {
+				Kind string `json:"kind"`
+			} `json:"involvedObject"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return 0, fmt.Errorf("parse events: %w", err)
+	}
+	n := 0
+	for _, ev := range list.Items {
+		t := ev.LastTimestamp
+		if t.IsZero() {
+			t = ev.EventTime
+		}
+		if provisioningKinds[ev.InvolvedObject.Kind] && t.After(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Regressions compares a cluster with its post-upgrade baseline and
+// returns one line per signal over its threshold.
+func Regressions(kubeCtx, namespace string, baseline Health, s Signals) ([]string, error) {
+	now, err := SampleHealth(kubeCtx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	if d := now.Restarts - baseline.Restarts; d > s.MaxControllerRestarts {
+		out = append(out, fmt.Sprintf("controller restarted %d time(s) since the upgrade (limit %d)", d, s.MaxControllerRestarts))
+	}
+	n, err := provisioningErrors(kubeCtx, baseline.At)
+	if err != nil {
+		return out, err
+	}
+	if n > s.MaxProvisioningErrors {
+		out = append(out, fmt.Sprintf("%d provisioning error event(s) since the upgrade (limit %d)", n, s.MaxProvisioningErrors))
+	}
+	return out, nil
+}
@@ -0,0 +1,264 @@
+// Package gcp automates the Karpenter provider setup on GKE through gcloud:
+// the controller's Google service account, its IAM roles, and the Workload
+// Identity binding to the controller's Kubernetes service account.
+//
+// Like internal/awscli and internal/azure, it shells out to `gcloud` so the
+// user's existing auth and configuration apply.
+package gcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// DefaultServiceAccount is the Google service account created for the controller.
+	DefaultServiceAccount = "karpenter"
+	// KubeServiceAccount is the controller's Kubernetes service account. It is
+	// set explicitly on the chart so the Workload Identity binding matches.
+	KubeServiceAccount = "karpenter"
+)
+
+// controllerRoles are granted to the controller's Google service account at
+// project level, matching the karpenter-provider-gcp getting-started guide.
+var controllerRoles = []string{
+	"roles/compute.admin",
+	"roles/container.admin",
+	"roles/iam.serviceAccountUser",
+}
+
+// Cluster is the subset of `gcloud container clusters describe` the install
+// flow needs.
+type Cluster struct {
+	Name                 string `json:"name"`
+	Location             string `json:"location"`
+	Endpoint             string `json:"endpoint"`
+	Network              string `json:"network"`
+	Subnetwork           string `json:"subnetwork"`
+	CurrentMasterVersion string `json:"currentMasterVersion"`
+
+	WorkloadIdentityConfig *struct {
+		WorkloadPool string `json:"workloadPool"`
+	} `json:"workloadIdentityConfig"`
+	NodePools []struct {
+		Name   string `json:"name"`
+		Config struct {
+			WorkloadMetadataConfig *struct {
+				Mode string `json:"mode"`
+			} `json:"workloadMetadataConfig"`
+		} `json:"config"`
+	} `json:"nodePools"`
+
+	Project string `json:"-"`
+}
+
+// WorkloadPool returns the cluster's Workload Identity pool, or "" when
+// Workload Identity is disabled.
+func (c *Cluster) WorkloadPool() string {
+	if c.WorkloadIdentityConfig == nil {
+		return ""
+	}
+	return c.WorkloadIdentityConfig.WorkloadPool
+}
+
+// PoolsWithoutMetadataServer lists node pools that do not run the GKE metadata
+// server — pods scheduled there cannot use Workload Identity, including the
+// Karpenter controller itself.
+func (c *Cluster) PoolsWithoutMetadataServer() []string {
+	var out []string
+	for _, p := range c.NodePools {
+		if p.Config.WorkloadMetadataConfig == nil || p.Config.WorkloadMetadataConfig.Mode != "GKE_METADATA" {
+			out = append(out, p.Name)
+		}
+	}
+	return out
+}
+
+// ParseContext splits a gcloud-generated kubeconfig context name.
+// "gke_<project>_<location>_<cluster>" → project, location, cluster.
+func ParseContext(kubeCtx string) (project, location, cluster string, ok bool) {
+	parts := strings.SplitN(kubeCtx, "_", 4)
+	if len(parts) != 4 || parts[0] != "gke" {
+		return "", "", "", false
+	}
+	return parts[1], parts[2], parts[3], true
+}
+
+// Available reports whether the gcloud binary is on PATH.
+func Available() bool {
+	_, err := exec.LookPath("gcloud")
+	return err == nil
+}
+
+// DefaultProject returns the project configured in gcloud, or "".
+func DefaultProject() string {
+	out, err := exec.Command("gcloud", "config", "get-value", "project").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// DescribeCluster fetches a GKE cluster.
+func DescribeCluster(project, location, name string) (*Cluster, error) {
+	if !Available() {
+		return nil, fmt.Errorf("gcloud CLI not found — install it from https://cloud.google.com/sdk/docs/install")
+	}
+	var c Cluster
+	if err := gcloudJSON(&c, "container", "clusters", "describe", name,
+		"--location", location, "--project", project); err != nil {
+		return nil, err
+	}
+	c.Project = project
+	return &c, nil
+}
+
+// Projects returns the IDs of every active project the account can see.
+func Projects() ([]string, error) {
+	var projects []struct {
+		ProjectID      string `json:"projectId"`
+		LifecycleState string `json:"lifecycleState"`
+	}
+	if err := gcloudJSON(&projects, "projects", "list"); err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, p := range projects {
+		if p.LifecycleState == "" || p.LifecycleState == "ACTIVE" {
+			out = append(out, p.ProjectID)
+		}
+	}
+	return out, nil
+}
+
+// ListClusters returns every GKE cluster in project, across all locations.
+func ListClusters(project string) ([]Cluster, error) {
+	var list []Cluster
+	if err := gcloudJSON(&list, "container", "clusters", "list", "--project", project); err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Project = project
+	}
+	return list, nil
+}
+
+// GetCredentials merges the cluster into the local kubeconfig
+// (gcloud container clusters get-credentials).
+func GetCredentials(c *Cluster) error {
+	return gcloudRun("container", "clusters", "get-credentials", c.Name,
+		"--location", c.Location, "--project", c.Project)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Setup steps
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ServiceAccountEmail returns the email of a service account in project.
+func ServiceAccountEmail(project, name string) string {
+	return name + "@" + project + ".iam.gserviceaccount.com"
+}
+
+// EnableWorkloadIdentity sets the cluster's workload pool to
+// <project>.svc.id.goog. It is a no-op when a pool is already configured.
+func EnableWorkloadIdentity(c *Cluster) error {
+	if c.WorkloadPool() != "" {
+		return nil
+	}
+	pool := c.Project + ".svc.id.goog"
+	if err := gcloudRun("container", "clusters", "update", c.Name,
+		"--location", c.Location, "--project", c.Project,
+		"--workload-pool", pool); err != nil {
+		return err
+	}
+	c.WorkloadIdentityConfig = &struct {
+		WorkloadPool string `json:"workloadPool"`
+	}{pool}
+	return nil
+}
+
+// EnsureServiceAccount creates the controller's Google service account unless
+// it already exists, and returns its email.
+func EnsureServiceAccount(project, name string) (string, error) {
+	email := ServiceAccountEmail(project, name)
+	if err := gcloudRun("iam", "service-accounts", "describe", email, "--project", project); err == nil {
+		return email, nil
+	}
+	if err := gcloudRun("iam", "service-accounts", "create", name,
+		"--project", project,
+		"--display-name", "Karpenter controller"); err != nil {
+		return "", err
+	}
+	return email, nil
+}
+
+// GrantRoles binds the controller roles to the service account at project level.
+// Re-adding an existing binding is a no-op in IAM, so this is safe to repeat.
+func GrantRoles(project, email string) error {
+	for _, role := range controllerRoles {
+		if err := gcloudRun("projects", "add-iam-policy-binding", project,
+			"--member", "serviceAccount:"+email,
+			"--role", role,
+			"--condition", "None"); err != nil {
+			return fmt.Errorf("grant %s: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// BindWorkloadIdentity lets the Kubernetes service account namespace/ksa
+// impersonate the Google service account.
+func BindWorkloadIdentity(c *Cluster, email, namespace, ksa string) error {
+	return gcloudRun("iam", "service-accounts", "add-iam-policy-binding", email,
+		"--project", c.Project,
+		"--role", "roles/iam.workloadIdentityUser",
+		"--member", "serviceAccount:"+c.WorkloadPool()+"["+namespace+"/"+ksa+"]")
+}
+
+// HelmSetArgs returns the --set flags for the provider chart.
+func HelmSetArgs(c *Cluster, email string) []string {
+	return []string{
+		"--set", "serviceAccount.name=" + KubeServiceAccount,
+		"--set", "controller.settings.projectID=" + c.Project,
+		"--set", "controller.settings.location=" + c.Location,
+		"--set", "controller.settings.clusterName=" + c.Name,
+		"--set", "credentials.enabled=false",
+		"--set", "serviceAccount.annotations.iam\\.gke\\.io/gcp-service-account=" + email,
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CLI helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+func gcloudJSON(v any, args ...string) error {
+	args = append(args, "--format", "json")
+	out, err := exec.Command("gcloud", args...).Output()
+	if err != nil {
+		return fmt.Errorf("gcloud %s: %w", strings.Join(args[:min(3, len(args))], " "), stderrOf(err))
+	}
+	if err := json.Unmarshal(out, v); err != nil {
+		return fmt.Errorf("parse gcloud %s output: %w", strings.Join(args[:min(3, len(args))], " "), err)
+	}
+	return nil
+}
+
+func gcloudRun(args ...string) error {
+	args = append(args, "--quiet")
+	out, err := exec.Command("gcloud", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gcloud %s: %w: %s", strings.Join(args[:min(3, len(args))], " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func stderrOf(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
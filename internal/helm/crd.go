@@ -0,0 +1,143 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CRDVersionAnnotation is set by karpx on the CRDs it applies, so their
+// version is known without a karpenter-crd release.
+const CRDVersionAnnotation = "karpx.io/crd-version"
+
+// CRDInfo describes the Karpenter CRDs installed on a cluster.
+type CRDInfo struct {
+	Installed  bool
+	Version    string // "" when only the API version could be read
+	Source     string // where Version came from
+	Release    string // karpenter-crd Helm release; "" when the CRDs are not managed by one
+	Namespace  string
+	APIVersion string // storage version of the NodePool (or Provisioner) CRD, e.g. "v1"
+}
+
+// DetectCRDs finds the Karpenter CRD version: from a karpenter-crd Helm
+// release, the record of a karpx manifest install, or the annotation karpx
+// sets when it applies CRDs, in that order.
+func DetectCRDs(kubeCtx string) (*CRDInfo, error) {
+	info := &CRDInfo{}
+	for _, name := range []string{"nodepools.karpenter.sh", "provisioners.karpenter.sh"} {
+		crd, err := getCRD(kubeCtx, name)
+		if err != nil {
+			return nil, err
+		}
+		if crd == nil {
+			continue
+		}
+		info.Installed = true
+		info.APIVersion = crd.storageVersion()
+		if v := crd.Metadata.Annotations[CRDVersionAnnotation]; v != "" {
+			info.Version, info.Source = strings.TrimPrefix(v, "v"), CRDVersionAnnotation+" annotation"
+		}
+		break
+	}
+	if !info.Installed {
+		return info, nil
+	}
+
+	if rel, err := RecordedStatic(kubeCtx); err == nil && rel != nil {
+		info.Version, info.Source = strings.TrimPrefix(rel.Version, "v"), ReleaseConfigMap
+	}
+	args := []string{"list", "--all-namespaces", "--output", "json"}
+	if kubeCtx != "" {
+		args = append(args, "--kube-context", kubeCtx)
+	}
+	if out, err := exec.Command("helm", args...).Output(); err == nil {
+		var releases []helmRelease
+		if json.Unmarshal(out, &releases) == nil {
+			for _, r := range releases {
+				if v, ok := strings.CutPrefix(r.Chart, "karpenter-crd-"); ok {
+					info.Version, info.Source = strings.TrimPrefix(v, "v"), "karpenter-crd chart"
+					info.Release, info.Namespace = r.Name, r.Namespace
+					break
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// CRDMismatch describes how the CRDs diverge from controller version, or
+// returns "" when they match or either version is unknown. The API version
+// alone still catches a v1 controller running on v1beta1 CRDs.
+func CRDMismatch(crd *CRDInfo, controller string) string {
+	controller = strings.TrimPrefix(controller, "v")
+	if crd == nil || !crd.Installed || controller == "" {
+		return ""
+	}
+	if crd.Version == "" {
+		major, _, _ := strings.Cut(controller, ".")
+		if major != "0" && crd.APIVersion != "" && crd.APIVersion != "v1" {
+			return fmt.Sprintf("controller v%s needs the v1 APIs, but the CRDs store %s", controller, crd.APIVersion)
+		}
+		return ""
+	}
+	if crd.Version == controller {
+		return ""
+	}
+	return fmt.Sprintf("CRDs are v%s (%s), controller is v%s", crd.Version, crd.Source, controller)
+}
+
+// UpgradeCRDChart upgrades the karpenter-crd release to the chart archive at
+// chartPath, keeping it in lockstep with the controller.
+func UpgradeCRDChart(kubeCtx string, crd *CRDInfo, chartPath string) error {
+	args := []string{"upgrade", crd.Release, chartPath, "--namespace", crd.Namespace, "--reuse-values"}
+	if kubeCtx != "" {
+		args = append(args, "--kube-context", kubeCtx)
+	}
+	if out, err := exec.Command("helm", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("helm upgrade %s: %w\n%s", crd.Release, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+type crdObject struct {
+	Metadata struct {
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Versions []struct {
+			Name    string `json:"name"`
+			Storage bool   `json:"storage"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+func (c *crdObject) storageVersion() string {
+	for _, v := range c.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// getCRD returns the named CRD, or nil when it does not exist.
+func getCRD(kubeCtx, name string) (*crdObject, error) {
+	args := []string{"get", "crd", name, "--ignore-not-found", "-o", "json"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl get crd %s: %w", name, err)
+	}
+	if len(strings.TrimSpace(string(out))) == 0 {
+		return nil, nil
+	}
+	var crd crdObject
+	if err := json.Unmarshal(out, &crd); err != nil {
+		return nil, fmt.Errorf("parse crd %s: %w", name, err)
+	}
+	return &crd, nil
+}
@@ -22,6 +22,25 @@ type Info struct {
 	Version     string // Karpenter app version, e.g. "1.2.1"
 	Namespace   string
 	Chart       string
+	Manifests   bool   // installed by karpx as plain manifests (see ReleaseConfigMap)
+	Managed     string // cloud-managed variant (ManagedEKSAutoMode, ManagedAKSNAP); "" when self-managed
+}
+
+// Cloud-managed Karpenter variants. The controller runs in the provider's
+// control plane: there is no release or Deployment, and the provider installs
+// and upgrades it.
+const (
+	ManagedEKSAutoMode = "EKS Auto Mode"
+	ManagedAKSNAP      = "AKS Node Auto Provisioning"
+)
+
+// ManagedError returns an error for commands that would install, upgrade or
+// remove a cloud-managed Karpenter, or nil when it is self-managed.
+func (i *Info) ManagedError() error {
+	if i == nil || i.Managed == "" {
+		return nil
+	}
+	return fmt.Errorf("Karpenter is managed by %s — the cloud provider installs and upgrades it; karpx is read-only here (NodePools: karpx nodepools)", i.Managed)
 }
 
 type helmRelease struct {
@@ -49,13 +68,13 @@ func DetectKarpenter(kubeCtx string) (*Info, error) {
 		// helm is not available or the invocation failed — fall back to the
 		// Kubernetes API so we still detect Karpenter installed via manifests
 		// or when helm is broken/absent.
-		return detectViaKubeAPI(kubeCtx)
+		return detectWithoutHelm(kubeCtx)
 	}
 
 	var releases []helmRelease
 	if err := json.Unmarshal(out, &releases); err != nil {
 		// Malformed output from helm — try API fallback before giving up.
-		return detectViaKubeAPI(kubeCtx)
+		return detectWithoutHelm(kubeCtx)
 	}
 
 	for _, r := range releases {
@@ -73,14 +92,33 @@ func DetectKarpenter(kubeCtx string) (*Info, error) {
 	// Helm didn't find Karpenter — fall back to Kubernetes API detection.
 	// This covers clusters where Karpenter was installed outside of Helm
 	// (raw manifests, older tooling, operators, etc.).
+	return detectWithoutHelm(kubeCtx)
+}
+
+// detectWithoutHelm reads the record of a karpx manifest install, which
+// knows the release name and exact version, before falling back to the API.
+func detectWithoutHelm(kubeCtx string) (*Info, error) {
+	if rel, err := RecordedStatic(kubeCtx); err == nil && rel != nil {
+		return &Info{
+			Installed:   true,
+			ReleaseName: rel.Name,
+			Version:     rel.Version,
+			Namespace:   rel.Namespace,
+			Manifests:   true,
+		}, nil
+	}
 	return detectViaKubeAPI(kubeCtx)
 }
 
 // isKarpenterRelease returns true when the Helm release name or chart name
 // looks like Karpenter (covers both upstream and Karpenter provider variants).
+// The karpenter-crd release only holds the CRDs (see DetectCRDs).
 func isKarpenterRelease(r helmRelease) bool {
 	name := strings.ToLower(r.Name)
 	chart := strings.ToLower(r.Chart)
+	if strings.HasPrefix(chart, "karpenter-crd-") {
+		return false
+	}
 	return strings.Contains(name, "karpenter") || strings.Contains(chart, "karpenter")
 }
 
@@ -132,8 +170,9 @@ func detectViaKubeAPI(kubeCtx string) (*Info, error) {
 		})
 	}
 	if len(deps.Items) == 0 {
-		// CRDs present but no standard deployment found; still installed.
-		return &Info{Installed: true}, nil
+		// CRDs present but no controller in the cluster: either a managed
+		// variant running in the control plane, or an unusual install.
+		return &Info{Installed: true, Managed: managedVariant(cs, groups.Groups)}, nil
 	}
 
 	dep := deps.Items[0]
@@ -162,6 +201,31 @@ func detectViaKubeAPI(kubeCtx string) (*Info, error) {
 	}, nil
 }
 
+// managedVariant recognises EKS Auto Mode by its built-in NodeClass API
+// (eks.amazonaws.com) and AKS Node Auto Provisioning by the AKSNodeClass API
+// with no controller in the cluster.
+func managedVariant(cs *kubernetes.Clientset, groups []metav1.APIGroup) string {
+	for _, g := range groups {
+		switch g.Name {
+		case "eks.amazonaws.com":
+			for _, v := range g.Versions {
+				res, err := cs.Discovery().ServerResourcesForGroupVersion(v.GroupVersion)
+				if err != nil {
+					continue
+				}
+				for _, r := range res.APIResources {
+					if r.Name == "nodeclasses" {
+						return ManagedEKSAutoMode
+					}
+				}
+			}
+		case "karpenter.azure.com":
+			return ManagedAKSNAP
+		}
+	}
+	return ""
+}
+
 // imageTagVersion extracts a semver-like version string from a container image
 // reference. E.g. "public.ecr.aws/karpenter/controller:v1.2.1" → "1.2.1".
 // Returns empty string for non-version tags such as git SHAs or "latest".
@@ -0,0 +1,83 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// HelmfileRelease is one release of an exported helmfile.yaml.
+type HelmfileRelease struct {
+	Name      string
+	Namespace string
+	Chart     string // repository/chart, or an oci:// reference
+	Version   string
+	Values    map[string]any
+}
+
+type helmfileRepo struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	OCI  bool   `json:"oci,omitempty"`
+}
+
+type helmfileRelease struct {
+	Name            string           `json:"name"`
+	Namespace       string           `json:"namespace"`
+	CreateNamespace bool             `json:"createNamespace"`
+	Chart           string           `json:"chart"`
+	Version         string           `json:"version,omitempty"`
+	Values          []map[string]any `json:"values,omitempty"`
+}
+
+// Helmfile renders releases as a helmfile.yaml. helmfile does not take
+// oci:// chart references, so each OCI registry becomes an entry under
+// repositories with oci: true and the release refers to it by name. header
+// is written as a leading comment.
+func Helmfile(header string, releases ...HelmfileRelease) ([]byte, error) {
+	var doc struct {
+		Repositories []helmfileRepo    `json:"repositories,omitempty"`
+		Releases     []helmfileRelease `json:"releases"`
+	}
+	repoNames := map[string]string{} // registry path → repository name
+	for _, r := range releases {
+		chart := r.Chart
+		if ref, ok := strings.CutPrefix(chart, "oci://"); ok {
+			url, name := path.Split(ref)
+			url = strings.TrimSuffix(url, "/")
+			repo, seen := repoNames[url]
+			if !seen {
+				repo = path.Base(url)
+				repoNames[url] = repo
+				doc.Repositories = append(doc.Repositories, helmfileRepo{Name: repo, URL: url, OCI: true})
+			}
+			chart = repo + "/" + name
+		}
+		hr := helmfileRelease{
+			Name:            r.Name,
+			Namespace:       r.Namespace,
+			CreateNamespace: true,
+			Chart:           chart,
+			Version:         strings.TrimPrefix(r.Version, "v"),
+		}
+		if len(r.Values) > 0 {
+			hr.Values = []map[string]any{r.Values}
+		}
+		doc.Releases = append(doc.Releases, hr)
+	}
+	body, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("render helmfile: %w", err)
+	}
+	var buf bytes.Buffer
+	for _, line := range strings.Split(strings.TrimSpace(header), "\n") {
+		if line != "" {
+			fmt.Fprintf(&buf, "# %s\n", line)
+		}
+	}
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,128 @@
+package helm
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Image is one container image a chart deploys by default.
+type Image struct {
+	Values     string `json:"values"` // values path of the image block, e.g. "controller.image"
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// Ref is the pull reference, pinned to the digest when the chart has one.
+func (i Image) Ref() string {
+	ref := i.Repository + ":" + i.Tag
+	if i.Digest != "" {
+		ref += "@" + i.Digest
+	}
+	return ref
+}
+
+// Artifact is a chart version and the images it pulls.
+type Artifact struct {
+	Chart   string  `json:"chart"`
+	Version string  `json:"version"`
+	Digest  string  `json:"digest,omitempty"` // of the chart itself
+	Images  []Image `json:"images"`
+}
+
+// Images pulls chart at version and lists the images in its default values:
+// every block with a repository and a tag or digest. Karpenter charts pin
+// the controller (which also serves the webhooks) by digest.
+func Images(chart, version string) (*Artifact, error) {
+	p, err := Pull(chart, version, "")
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+	out, err := exec.Command("helm", "show", "values", p.Path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm show values %s %s: %w", chart, version, err)
+	}
+	var vals map[string]any
+	if err := yaml.Unmarshal(out, &vals); err != nil {
+		return nil, fmt.Errorf("parse chart values %s %s: %w", chart, version, err)
+	}
+	a := &Artifact{Chart: chart, Version: strings.TrimPrefix(version, "v"), Digest: p.Digest}
+	collectImages(vals, "", a)
+	sort.Slice(a.Images, func(i, j int) bool { return a.Images[i].Values < a.Images[j].Values })
+	return a, nil
+}
+
+func collectImages(m map[string]any, path string, a *Artifact) {
+	repo, _ := m["repository"].(string)
+	tag, _ := m["tag"].(string)
+	digest, _ := m["digest"].(string)
+	if repo != "" && (tag != "" || digest != "") {
+		if tag == "" {
+			tag = a.Version // Karpenter charts version in lockstep with the app
+		}
+		a.Images = append(a.Images, Image{Values: path, Repository: repo, Tag: tag, Digest: digest})
+		return
+	}
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			collectImages(sub, p, a)
+		}
+	}
+}
+
+// ChartSource is the registry path a chart reference lives under:
+// "oci://public.ecr.aws/karpenter/karpenter" → "public.ecr.aws/karpenter".
+func ChartSource(chart string) string {
+	s := strings.TrimPrefix(chart, "oci://")
+	if i := strings.LastIndexByte(s, '/'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// MirrorRepository maps repo into mirror with the layout SetRegistry
+// expects: the part of repo below source is kept, so
+// public.ecr.aws/karpenter/controller becomes <mirror>/controller. Images
+// from other registries keep their path without the host.
+func MirrorRepository(repo, source, mirror string) string {
+	mirror = strings.TrimSuffix(strings.TrimPrefix(mirror, "oci://"), "/")
+	if rest, ok := strings.CutPrefix(repo, source+"/"); ok {
+		return mirror + "/" + rest
+	}
+	if i := strings.IndexByte(repo, '/'); i >= 0 && strings.ContainsAny(repo[:i], ".:") {
+		repo = repo[i+1:]
+	}
+	return mirror + "/" + repo
+}
+
+// MirrorValues is the values file pointing every image of a at mirror. The
+// digests stay, so the mirror must be filled with a digest-preserving copy.
+func MirrorValues(a *Artifact, mirror string) ([]byte, error) {
+	vals := map[string]any{}
+	for _, img := range a.Images {
+		node := vals
+		for _, k := range strings.Split(img.Values, ".") {
+			next, ok := node[k].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				node[k] = next
+			}
+			node = next
+		}
+		node["repository"] = MirrorRepository(img.Repository, ChartSource(a.Chart), mirror)
+		node["tag"] = img.Tag
+		if img.Digest != "" {
+			node["digest"] = img.Digest
+		}
+	}
+	return yaml.Marshal(vals)
+}
@@ -0,0 +1,163 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ProvenanceConfigMap records, in the Karpenter namespace, which chart
+// artifact karpx deployed last.
+const ProvenanceConfigMap = "karpx-chart-provenance"
+
+// Provenance identifies the exact chart artifact behind a release.
+type Provenance struct {
+	Chart      string    `json:"chart"`
+	Version    string    `json:"version"`
+	Digest     string    `json:"digest"` // sha256:… of the OCI manifest
+	DeployedAt time.Time `json:"deployedAt"`
+}
+
+// Pulled is a chart archive downloaded to a temporary directory. Installing
+// from Path instead of the registry reference guarantees the deployed chart
+// is the artifact whose Digest was checked.
+type Pulled struct {
+	Path   string
+	Digest string
+	dir    string
+}
+
+// Close removes the downloaded archive.
+func (p *Pulled) Close() { os.RemoveAll(p.dir) }
+
+var digestRe = regexp.MustCompile(`Digest:\s*(sha256:[0-9a-f]{64})`)
+
+// Pull downloads chart at version. When want is set (with or without the
+// "sha256:" prefix) the registry digest must match it exactly.
+func Pull(chart, version, want string) (*Pulled, error) {
+	dir, err := os.MkdirTemp("", "karpx-chart-*")
+	if err != nil {
+		return nil, err
+	}
+	out, err := exec.Command("helm", "pull", chart,
+		"--version", strings.TrimPrefix(version, "v"),
+		"--destination", dir,
+	).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("helm pull %s %s: %w\n%s", chart, version, err, strings.TrimSpace(string(out)))
+	}
+	p := &Pulled{dir: dir}
+	if m := digestRe.FindSubmatch(out); m != nil {
+		p.Digest = string(m[1])
+	}
+	archives, _ := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if len(archives) != 1 {
+		p.Close()
+		return nil, fmt.Errorf("helm pull %s %s: expected one chart archive, found %d", chart, version, len(archives))
+	}
+	p.Path = archives[0]
+
+	if want != "" {
+		if !strings.HasPrefix(want, "sha256:") {
+			want = "sha256:" + want
+		}
+		if p.Digest == "" {
+			p.Close()
+			return nil, fmt.Errorf("registry did not report a digest for %s %s — cannot verify --chart-digest", chart, version)
+		}
+		if p.Digest != want {
+			p.Close()
+			return nil, fmt.Errorf("chart digest mismatch for %s %s: registry has %s, expected %s", chart, version, p.Digest, want)
+		}
+	}
+	return p, nil
+}
+
+// RecordProvenance stores p in the provenance ConfigMap of namespace.
+func RecordProvenance(kubeCtx, namespace string, p Provenance) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	cm := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      ProvenanceConfigMap,
+			"namespace": namespace,
+			"labels": map[string]string{
+				"app.kubernetes.io/managed-by": "karpx",
+			},
+		},
+		"data": map[string]string{"provenance": string(data)},
+	}
+	body, err := json.Marshal(cm)
+	if err != nil {
+		return err
+	}
+	args := []string{"apply", "-f", "-"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin = bytes.NewReader(body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("record chart provenance: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// RecordedProvenance returns the provenance karpx recorded in namespace, or
+// nil when there is none (installed by other means, or before karpx
+// recorded it).
+func RecordedProvenance(kubeCtx, namespace string) (*Provenance, error) {
+	args := []string{"get", "configmap", ProvenanceConfigMap, "-n", namespace,
+		"--ignore-not-found", "-o", "jsonpath={.data.provenance}"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("read chart provenance: %w", err)
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var p Provenance
+	if err := json.Unmarshal(out, &p); err != nil {
+		return nil, fmt.Errorf("parse chart provenance: %w", err)
+	}
+	return &p, nil
+}
+
+// VerifyProvenance checks a recorded provenance against the cluster and the
+// registry: the release must still run the recorded version, and the
+// registry must still serve the recorded digest for it. Each problem found
+// is returned as one line; none means the release is what karpx deployed.
+func VerifyProvenance(p *Provenance, installedVersion string) []string {
+	var problems []string
+	if installedVersion != "" && strings.TrimPrefix(installedVersion, "v") != strings.TrimPrefix(p.Version, "v") {
+		problems = append(problems, fmt.Sprintf("release runs v%s but karpx deployed v%s — it was changed outside karpx",
+			strings.TrimPrefix(installedVersion, "v"), strings.TrimPrefix(p.Version, "v")))
+	}
+	if p.Digest == "" {
+		return problems
+	}
+	pulled, err := Pull(p.Chart, p.Version, "")
+	if err != nil {
+		return append(problems, fmt.Sprintf("could not re-check the registry digest: %v", err))
+	}
+	defer pulled.Close()
+	if pulled.Digest != p.Digest {
+		problems = append(problems, fmt.Sprintf("registry now serves %s for v%s, karpx deployed %s — the tag was re-pushed",
+			pulled.Digest, strings.TrimPrefix(p.Version, "v"), p.Digest))
+	}
+	return problems
+}
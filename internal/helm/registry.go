@@ -0,0 +1,43 @@
+package helm
+
+import "strings"
+
+// PublicRegistry is where Karpenter publishes its AWS charts and images.
+const PublicRegistry = "public.ecr.aws/karpenter"
+
+var (
+	// KarpenterChart is the OCI chart karpx installs and upgrades on AWS.
+	KarpenterChart = "oci://" + PublicRegistry + "/karpenter"
+
+	// CRDChart is the chart that owns the Karpenter CRDs when they are
+	// managed apart from the controller release.
+	CRDChart = "oci://" + PublicRegistry + "/karpenter-crd"
+
+	registry = PublicRegistry
+)
+
+// SetRegistry points the charts and the controller image at a mirror of
+// public.ecr.aws/karpenter, e.g. a private ECR registry in a China region
+// where public ECR cannot be reached. "" restores the public registry.
+//
+// The mirror must keep the public layout (karpenter, karpenter-crd and
+// controller repositories) and the image digests, which the chart pins:
+// copy with `crane copy` or `skopeo copy --all`.
+func SetRegistry(r string) {
+	r = strings.TrimSuffix(strings.TrimPrefix(r, "oci://"), "/")
+	if r == "" {
+		r = PublicRegistry
+	}
+	registry = r
+	KarpenterChart = "oci://" + r + "/karpenter"
+	CRDChart = "oci://" + r + "/karpenter-crd"
+}
+
+// Registry returns the registry set with SetRegistry.
+func Registry() string { return registry }
+
+// Mirrored reports whether a mirror replaces the public registry.
+func Mirrored() bool { return registry != PublicRegistry }
+
+// ControllerImage is the controller image repository, without a tag.
+func ControllerImage() string { return registry + "/controller" }
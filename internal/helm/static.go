@@ -0,0 +1,298 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kemilad/karpx/internal/manifest"
+)
+
+// ReleaseConfigMap stands in for a Helm release when Karpenter was installed
+// as plain manifests (install --manifests). It lives in the Karpenter
+// namespace and records what was rendered, so detect and upgrade can treat
+// the install like a release.
+const ReleaseConfigMap = "karpx-release"
+
+// FieldManager is the server-side apply field manager of manifest installs.
+const FieldManager = "karpx"
+
+// StaticRelease is the record of a manifest install.
+type StaticRelease struct {
+	Name       string    `json:"name"`
+	Namespace  string    `json:"namespace"`
+	Chart      string    `json:"chart"`
+	Version    string    `json:"version"`
+	Digest     string    `json:"digest,omitempty"`
+	Set        []string  `json:"set,omitempty"`     // --set values the chart was rendered with
+	SetJSON    []string  `json:"setJson,omitempty"` // --set-json values, e.g. renamed keys carried over by upgrade
+	Objects    []string  `json:"objects"`           // kind/namespace/name of every applied object
+	DeployedAt time.Time `json:"deployedAt"`
+}
+
+// Render runs helm template for the chart archive at chartPath, CRDs
+// included, labels every object as owned by karpx and places namespaced
+// objects in namespace.
+func Render(chartPath, release, namespace string, set, setJSON []string) ([]manifest.Object, error) {
+	objs, err := Template(chartPath, release, namespace, set, setJSON)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objs {
+		meta := o["metadata"].(map[string]any)
+		labels, _ := meta["labels"].(map[string]any)
+		if labels == nil {
+			labels = map[string]any{}
+			meta["labels"] = labels
+		}
+		labels["app.kubernetes.io/managed-by"] = "karpx"
+		labels["app.kubernetes.io/instance"] = release
+	}
+	return objs, nil
+}
+
+// Template is Render without the karpx ownership labels: the objects as helm
+// install would create them.
+func Template(chartPath, release, namespace string, set, setJSON []string) ([]manifest.Object, error) {
+	args := []string{"template", release, chartPath, "--namespace", namespace, "--include-crds"}
+	for _, s := range set {
+		args = append(args, "--set", s)
+	}
+	for _, s := range setJSON {
+		args = append(args, "--set-json", s)
+	}
+	out, err := exec.Command("helm", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("helm template: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	objs, err := manifest.Decode(out)
+	if err != nil {
+		return nil, fmt.Errorf("parse rendered chart: %w", err)
+	}
+	for _, o := range objs {
+		meta, _ := o["metadata"].(map[string]any)
+		if meta == nil {
+			meta = map[string]any{}
+			o["metadata"] = meta
+		}
+		// helm template leaves namespace unset on most objects; kubectl
+		// would put them in the context's default namespace.
+		if o.Namespace() == "" && !clusterScoped[o.Kind()] {
+			meta["namespace"] = namespace
+		}
+	}
+	return objs, nil
+}
+
+// ApplyStatic server-side applies objs and records rel. Objects listed in
+// the previous record but no longer rendered are deleted, as helm upgrade
+// would — except CRDs, whose deletion would take every custom resource
+// with them.
+func ApplyStatic(kubeCtx string, rel StaticRelease, objs []manifest.Object) error {
+	items := make([]any, len(objs))
+	rel.Objects = nil
+	for i, o := range objs {
+		items[i] = o
+		rel.Objects = append(rel.Objects, objectRef(o, rel.Namespace))
+	}
+	sort.Strings(rel.Objects)
+	body, err := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return err
+	}
+
+	prev, err := RecordedStatic(kubeCtx)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"apply", "-f", "-", "--server-side", "--force-conflicts", "--field-manager", FieldManager}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin = bytes.NewReader(body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("kubectl apply --server-side: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	// Objects that fail to delete stay in the record, so the next apply
+	// retries them.
+	var pruneErrs []error
+	if prev != nil {
+		keep := map[string]bool{}
+		for _, ref := range rel.Objects {
+			keep[ref] = true
+		}
+		for _, ref := range prev.Objects {
+			if !keep[ref] && !strings.HasPrefix(ref, "CustomResourceDefinition/") {
+				if err := deleteRef(kubeCtx, ref); err != nil {
+					pruneErrs = append(pruneErrs, err)
+					rel.Objects = append(rel.Objects, ref)
+				}
+			}
+		}
+		sort.Strings(rel.Objects)
+	}
+	rel.DeployedAt = time.Now().UTC()
+	if err := recordStatic(kubeCtx, rel); err != nil {
+		return err
+	}
+	if len(pruneErrs) > 0 {
+		return fmt.Errorf("prune objects no longer rendered: %w", errors.Join(pruneErrs...))
+	}
+	return nil
+}
+
+// DryRunApply server-side applies objs with --dry-run=server: the API server
+// runs admission and validation and reports what would change, but nothing
+// is persisted. It returns kubectl's per-object output.
+func DryRunApply(kubeCtx string, objs []manifest.Object) (string, error) {
+	items := make([]any, len(objs))
+	for i, o := range objs {
+		items[i] = o
+	}
+	body, err := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return "", err
+	}
+	args := []string{"apply", "-f", "-", "--server-side", "--force-conflicts", "--field-manager", FieldManager, "--dry-run=server"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin = bytes.NewReader(body)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("kubectl apply --dry-run=server: %w", err)
+	}
+	return string(out), nil
+}
+
+// RecordedStatic returns the manifest install record found in any
+// namespace, or nil when Karpenter was not installed with --manifests.
+func RecordedStatic(kubeCtx string) (*StaticRelease, error) {
+	args := []string{"get", "configmaps", "--all-namespaces",
+		"--field-selector", "metadata.name=" + ReleaseConfigMap, "-o", "json"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", ReleaseConfigMap, err)
+	}
+	var list struct {
+		Items []struct {
+			Data map[string]string `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ReleaseConfigMap, err)
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+	var rel StaticRelease
+	if err := json.Unmarshal([]byte(list.Items[0].Data["release"]), &rel); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ReleaseConfigMap, err)
+	}
+	return &rel, nil
+}
+
+// UninstallStatic deletes every object of a manifest install and its record.
+// CRDs are kept unless deleteCRDs is set, as with helm uninstall.
+func UninstallStatic(kubeCtx string, rel *StaticRelease, deleteCRDs bool) []error {
+	var errs []error
+	for _, ref := range rel.Objects {
+		if strings.HasPrefix(ref, "CustomResourceDefinition/") && !deleteCRDs {
+			continue
+		}
+		if err := deleteRef(kubeCtx, ref); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := deleteRef(kubeCtx, "ConfigMap/"+rel.Namespace+"/"+ReleaseConfigMap); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func recordStatic(kubeCtx string, rel StaticRelease) error {
+	data, err := json.Marshal(rel)
+	if err != nil {
+		return err
+	}
+	cm := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      ReleaseConfigMap,
+			"namespace": rel.Namespace,
+			"labels": map[string]string{
+				"app.kubernetes.io/managed-by": "karpx",
+				"app.kubernetes.io/instance":   rel.Name,
+			},
+		},
+		"data": map[string]string{"release": string(data)},
+	}
+	body, err := json.Marshal(cm)
+	if err != nil {
+		return err
+	}
+	args := []string{"apply", "-f", "-", "--server-side", "--force-conflicts", "--field-manager", FieldManager}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin = bytes.NewReader(body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("record %s: %w\n%s", ReleaseConfigMap, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// objectRef identifies an object as kind/namespace/name; cluster-scoped
+// objects have an empty namespace. Rendered namespaced objects without a
+// namespace are deployed to defaultNS.
+func objectRef(o manifest.Object, defaultNS string) string {
+	ns := o.Namespace()
+	if ns == "" && !clusterScoped[o.Kind()] {
+		ns = defaultNS
+	}
+	return o.Kind() + "/" + ns + "/" + o.Name()
+}
+
+// clusterScoped are the cluster-scoped kinds the Karpenter charts render.
+var clusterScoped = map[string]bool{
+	"CustomResourceDefinition":       true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"Namespace":                      true,
+	"PriorityClass":                  true,
+	"ValidatingWebhookConfiguration": true,
+	"MutatingWebhookConfiguration":   true,
+	"APIService":                     true,
+}
+
+func deleteRef(kubeCtx, ref string) error {
+	parts := strings.SplitN(ref, "/", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid object reference %q", ref)
+	}
+	args := []string{"delete", parts[0], parts[2], "--ignore-not-found"}
+	if parts[1] != "" {
+		args = append(args, "-n", parts[1])
+	}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	if out, err := exec.Command("kubectl", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("delete %s: %w\n%s", ref, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
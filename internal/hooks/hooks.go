@@ -0,0 +1,148 @@
+// Package hooks runs the commands configured in the karpx config file before
+// and after install, upgrade and apply (change-management notifications,
+// backups, smoke tests, …).
+//
+// Each hook runs through the shell with these variables set:
+//
+//	KARPX_EVENT     install | upgrade | apply
+//	KARPX_PHASE     pre | post
+//	KARPX_CONTEXT   kubeconfig context
+//	KARPX_RESULT    post hooks only: success | failure
+//	KARPX_ERROR     post hooks only: the error when the operation failed
+//
+// plus the operation's own variables (KARPX_VERSION, KARPX_FROM_VERSION,
+// KARPX_NAMESPACE, …). A pre hook that exits non-zero aborts the operation;
+// a failing post hook fails the command, though the operation stays done.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/kemilad/karpx/internal/config"
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// Event is an operation hooks can run around.
+type Event string
+
+const (
+	Install Event = "install"
+	Upgrade Event = "upgrade"
+	Apply   Event = "apply"
+)
+
+// defaultTimeout bounds a hook without its own timeout.
+const defaultTimeout = 5 * time.Minute
+
+// ErrAborted wraps the error of a pre hook that stopped an operation.
+var ErrAborted = errors.New("aborted by pre hook")
+
+// Around runs op between the pre and post hooks configured for event on
+// kubeCtx, writing hook output to out. vars are exported to every hook.
+// A failing pre hook returns an ErrAborted error without running op. Post
+// hooks run whether op succeeded or not; op's error is returned first.
+func Around(event Event, kubeCtx string, vars map[string]string, out io.Writer, op func() error) error {
+	set, err := forContext(kubeCtx)
+	if err != nil {
+		return err
+	}
+	pre, post := set.PreInstall, set.PostInstall
+	switch event {
+	case Upgrade:
+		pre, post = set.PreUpgrade, set.PostUpgrade
+	case Apply:
+		pre, post = set.PreApply, set.PostApply
+	}
+
+	env := baseEnv(event, kubeCtx, vars)
+	if err := runAll(pre, "pre", event, append(env, "KARPX_PHASE=pre"), out); err != nil {
+		return fmt.Errorf("%s %w: %v", event, ErrAborted, err)
+	}
+
+	opErr := op()
+
+	result := "success"
+	if opErr != nil {
+		result = "failure"
+		env = append(env, "KARPX_ERROR="+opErr.Error())
+	}
+	env = append(env, "KARPX_PHASE=post", "KARPX_RESULT="+result)
+	postErr := runAll(post, "post", event, env, out)
+	if opErr != nil {
+		return opErr
+	}
+	return postErr
+}
+
+// forContext reads the hooks for kubeCtx. A config file that cannot be read
+// is an error: silently skipping a configured backup is worse than stopping.
+func forContext(kubeCtx string) (config.HookSet, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return config.HookSet{}, fmt.Errorf("read hooks: %w", err)
+	}
+	return cfg.Hooks.For(kube.ContextName(kubeCtx)), nil
+}
+
+func baseEnv(event Event, kubeCtx string, vars map[string]string) []string {
+	env := append(os.Environ(),
+		"KARPX_EVENT="+string(event),
+		"KARPX_CONTEXT="+kube.ContextName(kubeCtx),
+	)
+	for k, v := range vars {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+// runAll runs hooks in order and stops at the first failure that is not
+// marked continueOnError.
+func runAll(list []config.Hook, phase string, event Event, env []string, out io.Writer) error {
+	for _, h := range list {
+		timeout := defaultTimeout
+		if h.Timeout != "" {
+			d, err := time.ParseDuration(h.Timeout)
+			if err != nil {
+				return fmt.Errorf("%s-%s hook %q: timeout: %w", phase, event, h.Run, err)
+			}
+			timeout = d
+		}
+		fmt.Fprintf(out, "  ► %s-%s hook: %s\n", phase, event, h.Run)
+		err := run(h.Run, timeout, env, out)
+		switch {
+		case err == nil:
+			fmt.Fprintf(out, "  ✓  %s-%s hook passed.\n", phase, event)
+		case h.ContinueOnError:
+			fmt.Fprintf(out, "  ⚠  %s-%s hook failed (continueOnError): %v\n", phase, event, err)
+		default:
+			fmt.Fprintf(out, "  ✗ %s-%s hook failed: %v\n", phase, event, err)
+			return fmt.Errorf("%s-%s hook %q: %w", phase, event, h.Run, err)
+		}
+	}
+	return nil
+}
+
+func run(command string, timeout time.Duration, env []string, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	cmd.Env = env
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
@@ -0,0 +1,334 @@
+// Package iampolicy generates the Karpenter controller IAM policy for one
+// cluster, trimmed to the features it actually uses.
+//
+// The statements follow the upstream controller policy for Karpenter v1
+// (karpenter.sh/docs/reference/cloudformation). Everything that creates,
+// tags or deletes resources is scoped to the cluster's ownership tag
+// (kubernetes.io/cluster/<name>: owned), so the controller cannot touch
+// instances, launch templates or instance profiles of other clusters in the
+// account. Features the cluster does not use drop their statements entirely:
+// no interruption queue, no SQS permissions.
+package iampolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kemilad/karpx/internal/awscli"
+)
+
+// Options selects what the policy grants.
+type Options struct {
+	ClusterName       string
+	Region            string // "" = any region
+	Partition         string // aws | aws-cn | aws-us-gov; "" = from Region
+	AccountID         string // "" = any account
+	InterruptionQueue string // SQS queue name; "" = no interruption handling
+	NodeRoleName      string // role passed to instances; default KarpenterNodeRole-<cluster>
+	InstanceProfiles  bool   // Karpenter manages instance profiles (EC2NodeClass spec.role)
+	RegionCondition   bool   // also require aws:RequestedRegion = Region on every EC2 call
+}
+
+// Document is an IAM policy document.
+type Document struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+// Statement is one IAM policy statement. Condition maps an operator to
+// key → values; encoding/json sorts map keys, so output is stable.
+type Statement struct {
+	Sid       string                         `json:"Sid"`
+	Effect    string                         `json:"Effect"`
+	Action    []string                       `json:"Action"`
+	Resource  []string                       `json:"Resource"`
+	Condition map[string]map[string][]string `json:"Condition,omitempty"`
+}
+
+// Generate builds the policy for o.
+func Generate(o Options) Document {
+	part := o.Partition
+	if part == "" {
+		part = awscli.Partition(o.Region)
+	}
+	region := o.Region
+	if region == "" {
+		region = "*"
+	}
+	account := o.AccountID
+	if account == "" {
+		account = "*"
+	}
+	nodeRole := o.NodeRoleName
+	if nodeRole == "" {
+		nodeRole = "KarpenterNodeRole-" + o.ClusterName
+	}
+	clusterTag := "kubernetes.io/cluster/" + o.ClusterName
+
+	ec2 := func(types ...string) []string {
+		out := make([]string, len(types))
+		for i, t := range types {
+			out[i] = fmt.Sprintf("arn:%s:ec2:%s:*:%s/*", part, region, t)
+		}
+		return out
+	}
+	// images and snapshots are owned by other accounts (AWS, Marketplace).
+	ec2Public := func(types ...string) []string {
+		out := make([]string, len(types))
+		for i, t := range types {
+			out[i] = fmt.Sprintf("arn:%s:ec2:%s::%s/*", part, region, t)
+		}
+		return out
+	}
+	// regional adds the aws:RequestedRegion condition when asked for.
+	regional := func(c map[string]map[string][]string) map[string]map[string][]string {
+		if !o.RegionCondition || o.Region == "" {
+			return c
+		}
+		if c == nil {
+			c = map[string]map[string][]string{}
+		}
+		if c["StringEquals"] == nil {
+			c["StringEquals"] = map[string][]string{}
+		}
+		c["StringEquals"]["aws:RequestedRegion"] = []string{o.Region}
+		return c
+	}
+
+	launched := ec2("fleet", "instance", "volume", "network-interface", "launch-template", "spot-instances-request")
+
+	var st []Statement
+	st = append(st,
+		Statement{
+			Sid:    "AllowScopedEC2InstanceAccessActions",
+			Action: []string{"ec2:RunInstances", "ec2:CreateFleet"},
+			Resource: append(ec2Public("image", "snapshot"),
+				ec2("security-group", "subnet", "capacity-reservation")...),
+			Condition: regional(nil),
+		},
+		Statement{
+			Sid:      "AllowScopedEC2LaunchTemplateAccessActions",
+			Action:   []string{"ec2:RunInstances", "ec2:CreateFleet"},
+			Resource: ec2("launch-template"),
+			Condition: regional(map[string]map[string][]string{
+				"StringEquals": {"aws:ResourceTag/" + clusterTag: {"owned"}},
+				"StringLike":   {"aws:ResourceTag/karpenter.sh/nodepool": {"*"}},
+			}),
+		},
+		Statement{
+			Sid:      "AllowScopedEC2InstanceActionsWithTags",
+			Action:   []string{"ec2:RunInstances", "ec2:CreateFleet", "ec2:CreateLaunchTemplate"},
+			Resource: launched,
+			Condition: regional(map[string]map[string][]string{
+				"StringEquals": {
+					"aws:RequestTag/" + clusterTag:        {"owned"},
+					"aws:RequestTag/eks:eks-cluster-name": {o.ClusterName},
+				},
+				"StringLike": {"aws:RequestTag/karpenter.sh/nodepool": {"*"}},
+			}),
+		},
+		Statement{
+			Sid:      "AllowScopedResourceCreationTagging",
+			Action:   []string{"ec2:CreateTags"},
+			Resource: launched,
+			Condition: regional(map[string]map[string][]string{
+				"StringEquals": {
+					"aws:RequestTag/" + clusterTag:        {"owned"},
+					"aws:RequestTag/eks:eks-cluster-name": {o.ClusterName},
+					"ec2:CreateAction":                    {"RunInstances", "CreateFleet", "CreateLaunchTemplate"},
+				},
+				"StringLike": {"aws:RequestTag/karpenter.sh/nodepool": {"*"}},
+			}),
+		},
+		Statement{
+			Sid:      "AllowScopedResourceTagging",
+			Action:   []string{"ec2:CreateTags"},
+			Resource: ec2("instance"),
+			Condition: regional(map[string]map[string][]string{
+				"StringEquals":              {"aws:ResourceTag/" + clusterTag: {"owned"}},
+				"StringLike":                {"aws:ResourceTag/karpenter.sh/nodepool": {"*"}},
+				"StringEqualsIfExists":      {"aws:RequestTag/eks:eks-cluster-name": {o.ClusterName}},
+				"ForAllValues:StringEquals": {"aws:TagKeys": {"eks:eks-cluster-name", "karpenter.sh/nodeclaim", "Name"}},
+			}),
+		},
+		Statement{
+			Sid:      "AllowScopedDeletion",
+			Action:   []string{"ec2:TerminateInstances", "ec2:DeleteLaunchTemplate"},
+			Resource: ec2("instance", "launch-template"),
+			Condition: regional(map[string]map[string][]string{
+				"StringEquals": {"aws:ResourceTag/" + clusterTag: {"owned"}},
+				"StringLike":   {"aws:ResourceTag/karpenter.sh/nodepool": {"*"}},
+			}),
+		},
+	)
+
+	readCond := map[string]map[string][]string(nil)
+	if o.Region != "" {
+		// Describe calls cannot be scoped by ARN; the region is the only
+		// boundary available, so it is applied whenever it is known.
+		readCond = map[string]map[string][]string{"StringEquals": {"aws:RequestedRegion": {o.Region}}}
+	}
+	st = append(st,
+		Statement{
+			Sid: "AllowRegionalReadActions",
+			Action: []string{
+				"ec2:DescribeAvailabilityZones", "ec2:DescribeImages", "ec2:DescribeInstances",
+				"ec2:DescribeInstanceTypeOfferings", "ec2:DescribeInstanceTypes", "ec2:DescribeLaunchTemplates",
+				"ec2:DescribeSecurityGroups", "ec2:DescribeSpotPriceHistory", "ec2:DescribeSubnets",
+			},
+			Resource:  []string{"*"},
+			Condition: readCond,
+		},
+		Statement{
+			Sid:      "AllowSSMReadActions",
+			Action:   []string{"ssm:GetParameter"},
+			Resource: []string{fmt.Sprintf("arn:%s:ssm:%s::parameter/aws/service/*", part, region)},
+		},
+		Statement{
+			Sid:      "AllowPricingReadActions",
+			Action:   []string{"pricing:GetProducts"},
+			Resource: []string{"*"},
+		},
+	)
+
+	if o.InterruptionQueue != "" {
+		st = append(st, Statement{
+			Sid:      "AllowInterruptionQueueActions",
+			Action:   []string{"sqs:DeleteMessage", "sqs:GetQueueUrl", "sqs:ReceiveMessage"},
+			Resource: []string{awscli.ARN(part, "sqs", region, account, o.InterruptionQueue)},
+		})
+	}
+
+	ec2Service := "ec2." + awscli.DNSSuffix(part)
+	st = append(st, Statement{
+		Sid:       "AllowPassingInstanceRole",
+		Action:    []string{"iam:PassRole"},
+		Resource:  []string{awscli.ARN(part, "iam", "", account, "role/"+nodeRole)},
+		Condition: map[string]map[string][]string{"StringEquals": {"iam:PassedToService": {ec2Service}}},
+	})
+
+	if o.InstanceProfiles {
+		profiles := []string{awscli.ARN(part, "iam", "", account, "instance-profile/*")}
+		st = append(st,
+			Statement{
+				Sid:      "AllowScopedInstanceProfileCreationActions",
+				Action:   []string{"iam:CreateInstanceProfile"},
+				Resource: profiles,
+				Condition: map[string]map[string][]string{
+					"StringEquals": {
+						"aws:RequestTag/" + clusterTag:                 {"owned"},
+						"aws:RequestTag/eks:eks-cluster-name":          {o.ClusterName},
+						"aws:RequestTag/topology.kubernetes.io/region": {region},
+					},
+					"StringLike": {"aws:RequestTag/karpenter.k8s.aws/ec2nodeclass": {"*"}},
+				},
+			},
+			Statement{
+				Sid:      "AllowScopedInstanceProfileTagActions",
+				Action:   []string{"iam:TagInstanceProfile"},
+				Resource: profiles,
+				Condition: map[string]map[string][]string{
+					"StringEquals": {
+						"aws:ResourceTag/" + clusterTag:                 {"owned"},
+						"aws:ResourceTag/topology.kubernetes.io/region": {region},
+						"aws:RequestTag/" + clusterTag:                  {"owned"},
+						"aws:RequestTag/eks:eks-cluster-name":           {o.ClusterName},
+						"aws:RequestTag/topology.kubernetes.io/region":  {region},
+					},
+					"StringLike": {
+						"aws:ResourceTag/karpenter.k8s.aws/ec2nodeclass": {"*"},
+						"aws:RequestTag/karpenter.k8s.aws/ec2nodeclass":  {"*"},
+					},
+				},
+			},
+			Statement{
+				Sid:      "AllowScopedInstanceProfileActions",
+				Action:   []string{"iam:AddRoleToInstanceProfile", "iam:RemoveRoleFromInstanceProfile", "iam:DeleteInstanceProfile"},
+				Resource: profiles,
+				Condition: map[string]map[string][]string{
+					"StringEquals": {
+						"aws:ResourceTag/" + clusterTag:                 {"owned"},
+						"aws:ResourceTag/topology.kubernetes.io/region": {region},
+					},
+					"StringLike": {"aws:ResourceTag/karpenter.k8s.aws/ec2nodeclass": {"*"}},
+				},
+			},
+			Statement{
+				Sid:      "AllowInstanceProfileReadActions",
+				Action:   []string{"iam:GetInstanceProfile"},
+				Resource: profiles,
+			},
+		)
+	}
+
+	st = append(st, Statement{
+		Sid:      "AllowAPIServerEndpointDiscovery",
+		Action:   []string{"eks:DescribeCluster"},
+		Resource: []string{fmt.Sprintf("arn:%s:eks:%s:%s:cluster/%s", part, region, account, o.ClusterName)},
+	})
+
+	for i := range st {
+		st[i].Effect = "Allow"
+	}
+	return Document{Version: "2012-10-17", Statement: st}
+}
+
+// JSON renders d as an indented policy document.
+func (d Document) JSON() (string, error) {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// Terraform renders d as an aws_iam_policy_document data source plus an
+// aws_iam_policy resource named policyName.
+func (d Document) Terraform(policyName string) string {
+	var b strings.Builder
+	b.WriteString("data \"aws_iam_policy_document\" \"karpenter_controller\" {\n")
+	for _, s := range d.Statement {
+		b.WriteString("  statement {\n")
+		fmt.Fprintf(&b, "    sid       = %q\n", s.Sid)
+		fmt.Fprintf(&b, "    effect    = %q\n", s.Effect)
+		fmt.Fprintf(&b, "    actions   = %s\n", hclList(s.Action))
+		fmt.Fprintf(&b, "    resources = %s\n", hclList(s.Resource))
+		for _, test := range sortedKeys(s.Condition) {
+			for _, variable := range sortedKeys(s.Condition[test]) {
+				b.WriteString("\n    condition {\n")
+				fmt.Fprintf(&b, "      test     = %q\n", test)
+				fmt.Fprintf(&b, "      variable = %q\n", variable)
+				fmt.Fprintf(&b, "      values   = %s\n", hclList(s.Condition[test][variable]))
+				b.WriteString("    }\n")
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("resource \"aws_iam_policy\" \"karpenter_controller\" {\n")
+	fmt.Fprintf(&b, "  name   = %q\n", policyName)
+	b.WriteString("  policy = data.aws_iam_policy_document.karpenter_controller.json\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// hclList renders values as a one-line HCL list. %q escapes match HCL's for
+// everything that appears in a policy; "${" never does.
+func hclList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// CoreAddons are the versions of the cluster add-ons every EKS node depends
+// on, read from their kube-system image tags. An empty version means the
+// add-on was not found.
+type CoreAddons struct {
+	VPCCNI           string   `json:"vpc_cni,omitempty"`
+	CoreDNS          string   `json:"coredns,omitempty"`
+	KubeProxy        string   `json:"kube_proxy,omitempty"`
+	CNI              CNIMode  `json:"cni,omitempty"`
+	InstanceFamilies []string `json:"instance_families,omitempty"` // families of the running nodes
+}
+
+// DetectCoreAddons reads the VPC CNI (aws-node), CoreDNS and kube-proxy
+// versions, the CNI mode and the instance families of the running nodes.
+func DetectCoreAddons(kubeCtx string) (*CoreAddons, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &CoreAddons{CNI: detectCNI(cs)}
+	apps := cs.AppsV1()
+	if ds, err := apps.DaemonSets("kube-system").Get(context.TODO(), "aws-node", metav1.GetOptions{}); err == nil {
+		a.VPCCNI = containerVersion(ds.Spec.Template.Spec.Containers, "aws-node")
+	}
+	if ds, err := apps.DaemonSets("kube-system").Get(context.TODO(), "kube-proxy", metav1.GetOptions{}); err == nil {
+		a.KubeProxy = containerVersion(ds.Spec.Template.Spec.Containers, "kube-proxy")
+	}
+	if d, err := apps.Deployments("kube-system").Get(context.TODO(), "coredns", metav1.GetOptions{}); err == nil {
+		a.CoreDNS = containerVersion(d.Spec.Template.Spec.Containers, "coredns")
+	}
+
+	nodes, err := cs.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return a, nil
+	}
+	seen := map[string]bool{}
+	for _, n := range nodes.Items {
+		family, _, ok := strings.Cut(n.Labels["node.kubernetes.io/instance-type"], ".")
+		if ok && !seen[family] {
+			seen[family] = true
+			a.InstanceFamilies = append(a.InstanceFamilies, family)
+		}
+	}
+	sort.Strings(a.InstanceFamilies)
+	return a, nil
+}
+
+// containerVersion returns the image tag of the named container without the
+// leading "v" and any EKS build suffix ("v1.18.3-eksbuild.1" → "1.18.3").
+func containerVersion(containers []corev1.Container, name string) string {
+	for _, c := range containers {
+		if c.Name != name {
+			continue
+		}
+		image := c.Image
+		if i := strings.Index(image, "@"); i >= 0 {
+			image = image[:i]
+		}
+		i := strings.LastIndex(image, ":")
+		if i < 0 || strings.Contains(image[i:], "/") {
+			return ""
+		}
+		tag := strings.TrimPrefix(image[i+1:], "v")
+		if j := strings.IndexAny(tag, "-+_"); j >= 0 {
+			tag = tag[:j]
+		}
+		return tag
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package kube
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Headroom is the demand a cluster can grow into without anyone deploying
+// anything: HPAs scaling to maxReplicas and pods resized to their VPA targets.
+type Headroom struct {
+	CPUm   int64 // extra CPU requests in millicores
+	MemMiB int64 // extra memory requests in MiB
+	HPAs   int   // autoscalers that can still scale out
+	VPAs   int   // autoscalers whose target exceeds today's requests
+}
+
+var vpaGVR = schema.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"}
+
+// scaleHeadroom reads HPAs and, when the CRD is installed, VPAs. Errors only
+// shrink the result: autoscalers that cannot be read add no headroom.
+func scaleHeadroom(cfg *rest.Config, cs *kubernetes.Clientset) Headroom {
+	var h Headroom
+	ctx := context.TODO()
+
+	// HPA targets, so a workload also covered by a VPA is scaled with the
+	// larger replica count.
+	maxReplicas := map[string]int32{}
+
+	if hpas, err := cs.AutoscalingV2().HorizontalPodAutoscalers("").List(ctx, metav1.ListOptions{}); err == nil {
+		for _, hpa := range hpas.Items {
+			ref := hpa.Spec.ScaleTargetRef
+			t, ok := podTemplate(cs, hpa.Namespace, ref.Kind, ref.Name)
+			if !ok {
+				continue
+			}
+			maxReplicas[hpa.Namespace+"/"+ref.Kind+"/"+ref.Name] = hpa.Spec.MaxReplicas
+			current := hpa.Status.CurrentReplicas
+			if current == 0 {
+				current = t.replicas
+			}
+			extra := int64(hpa.Spec.MaxReplicas - current)
+			if extra <= 0 {
+				continue
+			}
+			h.CPUm += extra * t.cpuM
+			h.MemMiB += extra * t.memMiB
+			h.HPAs++
+		}
+	}
+
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return h
+	}
+	vpas, err := dyn.Resource(vpaGVR).Namespace("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return h // VPA not installed
+	}
+	for _, vpa := range vpas.Items {
+		kind, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "kind")
+		name, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "name")
+		t, ok := podTemplate(cs, vpa.GetNamespace(), kind, name)
+		if !ok {
+			continue
+		}
+		var targetCPUm, targetMemMiB int64
+		recs, _, _ := unstructured.NestedSlice(vpa.Object, "status", "recommendation", "containerRecommendations")
+		for _, r := range recs {
+			rm, _ := r.(map[string]any)
+			target, _, _ := unstructured.NestedStringMap(rm, "target")
+			if q, err := resource.ParseQuantity(target["cpu"]); err == nil {
+				targetCPUm += q.MilliValue()
+			}
+			if q, err := resource.ParseQuantity(target["memory"]); err == nil {
+				targetMemMiB += q.Value() / (1024 * 1024)
+			}
+		}
+		replicas := int64(t.replicas)
+		if m, ok := maxReplicas[vpa.GetNamespace()+"/"+kind+"/"+name]; ok {
+			replicas = int64(m)
+		}
+		dCPU := max(0, targetCPUm-t.cpuM) * replicas
+		dMem := max(0, targetMemMiB-t.memMiB) * replicas
+		if dCPU == 0 && dMem == 0 {
+			continue
+		}
+		h.CPUm += dCPU
+		h.MemMiB += dMem
+		h.VPAs++
+	}
+	return h
+}
+
+type template struct {
+	cpuM, memMiB int64 // per-pod requests
+	replicas     int32
+}
+
+// podTemplate returns the per-pod requests and replica count of a scalable
+// workload. Only the built-in apps kinds are resolved.
+func podTemplate(cs *kubernetes.Clientset, ns, kind, name string) (template, bool) {
+	ctx := context.TODO()
+	var spec corev1.PodSpec
+	var replicas *int32
+	switch kind {
+	case "Deployment":
+		d, err := cs.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return template{}, false
+		}
+		spec, replicas = d.Spec.Template.Spec, d.Spec.Replicas
+	case "StatefulSet":
+		s, err := cs.AppsV1().StatefulSets(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return template{}, false
+		}
+		spec, replicas = s.Spec.Template.Spec, s.Spec.Replicas
+	case "ReplicaSet":
+		r, err := cs.AppsV1().ReplicaSets(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return template{}, false
+		}
+		spec, replicas = r.Spec.Template.Spec, r.Spec.Replicas
+	default:
+		return template{}, false
+	}
+
+	t := template{replicas: 1}
+	if replicas != nil {
+		t.replicas = *replicas
+	}
+	for _, c := range spec.Containers {
+		if cpu := c.Resources.Requests.Cpu(); cpu != nil {
+			t.cpuM += cpu.MilliValue()
+		}
+		if mem := c.Resources.Requests.Memory(); mem != nil {
+			t.memMiB += mem.Value() / (1024 * 1024)
+		}
+	}
+	return t, true
+}
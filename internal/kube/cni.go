@@ -0,0 +1,148 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CNIMode is how pods get their IP addresses, which decides how many pods
+// fit on a node.
+type CNIMode string
+
+const (
+	CNIUnknown CNIMode = ""        // not detected; treated as CNIVPC
+	CNIVPC     CNIMode = "vpc-cni" // one VPC address per pod, limited by the node's ENIs
+	CNIPrefix  CNIMode = "prefix"  // VPC CNI with prefix delegation: /28 prefixes per ENI slot
+	CNIIPv6    CNIMode = "ipv6"    // VPC CNI in IPv6 mode: a /80 prefix per node
+	CNIOverlay CNIMode = "overlay" // Cilium, Calico, Flannel, … — pod IPs are not VPC addresses
+)
+
+// ParseCNI converts a --cni flag value to a CNIMode.
+func ParseCNI(s string) (CNIMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return CNIUnknown, nil
+	case "vpc-cni", "vpc", "eni":
+		return CNIVPC, nil
+	case "prefix", "prefix-delegation":
+		return CNIPrefix, nil
+	case "ipv6":
+		return CNIIPv6, nil
+	case "overlay":
+		return CNIOverlay, nil
+	}
+	return "", fmt.Errorf("--cni must be vpc-cni, prefix, ipv6 or overlay, got %q", s)
+}
+
+// ENILimited reports whether pods per node follow the ENI address count, as
+// Karpenter assumes when the kubelet's maxPods is not set.
+func (m CNIMode) ENILimited() bool { return m == CNIVPC || m == CNIUnknown }
+
+// KubeletMaxPods is the kubelet's own default pod limit, which applies when
+// pod IPs are not tied to the node's network interfaces.
+const KubeletMaxPods = 110
+
+// LargeNodeMaxPods is the EKS recommendation for nodes with 30 or more vCPUs
+// once addresses are no longer the limit (prefix delegation, IPv6).
+const LargeNodeMaxPods = 250
+
+// overlayDaemonSets are kube-system DaemonSets of CNIs that do not give pods
+// VPC addresses.
+var overlayDaemonSets = map[string]bool{
+	"cilium":          true,
+	"calico-node":     true,
+	"kube-flannel-ds": true,
+	"weave-net":       true,
+	"antrea-agent":    true,
+}
+
+// detectCNI looks for a known CNI DaemonSet in kube-system. aws-node wins
+// when both are present: Cilium and Calico chained behind the VPC CNI
+// (policy only) leave pods on VPC addresses. Its ENABLE_IPv6 and
+// ENABLE_PREFIX_DELEGATION settings select the IPv6 and prefix modes.
+// Without RBAC to list DaemonSets the mode is unknown.
+func detectCNI(cs *kubernetes.Clientset) CNIMode {
+	list, err := cs.AppsV1().DaemonSets("kube-system").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return CNIUnknown
+	}
+	vpc, overlay := CNIUnknown, false
+	for _, ds := range list.Items {
+		switch {
+		case ds.Name == "aws-node":
+			vpc = CNIVPC
+			for _, c := range ds.Spec.Template.Spec.Containers {
+				for _, e := range c.Env {
+					switch {
+					case e.Name == "ENABLE_IPv6" && e.Value == "true":
+						vpc = CNIIPv6
+					case e.Name == "ENABLE_PREFIX_DELEGATION" && e.Value == "true" && vpc != CNIIPv6:
+						vpc = CNIPrefix
+					}
+				}
+			}
+		case overlayDaemonSets[ds.Name]:
+			overlay = true
+		}
+	}
+	switch {
+	case vpc != CNIUnknown:
+		return vpc
+	case overlay:
+		return CNIOverlay
+	}
+	return CNIUnknown
+}
+
+// MaxPodsFor returns the pods-per-node limit of a node size under mode.
+// Prefix delegation and IPv6 lift the address limit, leaving the EKS caps:
+// 110 below 30 vCPUs and 250 above.
+func MaxPodsFor(vcpu int, mode CNIMode) int {
+	switch mode {
+	case CNIOverlay:
+		return KubeletMaxPods
+	case CNIPrefix, CNIIPv6:
+		if vcpu >= 30 {
+			return LargeNodeMaxPods
+		}
+		return KubeletMaxPods
+	}
+	return MaxPods(vcpu)
+}
+
+// eniSlots are the ENIs and IPv4 addresses per ENI of an instance size.
+type eniSlots struct{ enis, ips int }
+
+// nitroENIs are the ENI limits by vCPU of the current general purpose,
+// compute and memory families, which share them size for size.
+var nitroENIs = map[int]eniSlots{
+	2: {3, 10}, 4: {4, 15}, 8: {4, 15}, 16: {8, 30}, 32: {8, 30}, 48: {8, 30}, 64: {15, 50}, 96: {15, 50},
+}
+
+// burstableENIs are the ENI limits of the burstable families by vCPU. At 2
+// vCPUs they range from nano (4 pods) to large (35), so no single value holds.
+var burstableENIs = map[int]eniSlots{4: {4, 15}, 8: {4, 15}}
+
+// eniFamilies maps the families the recommendations use to their ENI table.
+var eniFamilies = map[string]map[int]eniSlots{
+	"m6g": nitroENIs, "m7g": nitroENIs, "c6g": nitroENIs, "c7g": nitroENIs, "r6g": nitroENIs, "r7g": nitroENIs,
+	"m6i": nitroENIs, "m7i": nitroENIs, "c6i": nitroENIs, "c7i": nitroENIs, "r6i": nitroENIs, "r7i": nitroENIs,
+	"m6a": nitroENIs, "c6a": nitroENIs,
+	"m7i-flex": nitroENIs, "c7i-flex": nitroENIs,
+	"t3": burstableENIs, "t3a": burstableENIs, "t4g": burstableENIs,
+}
+
+// ENIMaxPods returns the VPC CNI pod limit, without prefix delegation, of the
+// family's instance type with vcpu vCPUs: ENIs × (addresses per ENI − 1) + 2.
+// It reports false when the limit is not known for that type.
+func ENIMaxPods(family string, vcpu int) (int, bool) {
+	slots, ok := eniFamilies[family][vcpu]
+	if !ok {
+		return 0, false
+	}
+	return slots.enis*(slots.ips-1) + 2, true
+}
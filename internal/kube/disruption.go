@@ -0,0 +1,173 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// DoNotDisrupt is the annotation that stops Karpenter from consolidating or
+// drifting the node a pod runs on. In v1 it does not block expiry: a node past
+// expireAfter is drained anyway, the pod held only until the NodePool's
+// terminationGracePeriod.
+const DoNotDisrupt = "karpenter.sh/do-not-disrupt"
+
+// Workload is a pod controller (or a bare pod) as seen through its pods.
+type Workload struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Pods      int    `json:"pods"`
+	Protected int    `json:"protected"`        // pods carrying do-not-disrupt
+	Reason    string `json:"reason,omitempty"` // why it counts as critical
+}
+
+// Key is "namespace/Kind/name".
+func (w Workload) Key() string { return w.Namespace + "/" + w.Kind + "/" + w.Name }
+
+// BlockedNode is a node that consolidation and drift will never touch.
+type BlockedNode struct {
+	Name      string   `json:"name"`
+	Karpenter bool     `json:"karpenter"`
+	Node      bool     `json:"node_annotated"` // the node itself is annotated
+	Pods      []string `json:"pods"`           // namespace/name of annotated pods
+}
+
+// DisruptionCoverage reports how do-not-disrupt is used across a cluster.
+type DisruptionCoverage struct {
+	Protected    []Workload    `json:"protected"`   // at least one pod annotated
+	Unprotected  []Workload    `json:"unprotected"` // critical, but no pod annotated
+	BlockedNodes []BlockedNode `json:"blocked_nodes"`
+}
+
+// AnalyzeDisruption lists which workloads are protected by do-not-disrupt,
+// which critical ones are not, and which nodes the annotation blocks.
+//
+// A workload counts as critical when it is a StatefulSet, has a single
+// replica, or runs with a priority class; kube-system and DaemonSets are
+// excluded because Karpenter never evicts DaemonSet pods anyway.
+func AnalyzeDisruption(kubeCtx string) (*DisruptionCoverage, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeList, err := cs.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list nodes: %w", err)
+	}
+	pods, err := cs.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+
+	blocked := map[string]*BlockedNode{}
+	karpNodes := map[string]bool{}
+	for _, n := range nodeList.Items {
+		_, karp := n.Labels["karpenter.sh/nodepool"]
+		karpNodes[n.Name] = karp
+		if n.Annotations[DoNotDisrupt] == "true" {
+			blocked[n.Name] = &BlockedNode{Name: n.Name, Karpenter: karp, Node: true}
+		}
+	}
+
+	workloads := map[string]*Workload{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if ownedByDaemonSet(pod) || pod.Namespace == "kube-system" {
+			continue
+		}
+		kind, name := controllerOf(pod)
+		key := pod.Namespace + "/" + kind + "/" + name
+		w := workloads[key]
+		if w == nil {
+			w = &Workload{Namespace: pod.Namespace, Kind: kind, Name: name}
+			workloads[key] = w
+		}
+		w.Pods++
+		if pod.Spec.PriorityClassName != "" && w.Reason == "" {
+			w.Reason = "priority class " + pod.Spec.PriorityClassName
+		}
+		if !hasDoNotDisrupt(pod) {
+			continue
+		}
+		w.Protected++
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		b := blocked[pod.Spec.NodeName]
+		if b == nil {
+			b = &BlockedNode{Name: pod.Spec.NodeName, Karpenter: karpNodes[pod.Spec.NodeName]}
+			blocked[pod.Spec.NodeName] = b
+		}
+		b.Pods = append(b.Pods, pod.Namespace+"/"+pod.Name)
+	}
+
+	cov := &DisruptionCoverage{}
+	for _, w := range workloads {
+		switch {
+		case w.Protected > 0:
+			cov.Protected = append(cov.Protected, *w)
+		case w.Reason != "":
+			cov.Unprotected = append(cov.Unprotected, *w)
+		case w.Kind == "StatefulSet":
+			w.Reason = "StatefulSet"
+			cov.Unprotected = append(cov.Unprotected, *w)
+		case w.Pods == 1:
+			w.Reason = "single replica"
+			cov.Unprotected = append(cov.Unprotected, *w)
+		}
+	}
+	for _, b := range blocked {
+		cov.BlockedNodes = append(cov.BlockedNodes, *b)
+	}
+	sortWorkloads(cov.Protected)
+	sortWorkloads(cov.Unprotected)
+	sort.Slice(cov.BlockedNodes, func(i, j int) bool { return cov.BlockedNodes[i].Name < cov.BlockedNodes[j].Name })
+	return cov, nil
+}
+
+// hasDoNotDisrupt also accepts the pre-v1 do-not-evict annotation, which
+// older Karpenter versions still honour.
+func hasDoNotDisrupt(pod *corev1.Pod) bool {
+	return pod.Annotations[DoNotDisrupt] == "true" || pod.Annotations["karpenter.sh/do-not-evict"] == "true"
+}
+
+// controllerOf resolves a pod to its top-level controller. ReplicaSets made by
+// a Deployment are reported as the Deployment by stripping the template hash.
+func controllerOf(pod *corev1.Pod) (kind, name string) {
+	for _, o := range pod.OwnerReferences {
+		if o.Controller == nil || !*o.Controller {
+			continue
+		}
+		if o.Kind == "ReplicaSet" {
+			if hash := pod.Labels["pod-template-hash"]; hash != "" {
+				return "Deployment", strings.TrimSuffix(o.Name, "-"+hash)
+			}
+		}
+		return o.Kind, o.Name
+	}
+	return "Pod", pod.Name
+}
+
+func sortWorkloads(ws []Workload) {
+	sort.Slice(ws, func(i, j int) bool { return ws[i].Key() < ws[j].Key() })
+}
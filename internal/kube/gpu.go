@@ -0,0 +1,64 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// GPUStack is the NVIDIA software that makes GPUs schedulable. Without a
+// device plugin, nodes never advertise nvidia.com/gpu and GPU pods stay
+// pending however many GPU nodes Karpenter launches.
+type GPUStack struct {
+	DevicePlugin string // namespace/name of the device plugin DaemonSet
+	Operator     string // namespace/name of the GPU operator Deployment
+	Namespace    string // where the device plugin or operator runs
+}
+
+// Installed reports whether GPUs will be advertised to the scheduler. The
+// operator deploys the device plugin itself once GPU nodes join.
+func (s GPUStack) Installed() bool { return s.DevicePlugin != "" || s.Operator != "" }
+
+// DetectGPUStack looks for the NVIDIA device plugin and GPU operator in any
+// namespace.
+func DetectGPUStack(kubeCtx string) (*GPUStack, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &GPUStack{}
+	dss, err := cs.AppsV1().DaemonSets("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list DaemonSets: %w", err)
+	}
+	for _, ds := range dss.Items {
+		if strings.Contains(ds.Name, "nvidia-device-plugin") {
+			s.DevicePlugin = ds.Namespace + "/" + ds.Name
+			s.Namespace = ds.Namespace
+		}
+	}
+	if deps, err := cs.AppsV1().Deployments("").List(context.TODO(), metav1.ListOptions{}); err == nil {
+		for _, d := range deps.Items {
+			if d.Name == "gpu-operator" || strings.HasSuffix(d.Name, "-gpu-operator") {
+				s.Operator = d.Namespace + "/" + d.Name
+				s.Namespace = d.Namespace
+			}
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,44 @@
+package kube
+
+import (
+	"context"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// imageMiB returns the size of the largest image set one node holds, read
+// from the image lists nodes report in their status: for each node, the
+// images it has pulled that running pods use. Summing every distinct image in
+// the cluster would size each node for images spread across many. Images not
+// pulled on any node are not counted; 0 means nothing could be read.
+func imageMiB(cs *kubernetes.Clientset, images map[string]bool) int64 {
+	nodes, err := cs.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return 0
+	}
+	var largest int64
+	for _, n := range nodes.Items {
+		var total int64
+		for _, img := range n.Status.Images {
+			if usedImage(img.Names, images) {
+				total += img.SizeBytes
+			}
+		}
+		largest = max(largest, total)
+	}
+	return largest / (1024 * 1024)
+}
+
+// usedImage reports whether any of a node image's names is in images.
+// Nodes list images fully qualified; pod specs often use short names.
+func usedImage(names []string, images map[string]bool) bool {
+	for _, name := range names {
+		short := strings.TrimPrefix(strings.TrimPrefix(name, "docker.io/"), "library/")
+		if images[name] || images[short] || images["library/"+short] {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,8 @@ package kube
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -58,3 +60,142 @@ func EnsureNamespace(kubeCtx, namespace string) (NamespaceStatus, error) {
 	}
 	return NamespaceCreated, nil
 }
+
+// NamespaceExists reports whether namespace exists, without creating it.
+func NamespaceExists(kubeCtx, namespace string) (bool, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return false, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return false, err
+	}
+	_, err = cs.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("check namespace %q: %w", namespace, err)
+	}
+	return true, nil
+}
+
+// complianceLabels are the namespace label keys that mark regulated
+// workloads, e.g. compliance=pci or example.com/hipaa=true. A prefixed key
+// matches on its name after the "/".
+var complianceLabels = map[string]bool{
+	"compliance": true, "pci": true, "hipaa": true, "fedramp": true, "sox": true, "karpx.io/tenancy": true,
+}
+
+// complianceNamespaces returns the namespaces whose labels mark them as
+// regulated — candidates for dedicated tenancy.
+func complianceNamespaces(cs *kubernetes.Clientset) []string {
+	list, err := cs.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, ns := range list.Items {
+		if hasComplianceLabel(ns.Labels) {
+			out = append(out, ns.Name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func hasComplianceLabel(labels map[string]string) bool {
+	for k := range labels {
+		k = strings.ToLower(k)
+		if complianceLabels[k] {
+			return true
+		}
+		if i := strings.LastIndex(k, "/"); i >= 0 && complianceLabels[k[i+1:]] {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamUsage is the requests of the running pods owned by one team.
+type TeamUsage struct {
+	Team       string
+	Namespaces []string
+	Pods       int
+	CPUm       int64
+	MemMiB     int64
+}
+
+// UsageByTeam groups running pod requests by the value of labelKey on their
+// namespace. Namespaces without the label are left out — their pods stay on
+// the shared pool.
+func UsageByTeam(kubeCtx, labelKey string) ([]TeamUsage, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nsList, err := cs.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: labelKey})
+	if err != nil {
+		return nil, fmt.Errorf("list namespaces: %w", err)
+	}
+	teamOf := map[string]string{}
+	byTeam := map[string]*TeamUsage{}
+	for _, ns := range nsList.Items {
+		team := ns.Labels[labelKey]
+		if team == "" {
+			continue
+		}
+		teamOf[ns.Name] = team
+		if byTeam[team] == nil {
+			byTeam[team] = &TeamUsage{Team: team}
+		}
+		byTeam[team].Namespaces = append(byTeam[team].Namespaces, ns.Name)
+	}
+	if len(byTeam) == 0 {
+		return nil, fmt.Errorf("no namespaces are labelled %s=<team>", labelKey)
+	}
+
+	pods, err := cs.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		t := byTeam[teamOf[pod.Namespace]]
+		if t == nil || ownedByDaemonSet(pod) {
+			continue
+		}
+		req := podRequests(pod)
+		t.Pods++
+		t.CPUm += req.CPUm
+		t.MemMiB += req.MemMiB
+	}
+
+	out := make([]TeamUsage, 0, len(byTeam))
+	for _, t := range byTeam {
+		sort.Strings(t.Namespaces)
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Team < out[j].Team })
+	return out, nil
+}
@@ -0,0 +1,174 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// PodRequest is the summed container requests of a single pod.
+type PodRequest struct {
+	Namespace string
+	Name      string
+	CPUm      int64
+	MemMiB    int64
+}
+
+// NodeUsage describes one node and the requests of the pods scheduled on it.
+type NodeUsage struct {
+	Name         string
+	InstanceType string // node.kubernetes.io/instance-type
+	CapacityType string // "spot" | "on-demand" (best effort; "" when unknown)
+	Zone         string
+	Karpenter    bool // launched by Karpenter (karpenter.sh/nodepool label)
+
+	AllocCPUm   int64
+	AllocMemMiB int64
+
+	// DaemonSet pods run on every node and move with it, so they are tracked
+	// separately from the pods consolidation could repack.
+	DaemonCPUm   int64
+	DaemonMemMiB int64
+	DaemonPods   int
+
+	Pods []PodRequest // movable (non-DaemonSet) pods
+
+	// Pinned is true when the node hosts a pod that consolidation must not
+	// move: karpenter.sh/do-not-disrupt or a bare pod with no controller.
+	Pinned       bool
+	PinnedReason string
+}
+
+// ReqCPUm returns the total CPU requested on the node, DaemonSets included.
+func (n NodeUsage) ReqCPUm() int64 {
+	t := n.DaemonCPUm
+	for _, p := range n.Pods {
+		t += p.CPUm
+	}
+	return t
+}
+
+// ReqMemMiB returns the total memory requested on the node, DaemonSets included.
+func (n NodeUsage) ReqMemMiB() int64 {
+	t := n.DaemonMemMiB
+	for _, p := range n.Pods {
+		t += p.MemMiB
+	}
+	return t
+}
+
+// NodeUtilisation lists every schedulable node with its allocatable capacity
+// and the requests of the running pods bound to it.
+func NodeUtilisation(kubeCtx string) ([]NodeUsage, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeList, err := cs.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list nodes: %w", err)
+	}
+	pods, err := cs.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+
+	byName := map[string]*NodeUsage{}
+	out := make([]NodeUsage, 0, len(nodeList.Items))
+	for _, n := range nodeList.Items {
+		if n.Spec.Unschedulable {
+			continue
+		}
+		l := n.Labels
+		capType := l["karpenter.sh/capacity-type"]
+		if capType == "" {
+			switch l["eks.amazonaws.com/capacityType"] {
+			case "SPOT":
+				capType = "spot"
+			case "ON_DEMAND":
+				capType = "on-demand"
+			}
+		}
+		_, karp := l["karpenter.sh/nodepool"]
+		if !karp {
+			_, karp = l["karpenter.sh/provisioner-name"]
+		}
+		out = append(out, NodeUsage{
+			Name:         n.Name,
+			InstanceType: l["node.kubernetes.io/instance-type"],
+			CapacityType: capType,
+			Zone:         l["topology.kubernetes.io/zone"],
+			Karpenter:    karp,
+			AllocCPUm:    n.Status.Allocatable.Cpu().MilliValue(),
+			AllocMemMiB:  n.Status.Allocatable.Memory().Value() / (1024 * 1024),
+		})
+	}
+	for i := range out {
+		byName[out[i].Name] = &out[i]
+	}
+
+	for _, pod := range pods.Items {
+		node := byName[pod.Spec.NodeName]
+		if node == nil {
+			continue
+		}
+		req := podRequests(&pod)
+		if ownedByDaemonSet(&pod) {
+			node.DaemonCPUm += req.CPUm
+			node.DaemonMemMiB += req.MemMiB
+			node.DaemonPods++
+			continue
+		}
+		node.Pods = append(node.Pods, req)
+		if node.Pinned {
+			continue
+		}
+		switch {
+		case pod.Annotations["karpenter.sh/do-not-disrupt"] == "true",
+			pod.Annotations["karpenter.sh/do-not-evict"] == "true":
+			node.Pinned, node.PinnedReason = true, "do-not-disrupt pod "+pod.Namespace+"/"+pod.Name
+		case len(pod.OwnerReferences) == 0 && pod.Namespace != "kube-system":
+			node.Pinned, node.PinnedReason = true, "bare pod "+pod.Namespace+"/"+pod.Name
+		}
+	}
+	return out, nil
+}
+
+func podRequests(pod *corev1.Pod) PodRequest {
+	r := PodRequest{Namespace: pod.Namespace, Name: pod.Name}
+	for _, c := range pod.Spec.Containers {
+		if cpu := c.Resources.Requests.Cpu(); cpu != nil {
+			r.CPUm += cpu.MilliValue()
+		}
+		if mem := c.Resources.Requests.Memory(); mem != nil {
+			r.MemMiB += mem.Value() / (1024 * 1024)
+		}
+	}
+	return r
+}
+
+func ownedByDaemonSet(pod *corev1.Pod) bool {
+	for _, o := range pod.OwnerReferences {
+		if o.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
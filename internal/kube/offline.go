@@ -0,0 +1,162 @@
+package kube
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/kemilad/karpx/internal/manifest"
+)
+
+// ProfileFromObjects builds a WorkloadProfile from declared manifests instead
+// of a live cluster — for planning NodePools before the cluster exists.
+//
+// Each workload contributes its pod template requests multiplied by the
+// number of pods it would run: replicas for Deployments/StatefulSets/
+// ReplicaSets (default 1), parallelism for Jobs and CronJobs, and 1 for bare
+// Pods. DaemonSets are counted once per namespace since the node count is
+// not known yet. HorizontalPodAutoscalers among the objects add headroom for
+// their target scaling to maxReplicas.
+func ProfileFromObjects(objs []manifest.Object) *WorkloadProfile {
+	p := &WorkloadProfile{}
+	nsSet := map[string]struct{}{}
+	templates := map[string]template{}
+	var hpas []manifest.Object
+
+	for _, o := range objs {
+		var podSpec map[string]any
+		replicas := int64(1)
+
+		switch o.Kind() {
+		case "Deployment", "StatefulSet", "ReplicaSet":
+			podSpec = o.Map("spec", "template", "spec")
+			if r, ok := number(o.Get("spec", "replicas")); ok {
+				replicas = r
+			}
+		case "DaemonSet":
+			podSpec = o.Map("spec", "template", "spec")
+		case "Job":
+			podSpec = o.Map("spec", "template", "spec")
+			if r, ok := number(o.Get("spec", "parallelism")); ok {
+				replicas = r
+			}
+			p.HasBatchJobs = true
+		case "CronJob":
+			podSpec = o.Map("spec", "jobTemplate", "spec", "template", "spec")
+			if r, ok := number(o.Get("spec", "jobTemplate", "spec", "parallelism")); ok {
+				replicas = r
+			}
+			p.HasBatchJobs = true
+		case "Pod":
+			podSpec = o.Map("spec")
+		case "HorizontalPodAutoscaler":
+			hpas = append(hpas, o)
+			continue
+		default:
+			continue
+		}
+		if podSpec == nil || replicas <= 0 {
+			continue
+		}
+
+		ns := o.Namespace()
+		if ns == "" {
+			ns = "default"
+		}
+		nsSet[ns] = struct{}{}
+
+		var podCPUm, podMemMiB, podEphMiB int64
+		containers, _ := podSpec["containers"].([]any)
+		for _, c := range containers {
+			cm, _ := c.(map[string]any)
+			req := manifest.Object(cm).Map("resources", "requests")
+			if req == nil {
+				// Requests default to limits when only limits are set.
+				req = manifest.Object(cm).Map("resources", "limits")
+			}
+			for name, v := range req {
+				switch name {
+				case "cpu":
+					if q, err := resource.ParseQuantity(stringify(v)); err == nil {
+						podCPUm += q.MilliValue()
+					}
+				case "memory":
+					if q, err := resource.ParseQuantity(stringify(v)); err == nil {
+						podMemMiB += q.Value() / (1024 * 1024)
+					}
+				case "ephemeral-storage":
+					if q, err := resource.ParseQuantity(stringify(v)); err == nil {
+						podEphMiB += q.Value() / (1024 * 1024)
+					}
+				case "nvidia.com/gpu", "amd.com/gpu", "accelerator.google.com/gpu":
+					p.HasGPU = true
+				}
+			}
+		}
+
+		templates[ns+"/"+o.Kind()+"/"+o.Name()] = template{podCPUm, podMemMiB, int32(replicas)}
+
+		p.TotalPods += int(replicas)
+		p.TotalCPUm += podCPUm * replicas
+		p.TotalMemMiB += podMemMiB * replicas
+		if podCPUm > p.MaxPodCPUm {
+			p.MaxPodCPUm = podCPUm
+		}
+		if podMemMiB > p.MaxPodMemMiB {
+			p.MaxPodMemMiB = podMemMiB
+		}
+		p.TotalEphemeralMiB += podEphMiB * replicas
+		if podEphMiB > p.MaxPodEphemeralMiB {
+			p.MaxPodEphemeralMiB = podEphMiB
+		}
+	}
+	p.Namespaces = len(nsSet)
+
+	for _, h := range hpas {
+		ns := h.Namespace()
+		if ns == "" {
+			ns = "default"
+		}
+		t, ok := templates[ns+"/"+h.String("spec", "scaleTargetRef", "kind")+"/"+h.String("spec", "scaleTargetRef", "name")]
+		maxReplicas, _ := number(h.Get("spec", "maxReplicas"))
+		if !ok || maxReplicas <= int64(t.replicas) {
+			continue
+		}
+		extra := maxReplicas - int64(t.replicas)
+		p.Headroom.CPUm += extra * t.cpuM
+		p.Headroom.MemMiB += extra * t.memMiB
+		p.Headroom.HPAs++
+	}
+
+	if p.TotalCPUm > 0 {
+		p.MemPerCPUGiB = (float64(p.TotalMemMiB) / 1024.0) / (float64(p.TotalCPUm) / 1000.0)
+	}
+	if p.TotalCPUm == 0 && p.TotalMemMiB == 0 {
+		p.NoRequests = true
+	}
+	return p
+}
+
+// number reads an integer field decoded from YAML/JSON (always float64).
+func number(v any) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// stringify turns a decoded quantity (string or number) back into text.
+func stringify(v any) string {
+	switch n := v.(type) {
+	case string:
+		return strings.TrimSpace(n)
+	case float64:
+		return resource.NewMilliQuantity(int64(n*1000), resource.DecimalSI).String()
+	}
+	return ""
+}
@@ -3,6 +3,7 @@ package kube
 import (
 	"context"
 	"strings"
+	"sync"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -56,11 +57,56 @@ var providerMeta = map[Provider]ProviderMeta{
 	},
 }
 
+// ─────────────────────────────────────────────────────────────────────────────
+// Plugin providers
+// Providers karpx does not know are added at run time (see package plugin).
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Detector reports whether a cluster belongs to a registered provider, given
+// its context, API server URL and the spec.providerID of one node ("" when
+// unknown).
+type Detector func(kubeCtx, serverURL, providerID string) bool
+
+type registration struct {
+	meta    ProviderMeta
+	aliases []string
+	detect  Detector
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[Provider]registration{}
+	order      []Provider // registration order, so detection is deterministic
+)
+
+// RegisterProvider adds a provider that built-in detection does not know.
+// aliases are extra names ParseProvider accepts; detect may be nil.
+func RegisterProvider(p Provider, meta ProviderMeta, aliases []string, detect Detector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[p]; !ok {
+		order = append(order, p)
+	}
+	registry[p] = registration{meta: meta, aliases: aliases, detect: detect}
+}
+
+// Registered returns the providers added with RegisterProvider.
+func Registered() []Provider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Provider(nil), order...)
+}
+
 // Meta returns display metadata for this provider.
 func (p Provider) Meta() ProviderMeta {
 	if m, ok := providerMeta[p]; ok {
 		return m
 	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if r, ok := registry[p]; ok {
+		return r.meta
+	}
 	return providerMeta[ProviderUnknown]
 }
 
@@ -76,18 +122,32 @@ func ParseProvider(s string) Provider {
 		return ProviderAzure
 	case "gcp", "gke", "google":
 		return ProviderGCP
-	default:
-		return ProviderUnknown
 	}
+	name := strings.ToLower(strings.TrimSpace(s))
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, p := range order {
+		if string(p) == name {
+			return p
+		}
+		for _, a := range registry[p].aliases {
+			if strings.ToLower(a) == name {
+				return p
+			}
+		}
+	}
+	return ProviderUnknown
 }
 
 // DetectProvider attempts to identify the cloud provider for a cluster by:
 //  1. Parsing the kubeconfig server URL (instant, no API call)
 //  2. Falling back to reading a node's spec.providerID (one cluster call)
+//  3. Asking registered plugin providers about both
 //
 // Returns ProviderUnknown when detection fails (e.g. on-prem, local clusters).
 func DetectProvider(kubeCtx string) Provider {
 	// ── Step 1: kubeconfig server URL ─────────────────────────────────────
+	var server string
 	rules := clientcmd.NewDefaultClientConfigLoadingRules()
 	cfg, err := rules.Load()
 	if err == nil {
@@ -97,7 +157,8 @@ func DetectProvider(kubeCtx string) Provider {
 		}
 		if kubeCtxObj, ok := cfg.Contexts[name]; ok {
 			if cluster, ok := cfg.Clusters[kubeCtxObj.Cluster]; ok {
-				if p := fromServerURL(cluster.Server); p != ProviderUnknown {
+				server = cluster.Server
+				if p := fromServerURL(server); p != ProviderUnknown {
 					return p
 				}
 			}
@@ -105,7 +166,81 @@ func DetectProvider(kubeCtx string) Provider {
 	}
 
 	// ── Step 2: node providerID ────────────────────────────────────────────
-	return fromNodeProviderID(kubeCtx)
+	pid := nodeProviderID(kubeCtx)
+	if p := fromProviderIDString(pid); p != ProviderUnknown {
+		return p
+	}
+
+	// ── Step 3: plugin providers ───────────────────────────────────────────
+	return fromRegistered(kubeCtx, server, pid)
+}
+
+// ContextName resolves kubeCtx to an actual kubeconfig context name, returning
+// the current context when kubeCtx is empty. The cloud CLIs name contexts
+// after the cluster (AKS) or embed project/location/cluster (GKE), so this is
+// a good default for cluster-name prompts.
+func ContextName(kubeCtx string) string {
+	if kubeCtx != "" {
+		return kubeCtx
+	}
+	cfg, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		return ""
+	}
+	return cfg.CurrentContext
+}
+
+// ServerURL returns the API server URL of kubeCtx (current context when
+// empty), or "" when it cannot be resolved.
+func ServerURL(kubeCtx string) string {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return ""
+	}
+	return restCfg.Host
+}
+
+// ContextsByServer maps each kubeconfig API server host (scheme and port
+// stripped, lower-cased) to the first context that points at it. Used to
+// match clusters found through cloud APIs to existing kubeconfig entries.
+func ContextsByServer() map[string]string {
+	out := map[string]string{}
+	cfg, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		return out
+	}
+	for name, c := range cfg.Contexts {
+		cluster, ok := cfg.Clusters[c.Cluster]
+		if !ok {
+			continue
+		}
+		host := ServerHost(cluster.Server)
+		if prev, ok := out[host]; !ok || name < prev {
+			out[host] = name
+		}
+	}
+	return out
+}
+
+// ServerHost normalises an API server URL or bare host for comparison.
+// "https://ABC.gr7.us-east-1.eks.amazonaws.com:443/" → "abc.gr7.us-east-1.eks.amazonaws.com"
+func ServerHost(server string) string {
+	h := strings.ToLower(strings.TrimSpace(server))
+	h = strings.TrimPrefix(h, "https://")
+	h = strings.TrimPrefix(h, "http://")
+	if i := strings.IndexByte(h, '/'); i >= 0 {
+		h = h[:i]
+	}
+	if i := strings.LastIndexByte(h, ':'); i >= 0 && !strings.Contains(h[i:], "]") {
+		h = h[:i]
+	}
+	return h
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -128,7 +263,7 @@ func fromServerURL(serverURL string) Provider {
 	return ProviderUnknown
 }
 
-func fromNodeProviderID(kubeCtx string) Provider {
+func nodeProviderID(kubeCtx string) string {
 	overrides := &clientcmd.ConfigOverrides{}
 	if kubeCtx != "" {
 		overrides.CurrentContext = kubeCtx
@@ -137,17 +272,28 @@ func fromNodeProviderID(kubeCtx string) Provider {
 		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
 	).ClientConfig()
 	if err != nil {
-		return ProviderUnknown
+		return ""
 	}
 	cs, err := kubernetes.NewForConfig(restCfg)
 	if err != nil {
-		return ProviderUnknown
+		return ""
 	}
 	nodes, err := cs.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{Limit: 1})
 	if err != nil || len(nodes.Items) == 0 {
-		return ProviderUnknown
+		return ""
+	}
+	return nodes.Items[0].Spec.ProviderID
+}
+
+func fromRegistered(kubeCtx, server, pid string) Provider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, p := range order {
+		if d := registry[p].detect; d != nil && d(kubeCtx, server, pid) {
+			return p
+		}
 	}
-	return fromProviderIDString(nodes.Items[0].Spec.ProviderID)
+	return ProviderUnknown
 }
 
 func fromProviderIDString(pid string) Provider {
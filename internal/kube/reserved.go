@@ -0,0 +1,72 @@
+package kube
+
+// Node allocatable is capacity minus kube-reserved, system-reserved and the
+// hard eviction threshold. The figures below follow the EKS-optimised AMI
+// (and Karpenter's defaults for EC2NodeClass), which scale with node size:
+//
+//	CPU     6% of the 1st core, 1% of the 2nd, 0.5% of the 3rd and 4th,
+//	        0.25% of every core above 4
+//	memory  11 MiB per schedulable pod + 255 MiB, plus 100 MiB eviction
+//
+// AKS and GKE reserve on similar sliding scales, so the same model is used
+// as an approximation there.
+
+// EvictionMemMiB is the default memory.available hard eviction threshold.
+const EvictionMemMiB = 100
+
+// ReservedCPUm returns the kube-reserved CPU in millicores for a node with
+// the given number of vCPUs.
+func ReservedCPUm(vcpu int) int64 {
+	var m float64
+	for core := 1; core <= vcpu; core++ {
+		switch {
+		case core == 1:
+			m += 60
+		case core == 2:
+			m += 10
+		case core <= 4:
+			m += 5
+		default:
+			m += 2.5
+		}
+	}
+	return int64(m + 0.5)
+}
+
+// MaxPods approximates the pods-per-node limit of the VPC CNI for a node
+// size: ENI-limited on small nodes, capped at 110 below 30 vCPUs and 250
+// above, as the EKS AMI does.
+func MaxPods(vcpu int) int {
+	switch {
+	case vcpu <= 2:
+		return 29
+	case vcpu <= 8:
+		return 58
+	case vcpu <= 30:
+		return 110
+	default:
+		return 250
+	}
+}
+
+// ReservedMemMiB returns kube-reserved memory plus the eviction threshold.
+func ReservedMemMiB(vcpu int) int64 {
+	return ReservedMemMiBFor(vcpu, CNIUnknown)
+}
+
+// ReservedMemMiBFor is ReservedMemMiB for a node whose pod limit follows
+// mode: the reservation grows with the pods the node may run.
+func ReservedMemMiBFor(vcpu int, mode CNIMode) int64 {
+	return int64(11*MaxPodsFor(vcpu, mode)+255) + EvictionMemMiB
+}
+
+// Allocatable returns the schedulable CPU (millicores) and memory (MiB) of a
+// node with the given capacity.
+func Allocatable(vcpu int, memMiB int64) (cpuM, allocMemMiB int64) {
+	return AllocatableFor(vcpu, memMiB, CNIUnknown)
+}
+
+// AllocatableFor is Allocatable under the pod limit of mode.
+func AllocatableFor(vcpu int, memMiB int64, mode CNIMode) (cpuM, allocMemMiB int64) {
+	return int64(vcpu)*1000 - ReservedCPUm(vcpu), memMiB - ReservedMemMiBFor(vcpu, mode)
+}
@@ -0,0 +1,72 @@
+package kube
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// zoneKeys are the PV node-affinity keys that pin a volume to one zone:
+// the well-known label plus the CSI topology keys of EBS, Azure Disk and GCE PD.
+var zoneKeys = map[string]bool{
+	"topology.kubernetes.io/zone":            true,
+	"failure-domain.beta.kubernetes.io/zone": true,
+	"topology.ebs.csi.aws.com/zone":          true,
+	"topology.disk.csi.azure.com/zone":       true,
+	"topology.gke.io/zone":                   true,
+}
+
+// volumeZones returns the zones holding zonal volumes mounted by StatefulSet
+// pods, and how many StatefulSets mount them. Such pods can only ever run in
+// the zone of their volume, whatever the NodePool allows.
+func volumeZones(cs *kubernetes.Clientset, pods []corev1.Pod) (zones []string, statefulSets int) {
+	pvs, err := cs.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, 0
+	}
+	claimZones := map[string][]string{} // namespace/claim → zones
+	for _, pv := range pvs.Items {
+		if pv.Spec.ClaimRef == nil || pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+			continue
+		}
+		for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+			for _, expr := range term.MatchExpressions {
+				if zoneKeys[expr.Key] && expr.Operator == corev1.NodeSelectorOpIn {
+					key := pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+					claimZones[key] = append(claimZones[key], expr.Values...)
+				}
+			}
+		}
+	}
+
+	zoneSet := map[string]bool{}
+	stsSet := map[string]bool{}
+	for _, pod := range pods {
+		owner := ""
+		for _, ref := range pod.OwnerReferences {
+			if ref.Kind == "StatefulSet" {
+				owner = pod.Namespace + "/" + ref.Name
+			}
+		}
+		if owner == "" {
+			continue
+		}
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim == nil {
+				continue
+			}
+			for _, z := range claimZones[pod.Namespace+"/"+v.PersistentVolumeClaim.ClaimName] {
+				zoneSet[z] = true
+				stsSet[owner] = true
+			}
+		}
+	}
+	for z := range zoneSet {
+		zones = append(zones, z)
+	}
+	sort.Strings(zones)
+	return zones, len(stsSet)
+}
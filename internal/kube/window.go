@@ -0,0 +1,203 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kemilad/karpx/internal/outbound"
+)
+
+// DemandPercentile is the percentile of cluster-wide demand that windowed
+// profiles are built for — high enough to cover nightly batch peaks, low
+// enough to ignore a single runaway spike.
+const DemandPercentile = 95
+
+// SampleWorkloads snapshots the cluster every interval for duration and
+// returns a profile of p95 demand across the samples. onSample, if non-nil,
+// is called after each snapshot (1-based) so callers can show progress.
+//
+// Totals and the memory/CPU ratio use the percentile; the largest pod is the
+// maximum seen, because nodes must fit it whenever it runs.
+func SampleWorkloads(kubeCtx string, interval, duration time.Duration, onSample func(n, total int)) (*WorkloadProfile, error) {
+	if interval <= 0 || duration < interval {
+		return nil, fmt.Errorf("sample interval must be positive and no longer than the window")
+	}
+	total := int(duration/interval) + 1
+	var samples []*WorkloadProfile
+	for i := 0; i < total; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		p, err := AnalyzeWorkloads(kubeCtx)
+		if err != nil {
+			// A transient API error should not lose the samples taken so far.
+			if len(samples) == 0 {
+				return nil, err
+			}
+			continue
+		}
+		samples = append(samples, p)
+		if onSample != nil {
+			onSample(i+1, total)
+		}
+	}
+	return combineSamples(samples, fmt.Sprintf("p%d over %s (%d samples every %s)",
+		DemandPercentile, duration, len(samples), interval)), nil
+}
+
+// ProfileFromPrometheus builds a p95 profile from kube-state-metrics series
+// stored in Prometheus over window. base, usually a live snapshot, supplies
+// what metrics cannot (GPU use, batch jobs, namespaces); it may be nil.
+func ProfileFromPrometheus(promURL string, window time.Duration, base *WorkloadProfile) (*WorkloadProfile, error) {
+	end := time.Now()
+	start := end.Add(-window)
+	// ~250 points per series keeps responses small for any window.
+	step := max(time.Minute, window/250)
+
+	queries := map[string]string{
+		"cpu":    `sum(kube_pod_container_resource_requests{resource="cpu"} * on(namespace, pod) group_left() (kube_pod_status_phase{phase="Running"} == 1))`,
+		"mem":    `sum(kube_pod_container_resource_requests{resource="memory"} * on(namespace, pod) group_left() (kube_pod_status_phase{phase="Running"} == 1))`,
+		"pods":   `count(kube_pod_status_phase{phase="Running"} == 1)`,
+		"maxcpu": `max(sum by (namespace, pod) (kube_pod_container_resource_requests{resource="cpu"}))`,
+		"maxmem": `max(sum by (namespace, pod) (kube_pod_container_resource_requests{resource="memory"}))`,
+	}
+	series := map[string][]float64{}
+	for name, q := range queries {
+		vals, err := promRange(promURL, q, start, end, step)
+		if err != nil {
+			return nil, fmt.Errorf("prometheus %s query: %w", name, err)
+		}
+		series[name] = vals
+	}
+	if len(series["cpu"]) == 0 && len(series["mem"]) == 0 {
+		return nil, fmt.Errorf("no kube-state-metrics data in Prometheus for the last %s", window)
+	}
+
+	p := &WorkloadProfile{}
+	if base != nil {
+		*p = *base
+	}
+	p.TotalCPUm = int64(percentile(series["cpu"], DemandPercentile) * 1000)
+	p.TotalMemMiB = int64(percentile(series["mem"], DemandPercentile) / (1024 * 1024))
+	p.TotalPods = int(percentile(series["pods"], DemandPercentile))
+	p.MaxPodCPUm = max(p.MaxPodCPUm, int64(maxOf(series["maxcpu"])*1000))
+	p.MaxPodMemMiB = max(p.MaxPodMemMiB, int64(maxOf(series["maxmem"])/(1024*1024)))
+	p.MemPerCPUGiB = 0
+	if p.TotalCPUm > 0 {
+		p.MemPerCPUGiB = (float64(p.TotalMemMiB) / 1024.0) / (float64(p.TotalCPUm) / 1000.0)
+	}
+	p.NoRequests = p.TotalCPUm == 0 && p.TotalMemMiB == 0
+	p.Window = fmt.Sprintf("p%d over %s (Prometheus)", DemandPercentile, window)
+	return p, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+func combineSamples(samples []*WorkloadProfile, window string) *WorkloadProfile {
+	p := &WorkloadProfile{Window: window}
+	var cpu, mem, pods []float64
+	for _, s := range samples {
+		cpu = append(cpu, float64(s.TotalCPUm))
+		mem = append(mem, float64(s.TotalMemMiB))
+		pods = append(pods, float64(s.TotalPods))
+		p.MaxPodCPUm = max(p.MaxPodCPUm, s.MaxPodCPUm)
+		p.MaxPodMemMiB = max(p.MaxPodMemMiB, s.MaxPodMemMiB)
+		p.Namespaces = max(p.Namespaces, s.Namespaces)
+		p.HasGPU = p.HasGPU || s.HasGPU
+		p.HasBatchJobs = p.HasBatchJobs || s.HasBatchJobs
+		if s.Headroom.CPUm > p.Headroom.CPUm {
+			p.Headroom = s.Headroom
+		}
+		p.DoNotDisrupt = max(p.DoNotDisrupt, s.DoNotDisrupt)
+		if len(s.Compliance) > len(p.Compliance) {
+			p.Compliance = s.Compliance
+		}
+		if s.ZonalStateful > p.ZonalStateful {
+			p.VolumeZones, p.ZonalStateful = s.VolumeZones, s.ZonalStateful
+		}
+		if s.CNI != CNIUnknown {
+			p.CNI = s.CNI
+		}
+		p.TotalEphemeralMiB = max(p.TotalEphemeralMiB, s.TotalEphemeralMiB)
+		p.MaxPodEphemeralMiB = max(p.MaxPodEphemeralMiB, s.MaxPodEphemeralMiB)
+		p.ImageMiB = max(p.ImageMiB, s.ImageMiB)
+	}
+	p.TotalCPUm = int64(percentile(cpu, DemandPercentile))
+	p.TotalMemMiB = int64(percentile(mem, DemandPercentile))
+	p.TotalPods = int(percentile(pods, DemandPercentile))
+	if p.TotalCPUm > 0 {
+		p.MemPerCPUGiB = (float64(p.TotalMemMiB) / 1024.0) / (float64(p.TotalCPUm) / 1000.0)
+	}
+	p.NoRequests = p.TotalCPUm == 0 && p.TotalMemMiB == 0
+	return p
+}
+
+// percentile returns the nearest-rank percentile of vals (0 when empty).
+func percentile(vals []float64, pct int) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	rank := (pct*len(sorted) + 99) / 100 // ceil(pct/100 * n)
+	return sorted[max(0, rank-1)]
+}
+
+func maxOf(vals []float64) float64 {
+	m := 0.0
+	for _, v := range vals {
+		m = max(m, v)
+	}
+	return m
+}
+
+// promRange runs a range query and returns the values of the first series.
+func promRange(base, query string, start, end time.Time, step time.Duration) ([]float64, error) {
+	u := strings.TrimRight(base, "/") + "/api/v1/query_range?" + url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.Itoa(int(step.Seconds()))},
+	}.Encode()
+
+	client := outbound.Client(30 * time.Second)
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			Result []struct {
+				Values [][2]any `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("HTTP %d: %w", resp.StatusCode, err)
+	}
+	if body.Status != "success" {
+		return nil, fmt.Errorf("%s", body.Error)
+	}
+	if len(body.Data.Result) == 0 {
+		return nil, nil
+	}
+	var out []float64
+	for _, v := range body.Data.Result[0].Values {
+		s, _ := v[1].(string)
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
@@ -12,17 +12,34 @@ import (
 // WorkloadProfile summarises the resource demands of all running workloads.
 type WorkloadProfile struct {
 	TotalPods     int
-	TotalCPUm     int64   // aggregate CPU requests in millicores
-	TotalMemMiB   int64   // aggregate memory requests in MiB
-	MaxPodCPUm    int64   // largest single-pod CPU request (millicores)
-	MaxPodMemMiB  int64   // largest single-pod memory request (MiB)
-	HasGPU        bool    // any container requests nvidia/amd/google GPU resources
-	HasBatchJobs  bool    // Jobs or CronJobs detected in the cluster
-	MemPerCPUGiB  float64 // average GiB of memory per CPU core across all pods
-	Namespaces    int     // number of distinct namespaces that have running pods
-	NoRequests    bool    // true when no resource requests are set (nothing to analyse)
+	TotalCPUm     int64    // aggregate CPU requests in millicores
+	TotalMemMiB   int64    // aggregate memory requests in MiB
+	MaxPodCPUm    int64    // largest single-pod CPU request (millicores)
+	MaxPodMemMiB  int64    // largest single-pod memory request (MiB)
+	HasGPU        bool     // any container requests nvidia/amd/google GPU resources
+	HasBatchJobs  bool     // Jobs or CronJobs detected in the cluster
+	MemPerCPUGiB  float64  // average GiB of memory per CPU core across all pods
+	Namespaces    int      // number of distinct namespaces that have running pods
+	NoRequests    bool     // true when no resource requests are set (nothing to analyse)
+	Window        string   // "" for a point-in-time snapshot, else how demand was aggregated
+	Headroom      Headroom // growth available to HPAs/VPAs beyond today's requests
+	VolumeZones   []string // zones of zonal PVs mounted by StatefulSet pods
+	ZonalStateful int      // StatefulSets whose pods are bound to a zone by their volumes
+	DoNotDisrupt  int      // running pods annotated karpenter.sh/do-not-disrupt
+	Compliance    []string // namespaces labelled as regulated (pci, hipaa, …)
+	CNI           CNIMode  // how pods get IP addresses ("" when unknown)
+
+	TotalEphemeralMiB  int64 // aggregate ephemeral-storage requests in MiB
+	MaxPodEphemeralMiB int64 // largest single-pod ephemeral-storage request (MiB)
+	ImageMiB           int64 // largest set of pod images pulled on one node (0 = unknown)
 }
 
+// PeakCPUm is the CPU demand with every autoscaler at its maximum.
+func (p *WorkloadProfile) PeakCPUm() int64 { return p.TotalCPUm + p.Headroom.CPUm }
+
+// PeakMemMiB is the memory demand with every autoscaler at its maximum.
+func (p *WorkloadProfile) PeakMemMiB() int64 { return p.TotalMemMiB + p.Headroom.MemMiB }
+
 // AnalyzeWorkloads connects to the cluster and returns a WorkloadProfile built
 // from all currently running pods, Jobs, and CronJobs.
 //
@@ -46,6 +63,7 @@ func AnalyzeWorkloads(kubeCtx string) (*WorkloadProfile, error) {
 
 	p := &WorkloadProfile{}
 	nsSet := map[string]struct{}{}
+	images := map[string]bool{}
 
 	// ── Running pods ───────────────────────────────────────────────────────
 	pods, err := cs.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
@@ -58,15 +76,22 @@ func AnalyzeWorkloads(kubeCtx string) (*WorkloadProfile, error) {
 	for _, pod := range pods.Items {
 		nsSet[pod.Namespace] = struct{}{}
 		p.TotalPods++
+		if hasDoNotDisrupt(&pod) {
+			p.DoNotDisrupt++
+		}
 
-		var podCPUm, podMemMiB int64
+		var podCPUm, podMemMiB, podEphMiB int64
 		for _, c := range pod.Spec.Containers {
+			images[c.Image] = true
 			if cpu := c.Resources.Requests.Cpu(); cpu != nil {
 				podCPUm += cpu.MilliValue()
 			}
 			if mem := c.Resources.Requests.Memory(); mem != nil {
 				podMemMiB += mem.Value() / (1024 * 1024)
 			}
+			if eph := c.Resources.Requests.StorageEphemeral(); eph != nil {
+				podEphMiB += eph.Value() / (1024 * 1024)
+			}
 			for rname := range c.Resources.Requests {
 				switch string(rname) {
 				case "nvidia.com/gpu", "amd.com/gpu", "accelerator.google.com/gpu":
@@ -83,8 +108,18 @@ func AnalyzeWorkloads(kubeCtx string) (*WorkloadProfile, error) {
 		if podMemMiB > p.MaxPodMemMiB {
 			p.MaxPodMemMiB = podMemMiB
 		}
+		p.TotalEphemeralMiB += podEphMiB
+		if podEphMiB > p.MaxPodEphemeralMiB {
+			p.MaxPodEphemeralMiB = podEphMiB
+		}
 	}
 	p.Namespaces = len(nsSet)
+	p.ImageMiB = imageMiB(cs, images)
+
+	// ── Storage topology ───────────────────────────────────────────────────
+	p.VolumeZones, p.ZonalStateful = volumeZones(cs, pods.Items)
+	p.Compliance = complianceNamespaces(cs)
+	p.CNI = detectCNI(cs)
 
 	// ── Batch jobs ─────────────────────────────────────────────────────────
 	if jobs, err := cs.BatchV1().Jobs("").List(context.TODO(), metav1.ListOptions{}); err == nil && len(jobs.Items) > 0 {
@@ -94,6 +129,9 @@ func AnalyzeWorkloads(kubeCtx string) (*WorkloadProfile, error) {
 		p.HasBatchJobs = true
 	}
 
+	// ── Autoscaler headroom ────────────────────────────────────────────────
+	p.Headroom = scaleHeadroom(restCfg, cs)
+
 	// ── Derived ratios ─────────────────────────────────────────────────────
 	if p.TotalCPUm > 0 {
 		p.MemPerCPUGiB = (float64(p.TotalMemMiB) / 1024.0) / (float64(p.TotalCPUm) / 1000.0)
@@ -0,0 +1,270 @@
+// Package lint checks live NodePools and EC2NodeClasses for configurations
+// that work but cost money, hurt availability or block future upgrades: no
+// limits, a single instance type, disabled consolidation, no disruption
+// budgets, unconstrained requirements, deprecated fields, and Spot-only pools
+// under workloads whose PodDisruptionBudgets allow no disruption.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kemilad/karpx/internal/manifest"
+	"github.com/kemilad/karpx/internal/preflight"
+)
+
+// Severity of a finding, most severe first.
+type Severity string
+
+const (
+	// Error breaks provisioning or is rejected by the API.
+	Error Severity = "error"
+	// Warning is valid but risky: cost, availability or upgrade trouble.
+	Warning Severity = "warning"
+	// Info is worth knowing but often intentional.
+	Info Severity = "info"
+)
+
+// rank orders severities for sorting and --fail-on.
+func (s Severity) rank() int {
+	switch s {
+	case Error:
+		return 2
+	case Warning:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast reports whether s is as severe as min.
+func (s Severity) AtLeast(min Severity) bool { return s.rank() >= min.rank() }
+
+// ParseSeverity converts a --fail-on value; "none" returns "".
+func ParseSeverity(s string) (Severity, error) {
+	switch s {
+	case "error", "warning", "info":
+		return Severity(s), nil
+	case "none", "":
+		return "", nil
+	}
+	return "", fmt.Errorf("--fail-on must be error, warning, info or none, got %q", s)
+}
+
+// Finding is one rule violated by one resource.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name"`
+	Field    string   `json:"field,omitempty"`
+	Message  string   `json:"message"`
+	Fix      string   `json:"fix,omitempty"`
+}
+
+// Rules lists every rule and what it checks, for `karpx lint --rules`.
+var Rules = []struct{ Name, Description string }{
+	{"no-limits", "NodePool has no spec.limits — a runaway workload can launch unbounded capacity"},
+	{"single-instance-type", "NodePool allows one instance type — launches fail when it is out of capacity"},
+	{"no-consolidation", "NodePool never consolidates underutilized nodes"},
+	{"no-budgets", "NodePool has no disruption budgets — the 10% default applies at any time of day"},
+	{"broad-requirements", "NodePool does not constrain instance category, family, generation or size"},
+	{"spot-only-strict-pdbs", "Spot-only NodePool while PodDisruptionBudgets allow no disruptions"},
+	{"missing-nodeclass", "NodePool references an EC2NodeClass that does not exist"},
+	{"unused-nodeclass", "EC2NodeClass is not referenced by any NodePool"},
+	{"unpinned-ami", "EC2NodeClass follows @latest — new AMIs roll out without review"},
+	{"deprecated", "field or API removed or deprecated in Karpenter v1"},
+}
+
+// Cluster is what Run lints.
+type Cluster struct {
+	NodePools   []manifest.Object
+	NodeClasses []manifest.Object
+	// StrictPDBs are PodDisruptionBudgets (namespace/name) that currently
+	// allow no disruptions.
+	StrictPDBs []string
+}
+
+// Load reads the Karpenter resources and PodDisruptionBudgets of a cluster.
+func Load(kubeCtx string) (*Cluster, error) {
+	pools, err := manifest.ListCluster(kubeCtx, "nodepools.karpenter.sh", false)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cluster{NodePools: pools}
+	// Other providers have their own NodeClass kinds; only AWS is linted.
+	c.NodeClasses, _ = manifest.ListCluster(kubeCtx, "ec2nodeclasses.karpenter.k8s.aws", false)
+	if pdbs, err := manifest.ListCluster(kubeCtx, "poddisruptionbudgets", true); err == nil {
+		for _, p := range pdbs {
+			allowed, ok := p.Get("status", "disruptionsAllowed").(float64)
+			if ok && allowed == 0 && p.Get("status", "expectedPods") != float64(0) {
+				c.StrictPDBs = append(c.StrictPDBs, p.Namespace()+"/"+p.Name())
+			}
+		}
+	}
+	return c, nil
+}
+
+// Run lints every NodePool and EC2NodeClass, most severe findings first.
+func Run(c *Cluster) []Finding {
+	var out []Finding
+	classes := map[string]bool{}
+	for _, nc := range c.NodeClasses {
+		classes[nc.Name()] = true
+	}
+	used := map[string]bool{}
+	for _, np := range c.NodePools {
+		ref := np.String("spec", "template", "spec", "nodeClassRef", "name")
+		used[ref] = true
+		out = append(out, nodePool(np, classes, len(c.NodeClasses) > 0, c.StrictPDBs)...)
+	}
+	for _, nc := range c.NodeClasses {
+		out = append(out, nodeClass(nc, used)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Severity.rank() > out[j].Severity.rank() })
+	return out
+}
+
+// requirement returns the operator and values of the NodePool requirement
+// on key, or "" when there is none.
+func requirement(np manifest.Object, key string) (string, []string) {
+	for _, r := range np.Slice("spec", "template", "spec", "requirements") {
+		m, ok := r.(map[string]any)
+		if !ok || m["key"] != key {
+			continue
+		}
+		op, _ := m["operator"].(string)
+		var vals []string
+		raw, _ := m["values"].([]any)
+		for _, v := range raw {
+			if s, ok := v.(string); ok {
+				vals = append(vals, s)
+			}
+		}
+		return op, vals
+	}
+	return "", nil
+}
+
+// sizeKeys constrain which instance types a NodePool may launch.
+var sizeKeys = []string{
+	"node.kubernetes.io/instance-type",
+	"karpenter.k8s.aws/instance-category",
+	"karpenter.k8s.aws/instance-family",
+	"karpenter.k8s.aws/instance-generation",
+	"karpenter.k8s.aws/instance-cpu",
+	"karpenter.k8s.aws/instance-size",
+	"karpenter.k8s.aws/instance-memory",
+}
+
+func nodePool(np manifest.Object, classes map[string]bool, haveClasses bool, strictPDBs []string) []Finding {
+	var out []Finding
+	add := func(rule string, sev Severity, field, msg, fix string) {
+		out = append(out, Finding{Rule: rule, Severity: sev, Kind: "NodePool", Name: np.Name(), Field: field, Message: msg, Fix: fix})
+	}
+
+	if len(np.Map("spec", "limits")) == 0 {
+		add("no-limits", Warning, "spec.limits",
+			"no CPU or memory limit — a runaway deployment or bad HPA can launch unbounded capacity",
+			"set spec.limits.cpu and spec.limits.memory (karpx nodes sizes them from demand)")
+	}
+
+	if op, vals := requirement(np, "node.kubernetes.io/instance-type"); op == "In" && len(vals) == 1 {
+		add("single-instance-type", Warning, "spec.template.spec.requirements",
+			"only "+vals[0]+" may launch — an insufficient-capacity error leaves pods pending",
+			"allow several types or families of similar size")
+	}
+
+	policy := np.String("spec", "disruption", "consolidationPolicy")
+	if np.String("spec", "disruption", "consolidateAfter") == "Never" {
+		add("no-consolidation", Warning, "spec.disruption.consolidateAfter",
+			"consolidateAfter: Never — nodes are never consolidated, even when empty",
+			"set consolidateAfter to a duration, e.g. 1m")
+	} else if policy == "WhenEmpty" {
+		add("no-consolidation", Info, "spec.disruption.consolidationPolicy",
+			"WhenEmpty only removes empty nodes; underutilized nodes are kept",
+			"use WhenEmptyOrUnderutilized unless workloads cannot tolerate moves")
+	}
+
+	// The API server fills in the default budget, so it counts as none.
+	budgets := np.Slice("spec", "disruption", "budgets")
+	if len(budgets) == 1 {
+		if b, _ := budgets[0].(map[string]any); len(b) == 1 && b["nodes"] == "10%" {
+			budgets = nil
+		}
+	}
+	if len(budgets) == 0 {
+		add("no-budgets", Info, "spec.disruption.budgets",
+			"only the default budget — Karpenter may disrupt 10% of nodes at any time of day",
+			"add budgets, e.g. a schedule that blocks disruption during business hours")
+	}
+
+	broad := true
+	for _, k := range sizeKeys {
+		if op, _ := requirement(np, k); op != "" {
+			broad = false
+			break
+		}
+	}
+	if broad {
+		add("broad-requirements", Warning, "spec.template.spec.requirements",
+			"any instance type may launch — including old generations, metal and very large sizes",
+			"constrain instance-category, instance-generation (Gt 5) or instance-cpu")
+	}
+
+	if op, vals := requirement(np, "karpenter.sh/capacity-type"); op == "In" && len(vals) == 1 && vals[0] == "spot" && len(strictPDBs) > 0 {
+		names := strictPDBs
+		if len(names) > 3 {
+			names = append(names[:3:3], fmt.Sprintf("%d more", len(strictPDBs)-3))
+		}
+		add("spot-only-strict-pdbs", Warning, "spec.template.spec.requirements",
+			fmt.Sprintf("Spot only, but %d PodDisruptionBudget(s) allow no disruptions (%v) — Spot interruptions do not wait for PDBs",
+				len(strictPDBs), names),
+			"allow on-demand as a fallback, or run those workloads on an on-demand pool")
+	}
+
+	ref := np.String("spec", "template", "spec", "nodeClassRef", "name")
+	kind := np.String("spec", "template", "spec", "nodeClassRef", "kind")
+	if haveClasses && ref != "" && (kind == "" || kind == "EC2NodeClass") && !classes[ref] {
+		add("missing-nodeclass", Error, "spec.template.spec.nodeClassRef.name",
+			"EC2NodeClass "+ref+" does not exist — this NodePool cannot launch nodes",
+			"create the EC2NodeClass or fix the reference")
+	}
+
+	out = append(out, deprecated(np)...)
+	return out
+}
+
+func nodeClass(nc manifest.Object, used map[string]bool) []Finding {
+	var out []Finding
+	add := func(rule string, sev Severity, field, msg, fix string) {
+		out = append(out, Finding{Rule: rule, Severity: sev, Kind: "EC2NodeClass", Name: nc.Name(), Field: field, Message: msg, Fix: fix})
+	}
+	if !used[nc.Name()] {
+		add("unused-nodeclass", Info, "", "not referenced by any NodePool", "delete it if it is no longer needed")
+	}
+	for _, t := range nc.Slice("spec", "amiSelectorTerms") {
+		m, _ := t.(map[string]any)
+		if a, _ := m["alias"].(string); strings.HasSuffix(a, "@latest") {
+			add("unpinned-ami", Info, "spec.amiSelectorTerms",
+				a+" rolls every new AMI out to all nodes through drift, unreviewed",
+				"pin a version (e.g. al2023@v20240807) in production and bump it deliberately (karpx drift)")
+		}
+	}
+	out = append(out, deprecated(nc)...)
+	return out
+}
+
+// deprecated reuses the preflight v1 checks: a blocker there is an error
+// here, since the field is already ignored or rejected.
+func deprecated(o manifest.Object) []Finding {
+	var out []Finding
+	for _, f := range preflight.Check("cluster", o, "1.0.0") {
+		sev := Warning
+		if f.Severity == preflight.Blocker {
+			sev = Error
+		}
+		out = append(out, Finding{Rule: "deprecated", Severity: sev, Kind: f.Kind, Name: f.Name, Field: f.Field, Message: f.Message, Fix: f.Fix})
+	}
+	return out
+}
@@ -0,0 +1,193 @@
+// Package manifest reads Kubernetes objects as untyped maps — from YAML/JSON
+// files, directories of manifests (GitOps checkouts, rendered Helm charts), or
+// the live cluster via kubectl — for commands that inspect or rewrite
+// resources whose Go types karpx does not link (Karpenter CRDs, old API
+// versions).
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Object is a decoded Kubernetes manifest.
+type Object map[string]any
+
+// Kind returns the object's kind.
+func (o Object) Kind() string { return o.String("kind") }
+
+// APIVersion returns the object's apiVersion.
+func (o Object) APIVersion() string { return o.String("apiVersion") }
+
+// Name returns metadata.name.
+func (o Object) Name() string { return o.String("metadata", "name") }
+
+// Namespace returns metadata.namespace.
+func (o Object) Namespace() string { return o.String("metadata", "namespace") }
+
+// Get returns the value at the given field path, or nil.
+func (o Object) Get(path ...string) any {
+	var cur any = map[string]any(o)
+	for _, p := range path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[p]
+	}
+	return cur
+}
+
+// Has reports whether a value is set at the given field path.
+func (o Object) Has(path ...string) bool { return o.Get(path...) != nil }
+
+// String returns the string at the given field path, or "".
+func (o Object) String(path ...string) string {
+	s, _ := o.Get(path...).(string)
+	return s
+}
+
+// Map returns the map at the given field path, or nil.
+func (o Object) Map(path ...string) map[string]any {
+	m, _ := o.Get(path...).(map[string]any)
+	return m
+}
+
+// Slice returns the list at the given field path, or nil.
+func (o Object) Slice(path ...string) []any {
+	s, _ := o.Get(path...).([]any)
+	return s
+}
+
+// Sourced is an object together with where it was read from
+// ("cluster" or a file path).
+type Sourced struct {
+	Source string
+	Object Object
+}
+
+// DecodeFile reads every YAML/JSON document in path that looks like a
+// Kubernetes object (has apiVersion and kind). List kinds are flattened.
+func DecodeFile(path string) ([]Object, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// Decode splits a multi-document YAML/JSON stream into Kubernetes objects.
+func Decode(data []byte) ([]Object, error) {
+	r := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	var out []Object
+	for {
+		doc, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		var o Object
+		if err := yaml.Unmarshal(doc, &o); err != nil {
+			return nil, err
+		}
+		if o == nil || o["kind"] == nil || o["apiVersion"] == nil {
+			continue
+		}
+		if items, ok := o["items"].([]any); ok && strings.HasSuffix(o.Kind(), "List") {
+			for _, it := range items {
+				if m, ok := it.(map[string]any); ok {
+					out = append(out, Object(m))
+				}
+			}
+			continue
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+// ReadPath decodes every manifest under root (a file or a directory, walked
+// recursively). Hidden directories are skipped, and files that are not
+// Kubernetes manifests (values files, CI config, …) are ignored.
+func ReadPath(root string) ([]Sourced, error) {
+	var out []Sourced
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			return nil
+		}
+		objs, err := DecodeFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, o := range objs {
+			out = append(out, Sourced{Source: path, Object: o})
+		}
+		return nil
+	})
+	return out, err
+}
+
+// ListCluster returns every object of resource (e.g. "nodepools.karpenter.sh")
+// from the cluster via kubectl. allNamespaces adds --all-namespaces.
+func ListCluster(kubeCtx, resource string, allNamespaces bool) ([]Object, error) {
+	args := []string{"get", resource, "-o", "json"}
+	if allNamespaces {
+		args = append(args, "--all-namespaces")
+	}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	raw, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl get %s: %w", resource, err)
+	}
+	var list struct {
+		Items []Object `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", resource, err)
+	}
+	return list.Items, nil
+}
+
+// EncodeYAML writes objs as one multi-document YAML stream.
+func EncodeYAML(objs []Object) ([]byte, error) {
+	var b bytes.Buffer
+	for i, o := range objs {
+		data, err := yaml.Marshal(o)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s/%s: %w", o.Kind(), o.Name(), err)
+		}
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		b.Write(data)
+	}
+	return b.Bytes(), nil
+}
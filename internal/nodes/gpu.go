@@ -0,0 +1,335 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// GPUSharing is how pods share the GPUs of the GPU pool.
+type GPUSharing string
+
+const (
+	GPUSharingNone        GPUSharing = "none"         // one pod per GPU
+	GPUSharingTimeSlicing GPUSharing = "time-slicing" // several pods take turns on a GPU
+	GPUSharingMIG         GPUSharing = "mig"          // a GPU split into isolated MIG slices
+)
+
+// ParseGPUSharing converts a --gpu-sharing flag value to a GPUSharing.
+func ParseGPUSharing(s string) (GPUSharing, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return GPUSharingNone, nil
+	case "time-slicing", "timeslicing", "time-sliced":
+		return GPUSharingTimeSlicing, nil
+	case "mig":
+		return GPUSharingMIG, nil
+	}
+	return "", fmt.Errorf("--gpu-sharing must be none, time-slicing or mig, got %q", s)
+}
+
+// GPUOptions are the answers of the GPU wizard.
+type GPUOptions struct {
+	MemoryGiB  int        // GPU memory one pod needs (0 = any GPU)
+	Sharing    GPUSharing // "" is GPUSharingNone
+	Replicas   int        // pods per GPU with time-slicing (0 = 4)
+	MIGProfile string     // MIG slice, e.g. "1g.10gb" (default: smallest that fits MemoryGiB)
+	Limit      int        // GPUs the pool may launch (0 = 8)
+}
+
+// Validate checks the option ranges.
+func (o GPUOptions) Validate() error {
+	if o.MemoryGiB < 0 || o.MemoryGiB > 80 {
+		return fmt.Errorf("--gpu-memory must be between 1 and 80 GiB (0 for any GPU), got %d", o.MemoryGiB)
+	}
+	if o.Replicas != 0 && (o.Replicas < 2 || o.Replicas > 48) {
+		return fmt.Errorf("--gpu-replicas must be between 2 and 48, got %d", o.Replicas)
+	}
+	if o.Replicas > 0 && o.Sharing != GPUSharingTimeSlicing {
+		return fmt.Errorf("--gpu-replicas needs --gpu-sharing time-slicing")
+	}
+	if o.MIGProfile != "" && o.Sharing != GPUSharingMIG {
+		return fmt.Errorf("--mig-profile needs --gpu-sharing mig")
+	}
+	if o.Limit < 0 {
+		return fmt.Errorf("--gpu-limit must be positive, got %d", o.Limit)
+	}
+	return nil
+}
+
+// GPUFamily is an NVIDIA GPU instance family Karpenter can launch.
+type GPUFamily struct {
+	Family      string
+	GPU         string // karpenter.k8s.aws/instance-gpu-name
+	MemoryGiB   int    // per GPU
+	Arch        string
+	MIGProfiles []string // slices the GPU can be split into; nil without MIG
+}
+
+// GPUFamilies lists the NVIDIA families, cheapest GPU first (on-demand price
+// of the smallest size in us-east-1).
+var GPUFamilies = []GPUFamily{
+	{Family: "g5g", GPU: "t4g", MemoryGiB: 16, Arch: "arm64"},
+	{Family: "g4dn", GPU: "t4", MemoryGiB: 16, Arch: "amd64"},
+	{Family: "g6", GPU: "l4", MemoryGiB: 24, Arch: "amd64"},
+	{Family: "g5", GPU: "a10g", MemoryGiB: 24, Arch: "amd64"},
+	{Family: "g6e", GPU: "l40s", MemoryGiB: 48, Arch: "amd64"},
+	{Family: "p3", GPU: "v100", MemoryGiB: 16, Arch: "amd64"},
+	{Family: "p4d", GPU: "a100", MemoryGiB: 40, Arch: "amd64",
+		MIGProfiles: []string{"1g.5gb", "2g.10gb", "3g.20gb", "4g.20gb", "7g.40gb"}},
+	{Family: "p4de", GPU: "a100", MemoryGiB: 80, Arch: "amd64",
+		MIGProfiles: []string{"1g.10gb", "2g.20gb", "3g.40gb", "4g.40gb", "7g.80gb"}},
+	{Family: "p5", GPU: "h100", MemoryGiB: 80, Arch: "amd64",
+		MIGProfiles: []string{"1g.10gb", "1g.20gb", "2g.20gb", "3g.40gb", "4g.40gb", "7g.80gb"}},
+}
+
+// GPUPool is the tainted NodePool generated for GPU workloads.
+type GPUPool struct {
+	GPUOptions
+	Families      []string
+	Architectures []string
+	// Namespace of the NVIDIA device plugin, where the time-slicing
+	// ConfigMap goes.
+	Namespace string
+}
+
+// GPUPoolName is the name of the generated GPU NodePool.
+const GPUPoolName = "karpx-gpu"
+
+// GPUTaint keeps pods that do not use GPUs off the GPU nodes.
+const GPUTaint = "nvidia.com/gpu"
+
+// gpuSharingConfig is the device plugin config key the GPU nodes select.
+const gpuSharingConfig = "karpx-time-sliced"
+
+// AddGPU adds a tainted GPU pool to an AWS recommendation. Families are
+// those whose GPUs (or MIG slices) hold o.MemoryGiB, up to the next memory
+// size, so Karpenter can fall back without jumping to the largest GPUs.
+func AddGPU(r *Recommendation, o GPUOptions) error {
+	if r.Provider != kube.ProviderAWS {
+		r.Reasoning = append(r.Reasoning, "⚠ the GPU pool is generated for AWS only — add a GPU node pool with your provider's accelerator labels instead")
+		return nil
+	}
+	if o.Sharing == "" {
+		o.Sharing = GPUSharingNone
+	}
+	if o.Sharing == GPUSharingTimeSlicing && o.Replicas == 0 {
+		o.Replicas = 4
+	}
+	if o.Limit == 0 {
+		o.Limit = 8
+	}
+
+	var fits []GPUFamily
+	switch o.Sharing {
+	case GPUSharingMIG:
+		if o.MIGProfile == "" {
+			o.MIGProfile = smallestMIGProfile(o.MemoryGiB)
+			if o.MIGProfile == "" {
+				return fmt.Errorf("no MIG slice has %d GiB of GPU memory", o.MemoryGiB)
+			}
+		}
+		for _, f := range GPUFamilies {
+			if contains(f.MIGProfiles, o.MIGProfile) {
+				fits = append(fits, f)
+			}
+		}
+		if len(fits) == 0 {
+			return fmt.Errorf("no GPU family supports MIG profile %q", o.MIGProfile)
+		}
+	default:
+		// Time-sliced pods share one GPU's memory with no isolation.
+		need := o.MemoryGiB
+		if o.Sharing == GPUSharingTimeSlicing {
+			need *= o.Replicas
+		}
+		fits = gpuFamiliesFor(need)
+		if len(fits) == 0 {
+			return fmt.Errorf("no GPU family has %d GiB per GPU — lower --gpu-memory or --gpu-replicas", need)
+		}
+	}
+
+	g := &GPUPool{GPUOptions: o, Namespace: "gpu-operator"}
+	var names []string
+	for _, f := range fits {
+		g.Families = append(g.Families, f.Family)
+		if !contains(g.Architectures, f.Arch) {
+			g.Architectures = append(g.Architectures, f.Arch)
+		}
+		names = append(names, fmt.Sprintf("%s (%s %d GiB)", f.Family, strings.ToUpper(f.GPU), f.MemoryGiB))
+	}
+	r.GPU = g
+
+	if o.MemoryGiB > 0 {
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf("GPU pool for %d GiB of GPU memory per pod: %s", o.MemoryGiB, strings.Join(names, ", ")))
+	} else {
+		r.Reasoning = append(r.Reasoning, "GPU pool: "+strings.Join(names, ", "))
+	}
+	switch o.Sharing {
+	case GPUSharingTimeSlicing:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"Time-slicing: %d pods per GPU, sharing its memory without isolation — each pod still requests nvidia.com/gpu: 1", o.Replicas))
+	case GPUSharingMIG:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"MIG: every GPU split into %s slices by the GPU operator's MIG manager (mig.strategy single)", o.MIGProfile))
+	}
+	r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+		"GPU nodes tainted %s:NoSchedule and capped at %d GPUs; Karpenter picks the NVIDIA AMI variant", GPUTaint, o.Limit))
+	return nil
+}
+
+// gpuFamiliesFor returns the families with at least needGiB per GPU and no
+// more than the next memory size up.
+func gpuFamiliesFor(needGiB int) []GPUFamily {
+	smallest := 0
+	for _, f := range GPUFamilies {
+		if f.MemoryGiB >= needGiB && (smallest == 0 || f.MemoryGiB < smallest) {
+			smallest = f.MemoryGiB
+		}
+	}
+	next := 0
+	for _, f := range GPUFamilies {
+		if f.MemoryGiB > smallest && (next == 0 || f.MemoryGiB < next) {
+			next = f.MemoryGiB
+		}
+	}
+	var out []GPUFamily
+	for _, f := range GPUFamilies {
+		if smallest > 0 && f.MemoryGiB >= smallest && (f.MemoryGiB <= next || next == 0) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// smallestMIGProfile returns the smallest MIG slice with at least memGiB.
+func smallestMIGProfile(memGiB int) string {
+	best, bestMem := "", 0
+	for _, f := range GPUFamilies {
+		for _, p := range f.MIGProfiles {
+			var g, mem int
+			if _, err := fmt.Sscanf(p, "%dg.%dgb", &g, &mem); err != nil || mem < memGiB {
+				continue
+			}
+			if best == "" || mem < bestMem || (mem == bestMem && p < best) {
+				best, bestMem = p, mem
+			}
+		}
+	}
+	return best
+}
+
+// GPUSnippet returns what a GPU workload adds to its pod template to run on
+// the GPU pool.
+func GPUSnippet() string {
+	return fmt.Sprintf(`nodeSelector:
+  karpx.io/pool: %s
+tolerations:
+  - key: %s
+    operator: Exists
+    effect: NoSchedule
+containers:
+  - name: <container>
+    resources:
+      limits:
+        nvidia.com/gpu: 1
+`, GPUPoolName, GPUTaint)
+}
+
+// awsGPUNodePool renders the GPU NodePool, plus the device plugin
+// time-slicing ConfigMap when pods share GPUs that way.
+func awsGPUNodePool(r Recommendation) string {
+	g := r.GPU
+	labels := fmt.Sprintf("        karpx.io/pool: %s\n", GPUPoolName)
+	switch g.Sharing {
+	case GPUSharingTimeSlicing:
+		labels += fmt.Sprintf("        nvidia.com/device-plugin.config: %s\n", gpuSharingConfig)
+	case GPUSharingMIG:
+		labels += fmt.Sprintf("        nvidia.com/mig.config: all-%s\n", g.MIGProfile)
+	}
+
+	out := fmt.Sprintf(`---
+apiVersion: karpenter.sh/v1
+kind: NodePool
+metadata:
+  name: %s
+  annotations:
+    karpx.io/generated-mode: "%s"
+    karpx.io/gpu-sharing: "%s"
+spec:
+  template:
+    metadata:
+      labels:
+%s    spec:
+      nodeClassRef:
+        group: karpenter.k8s.aws
+        kind: EC2NodeClass
+        name: karpx-default
+      requirements:
+        - key: karpenter.sh/capacity-type
+          operator: In
+          values: [%s]
+        - key: kubernetes.io/arch
+          operator: In
+          values: [%s]
+        - key: karpenter.k8s.aws/instance-family
+          operator: In
+          values: [%s]
+        - key: karpenter.k8s.aws/instance-gpu-manufacturer
+          operator: In
+          values: ["nvidia"]
+%s      taints:
+        - key: %s
+          value: "true"
+          effect: NoSchedule
+  limits:
+    nvidia.com/gpu: %d
+  disruption:
+    # GPU nodes are slow to replace (drivers, large images): only remove empty ones.
+    consolidationPolicy: WhenEmpty
+    consolidateAfter: 5m
+`,
+		GPUPoolName,
+		string(r.Mode),
+		string(g.Sharing),
+		labels,
+		quotedList(r.CapacityTypes),
+		quotedList(g.Architectures),
+		quotedList(g.Families),
+		zoneRequirement(r),
+		GPUTaint,
+		g.Limit,
+	)
+
+	if g.Sharing == GPUSharingTimeSlicing {
+		out += fmt.Sprintf(`---
+# Device plugin config selected by the nvidia.com/device-plugin.config node
+# label. Point the GPU operator at it (ClusterPolicy devicePlugin.config.name)
+# or the device plugin chart (--set config.name=karpx-gpu-sharing).
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: karpx-gpu-sharing
+  namespace: %s
+data:
+  %s: |-
+    version: v1
+    sharing:
+      timeSlicing:
+        resources:
+          - name: nvidia.com/gpu
+            replicas: %d
+`, g.Namespace, gpuSharingConfig, g.Replicas)
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
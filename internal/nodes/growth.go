@@ -0,0 +1,67 @@
+package nodes
+
+import (
+	"fmt"
+
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// Growth plans capacity beyond today's requests. The zero value plans for
+// exactly today.
+type Growth struct {
+	Factor          float64 // total demand multiplier, e.g. 1.3 for 30% growth (0 or 1 = none)
+	HeadroomPercent float64 // share of every node kept free, 0–90
+}
+
+// Validate rejects values that would shrink or nonsensically inflate a plan.
+func (g Growth) Validate() error {
+	if g.Factor != 0 && g.Factor < 1 {
+		return fmt.Errorf("growth factor must be at least 1 (e.g. 1.3 for 30%% growth), got %g", g.Factor)
+	}
+	if g.HeadroomPercent < 0 || g.HeadroomPercent > 90 {
+		return fmt.Errorf("headroom percent must be between 0 and 90, got %g", g.HeadroomPercent)
+	}
+	return nil
+}
+
+func (g Growth) none() bool { return (g.Factor == 0 || g.Factor == 1) && g.HeadroomPercent == 0 }
+
+// Apply returns a copy of p scaled for the plan: totals (and so limits and
+// node counts) grow by the factor, and every demand is divided by the share
+// of a node that may be used, so minimum node sizes keep the headroom free.
+func (g Growth) Apply(p *kube.WorkloadProfile) *kube.WorkloadProfile {
+	if g.none() {
+		return p
+	}
+	factor := g.Factor
+	if factor == 0 {
+		factor = 1
+	}
+	usable := 1 - g.HeadroomPercent/100
+	scale := func(v int64, f float64) int64 { return int64(float64(v)*f + 0.5) }
+
+	out := *p
+	out.TotalCPUm = scale(p.TotalCPUm, factor/usable)
+	out.TotalMemMiB = scale(p.TotalMemMiB, factor/usable)
+	out.Headroom.CPUm = scale(p.Headroom.CPUm, factor/usable)
+	out.Headroom.MemMiB = scale(p.Headroom.MemMiB, factor/usable)
+	out.MaxPodCPUm = scale(p.MaxPodCPUm, 1/usable)
+	out.MaxPodMemMiB = scale(p.MaxPodMemMiB, 1/usable)
+	return &out
+}
+
+// Explain adds the plan to the recommendation's reasoning.
+func (g Growth) Explain(r *Recommendation) {
+	if g.none() {
+		return
+	}
+	switch {
+	case g.Factor > 1 && g.HeadroomPercent > 0:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"Planned for %.0f%% growth with %.0f%% of every node kept free", (g.Factor-1)*100, g.HeadroomPercent))
+	case g.Factor > 1:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf("Planned for %.0f%% growth over today's requests", (g.Factor-1)*100))
+	default:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf("%.0f%% of every node kept free as headroom", g.HeadroomPercent))
+	}
+}
@@ -0,0 +1,138 @@
+package nodes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// Kubelet is the kubelet section of a generated EC2NodeClass. One NodeClass
+// serves every instance size of the pool, so the values must hold for all of
+// the recommendation's CPU sizes.
+type Kubelet struct {
+	MaxPods        int // 0 = left to Karpenter, which uses each instance type's ENI limit
+	SystemReserved map[string]string
+	KubeReserved   map[string]string
+	EvictionHard   map[string]string
+}
+
+// KubeletFor derives the kubelet configuration from the recommendation's CPU
+// sizes and CNI mode.
+//
+// With the ENI-limited VPC CNI, maxPods is only pinned when every instance
+// type of the pool — each family at each size — has the same ENI limit;
+// otherwise a static value would either strand IPs on large nodes or promise
+// small nodes pods they have no addresses for, and Karpenter's per-type ENI
+// limit is right. Karpenter does not know about
+// prefix delegation, IPv6 or overlays, so there maxPods is always set — to
+// the limit of the smallest size, which every size can hold.
+//
+// kube-reserved memory follows maxPods (11 MiB per pod + 255 MiB) and CPU is
+// sized for the median node, as the limits are; system-reserved is small and
+// fits in the 10% of every node the sizing keeps for DaemonSets.
+func KubeletFor(r Recommendation) Kubelet {
+	sizes := cpuInts(r.CPUSizes)
+	k := Kubelet{
+		SystemReserved: map[string]string{"cpu": "100m", "memory": "100Mi", "ephemeral-storage": "1Gi"},
+		KubeReserved:   map[string]string{"ephemeral-storage": "1Gi"},
+		EvictionHard: map[string]string{
+			"memory.available":  fmt.Sprintf("%dMi", kube.EvictionMemMiB),
+			"nodefs.available":  "10%",
+			"nodefs.inodesFree": "5%",
+			"imagefs.available": "15%",
+		},
+	}
+	if len(sizes) == 0 {
+		return k
+	}
+	maxPods := kube.MaxPodsFor(sizes[0], r.CNI)
+	if r.CNI.ENILimited() {
+		maxPods = sharedENIMaxPods(r.InstanceFamilies, sizes)
+	}
+	if maxPods > 0 {
+		k.MaxPods = maxPods
+		k.KubeReserved["memory"] = fmt.Sprintf("%dMi", 11*maxPods+255)
+	}
+	k.KubeReserved["cpu"] = fmt.Sprintf("%dm", kube.ReservedCPUm(sizes[len(sizes)/2]))
+	return k
+}
+
+// sharedENIMaxPods returns the ENI pod limit shared by every family at every
+// size, or 0 when the limits differ or one is not known.
+func sharedENIMaxPods(families []string, sizes []int) int {
+	shared := 0
+	for _, f := range families {
+		for _, vcpu := range sizes {
+			n, ok := kube.ENIMaxPods(f, vcpu)
+			if !ok || shared != 0 && n != shared {
+				return 0
+			}
+			shared = n
+		}
+	}
+	return shared
+}
+
+// explainKubelet adds the pod-density decision to the reasoning.
+func explainKubelet(r *Recommendation) {
+	k := KubeletFor(*r)
+	sizes := cpuInts(r.CPUSizes)
+	switch {
+	case len(sizes) == 0:
+	case r.CNI == kube.CNIOverlay:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"Overlay CNI — kubelet maxPods set to %d on every size instead of the ENI-derived limit", k.MaxPods))
+	case r.CNI == kube.CNIPrefix || r.CNI == kube.CNIIPv6:
+		what := "Prefix delegation"
+		if r.CNI == kube.CNIIPv6 {
+			what = "IPv6"
+		}
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"%s — addresses no longer limit pods; kubelet maxPods set to %d (Karpenter would otherwise assume the ENI limit of %d on %d vCPU)",
+			what, k.MaxPods, kube.MaxPods(sizes[0]), sizes[0]))
+	case k.MaxPods > 0:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf("Kubelet maxPods %d — the ENI limit of every chosen instance type", k.MaxPods))
+	default:
+		r.Reasoning = append(r.Reasoning,
+			"VPC CNI — maxPods left to Karpenter, which uses each instance type's ENI limit")
+	}
+}
+
+// yaml renders the section indented for an EC2NodeClass spec.
+func (k Kubelet) yaml() string {
+	var b strings.Builder
+	b.WriteString("  kubelet:\n")
+	if k.MaxPods > 0 {
+		fmt.Fprintf(&b, "    maxPods: %d\n", k.MaxPods)
+	}
+	writeMap := func(name string, m map[string]string) {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(&b, "    %s:\n", name)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "      %s: %q\n", key, m[key])
+		}
+	}
+	writeMap("systemReserved", k.SystemReserved)
+	writeMap("kubeReserved", k.KubeReserved)
+	writeMap("evictionHard", k.EvictionHard)
+	return b.String()
+}
+
+// cpuInts parses CPU sizes, smallest first.
+func cpuInts(sizes []string) []int {
+	var out []int
+	for _, s := range sizes {
+		var n int
+		if _, err := fmt.Sscanf(s, "%d", &n); err == nil {
+			out = append(out, n)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
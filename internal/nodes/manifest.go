@@ -19,11 +19,26 @@ func GenerateManifest(r Recommendation, clusterName, roleARN string) string {
 		return generateAzureManifest(r)
 	case kube.ProviderGCP:
 		return generateGCPManifest(r)
-	default:
-		return "# Provider not supported — no manifest generated\n"
 	}
+	if gen, ok := generators[r.Provider]; ok {
+		out, err := gen(r, clusterName)
+		if err != nil {
+			return fmt.Sprintf("# %s manifest generation failed: %s\n", r.Provider, strings.ReplaceAll(err.Error(), "\n", "\n# "))
+		}
+		return out
+	}
+	return "# Provider not supported — no manifest generated\n"
 }
 
+// Generator renders the manifest for a provider added by a plugin.
+type Generator func(r Recommendation, clusterName string) (string, error)
+
+var generators = map[kube.Provider]Generator{}
+
+// RegisterGenerator makes gen the manifest generator for p. Call it before
+// any manifest is generated; the registry is not locked.
+func RegisterGenerator(p kube.Provider, gen Generator) { generators[p] = gen }
+
 // ─────────────────────────────────────────────────────────────────────────────
 // AWS EKS — NodePool + EC2NodeClass
 // ─────────────────────────────────────────────────────────────────────────────
@@ -48,19 +63,6 @@ func generateAWSManifest(r Recommendation, clusterName, roleARN string) string {
 		roleName = "<KARPENTER_NODE_ROLE_NAME>"
 	}
 
-	families := quotedList(r.InstanceFamilies)
-	capacities := quotedList(r.CapacityTypes)
-	archs := quotedList(r.Architectures)
-	cpus := quotedList(r.CPUSizes)
-
-	// Mode-specific consolidation settings.
-	consolidateAfter := "1m"
-	consolidationPolicy := "WhenEmptyOrUnderutilized"
-	if r.Mode == ModeHighPerformance {
-		consolidateAfter = "5m"
-		consolidationPolicy = "WhenEmpty"
-	}
-
 	// Header comment describes what karpx chose and why.
 	header := fmt.Sprintf(`# ──────────────────────────────────────────────────────────────────────────
 # Generated by karpx
@@ -77,16 +79,65 @@ func generateAWSManifest(r Recommendation, clusterName, roleARN string) string {
 		commentLines(r.Reasoning),
 	)
 
-	nodepool := fmt.Sprintf(`apiVersion: karpenter.sh/v1
-kind: NodePool
+	nodepools := awsNodePool(r, nil)
+	for i := range r.Teams {
+		nodepools += "---\n" + awsNodePool(r, &r.Teams[i])
+	}
+	if r.GPU != nil {
+		nodepools += awsGPUNodePool(r)
+	}
+
+	nodeclass := fmt.Sprintf(`---
+apiVersion: karpenter.k8s.aws/v1
+kind: EC2NodeClass
 metadata:
   name: karpx-default
+spec:
+  amiSelectorTerms:
+    - alias: %s
+  role: "%s"
+%s  subnetSelectorTerms:
+    - tags:
+        karpenter.sh/discovery: "%s"
+  securityGroupSelectorTerms:
+    - tags:
+        karpenter.sh/discovery: "%s"
+  tags:
+    ManagedBy: karpx
+    OptimizationMode: "%s"
+%s%s%s%s`, r.AMIFamily.alias(), roleName, tenancySubnetNote(r), clusterName, clusterName, string(r.Mode), tenancyTag(r),
+		KubeletFor(r).yaml(), r.Volume.yaml(r.AMIFamily), userDataYAML(r.UserData))
+
+	return header + nodepools + nodeclass
+}
+
+// awsNodePool renders the NodePool for the recommendation, or for one team
+// carved out of it when t is non-nil.
+func awsNodePool(r Recommendation, t *TeamPool) string {
+	families := quotedList(r.InstanceFamilies)
+	capacities := quotedList(r.CapacityTypes)
+	archs := quotedList(r.Architectures)
+	cpus := quotedList(r.CPUSizes)
+
+	// Mode-specific consolidation settings.
+	consolidateAfter := "1m"
+	consolidationPolicy := "WhenEmptyOrUnderutilized"
+	if r.Mode == ModeHighPerformance {
+		consolidateAfter = "5m"
+		consolidationPolicy = "WhenEmpty"
+	}
+	limitCPU, limitMem := poolLimits(r, t)
+
+	return fmt.Sprintf(`apiVersion: karpenter.sh/v1
+kind: NodePool
+metadata:
+  name: %s
   annotations:
     karpx.io/generated-mode: "%s"
     karpx.io/workload-type: "%s"
 spec:
   template:
-    spec:
+%s    spec:
       nodeClassRef:
         group: karpenter.k8s.aws
         kind: EC2NodeClass
@@ -107,45 +158,29 @@ spec:
         - key: karpenter.k8s.aws/instance-memory
           operator: Gt
           values: ["%d"]
-  limits:
-    cpu: "1000"
-    memory: 4000Gi
+%s%s  limits:
+    cpu: "%d"
+    memory: %dGi
   disruption:
     consolidationPolicy: %s
     consolidateAfter: %s
 `,
+		poolName(t),
 		string(r.Mode),
 		string(r.WorkloadType),
+		teamMetadata(t),
 		capacities,
 		archs,
 		families,
 		cpus,
 		r.MinNodeMiB,
+		zoneRequirement(r),
+		teamTaints(t),
+		limitCPU,
+		limitMem,
 		consolidationPolicy,
 		consolidateAfter,
 	)
-
-	nodeclass := fmt.Sprintf(`---
-apiVersion: karpenter.k8s.aws/v1
-kind: EC2NodeClass
-metadata:
-  name: karpx-default
-spec:
-  amiSelectorTerms:
-    - alias: al2023@latest
-  role: "%s"
-  subnetSelectorTerms:
-    - tags:
-        karpenter.sh/discovery: "%s"
-  securityGroupSelectorTerms:
-    - tags:
-        karpenter.sh/discovery: "%s"
-  tags:
-    ManagedBy: karpx
-    OptimizationMode: "%s"
-`, roleName, clusterName, clusterName, string(r.Mode))
-
-	return header + nodepool + nodeclass
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -153,9 +188,6 @@ spec:
 // ─────────────────────────────────────────────────────────────────────────────
 
 func generateAzureManifest(r Recommendation) string {
-	families := quotedList(r.InstanceFamilies)
-	capacities := quotedList(r.CapacityTypes)
-
 	header := fmt.Sprintf(`# ──────────────────────────────────────────────────────────────────────────
 # Generated by karpx
 # Mode         : %s
@@ -170,13 +202,33 @@ func generateAzureManifest(r Recommendation) string {
 		commentLines(r.Reasoning),
 	)
 
-	nodepool := fmt.Sprintf(`apiVersion: karpenter.sh/v1
-kind: NodePool
+	nodepools := azureNodePool(r, nil)
+	for i := range r.Teams {
+		nodepools += "---\n" + azureNodePool(r, &r.Teams[i])
+	}
+
+	nodeclass := `---
+apiVersion: karpenter.azure.com/v1alpha2
+kind: AKSNodeClass
 metadata:
   name: karpx-default
+spec:
+  imageFamily: AzureLinux
+`
+
+	return header + nodepools + nodeclass
+}
+
+func azureNodePool(r Recommendation, t *TeamPool) string {
+	limitCPU, limitMem := poolLimits(r, t)
+
+	return fmt.Sprintf(`apiVersion: karpenter.sh/v1
+kind: NodePool
+metadata:
+  name: %s
 spec:
   template:
-    spec:
+%s    spec:
       nodeClassRef:
         apiVersion: karpenter.azure.com/v1alpha2
         kind: AKSNodeClass
@@ -191,25 +243,23 @@ spec:
         - key: karpenter.azure.com/sku-cpu
           operator: In
           values: [%s]
-  limits:
-    cpu: "1000"
+%s%s  limits:
+    cpu: "%d"
+    memory: %dGi
   disruption:
     consolidationPolicy: WhenEmptyOrUnderutilized
     consolidateAfter: 1m
----
-apiVersion: karpenter.azure.com/v1alpha2
-kind: AKSNodeClass
-metadata:
-  name: karpx-default
-spec:
-  imageFamily: AzureLinux
 `,
-		capacities,
-		families,
+		poolName(t),
+		teamMetadata(t),
+		quotedList(r.CapacityTypes),
+		quotedList(r.InstanceFamilies),
 		quotedList(r.CPUSizes),
+		zoneRequirement(r),
+		teamTaints(t),
+		limitCPU,
+		limitMem,
 	)
-
-	return header + nodepool
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -217,9 +267,6 @@ spec:
 // ─────────────────────────────────────────────────────────────────────────────
 
 func generateGCPManifest(r Recommendation) string {
-	families := quotedList(r.InstanceFamilies)
-	capacities := quotedList(r.CapacityTypes)
-
 	header := fmt.Sprintf(`# ──────────────────────────────────────────────────────────────────────────
 # Generated by karpx
 # Mode         : %s
@@ -234,13 +281,34 @@ func generateGCPManifest(r Recommendation) string {
 		commentLines(r.Reasoning),
 	)
 
-	nodepool := fmt.Sprintf(`apiVersion: karpenter.sh/v1
-kind: NodePool
+	nodepools := gcpNodePool(r, nil)
+	for i := range r.Teams {
+		nodepools += "---\n" + gcpNodePool(r, &r.Teams[i])
+	}
+
+	nodeclass := `---
+apiVersion: karpenter.k8s.gcp/v1
+kind: GCENodeClass
 metadata:
   name: karpx-default
+spec:
+  # Fill in your GCP project / image configuration
+  # See: https://github.com/kubernetes-sigs/karpenter-provider-gcp#readme
+`
+
+	return header + nodepools + nodeclass
+}
+
+func gcpNodePool(r Recommendation, t *TeamPool) string {
+	limitCPU, limitMem := poolLimits(r, t)
+
+	return fmt.Sprintf(`apiVersion: karpenter.sh/v1
+kind: NodePool
+metadata:
+  name: %s
 spec:
   template:
-    spec:
+%s    spec:
       nodeClassRef:
         apiVersion: karpenter.k8s.gcp/v1
         kind: GCENodeClass
@@ -252,31 +320,45 @@ spec:
         - key: cloud.google.com/machine-family
           operator: In
           values: [%s]
-  limits:
-    cpu: "1000"
+%s%s  limits:
+    cpu: "%d"
+    memory: %dGi
   disruption:
     consolidationPolicy: WhenEmptyOrUnderutilized
     consolidateAfter: 1m
----
-apiVersion: karpenter.k8s.gcp/v1
-kind: GCENodeClass
-metadata:
-  name: karpx-default
-spec:
-  # Fill in your GCP project / image configuration
-  # See: https://github.com/kubernetes-sigs/karpenter-provider-gcp#readme
 `,
-		capacities,
-		families,
+		poolName(t),
+		teamMetadata(t),
+		quotedList(r.CapacityTypes),
+		quotedList(r.InstanceFamilies),
+		zoneRequirement(r),
+		teamTaints(t),
+		limitCPU,
+		limitMem,
 	)
-
-	return header + nodepool
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
 // Helpers
 // ─────────────────────────────────────────────────────────────────────────────
 
+// userDataYAML renders userData as a literal block, or "".
+func userDataYAML(userData string) string {
+	if userData == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("  userData: |\n")
+	for _, l := range strings.Split(strings.TrimRight(userData, "\n"), "\n") {
+		if l == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString("    " + l + "\n")
+	}
+	return b.String()
+}
+
 func quotedList(items []string) string {
 	quoted := make([]string, len(items))
 	for i, v := range items {
@@ -285,6 +367,34 @@ func quotedList(items []string) string {
 	return strings.Join(quoted, ", ")
 }
 
+// tenancySubnetNote explains where dedicated tenancy comes from: Karpenter has
+// no tenancy field, so nodes inherit it from a dedicated-tenancy VPC.
+func tenancySubnetNote(r Recommendation) string {
+	if r.Tenancy == TenancyDefault {
+		return ""
+	}
+	return "  # Dedicated tenancy: these subnets must belong to a VPC created with\n" +
+		"  # instance tenancy \"dedicated\" — Karpenter inherits it from the VPC.\n"
+}
+
+func tenancyTag(r Recommendation) string {
+	if r.Tenancy == TenancyDefault {
+		return ""
+	}
+	return "    Tenancy: \"" + string(TenancyDedicated) + "\"\n"
+}
+
+// zoneRequirement returns the zone requirement line for pinned pools, or "".
+func zoneRequirement(r Recommendation) string {
+	if len(r.Zones) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`        - key: topology.kubernetes.io/zone
+          operator: In
+          values: [%s]
+`, quotedList(r.Zones))
+}
+
 func commentLines(lines []string) string {
 	var b strings.Builder
 	for _, l := range lines {
@@ -5,6 +5,7 @@ package nodes
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/kemilad/karpx/internal/kube"
 )
@@ -19,6 +20,23 @@ const (
 	ModeFreeTier        OptimizationMode = "freetier"
 )
 
+// ParseMode converts a --mode flag value to an OptimizationMode.
+// Unrecognised values fall back to ModeBalanced; "" returns "".
+func ParseMode(s string) OptimizationMode {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return ""
+	case "cost":
+		return ModeCostOptimized
+	case "performance", "perf":
+		return ModeHighPerformance
+	case "freetier", "free-tier", "free":
+		return ModeFreeTier
+	default:
+		return ModeBalanced
+	}
+}
+
 // Recommendation holds all parameters needed to generate a NodePool manifest.
 type Recommendation struct {
 	Mode             OptimizationMode
@@ -35,6 +53,33 @@ type Recommendation struct {
 	MinNodeCPU  int // minimum vCPUs per node
 	MinNodeMiB  int // minimum memory per node in MiB
 
+	// NodePool limits and the node count expected at peak demand
+	LimitCPU      int // cores
+	LimitMemGiB   int
+	ExpectedNodes int // at peak, on nodes of the median CPU size (0 = unknown)
+
+	// Zones the pool is pinned to because StatefulSets have zonal volumes there
+	Zones []string
+
+	// Tenancy of the nodes (AWS only; see ApplyTenancy)
+	Tenancy Tenancy
+
+	// Pod networking, which bounds pods per node (AWS only; see KubeletFor)
+	CNI kube.CNIMode
+
+	// Root EBS volume (AWS only; see SizeVolume)
+	Volume Volume
+
+	// AMI family and merged userData snippets (AWS only; see ApplyUserData)
+	AMIFamily AMIFamily
+	UserData  string
+
+	// Per-team pools generated alongside the shared one (see AddTeams)
+	Teams []TeamPool
+
+	// Tainted GPU pool generated alongside the shared one (AWS only; see AddGPU)
+	GPU *GPUPool
+
 	// Human-readable explanation bullets printed to the user
 	Reasoning []string
 }
@@ -52,12 +97,16 @@ func Build(
 		Mode:         mode,
 		WorkloadType: wtype,
 		Provider:     provider,
+		CNI:          profile.CNI,
 	}
 
 	// ── Sizing hints from observed workloads ────────────────────────────────
 	r.MinNodeCPU = minCPU(profile.MaxPodCPUm)
-	r.MinNodeMiB = minMemMiB(profile.MaxPodMemMiB)
+	r.MinNodeMiB = minMemMiB(profile.MaxPodMemMiB, profile.CNI)
 	r.CPUSizes = cpuSizes(r.MinNodeCPU)
+	if profile.Window != "" {
+		r.Reasoning = append(r.Reasoning, "Sized for "+profile.Window+" demand, not a single snapshot")
+	}
 
 	// ── Provider-specific instance selection ────────────────────────────────
 	switch provider {
@@ -71,6 +120,20 @@ func Build(
 		r.Reasoning = append(r.Reasoning, "Provider unknown — showing generic guidance only")
 	}
 
+	// ── Limits from peak demand ─────────────────────────────────────────────
+	sizeLimits(&r, profile)
+	if provider == kube.ProviderAWS {
+		explainKubelet(&r)
+	}
+
+	// ── Storage topology ────────────────────────────────────────────────────
+	pinVolumeZones(&r, profile)
+	if profile.DoNotDisrupt > 0 {
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"%d pod(s) carry %s — consolidation and drift skip their nodes (see `karpx audit`)",
+			profile.DoNotDisrupt, kube.DoNotDisrupt))
+	}
+
 	return r
 }
 
@@ -296,48 +359,38 @@ func buildGCP(r *Recommendation, _ *kube.WorkloadProfile, wtype kube.WorkloadTyp
 // Sizing helpers
 // ─────────────────────────────────────────────────────────────────────────────
 
-// minCPU returns the smallest vCPU count a node must have to fit the largest
-// pod's CPU request (with 20% overhead headroom).
+// minCPU returns the smallest vCPU count whose allocatable CPU — capacity
+// minus kube-reserved — fits the largest pod's CPU request, keeping 10% of
+// allocatable for DaemonSets.
 func minCPU(maxPodCPUm int64) int {
 	if maxPodCPUm == 0 {
 		return 2
 	}
-	needed := int((float64(maxPodCPUm) / 1000.0) * 1.2) // 20% headroom
-	switch {
-	case needed <= 1:
-		return 2
-	case needed <= 2:
-		return 2
-	case needed <= 4:
-		return 4
-	case needed <= 8:
-		return 8
-	default:
-		return 16
+	for _, vcpu := range []int{2, 4, 8, 16, 32, 48} {
+		alloc, _ := kube.Allocatable(vcpu, 0)
+		if maxPodCPUm <= alloc*9/10 {
+			return vcpu
+		}
 	}
+	return 64
 }
 
-// minMemMiB returns the minimum node memory in MiB needed to fit the largest
-// pod's memory request (with 25% overhead headroom).
-func minMemMiB(maxPodMemMiB int64) int {
+// minMemMiB returns the minimum node memory in MiB whose allocatable memory —
+// capacity minus kube-reserved and the eviction threshold — fits the largest
+// pod's memory request, keeping 10% of allocatable for DaemonSets. Node vCPUs
+// and the CNI's pod limit (which drive the reservation) are assumed at the
+// general-purpose 4 GiB/vCPU.
+func minMemMiB(maxPodMemMiB int64, cni kube.CNIMode) int {
 	if maxPodMemMiB == 0 {
 		return 2048
 	}
-	needed := int(float64(maxPodMemMiB) * 1.25)
-	switch {
-	case needed <= 1024:
-		return 2048
-	case needed <= 2048:
-		return 2048
-	case needed <= 4096:
-		return 4096
-	case needed <= 8192:
-		return 8192
-	case needed <= 16384:
-		return 16384
-	default:
-		return 32768
+	for _, mem := range []int{2048, 4096, 8192, 16384, 32768} {
+		_, alloc := kube.AllocatableFor(max(2, mem/4096), int64(mem), cni)
+		if maxPodMemMiB <= alloc*9/10 {
+			return mem
+		}
 	}
+	return 65536
 }
 
 // cpuSizes returns the list of vCPU sizes Karpenter should consider,
@@ -358,6 +411,99 @@ func cpuSizes(minCPU int) []string {
 	return out
 }
 
+// Defaults used when nothing is known about demand — effectively unlimited.
+const (
+	defaultLimitCPU    = 1000
+	defaultLimitMemGiB = 4000
+)
+
+// sizeLimits sets the NodePool limits to twice the peak demand (today's
+// requests plus HPA/VPA headroom), so a runaway scale-out is capped without
+// blocking legitimate growth, and estimates how many nodes the peak needs.
+func sizeLimits(r *Recommendation, p *kube.WorkloadProfile) {
+	r.LimitCPU, r.LimitMemGiB = defaultLimitCPU, defaultLimitMemGiB
+	peakCPUm, peakMemMiB := p.PeakCPUm(), p.PeakMemMiB()
+	if p.NoRequests || peakCPUm == 0 {
+		return
+	}
+	peakCores := float64(peakCPUm) / 1000
+	peakGiB := float64(peakMemMiB) / 1024
+
+	r.LimitCPU = max(16, roundUp(int(peakCores*2+0.999), 8))
+	r.LimitMemGiB = max(64, roundUp(int(peakGiB*2+0.999), 32))
+
+	var nodeCPU int
+	fmt.Sscanf(r.CPUSizes[len(r.CPUSizes)/2], "%d", &nodeCPU)
+	// Allocatable after kube-reserved, less ~10% for DaemonSets.
+	allocCPUm, _ := kube.Allocatable(nodeCPU, 0)
+	perNode := float64(allocCPUm) / 1000 * 0.9
+	r.ExpectedNodes = int(peakCores/perNode + 0.999)
+
+	// Small pods can run out of pod slots (IP addresses with the VPC CNI)
+	// before they run out of CPU.
+	if podsPerNode := kube.MaxPodsFor(nodeCPU, p.CNI); p.TotalPods > r.ExpectedNodes*podsPerNode {
+		r.ExpectedNodes = (p.TotalPods + podsPerNode - 1) / podsPerNode
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"Pod density bounds the node count: %d pods at %d per %d-vCPU node (%s)",
+			p.TotalPods, podsPerNode, nodeCPU, cniLabel(p.CNI)))
+	}
+
+	if p.Headroom.HPAs > 0 || p.Headroom.VPAs > 0 {
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"Assumed peak: %.1f cores / %.0f GiB — %d HPA(s) at maxReplicas, %d VPA target(s) applied (today: %.1f cores)",
+			peakCores, peakGiB, p.Headroom.HPAs, p.Headroom.VPAs, float64(p.TotalCPUm)/1000))
+	} else {
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"Assumed peak: %.1f cores / %.0f GiB — no autoscalers found, so today's requests", peakCores, peakGiB))
+	}
+	r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+		"Limits %d cores / %d GiB (2× peak); expect ~%d × %d-vCPU node(s) at peak",
+		r.LimitCPU, r.LimitMemGiB, r.ExpectedNodes, nodeCPU))
+}
+
+// pinVolumeZones restricts the pool to the zones holding StatefulSet volumes.
+// A zonal volume can only attach in its own zone, so a node launched anywhere
+// else for a rescheduled replica is wasted and the pod stays Pending.
+func pinVolumeZones(r *Recommendation, p *kube.WorkloadProfile) {
+	if p.ZonalStateful == 0 || len(p.VolumeZones) == 0 {
+		return
+	}
+	r.Zones = p.VolumeZones
+	zones := strings.Join(p.VolumeZones, ", ")
+	r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+		"%d StatefulSet(s) use zonal volumes in %s — pool pinned to those zones", p.ZonalStateful, zones))
+	if len(p.VolumeZones) == 1 {
+		r.Reasoning = append(r.Reasoning,
+			"⚠ Only one zone holds volumes, so every node lands there — give stateless workloads a separate multi-zone pool")
+	}
+	for _, c := range r.CapacityTypes {
+		if c == "spot" {
+			r.Reasoning = append(r.Reasoning,
+				"⚠ Spot interruptions and consolidation can only move stateful pods within their volume's zone; "+
+					"if that zone has no capacity they stay Pending — consider on-demand for StatefulSets")
+			break
+		}
+	}
+}
+
+// cniLabel names the pod limit a CNI mode implies.
+func cniLabel(m kube.CNIMode) string {
+	switch m {
+	case kube.CNIPrefix:
+		return "VPC CNI prefix delegation"
+	case kube.CNIIPv6:
+		return "VPC CNI IPv6"
+	case kube.CNIOverlay:
+		return "overlay CNI"
+	}
+	return "VPC CNI ENI limit"
+}
+
+// roundUp rounds n up to a multiple of step.
+func roundUp(n, step int) int {
+	return (n + step - 1) / step * step
+}
+
 func addReasons(existing []string, more ...string) []string {
 	return append(existing, more...)
 }
@@ -0,0 +1,137 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// TeamPool is one team's NodePool, carved out of a recommendation. Its nodes
+// carry the team label and a matching NoSchedule taint, so only that team's
+// workloads (which tolerate the taint and select the label) land there.
+type TeamPool struct {
+	LabelKey    string
+	Team        string
+	Name        string // NodePool name, unique among the recommendation's pools
+	Namespaces  []string
+	LimitCPU    int // cores
+	LimitMemGiB int
+}
+
+// PoolName is the NodePool name generated for the team.
+func (t TeamPool) PoolName() string { return poolName(&t) }
+
+// AddTeams adds one TeamPool per team to r. Limits are the team's share of
+// today's requests applied to the recommendation's overall limits, so a team
+// that uses a third of the cluster may grow to a third of the cap.
+func AddTeams(r *Recommendation, labelKey string, teams []kube.TeamUsage) {
+	var totalCPUm, totalMemMiB int64
+	for _, t := range teams {
+		totalCPUm += t.CPUm
+		totalMemMiB += t.MemMiB
+	}
+	taken := map[string]bool{poolName(nil): true, GPUPoolName: true}
+	for _, t := range r.Teams {
+		taken[t.Name] = true
+	}
+	for _, t := range teams {
+		cpuShare, memShare := 1.0/float64(len(teams)), 1.0/float64(len(teams))
+		if totalCPUm > 0 {
+			cpuShare = float64(t.CPUm) / float64(totalCPUm)
+		}
+		if totalMemMiB > 0 {
+			memShare = float64(t.MemMiB) / float64(totalMemMiB)
+		}
+		r.Teams = append(r.Teams, TeamPool{
+			LabelKey:    labelKey,
+			Team:        t.Team,
+			Name:        uniquePoolName(t.Team, taken),
+			Namespaces:  t.Namespaces,
+			LimitCPU:    max(8, roundUp(int(float64(r.LimitCPU)*cpuShare+0.999), 8)),
+			LimitMemGiB: max(32, roundUp(int(float64(r.LimitMemGiB)*memShare+0.999), 32)),
+		})
+	}
+	if len(teams) > 0 {
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"%d team pool(s) from namespace label %q — tainted %s=<team>:NoSchedule, limits by share of today's requests",
+			len(teams), labelKey, labelKey))
+	}
+}
+
+// TeamSnippet returns the tolerations and nodeSelector a team adds to its pod
+// templates to run on its own pool.
+func TeamSnippet(t TeamPool) string {
+	return fmt.Sprintf(`nodeSelector:
+  %s: %q
+tolerations:
+  - key: %s
+    operator: Equal
+    value: %q
+    effect: NoSchedule
+`, t.LabelKey, t.Team, t.LabelKey, t.Team)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers used by the manifest generators
+// ─────────────────────────────────────────────────────────────────────────────
+
+// poolName is "karpx-default" for the shared pool and the team pool's
+// unique name otherwise.
+func poolName(t *TeamPool) string {
+	if t == nil {
+		return "karpx-default"
+	}
+	return t.Name
+}
+
+// uniquePoolName is "karpx-<team>" with the team sanitised to a DNS label.
+// A name already in taken — the shared or GPU pool, or a team that sanitises
+// to the same string — gets a numeric suffix so no pool overwrites another.
+func uniquePoolName(team string, taken map[string]bool) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(team) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	base := "karpx-" + strings.Trim(b.String(), "-")
+	if base == "karpx-" {
+		base = "karpx-team"
+	}
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	taken[name] = true
+	return name
+}
+
+func poolLimits(r Recommendation, t *TeamPool) (cpu, memGiB int) {
+	if t == nil {
+		return r.LimitCPU, r.LimitMemGiB
+	}
+	return t.LimitCPU, t.LimitMemGiB
+}
+
+// teamMetadata is the template metadata block that labels a team's nodes.
+func teamMetadata(t *TeamPool) string {
+	if t == nil {
+		return ""
+	}
+	return fmt.Sprintf("    metadata:\n      labels:\n        %s: %q\n", t.LabelKey, t.Team)
+}
+
+// teamTaints keeps other teams' pods off a team's nodes.
+func teamTaints(t *TeamPool) string {
+	if t == nil {
+		return ""
+	}
+	return fmt.Sprintf(`      taints:
+        - key: %s
+          value: %q
+          effect: NoSchedule
+`, t.LabelKey, t.Team)
+}
@@ -0,0 +1,85 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kemilad/karpx/internal/kube"
+	"github.com/kemilad/karpx/internal/pricing"
+)
+
+// Tenancy is the EC2 placement tenancy of the generated pool.
+type Tenancy string
+
+const (
+	TenancyDefault   Tenancy = ""          // shared hardware
+	TenancyDedicated Tenancy = "dedicated" // single-tenant hardware (Dedicated Instances)
+)
+
+// ParseTenancy converts a --tenancy flag value to a Tenancy. Dedicated Hosts
+// are rejected: Karpenter cannot place nodes on them.
+func ParseTenancy(s string) (Tenancy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "default", "shared":
+		return TenancyDefault, nil
+	case "dedicated":
+		return TenancyDedicated, nil
+	case "host":
+		return "", fmt.Errorf("--tenancy host is not supported: Karpenter cannot place nodes on Dedicated Hosts — " +
+			"use a managed node group with a host resource group, or --tenancy dedicated")
+	}
+	return "", fmt.Errorf("--tenancy must be dedicated, got %q", s)
+}
+
+// DedicatedRegionFee is the hourly AWS fee, in USD, charged per region while
+// at least one Dedicated Instance is running, on top of the instance price.
+const DedicatedRegionFee = 2.0
+
+// noDedicated lists families that cannot run with dedicated tenancy:
+// burstable and flex instances share CPU credits by design.
+var noDedicated = map[string]bool{
+	"t2": true, "t3": true, "t3a": true, "t4g": true,
+	"m7i-flex": true, "c7i-flex": true,
+}
+
+// ApplyTenancy restricts an AWS recommendation to dedicated hardware: Spot is
+// not sold with dedicated tenancy, and families that do not support it are
+// dropped. Other providers are left unchanged with a note. currency is the
+// one instance prices are quoted in for the cluster's region.
+func ApplyTenancy(r *Recommendation, t Tenancy, currency string) {
+	if t == TenancyDefault {
+		return
+	}
+	r.Tenancy = t
+	if r.Provider != kube.ProviderAWS {
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"⚠ --tenancy %s applies to AWS only — use Azure Dedicated Hosts or GKE sole-tenant nodes instead", t))
+		r.Tenancy = TenancyDefault
+		return
+	}
+
+	var families, dropped []string
+	for _, f := range r.InstanceFamilies {
+		if noDedicated[f] {
+			dropped = append(dropped, f)
+			continue
+		}
+		families = append(families, f)
+	}
+	if len(families) == 0 {
+		families = []string{"m7i", "c7i", "m6i"}
+		r.Architectures = []string{"amd64"}
+	}
+	r.InstanceFamilies = families
+	r.CapacityTypes = []string{"on-demand"}
+
+	r.Reasoning = append(r.Reasoning, "Dedicated tenancy — on-demand only (no Spot for single-tenant hardware)")
+	if len(dropped) > 0 {
+		r.Reasoning = append(r.Reasoning, "Dropped families without dedicated tenancy: "+strings.Join(dropped, ", "))
+	}
+	r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+		"Cost: dedicated instance prices (%s) plus a region fee of USD %.0f/hour (~USD %.0f/month) — see `karpx pricing --tenancy %s`",
+		currency, DedicatedRegionFee, DedicatedRegionFee*pricing.HoursPerMonth, t))
+	r.Reasoning = append(r.Reasoning,
+		"Karpenter inherits tenancy from the subnet's VPC — select subnets in a VPC created with instance tenancy \"dedicated\"")
+}
@@ -0,0 +1,297 @@
+package nodes
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// AMIFamily selects the EC2NodeClass AMI alias and the userData format
+// Karpenter merges with its own bootstrap.
+type AMIFamily string
+
+const (
+	AMIAL2023       AMIFamily = "al2023"       // MIME multi-part: shell scripts, cloud-config, NodeConfig
+	AMIBottlerocket AMIFamily = "bottlerocket" // TOML settings
+)
+
+// ParseAMIFamily converts an --ami-family flag value to an AMIFamily.
+func ParseAMIFamily(s string) (AMIFamily, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "al2023":
+		return AMIAL2023, nil
+	case "bottlerocket", "br":
+		return AMIBottlerocket, nil
+	}
+	return "", fmt.Errorf("--ami-family must be al2023 or bottlerocket, got %q", s)
+}
+
+// alias is the amiSelectorTerms alias of the family.
+func (f AMIFamily) alias() string {
+	if f == AMIBottlerocket {
+		return "bottlerocket@latest"
+	}
+	return "al2023@latest"
+}
+
+// Snippet is one user-supplied userData file: registry mirrors, proxy
+// settings, hardening scripts, …
+type Snippet struct {
+	Name    string // file name, used in errors
+	Content string
+}
+
+// UserData is the merged userData of an EC2NodeClass.
+type UserData struct {
+	Family  AMIFamily
+	Content string   // "" when no snippets were given
+	Sources []string // snippet names
+}
+
+// MergeUserData checks each snippet against the family's format — so a
+// shell script never reaches Bottlerocket — and merges them into one
+// userData document.
+func MergeUserData(family AMIFamily, snippets []Snippet) (UserData, error) {
+	u := UserData{Family: family}
+	if len(snippets) == 0 {
+		return u, nil
+	}
+	var err error
+	switch family {
+	case AMIBottlerocket:
+		u.Content, err = mergeTOML(snippets)
+	default:
+		u.Content, err = mergeMIME(snippets)
+	}
+	for _, s := range snippets {
+		u.Sources = append(u.Sources, s.Name)
+	}
+	return u, err
+}
+
+// ApplyUserData sets the AMI family and userData of an AWS recommendation.
+func ApplyUserData(r *Recommendation, u UserData) {
+	if r.Provider != kube.ProviderAWS {
+		if u.Family == AMIBottlerocket || u.Content != "" {
+			r.Reasoning = append(r.Reasoning, "⚠ --ami-family and --user-data apply to AWS only")
+		}
+		return
+	}
+	r.AMIFamily, r.UserData = u.Family, u.Content
+	if u.Content != "" {
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"userData from %s merged for %s — Karpenter adds its own bootstrap settings to it", strings.Join(u.Sources, ", "), u.Family))
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// AL2023 — MIME multi-part
+// ─────────────────────────────────────────────────────────────────────────────
+
+// mimeBoundary is the boundary Karpenter's own examples use.
+const mimeBoundary = "//"
+
+type mimePart struct {
+	contentType string
+	body        string
+}
+
+// mergeMIME combines the snippets into one MIME multi-part document. A
+// snippet may itself be a MIME document, whose parts are taken over.
+func mergeMIME(snippets []Snippet) (string, error) {
+	var parts []mimePart
+	for _, s := range snippets {
+		p, err := mimeParts(s)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, p...)
+	}
+	var b strings.Builder
+	b.WriteString("MIME-Version: 1.0\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\n\n", mimeBoundary)
+	for _, p := range parts {
+		fmt.Fprintf(&b, "--%s\nContent-Type: %s\n\n%s\n", mimeBoundary, p.contentType, strings.TrimRight(p.body, "\n"))
+	}
+	fmt.Fprintf(&b, "--%s--\n", mimeBoundary)
+	return b.String(), nil
+}
+
+// mimeParts classifies a snippet by its first line, as cloud-init does.
+func mimeParts(s Snippet) ([]mimePart, error) {
+	text := strings.TrimLeft(s.Content, "\n")
+	first, _, _ := strings.Cut(text, "\n")
+	first = strings.TrimSpace(first)
+	switch {
+	case strings.HasPrefix(first, "MIME-Version:"):
+		return splitMIME(s)
+	case strings.HasPrefix(first, "#!"):
+		return []mimePart{{`text/x-shellscript; charset="us-ascii"`, text}}, nil
+	case first == "#cloud-config":
+		return []mimePart{{`text/cloud-config; charset="us-ascii"`, text}}, nil
+	case strings.Contains(text, "apiVersion: node.eks.aws/"):
+		return []mimePart{{"application/node.eks.aws", text}}, nil
+	case looksLikeTOML(text):
+		return nil, fmt.Errorf("%s: looks like Bottlerocket TOML — use --ami-family bottlerocket", s.Name)
+	}
+	return nil, fmt.Errorf("%s: not a shell script (#!), #cloud-config, NodeConfig (node.eks.aws) or MIME document", s.Name)
+}
+
+// splitMIME returns the parts of a MIME multi-part snippet.
+func splitMIME(s Snippet) ([]mimePart, error) {
+	msg, err := mail.ReadMessage(strings.NewReader(strings.TrimLeft(s.Content, "\n")))
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid MIME document: %w", s.Name, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("%s: MIME document is not multipart", s.Name)
+	}
+	var parts []mimePart
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid MIME part: %w", s.Name, err)
+		}
+		body, err := io.ReadAll(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid MIME part: %w", s.Name, err)
+		}
+		ct := p.Header.Get("Content-Type")
+		if ct == "" {
+			ct = `text/plain; charset="us-ascii"`
+		}
+		parts = append(parts, mimePart{ct, string(body)})
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%s: MIME document has no parts", s.Name)
+	}
+	return parts, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Bottlerocket — TOML
+// ─────────────────────────────────────────────────────────────────────────────
+
+// mergeTOML concatenates Bottlerocket settings snippets table by table, so
+// two files may both add to [settings.kubernetes]. A key set twice is an
+// error: TOML forbids it and Bottlerocket would reject the whole userData.
+func mergeTOML(snippets []Snippet) (string, error) {
+	var order []string
+	tables := map[string][]string{}
+	owner := map[string]string{} // table.key → snippet
+	for _, s := range snippets {
+		if strings.HasPrefix(strings.TrimSpace(s.Content), "#!") || strings.HasPrefix(strings.TrimSpace(s.Content), "MIME-Version:") {
+			return "", fmt.Errorf("%s: Bottlerocket userData must be TOML settings, not a script or MIME document", s.Name)
+		}
+		table := ""
+		depth := 0 // open [ or { of a multi-line value
+		sc := bufio.NewScanner(strings.NewReader(s.Content))
+		for n := 1; sc.Scan(); n++ {
+			line := sc.Text()
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case depth > 0:
+				depth += bracketDepth(trimmed)
+			case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+				continue
+			case strings.HasPrefix(trimmed, "[["):
+				return "", fmt.Errorf("%s:%d: arrays of tables are not supported in Bottlerocket userData", s.Name, n)
+			case strings.HasPrefix(trimmed, "["):
+				if !strings.HasSuffix(trimmed, "]") {
+					return "", fmt.Errorf("%s:%d: invalid table header %q", s.Name, n, trimmed)
+				}
+				table = strings.TrimSpace(strings.Trim(trimmed, "[]"))
+				if _, ok := tables[table]; !ok {
+					order = append(order, table)
+					tables[table] = nil
+				}
+				continue
+			default:
+				key, value, ok := strings.Cut(trimmed, "=")
+				if !ok || strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
+					return "", fmt.Errorf("%s:%d: expected key = value, got %q", s.Name, n, trimmed)
+				}
+				full := table + "." + strings.Trim(strings.TrimSpace(key), `"`)
+				if prev, dup := owner[full]; dup {
+					return "", fmt.Errorf("%s:%d: %s is already set by %s", s.Name, n, strings.TrimPrefix(full, "."), prev)
+				}
+				owner[full] = s.Name
+				depth = bracketDepth(value)
+			}
+			if _, ok := tables[table]; !ok {
+				order = append(order, table)
+			}
+			tables[table] = append(tables[table], line)
+		}
+		if depth > 0 {
+			return "", fmt.Errorf("%s: unterminated array or inline table", s.Name)
+		}
+	}
+
+	// Top-level keys must come before the first table header.
+	var b bytes.Buffer
+	for _, l := range tables[""] {
+		b.WriteString(l + "\n")
+	}
+	for _, t := range order {
+		if t == "" || len(tables[t]) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", t)
+		for _, l := range tables[t] {
+			b.WriteString(l + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// bracketDepth returns how many [ and { a TOML value leaves open, ignoring
+// those inside quoted strings.
+func bracketDepth(s string) int {
+	depth := 0
+	var quote rune
+	for _, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return depth
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// looksLikeTOML reports whether text starts with a TOML table header.
+func looksLikeTOML(text string) bool {
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		return strings.HasPrefix(l, "[settings")
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package nodes
+
+import (
+	"fmt"
+
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// gp3 limits and the baseline every gp3 volume gets for free.
+const (
+	gp3BaseIOPS       = 3000
+	gp3MaxIOPS        = 16000
+	gp3BaseThroughput = 125 // MiB/s
+	gp3MaxThroughput  = 1000
+	gp3IOPSPerGiB     = 500
+)
+
+// baseVolumeGiB is the room the OS, kubelet and container runtime need on
+// the root volume before any image or pod storage — the AL2023 default size.
+const baseVolumeGiB = 20
+
+// imageGCHighPercent is the disk usage at which the kubelet starts deleting
+// images; the volume is sized so the expected load stays below it.
+const imageGCHighPercent = 85
+
+// VolumeOptions override the generated root volume.
+type VolumeOptions struct {
+	MinGiB     int // never size below this
+	IOPS       int // 0 = gp3 baseline (3000)
+	Throughput int // MiB/s; 0 = gp3 baseline (125)
+}
+
+// Validate checks the options against gp3 limits.
+func (o VolumeOptions) Validate() error {
+	if o.MinGiB < 0 {
+		return fmt.Errorf("minimum volume size must be positive, got %d", o.MinGiB)
+	}
+	if o.IOPS != 0 && (o.IOPS < gp3BaseIOPS || o.IOPS > gp3MaxIOPS) {
+		return fmt.Errorf("gp3 IOPS must be between %d and %d, got %d", gp3BaseIOPS, gp3MaxIOPS, o.IOPS)
+	}
+	if o.Throughput != 0 && (o.Throughput < gp3BaseThroughput || o.Throughput > gp3MaxThroughput) {
+		return fmt.Errorf("gp3 throughput must be between %d and %d MiB/s, got %d", gp3BaseThroughput, gp3MaxThroughput, o.Throughput)
+	}
+	if iops := max(o.IOPS, gp3BaseIOPS); o.Throughput*4 > iops {
+		return fmt.Errorf("gp3 throughput of %d MiB/s needs at least %d IOPS", o.Throughput, o.Throughput*4)
+	}
+	return nil
+}
+
+func (o VolumeOptions) set() bool { return o.MinGiB > 0 || o.IOPS > 0 || o.Throughput > 0 }
+
+// Volume is the root EBS volume of the generated EC2NodeClass. The zero
+// value leaves Karpenter's default (20 GiB gp3).
+type Volume struct {
+	SizeGiB    int
+	IOPS       int
+	Throughput int // MiB/s
+}
+
+// SizeVolume sizes the AWS root volume for the images of the fullest node
+// plus the ephemeral storage one node's share of pods requests, kept below the image
+// garbage-collection threshold. Nothing is generated when neither is known
+// and no option is set.
+func SizeVolume(r *Recommendation, p *kube.WorkloadProfile, o VolumeOptions) {
+	if r.Provider != kube.ProviderAWS {
+		return
+	}
+	if p.ImageMiB == 0 && p.TotalEphemeralMiB == 0 && !o.set() {
+		return
+	}
+
+	// Per node: the largest pod, or an even share of all requests at peak.
+	ephMiB := p.MaxPodEphemeralMiB
+	if r.ExpectedNodes > 0 {
+		ephMiB = max(ephMiB, p.TotalEphemeralMiB/int64(r.ExpectedNodes))
+	}
+	needMiB := int64(baseVolumeGiB)*1024 + p.ImageMiB + ephMiB
+	size := roundUp(int((needMiB*100/imageGCHighPercent+1023)/1024), 10)
+
+	v := Volume{
+		SizeGiB:    max(size, baseVolumeGiB, o.MinGiB),
+		IOPS:       max(o.IOPS, gp3BaseIOPS),
+		Throughput: max(o.Throughput, gp3BaseThroughput),
+	}
+	// gp3 allows at most 500 IOPS per GiB.
+	v.SizeGiB = max(v.SizeGiB, (v.IOPS+gp3IOPSPerGiB-1)/gp3IOPSPerGiB)
+	r.Volume = v
+
+	switch {
+	case p.ImageMiB > 0 || ephMiB > 0:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf(
+			"Root volume %d GiB gp3 — %.1f GiB of images (fullest node) + %.1f GiB ephemeral storage per node, kept under %d%% so image GC does not churn",
+			v.SizeGiB, float64(p.ImageMiB)/1024, float64(ephMiB)/1024, imageGCHighPercent))
+	default:
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf("Root volume %d GiB gp3 (set by flags)", v.SizeGiB))
+	}
+	if o.MinGiB > size {
+		r.Reasoning = append(r.Reasoning, fmt.Sprintf("Root volume raised to the %d GiB minimum", o.MinGiB))
+	}
+}
+
+// yaml renders the blockDeviceMappings of an EC2NodeClass spec, or "".
+// Bottlerocket keeps images and pod storage on a separate data volume
+// (/dev/xvdb); its OS volume keeps the default size.
+func (v Volume) yaml(family AMIFamily) string {
+	if v.SizeGiB == 0 {
+		return ""
+	}
+	device, osVolume := "/dev/xvda", ""
+	if family == AMIBottlerocket {
+		device = "/dev/xvdb"
+		osVolume = `    - deviceName: /dev/xvda
+      ebs:
+        volumeSize: 4Gi
+        volumeType: gp3
+        encrypted: true
+        deleteOnTermination: true
+`
+	}
+	return fmt.Sprintf(`  blockDeviceMappings:
+%s    - deviceName: %s
+      ebs:
+        volumeSize: %dGi
+        volumeType: gp3
+        iops: %d
+        throughput: %d
+        encrypted: true
+        deleteOnTermination: true
+`, osVolume, device, v.SizeGiB, v.IOPS, v.Throughput)
+}
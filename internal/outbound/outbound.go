@@ -0,0 +1,217 @@
+// Package outbound configures the proxy and the trusted certificate
+// authorities for every connection karpx makes. Corporate proxies that
+// inspect TLS re-sign traffic with their own CA, which neither karpx nor the
+// tools it runs trust out of the box.
+//
+// karpx's own HTTP calls (GitHub releases, Prometheus) go through Client.
+// helm, kubectl, aws, az and gcloud are configured through the environment
+// they inherit: HTTPS_PROXY and NO_PROXY, and a bundle of the system roots
+// plus the extra CAs in SSL_CERT_FILE, AWS_CA_BUNDLE, REQUESTS_CA_BUNDLE and
+// CLOUDSDK_CORE_CUSTOM_CA_CERTS_FILE. Variables already set are left alone.
+package outbound
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings are the proxy and CA settings from flags and the config file.
+type Settings struct {
+	HTTPSProxy string
+	NoProxy    string
+	CABundle   string // PEM file of extra trusted CAs
+}
+
+var (
+	mu       sync.Mutex
+	roots    *x509.CertPool // nil = system roots only
+	caFile   string
+	caCerts  int
+	bundle   string // system roots + extra CAs, for other tools
+	caEnvSet []string
+)
+
+// caEnv are the variables tools read a CA bundle from.
+var caEnv = []string{
+	"SSL_CERT_FILE",                      // helm, kubectl and other Go tools on Linux
+	"AWS_CA_BUNDLE",                      // aws
+	"REQUESTS_CA_BUNDLE",                 // az
+	"CLOUDSDK_CORE_CUSTOM_CA_CERTS_FILE", // gcloud
+}
+
+// systemBundles are where Linux distributions and macOS keep the system roots
+// as one PEM file.
+var systemBundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",                // Debian, Ubuntu, Alpine
+	"/etc/pki/tls/certs/ca-bundle.crt",                  // Fedora, RHEL
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // RHEL 7+
+	"/etc/ssl/ca-bundle.pem",                            // openSUSE
+	"/etc/ssl/cert.pem",                                 // macOS, Alpine
+}
+
+// Configure applies s. Call it once, before any outbound connection: Go reads
+// the proxy variables only on first use.
+func Configure(s Settings) error {
+	if getenv("HTTPS_PROXY") == "" && s.HTTPSProxy != "" {
+		os.Setenv("HTTPS_PROXY", s.HTTPSProxy)
+	}
+	if getenv("NO_PROXY") == "" && s.NoProxy != "" {
+		os.Setenv("NO_PROXY", s.NoProxy)
+	}
+	if s.CABundle == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.CABundle)
+	if err != nil {
+		return fmt.Errorf("CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	n := 0
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("CA bundle %s: %w", s.CABundle, err)
+		}
+		pool.AddCert(cert)
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("CA bundle %s has no PEM certificates", s.CABundle)
+	}
+
+	combined, err := writeBundle(data)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	roots, caFile, caCerts, bundle = pool, s.CABundle, n, combined
+	caEnvSet = nil
+	for _, v := range caEnv {
+		if os.Getenv(v) == "" {
+			os.Setenv(v, combined)
+			caEnvSet = append(caEnvSet, v)
+		}
+	}
+	return nil
+}
+
+// writeBundle writes the system roots followed by extra to the user cache
+// directory and returns its path. Tools replace their roots with the file
+// they are given, so the extra CAs alone would break every direct connection.
+func writeBundle(extra []byte) (string, error) {
+	var b bytes.Buffer
+	for _, p := range systemBundles {
+		if sys, err := os.ReadFile(p); err == nil {
+			b.Write(sys)
+			if !bytes.HasSuffix(sys, []byte("\n")) {
+				b.WriteByte('\n')
+			}
+			break
+		}
+	}
+	b.Write(extra)
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	dir = filepath.Join(dir, "karpx")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "ca-bundle.pem")
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("write CA bundle: %w", err)
+	}
+	return path, nil
+}
+
+// Client returns an HTTP client that uses the proxy from the environment
+// and trusts the configured CAs.
+func Client(timeout time.Duration) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	mu.Lock()
+	if roots != nil {
+		t.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	mu.Unlock()
+	return &http.Client{Timeout: timeout, Transport: t}
+}
+
+// Info describes the active settings, for `karpx doctor`.
+type Info struct {
+	HTTPSProxy string   `json:"https_proxy,omitempty"`
+	NoProxy    string   `json:"no_proxy,omitempty"`
+	CABundle   string   `json:"ca_bundle,omitempty"`
+	CACerts    int      `json:"ca_certs,omitempty"`
+	Bundle     string   `json:"bundle,omitempty"` // file handed to other tools
+	Exported   []string `json:"exported,omitempty"`
+}
+
+// Current returns the active settings.
+func Current() Info {
+	mu.Lock()
+	defer mu.Unlock()
+	return Info{
+		HTTPSProxy: redacted(getenv("HTTPS_PROXY")),
+		NoProxy:    getenv("NO_PROXY"),
+		CABundle:   caFile,
+		CACerts:    caCerts,
+		Bundle:     bundle,
+		Exported:   append([]string(nil), caEnvSet...),
+	}
+}
+
+// ProxyFor returns the proxy a request to rawURL goes through, or "" when it
+// connects directly.
+func ProxyFor(rawURL string) string {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return ""
+	}
+	u, err := http.ProxyFromEnvironment(req)
+	if err != nil || u == nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// getenv reads a proxy variable in either case, as Go and curl do.
+func getenv(name string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return os.Getenv(strings.ToLower(name))
+}
+
+// redacted hides the password of a proxy URL.
+func redacted(proxy string) string {
+	if u, err := url.Parse(proxy); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return proxy
+}
@@ -0,0 +1,336 @@
+// Package pause temporarily stops Karpenter from acting on a cluster during
+// maintenance windows and restores the exact previous state afterwards.
+//
+// Two strategies are supported:
+//
+//	controller — scale the Karpenter controller Deployment to zero replicas.
+//	             Nothing is provisioned or disrupted until resume.
+//	disruption — set every NodePool's disruption budget to nodes: "0".
+//	             Karpenter keeps provisioning for pending pods but never
+//	             consolidates or drifts nodes. Expiry ignores disruption
+//	             budgets, so nodes past expireAfter are still replaced.
+//
+// The state captured at pause time (original replica count, original budgets
+// per NodePool) is stored in the karpx-pause-state ConfigMap in the Karpenter
+// namespace so resume can put everything back exactly — even from a different
+// machine or after the terminal that paused the cluster has gone away.
+package pause
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode selects how Karpenter is paused.
+type Mode string
+
+const (
+	ModeController Mode = "controller"
+	ModeDisruption Mode = "disruption"
+)
+
+// StateConfigMap is the name of the ConfigMap that records the paused state.
+const StateConfigMap = "karpx-pause-state"
+
+// ErrNotPaused is returned by Resume when no pause state is recorded.
+var ErrNotPaused = errors.New("cluster is not paused by karpx")
+
+// ErrAlreadyPaused is returned by Pause when a pause state already exists.
+var ErrAlreadyPaused = errors.New("cluster is already paused by karpx — run `karpx resume` first")
+
+// State is the snapshot persisted at pause time.
+type State struct {
+	Mode       Mode      `json:"mode"`
+	PausedAt   time.Time `json:"pausedAt"`
+	Reason     string    `json:"reason,omitempty"`
+	Deployment string    `json:"deployment,omitempty"`
+	Replicas   int       `json:"replicas"` // controller replicas before the pause; may be 0
+	// Budgets maps NodePool name → original spec.disruption.budgets JSON.
+	// A nil value means the NodePool had no budgets set (API default applies).
+	Budgets map[string]json.RawMessage `json:"budgets,omitempty"`
+}
+
+// Params holds all inputs for Pause.
+type Params struct {
+	KubeCtx        string
+	Namespace      string // Karpenter namespace; defaults to "karpenter"
+	DeploymentName string // controller Deployment; defaults to "karpenter"
+	Mode           Mode
+	Reason         string
+}
+
+// ParseMode converts a user-supplied flag value to a Mode.
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "disruption", "budgets":
+		return ModeDisruption, nil
+	case "controller", "scale":
+		return ModeController, nil
+	}
+	return "", fmt.Errorf("unknown pause mode %q (want controller | disruption)", s)
+}
+
+// Pause records the current state and then blocks Karpenter according to p.Mode.
+// The state is written before anything is changed so a failure half-way
+// through can always be undone with Resume.
+func Pause(p Params) (*State, error) {
+	if p.Namespace == "" {
+		p.Namespace = "karpenter"
+	}
+	if p.DeploymentName == "" {
+		p.DeploymentName = "karpenter"
+	}
+
+	if existing, err := Status(p.KubeCtx, p.Namespace); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return existing, ErrAlreadyPaused
+	}
+
+	st := &State{
+		Mode:       p.Mode,
+		PausedAt:   time.Now().UTC(),
+		Reason:     p.Reason,
+		Deployment: p.DeploymentName,
+	}
+
+	switch p.Mode {
+	case ModeController:
+		replicas, err := deploymentReplicas(p.KubeCtx, p.Namespace, p.DeploymentName)
+		if err != nil {
+			return nil, err
+		}
+		st.Replicas = replicas
+		if err := saveState(p.KubeCtx, p.Namespace, st); err != nil {
+			return nil, err
+		}
+		if err := scaleDeployment(p.KubeCtx, p.Namespace, p.DeploymentName, 0); err != nil {
+			return st, fmt.Errorf("scale %s to 0: %w", p.DeploymentName, err)
+		}
+
+	case ModeDisruption:
+		budgets, err := nodePoolBudgets(p.KubeCtx)
+		if err != nil {
+			return nil, err
+		}
+		st.Budgets = budgets
+		if err := saveState(p.KubeCtx, p.Namespace, st); err != nil {
+			return nil, err
+		}
+		for _, name := range sortedKeys(budgets) {
+			if err := patchBudgets(p.KubeCtx, name, json.RawMessage(`[{"nodes":"0"}]`)); err != nil {
+				return st, fmt.Errorf("block disruption on NodePool %s: %w", name, err)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown pause mode %q", p.Mode)
+	}
+	return st, nil
+}
+
+// Resume restores the state recorded by Pause and deletes the state ConfigMap.
+// Returns ErrNotPaused when the cluster has no recorded pause.
+func Resume(kubeCtx, namespace string) (*State, error) {
+	if namespace == "" {
+		namespace = "karpenter"
+	}
+	st, err := Status(kubeCtx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, ErrNotPaused
+	}
+
+	switch st.Mode {
+	case ModeController:
+		if err := scaleDeployment(kubeCtx, namespace, st.Deployment, st.Replicas); err != nil {
+			return st, fmt.Errorf("scale %s back to %d: %w", st.Deployment, st.Replicas, err)
+		}
+	case ModeDisruption:
+		for _, name := range sortedKeys(st.Budgets) {
+			orig := st.Budgets[name]
+			if len(orig) == 0 {
+				orig = json.RawMessage("null") // merge-patch null removes the field
+			}
+			if err := patchBudgets(kubeCtx, name, orig); err != nil {
+				// A NodePool deleted during the window is not an error.
+				if strings.Contains(err.Error(), "NotFound") || strings.Contains(err.Error(), "not found") {
+					continue
+				}
+				return st, fmt.Errorf("restore budgets on NodePool %s: %w", name, err)
+			}
+		}
+	}
+
+	if err := deleteState(kubeCtx, namespace); err != nil {
+		return st, err
+	}
+	return st, nil
+}
+
+// Status returns the recorded pause state, or nil when the cluster is not paused.
+func Status(kubeCtx, namespace string) (*State, error) {
+	if namespace == "" {
+		namespace = "karpenter"
+	}
+	args := []string{"get", "configmap", StateConfigMap, "-n", namespace, "-o", "json", "--ignore-not-found"}
+	out, err := kubectl(kubeCtx, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("read pause state: %w", exitDetail(err))
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var cm struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(out, &cm); err != nil {
+		return nil, fmt.Errorf("parse pause state: %w", err)
+	}
+	raw := cm.Data["state"]
+	if raw == "" {
+		return nil, nil
+	}
+	var st State
+	if err := json.Unmarshal([]byte(raw), &st); err != nil {
+		return nil, fmt.Errorf("parse pause state: %w", err)
+	}
+	return &st, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+func kubectl(kubeCtx string, args ...string) *exec.Cmd {
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	return exec.Command("kubectl", args...)
+}
+
+// exitDetail folds kubectl's stderr into the error so callers see the reason.
+func exitDetail(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+func saveState(kubeCtx, namespace string, st *State) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	cm := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      StateConfigMap,
+			"namespace": namespace,
+			"labels": map[string]string{
+				"app.kubernetes.io/managed-by": "karpx",
+			},
+		},
+		"data": map[string]string{"state": string(data)},
+	}
+	body, err := json.Marshal(cm)
+	if err != nil {
+		return err
+	}
+	cmd := kubectl(kubeCtx, "apply", "-f", "-")
+	cmd.Stdin = bytes.NewReader(body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("record pause state: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func deleteState(kubeCtx, namespace string) error {
+	out, err := kubectl(kubeCtx, "delete", "configmap", StateConfigMap, "-n", namespace, "--ignore-not-found").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("remove pause state: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func deploymentReplicas(kubeCtx, namespace, name string) (int, error) {
+	out, err := kubectl(kubeCtx, "get", "deployment", name, "-n", namespace, "-o", "jsonpath={.spec.replicas}").Output()
+	if err != nil {
+		return 0, fmt.Errorf("read %s replicas: %w", name, exitDetail(err))
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("read %s replicas: unexpected value %q", name, strings.TrimSpace(string(out)))
+	}
+	return n, nil
+}
+
+func scaleDeployment(kubeCtx, namespace, name string, replicas int) error {
+	out, err := kubectl(kubeCtx, "scale", "deployment", name, "-n", namespace,
+		fmt.Sprintf("--replicas=%d", replicas)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// nodePoolBudgets returns every NodePool's current spec.disruption.budgets.
+func nodePoolBudgets(kubeCtx string) (map[string]json.RawMessage, error) {
+	out, err := kubectl(kubeCtx, "get", "nodepools.karpenter.sh", "-o", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("list NodePools: %w", exitDetail(err))
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Disruption struct {
+					Budgets json.RawMessage `json:"budgets"`
+				} `json:"disruption"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("parse NodePools: %w", err)
+	}
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("no NodePools found — nothing to pause")
+	}
+	budgets := make(map[string]json.RawMessage, len(list.Items))
+	for _, np := range list.Items {
+		budgets[np.Metadata.Name] = np.Spec.Disruption.Budgets
+	}
+	return budgets, nil
+}
+
+func patchBudgets(kubeCtx, nodePool string, budgets json.RawMessage) error {
+	patch := fmt.Sprintf(`{"spec":{"disruption":{"budgets":%s}}}`, string(budgets))
+	out, err := kubectl(kubeCtx, "patch", "nodepools.karpenter.sh", nodePool,
+		"--type", "merge", "-p", patch).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
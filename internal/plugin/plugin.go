@@ -0,0 +1,289 @@
+// Package plugin adds Karpenter providers that karpx does not ship with.
+// A plugin is any executable in ~/.karpx/plugins (or $KARPX_PLUGINS). karpx
+// runs it once per call, writes one JSON request to its stdin and reads one
+// JSON response from its stdout; stderr is shown to the user on failure.
+//
+//	→ {"protocol": 1, "method": "detect", "params": {"context": "…", "server": "…", "providerID": "…"}}
+//	← {"result": {"match": true}}
+//	← {"error": "message"}
+//
+// Methods:
+//
+//	describe  → Info. Called for every plugin when karpx starts.
+//	detect    DetectParams → {"match": bool}. Asked only when no built-in
+//	          provider matched the cluster.
+//	compat    → {"releases": ["1.2.0", …], "matrix": [{"karpenter": ">= 1.0.0, < 2.0.0", "k8sMin": "1.29", "k8sMax": "1.32"}]}
+//	manifest  ManifestParams → {"yaml": "…"}: the NodePool and NodeClass for
+//	          a recommendation.
+//
+// A plugin lists the methods it implements beyond describe in
+// Info.Capabilities.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kemilad/karpx/internal/compat"
+	"github.com/kemilad/karpx/internal/kube"
+	"github.com/kemilad/karpx/internal/nodes"
+)
+
+// ProtocolVersion is sent with every request. Plugins should reject versions
+// they do not understand.
+const ProtocolVersion = 1
+
+// Capabilities a plugin can declare.
+const (
+	CapDetect   = "detect"
+	CapCompat   = "compat"
+	CapManifest = "manifest"
+)
+
+// Info is a plugin's answer to describe.
+type Info struct {
+	Name         string   `json:"name"` // provider id shown in detect output, e.g. "alibaba"
+	Label        string   `json:"label"`
+	SupportLevel string   `json:"supportLevel,omitempty"`
+	ChartRepo    string   `json:"chartRepo,omitempty"`
+	DocsURL      string   `json:"docsURL,omitempty"`
+	ProviderRepo string   `json:"providerRepo,omitempty"`
+	Aliases      []string `json:"aliases,omitempty"` // extra names for --provider
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// Plugin is one discovered plugin executable.
+type Plugin struct {
+	Path string
+	Info Info
+}
+
+// Has reports whether the plugin declared capability c.
+func (p *Plugin) Has(c string) bool {
+	for _, x := range p.Info.Capabilities {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectParams describe the cluster being identified.
+type DetectParams struct {
+	Context    string `json:"context"`
+	Server     string `json:"server"`     // kubeconfig API server URL
+	ProviderID string `json:"providerID"` // spec.providerID of one node, "" when unreadable
+}
+
+// ManifestParams carry a node recommendation to the plugin.
+type ManifestParams struct {
+	ClusterName      string   `json:"clusterName,omitempty"`
+	Mode             string   `json:"mode"`
+	WorkloadType     string   `json:"workloadType"`
+	InstanceFamilies []string `json:"instanceFamilies"`
+	CapacityTypes    []string `json:"capacityTypes"`
+	Architectures    []string `json:"architectures"`
+	CPUSizes         []string `json:"cpuSizes"`
+	MinNodeCPU       int      `json:"minNodeCPU"`
+	MinNodeMiB       int      `json:"minNodeMiB"`
+	LimitCPU         int      `json:"limitCPU"`
+	LimitMemGiB      int      `json:"limitMemGiB"`
+	Zones            []string `json:"zones,omitempty"`
+}
+
+// Dir returns the plugin directory.
+func Dir() string {
+	if d := os.Getenv("KARPX_PLUGINS"); d != "" {
+		return d
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".karpx", "plugins")
+	}
+	return filepath.Join(home, ".karpx", "plugins")
+}
+
+// builtin are provider names a plugin may not take over.
+var builtin = map[string]bool{"aws": true, "azure": true, "gcp": true, "unknown": true}
+
+// Discover describes every executable in dir. A missing directory is not an
+// error. Plugins that fail to describe themselves are reported in errs and
+// skipped.
+func Discover(dir string) (plugins []*Plugin, errs []error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+	seen := map[string]string{}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		p := &Plugin{Path: filepath.Join(dir, e.Name())}
+		if err := p.call("describe", nil, &p.Info, 5*time.Second); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		name := strings.ToLower(p.Info.Name)
+		switch {
+		case name == "":
+			errs = append(errs, fmt.Errorf("plugin %s: describe returned no name", e.Name()))
+			continue
+		case builtin[name]:
+			errs = append(errs, fmt.Errorf("plugin %s: provider %q is built in", e.Name(), name))
+			continue
+		case seen[name] != "":
+			errs = append(errs, fmt.Errorf("plugin %s: provider %q is already provided by %s", e.Name(), name, seen[name]))
+			continue
+		}
+		seen[name] = e.Name()
+		p.Info.Name = name
+		if p.Info.Label == "" {
+			p.Info.Label = name
+		}
+		if p.Info.SupportLevel == "" {
+			p.Info.SupportLevel = "plugin"
+		}
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Info.Name < plugins[j].Info.Name })
+	return plugins, errs
+}
+
+var (
+	loadOnce sync.Once
+	loaded   []*Plugin
+	loadErrs []error
+)
+
+// Load discovers the plugins in Dir and registers them with provider
+// detection, the compatibility checks and manifest generation. Only the
+// first call does any work.
+func Load() ([]*Plugin, []error) {
+	loadOnce.Do(func() {
+		loaded, loadErrs = Discover(Dir())
+		for _, p := range loaded {
+			register(p)
+		}
+	})
+	return loaded, loadErrs
+}
+
+func register(p *Plugin) {
+	provider := kube.Provider(p.Info.Name)
+
+	var detect kube.Detector
+	if p.Has(CapDetect) {
+		detect = func(kubeCtx, server, providerID string) bool {
+			var res struct {
+				Match bool `json:"match"`
+			}
+			params := DetectParams{Context: kube.ContextName(kubeCtx), Server: server, ProviderID: providerID}
+			return p.call("detect", params, &res, 10*time.Second) == nil && res.Match
+		}
+	}
+	kube.RegisterProvider(provider, kube.ProviderMeta{
+		Label:        p.Info.Label,
+		SupportLevel: p.Info.SupportLevel,
+		ChartRepo:    p.Info.ChartRepo,
+		DocsURL:      p.Info.DocsURL,
+		ProviderRepo: p.Info.ProviderRepo,
+	}, p.Info.Aliases, detect)
+
+	if p.Has(CapCompat) {
+		// Asked once per run, on first use: status checks many clusters.
+		var once sync.Once
+		var res struct {
+			Releases []string      `json:"releases"`
+			Matrix   compat.Matrix `json:"matrix"`
+		}
+		var err error
+		compat.Register(p.Info.Name, func() ([]string, compat.Matrix, error) {
+			once.Do(func() { err = p.call("compat", nil, &res, 30*time.Second) })
+			return res.Releases, res.Matrix, err
+		})
+	}
+
+	if p.Has(CapManifest) {
+		nodes.RegisterGenerator(provider, func(r nodes.Recommendation, clusterName string) (string, error) {
+			var res struct {
+				YAML string `json:"yaml"`
+			}
+			err := p.call("manifest", ManifestParams{
+				ClusterName:      clusterName,
+				Mode:             string(r.Mode),
+				WorkloadType:     string(r.WorkloadType),
+				InstanceFamilies: r.InstanceFamilies,
+				CapacityTypes:    r.CapacityTypes,
+				Architectures:    r.Architectures,
+				CPUSizes:         r.CPUSizes,
+				MinNodeCPU:       r.MinNodeCPU,
+				MinNodeMiB:       r.MinNodeMiB,
+				LimitCPU:         r.LimitCPU,
+				LimitMemGiB:      r.LimitMemGiB,
+				Zones:            r.Zones,
+			}, &res, 30*time.Second)
+			return res.YAML, err
+		})
+	}
+}
+
+// call runs one request against the plugin.
+func (p *Plugin) call(method string, params, result any, timeout time.Duration) error {
+	req, err := json.Marshal(map[string]any{
+		"protocol": ProtocolVersion,
+		"method":   method,
+		"params":   params,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("no answer after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w\n%s", err, msg)
+		}
+		return fmt.Errorf("plugin %s %s: %w", filepath.Base(p.Path), method, err)
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("plugin %s %s: invalid response: %w", filepath.Base(p.Path), method, err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("plugin %s %s: %s", filepath.Base(p.Path), method, resp.Error)
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("plugin %s %s: unexpected result: %w", filepath.Base(p.Path), method, err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+// Package preflight scans Karpenter resources for API fields and annotations
+// that are removed or renamed by an upgrade, so every required change is
+// known before the upgrade starts instead of surfacing as a failed apply.
+//
+// Resources are read from the live cluster (kubectl) and, optionally, from
+// manifests stored on disk (e.g. a GitOps checkout), because fixing only the
+// cluster copy just means Git puts the old fields back on the next sync.
+package preflight
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/kemilad/karpx/internal/manifest"
+)
+
+// Severity of a finding.
+type Severity string
+
+const (
+	// Blocker must be fixed before the upgrade — the resource will be rejected
+	// or silently lose configuration.
+	Blocker Severity = "blocker"
+	// Warning is deprecated but still accepted, or changes default behaviour.
+	Warning Severity = "warning"
+)
+
+// Finding is a single thing that must (or should) change before upgrading.
+type Finding struct {
+	Severity Severity
+	Source   string // "cluster" or a file path
+	Kind     string
+	Name     string
+	Field    string // dotted path of the offending field or annotation
+	Message  string
+	Fix      string
+}
+
+// Params holds all inputs for Scan.
+type Params struct {
+	KubeCtx     string
+	Target      string   // desired version, bare semver
+	Paths       []string // files or directories of manifests to scan as well
+	SkipCluster bool     // only scan Paths
+}
+
+// CrossesV1 reports whether an upgrade from current to target crosses the
+// v1beta1 → v1 API boundary introduced in Karpenter 1.0. An unknown current
+// version is treated as pre-1.0 so the scan errs on the side of running.
+func CrossesV1(current, target string) bool {
+	t, err := semver.NewVersion(target)
+	if err != nil || t.Major() < 1 {
+		return false
+	}
+	if current == "" {
+		return true
+	}
+	c, err := semver.NewVersion(current)
+	if err != nil {
+		return true
+	}
+	return c.Major() < 1
+}
+
+// Scan collects Karpenter resources from the cluster and p.Paths and returns
+// every finding that applies to the target version, blockers first.
+func Scan(p Params) ([]Finding, error) {
+	var objs []manifest.Sourced
+
+	if !p.SkipCluster {
+		live, err := clusterObjects(p.KubeCtx)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, live...)
+	}
+	for _, path := range p.Paths {
+		fromDisk, err := manifest.ReadPath(path)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, fromDisk...)
+	}
+
+	var out []Finding
+	for _, o := range objs {
+		out = append(out, Check(o.Source, o.Object, p.Target)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Severity == Blocker && out[j].Severity != Blocker
+	})
+	return out, nil
+}
+
+// Check returns the findings for a single object when upgrading to target.
+func Check(source string, o manifest.Object, target string) []Finding {
+	kind := o.Kind()
+	apiVersion := o.APIVersion()
+	name := o.Name()
+
+	var out []Finding
+	add := func(sev Severity, field, msg, fix string) {
+		out = append(out, Finding{
+			Severity: sev, Source: source, Kind: kind, Name: name,
+			Field: field, Message: msg, Fix: fix,
+		})
+	}
+
+	toV1 := true
+	if t, err := semver.NewVersion(target); err == nil && t.Major() < 1 {
+		toV1 = false
+	}
+
+	// ── Annotations valid on any object (pods, nodes, templates) ──────────
+	for _, anns := range annotationSets(o) {
+		if !toV1 {
+			break
+		}
+		if _, ok := anns.m["karpenter.sh/do-not-evict"]; ok {
+			add(Blocker, anns.path+".karpenter.sh/do-not-evict",
+				"karpenter.sh/do-not-evict was removed",
+				"rename the annotation to karpenter.sh/do-not-disrupt")
+		}
+		if _, ok := anns.m["karpenter.sh/do-not-consolidate"]; ok {
+			add(Blocker, anns.path+".karpenter.sh/do-not-consolidate",
+				"karpenter.sh/do-not-consolidate was removed",
+				"rename the annotation to karpenter.sh/do-not-disrupt")
+		}
+	}
+
+	switch {
+	// ── Legacy v1alpha5 resources ─────────────────────────────────────────
+	case kind == "Provisioner" || kind == "AWSNodeTemplate" || kind == "Machine":
+		add(Blocker, "kind",
+			kind+" ("+apiVersion+") is no longer served",
+			"convert it with `karpx convert` and apply the NodePool/EC2NodeClass before upgrading")
+
+	// ── NodePool ──────────────────────────────────────────────────────────
+	case kind == "NodePool" && toV1:
+		if apiVersion == "karpenter.sh/v1beta1" {
+			add(Warning, "apiVersion", "karpenter.sh/v1beta1 is deprecated", "change apiVersion to karpenter.sh/v1")
+		}
+		policy := o.String("spec", "disruption", "consolidationPolicy")
+		if policy == "WhenUnderutilized" {
+			add(Blocker, "spec.disruption.consolidationPolicy",
+				"WhenUnderutilized was renamed",
+				"use consolidationPolicy: WhenEmptyOrUnderutilized and set consolidateAfter (e.g. 0s)")
+		}
+		if o.Has("spec", "disruption", "expireAfter") {
+			add(Blocker, "spec.disruption.expireAfter",
+				"expireAfter moved to the node template",
+				"move it to spec.template.spec.expireAfter")
+		}
+		if o.Has("spec", "template", "spec", "kubelet") {
+			add(Blocker, "spec.template.spec.kubelet",
+				"kubelet configuration moved to the NodeClass",
+				"move the block to EC2NodeClass spec.kubelet")
+		}
+		if o.Has("spec", "template", "spec", "nodeClassRef", "apiVersion") {
+			add(Blocker, "spec.template.spec.nodeClassRef.apiVersion",
+				"nodeClassRef.apiVersion was replaced by group",
+				"replace apiVersion with group: karpenter.k8s.aws (kind and name stay)")
+		} else if o.Has("spec", "template", "spec", "nodeClassRef") && !o.Has("spec", "template", "spec", "nodeClassRef", "group") {
+			add(Blocker, "spec.template.spec.nodeClassRef.group",
+				"nodeClassRef.group is required in v1",
+				"add group: karpenter.k8s.aws")
+		}
+
+	// ── EC2NodeClass ──────────────────────────────────────────────────────
+	case kind == "EC2NodeClass" && toV1:
+		if apiVersion == "karpenter.k8s.aws/v1beta1" {
+			add(Warning, "apiVersion", "karpenter.k8s.aws/v1beta1 is deprecated", "change apiVersion to karpenter.k8s.aws/v1")
+		}
+		if !o.Has("spec", "amiSelectorTerms") {
+			add(Blocker, "spec.amiSelectorTerms",
+				"amiSelectorTerms is required in v1",
+				"add amiSelectorTerms: [{alias: al2023@latest}] (or pin a version instead of latest)")
+		}
+		if o.String("spec", "amiFamily") == "Ubuntu" {
+			add(Blocker, "spec.amiFamily",
+				"the Ubuntu AMI family was removed",
+				"switch to AL2023 or Bottlerocket, or use amiFamily: AL2 with explicit amiSelectorTerms")
+		}
+		if tags, ok := o.Get("spec", "tags").(map[string]any); ok {
+			for k := range tags {
+				if k == "karpenter.sh/nodepool" || k == "karpenter.k8s.aws/ec2nodeclass" ||
+					strings.HasPrefix(k, "kubernetes.io/cluster/") || k == "eks:eks-cluster-name" {
+					add(Blocker, "spec.tags."+k, "tag key "+k+" is reserved in v1", "remove it — Karpenter sets it itself")
+				}
+			}
+		}
+		if !o.Has("spec", "metadataOptions", "httpPutResponseHopLimit") {
+			add(Warning, "spec.metadataOptions.httpPutResponseHopLimit",
+				"the default IMDS hop limit changed to 1",
+				"pods that read instance metadata need httpPutResponseHopLimit: 2")
+		}
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Object sources
+// ─────────────────────────────────────────────────────────────────────────────
+
+// clusterResources are fetched one by one because any of the CRDs may be
+// missing depending on which Karpenter generation is installed.
+var clusterResources = []string{
+	"nodepools.karpenter.sh",
+	"ec2nodeclasses.karpenter.k8s.aws",
+	"provisioners.karpenter.sh",
+	"awsnodetemplates.karpenter.k8s.aws",
+	"nodes",
+	"pods",
+}
+
+func clusterObjects(kubeCtx string) ([]manifest.Sourced, error) {
+	var out []manifest.Sourced
+	reached := false
+	for _, res := range clusterResources {
+		items, err := manifest.ListCluster(kubeCtx, res, res == "pods")
+		if err != nil {
+			continue // CRD not installed (or RBAC) — nothing to scan
+		}
+		reached = true
+		for _, o := range items {
+			out = append(out, manifest.Sourced{Source: "cluster", Object: o})
+		}
+	}
+	if !reached {
+		return nil, fmt.Errorf("could not read any resources from the cluster")
+	}
+	return out, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+type annotationSet struct {
+	path string
+	m    map[string]any
+}
+
+// annotationSets returns the object's own annotations and, for workload
+// kinds, the pod template annotations — where do-not-evict usually lives.
+func annotationSets(o manifest.Object) []annotationSet {
+	var out []annotationSet
+	if m, ok := o.Get("metadata", "annotations").(map[string]any); ok {
+		out = append(out, annotationSet{"metadata.annotations", m})
+	}
+	if m, ok := o.Get("spec", "template", "metadata", "annotations").(map[string]any); ok {
+		out = append(out, annotationSet{"spec.template.metadata.annotations", m})
+	}
+	if m, ok := o.Get("spec", "jobTemplate", "spec", "template", "metadata", "annotations").(map[string]any); ok {
+		out = append(out, annotationSet{"spec.jobTemplate.spec.template.metadata.annotations", m})
+	}
+	return out
+}
@@ -0,0 +1,336 @@
+// Package pricing looks up current EC2 on-demand and spot prices for the
+// instance families karpx recommends, so a recommendation can be sanity-checked
+// against real numbers before it is applied.
+//
+// Prices come from the AWS CLI:
+//
+//	on-demand  aws pricing get-products          (Linux, shared tenancy by default)
+//	spot       aws ec2 describe-spot-price-history (cheapest AZ, latest price)
+//	shapes     aws ec2 describe-instance-types    (vCPU + memory per size)
+//
+// The China regions are priced by the Price List API in cn-northwest-1, in
+// CNY. GovCloud has no Price List API, so its on-demand prices are unknown
+// and only Spot is priced there.
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kemilad/karpx/internal/awscli"
+)
+
+// HoursPerMonth is the average number of hours in a month (8760 / 12).
+const HoursPerMonth = 730
+
+// Price holds the hourly prices of one instance type, in Currency. Zero
+// means unknown.
+type Price struct {
+	InstanceType string
+	Family       string
+	VCPU         int
+	MemoryGiB    float64
+	OnDemand     float64
+	Spot         float64
+	Currency     string // "USD", or "CNY" in the China regions; set once priced
+	Unpriced     string // why OnDemand is unknown, the same for every type it hits; "" when found
+}
+
+// Symbol is the sign amounts in currency are written with: "$" for USD
+// (and when the currency is not known), "¥" for CNY, and the code followed
+// by a space otherwise.
+func Symbol(currency string) string {
+	switch currency {
+	case "", "USD":
+		return "$"
+	case "CNY":
+		return "¥"
+	}
+	return currency + " "
+}
+
+// PerVCPU returns the on-demand and spot price per vCPU-hour.
+func (p Price) PerVCPU() (onDemand, spot float64) {
+	if p.VCPU == 0 {
+		return 0, 0
+	}
+	return p.OnDemand / float64(p.VCPU), p.Spot / float64(p.VCPU)
+}
+
+// PerGiB returns the on-demand and spot price per GiB-hour.
+func (p Price) PerGiB() (onDemand, spot float64) {
+	if p.MemoryGiB == 0 {
+		return 0, 0
+	}
+	return p.OnDemand / p.MemoryGiB, p.Spot / p.MemoryGiB
+}
+
+// SpotSaving returns the spot discount as a percentage of the on-demand price.
+func (p Price) SpotSaving() float64 {
+	if p.OnDemand == 0 || p.Spot == 0 {
+		return 0
+	}
+	return (1 - p.Spot/p.OnDemand) * 100
+}
+
+// Hourly returns the hourly price for the given capacity type, falling back
+// to on-demand when no spot price is known.
+func (p Price) Hourly(capacityType string) float64 {
+	if capacityType == "spot" && p.Spot > 0 {
+		return p.Spot
+	}
+	return p.OnDemand
+}
+
+// Lookup returns prices for every size of the given families in region.
+// When cpuSizes is non-empty only sizes with those vCPU counts are included.
+// tenancy is "" (shared) or "dedicated"; Spot is only priced for shared
+// tenancy. Results are sorted by family (in the order given) then by vCPU.
+func Lookup(region, tenancy string, families, cpuSizes []string) ([]Price, error) {
+	if len(families) == 0 {
+		return nil, fmt.Errorf("no instance families given")
+	}
+	if !awscli.Available() {
+		return nil, fmt.Errorf("aws CLI not found — install it from https://aws.amazon.com/cli/")
+	}
+
+	prices, err := instanceShapes(region, families, cpuSizes)
+	if err != nil {
+		return nil, err
+	}
+	if len(prices) == 0 {
+		return nil, nil
+	}
+
+	if err := fill(region, tenancy, prices); err != nil {
+		return nil, err
+	}
+
+	order := map[string]int{}
+	for i, f := range families {
+		order[f] = i
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if prices[i].Family != prices[j].Family {
+			return order[prices[i].Family] < order[prices[j].Family]
+		}
+		return prices[i].VCPU < prices[j].VCPU
+	})
+	return prices, nil
+}
+
+// ForTypes returns prices for an explicit list of instance types — used to
+// cost the nodes a cluster is running today. Unknown types are skipped.
+func ForTypes(region string, types []string) (map[string]Price, error) {
+	if len(types) == 0 {
+		return map[string]Price{}, nil
+	}
+	if !awscli.Available() {
+		return nil, fmt.Errorf("aws CLI not found — install it from https://aws.amazon.com/cli/")
+	}
+	prices, err := describeShapes(region, "--instance-types", types)
+	if err != nil {
+		return nil, err
+	}
+	if err := fill(region, "", prices); err != nil {
+		return nil, err
+	}
+	out := make(map[string]Price, len(prices))
+	for _, p := range prices {
+		out[p.InstanceType] = p
+	}
+	return out, nil
+}
+
+// Fill looks up the shared-tenancy on-demand and spot prices of instance
+// types whose shapes are already known, in place.
+func Fill(region string, prices []Price) error {
+	if !awscli.Available() {
+		return fmt.Errorf("aws CLI not found — install it from https://aws.amazon.com/cli/")
+	}
+	return fill(region, "", prices)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// fill populates the spot and on-demand prices of every entry in place.
+func fill(region, tenancy string, prices []Price) error {
+	types := make([]string, len(prices))
+	for i, p := range prices {
+		types[i] = p.InstanceType
+	}
+	spot := map[string]float64{}
+	if tenancy == "" {
+		var err error
+		if spot, err = spotPrices(region, types); err != nil {
+			return err
+		}
+	}
+
+	// The Pricing API takes one instance type per query — fan out with the
+	// same concurrency bound the web dashboard uses for cluster checks.
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	currency := awscli.Currency(region)
+	for i := range prices {
+		prices[i].Spot = spot[prices[i].InstanceType]
+		prices[i].Currency = currency
+		wg.Add(1)
+		go func(p *Price) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			v, err := onDemandPrice(region, tenancy, p.InstanceType)
+			if err != nil {
+				p.Unpriced = err.Error()
+				return
+			}
+			p.OnDemand = v
+		}(&prices[i])
+	}
+	wg.Wait()
+	return nil
+}
+
+func instanceShapes(region string, families, cpuSizes []string) ([]Price, error) {
+	patterns := make([]string, len(families))
+	for i, f := range families {
+		patterns[i] = f + ".*"
+	}
+	filters := []string{"Name=instance-type,Values=" + strings.Join(patterns, ",")}
+	if len(cpuSizes) > 0 {
+		filters = append(filters, "Name=vcpu-info.default-vcpus,Values="+strings.Join(cpuSizes, ","))
+	}
+	return describeShapes(region, "--filters", filters)
+}
+
+// describeShapes runs describe-instance-types with either --filters or
+// --instance-types and returns one Price (without prices yet) per type.
+func describeShapes(region, flag string, values []string) ([]Price, error) {
+	args := append([]string{"ec2", "describe-instance-types", flag}, values...)
+
+	var resp struct {
+		InstanceTypes []struct {
+			InstanceType string `json:"InstanceType"`
+			VCpuInfo     struct {
+				DefaultVCpus int `json:"DefaultVCpus"`
+			} `json:"VCpuInfo"`
+			MemoryInfo struct {
+				SizeInMiB int `json:"SizeInMiB"`
+			} `json:"MemoryInfo"`
+		} `json:"InstanceTypes"`
+	}
+	if err := awscli.JSON(&resp, region, args...); err != nil {
+		return nil, err
+	}
+
+	out := make([]Price, 0, len(resp.InstanceTypes))
+	for _, it := range resp.InstanceTypes {
+		family, _, _ := strings.Cut(it.InstanceType, ".")
+		out = append(out, Price{
+			InstanceType: it.InstanceType,
+			Family:       family,
+			VCPU:         it.VCpuInfo.DefaultVCpus,
+			MemoryGiB:    float64(it.MemoryInfo.SizeInMiB) / 1024,
+		})
+	}
+	return out, nil
+}
+
+// spotPrices returns the cheapest current Linux spot price per instance type
+// across all availability zones in region.
+func spotPrices(region string, types []string) (map[string]float64, error) {
+	args := append([]string{
+		"ec2", "describe-spot-price-history",
+		"--product-descriptions", "Linux/UNIX",
+		"--start-time", time.Now().UTC().Format(time.RFC3339),
+		"--instance-types",
+	}, types...)
+
+	var resp struct {
+		SpotPriceHistory []struct {
+			InstanceType string `json:"InstanceType"`
+			SpotPrice    string `json:"SpotPrice"`
+		} `json:"SpotPriceHistory"`
+	}
+	if err := awscli.JSON(&resp, region, args...); err != nil {
+		return nil, err
+	}
+
+	out := map[string]float64{}
+	for _, h := range resp.SpotPriceHistory {
+		v, err := strconv.ParseFloat(h.SpotPrice, 64)
+		if err != nil || v == 0 {
+			continue
+		}
+		if cur, ok := out[h.InstanceType]; !ok || v < cur {
+			out[h.InstanceType] = v
+		}
+	}
+	return out, nil
+}
+
+// onDemandPrice queries the Pricing API for the Linux on-demand hourly price.
+// The Pricing API is only served from a few regions per partition, so the
+// target region is passed as a product filter instead of --region. Errors
+// do not name the instance type, so Price.Unpriced can group types by them.
+func onDemandPrice(region, tenancy, instanceType string) (float64, error) {
+	endpoint := awscli.PricingRegion(region)
+	if endpoint == "" {
+		return 0, fmt.Errorf("no Price List API in the %s partition", awscli.Partition(region))
+	}
+	productTenancy := "Shared"
+	if tenancy == "dedicated" {
+		productTenancy = "Dedicated"
+	}
+	var resp struct {
+		PriceList []string `json:"PriceList"`
+	}
+	if err := awscli.JSON(&resp, endpoint,
+		"pricing", "get-products",
+		"--service-code", "AmazonEC2",
+		"--filters",
+		"Type=TERM_MATCH,Field=instanceType,Value="+instanceType,
+		"Type=TERM_MATCH,Field=regionCode,Value="+region,
+		"Type=TERM_MATCH,Field=operatingSystem,Value=Linux",
+		"Type=TERM_MATCH,Field=tenancy,Value="+productTenancy,
+		"Type=TERM_MATCH,Field=preInstalledSw,Value=NA",
+		"Type=TERM_MATCH,Field=capacitystatus,Value=Used",
+		"--max-items", "1",
+	); err != nil {
+		return 0, err
+	}
+	if len(resp.PriceList) == 0 {
+		return 0, fmt.Errorf("not listed in the Price List API for %s", region)
+	}
+
+	// Each PriceList entry is itself a JSON document.
+	var product struct {
+		Terms struct {
+			OnDemand map[string]struct {
+				PriceDimensions map[string]struct {
+					PricePerUnit map[string]string `json:"pricePerUnit"`
+				} `json:"priceDimensions"`
+			} `json:"OnDemand"`
+		} `json:"terms"`
+	}
+	if err := json.Unmarshal([]byte(resp.PriceList[0]), &product); err != nil {
+		return 0, fmt.Errorf("parse Price List entry: %w", err)
+	}
+	currency := awscli.Currency(region)
+	for _, term := range product.Terms.OnDemand {
+		for _, dim := range term.PriceDimensions {
+			if v, err := strconv.ParseFloat(dim.PricePerUnit[currency], 64); err == nil && v > 0 {
+				return v, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no %s price in the Price List entry", currency)
+}
@@ -0,0 +1,189 @@
+// Package progress emits machine-readable progress events so karpx can be
+// driven by other tools — internal portals, ChatOps bots, CI wrappers —
+// without scraping the human-oriented output.
+//
+// With --progress json every event is written to stderr as one JSON object
+// per line (NDJSON); stdout keeps the usual text, so generated manifests and
+// `--output json` results stay clean. With the default --progress text no
+// events are written.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	CommandStarted   = "command_started"
+	CommandCompleted = "command_completed"
+	CommandFailed    = "command_failed"
+	StepStarted      = "step_started"
+	StepCompleted    = "step_completed"
+	StepFailed       = "step_failed"
+	Log              = "log"
+)
+
+// Event is one NDJSON line.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Command string    `json:"command"`
+	Step    string    `json:"step,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+	Level   string    `json:"level,omitempty"` // log events: "info" | "warn"
+	// Percent is an estimate from the number of steps the command expects;
+	// it is omitted when the command did not declare a step count.
+	Percent *int   `json:"percent,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+var (
+	mu       sync.Mutex
+	enabled  bool
+	out      io.Writer = os.Stderr
+	command  string
+	current  string
+	expected int
+	done     int
+)
+
+// SetFormat selects "text" (no events) or "json" (NDJSON on stderr).
+func SetFormat(format string) error {
+	switch format {
+	case "", "text":
+		enabled = false
+	case "json":
+		enabled = true
+	default:
+		return fmt.Errorf("--progress must be text or json, got %q", format)
+	}
+	return nil
+}
+
+// SetOutput redirects events, e.g. to a stderr that has been wrapped after
+// start-up (--redact).
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// Enabled reports whether events are being emitted.
+func Enabled() bool { return enabled }
+
+// Begin starts a command.
+func Begin(cmd string) {
+	mu.Lock()
+	defer mu.Unlock()
+	command, current, expected, done = cmd, "", 0, 0
+	emit(Event{Type: CommandStarted})
+}
+
+// Expect declares how many steps the command will run, enabling percentages.
+func Expect(steps int) {
+	mu.Lock()
+	defer mu.Unlock()
+	expected = steps
+}
+
+// Step completes the current step, if any, and starts the next one. It suits
+// flows that move through sections one after another.
+func Step(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current != "" {
+		complete(current, "")
+	}
+	current = name
+	emit(Event{Type: StepStarted, Step: name})
+}
+
+// Start begins a step that will be closed explicitly with Complete or Fail.
+func Start(name, detail string) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = name
+	emit(Event{Type: StepStarted, Step: name, Detail: detail})
+}
+
+// Complete marks a step as finished.
+func Complete(name, detail string) {
+	mu.Lock()
+	defer mu.Unlock()
+	complete(name, detail)
+	if current == name {
+		current = ""
+	}
+}
+
+// Fail marks a step as failed.
+func Fail(name string, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	emit(Event{Type: StepFailed, Step: name, Error: err.Error()})
+	if current == name {
+		current = ""
+	}
+}
+
+// Info and Warn emit free-text log events.
+func Info(format string, args ...any) { logf("info", format, args...) }
+func Warn(format string, args ...any) { logf("warn", format, args...) }
+
+// Finish closes the open step and emits the command result.
+func Finish(err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if command == "" {
+		return
+	}
+	if err != nil {
+		if current != "" {
+			emit(Event{Type: StepFailed, Step: current, Error: err.Error()})
+		}
+		emit(Event{Type: CommandFailed, Error: err.Error()})
+	} else {
+		if current != "" {
+			complete(current, "")
+		}
+		full := 100
+		emit(Event{Type: CommandCompleted, Percent: &full})
+	}
+	command, current = "", ""
+}
+
+func logf(level, format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	emit(Event{Type: Log, Level: level, Step: current, Detail: fmt.Sprintf(format, args...)})
+}
+
+// complete must be called with mu held.
+func complete(name, detail string) {
+	done++
+	ev := Event{Type: StepCompleted, Step: name, Detail: detail}
+	if expected > 0 {
+		pct := min(99, done*100/expected)
+		ev.Percent = &pct
+	}
+	emit(ev)
+}
+
+// emit must be called with mu held.
+func emit(ev Event) {
+	if !enabled {
+		return
+	}
+	ev.Time = time.Now().UTC()
+	ev.Command = command
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(out, "%s\n", b)
+}
@@ -0,0 +1,186 @@
+// Package prompt implements the interactive questions karpx asks during
+// install, upgrade, and the other guided flows.
+//
+// Questions are huh forms drawn on Output(): menus are picked with the arrow
+// keys, answers are validated inline before Enter is accepted, and a
+// one-line summary of each answer stays in the scrollback once the form
+// closes. TERM=dumb switches huh to its accessible line mode for terminals
+// that cannot redraw. When stdin is not a terminal — CI, pipes, IDE run
+// panes, `< /dev/null` — prompts never block: every question resolves to its
+// default, required values must come from flags, and confirmations need
+// --yes.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"golang.org/x/term"
+)
+
+var (
+	out       io.Writer // nil = os.Stdout at the time of writing
+	assumeYes bool
+	noInput   bool
+)
+
+// SetOutput sends questions to w instead of stdout, e.g. stderr when stdout
+// carries a manifest or JSON.
+func SetOutput(w io.Writer) { out = w }
+
+// Output is where questions are written.
+func Output() io.Writer {
+	if out != nil {
+		return out
+	}
+	return os.Stdout
+}
+
+// SetAssumeYes makes every Confirm return true without asking (--yes).
+func SetAssumeYes(v bool) { assumeYes = v }
+
+// AssumeYes reports whether confirmations are answered by --yes.
+func AssumeYes() bool { return assumeYes }
+
+// SetNoInput disables prompting even when stdin is a terminal (--no-input).
+func SetNoInput(v bool) { noInput = v }
+
+// Interactive reports whether questions are read from the user.
+func Interactive() bool {
+	return !noInput && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// String asks for a value and returns def when the answer is empty or the
+// session is not interactive. A non-nil validate rejects bad answers inline
+// until they are fixed.
+func String(label, def string, validate func(string) error) string {
+	if !Interactive() {
+		fmt.Fprintf(Output(), "  %s: ", label)
+		nonInteractiveNote(def)
+		return def
+	}
+	var answer string
+	run(huh.NewInput().
+		Title(label).
+		Placeholder(def).
+		Value(&answer).
+		Validate(func(v string) error {
+			if v = strings.TrimSpace(v); v == "" || validate == nil {
+				return nil
+			}
+			return validate(v)
+		}))
+	if answer = strings.TrimSpace(answer); answer == "" {
+		answer = def
+	}
+	fmt.Fprintf(Output(), "  %s: %s\n", label, answer)
+	return answer
+}
+
+// Confirm asks a yes/no question; def is the answer the form starts on. With
+// --yes it returns true immediately. Without a terminal it returns def, so
+// questions defaulting to "no" (anything destructive) need --yes in CI.
+func Confirm(label string, def bool) bool {
+	if assumeYes {
+		fmt.Fprintf(Output(), "%sy (--yes)\n", label)
+		return true
+	}
+	if !Interactive() {
+		fmt.Fprint(Output(), label)
+		if def {
+			nonInteractiveNote("y")
+		} else {
+			nonInteractiveNote("n")
+		}
+		return def
+	}
+	answer := def
+	run(huh.NewConfirm().
+		Title(confirmTitle(label)).
+		Affirmative("Yes").
+		Negative("No").
+		Value(&answer))
+	if answer {
+		fmt.Fprintf(Output(), "%sy\n", label)
+	} else {
+		fmt.Fprintf(Output(), "%sn\n", label)
+	}
+	return answer
+}
+
+// Option is one entry of a Select menu.
+type Option struct {
+	Label  string
+	Detail string // shown after the label; may be empty
+}
+
+// Select asks for one of options and returns its index, or def when there is
+// no terminal. def may be -1 for "no choice"; the cursor then starts on the
+// first option.
+func Select(title string, options []Option, def int) int {
+	if !Interactive() {
+		fmt.Fprintf(Output(), "  %s: ", title)
+		if def >= 0 {
+			nonInteractiveNote(options[def].Label)
+		} else {
+			nonInteractiveNote("")
+		}
+		return def
+	}
+	choice := max(def, 0)
+	opts := make([]huh.Option[int], len(options))
+	for i, o := range options {
+		label := o.Label
+		if o.Detail != "" {
+			label += " — " + o.Detail
+		}
+		opts[i] = huh.NewOption(label, i)
+	}
+	// Value before Options, so the cursor starts on the default.
+	run(huh.NewSelect[int]().
+		Title(title).
+		Value(&choice).
+		Options(opts...))
+	fmt.Fprintf(Output(), "  %s: %s\n", title, options[choice].Label)
+	return choice
+}
+
+// run shows a one-field form. Ctrl+C ends karpx with the usual interrupt
+// status, as it did before the form took over the terminal.
+func run(field huh.Field) {
+	err := huh.NewForm(huh.NewGroup(field)).
+		WithShowHelp(false).
+		WithOutput(Output()).
+		WithAccessible(os.Getenv("TERM") == "dumb").
+		Run()
+	if errors.Is(err, huh.ErrUserAborted) {
+		fmt.Fprintf(Output(), "  Cancelled.\n")
+		os.Exit(130)
+	}
+	if err != nil {
+		fmt.Fprintf(Output(), "  ✗ prompt: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// confirmTitle strips the leading indent and the "[y/N]" hint from a Confirm
+// label; the form shows its own Yes/No buttons.
+func confirmTitle(label string) string {
+	t := strings.TrimSpace(label)
+	for _, hint := range []string{"[y/N]", "[Y/n]"} {
+		t = strings.TrimSpace(strings.TrimSuffix(t, hint))
+	}
+	return t
+}
+
+func nonInteractiveNote(def string) {
+	if def == "" {
+		fmt.Fprintf(Output(), "(no TTY — pass it as a flag)\n")
+		return
+	}
+	fmt.Fprintf(Output(), "%s (no TTY — default)\n", def)
+}
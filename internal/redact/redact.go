@@ -0,0 +1,210 @@
+// Package redact masks infrastructure identifiers in karpx output so that
+// diagnostics and reports can be shared outside the team (--redact).
+//
+// AWS account IDs, ARNs, cluster API endpoints and kubeconfig context names
+// are replaced with stable pseudonyms — the same value always maps to the
+// same pseudonym within one run — so a redacted report still shows that two
+// lines refer to the same cluster or account:
+//
+//	arn:aws:iam::123456789012:role/KarpenterController  →  arn:aws:iam::account-1:arn-1
+//	https://ABCD1234.gr7.us-east-1.eks.amazonaws.com    →  https://endpoint-1
+//	prod-eu-west-1                                      →  context-1
+package redact
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mu       sync.Mutex
+	enabled  bool
+	contexts []contextName // longest first, so a context is never masked by a prefix of it
+	names    = map[string]string{}
+	counts   = map[string]int{}
+)
+
+var (
+	arnRe      = regexp.MustCompile(`arn:(aws[a-z-]*):([a-z0-9-]*):([a-z0-9-]*):(\d{12})?:([^\s"',;)\]}]+)`)
+	endpointRe = regexp.MustCompile(`https://[A-Za-z0-9.-]+\.(eks\.amazonaws\.com(\.cn)?|azmk8s\.io)|https://\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}(:\d+)?`)
+	// accountRe only matches a 12-digit number where it is labelled as an
+	// account ("Account": "…", account-id=…) or names an ECR registry, so
+	// other long numbers are left alone.
+	accountRe = regexp.MustCompile(`(?i)(\baccount[\w-]*"?\s*[:=]?\s*"?)(\d{12})\b|\b(\d{12})(\.dkr\.ecr\.)`)
+)
+
+// contextName matches one context as a whole token: "staging" is masked in
+// "context staging:" but not inside "staging-2".
+type contextName struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Enable turns redaction on. ctxNames are the kubeconfig context names to
+// mask wherever they appear as a whole token.
+func Enable(ctxNames []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = true
+	contexts = nil
+	for _, c := range ctxNames {
+		if c != "" {
+			re := regexp.MustCompile(`(^|[^\w.-])` + regexp.QuoteMeta(c) + `([^\w.-]|$)`)
+			contexts = append(contexts, contextName{c, re})
+		}
+	}
+	sort.Slice(contexts, func(i, j int) bool { return len(contexts[i].name) > len(contexts[j].name) })
+}
+
+// Enabled reports whether output is being redacted.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// String returns s with every identifier replaced by its pseudonym. It is a
+// no-op when redaction is off.
+func String(s string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return s
+	}
+	for _, c := range contexts {
+		// Matches consume their boundary character, so back-to-back
+		// occurrences ("a a") need a second pass.
+		for strings.Contains(s, c.name) {
+			next := c.re.ReplaceAllString(s, "${1}"+pseudonym("context", c.name)+"${2}")
+			if next == s {
+				break
+			}
+			s = next
+		}
+	}
+	s = arnRe.ReplaceAllStringFunc(s, func(arn string) string {
+		m := arnRe.FindStringSubmatch(arn)
+		account := ""
+		if m[4] != "" {
+			account = pseudonym("account", m[4])
+		}
+		return fmt.Sprintf("arn:%s:%s:%s:%s:%s", m[1], m[2], m[3], account, pseudonym("arn", arn))
+	})
+	s = endpointRe.ReplaceAllStringFunc(s, func(ep string) string {
+		return "https://" + pseudonym("endpoint", ep)
+	})
+	s = accountRe.ReplaceAllStringFunc(s, func(match string) string {
+		m := accountRe.FindStringSubmatch(match)
+		if m[2] != "" {
+			return m[1] + pseudonym("account", m[2])
+		}
+		return pseudonym("account", m[3]) + m[4]
+	})
+	return s
+}
+
+// pseudonym returns the stable replacement for value within kind. mu is held.
+func pseudonym(kind, value string) string {
+	key := kind + "\x00" + value
+	if p, ok := names[key]; ok {
+		return p
+	}
+	counts[kind]++
+	p := fmt.Sprintf("%s-%d", kind, counts[kind])
+	names[key] = p
+	return p
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Standard stream filtering
+// ─────────────────────────────────────────────────────────────────────────────
+
+// flushAfter is how long a partial line (a prompt waiting for input) is held
+// back before it is written anyway.
+const flushAfter = 50 * time.Millisecond
+
+// Install replaces os.Stdout and os.Stderr with pipes whose contents are
+// redacted line by line before reaching the terminal. The returned function
+// restores the original streams and waits until everything is written; call
+// it before the process exits.
+func Install() (restore func()) {
+	origOut, origErr := os.Stdout, os.Stderr
+	stopOut := filter(&os.Stdout, origOut)
+	stopErr := filter(&os.Stderr, origErr)
+	return func() {
+		stopOut()
+		stopErr()
+		os.Stdout, os.Stderr = origOut, origErr
+	}
+}
+
+// filter points *stream at a pipe and copies the pipe to dst, redacted. Text
+// is redacted a whole line at a time so identifiers split across writes are
+// still caught; a trailing partial line is flushed after flushAfter.
+func filter(stream **os.File, dst io.Writer) (stop func()) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+	*stream = w
+
+	chunks := make(chan []byte)
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				chunks <- append([]byte(nil), buf[:n]...)
+			}
+			if err != nil {
+				close(chunks)
+				return
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var pending []byte
+		timer := time.NewTimer(flushAfter)
+		timer.Stop()
+		for {
+			select {
+			case chunk, ok := <-chunks:
+				if !ok {
+					if len(pending) > 0 {
+						io.WriteString(dst, String(string(pending)))
+					}
+					return
+				}
+				pending = append(pending, chunk...)
+				if i := bytes.LastIndexByte(pending, '\n'); i >= 0 {
+					io.WriteString(dst, String(string(pending[:i+1])))
+					pending = append([]byte(nil), pending[i+1:]...)
+				}
+				if len(pending) > 0 {
+					timer.Reset(flushAfter)
+				}
+			case <-timer.C:
+				if len(pending) > 0 {
+					io.WriteString(dst, String(string(pending)))
+					pending = nil
+				}
+			}
+		}
+	}()
+
+	return func() {
+		w.Close()
+		<-done
+		r.Close()
+	}
+}
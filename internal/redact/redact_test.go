@@ -0,0 +1,125 @@
+package redact
+
+import "testing"
+
+// enable turns redaction on for one test with fresh pseudonym counters, so
+// the first value of each kind is always "<kind>-1".
+func enable(t *testing.T, ctxNames ...string) {
+	t.Helper()
+	mu.Lock()
+	names, counts = map[string]string{}, map[string]int{}
+	mu.Unlock()
+	Enable(ctxNames)
+	t.Cleanup(func() {
+		mu.Lock()
+		enabled, contexts = false, nil
+		mu.Unlock()
+	})
+}
+
+func TestStringARN(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"aws", "role arn:aws:iam::123456789012:role/KarpenterController",
+			"role arn:aws:iam::account-1:arn-1"},
+		{"aws-cn", "arn:aws-cn:eks:cn-north-1:123456789012:cluster/prod",
+			"arn:aws-cn:eks:cn-north-1:account-1:arn-1"},
+		{"aws-us-gov", "arn:aws-us-gov:sqs:us-gov-west-1:123456789012:karpenter",
+			"arn:aws-us-gov:sqs:us-gov-west-1:account-1:arn-1"},
+		{"no account", "arn:aws:s3:::my-bucket/key",
+			"arn:aws:s3:::arn-1"},
+		{"quoted", `"Arn": "arn:aws:iam::123456789012:user/ci",`,
+			`"Arn": "arn:aws:iam::account-1:arn-1",`},
+		{"same ARN twice", "arn:aws:iam::123456789012:role/a arn:aws:iam::123456789012:role/a",
+			"arn:aws:iam::account-1:arn-1 arn:aws:iam::account-1:arn-1"},
+		{"two ARNs one account", "arn:aws:iam::123456789012:role/a arn:aws:iam::123456789012:role/b",
+			"arn:aws:iam::account-1:arn-1 arn:aws:iam::account-1:arn-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enable(t)
+			if got := String(tt.in); got != tt.want {
+				t.Errorf("String(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringEndpoint(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"eks", "server: https://ABCD1234.gr7.us-east-1.eks.amazonaws.com",
+			"server: https://endpoint-1"},
+		{"eks china", "server: https://ABCD1234.yl4.cn-north-1.eks.amazonaws.com.cn",
+			"server: https://endpoint-1"},
+		{"aks", "https://prod-dns-1a2b3c4d.hcp.westeurope.azmk8s.io:443",
+			"https://endpoint-1:443"},
+		{"ip with port", "https://10.0.12.7:6443 is unreachable",
+			"https://endpoint-1 is unreachable"},
+		{"other host", "see https://karpenter.sh/docs",
+			"see https://karpenter.sh/docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enable(t)
+			if got := String(tt.in); got != tt.want {
+				t.Errorf("String(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringAccount(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"json label", `"Account": "123456789012"`, `"Account": "account-1"`},
+		{"flag label", "--account-id=123456789012", "--account-id=account-1"},
+		{"prose label", "account 123456789012", "account account-1"},
+		{"ecr registry", "123456789012.dkr.ecr.us-east-1.amazonaws.com/karpenter",
+			"account-1.dkr.ecr.us-east-1.amazonaws.com/karpenter"},
+		{"unlabelled", "resourceVersion 123456789012", "resourceVersion 123456789012"},
+		{"13 digits", "account 1234567890123", "account 1234567890123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enable(t)
+			if got := String(tt.in); got != tt.want {
+				t.Errorf("String(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		contexts []string
+		in, want string
+	}{
+		{"whole token", []string{"staging"}, "context staging: ok", "context context-1: ok"},
+		{"inside another word", []string{"staging"}, "staging-2 staging.yaml", "staging-2 staging.yaml"},
+		{"back to back", []string{"staging"}, "staging staging,staging", "context-1 context-1,context-1"},
+		{"prefix of another context", []string{"prod", "prod-eu"}, "prod-eu then prod",
+			"context-1 then context-2"},
+		{"EKS ARN context", []string{"arn:aws:eks:eu-west-1:123456789012:cluster/prod"},
+			"switched to arn:aws:eks:eu-west-1:123456789012:cluster/prod", "switched to context-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enable(t, tt.contexts...)
+			if got := String(tt.in); got != tt.want {
+				t.Errorf("String(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringDisabled(t *testing.T) {
+	in := "arn:aws:iam::123456789012:role/a https://10.0.0.1 staging"
+	if got := String(in); got != in {
+		t.Errorf("String changed %q to %q with redaction off", in, got)
+	}
+}
@@ -0,0 +1,409 @@
+// Package report builds the scheduled Karpenter health report: fleet
+// compatibility status, a cost summary, lint findings and what changed since
+// the previous report, rendered as a self-contained HTML page or Markdown.
+//
+// The previous report is remembered in a small state file (by default
+// ~/.karpx/report-state.json), so running the report from cron every week
+// shows the week's changes without any bookkeeping.
+package report
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/kemilad/karpx/internal/awscli"
+	"github.com/kemilad/karpx/internal/config"
+	"github.com/kemilad/karpx/internal/kube"
+	"github.com/kemilad/karpx/internal/lint"
+	"github.com/kemilad/karpx/internal/pricing"
+	"github.com/kemilad/karpx/internal/showback"
+	"github.com/kemilad/karpx/internal/status"
+)
+
+//go:embed report.html
+var reportHTML string
+
+//go:embed report.md
+var reportMD string
+
+// topNamespaces is how many of the most expensive namespaces are listed.
+const topNamespaces = 5
+
+// Cost is the showback summary of one cluster.
+type Cost struct {
+	Nodes    int            `json:"nodes"`
+	Monthly  float64        `json:"monthly"`
+	Currency string         `json:"currency,omitempty"`
+	Top      []showback.Row `json:"top,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// Cluster is one cluster in the report.
+type Cluster struct {
+	status.Cluster
+	Cost    *Cost          `json:"cost,omitempty"`
+	Lint    []lint.Finding `json:"lint,omitempty"`
+	LintErr string         `json:"lint_error,omitempty"`
+	Changes []string       `json:"changes,omitempty"`
+}
+
+// Report is everything one report renders.
+type Report struct {
+	Generated time.Time `json:"generated"`
+	Version   string    `json:"karpx_version"`
+	Previous  time.Time `json:"previous,omitempty"` // zero for the first report
+	Clusters  []Cluster `json:"clusters"`
+	// Removed are contexts in the previous report that are gone now.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Totals are the headline numbers of a report. Monthly is zero when the
+// clusters are priced in different currencies.
+type Totals struct {
+	Installed, Upgrades, Incompatible, OutOfPolicy int
+	LintErrors, LintWarnings                       int
+	Monthly                                        float64
+	Currency                                       string
+	Changed                                        int
+}
+
+// Totals sums the headline numbers.
+func (r *Report) Totals() Totals {
+	var t Totals
+	mixed := false
+	for _, c := range r.Clusters {
+		if c.KarpenterInstalled {
+			t.Installed++
+		}
+		if c.UpgradeAvailable {
+			t.Upgrades++
+		}
+		if c.Compatible != nil && !*c.Compatible {
+			t.Incompatible++
+		}
+		if c.OutOfPolicy {
+			t.OutOfPolicy++
+		}
+		for _, f := range c.Lint {
+			switch f.Severity {
+			case lint.Error:
+				t.LintErrors++
+			case lint.Warning:
+				t.LintWarnings++
+			}
+		}
+		if c.Cost != nil && c.Cost.Error == "" {
+			if t.Currency != "" && t.Currency != c.Cost.Currency {
+				mixed = true
+			}
+			t.Currency = c.Cost.Currency
+			t.Monthly += c.Cost.Monthly
+		}
+		if len(c.Changes) > 0 {
+			t.Changed++
+		}
+	}
+	if mixed {
+		t.Monthly, t.Currency = 0, ""
+	}
+	return t
+}
+
+// Params holds all inputs for Build.
+type Params struct {
+	KubeCtx  string // one context; "" for every context matching Selector
+	Selector config.Selector
+	Version  string
+	Cost     bool   // price the Karpenter nodes (AWS clusters only)
+	State    string // state file path; "" disables changes since the last report
+}
+
+// Build collects the report. progress, when set, is told which cluster is
+// being inspected.
+func Build(p Params, progress func(context string)) (*Report, error) {
+	contexts := []string{p.KubeCtx}
+	if p.KubeCtx == "" {
+		contexts = status.Filter(status.AllContexts(), p.Selector)
+	}
+	r := &Report{Generated: time.Now(), Version: p.Version}
+	for _, st := range status.Check(contexts) {
+		c := Cluster{Cluster: st}
+		if st.Error == "" && st.KarpenterInstalled {
+			if progress != nil {
+				progress(st.Context)
+			}
+			if lc, err := lint.Load(st.Context); err != nil {
+				c.LintErr = err.Error()
+			} else {
+				c.Lint = lint.Run(lc)
+			}
+			if p.Cost && st.Managed == "" {
+				c.Cost = cost(st)
+			}
+		}
+		r.Clusters = append(r.Clusters, c)
+	}
+
+	if p.State != "" {
+		prev, err := loadState(p.State)
+		if err != nil {
+			return nil, err
+		}
+		// A report of one context or a tagged subset says nothing about the
+		// clusters it skipped.
+		r.diff(prev, p.KubeCtx == "" && len(p.Selector) == 0)
+	}
+	return r, nil
+}
+
+// cost summarises the showback of an AWS cluster, or returns nil elsewhere.
+func cost(st status.Cluster) *Cost {
+	if kube.Provider(st.Provider) != kube.ProviderAWS {
+		return nil
+	}
+	region := awscli.RegionFromContext(st.Context)
+	if region == "" || !awscli.Available() {
+		return &Cost{Error: "AWS region or CLI not available"}
+	}
+	rep, err := showback.Load(st.Context, region, "", false)
+	if err != nil {
+		return &Cost{Error: err.Error()}
+	}
+	c := &Cost{Nodes: rep.Nodes, Monthly: rep.Monthly(), Currency: awscli.Currency(region)}
+	for _, row := range rep.Rows {
+		if len(c.Top) == topNamespaces {
+			break
+		}
+		c.Top = append(c.Top, row)
+	}
+	return c
+}
+
+// money formats a monthly amount in currency.
+func money(currency string, v float64) string {
+	return fmt.Sprintf("%s%.0f", pricing.Symbol(currency), v)
+}
+
+// sameCurrency reports whether two recorded currencies match. States saved
+// before the currency was recorded were always USD.
+func sameCurrency(a, b string) bool {
+	return pricing.Symbol(a) == pricing.Symbol(b)
+}
+
+// Render writes the report as "html" or "md".
+func (r *Report) Render(w io.Writer, format string) error {
+	data := struct {
+		*Report
+		Totals Totals
+	}{r, r.Totals()}
+	funcs := map[string]any{
+		"deref":   func(b *bool) bool { return b != nil && *b },
+		"money":   func(currency string, v float64) string { return money(currency, v) },
+		"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v) },
+		"date":    func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+		"cell":    func(s string) string { return strings.ReplaceAll(s, "|", `\|`) },
+	}
+	switch format {
+	case "html":
+		t, err := htmltemplate.New("report").Funcs(funcs).Parse(reportHTML)
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, data)
+	case "md":
+		t, err := texttemplate.New("report").Funcs(funcs).Parse(reportMD)
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, data)
+	}
+	return fmt.Errorf("--format must be html or md, got %q", format)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// State — what the previous report saw
+// ─────────────────────────────────────────────────────────────────────────────
+
+// DefaultStatePath is where the previous report is remembered.
+func DefaultStatePath() string {
+	return filepath.Join(filepath.Dir(config.Path()), "report-state.json")
+}
+
+type state struct {
+	Generated time.Time               `json:"generated"`
+	Clusters  map[string]clusterState `json:"clusters"`
+}
+
+type clusterState struct {
+	K8sVersion       string   `json:"k8s_version"`
+	KarpenterVersion string   `json:"karpenter_version,omitempty"`
+	Installed        bool     `json:"karpenter_installed"`
+	Compatible       *bool    `json:"compatible,omitempty"`
+	LatestCompatible string   `json:"latest_compatible,omitempty"`
+	Monthly          *float64 `json:"monthly,omitempty"`
+	Currency         string   `json:"currency,omitempty"`
+	Findings         []string `json:"findings,omitempty"` // severity rule kind/name
+}
+
+func loadState(path string) (*state, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// SaveState records the report as the baseline for the next one. Call it
+// only after the report was written, so a failed run does not hide changes.
+func (r *Report) SaveState(path string) error {
+	// Contexts this report skipped or could not reach keep their last good
+	// state; removed ones are dropped.
+	s := state{Generated: r.Generated, Clusters: map[string]clusterState{}}
+	if prev, err := loadState(path); err == nil && prev != nil && prev.Clusters != nil {
+		s.Clusters = prev.Clusters
+	}
+	for _, ctx := range r.Removed {
+		delete(s.Clusters, ctx)
+	}
+	for _, c := range r.Clusters {
+		if c.Error == "" {
+			s.Clusters[c.Context] = snapshot(c)
+		}
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func snapshot(c Cluster) clusterState {
+	cs := clusterState{
+		K8sVersion:       c.K8sVersion,
+		KarpenterVersion: c.KarpenterVersion,
+		Installed:        c.KarpenterInstalled,
+		Compatible:       c.Compatible,
+		LatestCompatible: c.LatestCompatible,
+	}
+	if c.Cost != nil && c.Cost.Error == "" {
+		m := c.Cost.Monthly
+		cs.Monthly, cs.Currency = &m, c.Cost.Currency
+	}
+	for _, f := range c.Lint {
+		cs.Findings = append(cs.Findings, findingKey(f))
+	}
+	sort.Strings(cs.Findings)
+	return cs
+}
+
+func findingKey(f lint.Finding) string {
+	return fmt.Sprintf("%s %s %s/%s", f.Severity, f.Rule, f.Kind, f.Name)
+}
+
+// diff fills in Changes against the previous state, and Removed when the
+// report covers every context.
+func (r *Report) diff(prev *state, all bool) {
+	if prev == nil {
+		return
+	}
+	r.Previous = prev.Generated
+	seen := map[string]bool{}
+	for i := range r.Clusters {
+		c := &r.Clusters[i]
+		seen[c.Context] = true
+		if c.Error != "" {
+			continue
+		}
+		old, ok := prev.Clusters[c.Context]
+		if !ok {
+			c.Changes = append(c.Changes, "new cluster in the report")
+			continue
+		}
+		now := snapshot(*c)
+		add := func(format string, args ...any) { c.Changes = append(c.Changes, fmt.Sprintf(format, args...)) }
+		if old.K8sVersion != now.K8sVersion && now.K8sVersion != "" {
+			add("Kubernetes %s → %s", old.K8sVersion, now.K8sVersion)
+		}
+		switch {
+		case old.Installed && !now.Installed:
+			add("Karpenter uninstalled")
+		case !old.Installed && now.Installed:
+			add("Karpenter v%s installed", now.KarpenterVersion)
+		case old.KarpenterVersion != now.KarpenterVersion:
+			add("Karpenter v%s → v%s", old.KarpenterVersion, now.KarpenterVersion)
+		}
+		oldOK, nowOK := old.Compatible != nil && *old.Compatible, now.Compatible != nil && *now.Compatible
+		if old.Compatible != nil && now.Compatible != nil && oldOK != nowOK {
+			if nowOK {
+				add("now compatible")
+			} else {
+				add("became incompatible")
+			}
+		}
+		if now.LatestCompatible != "" && old.LatestCompatible != now.LatestCompatible {
+			add("new compatible release v%s", now.LatestCompatible)
+		}
+		if old.Monthly != nil && now.Monthly != nil && *old.Monthly > 0 && sameCurrency(old.Currency, now.Currency) {
+			delta := (*now.Monthly - *old.Monthly) / *old.Monthly * 100
+			if delta >= 5 || delta <= -5 {
+				add("cost %s → %s/month (%+.0f%%)", money(old.Currency, *old.Monthly), money(now.Currency, *now.Monthly), delta)
+			}
+		}
+		added, resolved := compare(old.Findings, now.Findings)
+		if len(added) > 0 {
+			add("%d new lint finding(s): %s", len(added), strings.Join(added, "; "))
+		}
+		if len(resolved) > 0 {
+			add("%d lint finding(s) resolved", len(resolved))
+		}
+	}
+	for ctx := range prev.Clusters {
+		if all && !seen[ctx] {
+			r.Removed = append(r.Removed, ctx)
+		}
+	}
+	sort.Strings(r.Removed)
+}
+
+// compare returns the keys only in now and only in old.
+func compare(old, now []string) (added, removed []string) {
+	in := func(list []string, s string) bool {
+		for _, v := range list {
+			if v == s {
+				return true
+			}
+		}
+		return false
+	}
+	for _, k := range now {
+		if !in(old, k) {
+			added = append(added, k)
+		}
+	}
+	for _, k := range old {
+		if !in(now, k) {
+			removed = append(removed, k)
+		}
+	}
+	return added, removed
+}
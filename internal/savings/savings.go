@@ -0,0 +1,183 @@
+// Package savings estimates what Karpenter consolidation would save on a
+// cluster by repacking the pods running today onto fewer or cheaper nodes of
+// the recommended instance families.
+//
+// The simulation is deliberately conservative: movable pods are packed with
+// first-fit-decreasing onto nodes of a single instance type (the cheapest
+// candidate that fits everything), whereas real Karpenter mixes shapes and
+// usually does slightly better. Nodes hosting pods that must not move are
+// kept as-is at their current price.
+package savings
+
+import (
+	"sort"
+
+	"github.com/kemilad/karpx/internal/kube"
+	"github.com/kemilad/karpx/internal/pricing"
+)
+
+// Result is the outcome of a consolidation simulation. Prices are per hour,
+// in Currency.
+type Result struct {
+	Currency      string
+	CurrentNodes  int
+	CurrentHourly float64
+	CPUUtil       float64 // requested / allocatable across all nodes (0–1)
+	MemUtil       float64
+
+	PinnedNodes  int
+	PinnedHourly float64
+
+	Target         pricing.Price // chosen instance type for the repacked pods
+	TargetCapacity string        // "spot" | "on-demand"
+	TargetNodes    int
+	TargetHourly   float64
+
+	MovablePods int
+	Unpriced    []string // instance types in the cluster with no known price
+}
+
+// ProjectedHourly is the simulated cost after consolidation.
+func (r Result) ProjectedHourly() float64 { return r.PinnedHourly + r.TargetHourly }
+
+// MonthlySaving is the estimated saving per month (negative means more expensive).
+func (r Result) MonthlySaving() float64 {
+	return (r.CurrentHourly - r.ProjectedHourly()) * pricing.HoursPerMonth
+}
+
+// SavingPercent is the estimated saving as a percentage of the current cost.
+func (r Result) SavingPercent() float64 {
+	if r.CurrentHourly == 0 {
+		return 0
+	}
+	return (r.CurrentHourly - r.ProjectedHourly()) / r.CurrentHourly * 100
+}
+
+// Simulate repacks the movable pods of nodes onto the cheapest candidate
+// instance type. current prices the instance types running today; capacity
+// is the capacity type the recommendation would launch ("spot" or "on-demand").
+// cni sets how many pods fit on each candidate size.
+func Simulate(nodes []kube.NodeUsage, current map[string]pricing.Price, candidates []pricing.Price, capacity string, cni kube.CNIMode) Result {
+	r := Result{CurrentNodes: len(nodes), TargetCapacity: capacity}
+	for _, p := range candidates {
+		if p.Currency != "" {
+			r.Currency = p.Currency
+			break
+		}
+	}
+
+	var allocCPU, allocMem, reqCPU, reqMem int64
+	var daemonCPU, daemonMem int64
+	var daemonPods int
+	var pods []kube.PodRequest
+	unpriced := map[string]bool{}
+
+	for _, n := range nodes {
+		allocCPU += n.AllocCPUm
+		allocMem += n.AllocMemMiB
+		reqCPU += n.ReqCPUm()
+		reqMem += n.ReqMemMiB()
+
+		hourly := 0.0
+		if p, ok := current[n.InstanceType]; ok {
+			hourly = p.Hourly(n.CapacityType)
+		}
+		if hourly == 0 && n.InstanceType != "" {
+			unpriced[n.InstanceType] = true
+		}
+		r.CurrentHourly += hourly
+
+		if n.Pinned {
+			r.PinnedNodes++
+			r.PinnedHourly += hourly
+			continue
+		}
+		pods = append(pods, n.Pods...)
+		// Every new node carries the same DaemonSets — use the largest observed.
+		daemonCPU = max(daemonCPU, n.DaemonCPUm)
+		daemonMem = max(daemonMem, n.DaemonMemMiB)
+		daemonPods = max(daemonPods, n.DaemonPods)
+	}
+	if allocCPU > 0 {
+		r.CPUUtil = float64(reqCPU) / float64(allocCPU)
+	}
+	if allocMem > 0 {
+		r.MemUtil = float64(reqMem) / float64(allocMem)
+	}
+	for t := range unpriced {
+		r.Unpriced = append(r.Unpriced, t)
+	}
+	sort.Strings(r.Unpriced)
+
+	r.MovablePods = len(pods)
+	if len(pods) == 0 {
+		return r
+	}
+
+	best := -1.0
+	for _, c := range candidates {
+		price := c.Hourly(capacity)
+		if price == 0 {
+			continue
+		}
+		cpu, mem := Allocatable(c, cni)
+		cpu -= daemonCPU
+		mem -= daemonMem
+		n := pack(pods, cpu, mem, kube.MaxPodsFor(c.VCPU, cni)-daemonPods)
+		if n == 0 {
+			continue // at least one pod does not fit this shape
+		}
+		if cost := float64(n) * price; best < 0 || cost < best {
+			best = cost
+			r.Target, r.TargetNodes, r.TargetHourly = c, n, cost
+		}
+	}
+	return r
+}
+
+// Allocatable estimates the schedulable CPU (millicores) and memory (MiB) of
+// an instance type after kubelet, system, and eviction reservations.
+func Allocatable(p pricing.Price, cni kube.CNIMode) (cpuM, memMiB int64) {
+	return kube.AllocatableFor(p.VCPU, int64(p.MemoryGiB*1024), cni)
+}
+
+// pack returns the number of bins of the given size needed to hold pods using
+// first-fit-decreasing, or 0 if any single pod is larger than a bin. A bin
+// holds at most binPods pods.
+func pack(pods []kube.PodRequest, binCPU, binMem int64, binPods int) int {
+	if binCPU <= 0 || binMem <= 0 || binPods <= 0 {
+		return 0
+	}
+	sorted := make([]kube.PodRequest, len(pods))
+	copy(sorted, pods)
+	// Sort by the pod's dominant share of a bin so awkward pods go first.
+	share := func(p kube.PodRequest) float64 {
+		return max(float64(p.CPUm)/float64(binCPU), float64(p.MemMiB)/float64(binMem))
+	}
+	sort.Slice(sorted, func(i, j int) bool { return share(sorted[i]) > share(sorted[j]) })
+
+	type bin struct {
+		cpu, mem int64
+		pods     int
+	}
+	var bins []bin
+	for _, p := range sorted {
+		if p.CPUm > binCPU || p.MemMiB > binMem {
+			return 0
+		}
+		placed := false
+		for i := range bins {
+			if bins[i].cpu >= p.CPUm && bins[i].mem >= p.MemMiB && bins[i].pods < binPods {
+				bins[i].cpu -= p.CPUm
+				bins[i].mem -= p.MemMiB
+				bins[i].pods++
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			bins = append(bins, bin{binCPU - p.CPUm, binMem - p.MemMiB, 1})
+		}
+	}
+	return len(bins)
+}
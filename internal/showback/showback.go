@@ -0,0 +1,248 @@
+// Package showback attributes the cost of Karpenter-managed nodes to the
+// namespaces (or teams) whose pods run on them.
+//
+// Each node's hourly price is split between its pods in proportion to their
+// requests — half by share of allocatable CPU, half by share of allocatable
+// memory. DaemonSet pods and the capacity nobody requested are reported as
+// their own rows, or spread over the namespaces in proportion to what they
+// were attributed when SpreadOverhead is set.
+package showback
+
+import (
+	"sort"
+
+	"github.com/kemilad/karpx/internal/awscli"
+	"github.com/kemilad/karpx/internal/kube"
+	"github.com/kemilad/karpx/internal/pricing"
+)
+
+// Names of the rows that are not a namespace or team.
+const (
+	DaemonSets = "(daemonsets)"
+	Idle       = "(idle)"
+	Unassigned = "(unassigned)"
+)
+
+// Row is the cost attributed to one namespace or team. Prices are per hour,
+// in the currency of its Report.
+type Row struct {
+	Name       string   `json:"name"`
+	Namespaces []string `json:"namespaces,omitempty"` // when grouped by team
+	Pods       int      `json:"pods"`
+	CPUm       int64    `json:"cpu_millicores"`
+	MemMiB     int64    `json:"memory_mib"`
+	Hourly     float64  `json:"hourly"`
+	Percent    float64  `json:"percent"` // of the total cost
+}
+
+// Monthly is the attributed cost per month.
+func (r Row) Monthly() float64 { return r.Hourly * pricing.HoursPerMonth }
+
+// Report is the showback of one cluster.
+type Report struct {
+	GroupBy  string   `json:"group_by"` // "namespace" or the team label key
+	Nodes    int      `json:"nodes"`    // Karpenter nodes priced
+	Hourly   float64  `json:"hourly"`
+	Currency string   `json:"currency"` // of every price in the report
+	Rows     []Row    `json:"rows"`     // most expensive first; overhead rows last
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// Monthly is the total cost of the Karpenter nodes per month.
+func (r Report) Monthly() float64 { return r.Hourly * pricing.HoursPerMonth }
+
+// Options controls how cost is grouped and how overhead is treated.
+type Options struct {
+	// GroupBy maps a namespace to the row it is charged to; nil groups by
+	// namespace.
+	GroupBy func(namespace string) string
+	// GroupName is recorded in Report.GroupBy.
+	GroupName string
+	// SpreadOverhead charges DaemonSet and idle cost to the rows in
+	// proportion to their direct cost instead of reporting it separately.
+	SpreadOverhead bool
+}
+
+// Attribute splits the hourly price of every Karpenter node between the pods
+// on it. Nodes not launched by Karpenter are ignored; nodes whose instance
+// type has no price count as $0 and are listed in Unpriced.
+func Attribute(nodes []kube.NodeUsage, prices map[string]pricing.Price, o Options) Report {
+	group := o.GroupBy
+	if group == nil {
+		group = func(ns string) string { return ns }
+	}
+	r := Report{GroupBy: o.GroupName}
+	if r.GroupBy == "" {
+		r.GroupBy = "namespace"
+	}
+	for _, p := range prices {
+		if p.Currency != "" {
+			r.Currency = p.Currency
+			break
+		}
+	}
+	rows := map[string]*Row{}
+	namespaces := map[string]map[string]bool{}
+	row := func(name string) *Row {
+		if rows[name] == nil {
+			rows[name] = &Row{Name: name}
+			namespaces[name] = map[string]bool{}
+		}
+		return rows[name]
+	}
+	unpriced := map[string]bool{}
+
+	for _, n := range nodes {
+		if !n.Karpenter {
+			continue
+		}
+		r.Nodes++
+		hourly := 0.0
+		if p, ok := prices[n.InstanceType]; ok {
+			hourly = p.Hourly(n.CapacityType)
+		}
+		if hourly == 0 {
+			unpriced[n.InstanceType] = true
+		}
+		r.Hourly += hourly
+
+		share := func(cpuM, memMiB int64) float64 {
+			var s float64
+			if n.AllocCPUm > 0 {
+				s += float64(cpuM) / float64(n.AllocCPUm) / 2
+			}
+			if n.AllocMemMiB > 0 {
+				s += float64(memMiB) / float64(n.AllocMemMiB) / 2
+			}
+			return s * hourly
+		}
+		// Requests can exceed allocatable by rounding; never charge more
+		// than the node costs.
+		remaining := hourly
+		charge := func(rw *Row, cost float64) {
+			cost = min(cost, remaining)
+			rw.Hourly += cost
+			remaining -= cost
+		}
+		for _, p := range n.Pods {
+			name := group(p.Namespace)
+			rw := row(name)
+			rw.Pods++
+			rw.CPUm += p.CPUm
+			rw.MemMiB += p.MemMiB
+			namespaces[name][p.Namespace] = true
+			charge(rw, share(p.CPUm, p.MemMiB))
+		}
+		ds := row(DaemonSets)
+		ds.Pods += n.DaemonPods
+		ds.CPUm += n.DaemonCPUm
+		ds.MemMiB += n.DaemonMemMiB
+		charge(ds, share(n.DaemonCPUm, n.DaemonMemMiB))
+		row(Idle).Hourly += remaining
+	}
+
+	if o.SpreadOverhead {
+		overhead := rows[DaemonSets].hourly() + rows[Idle].hourly()
+		direct := r.Hourly - overhead
+		delete(rows, DaemonSets)
+		delete(rows, Idle)
+		for _, rw := range rows {
+			if direct > 0 {
+				rw.Hourly += overhead * rw.Hourly / direct
+			}
+		}
+	}
+
+	for name, rw := range rows {
+		if rw.Pods == 0 && rw.Hourly == 0 {
+			continue
+		}
+		if o.GroupBy != nil && name != DaemonSets && name != Idle {
+			for ns := range namespaces[name] {
+				rw.Namespaces = append(rw.Namespaces, ns)
+			}
+			sort.Strings(rw.Namespaces)
+		}
+		if r.Hourly > 0 {
+			rw.Percent = rw.Hourly / r.Hourly * 100
+		}
+		r.Rows = append(r.Rows, *rw)
+	}
+	sort.Slice(r.Rows, func(i, j int) bool {
+		oi, oj := overheadRow(r.Rows[i].Name), overheadRow(r.Rows[j].Name)
+		if oi != oj {
+			return oj
+		}
+		if r.Rows[i].Hourly != r.Rows[j].Hourly {
+			return r.Rows[i].Hourly > r.Rows[j].Hourly
+		}
+		return r.Rows[i].Name < r.Rows[j].Name
+	})
+	for t := range unpriced {
+		if t != "" {
+			r.Unpriced = append(r.Unpriced, t)
+		}
+	}
+	sort.Strings(r.Unpriced)
+	return r
+}
+
+// Load reads node utilisation and prices the Karpenter nodes in region.
+// teamLabel, when set, groups namespaces by that namespace label; namespaces
+// without it are charged to Unassigned.
+func Load(kubeCtx, region, teamLabel string, spreadOverhead bool) (Report, error) {
+	nodes, err := kube.NodeUtilisation(kubeCtx)
+	if err != nil {
+		return Report{}, err
+	}
+	o := Options{SpreadOverhead: spreadOverhead}
+	if teamLabel != "" {
+		teams, err := kube.UsageByTeam(kubeCtx, teamLabel)
+		if err != nil {
+			return Report{}, err
+		}
+		teamOf := map[string]string{}
+		for _, t := range teams {
+			for _, ns := range t.Namespaces {
+				teamOf[ns] = t.Team
+			}
+		}
+		o.GroupName = teamLabel
+		o.GroupBy = func(ns string) string {
+			if t, ok := teamOf[ns]; ok {
+				return t
+			}
+			return Unassigned
+		}
+	}
+	prices, err := pricing.ForTypes(region, instanceTypes(nodes))
+	if err != nil {
+		return Report{}, err
+	}
+	rep := Attribute(nodes, prices, o)
+	rep.Currency = awscli.Currency(region) // also when no node could be priced
+	return rep, nil
+}
+
+// instanceTypes returns the instance types of the Karpenter nodes.
+func instanceTypes(nodes []kube.NodeUsage) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, n := range nodes {
+		if n.Karpenter && n.InstanceType != "" && !seen[n.InstanceType] {
+			seen[n.InstanceType] = true
+			out = append(out, n.InstanceType)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (r *Row) hourly() float64 {
+	if r == nil {
+		return 0
+	}
+	return r.Hourly
+}
+
+func overheadRow(name string) bool { return name == DaemonSets || name == Idle }
@@ -0,0 +1,232 @@
+// Package status inspects kubeconfig contexts for their provider, Kubernetes
+// version, and Karpenter installation. It backs both the web dashboard and
+// `karpx detect --all`, so the two always report the same thing.
+package status
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kemilad/karpx/internal/backend"
+	"github.com/kemilad/karpx/internal/compat"
+	"github.com/kemilad/karpx/internal/config"
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// Cluster is the detection result for one kubeconfig context. It is also the
+// JSON payload returned by the dashboard's /api/clusters endpoint.
+//
+// When the config file pins the context to a minor line, LatestCompatible is
+// the latest release of that line: a newer line existing is expected, not an
+// upgrade. OutOfPolicy is set when the installed version is off the line.
+type Cluster struct {
+	Context            string              `json:"context"`
+	Provider           string              `json:"provider"`
+	DocsURL            string              `json:"docs_url,omitempty"`
+	K8sVersion         string              `json:"k8s_version"`
+	KarpenterInstalled bool                `json:"karpenter_installed"`
+	KarpenterVersion   string              `json:"karpenter_version,omitempty"`
+	KarpenterNamespace string              `json:"karpenter_namespace,omitempty"`
+	KarpenterRelease   string              `json:"karpenter_release,omitempty"`
+	Compatible         *bool               `json:"compatible,omitempty"`
+	UpgradeAvailable   bool                `json:"upgrade_available"`
+	LatestCompatible   string              `json:"latest_compatible,omitempty"`
+	MinCompatible      string              `json:"min_compatible,omitempty"`
+	PinnedLine         string              `json:"pinned_line,omitempty"`
+	OutOfPolicy        bool                `json:"out_of_policy"`
+	Tags               map[string]string   `json:"tags,omitempty"`
+	AddonIssues        []compat.AddonIssue `json:"addon_issues,omitempty"`
+	Managed            string              `json:"managed,omitempty"` // EKS Auto Mode / AKS NAP: read-only
+	Error              string              `json:"error,omitempty"`
+}
+
+// AllContexts returns every context name from the active kubeconfig.
+func AllContexts() []string {
+	return backend.Current().Kube.Contexts()
+}
+
+// Filter returns the contexts whose tags in the config file match sel, in
+// input order. An empty selector keeps every context.
+func Filter(contexts []string, sel config.Selector) []string {
+	if len(sel) == 0 {
+		return contexts
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	var out []string
+	for _, ctx := range contexts {
+		if sel.Matches(cfg.Tags.For(ctx)) {
+			out = append(out, ctx)
+		}
+	}
+	return out
+}
+
+// Check inspects each context concurrently. Results keep the input order.
+func Check(contexts []string) []Cluster {
+	results := make([]Cluster, len(contexts))
+	var wg sync.WaitGroup
+
+	// Limit parallelism to avoid hammering kubeconfig / network.
+	sem := make(chan struct{}, 8)
+
+	for i, ctx := range contexts {
+		wg.Add(1)
+		go func(i int, ctx string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = Inspect(ctx)
+		}(i, ctx)
+	}
+	wg.Wait()
+	return results
+}
+
+// Inspect gathers all status fields for one kubeconfig context.
+func Inspect(ctx string) Cluster {
+	be := backend.Current()
+	// Report the resolved name: "" would leave JSON consumers guessing.
+	s := Cluster{Context: be.Kube.ContextName(ctx)}
+	if cfg, err := config.Load(); err == nil {
+		s.PinnedLine = strings.TrimPrefix(cfg.Policy.Pin(s.Context), "v")
+		s.Tags = cfg.Tags.For(s.Context)
+	}
+
+	// Provider.
+	provider := be.Kube.DetectProvider(ctx)
+	s.Provider = string(provider)
+	s.DocsURL = provider.Meta().DocsURL
+
+	// Kubernetes version (with a short timeout).
+	k8sVer, err := withTimeout(5*time.Second, func() (string, error) {
+		return be.Kube.ServerVersion(ctx)
+	})
+	if err != nil {
+		s.Error = fmt.Sprintf("cluster unreachable: %v", err)
+		return s
+	}
+	s.K8sVersion = k8sVer
+
+	// Karpenter via helm.
+	info, err := be.Helm.DetectKarpenter(ctx)
+	if err != nil {
+		s.Error = fmt.Sprintf("helm error: %v", err)
+		return s
+	}
+	s.KarpenterInstalled = info.Installed
+	if info.Managed != "" {
+		// The provider owns the version; there is nothing to compare or upgrade.
+		s.Managed = info.Managed
+		return s
+	}
+	if info.Installed {
+		s.KarpenterVersion = strings.TrimPrefix(info.Version, "v")
+		s.KarpenterNamespace = info.Namespace
+		s.KarpenterRelease = info.ReleaseName
+		if s.KarpenterRelease == "" {
+			s.KarpenterRelease = "karpenter"
+		}
+		if s.PinnedLine != "" && s.KarpenterVersion != "" {
+			s.OutOfPolicy = !compat.InLine(s.KarpenterVersion, s.PinnedLine)
+		}
+	}
+
+	// Compatibility + upgrade check: AWS from the embedded matrix and
+	// GitHub releases, plugin providers from the source they registered.
+	if src, known := be.Compat.Source(provider); known {
+		// One release listing per cluster; the matrix alone gives the
+		// minimum compatible version.
+		available, matrix, _ := src()
+		s.MinCompatible = matrix.MinKarpenter(k8sVer)
+
+		// Latest compatible version, restricted to the pinned line when
+		// there is one.
+		var latest string
+		all := matrix.FilterCompatible(k8sVer, available)
+		if len(all) > 0 {
+			latest = all[0]
+		}
+		if s.PinnedLine != "" {
+			latest = compat.LatestInLine(all, s.PinnedLine)
+		}
+
+		if info.Installed {
+			installed := strings.TrimPrefix(info.Version, "v")
+			if installed != "" {
+				ok := matrix.IsCompatible(installed, k8sVer)
+				s.Compatible = &ok
+				// A cluster ahead of its pinned line is out of policy, but
+				// moving it back is a downgrade, not an upgrade.
+				if latest != "" && installed != latest && !compat.Newer(installed, latest) {
+					s.UpgradeAvailable = true
+				}
+			} else {
+				// Version unknown (installed outside Helm without a readable image tag).
+				// Always recommend upgrading — we cannot determine if it's current.
+				s.UpgradeAvailable = true
+			}
+			if latest != "" {
+				s.LatestCompatible = latest
+			}
+		} else {
+			// Not installed — surface the latest compatible version for the
+			// install button in the dashboard.
+			s.LatestCompatible = latest
+		}
+	}
+
+	if provider == kube.ProviderAWS {
+		if _, issues, err := CheckAddons(ctx, k8sVer, s.KarpenterVersion); err == nil {
+			s.AddonIssues = issues
+		}
+	}
+
+	return s
+}
+
+// CheckAddons reads the cluster's core add-ons and checks them against
+// Kubernetes k8sVer and Karpenter karpVer ("" to skip the Karpenter checks).
+func CheckAddons(ctx, k8sVer, karpVer string) (*kube.CoreAddons, []compat.AddonIssue, error) {
+	a, err := backend.Current().Kube.CoreAddons(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, compat.CheckAddons(AddonVersions(a), k8sVer, karpVer), nil
+}
+
+// AddonVersions converts detected add-ons to the input of compat.CheckAddons.
+func AddonVersions(a *kube.CoreAddons) compat.Addons {
+	return compat.Addons{
+		VPCCNI:           a.VPCCNI,
+		CoreDNS:          a.CoreDNS,
+		KubeProxy:        a.KubeProxy,
+		PrefixDelegation: a.CNI == kube.CNIPrefix,
+		IPv6:             a.CNI == kube.CNIIPv6,
+		InstanceFamilies: a.InstanceFamilies,
+	}
+}
+
+// withTimeout runs fn in a goroutine and returns its result or an error if
+// the deadline is exceeded.
+func withTimeout(d time.Duration, fn func() (string, error)) (string, error) {
+	type res struct {
+		v   string
+		err error
+	}
+	ch := make(chan res, 1)
+	go func() {
+		v, err := fn()
+		ch <- res{v, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.v, r.err
+	case <-time.After(d):
+		return "", fmt.Errorf("timeout after %s", d)
+	}
+}
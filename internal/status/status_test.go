@@ -0,0 +1,128 @@
+package status_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kemilad/karpx/internal/backend"
+	"github.com/kemilad/karpx/internal/demo"
+	"github.com/kemilad/karpx/internal/helm"
+	"github.com/kemilad/karpx/internal/status"
+)
+
+// useDemo switches to the demo clusters, with no config file, for one test.
+func useDemo(t *testing.T) {
+	t.Helper()
+	t.Setenv("KARPX_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	backend.Use(demo.Clients())
+	t.Cleanup(func() { backend.Use(backend.Live()) })
+}
+
+func TestCheckDemo(t *testing.T) {
+	useDemo(t)
+	contexts := status.AllContexts()
+	if len(contexts) != len(demo.Clusters) {
+		t.Fatalf("AllContexts returned %d contexts, want %d", len(contexts), len(demo.Clusters))
+	}
+	results := status.Check(contexts)
+	for i, r := range results {
+		if r.Context != contexts[i] {
+			t.Fatalf("result %d is %s, want %s: Check must keep the input order", i, r.Context, contexts[i])
+		}
+	}
+	byName := map[string]status.Cluster{}
+	for _, r := range results {
+		byName[r.Context[strings.LastIndex(r.Context, "/")+1:]] = r
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name        string
+		provider    string
+		installed   bool
+		version     string
+		compatible  *bool
+		upgrade     bool
+		latest      string
+		minCompat   string
+		managed     string
+		addonIssues bool
+	}{
+		{name: "prod-payments", provider: "aws", installed: true, version: "1.2.1", compatible: &yes,
+			upgrade: true, latest: "1.5.0", minCompat: "1.0.0"},
+		{name: "staging", provider: "aws", installed: true, version: "1.5.0", compatible: &yes,
+			latest: "1.5.0", minCompat: "1.2.0"},
+		{name: "legacy-batch", provider: "aws", installed: true, version: "0.36.2", compatible: &no,
+			upgrade: true, latest: "1.5.0", minCompat: "0.37.0", addonIssues: true},
+		{name: "analytics", provider: "aws", latest: "1.5.0", minCompat: "1.2.0"},
+		{name: "sandbox-auto", provider: "aws", installed: true, managed: helm.ManagedEKSAutoMode},
+		{name: "aks-data-platform", provider: "azure", installed: true, version: "1.4.0", compatible: &yes,
+			upgrade: true, latest: "1.5.0", minCompat: "1.0.0"},
+		{name: "gke-ml-training", provider: "gcp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := byName[tt.name]
+			if !ok {
+				t.Fatalf("not checked")
+			}
+			if r.Error != "" {
+				t.Fatalf("unexpected error: %s", r.Error)
+			}
+			if r.Provider != tt.provider {
+				t.Errorf("provider = %q, want %q", r.Provider, tt.provider)
+			}
+			if r.KarpenterInstalled != tt.installed || r.KarpenterVersion != tt.version {
+				t.Errorf("installed = %t v%q, want %t v%q", r.KarpenterInstalled, r.KarpenterVersion, tt.installed, tt.version)
+			}
+			if (r.Compatible == nil) != (tt.compatible == nil) || r.Compatible != nil && *r.Compatible != *tt.compatible {
+				t.Errorf("compatible = %v, want %v", fmtBool(r.Compatible), fmtBool(tt.compatible))
+			}
+			if r.UpgradeAvailable != tt.upgrade || r.LatestCompatible != tt.latest {
+				t.Errorf("upgrade = %t to %q, want %t to %q", r.UpgradeAvailable, r.LatestCompatible, tt.upgrade, tt.latest)
+			}
+			if r.MinCompatible != tt.minCompat {
+				t.Errorf("min compatible = %q, want %q", r.MinCompatible, tt.minCompat)
+			}
+			if r.Managed != tt.managed {
+				t.Errorf("managed = %q, want %q", r.Managed, tt.managed)
+			}
+			if got := len(r.AddonIssues) > 0; got != tt.addonIssues {
+				t.Errorf("add-on issues = %v, want any: %t", r.AddonIssues, tt.addonIssues)
+			}
+		})
+	}
+
+	if r := byName["kind-local"]; !strings.HasPrefix(r.Error, "cluster unreachable") {
+		t.Errorf("kind-local: error %q, want the cluster unreachable", r.Error)
+	}
+}
+
+func TestInspectDemoCurrentContext(t *testing.T) {
+	useDemo(t)
+	r := status.Inspect("")
+	if r.Context != demo.Clusters[0].Context {
+		t.Errorf("Inspect(\"\") reports context %q, want the current context %q", r.Context, demo.Clusters[0].Context)
+	}
+	if r.Error != "" || !r.KarpenterInstalled {
+		t.Errorf("current context: %+v", r)
+	}
+}
+
+func TestInspectDemoUnknownContext(t *testing.T) {
+	useDemo(t)
+	if r := status.Inspect("no-such-context"); r.Error == "" {
+		t.Errorf("Inspect of an unknown context succeeded: %+v", r)
+	}
+}
+
+func fmtBool(b *bool) string {
+	if b == nil {
+		return "nil"
+	}
+	if *b {
+		return "true"
+	}
+	return "false"
+}
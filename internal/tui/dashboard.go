@@ -2,15 +2,16 @@ package tui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/kemilad/karpx/internal/backend"
 	"github.com/kemilad/karpx/internal/compat"
-	"github.com/kemilad/karpx/internal/helm"
+	"github.com/kemilad/karpx/internal/config"
 	"github.com/kemilad/karpx/internal/kube"
 )
 
@@ -38,6 +39,8 @@ type ClusterEntry struct {
 	LatestVersion    string // latest compatible Karpenter version from GitHub
 	UpgradeNeeded    bool   // true if installed version is incompatible OR newer exists
 	Incompatible     bool   // true specifically when installed version is not compatible
+	Managed          string // EKS Auto Mode / AKS NAP: read-only, no install or upgrade
+	Tags             map[string]string // from the karpx config file
 	Error            string
 }
 
@@ -45,10 +48,19 @@ type ClusterEntry struct {
 // Model
 // ─────────────────────────────────────────────────────────────────────────────
 
+// The tag filter ("/") narrows the table to clusters whose config-file tags
+// match a selector such as "team=payments,environment!=dev"; cursor indexes
+// the filtered rows.
 type DashboardModel struct {
 	clusters []ClusterEntry
 	cursor   int
 	loading  bool
+
+	filter      config.Selector
+	filtering   bool   // the filter prompt has focus
+	filterInput string // text typed at the prompt
+	filterErr   string
+
 	kubeCtx  string
 	region   string
 	width    int
@@ -87,15 +99,23 @@ func (m *DashboardModel) Update(msg tea.Msg) (*DashboardModel, tea.Cmd) {
 		}
 
 	case tea.KeyMsg:
+		if m.filtering {
+			m.updateFilter(msg)
+			return m, nil
+		}
 		switch msg.String() {
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
 			}
 		case "down", "j":
-			if m.cursor < len(m.clusters)-1 {
+			if m.cursor < len(m.visible())-1 {
 				m.cursor++
 			}
+		case "/":
+			m.filtering = true
+			m.filterInput = m.filter.String()
+			m.filterErr = ""
 		case "i":
 			return m, m.navInstall()
 		case "u":
@@ -104,6 +124,8 @@ func (m *DashboardModel) Update(msg tea.Msg) (*DashboardModel, tea.Cmd) {
 			return m, m.navNodePools()
 		case "a":
 			return m, m.navAddons()
+		case "s":
+			return m, m.navInterruptions()
 		case "r":
 			m.loading = true
 			return m, loadClusters(m.kubeCtx)
@@ -115,10 +137,18 @@ func (m *DashboardModel) Update(msg tea.Msg) (*DashboardModel, tea.Cmd) {
 func (m *DashboardModel) View() string {
 	var b strings.Builder
 
+	tagline := "Kubernetes Essentials"
+	if backend.Current().Demo {
+		tagline = "DEMO · fake clusters"
+	}
+	// Pad between the name and the tagline so the header fills one line
+	// inside its horizontal padding instead of wrapping.
+	brand := "  ⚡ karpx"
+	gap := m.width - StyleHeader.GetHorizontalFrameSize() - lipgloss.Width(brand) - lipgloss.Width(tagline)
 	header := StyleHeader.Width(m.width).Render(
-		"  ⚡ karpx" +
-			strings.Repeat(" ", max(0, m.width-30)) +
-			"Kubernetes Essentials",
+		brand +
+			strings.Repeat(" ", max(0, gap)) +
+			tagline,
 	)
 	b.WriteString(header + "\n\n")
 
@@ -135,7 +165,20 @@ func (m *DashboardModel) View() string {
 		return b.String()
 	}
 
-	b.WriteString(SectionTitle(fmt.Sprintf("Clusters (%d)", len(m.clusters))) + "\n\n")
+	rows := m.visible()
+	title := fmt.Sprintf("Clusters (%d)", len(m.clusters))
+	if len(m.filter) > 0 {
+		title = fmt.Sprintf("Clusters (%d of %d · tag %s)", len(rows), len(m.clusters), m.filter)
+	}
+	b.WriteString(SectionTitle(title) + "\n")
+	switch {
+	case m.filtering:
+		b.WriteString("  " + StyleAccent.Render("filter by tag: ") + StyleNormal.Render(m.filterInput+"█") +
+			StyleMuted.Render("   key=value, key!=value or key · enter apply · esc cancel") + "\n")
+	case m.filterErr != "":
+		b.WriteString(StyleDanger.Render("  ✗ "+m.filterErr) + "\n")
+	}
+	b.WriteString("\n")
 
 	colCluster := 32
 	colK8s     := 8
@@ -152,8 +195,11 @@ func (m *DashboardModel) View() string {
 	b.WriteString(headerRow + "\n")
 	b.WriteString(StyleMuted.Render("  "+strings.Repeat("─", min(m.width-4, 90))) + "\n")
 
-	for i, c := range m.clusters {
-		b.WriteString(m.renderRow(c, i == m.cursor, colCluster, colK8s, colVer, colLatest) + "\n")
+	for i, idx := range rows {
+		b.WriteString(m.renderRow(m.clusters[idx], i == m.cursor, colCluster, colK8s, colVer, colLatest) + "\n")
+	}
+	if len(rows) == 0 {
+		b.WriteString(StyleMuted.Render("  No cluster matches the tag filter — press / to change it.") + "\n")
 	}
 
 	if sel := m.selected(); sel != nil {
@@ -207,6 +253,8 @@ func (m *DashboardModel) statusBadge(c ClusterEntry) string {
 		return BadgeError()
 	case !c.Installed:
 		return BadgeNotInstalled()
+	case c.Managed != "":
+		return BadgeManaged()
 	case c.Incompatible:
 		return BadgeIncompatible(c.LatestVersion)
 	case c.UpgradeNeeded:
@@ -241,6 +289,22 @@ func (m *DashboardModel) renderDetail(c *ClusterEntry) string {
 		StyleAccent.Render("  provider   ") + providerLine + "\n" +
 		StyleAccent.Render("  k8s        ") + StyleNormal.Render(dash(c.K8sVersion)) + "\n" +
 		StyleAccent.Render("  karpenter  ") + StyleNormal.Render(dash(c.ChartVersion))
+	if c.Managed != "" {
+		lines += "\n" + StyleMuted.Render("  ℹ  managed by "+c.Managed+" — read-only; NodePools stay viewable")
+	}
+
+	if len(c.Tags) > 0 {
+		keys := make([]string, 0, len(c.Tags))
+		for k := range c.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		tags := make([]string, len(keys))
+		for i, k := range keys {
+			tags[i] = k + "=" + c.Tags[k]
+		}
+		lines += "\n" + StyleAccent.Render("  tags       ") + StyleNormal.Render(strings.Join(tags, "  "))
+	}
 
 	if c.Provider == kube.ProviderUnknown {
 		lines += "\n" + StyleMuted.Render("  ℹ  run `karpx install` for provider options and guidance")
@@ -260,20 +324,24 @@ func (m *DashboardModel) renderDetail(c *ClusterEntry) string {
 }
 
 func (m *DashboardModel) renderHints() string {
-	hints := []string{Key("↑↓", "move"), Key("r", "refresh")}
+	hints := []string{Key("↑↓", "move"), Key("r", "refresh"), Key("/", "filter")}
 	if sel := m.selected(); sel != nil {
-		if !sel.Installed {
+		// Managed clusters get neither: the cloud provider installs and upgrades.
+		switch {
+		case sel.Managed != "":
+		case !sel.Installed:
 			hints = append(hints, KeyActive("i", "install"))
-		} else {
-			if sel.UpgradeNeeded || sel.Incompatible {
-				hints = append(hints, KeyActive("u", "upgrade"))
-			}
+		case sel.UpgradeNeeded || sel.Incompatible:
+			hints = append(hints, KeyActive("u", "upgrade"))
 		}
 		// Always show nodepools and add-ons — useful even when detection missed a pre-installed Karpenter.
 		if !sel.Checking {
 			hints = append(hints, Key("n", "nodepools"))
 			hints = append(hints, Key("a", "add-ons"))
 		}
+		if sel.Installed {
+			hints = append(hints, Key("s", "interruptions"))
+		}
 	}
 	hints = append(hints, Key("q", "quit"))
 	return "  " + strings.Join(hints, "  ") + "\n"
@@ -284,15 +352,50 @@ func (m *DashboardModel) renderHints() string {
 // ─────────────────────────────────────────────────────────────────────────────
 
 func (m *DashboardModel) selected() *ClusterEntry {
-	if len(m.clusters) == 0 || m.cursor >= len(m.clusters) {
+	rows := m.visible()
+	if len(rows) == 0 || m.cursor >= len(rows) {
 		return nil
 	}
-	return &m.clusters[m.cursor]
+	return &m.clusters[rows[m.cursor]]
+}
+
+// visible returns the indexes of the clusters matching the tag filter.
+func (m *DashboardModel) visible() []int {
+	rows := make([]int, 0, len(m.clusters))
+	for i, c := range m.clusters {
+		if m.filter.Matches(c.Tags) {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// updateFilter handles a key while the filter prompt has focus.
+func (m *DashboardModel) updateFilter(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		sel, err := config.ParseSelector([]string{m.filterInput})
+		if err != nil {
+			m.filterErr = err.Error()
+		} else {
+			m.filter, m.filterErr = sel, ""
+			m.cursor = 0
+		}
+		m.filtering = false
+	case tea.KeyEsc:
+		m.filtering = false
+	case tea.KeyBackspace:
+		if r := []rune(m.filterInput); len(r) > 0 {
+			m.filterInput = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.filterInput += string(msg.Runes)
+	}
 }
 
 func (m *DashboardModel) navInstall() tea.Cmd {
 	s := m.selected()
-	if s == nil {
+	if s == nil || s.Managed != "" {
 		return nil
 	}
 	return func() tea.Msg {
@@ -302,7 +405,7 @@ func (m *DashboardModel) navInstall() tea.Cmd {
 
 func (m *DashboardModel) navUpgrade() tea.Cmd {
 	s := m.selected()
-	if s == nil || !s.Installed {
+	if s == nil || !s.Installed || s.Managed != "" {
 		return nil
 	}
 	return func() tea.Msg {
@@ -335,19 +438,28 @@ func (m *DashboardModel) navAddons() tea.Cmd {
 	}
 }
 
+func (m *DashboardModel) navInterruptions() tea.Cmd {
+	s := m.selected()
+	if s == nil || !s.Installed {
+		return nil
+	}
+	return func() tea.Msg {
+		return NavigateMsg{Target: NavInterruptions, KubeContext: s.Context, Region: m.region}
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Async commands
 // ─────────────────────────────────────────────────────────────────────────────
 
 func loadClusters(preferCtx string) tea.Cmd {
 	return func() tea.Msg {
-		rules := clientcmd.NewDefaultClientConfigLoadingRules()
-		cfg, err := rules.Load()
-		if err != nil {
-			return clustersLoadedMsg{}
+		tags := config.Tags{}
+		if kc, err := config.Load(); err == nil {
+			tags = kc.Tags
 		}
 		var entries []ClusterEntry
-		for name := range cfg.Contexts {
+		for _, name := range backend.Current().Kube.Contexts() {
 			if preferCtx != "" && name != preferCtx {
 				continue
 			}
@@ -355,6 +467,7 @@ func loadClusters(preferCtx string) tea.Cmd {
 				Name:     name,
 				Context:  name,
 				Checking: true,
+				Tags:     tags.For(name),
 			})
 		}
 		return clustersLoadedMsg(entries)
@@ -370,23 +483,29 @@ func loadClusters(preferCtx string) tea.Cmd {
 func checkCluster(c ClusterEntry) tea.Cmd {
 	return func() tea.Msg {
 		c.Checking = false
+		be := backend.Current()
 
 		// ── Step 1: detect cloud provider ──────────────────────────────────
-		c.Provider = kube.DetectProvider(c.Context)
+		c.Provider = be.Kube.DetectProvider(c.Context)
 
 		// ── Step 2: detect Karpenter via helm ──────────────────────────────
-		info, err := helm.DetectKarpenter(c.Context)
+		info, err := be.Helm.DetectKarpenter(c.Context)
 		if err != nil {
 			c.Error = err.Error()
 			return clusterCheckedMsg(c)
 		}
 		c.Installed = info.Installed
+		c.Managed = info.Managed
 		if info.Installed {
 			c.ChartVersion = info.Version
 		}
+		if c.Managed != "" {
+			c.K8sVersion, _ = be.Kube.ServerVersion(c.Context)
+			return clusterCheckedMsg(c)
+		}
 
 		// ── Step 3: get cluster Kubernetes version ──────────────────────────
-		k8sVer, err := kube.GetServerVersion(c.Context)
+		k8sVer, err := be.Kube.ServerVersion(c.Context)
 		if err != nil {
 			c.Error = "k8s version: " + err.Error()
 			return clusterCheckedMsg(c)
@@ -408,8 +527,13 @@ func checkCluster(c ClusterEntry) tea.Cmd {
 
 		// ── Step 5: fetch latest compatible version from GitHub ─────────────
 		if c.Provider == kube.ProviderAWS {
-			latest, _, err := compat.LatestCompatible(k8sVer)
-			if err == nil && latest != "" {
+			var latest string
+			src, _ := be.Compat.Source(c.Provider)
+			available, matrix, err := src()
+			if all := matrix.FilterCompatible(k8sVer, available); err == nil && len(all) > 0 {
+				latest = all[0]
+			}
+			if latest != "" {
 				c.LatestVersion = latest
 				if !c.UpgradeNeeded && c.Installed && c.ChartVersion != "" {
 					iv, e1 := parseVer(c.ChartVersion)
@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kemilad/karpx/internal/backend"
+	"github.com/kemilad/karpx/internal/demo"
+	"github.com/kemilad/karpx/internal/helm"
+	"github.com/kemilad/karpx/internal/kube"
+)
+
+// useDemo switches to the demo clusters, with no config file, for one test.
+func useDemo(t *testing.T) {
+	t.Helper()
+	t.Setenv("KARPX_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	backend.Use(demo.Clients())
+	t.Cleanup(func() { backend.Use(backend.Live()) })
+}
+
+// drain runs cmd and every command it leads to through m, as the BubbleTea
+// runtime would, and returns the settled model.
+func drain(m tea.Model, cmd tea.Cmd) tea.Model {
+	for cmd != nil {
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, c := range batch {
+				m = drain(m, c)
+			}
+			return m
+		}
+		m, cmd = m.Update(msg)
+	}
+	return m
+}
+
+// loaded returns a root model whose dashboard has checked every cluster.
+func loaded(t *testing.T, kubeCtx string) *Model {
+	t.Helper()
+	m := NewModel(Config{KubeContext: kubeCtx})
+	m.Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	return drain(m, m.Init()).(*Model)
+}
+
+func TestDashboardDemoClusters(t *testing.T) {
+	useDemo(t)
+	m := loaded(t, "")
+
+	if got, want := len(m.dashboard.clusters), len(demo.Clusters); got != want {
+		t.Fatalf("dashboard lists %d clusters, want %d", got, want)
+	}
+	byName := map[string]ClusterEntry{}
+	for _, c := range m.dashboard.clusters {
+		if c.Checking {
+			t.Errorf("%s: still checking", c.Context)
+		}
+		byName[c.Context[strings.LastIndex(c.Context, "/")+1:]] = c
+	}
+
+	tests := []struct {
+		name          string
+		provider      kube.Provider
+		k8s           string
+		chart         string
+		latest        string
+		upgradeNeeded bool
+		incompatible  bool
+		managed       string
+	}{
+		{name: "prod-payments", provider: kube.ProviderAWS, k8s: "1.31.4", chart: "1.2.1", latest: "1.5.0", upgradeNeeded: true},
+		{name: "staging", provider: kube.ProviderAWS, k8s: "1.32.1", chart: "1.5.0", latest: "1.5.0"},
+		{name: "legacy-batch", provider: kube.ProviderAWS, k8s: "1.30.6", chart: "0.36.2", latest: "1.5.0", upgradeNeeded: true, incompatible: true},
+		{name: "analytics", provider: kube.ProviderAWS, k8s: "1.32.0", latest: "1.5.0"},
+		{name: "sandbox-auto", provider: kube.ProviderAWS, k8s: "1.33.1", managed: helm.ManagedEKSAutoMode},
+		{name: "aks-data-platform", provider: kube.ProviderAzure, k8s: "1.31.2", chart: "1.4.0"},
+		{name: "gke-ml-training", provider: kube.ProviderGCP, k8s: "1.30.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := byName[tt.name]
+			if !ok {
+				t.Fatalf("not on the dashboard")
+			}
+			if c.Error != "" {
+				t.Fatalf("unexpected error: %s", c.Error)
+			}
+			if c.Provider != tt.provider || c.K8sVersion != tt.k8s || c.ChartVersion != tt.chart ||
+				c.LatestVersion != tt.latest || c.UpgradeNeeded != tt.upgradeNeeded ||
+				c.Incompatible != tt.incompatible || c.Managed != tt.managed {
+				t.Errorf("got %+v", c)
+			}
+		})
+	}
+
+	if c := byName["kind-local"]; !strings.Contains(c.Error, "connection refused") {
+		t.Errorf("kind-local: error %q, want the cluster unreachable", c.Error)
+	}
+
+	view := m.View()
+	for _, want := range []string{"DEMO · fake clusters", "Clusters (8)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("dashboard view is missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestDashboardDemoPreferredContext(t *testing.T) {
+	useDemo(t)
+	m := loaded(t, "aks-data-platform")
+
+	if len(m.dashboard.clusters) != 1 || m.dashboard.clusters[0].Context != "aks-data-platform" {
+		t.Fatalf("dashboard lists %+v, want only aks-data-platform", m.dashboard.clusters)
+	}
+}
+
+func TestDemoIsReadOnly(t *testing.T) {
+	useDemo(t)
+	m := loaded(t, "")
+
+	for _, target := range []NavTarget{NavInstall, NavUpgrade, NavAddonsInstall, NavAddonsUninstall} {
+		if _, cmd := m.Update(NavigateMsg{Target: target, KubeContext: demo.Clusters[0].Context}); cmd != nil {
+			t.Errorf("navigation %v returned a command in demo mode", target)
+		}
+		if m.current != viewDashboard {
+			t.Errorf("navigation %v left the dashboard", target)
+		}
+	}
+}
+
+func TestDemoNodePools(t *testing.T) {
+	useDemo(t)
+	m := loaded(t, "")
+
+	// The first cluster is selected; n opens its NodePools.
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = drain(m, cmd).(*Model)
+	if m.current != viewNodePools {
+		t.Fatalf("n did not open the NodePools view")
+	}
+	if got := len(m.nodepools.nodePools); got != len(demo.Clusters[0].NodePools) {
+		t.Errorf("NodePools view lists %d NodePools, want %d", got, len(demo.Clusters[0].NodePools))
+	}
+	if got := len(m.nodepools.nodeClasses); got != len(demo.Clusters[0].NodeClasses) {
+		t.Errorf("NodePools view lists %d NodeClasses, want %d", got, len(demo.Clusters[0].NodeClasses))
+	}
+	if view := m.View(); !strings.Contains(view, "spot-batch") {
+		t.Errorf("NodePools view does not show spot-batch")
+	}
+}
@@ -0,0 +1,322 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kemilad/karpx/internal/backend"
+)
+
+// The feed is built from the Kubernetes events Karpenter publishes on the
+// Node when its interruption controller handles a message. Reading the SQS
+// queue directly is not an option: receiving a message hides it from the
+// controller for the visibility timeout, delaying the very drain we watch.
+
+// interruptionReasons are the event reasons Karpenter's interruption
+// controller records, mapped to a short label for the feed.
+var interruptionReasons = map[string]string{
+	"SpotInterrupted":             "SPOT INTERRUPTION",
+	"SpotRebalanceRecommendation": "REBALANCE",
+	"InstanceStopping":            "STOPPING",
+	"InstanceTerminating":         "TERMINATING",
+	"InstanceScheduledChange":     "SCHEDULED CHANGE",
+	"InstanceUnhealthy":           "UNHEALTHY",
+}
+
+// interruptionPoll is how often the feed re-reads events.
+const interruptionPoll = 3 * time.Second
+
+// maxInterruptions bounds the feed so a long storm does not grow it forever.
+const maxInterruptions = 200
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Data types
+// ─────────────────────────────────────────────────────────────────────────────
+
+// InterruptionEntry is one interruption or rebalance notice for a node.
+type InterruptionEntry struct {
+	UID     string
+	Time    time.Time
+	Reason  string
+	Node    string
+	Message string
+	Pods    []string // namespace/name of pods on the node when first seen
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Messages
+// ─────────────────────────────────────────────────────────────────────────────
+
+type interruptionsLoadedMsg struct {
+	gen     int
+	entries []InterruptionEntry
+	err     string
+}
+
+type interruptionTickMsg struct{ gen int }
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Model
+// ─────────────────────────────────────────────────────────────────────────────
+
+// InterruptionsModel tails interruption events for one cluster. gen tags the
+// poll loop so ticks from a previous visit to the screen are ignored.
+type InterruptionsModel struct {
+	kubeCtx string
+	gen     int
+	entries []InterruptionEntry
+	seen    map[string]bool
+	pods    map[string][]string // node → pods, looked up once per node
+	loading bool
+	err     string
+	updated time.Time
+	width   int
+	height  int
+}
+
+var interruptionGen int
+
+func NewInterruptionsModel(kubeCtx string) *InterruptionsModel {
+	interruptionGen++
+	return &InterruptionsModel{
+		kubeCtx: kubeCtx,
+		gen:     interruptionGen,
+		seen:    map[string]bool{},
+		pods:    map[string][]string{},
+		loading: true,
+	}
+}
+
+func (m *InterruptionsModel) Init() tea.Cmd {
+	return fetchInterruptions(m.kubeCtx, m.gen, m.knownNodes())
+}
+
+func (m *InterruptionsModel) Update(msg tea.Msg) (*InterruptionsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case interruptionsLoadedMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		m.updated = time.Now()
+		for _, e := range msg.entries {
+			if m.seen[e.UID] {
+				continue
+			}
+			m.seen[e.UID] = true
+			if e.Pods != nil {
+				m.pods[e.Node] = e.Pods
+			}
+			e.Pods = m.pods[e.Node]
+			m.entries = append(m.entries, e)
+		}
+		sort.SliceStable(m.entries, func(i, j int) bool { return m.entries[i].Time.After(m.entries[j].Time) })
+		if len(m.entries) > maxInterruptions {
+			m.entries = m.entries[:maxInterruptions]
+		}
+		return m, tea.Tick(interruptionPoll, func(time.Time) tea.Msg { return interruptionTickMsg{m.gen} })
+
+	case interruptionTickMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		return m, fetchInterruptions(m.kubeCtx, m.gen, m.knownNodes())
+	}
+	return m, nil
+}
+
+func (m *InterruptionsModel) View() string {
+	var b strings.Builder
+
+	rightSide := "Interruptions"
+	if m.kubeCtx != "" {
+		clusterName := m.kubeCtx
+		if idx := strings.LastIndex(clusterName, "/"); idx >= 0 && strings.Contains(clusterName, ":cluster/") {
+			clusterName = clusterName[idx+1:]
+		}
+		rightSide = clusterName + "  |  Interruptions"
+	}
+	headerText := "  ⚡ karpx" + strings.Repeat(" ", max(0, m.width-len(rightSide)-10)) + rightSide
+	header := StyleHeader.Width(max(1, m.width)).Render(headerText)
+	b.WriteString(header + "\n\n")
+
+	if m.loading {
+		b.WriteString(StyleMuted.Render("  Reading interruption events from cluster…") + "\n")
+		b.WriteString("\n  " + Key("esc", "back") + "  " + Key("q", "quit") + "\n")
+		return b.String()
+	}
+
+	if m.err != "" {
+		b.WriteString(StyleDanger.Render("  ✗ "+m.err) + "\n\n")
+	}
+
+	// ── Counters ───────────────────────────────────────────────────────────
+	counts := map[string]int{}
+	nodes := map[string]bool{}
+	for _, e := range m.entries {
+		counts[e.Reason]++
+		nodes[e.Node] = true
+	}
+	b.WriteString(SectionTitle(fmt.Sprintf("Interruption feed (%d notices, %d nodes)", len(m.entries), len(nodes))) + "\n\n")
+	var summary []string
+	for _, reason := range []string{"SpotInterrupted", "SpotRebalanceRecommendation", "InstanceStopping", "InstanceTerminating", "InstanceScheduledChange", "InstanceUnhealthy"} {
+		if counts[reason] > 0 {
+			summary = append(summary, fmt.Sprintf("%s %d", interruptionReasons[reason], counts[reason]))
+		}
+	}
+	if len(summary) > 0 {
+		b.WriteString("  " + StyleWarning.Render(strings.Join(summary, "  ·  ")) + "\n\n")
+	}
+
+	if len(m.entries) == 0 {
+		b.WriteString(StyleMuted.Render("  No interruption or rebalance notices in the cluster's event history.") + "\n")
+		b.WriteString(StyleMuted.Render("  New notices appear here as Karpenter handles them.") + "\n")
+	}
+
+	// ── Feed ───────────────────────────────────────────────────────────────
+	limit := len(m.entries)
+	if m.height > 0 {
+		// Header, counters and hints take about 10 lines; each entry takes 2.
+		limit = min(limit, max(1, (m.height-10)/2))
+	}
+	for _, e := range m.entries[:limit] {
+		label := interruptionReasons[e.Reason]
+		style := StyleWarning
+		if e.Reason == "SpotInterrupted" || e.Reason == "InstanceTerminating" {
+			style = StyleDanger
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s  %s\n",
+			StyleMuted.Render(e.Time.Local().Format("15:04:05")),
+			style.Render(fmt.Sprintf("%-17s", label)),
+			StyleNormal.Render(e.Node)))
+		pods := StyleMuted.Render("no pods recorded")
+		if len(e.Pods) > 0 {
+			shown := e.Pods
+			if len(shown) > 4 {
+				shown = shown[:4]
+			}
+			text := strings.Join(shown, ", ")
+			if len(e.Pods) > len(shown) {
+				text += fmt.Sprintf(", … (%d more)", len(e.Pods)-len(shown))
+			}
+			pods = StyleNormal.Render(fmt.Sprintf("%d pod(s): ", len(e.Pods))) + StyleMuted.Render(text)
+		}
+		b.WriteString("            " + pods + "\n")
+	}
+	if limit < len(m.entries) {
+		b.WriteString(StyleMuted.Render(fmt.Sprintf("  … %d older notice(s)", len(m.entries)-limit)) + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(StyleMuted.Render(fmt.Sprintf("  live · every %s · updated %s", interruptionPoll, m.updated.Format("15:04:05"))) + "\n")
+	b.WriteString("  " + Key("esc", "back") + "  " + Key("q", "quit") + "\n")
+	return b.String()
+}
+
+// knownNodes returns the nodes whose pods were already looked up.
+func (m *InterruptionsModel) knownNodes() map[string]bool {
+	out := make(map[string]bool, len(m.pods))
+	for n := range m.pods {
+		out[n] = true
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Async fetch command
+// ─────────────────────────────────────────────────────────────────────────────
+
+// fetchInterruptions reads Node events and keeps the interruption ones. Pods
+// are looked up only for nodes not in known — once a node is drained its pods
+// are gone, so the first lookup is the one worth keeping.
+func fetchInterruptions(kubeCtx string, gen int, known map[string]bool) tea.Cmd {
+	return func() tea.Msg {
+		msg := interruptionsLoadedMsg{gen: gen}
+
+		args := []string{"get", "events", "-A", "--field-selector", "involvedObject.kind=Node", "-o", "json"}
+		out, err := backend.Current().Kube.Kubectl(context.TODO(), kubeCtx, args...)
+		if err != nil {
+			msg.err = "could not read events: " + err.Error()
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				msg.err = strings.TrimSpace(string(exitErr.Stderr))
+			}
+			return msg
+		}
+
+		var list struct {
+			Items []struct {
+				Metadata struct {
+					UID string `json:"uid"`
+				} `json:"metadata"`
+				Reason         string    `json:"reason"`
+				Message        string    `json:"message"`
+				LastTimestamp  time.Time `json:"lastTimestamp"`
+				EventTime      time.Time `json:"eventTime"`
+				InvolvedObject struct {
+					Name string `json:"name"`
+				} `json:"involvedObject"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(out, &list); err != nil {
+			msg.err = "could not parse events: " + err.Error()
+			return msg
+		}
+
+		for _, ev := range list.Items {
+			if _, ok := interruptionReasons[ev.Reason]; !ok {
+				continue
+			}
+			t := ev.LastTimestamp
+			if t.IsZero() {
+				t = ev.EventTime
+			}
+			e := InterruptionEntry{
+				UID:     ev.Metadata.UID,
+				Time:    t,
+				Reason:  ev.Reason,
+				Node:    ev.InvolvedObject.Name,
+				Message: ev.Message,
+			}
+			if !known[e.Node] {
+				e.Pods = podsOnNode(kubeCtx, e.Node)
+				known[e.Node] = true
+			}
+			msg.entries = append(msg.entries, e)
+		}
+		return msg
+	}
+}
+
+// podsOnNode lists the non-terminated pods scheduled to node as namespace/name.
+// It returns an empty (non-nil) slice when there are none, so the caller can
+// tell "looked up, nothing there" from "not looked up".
+func podsOnNode(kubeCtx, node string) []string {
+	args := []string{"get", "pods", "-A",
+		"--field-selector", "spec.nodeName=" + node + ",status.phase!=Succeeded,status.phase!=Failed",
+		"-o", `jsonpath={range .items[*]}{.metadata.namespace}/{.metadata.name}{"\n"}{end}`}
+	pods := []string{}
+	out, err := backend.Current().Kube.Kubectl(context.TODO(), kubeCtx, args...)
+	if err != nil {
+		return pods
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			pods = append(pods, line)
+		}
+	}
+	return pods
+}
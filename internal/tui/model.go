@@ -6,6 +6,8 @@ import (
 	"os/exec"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kemilad/karpx/internal/backend"
 )
 
 // Config holds the runtime configuration passed from the CLI to the TUI.
@@ -21,6 +23,7 @@ const (
 	viewDashboard view = iota
 	viewNodePools
 	viewAddons
+	viewInterruptions
 )
 
 // Model is the root BubbleTea model; it owns navigation between views.
@@ -30,6 +33,7 @@ type Model struct {
 	dashboard  *DashboardModel
 	nodepools  *NodePoolsModel
 	addonsView *AddonsModel
+	feed       *InterruptionsModel
 }
 
 // NewModel constructs the root model and wires up the initial dashboard view.
@@ -52,6 +56,12 @@ type addonsDoneMsg struct{ err error }
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// While the tag filter is being typed every key is text, not a command.
+		if m.current == viewDashboard && m.dashboard.filtering && msg.String() != "ctrl+c" {
+			updated, cmd := m.dashboard.Update(msg)
+			m.dashboard = updated
+			return m, cmd
+		}
 		switch msg.String() {
 		case "q", "ctrl+c":
 			return m, tea.Quit
@@ -63,6 +73,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case NavigateMsg:
+		// Fake clusters cannot be changed; the views stay browsable.
+		if backend.Current().Demo {
+			switch msg.Target {
+			case NavInstall, NavUpgrade, NavAddonsInstall, NavAddonsUninstall:
+				return m, nil
+			}
+		}
 		switch msg.Target {
 		case NavInstall:
 			return m, m.execInstall(msg.KubeContext, msg.Region)
@@ -76,6 +93,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.addonsView = NewAddonsModel(msg.KubeContext)
 			m.current = viewAddons
 			return m, m.addonsView.Init()
+		case NavInterruptions:
+			m.feed = NewInterruptionsModel(msg.KubeContext)
+			m.current = viewInterruptions
+			return m, m.feed.Init()
 		case NavAddonsInstall:
 			return m, m.execAddonsInstall(msg.AddonID, msg.KubeContext)
 		case NavAddonsUninstall:
@@ -114,6 +135,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.addonsView = updated
 			return m, cmd
 		}
+	case viewInterruptions:
+		if m.feed != nil {
+			updated, cmd := m.feed.Update(msg)
+			m.feed = updated
+			return m, cmd
+		}
 	}
 
 	return m, nil
@@ -129,6 +156,10 @@ func (m *Model) View() string {
 		if m.addonsView != nil {
 			return m.addonsView.View()
 		}
+	case viewInterruptions:
+		if m.feed != nil {
+			return m.feed.View()
+		}
 	}
 	return m.dashboard.View()
 }
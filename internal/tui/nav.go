@@ -10,6 +10,7 @@ const (
 	NavAddons
 	NavAddonsInstall
 	NavAddonsUninstall
+	NavInterruptions
 )
 
 // NavigateMsg is sent by child views to request a screen transition.
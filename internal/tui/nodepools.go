@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/kemilad/karpx/internal/backend"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -214,10 +217,7 @@ func fetchNodePools(kubeCtx string) tea.Cmd {
 
 		// ── NodePools (v1beta1, Karpenter ≥ v0.31) ────────────────────────
 		npArgs := []string{"get", "nodepools.karpenter.sh", "-o", "json"}
-		if kubeCtx != "" {
-			npArgs = append(npArgs, "--context", kubeCtx)
-		}
-		out, err := exec.Command("kubectl", npArgs...).Output()
+		out, err := backend.Current().Kube.Kubectl(context.TODO(), kubeCtx, npArgs...)
 		if err == nil {
 			msg.nodePools = parseNodePools(out)
 		} else {
@@ -238,30 +238,21 @@ func fetchNodePools(kubeCtx string) tea.Cmd {
 		// Try when no NodePools were found (older cluster format).
 		if len(msg.nodePools) == 0 {
 			provArgs := []string{"get", "provisioners.karpenter.sh", "-o", "json"}
-			if kubeCtx != "" {
-				provArgs = append(provArgs, "--context", kubeCtx)
-			}
-			if out2, err2 := exec.Command("kubectl", provArgs...).Output(); err2 == nil {
+			if out2, err2 := backend.Current().Kube.Kubectl(context.TODO(), kubeCtx, provArgs...); err2 == nil {
 				msg.nodePools = parseProvisioners(out2)
 			}
 		}
 
 		// ── EC2NodeClasses (v1beta1, Karpenter ≥ v0.31) ───────────────────
 		ncArgs := []string{"get", "ec2nodeclasses.karpenter.k8s.aws", "-o", "json"}
-		if kubeCtx != "" {
-			ncArgs = append(ncArgs, "--context", kubeCtx)
-		}
-		if out3, err3 := exec.Command("kubectl", ncArgs...).Output(); err3 == nil {
+		if out3, err3 := backend.Current().Kube.Kubectl(context.TODO(), kubeCtx, ncArgs...); err3 == nil {
 			msg.nodeClasses = parseNodeClasses(out3)
 		}
 
 		// ── AWSNodeTemplates (v1alpha1, Karpenter < v0.31) — fallback ─────
 		if len(msg.nodeClasses) == 0 {
 			antArgs := []string{"get", "awsnodetemplates.karpenter.k8s.aws", "-o", "json"}
-			if kubeCtx != "" {
-				antArgs = append(antArgs, "--context", kubeCtx)
-			}
-			if out4, err4 := exec.Command("kubectl", antArgs...).Output(); err4 == nil {
+			if out4, err4 := backend.Current().Kube.Kubectl(context.TODO(), kubeCtx, antArgs...); err4 == nil {
 				msg.nodeClasses = parseAWSNodeTemplates(out4)
 			}
 		}
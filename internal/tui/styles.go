@@ -124,6 +124,12 @@ func BadgeIncompatible(toVer string) string {
 		Render(label)
 }
 
+func BadgeManaged() string {
+	return lipgloss.NewStyle().
+		Background(colBorder).Foreground(colHighlight).Bold(true).Padding(0, 1).
+		Render("◆ MANAGED")
+}
+
 func BadgeNotInstalled() string {
 	return lipgloss.NewStyle().
 		Background(colDanger).Foreground(colHighlight).Bold(true).Padding(0, 1).
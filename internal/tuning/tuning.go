@@ -0,0 +1,445 @@
+// Package tuning recommends Karpenter controller sizing for the cluster it
+// runs in: replicas, CPU and memory requests, and the provisioning batch
+// window (batchIdleDuration / batchMaxDuration).
+//
+// The chart ships with no requests and a batch window tuned for mid-sized
+// clusters. Small clusters reserve more than the controller ever uses; large
+// ones starve it, and a controller short of CPU schedules slowly while one
+// short of memory is OOM-killed mid-reconcile. The starting point is a tier
+// picked from the node and pod counts, adjusted by what metrics-server and
+// the controller's own metrics show.
+package tuning
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// Chart defaults for the batch window.
+const (
+	defaultBatchIdle = "1s"
+	defaultBatchMax  = "10s"
+)
+
+// headroom is the multiple of observed peak usage a request should cover.
+const headroom = 1.5
+
+// Tier is a controller size for clusters up to MaxNodes nodes and MaxPods
+// pods.
+type Tier struct {
+	Name      string
+	MaxNodes  int
+	MaxPods   int
+	CPU       string
+	Memory    string
+	BatchIdle string
+	BatchMax  string
+}
+
+// Tiers are the sizes, smallest first. The last tier has no upper bound.
+// Bigger clusters get a wider batch window so a burst of pending pods is
+// packed into fewer, larger nodes instead of many scheduling rounds.
+var Tiers = []Tier{
+	{"small", 50, 1500, "250m", "512Mi", defaultBatchIdle, defaultBatchMax},
+	{"medium", 250, 7500, "1", "1Gi", defaultBatchIdle, defaultBatchMax},
+	{"large", 1000, 30000, "2", "4Gi", "2s", "15s"},
+	{"xlarge", 0, 0, "4", "8Gi", "3s", "20s"},
+}
+
+// Observed is the controller and cluster as they are now.
+type Observed struct {
+	Nodes          int `json:"nodes"`
+	KarpenterNodes int `json:"karpenter_nodes"`
+	Pods           int `json:"pods"`
+
+	Replicas      int    `json:"replicas"`
+	CPURequest    string `json:"cpu_request,omitempty"`
+	MemoryRequest string `json:"memory_request,omitempty"`
+	MemoryLimit   string `json:"memory_limit,omitempty"`
+	BatchIdle     string `json:"batch_idle_duration"`
+	BatchMax      string `json:"batch_max_duration"`
+
+	// Usage is the highest across controller pods; zero without metrics-server.
+	CPUUsageMilli int64 `json:"cpu_usage_millicores,omitempty"`
+	MemoryUsage   int64 `json:"memory_usage_bytes,omitempty"`
+	OOMKilled     bool  `json:"oom_killed,omitempty"`
+	Restarts      int32 `json:"restarts,omitempty"`
+	// Scheduling is the mean time of a scheduling round from the controller's
+	// metrics; zero when they cannot be read.
+	Scheduling time.Duration `json:"scheduling_ns,omitempty"`
+}
+
+// Change is one setting to change, as a chart value.
+type Change struct {
+	Key         string `json:"key"` // dotted chart value
+	Current     string `json:"current"`
+	Recommended string `json:"recommended"`
+	Reason      string `json:"reason"`
+}
+
+// Plan is the recommendation for one controller.
+type Plan struct {
+	Observed Observed `json:"observed"`
+	Tier     string   `json:"tier"`
+	Changes  []Change `json:"changes"`
+}
+
+// Observe reads the controller Deployment, cluster size and usage metrics.
+func Observe(kubeCtx, namespace, deployment string) (*Observed, error) {
+	cs, err := clientset(kubeCtx)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.TODO()
+	dep, err := cs.AppsV1().Deployments(namespace).Get(ctx, deployment, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get Deployment %s/%s: %w", namespace, deployment, err)
+	}
+	o := &Observed{Replicas: 1, BatchIdle: defaultBatchIdle, BatchMax: defaultBatchMax}
+	if dep.Spec.Replicas != nil {
+		o.Replicas = int(*dep.Spec.Replicas)
+	}
+	c := controllerContainer(dep.Spec.Template.Spec.Containers)
+	if c != nil {
+		if q, ok := c.Resources.Requests[corev1.ResourceCPU]; ok {
+			o.CPURequest = q.String()
+		}
+		if q, ok := c.Resources.Requests[corev1.ResourceMemory]; ok {
+			o.MemoryRequest = q.String()
+		}
+		if q, ok := c.Resources.Limits[corev1.ResourceMemory]; ok {
+			o.MemoryLimit = q.String()
+		}
+		for _, e := range c.Env {
+			switch e.Name {
+			case "BATCH_IDLE_DURATION":
+				o.BatchIdle = e.Value
+			case "BATCH_MAX_DURATION":
+				o.BatchMax = e.Value
+			}
+		}
+	}
+
+	nodes, err := cs.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list nodes: %w", err)
+	}
+	o.Nodes = len(nodes.Items)
+	for _, n := range nodes.Items {
+		if n.Labels["karpenter.sh/nodepool"] != "" || n.Labels["karpenter.sh/provisioner-name"] != "" {
+			o.KarpenterNodes++
+		}
+	}
+	pods, err := cs.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	o.Pods = len(pods.Items)
+
+	selector := metav1.FormatLabelSelector(dep.Spec.Selector)
+	ctrl, err := cs.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err == nil {
+		for _, p := range ctrl.Items {
+			for _, st := range p.Status.ContainerStatuses {
+				o.Restarts += st.RestartCount
+				if t := st.LastTerminationState.Terminated; t != nil && t.Reason == "OOMKilled" {
+					o.OOMKilled = true
+				}
+			}
+		}
+		o.Scheduling = schedulingTime(ctx, cs, ctrl.Items)
+	}
+	usage(ctx, cs, namespace, selector, c, o)
+	return o, nil
+}
+
+func controllerContainer(cs []corev1.Container) *corev1.Container {
+	for i := range cs {
+		if cs[i].Name == "controller" {
+			return &cs[i]
+		}
+	}
+	if len(cs) > 0 {
+		return &cs[0]
+	}
+	return nil
+}
+
+// usage fills in peak controller usage from metrics-server, when installed.
+func usage(ctx context.Context, cs *kubernetes.Clientset, namespace, selector string, c *corev1.Container, o *Observed) {
+	raw, err := cs.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces/"+namespace+"/pods").
+		Param("labelSelector", selector).
+		DoRaw(ctx)
+	if err != nil {
+		return
+	}
+	var list struct {
+		Items []struct {
+			Containers []struct {
+				Name  string            `json:"name"`
+				Usage map[string]string `json:"usage"`
+			} `json:"containers"`
+		} `json:"items"`
+	}
+	if json.Unmarshal(raw, &list) != nil {
+		return
+	}
+	for _, item := range list.Items {
+		for _, ct := range item.Containers {
+			if c != nil && ct.Name != c.Name {
+				continue
+			}
+			if q, err := resource.ParseQuantity(ct.Usage["cpu"]); err == nil {
+				o.CPUUsageMilli = max(o.CPUUsageMilli, q.MilliValue())
+			}
+			if q, err := resource.ParseQuantity(ct.Usage["memory"]); err == nil {
+				o.MemoryUsage = max(o.MemoryUsage, q.Value())
+			}
+		}
+	}
+}
+
+// schedulingMetrics are the scheduling-duration histograms, v1 first.
+var schedulingMetrics = []string{
+	"karpenter_scheduler_scheduling_duration_seconds",
+	"karpenter_provisioner_scheduling_duration_seconds",
+}
+
+// schedulingTime is the mean scheduling round across controller pods, read
+// from their metrics endpoint through the API server. Only the leader
+// schedules, so the others contribute nothing.
+func schedulingTime(ctx context.Context, cs *kubernetes.Clientset, pods []corev1.Pod) time.Duration {
+	var sum, count float64
+	for _, p := range pods {
+		if p.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		port := "8080"
+		for _, c := range p.Spec.Containers {
+			for _, cp := range c.Ports {
+				if cp.Name == "http-metrics" {
+					port = strconv.Itoa(int(cp.ContainerPort))
+				}
+			}
+		}
+		raw, err := cs.CoreV1().Pods(p.Namespace).ProxyGet("http", p.Name, port, "metrics", nil).DoRaw(ctx)
+		if err != nil {
+			continue
+		}
+		s, n := histogram(raw)
+		sum += s
+		count += n
+	}
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(sum / count * float64(time.Second))
+}
+
+// histogram returns the _sum and _count of the first scheduling histogram
+// found in a Prometheus text exposition.
+func histogram(raw []byte) (sum, count float64) {
+	for _, name := range schedulingMetrics {
+		found := false
+		sc := bufio.NewScanner(bytes.NewReader(raw))
+		sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for sc.Scan() {
+			line := sc.Text()
+			var target *float64
+			switch {
+			case strings.HasPrefix(line, name+"_sum"):
+				target = &sum
+			case strings.HasPrefix(line, name+"_count"):
+				target = &count
+			default:
+				continue
+			}
+			fields := strings.Fields(line)
+			if v, err := strconv.ParseFloat(fields[len(fields)-1], 64); err == nil {
+				*target += v
+				found = true
+			}
+		}
+		if found {
+			return sum, count
+		}
+	}
+	return 0, 0
+}
+
+// Recommend sizes the controller for o.
+func Recommend(o *Observed) *Plan {
+	t := Tiers[len(Tiers)-1]
+	for _, tier := range Tiers[:len(Tiers)-1] {
+		if o.Nodes <= tier.MaxNodes && o.Pods <= tier.MaxPods {
+			t = tier
+			break
+		}
+	}
+	p := &Plan{Observed: *o, Tier: t.Name}
+	size := fmt.Sprintf("%s cluster (%d nodes, %d pods)", t.Name, o.Nodes, o.Pods)
+
+	// Only the leader works; a second replica takes over within seconds
+	// when it dies, and more only reserve resources.
+	if o.Replicas != 2 {
+		p.add("replicas", strconv.Itoa(o.Replicas), "2",
+			"one leader plus one standby; other replicas sit idle")
+	}
+
+	cpu := resource.MustParse(t.CPU)
+	cpuReason := "sized for a " + size
+	if o.CPUUsageMilli > 0 {
+		if need := int64(float64(o.CPUUsageMilli) * headroom); need > cpu.MilliValue() {
+			cpu = *resource.NewMilliQuantity(roundUp(need, 250), resource.DecimalSI)
+			cpuReason = fmt.Sprintf("peak usage %dm plus headroom", o.CPUUsageMilli)
+		}
+	}
+	if o.Scheduling > 2*time.Second {
+		cpu = *resource.NewMilliQuantity(cpu.MilliValue()*2, resource.DecimalSI)
+		cpuReason = fmt.Sprintf("scheduling rounds average %s — the controller is CPU-bound", o.Scheduling.Round(100*time.Millisecond))
+	}
+	if !same(o.CPURequest, cpu) {
+		p.add("controller.resources.requests.cpu", orUnset(o.CPURequest), cpu.String(), cpuReason)
+	}
+
+	const mi = 1 << 20
+	mem := resource.MustParse(t.Memory)
+	memReason := "sized for a " + size
+	if o.MemoryUsage > 0 {
+		if need := int64(float64(o.MemoryUsage) * headroom); need > mem.Value() {
+			mem = *resource.NewQuantity(roundUp(need, 256*mi), resource.BinarySI)
+			memReason = fmt.Sprintf("peak usage %dMi plus headroom", o.MemoryUsage/mi)
+		}
+	}
+	if o.OOMKilled {
+		cur := mem.Value()
+		if q, err := resource.ParseQuantity(o.MemoryLimit); err == nil && q.Value() > cur {
+			cur = q.Value()
+		}
+		mem = *resource.NewQuantity(roundUp(cur*2, 256*mi), resource.BinarySI)
+		memReason = "the controller was OOM-killed"
+	}
+	if !same(o.MemoryRequest, mem) {
+		p.add("controller.resources.requests.memory", orUnset(o.MemoryRequest), mem.String(), memReason)
+	}
+	// A memory limit above the request lets the node overcommit and evict
+	// the controller under pressure.
+	if !same(o.MemoryLimit, mem) {
+		p.add("controller.resources.limits.memory", orUnset(o.MemoryLimit), mem.String(), "equal to the request, so the controller is never evicted for memory")
+	}
+
+	if o.BatchIdle != t.BatchIdle {
+		p.add("settings.batchIdleDuration", o.BatchIdle, t.BatchIdle, "batch window for a "+size)
+	}
+	if o.BatchMax != t.BatchMax {
+		p.add("settings.batchMaxDuration", o.BatchMax, t.BatchMax, "batch window for a "+size)
+	}
+	return p
+}
+
+func (p *Plan) add(key, current, recommended, reason string) {
+	p.Changes = append(p.Changes, Change{Key: key, Current: current, Recommended: recommended, Reason: reason})
+}
+
+func roundUp(v, step int64) int64 { return (v + step - 1) / step * step }
+
+func same(current string, want resource.Quantity) bool {
+	q, err := resource.ParseQuantity(current)
+	return err == nil && q.Cmp(want) == 0
+}
+
+func orUnset(s string) string {
+	if s == "" {
+		return "(unset)"
+	}
+	return s
+}
+
+// Values is the values patch applying the plan.
+func (p *Plan) Values() ([]byte, error) {
+	vals := map[string]any{}
+	for _, c := range p.Changes {
+		keys := strings.Split(c.Key, ".")
+		node := vals
+		for _, k := range keys[:len(keys)-1] {
+			next, ok := node[k].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				node[k] = next
+			}
+			node = next
+		}
+		var v any = c.Recommended
+		if n, err := strconv.Atoi(c.Recommended); err == nil && c.Key == "replicas" {
+			v = n
+		}
+		node[keys[len(keys)-1]] = v
+	}
+	return yaml.Marshal(vals)
+}
+
+// Apply upgrades the Helm release in place with the plan's values on top of
+// the deployed ones, keeping the installed chart version.
+func Apply(kubeCtx, namespace, release, chart, version string, p *Plan) error {
+	vals, err := p.Values()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", "karpx-tune-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(vals); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+
+	args := []string{"upgrade", release, chart,
+		"--namespace", namespace,
+		"--version", strings.TrimPrefix(version, "v"),
+		"--reuse-values", "--values", f.Name(),
+		"--wait", "--timeout", "5m",
+	}
+	if kubeCtx != "" {
+		args = append(args, "--kube-context", kubeCtx)
+	}
+	out, err := exec.Command("helm", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("helm upgrade: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func clientset(kubeCtx string) (*kubernetes.Clientset, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	return kubernetes.NewForConfig(restCfg)
+}
@@ -4,6 +4,7 @@
 package ui
 
 import (
+	"bytes"
 	"context"
 	"embed"
 	"encoding/json"
@@ -23,10 +24,16 @@ import (
 	"time"
 
 	"github.com/kemilad/karpx/internal/addons"
+	"github.com/kemilad/karpx/internal/awscli"
+	"github.com/kemilad/karpx/internal/backend"
 	"github.com/kemilad/karpx/internal/compat"
+	"github.com/kemilad/karpx/internal/config"
 	"github.com/kemilad/karpx/internal/helm"
+	"github.com/kemilad/karpx/internal/hooks"
 	"github.com/kemilad/karpx/internal/kube"
 	"github.com/kemilad/karpx/internal/nodes"
+	"github.com/kemilad/karpx/internal/showback"
+	"github.com/kemilad/karpx/internal/status"
 	karpupgrade "github.com/kemilad/karpx/internal/upgrade"
 )
 
@@ -96,23 +103,6 @@ func startGrafanaPortForward(kubeCtx, namespace, svc string) error {
 	return fmt.Errorf("timed out waiting for port-forward to become ready on :%d", localPort)
 }
 
-// ClusterStatus is the JSON payload returned by /api/clusters.
-type ClusterStatus struct {
-	Context              string `json:"context"`
-	Provider             string `json:"provider"`
-	DocsURL              string `json:"docs_url,omitempty"`
-	K8sVersion           string `json:"k8s_version"`
-	KarpenterInstalled   bool   `json:"karpenter_installed"`
-	KarpenterVersion     string `json:"karpenter_version,omitempty"`
-	KarpenterNamespace   string `json:"karpenter_namespace,omitempty"`
-	KarpenterRelease     string `json:"karpenter_release,omitempty"`
-	Compatible           *bool  `json:"compatible,omitempty"`
-	UpgradeAvailable     bool   `json:"upgrade_available"`
-	LatestCompatible     string `json:"latest_compatible,omitempty"`
-	MinCompatible        string `json:"min_compatible,omitempty"`
-	Error                string `json:"error,omitempty"`
-}
-
 // InstallRequest is the JSON body for POST /api/install.
 type InstallRequest struct {
 	Context           string `json:"context"`
@@ -199,6 +189,13 @@ type NodePoolListResponse struct {
 	Error       string            `json:"error,omitempty"`
 }
 
+// ShowbackResponse is returned by GET /api/showback.
+type ShowbackResponse struct {
+	showback.Report
+	Monthly float64 `json:"monthly"`
+	Error   string  `json:"error,omitempty"`
+}
+
 // RecommendRequest is the JSON body for POST /api/nodes/recommend.
 type RecommendRequest struct {
 	Context     string `json:"context"`
@@ -247,7 +244,8 @@ type AddonActionRequest struct {
 // Serve starts the dashboard HTTP server on the given port.
 // If port is 0 a free port is chosen automatically.
 // kubeCtx restricts the dashboard to a single context; pass "" to show all.
-func Serve(port int, kubeCtx string) error {
+// sel is the default tag filter; the page can change it per request.
+func Serve(port int, kubeCtx string, sel config.Selector) error {
 	// Resolve the address.
 	addr := fmt.Sprintf("127.0.0.1:%d", port)
 
@@ -279,10 +277,22 @@ func Serve(port int, kubeCtx string) error {
 		if kubeCtx != "" {
 			contexts = []string{kubeCtx}
 		} else {
-			contexts = allContexts()
+			contexts = status.AllContexts()
+		}
+
+		// ?tag= overrides the --tag filter; an empty value shows everything.
+		filter := sel
+		if r.URL.Query().Has("tag") {
+			parsed, err := config.ParseSelector([]string{r.URL.Query().Get("tag")})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			filter = parsed
 		}
+		w.Header().Set("X-Karpx-Tag-Filter", filter.String())
 
-		results := checkClusters(contexts)
+		results := status.Check(status.Filter(contexts, filter))
 		json.NewEncoder(w).Encode(results)
 	})
 
@@ -309,7 +319,7 @@ func Serve(port int, kubeCtx string) error {
 			json.NewEncoder(w).Encode(VersionsResponse{Error: "k8s query param required"})
 			return
 		}
-		latest, all, err := compat.LatestCompatible(k8sVer)
+		latest, all, err := latestCompatible(k8sVer)
 		if err != nil {
 			// GitHub may be rate-limited — return the min compatible as a
 			// fallback so the UI can still suggest something.
@@ -341,6 +351,12 @@ func Serve(port int, kubeCtx string) error {
 			json.NewEncoder(w).Encode(InstallResponse{Error: "context, version, cluster_name, and region are required"})
 			return
 		}
+		if info, _ := helm.DetectKarpenter(req.Context); info != nil {
+			if err := info.ManagedError(); err != nil {
+				json.NewEncoder(w).Encode(InstallResponse{Error: err.Error()})
+				return
+			}
+		}
 
 		ns := req.Namespace
 		if ns == "" {
@@ -348,10 +364,16 @@ func Serve(port int, kubeCtx string) error {
 		}
 		ver := strings.TrimPrefix(req.Version, "v")
 
+		chart, err := helm.Pull(helm.KarpenterChart, ver, "")
+		if err != nil {
+			json.NewEncoder(w).Encode(InstallResponse{Error: err.Error()})
+			return
+		}
+		defer chart.Close()
+
 		args := []string{
 			"install", "karpenter",
-			"oci://public.ecr.aws/karpenter/karpenter",
-			"--version", ver,
+			chart.Path,
 			"--namespace", ns,
 			"--create-namespace",
 			"--kube-context", req.Context,
@@ -363,20 +385,36 @@ func Serve(port int, kubeCtx string) error {
 			args = append(args, "--set",
 				`serviceAccount.annotations.eks\.amazonaws\.com/role-arn=`+req.ControllerRoleARN)
 		}
+		if helm.Mirrored() {
+			args = append(args, "--set", "controller.image.repository="+helm.ControllerImage())
+		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 		defer cancel()
 
-		out, err := exec.CommandContext(ctx, "helm", args...).CombinedOutput()
+		// Hook output and helm's share one log, in the order they ran.
+		var out bytes.Buffer
+		vars := map[string]string{"KARPX_PROVIDER": "aws", "KARPX_NAMESPACE": ns, "KARPX_VERSION": ver}
+		err = hooks.Around(hooks.Install, req.Context, vars, &out, func() error {
+			cmd := exec.CommandContext(ctx, "helm", args...)
+			cmd.Stdout = &out
+			cmd.Stderr = &out
+			return cmd.Run()
+		})
 		if err != nil {
 			json.NewEncoder(w).Encode(InstallResponse{
-				Error: fmt.Sprintf("%v\n%s", err, strings.TrimSpace(string(out))),
+				Error: fmt.Sprintf("%v\n%s", err, strings.TrimSpace(out.String())),
 			})
 			return
 		}
+		if chart.Digest != "" {
+			_ = helm.RecordProvenance(req.Context, ns, helm.Provenance{
+				Chart: helm.KarpenterChart, Version: ver, Digest: chart.Digest, DeployedAt: time.Now().UTC(),
+			})
+		}
 		json.NewEncoder(w).Encode(InstallResponse{
 			Success: true,
-			Output:  strings.TrimSpace(string(out)),
+			Output:  strings.TrimSpace(out.String()),
 		})
 	})
 
@@ -398,6 +436,12 @@ func Serve(port int, kubeCtx string) error {
 			json.NewEncoder(w).Encode(InstallResponse{Error: "context is required"})
 			return
 		}
+		if info, _ := helm.DetectKarpenter(req.Context); info != nil {
+			if err := info.ManagedError(); err != nil {
+				json.NewEncoder(w).Encode(InstallResponse{Error: err.Error()})
+				return
+			}
+		}
 		release := req.Release
 		if release == "" {
 			release = "karpenter"
@@ -413,16 +457,28 @@ func Serve(port int, kubeCtx string) error {
 		var steps []string
 		addStep := func(s string) { steps = append(steps, s) }
 
-		// ── Step 1: helm uninstall ────────────────────────────────────────
-		addStep("Running helm uninstall…")
-		helmArgs := []string{"uninstall", release, "--namespace", ns, "--kube-context", req.Context}
-		out, err := exec.CommandContext(ctx, "helm", helmArgs...).CombinedOutput()
-		if err != nil {
-			addStep(fmt.Sprintf("✗ helm uninstall failed: %v — %s", err, strings.TrimSpace(string(out))))
-			json.NewEncoder(w).Encode(InstallResponse{Error: strings.Join(steps, "\n"), Steps: steps})
-			return
+		// ── Step 1: helm uninstall (or delete a manifest install) ─────────
+		if rel, _ := helm.RecordedStatic(req.Context); rel != nil {
+			addStep("Deleting manifest-installed objects…")
+			if errs := helm.UninstallStatic(req.Context, rel, false); len(errs) > 0 {
+				for _, e := range errs {
+					addStep(fmt.Sprintf("✗ %v", e))
+				}
+				json.NewEncoder(w).Encode(InstallResponse{Error: strings.Join(steps, "\n"), Steps: steps})
+				return
+			}
+			addStep("✓ Manifest install removed")
+		} else {
+			addStep("Running helm uninstall…")
+			helmArgs := []string{"uninstall", release, "--namespace", ns, "--kube-context", req.Context}
+			out, err := exec.CommandContext(ctx, "helm", helmArgs...).CombinedOutput()
+			if err != nil {
+				addStep(fmt.Sprintf("✗ helm uninstall failed: %v — %s", err, strings.TrimSpace(string(out))))
+				json.NewEncoder(w).Encode(InstallResponse{Error: strings.Join(steps, "\n"), Steps: steps})
+				return
+			}
+			addStep("✓ Helm release removed")
 		}
-		addStep("✓ Helm release removed")
 
 		// ── Step 2: delete custom resources ──────────────────────────────
 		if req.DeleteCRDs {
@@ -510,6 +566,10 @@ func Serve(port int, kubeCtx string) error {
 			json.NewEncoder(w).Encode(InstallResponse{Error: "Karpenter not detected on this cluster"})
 			return
 		}
+		if err := info.ManagedError(); err != nil {
+			json.NewEncoder(w).Encode(InstallResponse{Error: err.Error()})
+			return
+		}
 
 		// info.Chart is only populated for Helm-managed releases; empty = raw manifests.
 		viaHelm := info.Chart != ""
@@ -558,21 +618,34 @@ func Serve(port int, kubeCtx string) error {
 		defer cancel()
 		_ = ctx // upgrade.Run uses exec directly; context enforced above
 
-		if err := karpupgrade.Run(karpupgrade.Params{
-			KubeCtx:        req.Context,
-			Namespace:      ns,
-			ReleaseName:    release,
-			DeploymentName: deploymentName,
-			Current:        installed,
-			Target:         target,
-			AllVersions:    allVersions,
-			ReuseValues:    true,
-			ViaHelm:        viaHelm,
-		}, reporter); err != nil {
-			json.NewEncoder(w).Encode(InstallResponse{Error: err.Error(), Steps: steps})
+		crds, _ := helm.DetectCRDs(req.Context)
+
+		var hookOut bytes.Buffer
+		vars := map[string]string{
+			"KARPX_VERSION":      target,
+			"KARPX_FROM_VERSION": installed,
+			"KARPX_NAMESPACE":    ns,
+			"KARPX_RELEASE":      release,
+		}
+		if err := hooks.Around(hooks.Upgrade, req.Context, vars, &hookOut, func() error {
+			return karpupgrade.Run(karpupgrade.Params{
+				KubeCtx:        req.Context,
+				Namespace:      ns,
+				ReleaseName:    release,
+				DeploymentName: deploymentName,
+				Current:        installed,
+				Target:         target,
+				AllVersions:    allVersions,
+				ReuseValues:    true,
+				ViaHelm:        viaHelm,
+				Manifests:      info.Manifests,
+				CRDs:           crds,
+			}, reporter)
+		}); err != nil {
+			json.NewEncoder(w).Encode(InstallResponse{Error: err.Error(), Steps: steps, Output: strings.TrimSpace(hookOut.String())})
 			return
 		}
-		json.NewEncoder(w).Encode(InstallResponse{Success: true, Steps: steps})
+		json.NewEncoder(w).Encode(InstallResponse{Success: true, Steps: steps, Output: strings.TrimSpace(hookOut.String())})
 	})
 
 	// ── NodePools list ──────────────────────────────────────────────────────
@@ -581,195 +654,31 @@ func Serve(port int, kubeCtx string) error {
 		w.Header().Set("Cache-Control", "no-store")
 
 		kubeCtxParam := r.URL.Query().Get("context")
+		json.NewEncoder(w).Encode(listNodePools(r.Context(), kubeCtxParam))
+	})
 
-		// Inline types for k8s JSON parsing (mirrors tui/nodepools.go).
-		type k8sCond struct {
-			Type    string `json:"type"`
-			Status  string `json:"status"`
-			Message string `json:"message"`
-			Reason  string `json:"reason"`
-		}
-		// k8sMeta covers NodePool (v1beta1) and EC2NodeClass (v1beta1).
-		type k8sMeta struct {
-			Metadata struct {
-				Name        string            `json:"name"`
-				Annotations map[string]string `json:"annotations"`
-			} `json:"metadata"`
-			Spec struct {
-				Limits          map[string]string `json:"limits"`          // NodePool v1beta1
-				Role            string            `json:"role"`            // EC2NodeClass v1beta1
-				InstanceProfile string            `json:"instanceProfile"` // AWSNodeTemplate v1alpha1
-			} `json:"spec"`
-			Status struct {
-				Conditions []k8sCond `json:"conditions"`
-			} `json:"status"`
-		}
-		// provisionerMeta covers Provisioner (v1alpha5) with nested limits.
-		type provisionerMeta struct {
-			Metadata struct {
-				Name        string            `json:"name"`
-				Annotations map[string]string `json:"annotations"`
-			} `json:"metadata"`
-			Spec struct {
-				Limits struct {
-					Resources map[string]string `json:"resources"`
-				} `json:"limits"`
-			} `json:"spec"`
-			Status struct {
-				Conditions []k8sCond `json:"conditions"`
-			} `json:"status"`
-		}
-		type k8sList struct {
-			Items []json.RawMessage `json:"items"`
-		}
-		readyStatus := func(conds []k8sCond) (bool, string) {
-			for _, c := range conds {
-				if c.Type == "Ready" {
-					if c.Status == "True" {
-						return true, ""
-					}
-					msg := c.Reason
-					if c.Message != "" {
-						msg = c.Message
-					}
-					return false, msg
-				}
-			}
-			return false, ""
-		}
-		isCRDMissing := func(errStr string) bool {
-			return strings.Contains(errStr, "no matches for kind") ||
-				strings.Contains(errStr, "the server doesn't have a resource type")
-		}
-
-		resp := NodePoolListResponse{
-			NodePools:   []NodePoolDetail{},
-			NodeClasses: []NodeClassDetail{},
-		}
-
-		// ── NodePools (v1beta1, Karpenter ≥ v0.31) ────────────────────────
-		npArgs := []string{"get", "nodepools.karpenter.sh", "-o", "json"}
-		if kubeCtxParam != "" {
-			npArgs = append(npArgs, "--context", kubeCtxParam)
-		}
-		npOut, npErr := exec.CommandContext(r.Context(), "kubectl", npArgs...).Output()
-		if npErr != nil {
-			var exitErr *exec.ExitError
-			if errors.As(npErr, &exitErr) {
-				errStr := strings.TrimSpace(string(exitErr.Stderr))
-				if errStr != "" && !isCRDMissing(errStr) {
-					resp.Error = errStr
-				}
-			}
-		} else {
-			var list k8sList
-			if json.Unmarshal(npOut, &list) == nil {
-				for _, raw := range list.Items {
-					var m k8sMeta
-					if json.Unmarshal(raw, &m) != nil {
-						continue
-					}
-					ready, msg := readyStatus(m.Status.Conditions)
-					resp.NodePools = append(resp.NodePools, NodePoolDetail{
-						Name:        m.Metadata.Name,
-						Mode:        m.Metadata.Annotations["karpx.io/generated-mode"],
-						Ready:       ready,
-						NotReadyMsg: msg,
-						CPULim:      m.Spec.Limits["cpu"],
-						MemLim:      m.Spec.Limits["memory"],
-					})
-				}
-			}
-		}
-
-		// ── Provisioners (v1alpha5, Karpenter < v0.31) — fallback ─────────
-		if len(resp.NodePools) == 0 && resp.Error == "" {
-			provArgs := []string{"get", "provisioners.karpenter.sh", "-o", "json"}
-			if kubeCtxParam != "" {
-				provArgs = append(provArgs, "--context", kubeCtxParam)
-			}
-			if provOut, provErr := exec.CommandContext(r.Context(), "kubectl", provArgs...).Output(); provErr == nil {
-				var list k8sList
-				if json.Unmarshal(provOut, &list) == nil {
-					for _, raw := range list.Items {
-						var m provisionerMeta
-						if json.Unmarshal(raw, &m) != nil {
-							continue
-						}
-						ready, msg := readyStatus(m.Status.Conditions)
-						resp.NodePools = append(resp.NodePools, NodePoolDetail{
-							Name:        m.Metadata.Name,
-							Mode:        m.Metadata.Annotations["karpx.io/generated-mode"],
-							Ready:       ready,
-							NotReadyMsg: msg,
-							CPULim:      m.Spec.Limits.Resources["cpu"],
-							MemLim:      m.Spec.Limits.Resources["memory"],
-						})
-					}
-				}
-			}
-		}
+	// ── Cost showback ───────────────────────────────────────────────────────
+	mux.HandleFunc("/api/showback", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
 
-		// ── EC2NodeClasses (v1beta1, Karpenter ≥ v0.31) ───────────────────
-		ncArgs := []string{"get", "ec2nodeclasses.karpenter.k8s.aws", "-o", "json"}
-		if kubeCtxParam != "" {
-			ncArgs = append(ncArgs, "--context", kubeCtxParam)
+		q := r.URL.Query()
+		kubeCtxParam := q.Get("context")
+		if p := kube.DetectProvider(kubeCtxParam); p != kube.ProviderAWS && p != kube.ProviderUnknown {
+			json.NewEncoder(w).Encode(ShowbackResponse{Error: "showback currently supports AWS only"})
+			return
 		}
-		ncOut, ncErr := exec.CommandContext(r.Context(), "kubectl", ncArgs...).Output()
-		if ncErr == nil {
-			var list k8sList
-			if json.Unmarshal(ncOut, &list) == nil {
-				for _, raw := range list.Items {
-					var m k8sMeta
-					if json.Unmarshal(raw, &m) != nil {
-						continue
-					}
-					ready, msg := readyStatus(m.Status.Conditions)
-					role := m.Spec.Role
-					if role == "" {
-						role = m.Spec.InstanceProfile
-					}
-					resp.NodeClasses = append(resp.NodeClasses, NodeClassDetail{
-						Name:        m.Metadata.Name,
-						Role:        role,
-						Ready:       ready,
-						NotReadyMsg: msg,
-					})
-				}
-			}
+		region := awscli.RegionFromContext(kubeCtxParam)
+		if region == "" {
+			json.NewEncoder(w).Encode(ShowbackResponse{Error: "could not determine the AWS region of this context"})
+			return
 		}
-
-		// ── AWSNodeTemplates (v1alpha1, Karpenter < v0.31) — fallback ─────
-		if len(resp.NodeClasses) == 0 {
-			antArgs := []string{"get", "awsnodetemplates.karpenter.k8s.aws", "-o", "json"}
-			if kubeCtxParam != "" {
-				antArgs = append(antArgs, "--context", kubeCtxParam)
-			}
-			if antOut, antErr := exec.CommandContext(r.Context(), "kubectl", antArgs...).Output(); antErr == nil {
-				var list k8sList
-				if json.Unmarshal(antOut, &list) == nil {
-					for _, raw := range list.Items {
-						var m k8sMeta
-						if json.Unmarshal(raw, &m) != nil {
-							continue
-						}
-						ready, msg := readyStatus(m.Status.Conditions)
-						role := m.Spec.InstanceProfile
-						if role == "" {
-							role = m.Spec.Role
-						}
-						resp.NodeClasses = append(resp.NodeClasses, NodeClassDetail{
-							Name:        m.Metadata.Name,
-							Role:        role,
-							Ready:       ready,
-							NotReadyMsg: msg,
-						})
-					}
-				}
-			}
+		rep, err := showback.Load(kubeCtxParam, region, q.Get("team_label"), q.Get("spread") == "true")
+		if err != nil {
+			json.NewEncoder(w).Encode(ShowbackResponse{Error: err.Error()})
+			return
 		}
-
-		json.NewEncoder(w).Encode(resp)
+		json.NewEncoder(w).Encode(ShowbackResponse{Report: rep, Monthly: rep.Monthly()})
 	})
 
 	// ── Node recommendation ─────────────────────────────────────────────────
@@ -866,14 +775,16 @@ func Serve(port int, kubeCtx string) error {
 		if req.Context != "" {
 			args = append(args, "--context", req.Context)
 		}
+		var out bytes.Buffer
 		cmd := exec.CommandContext(r.Context(), "kubectl", args...)
 		cmd.Stdin = strings.NewReader(req.Manifest)
-		out, err := cmd.CombinedOutput()
-		if err != nil {
-			json.NewEncoder(w).Encode(InstallResponse{Error: fmt.Sprintf("%v\n%s", err, strings.TrimSpace(string(out)))})
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		if err := hooks.Around(hooks.Apply, req.Context, nil, &out, cmd.Run); err != nil {
+			json.NewEncoder(w).Encode(InstallResponse{Error: fmt.Sprintf("%v\n%s", err, strings.TrimSpace(out.String()))})
 			return
 		}
-		json.NewEncoder(w).Encode(InstallResponse{Success: true, Output: strings.TrimSpace(string(out))})
+		json.NewEncoder(w).Encode(InstallResponse{Success: true, Output: strings.TrimSpace(out.String())})
 	})
 
 	// ── Add-ons list ────────────────────────────────────────────────────────
@@ -1328,9 +1239,14 @@ func Serve(port int, kubeCtx string) error {
 		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "url": "http://localhost:3000"})
 	})
 
+	var handler http.Handler = mux
+	if backend.Current().Demo {
+		handler = demoGuard(mux)
+	}
+
 	srv := &http.Server{
 		Addr:        addr,
-		Handler:     mux,
+		Handler:     handler,
 		ReadTimeout: 10 * time.Second,
 		// WriteTimeout is generous to accommodate the /api/install endpoint,
 		// which shells out to helm and can take several minutes.
@@ -1340,6 +1256,9 @@ func Serve(port int, kubeCtx string) error {
 	url := "http://" + addr
 	fmt.Printf("\n  ⚡ karpx dashboard\n\n")
 	fmt.Printf("  URL     : %s\n", url)
+	if backend.Current().Demo {
+		fmt.Printf("  Mode    : demo — fake clusters, changes disabled\n")
+	}
 	fmt.Printf("  Refresh : every 30 s (or click Refresh in the browser)\n")
 	fmt.Printf("  Stop    : Ctrl+C\n\n")
 
@@ -1364,135 +1283,47 @@ func Serve(port int, kubeCtx string) error {
 	return nil
 }
 
-// ─────────────────────────────────────────────────────────────────────────────
-// Cluster inspection helpers
-// ─────────────────────────────────────────────────────────────────────────────
-
-// allContexts returns every context name from the active kubeconfig.
-func allContexts() []string {
-	out, err := exec.Command("kubectl", "config", "get-contexts",
-		"-o", "name").Output()
+// latestCompatible is compat.LatestCompatible read through the backend, so
+// the demo answers without GitHub.
+func latestCompatible(k8sVer string) (latest string, all []string, err error) {
+	src, _ := backend.Current().Compat.Source(kube.ProviderAWS)
+	available, matrix, err := src()
 	if err != nil {
-		return nil
+		return "", nil, err
 	}
-	var ctxs []string
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			ctxs = append(ctxs, line)
-		}
+	all = matrix.FilterCompatible(k8sVer, available)
+	if len(all) == 0 {
+		return "", nil, nil
 	}
-	return ctxs
+	return all[0], all, nil
 }
 
-// checkClusters inspects each context concurrently.
-func checkClusters(contexts []string) []ClusterStatus {
-	results := make([]ClusterStatus, len(contexts))
-	var wg sync.WaitGroup
-
-	// Limit parallelism to avoid hammering kubeconfig / network.
-	sem := make(chan struct{}, 8)
-
-	for i, ctx := range contexts {
-		wg.Add(1)
-		go func(i int, ctx string) {
-			defer wg.Done()
-			sem <- struct{}{}
-			defer func() { <-sem }()
-			results[i] = inspectContext(ctx)
-		}(i, ctx)
-	}
-	wg.Wait()
-	return results
+// demoBlocked are the endpoints refused in demo mode: they change a cluster
+// or need a real one.
+var demoBlocked = map[string]bool{
+	"/api/install":                    true,
+	"/api/uninstall":                  true,
+	"/api/upgrade":                    true,
+	"/api/showback":                   true,
+	"/api/nodes/recommend":            true,
+	"/api/nodes/validate":             true,
+	"/api/nodes/apply":                true,
+	"/api/addons/install":             true,
+	"/api/addons/uninstall":           true,
+	"/api/addons/grafana-portforward": true,
 }
 
-// inspectContext gathers all status fields for one kubeconfig context.
-func inspectContext(ctx string) ClusterStatus {
-	s := ClusterStatus{Context: ctx}
-
-	// Provider.
-	provider := kube.DetectProvider(ctx)
-	s.Provider = string(provider)
-	s.DocsURL = provider.Meta().DocsURL
-
-	// Kubernetes version (with a short timeout).
-	k8sVer, err := withTimeout(5*time.Second, func() (string, error) {
-		return kube.GetServerVersion(ctx)
-	})
-	if err != nil {
-		s.Error = fmt.Sprintf("cluster unreachable: %v", err)
-		return s
-	}
-	s.K8sVersion = k8sVer
-
-	// Karpenter via helm.
-	info, err := helm.DetectKarpenter(ctx)
-	if err != nil {
-		s.Error = fmt.Sprintf("helm error: %v", err)
-		return s
-	}
-	s.KarpenterInstalled = info.Installed
-	if info.Installed {
-		s.KarpenterVersion = strings.TrimPrefix(info.Version, "v")
-		s.KarpenterNamespace = info.Namespace
-		s.KarpenterRelease = info.ReleaseName
-		if s.KarpenterRelease == "" {
-			s.KarpenterRelease = "karpenter"
-		}
-	}
-
-	// Compatibility + upgrade check (AWS only for now).
-	if provider == kube.ProviderAWS {
-		// Minimum compatible version from the embedded matrix (no network).
-		s.MinCompatible = compat.MinCompatibleKarpenter(k8sVer)
-
-		// Latest compatible version from GitHub (one network call per cluster).
-		latest, _, _ := compat.LatestCompatible(k8sVer)
-
-		if info.Installed {
-			installed := strings.TrimPrefix(info.Version, "v")
-			if installed != "" {
-				ok := compat.IsCompatible(installed, k8sVer)
-				s.Compatible = &ok
-				if latest != "" && installed != latest {
-					s.UpgradeAvailable = true
-				}
-			} else {
-				// Version unknown (installed outside Helm without a readable image tag).
-				// Always recommend upgrading — we cannot determine if it's current.
-				s.UpgradeAvailable = true
-			}
-			if latest != "" {
-				s.LatestCompatible = latest
-			}
-		} else {
-			// Not installed — surface the latest compatible version for the
-			// install button in the dashboard.
-			s.LatestCompatible = latest
+// demoGuard refuses demoBlocked endpoints while the dashboard shows fake clusters.
+func demoGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if demoBlocked[r.URL.Path] {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]any{"success": false, "error": "not available in demo mode"})
+			return
 		}
-	}
-
-	return s
-}
-
-// withTimeout runs fn in a goroutine and returns its result or an error if
-// the deadline is exceeded.
-func withTimeout(d time.Duration, fn func() (string, error)) (string, error) {
-	type res struct {
-		v   string
-		err error
-	}
-	ch := make(chan res, 1)
-	go func() {
-		v, err := fn()
-		ch <- res{v, err}
-	}()
-	select {
-	case r := <-ch:
-		return r.v, r.err
-	case <-time.After(d):
-		return "", fmt.Errorf("timeout after %s", d)
-	}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -1557,14 +1388,193 @@ func addonVPCID(region, clusterName string) string {
 	if region == "" || clusterName == "" {
 		return ""
 	}
-	out, err := exec.Command("aws", "eks", "describe-cluster",
-		"--region", region,
+	out, err := awscli.Text(region, "eks", "describe-cluster",
 		"--name", clusterName,
 		"--query", "cluster.resourcesVpcConfig.vpcId",
-		"--output", "text",
-	).Output()
+	)
 	if err != nil {
 		return ""
 	}
-	return strings.TrimSpace(string(out))
+	return out
+}
+
+// listNodePools reads NodePools and NodeClasses (falling back to the v1alpha5
+// Provisioner/AWSNodeTemplate kinds) for GET /api/nodepools and snapshots.
+func listNodePools(ctx context.Context, kubeCtxParam string) NodePoolListResponse {
+
+	// Inline types for k8s JSON parsing (mirrors tui/nodepools.go).
+	type k8sCond struct {
+		Type    string `json:"type"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	}
+	// k8sMeta covers NodePool (v1beta1) and EC2NodeClass (v1beta1).
+	type k8sMeta struct {
+		Metadata struct {
+			Name        string            `json:"name"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec struct {
+			Limits          map[string]string `json:"limits"`          // NodePool v1beta1
+			Role            string            `json:"role"`            // EC2NodeClass v1beta1
+			InstanceProfile string            `json:"instanceProfile"` // AWSNodeTemplate v1alpha1
+		} `json:"spec"`
+		Status struct {
+			Conditions []k8sCond `json:"conditions"`
+		} `json:"status"`
+	}
+	// provisionerMeta covers Provisioner (v1alpha5) with nested limits.
+	type provisionerMeta struct {
+		Metadata struct {
+			Name        string            `json:"name"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Spec struct {
+			Limits struct {
+				Resources map[string]string `json:"resources"`
+			} `json:"limits"`
+		} `json:"spec"`
+		Status struct {
+			Conditions []k8sCond `json:"conditions"`
+		} `json:"status"`
+	}
+	type k8sList struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	readyStatus := func(conds []k8sCond) (bool, string) {
+		for _, c := range conds {
+			if c.Type == "Ready" {
+				if c.Status == "True" {
+					return true, ""
+				}
+				msg := c.Reason
+				if c.Message != "" {
+					msg = c.Message
+				}
+				return false, msg
+			}
+		}
+		return false, ""
+	}
+	isCRDMissing := func(errStr string) bool {
+		return strings.Contains(errStr, "no matches for kind") ||
+			strings.Contains(errStr, "the server doesn't have a resource type")
+	}
+
+	resp := NodePoolListResponse{
+		NodePools:   []NodePoolDetail{},
+		NodeClasses: []NodeClassDetail{},
+	}
+
+	// ── NodePools (v1beta1, Karpenter ≥ v0.31) ────────────────────────
+	npArgs := []string{"get", "nodepools.karpenter.sh", "-o", "json"}
+	npOut, npErr := backend.Current().Kube.Kubectl(ctx, kubeCtxParam, npArgs...)
+	if npErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(npErr, &exitErr) {
+			errStr := strings.TrimSpace(string(exitErr.Stderr))
+			if errStr != "" && !isCRDMissing(errStr) {
+				resp.Error = errStr
+			}
+		}
+	} else {
+		var list k8sList
+		if json.Unmarshal(npOut, &list) == nil {
+			for _, raw := range list.Items {
+				var m k8sMeta
+				if json.Unmarshal(raw, &m) != nil {
+					continue
+				}
+				ready, msg := readyStatus(m.Status.Conditions)
+				resp.NodePools = append(resp.NodePools, NodePoolDetail{
+					Name:        m.Metadata.Name,
+					Mode:        m.Metadata.Annotations["karpx.io/generated-mode"],
+					Ready:       ready,
+					NotReadyMsg: msg,
+					CPULim:      m.Spec.Limits["cpu"],
+					MemLim:      m.Spec.Limits["memory"],
+				})
+			}
+		}
+	}
+
+	// ── Provisioners (v1alpha5, Karpenter < v0.31) — fallback ─────────
+	if len(resp.NodePools) == 0 && resp.Error == "" {
+		provArgs := []string{"get", "provisioners.karpenter.sh", "-o", "json"}
+		if provOut, provErr := backend.Current().Kube.Kubectl(ctx, kubeCtxParam, provArgs...); provErr == nil {
+			var list k8sList
+			if json.Unmarshal(provOut, &list) == nil {
+				for _, raw := range list.Items {
+					var m provisionerMeta
+					if json.Unmarshal(raw, &m) != nil {
+						continue
+					}
+					ready, msg := readyStatus(m.Status.Conditions)
+					resp.NodePools = append(resp.NodePools, NodePoolDetail{
+						Name:        m.Metadata.Name,
+						Mode:        m.Metadata.Annotations["karpx.io/generated-mode"],
+						Ready:       ready,
+						NotReadyMsg: msg,
+						CPULim:      m.Spec.Limits.Resources["cpu"],
+						MemLim:      m.Spec.Limits.Resources["memory"],
+					})
+				}
+			}
+		}
+	}
+
+	// ── EC2NodeClasses (v1beta1, Karpenter ≥ v0.31) ───────────────────
+	ncArgs := []string{"get", "ec2nodeclasses.karpenter.k8s.aws", "-o", "json"}
+	ncOut, ncErr := backend.Current().Kube.Kubectl(ctx, kubeCtxParam, ncArgs...)
+	if ncErr == nil {
+		var list k8sList
+		if json.Unmarshal(ncOut, &list) == nil {
+			for _, raw := range list.Items {
+				var m k8sMeta
+				if json.Unmarshal(raw, &m) != nil {
+					continue
+				}
+				ready, msg := readyStatus(m.Status.Conditions)
+				role := m.Spec.Role
+				if role == "" {
+					role = m.Spec.InstanceProfile
+				}
+				resp.NodeClasses = append(resp.NodeClasses, NodeClassDetail{
+					Name:        m.Metadata.Name,
+					Role:        role,
+					Ready:       ready,
+					NotReadyMsg: msg,
+				})
+			}
+		}
+	}
+
+	// ── AWSNodeTemplates (v1alpha1, Karpenter < v0.31) — fallback ─────
+	if len(resp.NodeClasses) == 0 {
+		antArgs := []string{"get", "awsnodetemplates.karpenter.k8s.aws", "-o", "json"}
+		if antOut, antErr := backend.Current().Kube.Kubectl(ctx, kubeCtxParam, antArgs...); antErr == nil {
+			var list k8sList
+			if json.Unmarshal(antOut, &list) == nil {
+				for _, raw := range list.Items {
+					var m k8sMeta
+					if json.Unmarshal(raw, &m) != nil {
+						continue
+					}
+					ready, msg := readyStatus(m.Status.Conditions)
+					role := m.Spec.InstanceProfile
+					if role == "" {
+						role = m.Spec.Role
+					}
+					resp.NodeClasses = append(resp.NodeClasses, NodeClassDetail{
+						Name:        m.Metadata.Name,
+						Role:        role,
+						Ready:       ready,
+						NotReadyMsg: msg,
+					})
+				}
+			}
+		}
+	}
+	return resp
 }
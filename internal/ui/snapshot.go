@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"context"
+	_ "embed"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/kemilad/karpx/internal/config"
+	"github.com/kemilad/karpx/internal/status"
+)
+
+//go:embed snapshot.html
+var snapshotHTML string
+
+var snapshotTmpl = template.Must(template.New("snapshot").Funcs(template.FuncMap{
+	"deref": func(b *bool) bool { return b != nil && *b },
+}).Parse(snapshotHTML))
+
+// ClusterPools is the NodePool summary of one cluster in a snapshot.
+type ClusterPools struct {
+	Context string `json:"context"`
+	NodePoolListResponse
+}
+
+// SnapshotData is everything a snapshot renders. It is also embedded in the
+// page as JSON so the file can be read by scripts as well as people.
+type SnapshotData struct {
+	Generated time.Time        `json:"generated"`
+	Version   string           `json:"karpx_version"`
+	Clusters  []status.Cluster `json:"clusters"`
+	Pools     []ClusterPools   `json:"node_pools"`
+}
+
+// Snapshot collects the same data the dashboard shows — /api/clusters plus
+// the NodePools of every cluster with Karpenter — and writes it to w as one
+// self-contained HTML page: styles and logo inline, no scripts to run, no
+// server needed. kubeCtx restricts it to one context; pass "" for all. sel
+// keeps only clusters whose config-file tags match.
+func Snapshot(w io.Writer, kubeCtx, version string, sel config.Selector) error {
+	var contexts []string
+	if kubeCtx != "" {
+		contexts = []string{kubeCtx}
+	} else {
+		contexts = status.Filter(status.AllContexts(), sel)
+	}
+
+	data := SnapshotData{
+		Generated: time.Now(),
+		Version:   version,
+		Clusters:  status.Check(contexts),
+	}
+	for _, c := range data.Clusters {
+		if c.Error != "" || !c.KarpenterInstalled {
+			continue
+		}
+		data.Pools = append(data.Pools, ClusterPools{
+			Context:              c.Context,
+			NodePoolListResponse: listNodePools(context.Background(), c.Context),
+		})
+	}
+
+	logo, err := staticFiles.ReadFile("static/karpx-logo.svg")
+	if err != nil {
+		return err
+	}
+	view := struct {
+		SnapshotData
+		Logo                                           template.HTML
+		Raw                                            SnapshotData
+		Installed, Upgrades, Incompatible, OutOfPolicy int
+	}{SnapshotData: data, Logo: template.HTML(logo), Raw: data}
+	for _, c := range data.Clusters {
+		if c.KarpenterInstalled {
+			view.Installed++
+		}
+		if c.UpgradeAvailable {
+			view.Upgrades++
+		}
+		if c.Compatible != nil && !*c.Compatible {
+			view.Incompatible++
+		}
+		if c.OutOfPolicy {
+			view.OutOfPolicy++
+		}
+	}
+	return snapshotTmpl.Execute(w, view)
+}
@@ -0,0 +1,56 @@
+package upgrade
+
+import "github.com/Masterminds/semver/v3"
+
+// ManualStep is a migration task the automated upgrade cannot do for the
+// user — it must be done by hand before (or while) crossing Version.
+type ManualStep struct {
+	Version string // first release that requires the step
+	Title   string
+	Detail  string
+}
+
+// manualSteps is the per-version knowledge base, taken from the upstream
+// upgrade guides, oldest first. Detail is printed as-is in the plan.
+var manualSteps = []ManualStep{
+	{"0.32.0", "Migrate to the v1beta1 APIs",
+		"Provisioner/AWSNodeTemplate/Machine become NodePool/EC2NodeClass/NodeClaim; convert them with `karpx convert` and roll nodes over before leaving v1alpha5"},
+	{"0.32.0", "Rename the do-not-evict annotation",
+		"karpenter.sh/do-not-evict and do-not-consolidate are replaced by karpenter.sh/do-not-disrupt on pods and nodes"},
+	{"0.33.0", "Move settings out of the karpenter-global-settings ConfigMap",
+		"the ConfigMap is no longer read; set the values under settings.* in the Helm chart and delete the ConfigMap"},
+	{"0.33.0", "Switch to the OCI chart",
+		"the charts.karpenter.sh repository is frozen; releases are published to oci://public.ecr.aws/karpenter/karpenter"},
+	{"0.34.0", "Take CRD ownership with the karpenter-crd chart",
+		"CRDs shipped in the main chart's crds/ directory are never upgraded by Helm; install karpenter-crd and adopt the existing CRDs (meta.helm.sh/release-name annotations)"},
+	{"1.0.0", "Update the controller IAM policy",
+		"v1 requires the new policy (instance profile management, ec2:DescribeImages scoping); apply it before the controller starts"},
+	{"1.0.0", "Enable the conversion webhooks",
+		"stay on a 0.37.x patch with webhooks enabled, let it store every NodePool/EC2NodeClass/NodeClaim as v1, then upgrade"},
+	{"1.1.0", "Remove the conversion webhooks",
+		"webhooks are gone from the chart; every object must already be stored as v1 and the validating/mutating webhook configurations deleted"},
+}
+
+// ManualSteps returns the steps that apply when upgrading from → to: every
+// entry with from < Version ≤ to, oldest first. An empty or unparseable from
+// includes every step up to to, since the starting point is unknown.
+func ManualSteps(from, to string) []ManualStep {
+	t, err := semver.NewVersion(to)
+	if err != nil {
+		return nil
+	}
+	f, _ := semver.NewVersion(from)
+
+	var out []ManualStep
+	for _, s := range manualSteps {
+		v := semver.MustParse(s.Version)
+		if v.GreaterThan(t) {
+			continue
+		}
+		if f != nil && !v.GreaterThan(f) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
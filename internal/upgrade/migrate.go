@@ -0,0 +1,241 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kemilad/karpx/internal/helm"
+)
+
+// DefaultNamespace is the dedicated namespace current guidance installs
+// Karpenter into, and the default target of MigrateNamespace.
+const DefaultNamespace = "karpenter"
+
+// MigrateParams holds all inputs for MigrateNamespace.
+type MigrateParams struct {
+	KubeCtx   string
+	Release   string        // Helm release (or karpx manifest install) to move
+	From, To  string        // namespaces
+	Version   string        // installed version, reinstalled unchanged
+	Manifests bool          // true when karpx installed plain manifests
+	CRDs      *helm.CRDInfo // a karpenter-crd release in From moves too
+}
+
+// MigrateNamespace moves a Karpenter install to another namespace without
+// touching NodePools, NodeClasses or the nodes they launched:
+//
+//  1. Protect the CRDs (helm.sh/resource-policy: keep) and point their Helm
+//     ownership at the new namespace, so the uninstall cannot delete them
+//     and the new release adopts them
+//  2. Scale the old controller to 0 — leader election is per namespace, so
+//     two controllers would both provision
+//  3. Remove the old release and install the same version with the same
+//     values in To (a manifest install is applied in To first, then the old
+//     objects are pruned)
+//  4. Verify the rollout
+//
+// Nothing provisions or consolidates between steps 2 and 4; running nodes
+// are unaffected. When step 3 fails before the old Helm release is removed,
+// the old controller is scaled back up; after that there is nothing to scale
+// and the error says how to reinstall it.
+func MigrateNamespace(p MigrateParams, report Reporter) error {
+	if p.From == p.To {
+		return fmt.Errorf("Karpenter is already in namespace %s", p.To)
+	}
+
+	// ── 1. CRDs ───────────────────────────────────────────────────────────
+	crdStep := "Protect CRDs"
+	report(Step{Name: crdStep, Detail: "helm.sh/resource-policy: keep"})
+	moveCRDRelease := p.CRDs != nil && p.CRDs.Release != "" && p.CRDs.Namespace == p.From
+	owner := ""
+	if moveCRDRelease {
+		owner = p.CRDs.Release
+	}
+	if err := protectCRDs(p.KubeCtx, owner, p.To); err != nil {
+		report(Step{Name: crdStep, Err: err.Error()})
+		return err
+	}
+	report(Step{Name: crdStep, Detail: "NodePools and NodeClaims survive the move", OK: true})
+
+	// ── 2. Stop the old controller ────────────────────────────────────────
+	replicas := currentReplicas(p.KubeCtx, p.From, p.Release)
+	stopStep := fmt.Sprintf("Scale %s/%s to 0", p.From, p.Release)
+	report(Step{Name: stopStep})
+	if err := scaleDeployment(p.KubeCtx, p.From, p.Release, 0); err != nil {
+		report(Step{Name: stopStep, Err: err.Error()})
+		return fmt.Errorf("scale down the old controller: %w", err)
+	}
+	report(Step{Name: stopStep, OK: true})
+	restore := func() { _ = scaleDeployment(p.KubeCtx, p.From, p.Release, max(replicas, 1)) }
+
+	// ── 3. Reinstall in the target namespace ──────────────────────────────
+	installStep := fmt.Sprintf("Install v%s in %s", p.Version, p.To)
+	report(Step{Name: installStep})
+	chart, err := helm.Pull(helm.KarpenterChart, p.Version, "")
+	if err != nil {
+		restore()
+		report(Step{Name: installStep, Err: err.Error()})
+		return err
+	}
+	defer chart.Close()
+	removed := false
+	if p.Manifests {
+		err = moveStatic(p, chart)
+	} else {
+		removed, err = moveRelease(p, chart.Path, moveCRDRelease)
+	}
+	if err != nil {
+		if !removed {
+			restore()
+		}
+		report(Step{Name: installStep, Err: err.Error()})
+		return err
+	}
+	report(Step{Name: installStep, OK: true})
+
+	// ── 4. Verify ─────────────────────────────────────────────────────────
+	rollStep := "Verify rollout"
+	report(Step{Name: rollStep, Detail: "kubectl rollout status (timeout 5m)"})
+	if err := waitRollout(p.KubeCtx, p.To, p.Release, 5*time.Minute); err != nil {
+		report(Step{Name: rollStep, Err: err.Error()})
+		return fmt.Errorf("rollout verification: %w", err)
+	}
+	report(Step{Name: rollStep, Detail: "controller running in " + p.To, OK: true})
+	return nil
+}
+
+// moveRelease uninstalls the release from p.From and installs it in p.To
+// with its deployed values. The CRD-only release moves the same way. removed
+// reports whether the old release was uninstalled, even when an error follows.
+func moveRelease(p MigrateParams, chartPath string, moveCRDRelease bool) (removed bool, err error) {
+	vals, err := deployedValues(p.KubeCtx, p.From, p.Release)
+	if err != nil {
+		return false, err
+	}
+	dir, err := os.MkdirTemp("", "karpx-migrate-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(dir)
+	valuesFile := filepath.Join(dir, "values.json")
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(valuesFile, data, 0600); err != nil {
+		return false, err
+	}
+
+	// Cluster-scoped objects (ClusterRoles, webhooks) share names across the
+	// two releases, so the old one goes first.
+	if err := helmRun(p.KubeCtx, "uninstall", p.Release, "--namespace", p.From, "--wait"); err != nil {
+		return false, fmt.Errorf("uninstall %s/%s: %w", p.From, p.Release, err)
+	}
+	if moveCRDRelease {
+		if err := helmRun(p.KubeCtx, "uninstall", p.CRDs.Release, "--namespace", p.From); err != nil {
+			return true, fmt.Errorf("uninstall %s/%s: %w", p.From, p.CRDs.Release, err)
+		}
+		version := p.CRDs.Version
+		if version == "" {
+			version = p.Version
+		}
+		crdChart, err := helm.Pull(helm.CRDChart, version, "")
+		if err != nil {
+			return true, err
+		}
+		defer crdChart.Close()
+		if err := helmRun(p.KubeCtx, "install", p.CRDs.Release, crdChart.Path, "--namespace", p.To, "--create-namespace"); err != nil {
+			return true, fmt.Errorf("install %s in %s: %w", p.CRDs.Release, p.To, err)
+		}
+	}
+	if err := helmRun(p.KubeCtx, "install", p.Release, chartPath,
+		"--namespace", p.To, "--create-namespace", "--skip-crds", "--values", valuesFile); err != nil {
+		return true, fmt.Errorf("install %s in %s — reinstall it in %s with the same values to roll back: %w", p.Release, p.To, p.From, err)
+	}
+	return true, nil
+}
+
+// moveStatic re-renders a karpx manifest install in p.To. Applying it prunes
+// the objects recorded in p.From; the old record is then deleted.
+func moveStatic(p MigrateParams, chart *helm.Pulled) error {
+	rel, err := helm.RecordedStatic(p.KubeCtx)
+	if err != nil {
+		return err
+	}
+	if rel == nil {
+		return fmt.Errorf("%s ConfigMap not found", helm.ReleaseConfigMap)
+	}
+	objs, err := helm.Render(chart.Path, rel.Name, p.To, rel.Set, rel.SetJSON)
+	if err != nil {
+		return err
+	}
+	rel.Namespace = p.To
+	if err := helm.ApplyStatic(p.KubeCtx, *rel, objs); err != nil {
+		return err
+	}
+	return kubectlRun(p.KubeCtx, "delete", "configmap", helm.ReleaseConfigMap, "-n", p.From, "--ignore-not-found")
+}
+
+// protectCRDs marks every Karpenter CRD to be kept by helm uninstall. When
+// owner is set, the CRDs' Helm ownership moves to that release in namespace
+// to, so reinstalling it adopts them instead of failing on existing objects.
+func protectCRDs(kubeCtx, owner, to string) error {
+	out, err := kubectlOutput(kubeCtx, "get", "crd", "-o", "name")
+	if err != nil {
+		return err
+	}
+	var crds []string
+	for _, name := range strings.Fields(out) {
+		if strings.Contains(name, "karpenter") {
+			crds = append(crds, name)
+		}
+	}
+	if len(crds) == 0 {
+		return nil
+	}
+	args := append([]string{"annotate", "--overwrite"}, crds...)
+	args = append(args, "helm.sh/resource-policy=keep")
+	if owner != "" {
+		args = append(args, "meta.helm.sh/release-name="+owner, "meta.helm.sh/release-namespace="+to)
+	}
+	return kubectlRun(kubeCtx, args...)
+}
+
+// ServiceAccountRole returns the IRSA role of the controller service account,
+// whose trust policy names the namespace and must be updated with it.
+func ServiceAccountRole(kubeCtx, namespace, name string) string {
+	out, _ := kubectlOutput(kubeCtx, "get", "serviceaccount", name, "-n", namespace,
+		"-o", `jsonpath={.metadata.annotations.eks\.amazonaws\.com/role-arn}`)
+	return strings.TrimSpace(out)
+}
+
+func helmRun(kubeCtx string, args ...string) error {
+	if kubeCtx != "" {
+		args = append(args, "--kube-context", kubeCtx)
+	}
+	if out, err := exec.Command("helm", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func kubectlRun(kubeCtx string, args ...string) error {
+	_, err := kubectlOutput(kubeCtx, args...)
+	return err
+}
+
+func kubectlOutput(kubeCtx string, args ...string) (string, error) {
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	out, err := exec.Command("kubectl", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("kubectl %s: %w\n%s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
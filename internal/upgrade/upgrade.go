@@ -1,11 +1,17 @@
 // Package upgrade implements zero-downtime Karpenter upgrades.
 //
 // For each minor-version hop the sequence is:
-//  1. Apply CRDs from the official Helm chart (helm show crds | kubectl apply --server-side)
+//  0. Pull the chart for the hop (the target chart is pulled and checked
+//     against --chart-digest before the first hop)
+//  1. Apply CRDs from the pulled chart (helm show crds | kubectl apply --server-side),
+//     or upgrade the karpenter-crd release to the same version when one owns them
 //  2. Scale the controller to ≥ 2 replicas and wait for the extra pod to be Ready
 //  3a. If Karpenter was installed via Helm: helm upgrade --reuse-values
-//  3b. If installed via raw manifests: kubectl set image (preserves all existing config)
+//  3b. If installed by karpx as manifests: re-render the chart with the
+//      recorded values and server-side apply it
+//  3c. If installed via other raw manifests: kubectl set image (preserves all existing config)
 //  4. kubectl rollout status (wait up to 5 minutes)
+//  5. Record the chart digest in the karpx-chart-provenance ConfigMap (Helm and karpx manifests)
 //
 // When upgrading across multiple minor versions the hop is split into one
 // step per minor (e.g. 1.0 → 1.1 → 1.2 → 1.3) as recommended by upstream.
@@ -16,6 +22,7 @@ package upgrade
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"sort"
@@ -24,6 +31,9 @@ import (
 	"time"
 
 	"github.com/Masterminds/semver/v3"
+
+	"github.com/kemilad/karpx/internal/helm"
+	"github.com/kemilad/karpx/internal/manifest"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -51,7 +61,11 @@ type Params struct {
 	Target         string   // desired version, bare semver e.g. "1.3.0"
 	AllVersions    []string // all stable releases (newest first) — used for path building
 	ReuseValues    bool
-	ViaHelm        bool // true when a Helm release manages this install
+	ViaHelm        bool          // true when a Helm release manages this install
+	Manifests      bool          // true when karpx installed plain manifests (install --manifests)
+	ExtraArgs      []string      // SetArgs for renamed values: appended to helm upgrade, or recorded for manifest installs
+	ChartDigest    string        // required sha256 digest of the target chart; "" = not pinned
+	CRDs           *helm.CRDInfo // installed CRDs; a karpenter-crd release is upgraded in lockstep
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -79,7 +93,12 @@ func Run(p Params, report Reporter) error {
 			Detail: "installed version could not be determined; performing direct upgrade to v" + p.Target,
 			OK:     true,
 		})
-		return runHop(p, p.Current, p.Target, report)
+		target, err := pullTarget(p, report)
+		if err != nil {
+			return err
+		}
+		defer target.Close()
+		return runHop(p, p.Current, p.Target, target, report)
 	}
 
 	path, err := BuildPath(p.Current, p.Target, p.AllVersions)
@@ -87,6 +106,14 @@ func Run(p Params, report Reporter) error {
 		return err
 	}
 
+	// A pinned target is verified before anything is applied: a mismatch
+	// must refuse the whole plan, not stop it after the intermediate hops.
+	target, err := pullTarget(p, report)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
 	if len(path) > 1 {
 		report(Step{
 			Name:   "Upgrade path",
@@ -101,7 +128,7 @@ func Run(p Params, report Reporter) error {
 	for _, to := range path {
 		hopParams := p
 		hopParams.Current = from
-		if err := runHop(hopParams, from, to, report); err != nil {
+		if err := runHop(hopParams, from, to, target, report); err != nil {
 			// Restore original replica count on failure so we don't leave
 			// the cluster in an unexpected HA state.
 			if origReplicas > 0 && origReplicas < 2 {
@@ -130,15 +157,64 @@ func Run(p Params, report Reporter) error {
 // Single-hop logic
 // ─────────────────────────────────────────────────────────────────────────────
 
-func runHop(p Params, from, to string, report Reporter) error {
+// pullTarget pulls the target chart and checks it against --chart-digest.
+func pullTarget(p Params, report Reporter) (*helm.Pulled, error) {
+	pullStep := fmt.Sprintf("Pull chart  v%s", p.Target)
+	report(Step{Name: pullStep})
+	chart, err := helm.Pull(helm.KarpenterChart, p.Target, p.ChartDigest)
+	if err != nil {
+		report(Step{Name: pullStep, Err: err.Error()})
+		return nil, err
+	}
+	detail := chart.Digest
+	if p.ChartDigest != "" {
+		detail += "  (matches --chart-digest)"
+	}
+	report(Step{Name: pullStep, Detail: detail, OK: true})
+	return chart, nil
+}
+
+// runHop upgrades from → to. target is the already verified target chart,
+// used for the hop to p.Target; intermediate hops pull their own chart.
+func runHop(p Params, from, to string, target *helm.Pulled, report Reporter) error {
+	// ── 0. Pull the chart ─────────────────────────────────────────────────
+	// Every later step uses this one archive, so the CRDs and the release
+	// come from the artifact whose digest was checked. Only the target is
+	// pinned: intermediate hops are not what was reviewed.
+	chart := target
+	if to != p.Target {
+		pullStep := fmt.Sprintf("Pull chart  v%s", to)
+		report(Step{Name: pullStep})
+		pulled, err := helm.Pull(helm.KarpenterChart, to, "")
+		if err != nil {
+			report(Step{Name: pullStep, Err: err.Error()})
+			return err
+		}
+		defer pulled.Close()
+		report(Step{Name: pullStep, Detail: pulled.Digest, OK: true})
+		chart = pulled
+	}
+
 	// ── 1. Apply CRDs ─────────────────────────────────────────────────────
-	crdStep := fmt.Sprintf("Apply CRDs  v%s", to)
-	report(Step{Name: crdStep, Detail: "helm show crds → kubectl apply --server-side"})
-	if err := applyCRDs(p.KubeCtx, to); err != nil {
-		report(Step{Name: crdStep, Err: err.Error()})
-		return fmt.Errorf("apply CRDs for v%s: %w", to, err)
+	if p.CRDs != nil && p.CRDs.Release != "" {
+		// Applying over a karpenter-crd release would leave the release
+		// at the old version, reporting a mismatch from then on.
+		crdStep := fmt.Sprintf("helm upgrade %s  v%s", p.CRDs.Release, to)
+		report(Step{Name: crdStep})
+		if err := SyncCRDs(p.KubeCtx, p.CRDs, to); err != nil {
+			report(Step{Name: crdStep, Err: err.Error()})
+			return fmt.Errorf("upgrade %s to v%s: %w", p.CRDs.Release, to, err)
+		}
+		report(Step{Name: crdStep, Detail: "CRDs updated", OK: true})
+	} else {
+		crdStep := fmt.Sprintf("Apply CRDs  v%s", to)
+		report(Step{Name: crdStep, Detail: "helm show crds → kubectl apply --server-side"})
+		if err := applyCRDs(p.KubeCtx, chart.Path, to); err != nil {
+			report(Step{Name: crdStep, Err: err.Error()})
+			return fmt.Errorf("apply CRDs for v%s: %w", to, err)
+		}
+		report(Step{Name: crdStep, Detail: "CRDs updated", OK: true})
 	}
-	report(Step{Name: crdStep, Detail: "CRDs updated", OK: true})
 
 	// ── 2. Scale to ≥ 2 replicas and wait for HA ──────────────────────────
 	origReplicas := currentReplicas(p.KubeCtx, p.Namespace, p.DeploymentName)
@@ -165,11 +241,19 @@ func runHop(p Params, from, to string, report Reporter) error {
 	if p.ViaHelm {
 		helmStep := fmt.Sprintf("helm upgrade  v%s → v%s", from, to)
 		report(Step{Name: helmStep})
-		if err := helmUpgrade(p.KubeCtx, p.Namespace, p.ReleaseName, to, p.ReuseValues); err != nil {
+		if err := helmUpgrade(p.KubeCtx, p.Namespace, p.ReleaseName, chart.Path, p.ReuseValues, p.ExtraArgs); err != nil {
 			report(Step{Name: helmStep, Err: err.Error()})
 			return fmt.Errorf("helm upgrade to v%s: %w", to, err)
 		}
 		report(Step{Name: helmStep, OK: true})
+	} else if p.Manifests {
+		applyStep := fmt.Sprintf("Apply manifests  v%s → v%s", from, to)
+		report(Step{Name: applyStep, Detail: "helm template → kubectl apply --server-side"})
+		if err := staticUpgrade(p.KubeCtx, chart, to, p.ExtraArgs); err != nil {
+			report(Step{Name: applyStep, Err: err.Error()})
+			return fmt.Errorf("manifest upgrade to v%s: %w", to, err)
+		}
+		report(Step{Name: applyStep, OK: true})
 	} else {
 		imgStep := fmt.Sprintf("Update image  v%s → v%s", from, to)
 		report(Step{Name: imgStep, Detail: "kubectl set image (preserves existing configuration)"})
@@ -189,6 +273,18 @@ func runHop(p Params, from, to string, report Reporter) error {
 	}
 	report(Step{Name: rollStep, Detail: "all pods healthy", OK: true})
 
+	// ── 5. Record provenance ──────────────────────────────────────────────
+	if (p.ViaHelm || p.Manifests) && chart.Digest != "" {
+		provStep := "Record chart digest"
+		prov := helm.Provenance{Chart: helm.KarpenterChart, Version: to, Digest: chart.Digest, DeployedAt: time.Now().UTC()}
+		if err := helm.RecordProvenance(p.KubeCtx, p.Namespace, prov); err != nil {
+			// The upgrade itself succeeded; only the audit record is missing.
+			report(Step{Name: provStep, Detail: fmt.Sprintf("skipped (%v)", err), OK: true})
+		} else {
+			report(Step{Name: provStep, Detail: helm.ProvenanceConfigMap, OK: true})
+		}
+	}
+
 	return nil
 }
 
@@ -196,20 +292,60 @@ func runHop(p Params, from, to string, report Reporter) error {
 // Helpers
 // ─────────────────────────────────────────────────────────────────────────────
 
-func applyCRDs(kubeCtx, version string) error {
-	ver := strings.TrimPrefix(version, "v")
+// SyncCRDs brings the CRDs to version: the karpenter-crd release is
+// upgraded when one owns them, otherwise the CRDs of the controller chart
+// are applied.
+func SyncCRDs(kubeCtx string, crd *helm.CRDInfo, version string) error {
+	if crd != nil && crd.Release != "" {
+		chart, err := helm.Pull(helm.CRDChart, version, "")
+		if err != nil {
+			return err
+		}
+		defer chart.Close()
+		return helm.UpgradeCRDChart(kubeCtx, crd, chart.Path)
+	}
+	chart, err := helm.Pull(helm.KarpenterChart, version, "")
+	if err != nil {
+		return err
+	}
+	defer chart.Close()
+	return applyCRDs(kubeCtx, chart.Path, version)
+}
 
-	// Pull CRDs directly from the official Helm chart — no GitHub URL dependency.
-	crdOut, err := exec.Command("helm", "show", "crds",
-		"oci://public.ecr.aws/karpenter/karpenter",
-		"--version", ver,
-	).Output()
+// applyCRDs applies the CRDs shipped in the chart archive at chartPath,
+// annotated with the version they belong to.
+func applyCRDs(kubeCtx, chartPath, version string) error {
+	// CRDs come from the official Helm chart — no GitHub URL dependency.
+	crdOut, err := exec.Command("helm", "show", "crds", chartPath).Output()
 	if err != nil {
 		return fmt.Errorf("helm show crds: %w", err)
 	}
-	if len(bytes.TrimSpace(crdOut)) == 0 {
+	crds, err := manifest.Decode(crdOut)
+	if err != nil {
+		return fmt.Errorf("parse chart CRDs: %w", err)
+	}
+	if len(crds) == 0 {
 		return nil // nothing to apply
 	}
+	items := make([]any, len(crds))
+	for i, o := range crds {
+		meta, _ := o["metadata"].(map[string]any)
+		if meta == nil {
+			meta = map[string]any{}
+			o["metadata"] = meta
+		}
+		annotations, _ := meta["annotations"].(map[string]any)
+		if annotations == nil {
+			annotations = map[string]any{}
+			meta["annotations"] = annotations
+		}
+		annotations[helm.CRDVersionAnnotation] = version
+		items[i] = o
+	}
+	body, err := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return err
+	}
 
 	// --server-side + --force-conflicts handles CRD field ownership cleanly.
 	args := []string{"apply", "-f", "-", "--server-side", "--force-conflicts"}
@@ -217,7 +353,7 @@ func applyCRDs(kubeCtx, version string) error {
 		args = append(args, "--context", kubeCtx)
 	}
 	cmd := exec.Command("kubectl", args...)
-	cmd.Stdin = bytes.NewReader(crdOut)
+	cmd.Stdin = bytes.NewReader(body)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(out)))
@@ -225,18 +361,18 @@ func applyCRDs(kubeCtx, version string) error {
 	return nil
 }
 
-// helmUpgrade upgrades an existing Helm-managed Karpenter release.
-func helmUpgrade(kubeCtx, namespace, release, version string, reuseVals bool) error {
-	ver := strings.TrimPrefix(version, "v")
+// helmUpgrade upgrades an existing Helm-managed Karpenter release to the
+// chart archive at chartPath.
+func helmUpgrade(kubeCtx, namespace, release, chartPath string, reuseVals bool, extra []string) error {
 	args := []string{
 		"upgrade", release,
-		"oci://public.ecr.aws/karpenter/karpenter",
-		"--version", ver,
+		chartPath,
 		"--namespace", namespace,
 	}
 	if reuseVals {
 		args = append(args, "--reuse-values")
 	}
+	args = append(args, extra...)
 	if kubeCtx != "" {
 		args = append(args, "--kube-context", kubeCtx)
 	}
@@ -247,12 +383,33 @@ func helmUpgrade(kubeCtx, namespace, release, version string, reuseVals bool) er
 	return nil
 }
 
+// staticUpgrade re-renders a karpx manifest install at version with the
+// values it was installed with plus setArgs (renamed values carried over),
+// applies the result and records the migrated values.
+func staticUpgrade(kubeCtx string, chart *helm.Pulled, version string, setArgs []string) error {
+	rel, err := helm.RecordedStatic(kubeCtx)
+	if err != nil {
+		return err
+	}
+	if rel == nil {
+		return fmt.Errorf("%s ConfigMap not found", helm.ReleaseConfigMap)
+	}
+	MigrateStatic(rel, setArgs)
+	objs, err := helm.Render(chart.Path, rel.Name, rel.Namespace, rel.Set, rel.SetJSON)
+	if err != nil {
+		return err
+	}
+	rel.Version = version
+	rel.Digest = chart.Digest
+	return helm.ApplyStatic(kubeCtx, *rel, objs)
+}
+
 // imageUpgrade updates the Karpenter controller image for manifest-installed
 // (non-Helm) clusters. It uses kubectl set image so all existing Deployment
 // settings (env vars, IRSA annotations, resource limits, etc.) are preserved.
 func imageUpgrade(kubeCtx, namespace, deploymentName, version string) error {
 	ver := strings.TrimPrefix(version, "v")
-	image := fmt.Sprintf("public.ecr.aws/karpenter/controller:v%s", ver)
+	image := fmt.Sprintf("%s:v%s", helm.ControllerImage(), ver)
 	args := []string{
 		"set", "image",
 		"-n", namespace,
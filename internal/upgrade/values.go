@@ -0,0 +1,271 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kemilad/karpx/internal/helm"
+)
+
+// ValueChange is a deployed Helm value that the target chart no longer reads.
+// With --reuse-values Helm keeps passing it, the chart ignores it, and the
+// setting is silently lost.
+type ValueChange struct {
+	Key    string // dotted path in the deployed values
+	Kind   string // "renamed" | "removed" | "unknown"
+	To     string // new key when renamed
+	Value  any    // deployed value
+	Advice string
+}
+
+// valueRenames are chart keys known to have moved between Karpenter releases.
+// An empty target means the setting was dropped; Advice says where it went.
+var valueRenames = map[string]struct{ to, advice string }{
+	"settings.aws.clusterName":                {"settings.clusterName", ""},
+	"settings.aws.clusterEndpoint":            {"settings.clusterEndpoint", ""},
+	"settings.aws.interruptionQueueName":      {"settings.interruptionQueue", ""},
+	"settings.aws.isolatedVPC":                {"settings.isolatedVPC", ""},
+	"settings.aws.vmMemoryOverheadPercent":    {"settings.vmMemoryOverheadPercent", ""},
+	"settings.aws.reservedENIs":               {"settings.reservedENIs", ""},
+	"settings.aws.defaultInstanceProfile":     {"", "set spec.role (or spec.instanceProfile) on the EC2NodeClass instead"},
+	"settings.aws.enablePodENI":               {"", "pod ENI support is always on; remove the value"},
+	"settings.aws.enableENILimitedPodDensity": {"", "set spec.kubelet.maxPods on the EC2NodeClass instead"},
+	"settings.aws.tags":                       {"", "set spec.tags on the EC2NodeClass instead"},
+	"settings.aws.nodeNameConvention":         {"", "node names now always follow the EC2 private DNS name"},
+	"settings.aws.assumeRoleARN":              {"", "use IRSA or Pod Identity on the controller service account"},
+	"settings.aws.assumeRoleDuration":         {"", "use IRSA or Pod Identity on the controller service account"},
+	"settings.featureGates.drift":             {"", "drift is always enabled since v1"},
+}
+
+// CheckValues compares the values deployed for release with the default
+// values of the installed (from) and target (to) charts, and returns every
+// deployed key the target chart does not read. from may be "" when the
+// installed version is unknown; keys are then reported as "unknown" only.
+func CheckValues(kubeCtx, namespace, release, from, to string) ([]ValueChange, error) {
+	deployed, err := deployedValues(kubeCtx, namespace, release)
+	if err != nil {
+		return nil, err
+	}
+	return checkDeployed(deployed, from, to)
+}
+
+// CheckStaticValues is CheckValues for a manifest install: the deployed
+// values are the --set and --set-json values recorded for it.
+func CheckStaticValues(rel *helm.StaticRelease, from, to string) ([]ValueChange, error) {
+	return checkDeployed(staticValues(rel), from, to)
+}
+
+func checkDeployed(deployed map[string]any, from, to string) ([]ValueChange, error) {
+	if len(deployed) == 0 {
+		return nil, nil
+	}
+	target, err := chartValues(to)
+	if err != nil {
+		return nil, err
+	}
+	var previous map[string]any
+	if from != "" {
+		if previous, err = chartValues(from); err != nil {
+			return nil, err
+		}
+	}
+	return diffValues(deployed, previous, target), nil
+}
+
+// SetArgs returns helm --set-json flags that carry renamed values over to
+// their new keys.
+func SetArgs(changes []ValueChange) []string {
+	var args []string
+	for _, c := range changes {
+		if c.Kind != "renamed" || c.To == "" {
+			continue
+		}
+		v, err := json.Marshal(c.Value)
+		if err != nil {
+			continue
+		}
+		args = append(args, "--set-json", c.To+"="+string(v))
+	}
+	return args
+}
+
+// MigrateStatic applies SetArgs output to a manifest install record: each
+// --set-json value replaces any earlier one for its key, and the old key of
+// a known rename is dropped so the next values check does not report it.
+func MigrateStatic(rel *helm.StaticRelease, setArgs []string) {
+	moved := map[string]bool{}
+	for i := 0; i+1 < len(setArgs); i += 2 {
+		if setArgs[i] != "--set-json" {
+			continue
+		}
+		key, _, _ := strings.Cut(setArgs[i+1], "=")
+		moved[key] = true
+		rel.SetJSON = append(withoutKey(rel.SetJSON, key), setArgs[i+1])
+	}
+	for from, r := range valueRenames {
+		if r.to != "" && moved[r.to] {
+			rel.Set = withoutKey(rel.Set, from)
+			rel.SetJSON = withoutKey(rel.SetJSON, from)
+		}
+	}
+}
+
+// withoutKey returns the key=value entries whose key is not key.
+func withoutKey(entries []string, key string) []string {
+	var out []string
+	for _, e := range entries {
+		if k, _, _ := strings.Cut(e, "="); k != key {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// staticValues returns a manifest install's values as a flat map of dotted
+// keys, which leaves walks like a nested one.
+func staticValues(rel *helm.StaticRelease) map[string]any {
+	vals := map[string]any{}
+	for _, e := range rel.Set {
+		if k, v, ok := strings.Cut(e, "="); ok {
+			vals[k] = v
+		}
+	}
+	for _, e := range rel.SetJSON {
+		if k, v, ok := strings.Cut(e, "="); ok {
+			var val any
+			if json.Unmarshal([]byte(v), &val) != nil {
+				val = v
+			}
+			vals[k] = val
+		}
+	}
+	return vals
+}
+
+func diffValues(deployed, previous, target map[string]any) []ValueChange {
+	var out []ValueChange
+	for key, val := range leaves(deployed, "") {
+		if r, ok := valueRenames[key]; ok && (r.to != "" || r.advice != "") {
+			if !readsKey(target, key) {
+				kind := "removed"
+				if r.to != "" {
+					kind = "renamed"
+				}
+				out = append(out, ValueChange{Key: key, Kind: kind, To: r.to, Value: val, Advice: r.advice})
+				continue
+			}
+		}
+		if readsKey(target, key) {
+			continue
+		}
+		c := ValueChange{Key: key, Kind: "unknown", Value: val,
+			Advice: "not a value of the target chart — it has no effect"}
+		if previous != nil && readsKey(previous, key) {
+			c.Kind = "removed"
+			c.Advice = "read by the installed chart but not by the target — check the release notes"
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return kindOrder(out[i].Kind) < kindOrder(out[j].Kind)
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+func kindOrder(k string) int {
+	switch k {
+	case "renamed":
+		return 0
+	case "removed":
+		return 1
+	}
+	return 2
+}
+
+// readsKey reports whether the chart defaults accept the dotted key: every
+// segment exists, or the walk reaches an empty map (free-form, e.g.
+// podAnnotations) or a non-map value the user is overriding wholesale.
+func readsKey(defaults map[string]any, key string) bool {
+	cur := defaults
+	for _, seg := range strings.Split(key, ".") {
+		if len(cur) == 0 {
+			return true
+		}
+		v, ok := cur[seg]
+		if !ok {
+			return false
+		}
+		m, isMap := v.(map[string]any)
+		if !isMap {
+			return true
+		}
+		cur = m
+	}
+	return true
+}
+
+// leaves flattens nested maps to dotted keys. Lists are kept as values.
+func leaves(m map[string]any, prefix string) map[string]any {
+	out := map[string]any{}
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]any); ok && len(sub) > 0 {
+			for sk, sv := range leaves(sub, key) {
+				out[sk] = sv
+			}
+			continue
+		}
+		out[key] = v
+	}
+	return out
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helm
+// ─────────────────────────────────────────────────────────────────────────────
+
+// deployedValues returns the user-supplied values of a release (not the
+// computed ones, which would include every chart default).
+func deployedValues(kubeCtx, namespace, release string) (map[string]any, error) {
+	args := []string{"get", "values", release, "--namespace", namespace, "--output", "json"}
+	if kubeCtx != "" {
+		args = append(args, "--kube-context", kubeCtx)
+	}
+	out, err := exec.Command("helm", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm get values: %w", err)
+	}
+	var vals map[string]any
+	if err := json.Unmarshal(out, &vals); err != nil {
+		return nil, fmt.Errorf("parse deployed values: %w", err)
+	}
+	return vals, nil
+}
+
+// chartValues returns the default values.yaml of a Karpenter chart version.
+func chartValues(version string) (map[string]any, error) {
+	ver := strings.TrimPrefix(version, "v")
+	out, err := exec.Command("helm", "show", "values",
+		helm.KarpenterChart,
+		"--version", ver,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm show values %s: %w", ver, err)
+	}
+	var vals map[string]any
+	if err := yaml.Unmarshal(out, &vals); err != nil {
+		return nil, fmt.Errorf("parse chart values %s: %w", ver, err)
+	}
+	return vals, nil
+}
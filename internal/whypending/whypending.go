@@ -0,0 +1,466 @@
+// Package whypending explains why a pod is unschedulable on a Karpenter
+// cluster, in plain language and NodePool by NodePool:
+//
+//	taints          the NodePool taints nodes the pod does not tolerate
+//	requirements    nodeSelector / required node affinity conflicts with
+//	                the NodePool labels and requirements
+//	limits          launching a node for the pod would exceed spec.limits
+//	instance-types  no instance type the NodePool and pod both allow has
+//	                room for the pod's requests (AWS, needs a region)
+//
+// Capacity errors from recent NodeClaims and the scheduler's and
+// Karpenter's events on the pod are reported alongside.
+package whypending
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kemilad/karpx/internal/explain"
+	"github.com/kemilad/karpx/internal/kube"
+	"github.com/kemilad/karpx/internal/manifest"
+)
+
+// recentFailures is how far back NodeClaim launch failures are reported.
+const recentFailures = time.Hour
+
+// Reason is one thing that keeps a NodePool from launching a node for the pod.
+type Reason struct {
+	Check   string `json:"check"` // taints | requirements | limits | instance-types
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// PoolVerdict is the outcome for one NodePool.
+type PoolVerdict struct {
+	NodePool string   `json:"nodepool"`
+	Reasons  []Reason `json:"reasons,omitempty"`
+}
+
+// Compatible reports whether nothing was found against the NodePool.
+func (v PoolVerdict) Compatible() bool { return len(v.Reasons) == 0 }
+
+// Diagnosis is everything found about one pod.
+type Diagnosis struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Phase     string `json:"phase"`
+	Node      string `json:"node,omitempty"` // set when the pod is already scheduled
+	CPUm      int64  `json:"cpu_millicores"`
+	MemMiB    int64  `json:"memory_mib"`
+	GPUs      int64  `json:"gpus,omitempty"`
+	// Events are the pod's scheduling events, newest first.
+	Events []string `json:"events,omitempty"`
+	// CapacityErrors are NodeClaims that failed to launch in the last hour.
+	CapacityErrors []string      `json:"capacity_errors,omitempty"`
+	NodePools      []PoolVerdict `json:"nodepools"`
+	// InstanceTypesChecked is false when no region was given or the
+	// instance type catalog could not be read.
+	InstanceTypesChecked bool `json:"instance_types_checked"`
+}
+
+// Pending reports whether the pod is still waiting for a node.
+func (d *Diagnosis) Pending() bool { return d.Node == "" && d.Phase == string(corev1.PodPending) }
+
+// Params holds all inputs for Diagnose.
+type Params struct {
+	KubeCtx   string
+	Namespace string
+	Pod       string
+	// Region enables the instance type check (AWS); "" skips it.
+	Region string
+}
+
+// Diagnose reads the pod, its events, the NodePools and recent NodeClaims,
+// and checks the pod against every NodePool.
+func Diagnose(p Params) (*Diagnosis, error) {
+	cs, err := clientset(p.KubeCtx)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	pod, err := cs.CoreV1().Pods(p.Namespace).Get(ctx, p.Pod, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get pod %s/%s: %w", p.Namespace, p.Pod, err)
+	}
+	d := &Diagnosis{Namespace: pod.Namespace, Pod: pod.Name, Phase: string(pod.Status.Phase), Node: pod.Spec.NodeName}
+	d.CPUm, d.MemMiB, d.GPUs = requests(&pod.Spec)
+	d.Events = podEvents(ctx, cs, pod)
+	if !d.Pending() {
+		return d, nil
+	}
+
+	pools, err := manifest.ListCluster(p.KubeCtx, "nodepools.karpenter.sh", false)
+	if err != nil {
+		return nil, err
+	}
+	d.CapacityErrors = capacityErrors(p.KubeCtx)
+
+	var types []explain.InstanceType
+	if p.Region != "" {
+		if types, err = explain.Catalog(p.Region); err == nil {
+			d.InstanceTypesChecked = true
+		}
+	}
+	dsCPUm, dsMemMiB := daemonSetOverhead(ctx, cs)
+
+	for _, np := range pools {
+		v := PoolVerdict{NodePool: np.Name()}
+		add := func(check, msg, fix string) {
+			v.Reasons = append(v.Reasons, Reason{Check: check, Message: msg, Fix: fix})
+		}
+		for _, t := range untolerated(np, pod.Spec.Tolerations) {
+			add("taints", "nodes are tainted "+t+", which the pod does not tolerate",
+				"add a toleration for "+t+" to the pod, or use a NodePool without the taint")
+		}
+		podReqs, conflicts := matchAffinity(np, &pod.Spec)
+		for _, c := range conflicts {
+			add("requirements", c, "align the pod's nodeSelector / node affinity with the NodePool requirements")
+		}
+		if msg := limitReached(np, d.CPUm, d.MemMiB); msg != "" {
+			add("limits", msg, "raise spec.limits or free capacity in the NodePool")
+		}
+		if d.InstanceTypesChecked && len(conflicts) == 0 {
+			reqs := append(explain.Requirements(np), podReqs...)
+			if msg, fix := instanceFit(explain.Match(reqs, types, nil), d, dsCPUm, dsMemMiB); msg != "" {
+				add("instance-types", msg, fix)
+			}
+		}
+		d.NodePools = append(d.NodePools, v)
+	}
+	return d, nil
+}
+
+// requests sums the pod's container requests; an init container asking for
+// more than all containers together sets the floor, as in the scheduler.
+func requests(spec *corev1.PodSpec) (cpuM, memMiB, gpus int64) {
+	for _, c := range spec.Containers {
+		cpuM += c.Resources.Requests.Cpu().MilliValue()
+		memMiB += c.Resources.Requests.Memory().Value() / (1024 * 1024)
+		gpus += gpuCount(c.Resources)
+	}
+	for _, c := range spec.InitContainers {
+		cpuM = max(cpuM, c.Resources.Requests.Cpu().MilliValue())
+		memMiB = max(memMiB, c.Resources.Requests.Memory().Value()/(1024*1024))
+		gpus = max(gpus, gpuCount(c.Resources))
+	}
+	return cpuM, memMiB, gpus
+}
+
+func gpuCount(r corev1.ResourceRequirements) int64 {
+	var n int64
+	for _, name := range []corev1.ResourceName{"nvidia.com/gpu", "amd.com/gpu"} {
+		if q, ok := r.Limits[name]; ok {
+			n += q.Value()
+		} else if q, ok := r.Requests[name]; ok {
+			n += q.Value()
+		}
+	}
+	return n
+}
+
+// untolerated returns the NoSchedule / NoExecute taints of the NodePool
+// template the pod does not tolerate. Startup taints are removed before pods
+// schedule, so they do not count.
+func untolerated(np manifest.Object, tolerations []corev1.Toleration) []string {
+	var out []string
+	for _, raw := range np.Slice("spec", "template", "spec", "taints") {
+		m, _ := raw.(map[string]any)
+		t := corev1.Taint{}
+		t.Key, _ = m["key"].(string)
+		t.Value, _ = m["value"].(string)
+		effect, _ := m["effect"].(string)
+		t.Effect = corev1.TaintEffect(effect)
+		if t.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for i := range tolerations {
+			if tolerations[i].ToleratesTaint(&t) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			out = append(out, t.ToString())
+		}
+	}
+	return out
+}
+
+// matchAffinity checks the pod's nodeSelector and required node affinity
+// against the labels nodes of the NodePool would carry. It returns the pod
+// constraints as requirements (for the instance type check) and a sentence
+// per conflict. Affinity terms are alternatives: the first term without
+// conflicts is used, otherwise the conflicts of the first term are reported.
+func matchAffinity(np manifest.Object, spec *corev1.PodSpec) ([]explain.Requirement, []string) {
+	var base []explain.Requirement
+	keys := make([]string, 0, len(spec.NodeSelector))
+	for k := range spec.NodeSelector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		base = append(base, explain.Requirement{Key: k, Operator: "In", Values: []string{spec.NodeSelector[k]}})
+	}
+
+	var terms [][]explain.Requirement
+	if a := spec.Affinity; a != nil && a.NodeAffinity != nil && a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		for _, t := range a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			var reqs []explain.Requirement
+			for _, e := range t.MatchExpressions {
+				reqs = append(reqs, explain.Requirement{Key: e.Key, Operator: string(e.Operator), Values: e.Values})
+			}
+			terms = append(terms, reqs)
+		}
+	}
+	if len(terms) == 0 {
+		terms = [][]explain.Requirement{nil}
+	}
+
+	labels := np.Map("spec", "template", "metadata", "labels")
+	poolReqs := explain.Requirements(np)
+	var first []string
+	for i, t := range terms {
+		reqs := append(append([]explain.Requirement{}, base...), t...)
+		var conflicts []string
+		for _, r := range reqs {
+			if c := conflict(np.Name(), r, labels, poolReqs); c != "" {
+				conflicts = append(conflicts, c)
+			}
+		}
+		if len(conflicts) == 0 {
+			return reqs, nil
+		}
+		if i == 0 {
+			first = conflicts
+		}
+	}
+	if len(terms) > 1 {
+		first = append(first, fmt.Sprintf("none of the %d node affinity terms match either", len(terms)-1))
+	}
+	return nil, first
+}
+
+// wellKnown labels are set on every Karpenter node from its instance type,
+// zone and capacity type, so a pod may require them without the NodePool
+// mentioning them.
+var wellKnown = map[string]bool{
+	"kubernetes.io/hostname":        true,
+	"topology.kubernetes.io/region": true,
+}
+
+// conflict explains why nodes of the NodePool can never satisfy r, or
+// returns "".
+func conflict(pool string, r explain.Requirement, labels map[string]any, poolReqs []explain.Requirement) string {
+	if r.Key == "karpenter.sh/nodepool" {
+		if !r.Allows(pool, true) {
+			return fmt.Sprintf("the pod requires %s, which excludes this NodePool", r)
+		}
+		return ""
+	}
+	if v, ok := labels[r.Key].(string); ok {
+		if !r.Allows(v, true) {
+			return fmt.Sprintf("the pod requires %s, but nodes are labelled %s=%s", r, r.Key, v)
+		}
+		return ""
+	}
+	var values []string
+	constrained := false
+	for _, pr := range poolReqs {
+		if pr.Key != r.Key {
+			continue
+		}
+		constrained = true
+		if pr.Operator == "In" {
+			values = pr.Values
+		}
+	}
+	switch {
+	case len(values) > 0:
+		for _, v := range values {
+			if r.Allows(v, true) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("the pod requires %s, but the NodePool only allows %s", r, strings.Join(values, ","))
+	case constrained:
+		if r.Operator == "DoesNotExist" {
+			return fmt.Sprintf("the pod requires %s to be unset, but the NodePool sets it", r.Key)
+		}
+		return "" // NotIn / Exists / Gt / Lt on both sides: assume they overlap
+	case explain.Supported(r.Key) || wellKnown[r.Key]:
+		return "" // derived from the instance type; the instance type check decides
+	case r.Operator == "NotIn" || r.Operator == "DoesNotExist":
+		return ""
+	}
+	return fmt.Sprintf("the pod requires %s, but the NodePool does not set label %s", r, r.Key)
+}
+
+// limitReached describes how adding the pod would exceed spec.limits, or
+// returns "". Karpenter counts the capacity of nodes it launched, so this is
+// a lower bound: the node launched would be larger than the pod.
+func limitReached(np manifest.Object, cpuM, memMiB int64) string {
+	limits := np.Map("spec", "limits")
+	used := np.Map("status", "resources")
+	var out []string
+	check := func(name string, need int64, unit func(resource.Quantity) int64, format func(int64) string) {
+		raw, ok := limits[name]
+		if !ok {
+			return
+		}
+		limit, err := resource.ParseQuantity(fmt.Sprint(raw))
+		if err != nil {
+			return
+		}
+		var have int64
+		if u, ok := used[name]; ok {
+			if q, err := resource.ParseQuantity(fmt.Sprint(u)); err == nil {
+				have = unit(q)
+			}
+		}
+		if have+need > unit(limit) {
+			out = append(out, fmt.Sprintf("%s %s of %s in use", name, format(have), format(unit(limit))))
+		}
+	}
+	milli := func(q resource.Quantity) int64 { return q.MilliValue() }
+	mib := func(q resource.Quantity) int64 { return q.Value() / (1024 * 1024) }
+	check("cpu", cpuM, milli, func(v int64) string { return fmt.Sprintf("%.1f", float64(v)/1000) })
+	check("memory", memMiB, mib, func(v int64) string { return fmt.Sprintf("%.1fGi", float64(v)/1024) })
+	if len(out) == 0 {
+		return ""
+	}
+	return "limits reached — " + strings.Join(out, ", ") + "; no room for another node"
+}
+
+// instanceFit explains why none of the candidate instance types can hold
+// the pod next to the DaemonSets, or returns "".
+func instanceFit(candidates []explain.InstanceType, d *Diagnosis, dsCPUm, dsMemMiB int64) (msg, fix string) {
+	if len(candidates) == 0 {
+		return "no instance type offered in the region satisfies both the NodePool requirements and the pod's node selectors",
+			"run `karpx explain <nodepool>` to see what the NodePool allows"
+	}
+	var largest explain.InstanceType
+	var bestCPU, bestMem int64
+	for _, it := range candidates {
+		if d.GPUs > 0 && int64(it.GPUs) < d.GPUs {
+			continue
+		}
+		cpuM, memMiB := kube.Allocatable(it.VCPU, int64(it.MemoryGiB*1024))
+		cpuM, memMiB = cpuM-dsCPUm, memMiB-dsMemMiB
+		if cpuM >= d.CPUm && memMiB >= d.MemMiB {
+			return "", ""
+		}
+		if cpuM > bestCPU || (cpuM == bestCPU && memMiB > bestMem) {
+			largest, bestCPU, bestMem = it, cpuM, memMiB
+		}
+	}
+	if largest.InstanceType == "" {
+		return fmt.Sprintf("the pod requests %d GPU(s), but no allowed instance type has that many", d.GPUs),
+			"allow a GPU instance family (e.g. instance-category In g,p) in the NodePool"
+	}
+	return fmt.Sprintf("instance types too small — the largest allowed, %s, has %.1f vCPU / %.1f GiB free after kube-reserved and DaemonSets; the pod requests %.1f vCPU / %.1f GiB",
+			largest.InstanceType, float64(bestCPU)/1000, float64(bestMem)/1024, float64(d.CPUm)/1000, float64(d.MemMiB)/1024),
+		"allow larger sizes (instance-cpu / instance-size requirements) or lower the pod's requests"
+}
+
+// daemonSetOverhead sums the requests of one pod of every DaemonSet, which
+// each new node has to fit before the pending pod.
+func daemonSetOverhead(ctx context.Context, cs *kubernetes.Clientset) (cpuM, memMiB int64) {
+	list, err := cs.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, 0
+	}
+	for _, ds := range list.Items {
+		c, m, _ := requests(&ds.Spec.Template.Spec)
+		cpuM += c
+		memMiB += m
+	}
+	return cpuM, memMiB
+}
+
+// podEvents returns the scheduler's and Karpenter's events on the pod,
+// newest first.
+func podEvents(ctx context.Context, cs *kubernetes.Clientset, pod *corev1.Pod) []string {
+	list, err := cs.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod,involvedObject.name=" + pod.Name,
+	})
+	if err != nil {
+		return nil
+	}
+	events := list.Items
+	sort.Slice(events, func(i, j int) bool { return lastSeen(events[i]).After(lastSeen(events[j])) })
+	var out []string
+	seen := map[string]bool{}
+	for _, e := range events {
+		if e.Type != corev1.EventTypeWarning && e.Reason != "Nominated" {
+			continue
+		}
+		msg := e.Reason + ": " + strings.TrimSpace(e.Message)
+		if !seen[msg] {
+			seen[msg] = true
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func lastSeen(e corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+// capacityErrors lists NodeClaims created in the last hour whose launch
+// failed, e.g. InsufficientInstanceCapacity or an unfulfillable Spot request.
+func capacityErrors(kubeCtx string) []string {
+	claims, err := manifest.ListCluster(kubeCtx, "nodeclaims.karpenter.sh", false)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, nc := range claims {
+		created, err := time.Parse(time.RFC3339, nc.String("metadata", "creationTimestamp"))
+		if err != nil || time.Since(created) > recentFailures {
+			continue
+		}
+		for _, raw := range nc.Slice("status", "conditions") {
+			c, _ := raw.(map[string]any)
+			if c["type"] != "Launched" || c["status"] != "False" {
+				continue
+			}
+			msg, _ := c["message"].(string)
+			reason, _ := c["reason"].(string)
+			pool := nc.String("metadata", "labels", "karpenter.sh/nodepool")
+			out = append(out, fmt.Sprintf("%s (NodePool %s): %s %s", nc.Name(), pool, reason, strings.TrimSpace(msg)))
+		}
+	}
+	return out
+}
+
+func clientset(kubeCtx string) (*kubernetes.Clientset, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeCtx != "" {
+		overrides.CurrentContext = kubeCtx
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), overrides,
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	return kubernetes.NewForConfig(restCfg)
+}
@@ -1,31 +1,73 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/kemilad/karpx/internal/addons"
+	"github.com/kemilad/karpx/internal/amidrift"
+	"github.com/kemilad/karpx/internal/awscli"
+	"github.com/kemilad/karpx/internal/azure"
+	"github.com/kemilad/karpx/internal/backend"
+	"github.com/kemilad/karpx/internal/benchmark"
+	"github.com/kemilad/karpx/internal/cleanup"
 	"github.com/kemilad/karpx/internal/compat"
+	"github.com/kemilad/karpx/internal/config"
+	"github.com/kemilad/karpx/internal/convert"
+	"github.com/kemilad/karpx/internal/demo"
+	"github.com/kemilad/karpx/internal/discover"
+	"github.com/kemilad/karpx/internal/doctor"
+	"github.com/kemilad/karpx/internal/explain"
+	"github.com/kemilad/karpx/internal/featuregates"
+	"github.com/kemilad/karpx/internal/fleet"
+	"github.com/kemilad/karpx/internal/gcp"
 	"github.com/kemilad/karpx/internal/helm"
+	"github.com/kemilad/karpx/internal/hooks"
+	"github.com/kemilad/karpx/internal/iampolicy"
 	"github.com/kemilad/karpx/internal/kube"
+	"github.com/kemilad/karpx/internal/lint"
+	"github.com/kemilad/karpx/internal/manifest"
 	"github.com/kemilad/karpx/internal/nodes"
+	"github.com/kemilad/karpx/internal/outbound"
+	"github.com/kemilad/karpx/internal/pause"
+	"github.com/kemilad/karpx/internal/plugin"
+	"github.com/kemilad/karpx/internal/preflight"
+	"github.com/kemilad/karpx/internal/pricing"
+	"github.com/kemilad/karpx/internal/progress"
+	"github.com/kemilad/karpx/internal/prompt"
+	"github.com/kemilad/karpx/internal/redact"
+	"github.com/kemilad/karpx/internal/report"
+	"github.com/kemilad/karpx/internal/savings"
+	"github.com/kemilad/karpx/internal/showback"
+	"github.com/kemilad/karpx/internal/status"
 	"github.com/kemilad/karpx/internal/tui"
+	"github.com/kemilad/karpx/internal/tuning"
 	"github.com/kemilad/karpx/internal/ui"
 	karpupgrade "github.com/kemilad/karpx/internal/upgrade"
+	"github.com/kemilad/karpx/internal/whypending"
 )
 
 var version = "dev"
 
+// restoreStreams undoes --redact's stdout/stderr filtering and flushes it.
+var restoreStreams = func() {}
+
 const banner = `
   ██╗  ██╗ █████╗ ██████╗ ██████╗ ██╗  ██╗
   ██║ ██╔╝██╔══██╗██╔══██╗██╔══██╗╚██╗██╔╝
@@ -40,8 +82,13 @@ func main() {
 	debug.SetMemoryLimit(128 << 20)
 	debug.SetGCPercent(200)
 
-	if err := rootCmd().Execute(); err != nil {
+	err := rootCmd().Execute()
+	progress.Finish(err)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+	}
+	restoreStreams()
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -49,6 +96,9 @@ func main() {
 func rootCmd() *cobra.Command {
 	var kubeCtx string
 	var region  string
+	var awsProfile, registryMirror, caBundle string
+	var assumeYes, noInput, redactOut, demoMode bool
+	var progressFmt string
 
 	root := &cobra.Command{
 		Use:   "karpx",
@@ -68,9 +118,71 @@ func rootCmd() *cobra.Command {
     karpx                                  open TUI (current context)
     karpx --context staging                target a specific cluster
     karpx --context prod --region us-east-1
+    karpx --demo                           explore the TUI with fake clusters
+    karpx ui --demo                        the web dashboard with fake clusters
 
   Run 'karpx <command> --help' for non-interactive usage.
 `,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			prompt.SetAssumeYes(assumeYes)
+			prompt.SetNoInput(noInput)
+			if err := progress.SetFormat(progressFmt); err != nil {
+				return err
+			}
+			// Demo clusters exist only behind the dashboards and detect;
+			// everything else would reach for a real cluster.
+			if demoMode {
+				switch cmd.Name() {
+				case "karpx", "ui", "detect", "version":
+				default:
+					return fmt.Errorf("--demo works with the TUI, `karpx ui` and `karpx detect`, not `%s`", cmd.CommandPath())
+				}
+				backend.Use(demo.Clients())
+			}
+			// The TUI draws to a terminal and cannot be filtered line by line.
+			if redactOut && cmd.HasParent() {
+				redact.Enable(status.AllContexts())
+				restoreStreams = redact.Install()
+				progress.SetOutput(os.Stderr)
+			}
+			if err := configureOutbound(caBundle); err != nil {
+				return err
+			}
+			awscli.SetProfile(awsProfile)
+			helm.SetRegistry(registryMirror)
+			// Plugins register their providers before anything detects one.
+			// Broken plugins are reported by `karpx plugins`, not here.
+			plugin.Load()
+			// Re-authentication needs the terminal: not from the TUI, and not
+			// from dashboard requests served in the background.
+			if cmd.HasParent() && cmd.Name() != "ui" {
+				awscli.Reauth = func(profile string) bool {
+					if !prompt.Interactive() {
+						return false
+					}
+					label := "the default profile"
+					if profile != "" {
+						label = "profile " + profile
+					}
+					// Stdout may be carrying JSON or a manifest.
+					w := prompt.Output()
+					defer prompt.SetOutput(w)
+					prompt.SetOutput(os.Stderr)
+					fmt.Fprintf(os.Stderr, "\n  ⚠  The AWS SSO session for %s has expired.\n", label)
+					if !confirmDefaultPrompt("  Run `aws sso login` now? [Y/n] ") {
+						return false
+					}
+					if err := awscli.Login(); err != nil {
+						fmt.Fprintf(os.Stderr, "  ✗ aws sso login failed: %v\n", err)
+						return false
+					}
+					fmt.Fprintln(os.Stderr)
+					return true
+				}
+			}
+			progress.Begin(cmd.CommandPath())
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runTUI(kubeCtx, region)
 		},
@@ -78,12 +190,41 @@ func rootCmd() *cobra.Command {
 
 	root.PersistentFlags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context (default: current context)")
 	root.PersistentFlags().StringVarP(&region,  "region",  "r", "", "AWS region (default: from AWS config)")
+	root.PersistentFlags().StringVar(&awsProfile, "profile", "", "AWS CLI profile for every AWS call, including SSO profiles (default: AWS_PROFILE)")
+	root.PersistentFlags().StringVar(&registryMirror, "registry-mirror", "", "registry holding a copy of public.ecr.aws/karpenter charts and images, e.g. a private ECR in aws-cn")
+	root.PersistentFlags().StringVar(&caBundle, "ca-bundle", "", "PEM file of extra CAs to trust, e.g. a TLS-inspecting proxy's root (default: $KARPX_CA_BUNDLE, then config network.caBundle)")
+	root.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to every confirmation (needed when stdin is not a terminal)")
+	root.PersistentFlags().BoolVar(&noInput, "no-input", false, "never prompt; use flags and defaults only (automatic when stdin is not a terminal)")
+	root.PersistentFlags().StringVar(&progressFmt, "progress", "text", "progress output: text | json (NDJSON events on stderr)")
+	root.PersistentFlags().BoolVar(&redactOut, "redact", false, "mask account IDs, ARNs, cluster endpoints and context names in output, reports and snapshots")
+	root.PersistentFlags().BoolVar(&demoMode, "demo", false, "show fake clusters with realistic data instead of the kubeconfig (TUI, ui, and detect with --all or -o json, for every cluster or one picked with -c)")
 	root.SilenceUsage = true
 
-	root.AddCommand(detectCmd(), installCmd(), upgradeCmd(), uninstallCmd(), nodePoolsCmd(), nodesCmd(), uiCmd(), versionCmd(), addonsCmd())
+	root.AddCommand(detectCmd(), discoverCmd(), installCmd(), upgradeCmd(), fleetCmd(), preflightCmd(), lintCmd(), convertCmd(), iamPolicyCmd(), pluginsCmd(), uninstallCmd(), migrateNamespaceCmd(), pauseCmd(), resumeCmd(), cleanupCmd(), driftCmd(), benchmarkCmd(), nodePoolsCmd(), nodesCmd(), explainCmd(), whyPendingCmd(), pricingCmd(), savingsCmd(), showbackCmd(), auditCmd(), reportCmd(), tuneCmd(), featureGatesCmd(), doctorCmd(), imagesCmd(), uiCmd(), versionCmd(), addonsCmd())
 	return root
 }
 
+// configureOutbound applies the proxy and CA settings before anything
+// connects. --ca-bundle wins over $KARPX_CA_BUNDLE, which wins over the
+// config file; HTTPS_PROXY and NO_PROXY in the environment win over the file.
+func configureOutbound(caBundle string) error {
+	var nw config.Network
+	if cfg, err := config.Load(); err == nil {
+		nw = cfg.Network
+	}
+	if caBundle == "" {
+		caBundle = os.Getenv("KARPX_CA_BUNDLE")
+	}
+	if caBundle == "" {
+		caBundle = nw.CABundle
+	}
+	return outbound.Configure(outbound.Settings{
+		HTTPSProxy: nw.HTTPSProxy,
+		NoProxy:    nw.NoProxy,
+		CABundle:   caBundle,
+	})
+}
+
 func runTUI(kubeCtx, region string) error {
 	m := tui.NewModel(tui.Config{KubeContext: kubeCtx, Region: region})
 	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
@@ -96,20 +237,159 @@ func runTUI(kubeCtx, region string) error {
 // ─────────────────────────────────────────────────────────────────────────────
 
 func detectCmd() *cobra.Command {
-	var kubeCtx string
+	var (
+		kubeCtx   string
+		all       bool
+		output    string
+		tags      []string
+		targetK8s string
+	)
 	cmd := &cobra.Command{
-		Use:     "detect",
-		Short:   "Check cloud provider, Karpenter installation, and version compatibility",
-		Example: "  karpx detect\n  karpx detect -c my-cluster",
+		Use:   "detect",
+		Short: "Check cloud provider, Karpenter installation, and version compatibility",
+		Long: `Check cloud provider, Karpenter installation, and version compatibility.
+
+With --all every context in the kubeconfig is checked concurrently and the
+results are printed as one table (or JSON with --output json) — the same data
+the web dashboard shows, for cron jobs and reports. --tag narrows --all to
+the contexts whose tags in the karpx config match.
+
+On EKS the VPC CNI, CoreDNS and kube-proxy versions are checked against the
+Kubernetes and Karpenter versions, the CNI mode (prefix delegation, IPv6) and
+the instance families in use or recommended by karpx nodes. Pass
+--target-kubernetes to also check them against the version you are about to
+upgrade the control plane to.`,
+		Example: "  karpx detect\n  karpx detect -c my-cluster\n  karpx detect -c my-cluster --target-kubernetes 1.33\n  karpx detect --all\n  karpx detect --all --output json\n  karpx detect --all --tag team=payments,environment=prod",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDetect(kubeCtx)
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			if len(tags) > 0 && !all {
+				return fmt.Errorf("--tag filters --all; use -c for a single context")
+			}
+			if all {
+				sel, err := config.ParseSelector(tags)
+				if err != nil {
+					return err
+				}
+				return runDetectAll(output, sel)
+			}
+			if output == "json" {
+				return printJSON(status.Inspect(kubeCtx))
+			}
+			// The full report reads CRDs, pause state and provenance that the
+			// demo clusters do not have.
+			if backend.Current().Demo {
+				return fmt.Errorf("with --demo, use karpx detect --all or karpx detect -o json")
+			}
+			return runDetect(kubeCtx, targetK8s)
 		},
 	}
 	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().BoolVar(&all, "all", false, "check every context in the kubeconfig concurrently")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "with --all, only contexts whose config-file tags match (key=value, key!=value, key)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	cmd.Flags().StringVar(&targetK8s, "target-kubernetes", "", "also check core add-ons against this Kubernetes version, e.g. 1.33 (EKS)")
 	return cmd
 }
 
-func runDetect(kubeCtx string) error {
+// runDetectAll checks every kubeconfig context and prints one row per cluster.
+func runDetectAll(output string, sel config.Selector) error {
+	contexts := status.AllContexts()
+	if len(contexts) == 0 {
+		return fmt.Errorf("no contexts found in kubeconfig")
+	}
+	if contexts = status.Filter(contexts, sel); len(contexts) == 0 {
+		return fmt.Errorf("no context matches --tag %s", sel)
+	}
+	return runDetectContexts(contexts, output)
+}
+
+// runDetectContexts checks the given contexts concurrently and prints one
+// row per cluster.
+func runDetectContexts(contexts []string, output string) error {
+	if output == "table" {
+		fmt.Printf("\n  Checking %d context(s)…\n\n", len(contexts))
+	}
+	results := status.Check(contexts)
+	if output == "json" {
+		return printJSON(results)
+	}
+
+	ctxW := len("CONTEXT")
+	for _, r := range results {
+		ctxW = max(ctxW, len(r.Context))
+	}
+	fmt.Printf("  %-*s  %-10s  %-8s  %-12s  %-8s  %-14s  %s\n",
+		ctxW, "CONTEXT", "PROVIDER", "K8S", "KARPENTER", "COMPAT", "LATEST", "POLICY")
+	var failed, upgrades, offPolicy, addonIssues int
+	for _, r := range results {
+		for _, i := range r.AddonIssues {
+			if i.Blocking {
+				addonIssues++
+				break
+			}
+		}
+		if r.Error != "" {
+			failed++
+			fmt.Printf("  %-*s  %-10s  ✗ %s\n", ctxW, r.Context, r.Provider, r.Error)
+			continue
+		}
+		karp := "—"
+		if r.KarpenterInstalled {
+			karp = "unknown"
+			if r.KarpenterVersion != "" {
+				karp = "v" + r.KarpenterVersion
+			}
+			if r.Managed != "" {
+				karp = "managed"
+			}
+		}
+		compatible := "—"
+		if r.Compatible != nil {
+			compatible = "✓"
+			if !*r.Compatible {
+				compatible = "✗"
+			}
+		}
+		latest := "—"
+		if r.LatestCompatible != "" {
+			latest = "v" + r.LatestCompatible
+			if r.UpgradeAvailable {
+				latest += "  ▲"
+				upgrades++
+			}
+		}
+		policy := "—"
+		if r.PinnedLine != "" {
+			policy = "✓ " + r.PinnedLine
+			if r.OutOfPolicy {
+				policy = "✗ " + r.PinnedLine
+				offPolicy++
+			}
+		}
+		fmt.Printf("  %-*s  %-10s  %-8s  %-12s  %-8s  %-14s  %s\n",
+			ctxW, r.Context, r.Provider, r.K8sVersion, karp, compatible, latest, policy)
+	}
+
+	fmt.Printf("\n  %d cluster(s)", len(results))
+	if upgrades > 0 {
+		fmt.Printf(" · %d with an upgrade available", upgrades)
+	}
+	if offPolicy > 0 {
+		fmt.Printf(" · %d out of policy", offPolicy)
+	}
+	if addonIssues > 0 {
+		fmt.Printf(" · %d with add-on issues (karpx detect -c <ctx>)", addonIssues)
+	}
+	if failed > 0 {
+		fmt.Printf(" · %d unreachable", failed)
+	}
+	fmt.Printf("\n\n")
+	return nil
+}
+
+func runDetect(kubeCtx, targetK8s string) error {
 	fmt.Printf("\n  Checking cluster %s…\n\n", contextOrCurrent(kubeCtx))
 
 	// ── Provider detection ────────────────────────────────────────────────
@@ -129,7 +409,7 @@ func runDetect(kubeCtx string) error {
 	}
 
 	if !provider.Supported() {
-		printUnsupportedProvider()
+		printUnsupportedProvider(os.Stdout)
 		return nil
 	}
 
@@ -148,6 +428,14 @@ func runDetect(kubeCtx string) error {
 		return err
 	}
 
+	if info.Managed != "" {
+		fmt.Printf("  Karpenter           : managed by %s\n", info.Managed)
+		fmt.Printf("\n  ℹ  The cloud provider installs and upgrades Karpenter on this cluster; karpx\n")
+		fmt.Printf("     will not install, upgrade, pause or remove it. NodePools stay viewable:\n")
+		fmt.Printf("  ► karpx nodepools -c %s\n\n", contextOrCurrent(kubeCtx))
+		return nil
+	}
+
 	if !info.Installed {
 		fmt.Printf("  Karpenter           : not installed\n")
 	} else {
@@ -156,7 +444,26 @@ func runDetect(kubeCtx string) error {
 		} else {
 			fmt.Printf("  Karpenter version   : unknown (installed outside Helm)\n")
 		}
-		fmt.Printf("  Namespace           : %s\n", info.Namespace)
+		if info.Namespace != "" && info.Namespace != karpupgrade.DefaultNamespace {
+			fmt.Printf("  Namespace           : %s  ⚠  legacy location — karpx migrate-namespace -c %s moves it to %s\n",
+				info.Namespace, contextOrCurrent(kubeCtx), karpupgrade.DefaultNamespace)
+		} else {
+			fmt.Printf("  Namespace           : %s\n", info.Namespace)
+		}
+		if info.Manifests {
+			fmt.Printf("  Install method      : karpx manifests (no Helm release, see %s)\n", helm.ReleaseConfigMap)
+		}
+		if st, err := pause.Status(kubeCtx, info.Namespace); err == nil && st != nil {
+			fmt.Printf("  Paused              : ⏸  %s mode since %s  (karpx resume to restore)\n",
+				st.Mode, st.PausedAt.Local().Format("2006-01-02 15:04"))
+		}
+		if prov, err := helm.RecordedProvenance(kubeCtx, info.Namespace); err == nil && prov != nil {
+			if strings.TrimPrefix(info.Version, "v") == strings.TrimPrefix(prov.Version, "v") {
+				fmt.Printf("  Chart digest        : %s\n", prov.Digest)
+			} else {
+				fmt.Printf("  Chart digest        : ⚠  karpx deployed v%s (%s); the release was changed outside karpx\n", prov.Version, prov.Digest)
+			}
+		}
 
 		// Compatibility is defined for AWS only (other providers have their own matrices).
 		if provider == kube.ProviderAWS && info.Version != "" {
@@ -168,6 +475,45 @@ func runDetect(kubeCtx string) error {
 		}
 	}
 
+	// ── CRDs ──────────────────────────────────────────────────────────────
+	if info.Installed {
+		if crds, err := helm.DetectCRDs(kubeCtx); err == nil && crds.Installed {
+			if msg := helm.CRDMismatch(crds, info.Version); msg != "" {
+				fmt.Printf("  CRDs                : ⚠  %s\n", msg)
+				fmt.Printf("    A partial upgrade leaves the controller on fields its CRDs do not have.\n")
+				if prompt.Interactive() && !prompt.AssumeYes() {
+					if err := syncCRDs(kubeCtx, crds, info.Version); err != nil {
+						return err
+					}
+				} else {
+					fmt.Printf("  ► karpx upgrade -c %s --crds-only\n", contextOrCurrent(kubeCtx))
+				}
+			} else if crds.Version != "" {
+				fmt.Printf("  CRDs                : ✓  v%s (%s)\n", crds.Version, crds.Source)
+			}
+		}
+	}
+
+	// ── Version policy from the config file ───────────────────────────────
+	var pinned string
+	if cfg, err := config.Load(); err == nil {
+		pinned = strings.TrimPrefix(cfg.Policy.Pin(kube.ContextName(kubeCtx)), "v")
+	}
+	if pinned != "" {
+		if installed := strings.TrimPrefix(info.Version, "v"); !info.Installed || installed == "" {
+			fmt.Printf("  Policy              : pinned to %s.x\n", pinned)
+		} else if compat.InLine(installed, pinned) {
+			fmt.Printf("  Policy              : ✓  on the pinned %s.x line\n", pinned)
+		} else {
+			fmt.Printf("  Policy              : ✗  out of policy — pinned to %s.x, running v%s\n", pinned, installed)
+		}
+	}
+
+	// ── Core add-ons ──────────────────────────────────────────────────────
+	if provider == kube.ProviderAWS {
+		printAddonChecks(kubeCtx, k8sVer, targetK8s, info.Version)
+	}
+
 	// ── Latest compatible version ─────────────────────────────────────────
 	if provider == kube.ProviderAWS {
 		fmt.Printf("\n  Fetching latest compatible version from GitHub…\n")
@@ -184,6 +530,13 @@ func runDetect(kubeCtx string) error {
 		if len(all) > 1 {
 			fmt.Printf("  All compatible      : %s\n", formatVersionList(all, 5))
 		}
+		// A pinned cluster only tracks its own line; newer lines are expected.
+		if pinned != "" {
+			if inLine := compat.LatestInLine(all, pinned); inLine != "" {
+				latest = inLine
+				fmt.Printf("  Latest in policy    : v%s\n", latest)
+			}
+		}
 
 		if !info.Installed {
 			fmt.Printf("\n  ► Run to install:\n")
@@ -198,6 +551,13 @@ func runDetect(kubeCtx string) error {
 		} else if !compat.IsCompatible(installed, k8sVer) {
 			fmt.Printf("\n  ✗ Installed Karpenter is incompatible — upgrade required.\n")
 			fmt.Printf("  ► karpx upgrade -c %s --version v%s\n\n", contextOrCurrent(kubeCtx), latest)
+		} else if pinned != "" && !compat.InLine(installed, pinned) {
+			fmt.Printf("\n  ✗ Out of policy — pinned to the %s.x line.\n", pinned)
+			if compat.Newer(latest, installed) {
+				fmt.Printf("  ► karpx upgrade -c %s --version v%s\n\n", contextOrCurrent(kubeCtx), latest)
+			} else {
+				fmt.Printf("    Running ahead of the pin — update the pin in %s if this is intended.\n\n", config.Path())
+			}
 		} else if installed != latest {
 			fmt.Printf("\n  ▲ Upgrade available: v%s → v%s\n", installed, latest)
 			fmt.Printf("  ► karpx upgrade -c %s --version v%s\n\n", contextOrCurrent(kubeCtx), latest)
@@ -213,12 +573,305 @@ func runDetect(kubeCtx string) error {
 	return nil
 }
 
+// syncCRDs offers to bring the CRDs to the controller version, upgrading the
+// karpenter-crd release when one owns them.
+func syncCRDs(kubeCtx string, crds *helm.CRDInfo, version string) error {
+	version = strings.TrimPrefix(version, "v")
+	if crds == nil || !crds.Installed {
+		return fmt.Errorf("no Karpenter CRDs found")
+	}
+	if version == "" {
+		return fmt.Errorf("the controller version is unknown — run karpx upgrade --version to set both")
+	}
+	what := "Apply the v" + version + " CRDs"
+	if crds.Release != "" {
+		what = fmt.Sprintf("Upgrade the %s release to v%s", crds.Release, version)
+	}
+	if !confirmPrompt(fmt.Sprintf("\n  %s? [y/N] ", what)) {
+		fmt.Printf("  Skipped.\n\n")
+		return nil
+	}
+	if err := karpupgrade.SyncCRDs(kubeCtx, crds, version); err != nil {
+		fmt.Printf("  ✗ %v\n\n", err)
+		return err
+	}
+	fmt.Printf("  ✓  CRDs are at v%s\n\n", version)
+	return nil
+}
+
+// printAddonChecks prints the core add-on versions and their known-bad
+// combinations with the current and target Kubernetes versions.
+func printAddonChecks(kubeCtx, k8sVer, targetK8s, karpVer string) {
+	a, issues, err := status.CheckAddons(kubeCtx, k8sVer, karpVer)
+	if err != nil {
+		fmt.Printf("  Core add-ons        : (could not read: %v)\n", err)
+		return
+	}
+	orNone := func(v string) string {
+		if v == "" {
+			return "not found"
+		}
+		return v
+	}
+	fmt.Printf("  Core add-ons        : vpc-cni %s · coredns %s · kube-proxy %s\n",
+		orNone(a.VPCCNI), orNone(a.CoreDNS), orNone(a.KubeProxy))
+	printIssues := func(issues []compat.AddonIssue) {
+		for _, i := range issues {
+			glyph := "⚠ "
+			if i.Blocking {
+				glyph = "✗ "
+			}
+			fmt.Printf("    %s %s %s %s", glyph, i.Addon, i.Version, i.Message)
+			if i.Required != "" {
+				fmt.Printf(" (needs ≥ %s)", i.Required)
+			}
+			fmt.Println()
+		}
+	}
+	printIssues(issues)
+	if len(issues) == 0 {
+		fmt.Printf("    ✓  no known bad combinations\n")
+	}
+	if targetK8s == "" {
+		return
+	}
+
+	// Only what the target adds: the family checks do not depend on it.
+	seen := map[string]bool{}
+	for _, i := range issues {
+		seen[i.Addon+i.Message] = true
+	}
+	var extra []compat.AddonIssue
+	for _, i := range compat.CheckAddons(status.AddonVersions(a), targetK8s, karpVer) {
+		if !seen[i.Addon+i.Message] {
+			extra = append(extra, i)
+		}
+	}
+	if len(extra) == 0 {
+		fmt.Printf("  Kubernetes %-9s: ✓  add-ons ready\n", targetK8s)
+		return
+	}
+	fmt.Printf("  Kubernetes %-9s: add-on updates needed for this version\n", targetK8s)
+	printIssues(extra)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// discover command — find clusters through the cloud APIs
+// ─────────────────────────────────────────────────────────────────────────────
+
+func discoverCmd() *cobra.Command {
+	var providerFlag, regions, accounts, output string
+	var updateKubeconfig bool
+	cmd := &cobra.Command{
+		Use:   "discover",
+		Short: "List EKS/AKS/GKE clusters from the cloud APIs and show which lack Karpenter",
+		Long: `List managed Kubernetes clusters straight from the cloud provider APIs,
+including clusters that are not in your kubeconfig yet.
+
+Clusters already in the kubeconfig are checked for Karpenter. With
+--update-kubeconfig the missing ones are added (aws eks update-kubeconfig,
+az aks get-credentials, gcloud container clusters get-credentials) so they
+show up in the TUI and web dashboard.
+
+Uses the aws, az and gcloud CLIs with your existing logins. Without
+--provider every provider whose CLI is installed is searched. AWS is searched
+in one account: the one of the current profile (AWS_PROFILE, or --profile);
+run once per profile to cover several accounts.
+
+With --output json the result is {"clusters": [...], "errors": [...]}, and
+--update-kubeconfig needs --yes: there is no prompt in JSON mode.`,
+		Example: "  karpx discover\n  karpx discover --provider aws --regions us-east-1,eu-west-1\n  karpx discover --provider gcp --accounts my-project --update-kubeconfig\n  karpx discover --output json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			if output == "json" && updateKubeconfig && !prompt.AssumeYes() {
+				return fmt.Errorf("--update-kubeconfig with --output json needs --yes: a prompt would corrupt the JSON")
+			}
+			return runDiscover(providerFlag, splitList(regions), splitList(accounts), updateKubeconfig, output)
+		},
+	}
+	cmd.Flags().StringVar(&providerFlag, "provider", "", "providers to search: aws, azure, gcp (comma-separated; default: all with a CLI installed)")
+	cmd.Flags().StringVar(&regions, "regions", "", "AWS regions to search (comma-separated; default: all enabled regions)")
+	cmd.Flags().StringVar(&accounts, "accounts", "", "Azure subscriptions / GCP projects to search (comma-separated; default: all visible)")
+	cmd.Flags().BoolVar(&updateKubeconfig, "update-kubeconfig", false, "add clusters missing from the kubeconfig")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	return cmd
+}
+
+// discoveredCluster is a discovered cluster plus its Karpenter status, when
+// it could be checked through the kubeconfig.
+type discoveredCluster struct {
+	discover.Cluster
+	KarpenterInstalled *bool  `json:"karpenter_installed,omitempty"`
+	KarpenterVersion   string `json:"karpenter_version,omitempty"`
+	Error              string `json:"error,omitempty"`
+}
+
+// discoverResult is the JSON output of karpx discover.
+type discoverResult struct {
+	Clusters []discoveredCluster `json:"clusters"`
+	Errors   []string            `json:"errors,omitempty"` // providers that could not be searched
+}
+
+func runDiscover(providerFlag string, regions, accounts []string, updateKubeconfig bool, output string) error {
+	var providers []kube.Provider
+	for _, name := range splitList(providerFlag) {
+		p := kube.ParseProvider(name)
+		if p == kube.ProviderUnknown {
+			return fmt.Errorf("unknown provider %q (want aws, azure or gcp)", name)
+		}
+		providers = append(providers, p)
+	}
+	if len(providers) == 0 {
+		if awscli.Available() {
+			providers = append(providers, kube.ProviderAWS)
+		}
+		if azure.Available() {
+			providers = append(providers, kube.ProviderAzure)
+		}
+		if gcp.Available() {
+			providers = append(providers, kube.ProviderGCP)
+		}
+		if len(providers) == 0 {
+			return fmt.Errorf("none of the aws, az or gcloud CLIs were found on PATH")
+		}
+	}
+
+	table := output == "table"
+	if table {
+		names := make([]string, len(providers))
+		for i, p := range providers {
+			names[i] = p.Meta().Label
+		}
+		fmt.Printf("\n  Searching %s…\n\n", strings.Join(names, ", "))
+	}
+	clusters, errs := discover.Find(discover.Params{Providers: providers, Regions: regions, Accounts: accounts})
+	if table {
+		for _, err := range errs {
+			fmt.Printf("  ⚠  %v\n", err)
+		}
+		if len(errs) > 0 {
+			fmt.Println()
+		}
+	}
+
+	// ── Add missing clusters to kubeconfig ────────────────────────────────
+	var missing []int
+	for i, c := range clusters {
+		if c.Context == "" {
+			missing = append(missing, i)
+		}
+	}
+	// JSON mode was checked for --yes up front; its progress goes to stderr.
+	if updateKubeconfig && len(missing) > 0 &&
+		(!table || confirmPrompt(fmt.Sprintf("  Add %d cluster(s) to your kubeconfig? [y/N] ", len(missing)))) {
+		w := io.Writer(os.Stdout)
+		if !table {
+			w = os.Stderr
+		}
+		for _, i := range missing {
+			ctx, err := discover.AddToKubeconfig(clusters[i])
+			if err != nil {
+				fmt.Fprintf(w, "  ✗ %s: %v\n", clusters[i].Name, err)
+				continue
+			}
+			clusters[i].Context = ctx
+			fmt.Fprintf(w, "  ✓  %s → context %s\n", clusters[i].Name, ctx)
+		}
+		fmt.Fprintln(w)
+	}
+
+	// ── Karpenter status for clusters reachable through kubeconfig ────────
+	var contexts []string
+	for _, c := range clusters {
+		if c.Context != "" {
+			contexts = append(contexts, c.Context)
+		}
+	}
+	byContext := map[string]status.Cluster{}
+	for _, st := range status.Check(contexts) {
+		byContext[st.Context] = st
+	}
+
+	results := make([]discoveredCluster, len(clusters))
+	for i, c := range clusters {
+		results[i] = discoveredCluster{Cluster: c}
+		if st, ok := byContext[c.Context]; ok {
+			if st.Error != "" {
+				results[i].Error = st.Error
+			} else {
+				installed := st.KarpenterInstalled
+				results[i].KarpenterInstalled = &installed
+				results[i].KarpenterVersion = st.KarpenterVersion
+			}
+		}
+	}
+	if !table {
+		res := discoverResult{Clusters: results}
+		for _, err := range errs {
+			res.Errors = append(res.Errors, err.Error())
+		}
+		return printJSON(res)
+	}
+	if len(results) == 0 {
+		fmt.Printf("  No clusters found.\n\n")
+		return nil
+	}
+
+	nameW, acctW := len("CLUSTER"), len("ACCOUNT")
+	for _, r := range results {
+		nameW = max(nameW, len(r.Name))
+		acctW = max(acctW, len(r.Account))
+	}
+	fmt.Printf("  %-8s  %-*s  %-16s  %-*s  %-6s  %-10s  %s\n",
+		"PROVIDER", acctW, "ACCOUNT", "LOCATION", nameW, "CLUSTER", "K8S", "KUBECONFIG", "KARPENTER")
+	var without, notInKubeconfig int
+	for _, r := range results {
+		inKubeconfig := "✓"
+		if r.Context == "" {
+			inKubeconfig = "—"
+			notInKubeconfig++
+		}
+		karp := "?"
+		switch {
+		case r.Error != "":
+			karp = "✗ unreachable"
+		case r.KarpenterInstalled == nil:
+		case *r.KarpenterInstalled && r.KarpenterVersion != "":
+			karp = "v" + r.KarpenterVersion
+		case *r.KarpenterInstalled:
+			karp = "installed"
+		default:
+			karp = "not installed"
+			without++
+		}
+		fmt.Printf("  %-8s  %-*s  %-16s  %-*s  %-6s  %-10s  %s\n",
+			r.Provider, acctW, r.Account, r.Location, nameW, r.Name, r.Version, inKubeconfig, karp)
+	}
+
+	fmt.Printf("\n  %d cluster(s)", len(results))
+	if without > 0 {
+		fmt.Printf(" · %d without Karpenter", without)
+	}
+	if notInKubeconfig > 0 {
+		fmt.Printf(" · %d not in kubeconfig", notInKubeconfig)
+	}
+	fmt.Printf("\n")
+	if notInKubeconfig > 0 && !updateKubeconfig {
+		fmt.Printf("  ► Add them with: karpx discover --update-kubeconfig\n")
+	}
+	fmt.Println()
+	return nil
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // install command — provider-aware with interactive questioning
 // ─────────────────────────────────────────────────────────────────────────────
 
 func installCmd() *cobra.Command {
-	var kubeCtx, clusterName, region, roleARN, karpVer, intQueue, providerFlag, namespace string
+	var kubeCtx, clusterName, region, roleARN, karpVer, intQueue, providerFlag, namespace, resourceGroup, project, chartDigest, export, exportFile, dryRunDir string
+	var selfHosted, manifests, dryRun bool
 	cmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install Karpenter — detects cloud provider and guides through setup",
@@ -231,6 +884,21 @@ Supported providers:
   AWS EKS   — full support    (--provider aws)
   Azure AKS — preview         (--provider azure)
   GCP GKE   — experimental    (--provider gcp)
+
+--manifests installs without a Helm release, for clusters that forbid them:
+the chart is rendered locally, server-side applied with field manager karpx
+and labelled app.kubernetes.io/managed-by=karpx, and the release is recorded
+in the karpx-release ConfigMap so detect, upgrade and uninstall still work.
+
+--export helmfile writes the release karpx worked out (chart, version and
+values) to a helmfile.yaml instead of installing it, for teams that deploy
+with helmfile. Nothing is changed on the cluster.
+
+--dry-run resolves the version and values as a real install would, renders
+the chart and the NodePool, prints them (or writes them to --dry-run-dir)
+and sends them to the API server as a server-side dry-run apply, so
+admission and validation errors surface in change review. Nothing is
+changed on the cluster.
 `,
 		Example: `  # Interactive (karpx asks questions):
   karpx install -c my-cluster
@@ -240,23 +908,60 @@ Supported providers:
     -c my-cluster \
     --cluster-name my-cluster \
     -r ap-southeast-1 \
-    --role-arn arn:aws:iam::123456789:role/KarpenterController`,
+    --role-arn arn:aws:iam::123456789:role/KarpenterController
+
+  # Azure AKS — enables Node Auto Provisioning when the cluster supports it:
+  karpx install --provider azure -c my-aks --cluster-name my-aks --resource-group my-rg
+
+  # GCP GKE — project, location and cluster are read from the gke_… context:
+  karpx install --provider gcp -c gke_my-project_us-central1_my-gke
+
+  # AWS EKS without a Helm release:
+  karpx install --provider aws -c my-cluster --manifests
+
+  # Write a helmfile.yaml for the release instead of installing it:
+  karpx install --provider aws -c my-cluster --export helmfile --export-file helmfile.yaml
+
+  # Review exactly what would be installed, without changing anything:
+  karpx install --provider aws -c my-cluster --dry-run --dry-run-dir ./review`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runInstall(kubeCtx, providerFlag, clusterName, region, roleARN, karpVer, intQueue, namespace)
+			if export != "" && export != "helmfile" {
+				return fmt.Errorf("--export must be helmfile, got %q", export)
+			}
+			if export != "" && manifests {
+				return fmt.Errorf("--export and --manifests cannot be combined")
+			}
+			if dryRunDir != "" {
+				dryRun = true
+			}
+			if export != "" && dryRun {
+				return fmt.Errorf("--export and --dry-run cannot be combined")
+			}
+			return runInstall(kubeCtx, providerFlag, clusterName, region, roleARN, karpVer, intQueue, namespace, resourceGroup, project, chartDigest, exportFile, dryRunDir, selfHosted, manifests, export != "", dryRun)
 		},
 	}
 	cmd.Flags().StringVarP(&kubeCtx,      "context",            "c", "", "kubeconfig context")
 	cmd.Flags().StringVar(&providerFlag,   "provider",               "", "cloud provider: aws | azure | gcp (default: auto-detect)")
-	cmd.Flags().StringVarP(&clusterName,  "cluster-name",       "n", "", "EKS cluster name (AWS only)")
-	cmd.Flags().StringVarP(&region,       "region",             "r", "", "AWS region (AWS only)")
+	cmd.Flags().StringVarP(&clusterName, "cluster-name", "n", "", "EKS / AKS / GKE cluster name")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region, or GKE cluster location")
 	cmd.Flags().StringVar(&roleARN,       "role-arn",               "", "Karpenter controller IAM role ARN (AWS only)")
 	cmd.Flags().StringVar(&karpVer,       "version",                "", "Karpenter version (default: latest compatible)")
 	cmd.Flags().StringVar(&intQueue,      "interruption-queue",     "", "SQS queue name for spot interruption (AWS, optional)")
+	cmd.Flags().StringVar(&chartDigest, "chart-digest", "", "require the Karpenter chart to have this sha256 digest (AWS only; use with --version)")
 	cmd.Flags().StringVarP(&namespace,    "namespace",          "N", "", "namespace to install Karpenter into (default: karpenter; created if missing)")
+	cmd.Flags().StringVar(&resourceGroup, "resource-group", "", "AKS resource group (Azure only; default: search the subscription)")
+	cmd.Flags().StringVar(&project, "project", "", "GCP project ID (GCP only; default: from the gke_… context)")
+	cmd.Flags().StringVar(&export, "export", "", "write the release in this format instead of installing: helmfile (AWS only)")
+	cmd.Flags().StringVar(&exportFile, "export-file", "helmfile.yaml", "file --export writes to")
+	cmd.Flags().BoolVar(&selfHosted, "self-hosted", false, "install the self-hosted provider chart instead of enabling Node Auto Provisioning (Azure only)")
+	cmd.Flags().BoolVar(&manifests, "manifests", false, "install as server-side applied manifests, without a Helm release (AWS only)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "render and server-side dry-run the install without changing anything (AWS only)")
+	cmd.Flags().StringVar(&dryRunDir, "dry-run-dir", "", "write the --dry-run manifests to this directory instead of printing them (implies --dry-run)")
 	return cmd
 }
 
-func runInstall(kubeCtx, providerFlag, clusterName, region, roleARN, karpVer, intQueue, namespace string) error {
+func runInstall(kubeCtx, providerFlag, clusterName, region, roleARN, karpVer, intQueue, namespace, resourceGroup, project, chartDigest, exportFile, dryRunDir string, selfHosted, manifests, export, dryRun bool) error {
+	progress.Expect(9)
 	printSection("Step 1: Detecting cloud provider")
 
 	// ── Resolve provider ──────────────────────────────────────────────────
@@ -271,16 +976,25 @@ func runInstall(kubeCtx, providerFlag, clusterName, region, roleARN, karpVer, in
 			fmt.Printf("  Detected: %s\n", provider.Meta().Label)
 		} else {
 			fmt.Printf("  Could not auto-detect provider.\n")
-			provider = askProviderMenu()
+			provider = askProviderMenu(os.Stdout)
 		}
 	}
 
 	meta := provider.Meta()
+	if manifests && provider != kube.ProviderAWS {
+		return fmt.Errorf("--manifests is only supported on AWS EKS")
+	}
+	if export && provider != kube.ProviderAWS {
+		return fmt.Errorf("--export is only supported on AWS EKS")
+	}
+	if dryRun && provider != kube.ProviderAWS {
+		return fmt.Errorf("--dry-run is only supported on AWS EKS")
+	}
 
 	// ── Handle unsupported providers ──────────────────────────────────────
 	if !provider.Supported() {
 		fmt.Println()
-		printUnsupportedProvider()
+		printUnsupportedProvider(os.Stdout)
 		return nil
 	}
 
@@ -297,10 +1011,30 @@ func runInstall(kubeCtx, providerFlag, clusterName, region, roleARN, karpVer, in
 		fmt.Printf("    Docs : %s\n", meta.DocsURL)
 	}
 
+	// Plugin providers supply detection, compatibility and manifests; their
+	// controller is installed the way the provider documents.
+	switch provider {
+	case kube.ProviderAWS, kube.ProviderAzure, kube.ProviderGCP:
+	default:
+		fmt.Printf("  ◌ %s — provided by a plugin; karpx does not install it.\n", meta.Label)
+		if meta.ChartRepo != "" {
+			fmt.Printf("    Chart: %s\n", meta.ChartRepo)
+		}
+		if meta.DocsURL != "" {
+			fmt.Printf("    Docs : %s\n", meta.DocsURL)
+		}
+		fmt.Println()
+		return nil
+	}
+
 	// ── Check if already installed ────────────────────────────────────────
 	fmt.Println()
 	printSection("Step 2: Checking existing installation")
 	existingInfo, _ := helm.DetectKarpenter(kubeCtx)
+	if err := existingInfo.ManagedError(); err != nil {
+		fmt.Printf("  ✗ %v\n\n", err)
+		return err
+	}
 	if existingInfo.Installed {
 		fmt.Printf("  Karpenter v%s is already installed in namespace %s.\n",
 			existingInfo.Version, existingInfo.Namespace)
@@ -323,40 +1057,70 @@ func runInstall(kubeCtx, providerFlag, clusterName, region, roleARN, karpVer, in
 	// ── Step 3: Installation namespace ───────────────────────────────────
 	fmt.Println()
 	printSection("Step 3: Installation namespace")
-	namespace = askIfEmpty(namespace, "Namespace to install Karpenter into", "karpenter")
+	namespace = askIfEmptyValid(namespace, "Namespace to install Karpenter into", "karpenter", validNamespace)
 	if namespace == "" {
 		namespace = "karpenter"
 	}
-	fmt.Printf("  Checking namespace %q…\n", namespace)
-	nsStatus, err := kube.EnsureNamespace(kubeCtx, namespace)
-	if err != nil {
-		return fmt.Errorf("namespace setup: %w", err)
-	}
-	if nsStatus == kube.NamespaceCreated {
-		fmt.Printf("  ✓  Namespace %q created.\n", namespace)
+	nsExists := true
+	if export {
+		// helmfile creates the namespace when it syncs the release.
+		fmt.Printf("  Namespace %q will be created by helmfile if missing.\n", namespace)
+	} else if dryRun {
+		var err error
+		if nsExists, err = kube.NamespaceExists(kubeCtx, namespace); err != nil {
+			return err
+		}
+		if nsExists {
+			fmt.Printf("  ✓  Namespace %q already exists.\n", namespace)
+		} else {
+			fmt.Printf("  Namespace %q would be created (dry run).\n", namespace)
+		}
 	} else {
-		fmt.Printf("  ✓  Namespace %q already exists.\n", namespace)
+		fmt.Printf("  Checking namespace %q…\n", namespace)
+		nsStatus, err := kube.EnsureNamespace(kubeCtx, namespace)
+		if err != nil {
+			return fmt.Errorf("namespace setup: %w", err)
+		}
+		if nsStatus == kube.NamespaceCreated {
+			fmt.Printf("  ✓  Namespace %q created.\n", namespace)
+		} else {
+			fmt.Printf("  ✓  Namespace %q already exists.\n", namespace)
+		}
 	}
 
 	// ── Provider-specific install flow ────────────────────────────────────
 	switch provider {
 	case kube.ProviderAWS:
-		return runInstallAWS(kubeCtx, namespace, clusterName, region, roleARN, karpVer, intQueue)
+		if dryRun {
+			return runInstallAWS(kubeCtx, namespace, clusterName, region, roleARN, karpVer, intQueue, chartDigest, exportFile, manifests, export, &installDryRun{Dir: dryRunDir, NamespaceExists: nsExists})
+		}
+		return runInstallAWS(kubeCtx, namespace, clusterName, region, roleARN, karpVer, intQueue, chartDigest, exportFile, manifests, export, nil)
 	case kube.ProviderAzure:
-		return runInstallAzure(kubeCtx, namespace, karpVer)
+		return runInstallAzure(kubeCtx, namespace, clusterName, resourceGroup, karpVer, selfHosted)
 	case kube.ProviderGCP:
-		return runInstallGCP(kubeCtx, namespace, karpVer)
+		return runInstallGCP(kubeCtx, namespace, clusterName, region, project, karpVer)
 	}
 	return nil
 }
 
+// installHookVars are the variables install hooks see besides the context.
+func installHookVars(provider kube.Provider, namespace, karpVer string) map[string]string {
+	return map[string]string{
+		"KARPX_PROVIDER":  string(provider),
+		"KARPX_NAMESPACE": namespace,
+		"KARPX_VERSION":   strings.TrimPrefix(karpVer, "v"), // "" = provider default
+	}
+}
+
 // ── AWS EKS install flow ──────────────────────────────────────────────────────
 
 // eksClusterNameFromContext extracts the short cluster name from an EKS
-// kubeconfig context, which is often a full ARN like:
-//   arn:aws:eks:us-east-1:123456789:cluster/my-cluster
+// kubeconfig context, which is often a full ARN in any partition like:
+//
+//	arn:aws:eks:us-east-1:123456789:cluster/my-cluster
+//	arn:aws-us-gov:eks:us-gov-west-1:123456789:cluster/my-cluster
 func eksClusterNameFromContext(ctx string) string {
-	if strings.HasPrefix(ctx, "arn:aws:eks:") {
+	if awscli.IsARN(ctx, "eks") {
 		if i := strings.LastIndex(ctx, "/"); i >= 0 {
 			return ctx[i+1:]
 		}
@@ -367,7 +1131,7 @@ func eksClusterNameFromContext(ctx string) string {
 // stripARN returns just the cluster name, stripping a full EKS ARN if the user
 // accidentally entered one instead of just the name.
 func stripARN(name string) string {
-	if strings.HasPrefix(name, "arn:aws:eks:") {
+	if awscli.IsARN(name, "eks") {
 		if i := strings.LastIndex(name, "/"); i >= 0 {
 			return name[i+1:]
 		}
@@ -375,7 +1139,7 @@ func stripARN(name string) string {
 	return name
 }
 
-func runInstallAWS(kubeCtx, namespace, clusterName, region, roleARN, karpVer, intQueue string) error {
+func runInstallAWS(kubeCtx, namespace, clusterName, region, roleARN, karpVer, intQueue, chartDigest, exportFile string, manifests, export bool, dryRun *installDryRun) error {
 	fmt.Println()
 	printSection("Step 3: Cluster information (AWS EKS)")
 
@@ -390,13 +1154,18 @@ func runInstallAWS(kubeCtx, namespace, clusterName, region, roleARN, karpVer, in
 	fmt.Println()
 	printSection("Step 4: IAM configuration")
 	fmt.Printf("  Karpenter needs an IAM role to manage EC2 instances.\n")
-	fmt.Printf("  Create one at: https://karpenter.sh/docs/getting-started/getting-started-with-karpenter/#create-the-karpentercontroller-iam-role\n\n")
+	fmt.Printf("  Generate its least-privilege policy with:\n")
+	fmt.Printf("    karpx iam-policy --cluster-name %s -r %s [--interruption-queue <queue>] [-o terraform]\n\n", clusterName, region)
 
-	roleARN = askIfEmpty(roleARN, "Karpenter controller IAM role ARN", "")
+	roleARN = askIfEmptyValid(roleARN, "Karpenter controller IAM role ARN", "", validRoleARN)
 	if roleARN == "" {
 		return fmt.Errorf("IAM role ARN is required for AWS EKS installation")
 	}
 
+	if err := checkPartition(region, roleARN); err != nil {
+		return err
+	}
+
 	intQueue = askIfEmpty(intQueue, "SQS interruption queue name (press Enter to skip)", "")
 
 	fmt.Println()
@@ -431,6 +1200,9 @@ func runInstallAWS(kubeCtx, namespace, clusterName, region, roleARN, karpVer, in
 	// ── Step 6: Workload analysis + node type recommendation ──────────────
 	fmt.Println()
 	rec := runNodeRecommendation(kubeCtx, kube.ProviderAWS)
+	if rec != nil && rec.GPU != nil {
+		checkGPUPool(os.Stdout, rec, kubeCtx, false)
+	}
 
 	// ── Summary + confirm ─────────────────────────────────────────────────
 	fmt.Println()
@@ -452,97 +1224,459 @@ func runInstallAWS(kubeCtx, namespace, clusterName, region, roleARN, karpVer, in
 	}
 	fmt.Println()
 
+	if export {
+		return exportHelmfile(kubeCtx, exportFile, helm.HelmfileRelease{
+			Name:      "karpenter",
+			Namespace: namespace,
+			Chart:     helm.KarpenterChart,
+			Version:   karpVer,
+			Values:    awsChartValues(clusterName, region, roleARN, intQueue),
+		})
+	}
+	set := awsChartSet(clusterName, region, roleARN, intQueue)
+	if dryRun != nil {
+		nodePool := ""
+		if rec != nil {
+			nodePool = nodes.GenerateManifest(*rec, clusterName, roleARN)
+		}
+		return dryRun.run(kubeCtx, namespace, karpVer, chartDigest, set, nodePool, manifests)
+	}
+
 	if !confirmPrompt("  Proceed with installation? [y/N] ") {
 		fmt.Printf("  Cancelled.\n\n")
 		return nil
 	}
 
-	// ── Apply NodePool manifest ────────────────────────────────────────────
-	if rec != nil {
-		manifest := nodes.GenerateManifest(*rec, clusterName, roleARN)
-		fmt.Println()
-		applyOrSaveManifest(manifest, kubeCtx)
-	}
+	vars := installHookVars(kube.ProviderAWS, namespace, karpVer)
+	return hooks.Around(hooks.Install, kubeCtx, vars, os.Stdout, func() error {
+		// ── Apply NodePool manifest ────────────────────────────────────────────
+		if rec != nil {
+			manifest := nodes.GenerateManifest(*rec, clusterName, roleARN)
+			fmt.Println()
+			applyOrSaveManifest(manifest, kubeCtx, false)
+		}
 
-	fmt.Printf("\n  Installing Karpenter %s on AWS EKS into namespace %q…\n", karpVer, namespace)
+		if manifests {
+			progress.Step("Manifest install")
+			fmt.Printf("\n  Installing Karpenter %s on AWS EKS into namespace %q as plain manifests…\n", karpVer, namespace)
+		} else {
+			progress.Step("Helm install")
+			fmt.Printf("\n  Installing Karpenter %s on AWS EKS into namespace %q…\n", karpVer, namespace)
+		}
 
-	ver := strings.TrimPrefix(karpVer, "v")
-	helmArgs := []string{
-		"install", "karpenter",
-		"oci://public.ecr.aws/karpenter/karpenter",
-		"--version", ver,
-		"--namespace", namespace,
-		"--create-namespace",
-		"--set", "settings.clusterName=" + clusterName,
-		"--set", "controller.env[0].name=AWS_REGION",
-		"--set", "controller.env[0].value=" + region,
-		"--set", "serviceAccount.annotations.eks\\.amazonaws\\.com/role-arn=" + roleARN,
-	}
-	if kubeCtx != "" {
-		helmArgs = append(helmArgs, "--kube-context", kubeCtx)
+		// Install from a pulled archive so the chart deployed is the one whose
+		// digest is checked against --chart-digest and recorded afterwards.
+		ver := strings.TrimPrefix(karpVer, "v")
+		chart, err := helm.Pull(helm.KarpenterChart, ver, chartDigest)
+		if err != nil {
+			return err
+		}
+		defer chart.Close()
+		if chartDigest != "" {
+			fmt.Printf("  ✓  Chart digest %s matches --chart-digest.\n", chart.Digest)
+		}
+		if manifests {
+			// No Helm release: render, server-side apply, and keep the
+			// release record karpx needs for detect and upgrade.
+			objs, err := helm.Render(chart.Path, "karpenter", namespace, set, nil)
+			if err != nil {
+				return err
+			}
+			rel := helm.StaticRelease{Name: "karpenter", Namespace: namespace, Chart: helm.KarpenterChart, Version: ver, Digest: chart.Digest, Set: set}
+			if err := helm.ApplyStatic(kubeCtx, rel, objs); err != nil {
+				return fmt.Errorf("manifest install failed: %w", err)
+			}
+			fmt.Printf("  ✓  %d objects applied (field manager %s); release recorded in %s/%s.\n",
+				len(objs), helm.FieldManager, namespace, helm.ReleaseConfigMap)
+		} else {
+			helmArgs := []string{
+				"install", "karpenter",
+				chart.Path,
+				"--namespace", namespace,
+				"--create-namespace",
+			}
+			for _, v := range set {
+				helmArgs = append(helmArgs, "--set", v)
+			}
+			if kubeCtx != "" {
+				helmArgs = append(helmArgs, "--kube-context", kubeCtx)
+			}
+
+			helmCmd := exec.Command("helm", helmArgs...)
+			helmCmd.Stdout = os.Stdout
+			helmCmd.Stderr = os.Stderr
+			if err := helmCmd.Run(); err != nil {
+				return fmt.Errorf("helm install failed: %w", err)
+			}
+		}
+		if chart.Digest != "" {
+			prov := helm.Provenance{Chart: helm.KarpenterChart, Version: ver, Digest: chart.Digest, DeployedAt: time.Now().UTC()}
+			if err := helm.RecordProvenance(kubeCtx, namespace, prov); err != nil {
+				fmt.Printf("  ⚠  Chart digest not recorded: %v\n", err)
+			} else {
+				fmt.Printf("  ✓  Chart digest %s recorded in %s/%s.\n", chart.Digest, namespace, helm.ProvenanceConfigMap)
+			}
+		}
+		fmt.Printf("\n  ✓  Karpenter %s installed successfully.\n\n", karpVer)
+		return nil
+	})
+}
+
+// awsChartSet are the --set flags runInstallAWS passes to helm.
+func awsChartSet(clusterName, region, roleARN, intQueue string) []string {
+	set := []string{
+		"settings.clusterName=" + clusterName,
+		"controller.env[0].name=AWS_REGION",
+		"controller.env[0].value=" + region,
+		"serviceAccount.annotations.eks\\.amazonaws\\.com/role-arn=" + roleARN,
 	}
 	if intQueue != "" {
-		helmArgs = append(helmArgs, "--set", "settings.interruptionQueue="+intQueue)
+		set = append(set, "settings.interruptionQueue="+intQueue)
 	}
-
-	helmCmd := exec.Command("helm", helmArgs...)
-	helmCmd.Stdout = os.Stdout
-	helmCmd.Stderr = os.Stderr
-	if err := helmCmd.Run(); err != nil {
-		return fmt.Errorf("helm install failed: %w", err)
+	if helm.Mirrored() {
+		set = append(set, "controller.image.repository="+helm.ControllerImage())
 	}
-	fmt.Printf("\n  ✓  Karpenter %s installed successfully.\n\n", karpVer)
-	return nil
+	return set
 }
 
-// ── Azure AKS install flow ────────────────────────────────────────────────────
+// installDryRun holds the --dry-run settings of an AWS install.
+type installDryRun struct {
+	Dir             string // write the manifests here; "" prints them
+	NamespaceExists bool
+}
 
-func runInstallAzure(kubeCtx, namespace, karpVer string) error {
-	meta := kube.ProviderAzure.Meta()
+// run renders what runInstallAWS would deploy — the chart with set, and the
+// NodePool — shows it, and server-side dry-runs it. Nothing is persisted.
+func (d *installDryRun) run(kubeCtx, namespace, karpVer, chartDigest string, set []string, nodePool string, manifests bool) error {
 	fmt.Println()
-	printSection("Step 3: Azure AKS — Karpenter (Preview)")
-	fmt.Printf(`
-  Karpenter on Azure AKS uses a Microsoft-maintained provider chart.
+	printSection("Dry run")
+	ver := strings.TrimPrefix(karpVer, "v")
+	chart, err := helm.Pull(helm.KarpenterChart, ver, chartDigest)
+	if err != nil {
+		return err
+	}
+	defer chart.Close()
+	if chartDigest != "" {
+		fmt.Printf("  ✓  Chart digest %s matches --chart-digest.\n", chart.Digest)
+	}
+	render := helm.Template
+	if manifests {
+		render = helm.Render
+	}
+	objs, err := render(chart.Path, "karpenter", namespace, set, nil)
+	if err != nil {
+		return err
+	}
+	data, err := manifest.EncodeYAML(objs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Rendered %s v%s: %d objects\n", helm.KarpenterChart, ver, len(objs))
 
-  Chart   : %s
-  Repo    : %s
-  Docs    : %s
+	// ── Show or write the manifests ────────────────────────────────────────
+	if d.Dir != "" {
+		if err := os.MkdirAll(d.Dir, 0755); err != nil {
+			return err
+		}
+		files := map[string]string{"karpenter.yaml": string(data)}
+		if nodePool != "" {
+			files["nodepool.yaml"] = nodePool
+		}
+		for _, name := range []string{"karpenter.yaml", "nodepool.yaml"} {
+			content, ok := files[name]
+			if !ok {
+				continue
+			}
+			path := filepath.Join(d.Dir, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				return fmt.Errorf("write %s: %w", path, err)
+			}
+			fmt.Printf("  ✓  Wrote %s\n", path)
+		}
+	} else {
+		fmt.Println()
+		printSection("Karpenter manifests")
+		fmt.Println(string(data))
+		if nodePool != "" {
+			printSection("NodePool manifest")
+			fmt.Println()
+			fmt.Println(nodePool)
+		}
+	}
+
+	// ── Server-side dry-run ────────────────────────────────────────────────
+	fmt.Println()
+	printSection("Server-side dry run")
+	check := objs
+	if !d.NamespaceExists {
+		// The API server rejects objects in a namespace that does not exist,
+		// and a dry-run cannot create it.
+		check = nil
+		for _, o := range objs {
+			if o.Namespace() == "" {
+				check = append(check, o)
+			}
+		}
+		fmt.Printf("  ℹ  Namespace %q does not exist yet — only cluster-scoped objects can be checked.\n", namespace)
+	}
+	if pool, err := manifest.Decode([]byte(nodePool)); err == nil && len(pool) > 0 {
+		if crds, _ := helm.DetectCRDs(kubeCtx); crds != nil && crds.Installed {
+			check = append(check, pool...)
+		} else {
+			fmt.Printf("  ℹ  Karpenter CRDs are not installed yet — the NodePool cannot be checked.\n")
+		}
+	}
+	out, err := helm.DryRunApply(kubeCtx, check)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line != "" {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	if err != nil {
+		fmt.Printf("\n  ✗ The API server rejected the dry run: %v\n\n", err)
+		return err
+	}
+	fmt.Printf("\n  ✓  Dry run passed — nothing was changed.\n")
+	fmt.Printf("     Install for real by re-running without --dry-run.\n\n")
+	return nil
+}
 
-  Required Azure permissions:
-    • Contributor on the node resource group
-    • AKS Cluster Admin role
+// awsChartValues are the Karpenter chart values of an AWS install, matching
+// the --set flags runInstallAWS passes to helm.
+func awsChartValues(clusterName, region, roleARN, intQueue string) map[string]any {
+	settings := map[string]any{"clusterName": clusterName}
+	if intQueue != "" {
+		settings["interruptionQueue"] = intQueue
+	}
+	controller := map[string]any{
+		"env": []any{map[string]any{"name": "AWS_REGION", "value": region}},
+	}
+	if helm.Mirrored() {
+		controller["image"] = map[string]any{"repository": helm.ControllerImage()}
+	}
+	return map[string]any{
+		"settings":   settings,
+		"controller": controller,
+		"serviceAccount": map[string]any{
+			"annotations": map[string]any{"eks.amazonaws.com/role-arn": roleARN},
+		},
+	}
+}
 
-`, meta.ChartRepo, meta.ProviderRepo, meta.DocsURL)
+// exportHelmfile writes rel to path as a helmfile.yaml instead of installing.
+func exportHelmfile(kubeCtx, path string, rel helm.HelmfileRelease) error {
+	header := fmt.Sprintf("Karpenter %s for context %s, generated by karpx.\nApply with: helmfile -f %s sync", rel.Version, contextOrCurrent(kubeCtx), path)
+	data, err := helm.Helmfile(header, rel)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	fmt.Printf("  ✓  helmfile written to %s — nothing was installed.\n", path)
+	fmt.Printf("     Apply it with: helmfile -f %s sync\n", path)
+	fmt.Printf("     NodePools are not part of the release; generate them with: karpx nodes -c %s\n\n", contextOrCurrent(kubeCtx))
+	return nil
+}
+
+// ── Azure AKS install flow ────────────────────────────────────────────────────
+
+// runInstallAzure prefers Node Auto Provisioning — AKS-managed Karpenter that
+// needs a single `az aks update` — and falls back to installing the
+// self-hosted provider chart with a workload-identity-backed controller when
+// NAP cannot be enabled on the cluster or --self-hosted is set.
+func runInstallAzure(kubeCtx, namespace, clusterName, resourceGroup, karpVer string, selfHosted bool) error {
+	meta := kube.ProviderAzure.Meta()
+	fmt.Println()
+	printSection("Step 3: Cluster information (Azure AKS)")
+	fmt.Printf("  Docs    : %s\n\n", meta.DocsURL)
+
+	clusterName = askIfEmpty(clusterName, "AKS cluster name", kube.ContextName(kubeCtx))
+	if clusterName == "" {
+		return fmt.Errorf("cluster name is required for Azure AKS installation")
+	}
+	fmt.Printf("  Looking up AKS cluster %q…\n", clusterName)
+	cluster, err := azure.FindCluster(clusterName, resourceGroup)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Resource group      : %s\n", cluster.ResourceGroup)
+	fmt.Printf("  Location            : %s\n", cluster.Location)
+	fmt.Printf("  Node resource group : %s\n", cluster.NodeResourceGroup)
+	fmt.Printf("  Network             : %s %s %s\n", cluster.NetworkProfile.NetworkPlugin,
+		cluster.NetworkProfile.NetworkPluginMode, cluster.NetworkProfile.NetworkDataplane)
+
+	if cluster.NAPEnabled() {
+		fmt.Printf("\n  ✓  Node Auto Provisioning is already enabled — AKS manages Karpenter for this cluster.\n")
+		fmt.Printf("     Create NodePools with `karpx nodes -c %s`.\n\n", contextOrCurrent(kubeCtx))
+		return nil
+	}
 
-	k8sVer, _ := kube.GetServerVersion(kubeCtx)
-	if k8sVer != "" {
-		fmt.Printf("  Kubernetes version  : %s\n\n", k8sVer)
+	// ── Step 4: NAP or self-hosted ────────────────────────────────────────
+	fmt.Println()
+	printSection("Step 4: Node Auto Provisioning")
+	blockers := azure.NAPBlockers(cluster)
+	if !selfHosted && len(blockers) == 0 {
+		fmt.Printf("  ✓  This cluster can enable Node Auto Provisioning (managed Karpenter).\n")
+		fmt.Printf("     AKS runs and upgrades the controller; nothing is installed with Helm.\n\n")
+		fmt.Printf("  ► az aks update --name %s --resource-group %s --node-provisioning-mode Auto\n\n",
+			cluster.Name, cluster.ResourceGroup)
+		if confirmDefaultPrompt("  Enable Node Auto Provisioning? [Y/n] ") {
+			fmt.Printf("\n  Enabling Node Auto Provisioning (this takes a few minutes)…\n")
+			vars := installHookVars(kube.ProviderAzure, namespace, karpVer)
+			if err := hooks.Around(hooks.Install, kubeCtx, vars, os.Stdout, func() error {
+				return azure.EnableNAP(cluster)
+			}); err != nil {
+				return fmt.Errorf("enable node auto provisioning: %w", err)
+			}
+			fmt.Printf("  ✓  Node Auto Provisioning enabled on %s.\n\n", cluster.Name)
+			return nil
+		}
+		fmt.Printf("\n  Continuing with the self-hosted provider instead.\n")
+	} else if !selfHosted {
+		fmt.Printf("  Node Auto Provisioning cannot be enabled in place:\n")
+		for _, b := range blockers {
+			fmt.Printf("    ✗ %s\n", b)
+		}
+		fmt.Printf("\n  Falling back to the self-hosted provider chart.\n")
 	}
 
+	return runInstallAzureSelfHosted(kubeCtx, namespace, cluster, karpVer)
+}
+
+func runInstallAzureSelfHosted(kubeCtx, namespace string, cluster *azure.Cluster, karpVer string) error {
+	meta := kube.ProviderAzure.Meta()
+	fmt.Println()
+	printSection("Step 5: Self-hosted provider (Preview)")
+	fmt.Printf("  Chart   : %s\n", meta.ChartRepo)
+	fmt.Printf("  Repo    : %s\n\n", meta.ProviderRepo)
+
+	identityName := askIfEmpty("", "Managed identity name for the controller", azure.DefaultIdentityName)
+	sshKey := cluster.SSHPublicKey()
+	if sshKey == "" {
+		sshKey = askIfEmpty("", "SSH public key for new nodes (cluster has none)", "")
+		if sshKey == "" {
+			return fmt.Errorf("an SSH public key is required for self-hosted Karpenter nodes")
+		}
+	}
 	if karpVer == "" {
-		karpVer = askIfEmpty("", "Karpenter version to install (e.g. 0.7.0)", "")
+		karpVer = askIfEmpty("", "Karpenter provider version (e.g. 1.2.0; Enter for latest)", "")
 	}
 
 	fmt.Println()
-	fmt.Printf("  Sample install command:\n\n")
-	fmt.Printf("    helm install karpenter %s \\\n", meta.ChartRepo)
-	fmt.Printf("      --namespace %s \\\n", namespace)
+	printSection("Summary")
+	fmt.Printf("  Provider        : Azure AKS (self-hosted)\n")
+	fmt.Printf("  Context         : %s\n", contextOrCurrent(kubeCtx))
+	fmt.Printf("  Namespace       : %s\n", namespace)
+	fmt.Printf("  Cluster         : %s (%s)\n", cluster.Name, cluster.ResourceGroup)
+	fmt.Printf("  Identity        : %s\n", identityName)
+	fmt.Printf("  Roles           : Virtual Machine Contributor, Network Contributor,\n")
+	fmt.Printf("                    Managed Identity Operator on %s\n", cluster.NodeResourceGroup)
+	if !cluster.WorkloadIdentityEnabled() {
+		fmt.Printf("  Cluster update  : enable OIDC issuer + workload identity\n")
+	}
 	if karpVer != "" {
-		fmt.Printf("      --version %s \\\n", strings.TrimPrefix(karpVer, "v"))
+		fmt.Printf("  Karpenter       : %s\n", karpVer)
 	}
-	fmt.Printf("      --set controller.resources.requests.cpu=1 \\\n")
-	fmt.Printf("      --set controller.resources.requests.memory=1Gi\n\n")
-	fmt.Printf("  Full setup guide: %s\n\n", meta.DocsURL)
+	fmt.Println()
 
-	if !confirmPrompt("  Copy the command above and run it manually? Acknowledged [y/N] ") {
+	if !confirmPrompt("  Proceed with installation? [y/N] ") {
 		fmt.Printf("  Cancelled.\n\n")
+		return nil
 	}
-	return nil
+
+	vars := installHookVars(kube.ProviderAzure, namespace, karpVer)
+	return hooks.Around(hooks.Install, kubeCtx, vars, os.Stdout, func() error {
+		// ── Identity + federation ─────────────────────────────────────────────
+		fmt.Println()
+		if !cluster.WorkloadIdentityEnabled() {
+			fmt.Printf("  Enabling OIDC issuer and workload identity (this takes a few minutes)…\n")
+			updated, err := azure.EnableWorkloadIdentity(cluster)
+			if err != nil {
+				return fmt.Errorf("enable workload identity: %w", err)
+			}
+			cluster = updated
+		}
+		fmt.Printf("  ✓  Workload identity enabled.\n")
+
+		id, err := azure.EnsureIdentity(cluster, identityName)
+		if err != nil {
+			return fmt.Errorf("create managed identity: %w", err)
+		}
+		fmt.Printf("  ✓  Managed identity %s (client ID %s).\n", identityName, id.ClientID)
+
+		if err := azure.AssignRoles(cluster, id); err != nil {
+			return err
+		}
+		fmt.Printf("  ✓  Roles assigned on %s.\n", cluster.NodeResourceGroup)
+		if cluster.SubnetID() != "" {
+			fmt.Printf("  ⚠  Custom VNet detected — also grant Network Contributor on the subnet if it\n")
+			fmt.Printf("     lives outside the node resource group:\n")
+			fmt.Printf("       %s\n", cluster.SubnetID())
+		}
+
+		if err := azure.EnsureFederatedCredential(cluster, identityName, namespace, azure.DefaultServiceAccount); err != nil {
+			return fmt.Errorf("create federated credential: %w", err)
+		}
+		fmt.Printf("  ✓  Federated credential for %s/%s.\n", namespace, azure.DefaultServiceAccount)
+
+		// ── Chart values ──────────────────────────────────────────────────────
+		token, err := azure.BootstrapToken(kubeCtx)
+		if err != nil {
+			return err
+		}
+		values, err := azure.Values(azure.ValuesParams{
+			Cluster:        cluster,
+			Identity:       id,
+			ServiceAccount: azure.DefaultServiceAccount,
+			BootstrapToken: token,
+			SSHPublicKey:   sshKey,
+		})
+		if err != nil {
+			return fmt.Errorf("render values: %w", err)
+		}
+		valuesFile, err := os.CreateTemp("", "karpx-azure-values-*.yaml")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(valuesFile.Name())
+		if _, err := valuesFile.Write(values); err != nil {
+			valuesFile.Close()
+			return err
+		}
+		valuesFile.Close()
+
+		// ── Helm install ──────────────────────────────────────────────────────
+		progress.Step("Helm install")
+		fmt.Printf("\n  Installing the Azure Karpenter provider into namespace %q…\n", namespace)
+		helmArgs := []string{
+			"upgrade", "--install", "karpenter", meta.ChartRepo,
+			"--namespace", namespace,
+			"--create-namespace",
+			"--values", valuesFile.Name(),
+			"--wait",
+		}
+		if karpVer != "" {
+			helmArgs = append(helmArgs, "--version", strings.TrimPrefix(karpVer, "v"))
+		}
+		if kubeCtx != "" {
+			helmArgs = append(helmArgs, "--kube-context", kubeCtx)
+		}
+		helmCmd := exec.Command("helm", helmArgs...)
+		helmCmd.Stdout = os.Stdout
+		helmCmd.Stderr = os.Stderr
+		if err := helmCmd.Run(); err != nil {
+			return fmt.Errorf("helm install failed: %w", err)
+		}
+		fmt.Printf("\n  ✓  Azure Karpenter provider installed successfully.\n")
+		fmt.Printf("     Create NodePools and AKSNodeClasses next: %s\n\n", meta.DocsURL)
+		return nil
+	})
 }
 
 // ── GCP GKE install flow ──────────────────────────────────────────────────────
 
-func runInstallGCP(kubeCtx, namespace, karpVer string) error {
+func runInstallGCP(kubeCtx, namespace, clusterName, location, project, karpVer string) error {
 	meta := kube.ProviderGCP.Meta()
 	fmt.Println()
 	printSection("Step 3: GCP GKE — Karpenter (Experimental)")
@@ -556,26 +1690,115 @@ func runInstallGCP(kubeCtx, namespace, karpVer string) error {
 
 `, meta.ChartRepo, meta.ProviderRepo, meta.DocsURL)
 
-	k8sVer, _ := kube.GetServerVersion(kubeCtx)
-	if k8sVer != "" {
-		fmt.Printf("  Kubernetes version  : %s\n\n", k8sVer)
+	// gcloud names contexts gke_<project>_<location>_<cluster> — use that for
+	// defaults so a plain `karpx install` needs no extra flags.
+	ctxProject, ctxLocation, ctxCluster, _ := gcp.ParseContext(kube.ContextName(kubeCtx))
+	if ctxProject == "" {
+		ctxProject = gcp.DefaultProject()
+	}
+	project = askIfEmpty(project, "GCP project ID", ctxProject)
+	location = askIfEmpty(location, "Cluster location (region or zone)", ctxLocation)
+	clusterName = askIfEmpty(clusterName, "GKE cluster name", ctxCluster)
+	if project == "" || location == "" || clusterName == "" {
+		return fmt.Errorf("project, location and cluster name are required for GCP GKE installation")
+	}
+
+	fmt.Printf("\n  Looking up GKE cluster %s…\n", clusterName)
+	cluster, err := gcp.DescribeCluster(project, location, clusterName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Endpoint            : %s\n", cluster.Endpoint)
+	fmt.Printf("  Network             : %s / %s\n", cluster.Network, cluster.Subnetwork)
+	if pool := cluster.WorkloadPool(); pool != "" {
+		fmt.Printf("  Workload Identity   : %s\n", pool)
+	} else {
+		fmt.Printf("  Workload Identity   : disabled (will be enabled)\n")
 	}
 
 	if karpVer == "" {
-		karpVer = askIfEmpty("", "Karpenter version to install (e.g. 0.3.0)", "")
+		karpVer = askIfEmpty("", "Karpenter provider version (e.g. 0.3.0; Enter for latest)", "")
 	}
+	gsaName := askIfEmpty("", "Google service account for the controller", gcp.DefaultServiceAccount)
+	email := gcp.ServiceAccountEmail(project, gsaName)
 
 	fmt.Println()
-	fmt.Printf("  Sample install command:\n\n")
-	fmt.Printf("    helm install karpenter %s \\\n", meta.ChartRepo)
-	fmt.Printf("      --namespace %s \\\n", namespace)
+	printSection("Summary")
+	fmt.Printf("  Provider        : GCP GKE (experimental)\n")
+	fmt.Printf("  Context         : %s\n", contextOrCurrent(kubeCtx))
+	fmt.Printf("  Namespace       : %s\n", namespace)
+	fmt.Printf("  Cluster         : %s (%s, %s)\n", clusterName, location, project)
+	fmt.Printf("  Service account : %s\n", email)
+	fmt.Printf("  Roles           : compute.admin, container.admin, iam.serviceAccountUser\n")
+	fmt.Printf("  Workload ID     : %s/%s → %s\n", namespace, gcp.KubeServiceAccount, email)
 	if karpVer != "" {
-		fmt.Printf("      --version %s\n\n", strings.TrimPrefix(karpVer, "v"))
+		fmt.Printf("  Karpenter       : %s\n", karpVer)
 	}
-	fmt.Printf("  Full setup guide: %s\n\n", meta.DocsURL)
+	fmt.Println()
 
-	confirmPrompt("  Acknowledged (experimental) [y/N] ")
-	return nil
+	if !confirmPrompt("  Proceed with installation? [y/N] ") {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+
+	vars := installHookVars(kube.ProviderGCP, namespace, karpVer)
+	return hooks.Around(hooks.Install, kubeCtx, vars, os.Stdout, func() error {
+		// ── Workload Identity ─────────────────────────────────────────────────
+		fmt.Println()
+		if cluster.WorkloadPool() == "" {
+			fmt.Printf("  Enabling Workload Identity on the cluster (this takes a few minutes)…\n")
+			if err := gcp.EnableWorkloadIdentity(cluster); err != nil {
+				return fmt.Errorf("enable workload identity: %w", err)
+			}
+		}
+		fmt.Printf("  ✓  Workload Identity pool %s.\n", cluster.WorkloadPool())
+		if pools := cluster.PoolsWithoutMetadataServer(); len(pools) > 0 {
+			fmt.Printf("  ⚠  Node pools without the GKE metadata server: %s\n", strings.Join(pools, ", "))
+			fmt.Printf("     The controller cannot authenticate from those nodes. Update them with:\n")
+			fmt.Printf("       gcloud container node-pools update <pool> --cluster %s --location %s --workload-metadata GKE_METADATA\n",
+				clusterName, location)
+		}
+
+		// ── Service account + roles ───────────────────────────────────────────
+		if _, err := gcp.EnsureServiceAccount(project, gsaName); err != nil {
+			return fmt.Errorf("create service account: %w", err)
+		}
+		fmt.Printf("  ✓  Service account %s.\n", email)
+		if err := gcp.GrantRoles(project, email); err != nil {
+			return err
+		}
+		fmt.Printf("  ✓  Roles granted on project %s.\n", project)
+		if err := gcp.BindWorkloadIdentity(cluster, email, namespace, gcp.KubeServiceAccount); err != nil {
+			return fmt.Errorf("bind workload identity: %w", err)
+		}
+		fmt.Printf("  ✓  %s/%s can impersonate %s.\n", namespace, gcp.KubeServiceAccount, email)
+
+		// ── Helm install ──────────────────────────────────────────────────────
+		progress.Step("Helm install")
+		fmt.Printf("\n  Installing the GCP Karpenter provider into namespace %q…\n", namespace)
+		helmArgs := []string{
+			"upgrade", "--install", "karpenter", meta.ChartRepo,
+			"--namespace", namespace,
+			"--create-namespace",
+			"--wait",
+		}
+		helmArgs = append(helmArgs, gcp.HelmSetArgs(cluster, email)...)
+		if karpVer != "" {
+			helmArgs = append(helmArgs, "--version", strings.TrimPrefix(karpVer, "v"))
+		}
+		if kubeCtx != "" {
+			helmArgs = append(helmArgs, "--kube-context", kubeCtx)
+		}
+		helmCmd := exec.Command("helm", helmArgs...)
+		helmCmd.Stdout = os.Stdout
+		helmCmd.Stderr = os.Stderr
+		if err := helmCmd.Run(); err != nil {
+			return fmt.Errorf("helm install failed: %w", err)
+		}
+		fmt.Printf("\n  ✓  GCP Karpenter provider installed successfully.\n")
+		fmt.Printf("     Create NodePools and GCENodeClasses next: %s\n\n", meta.DocsURL)
+		return nil
+	})
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -583,23 +1806,29 @@ func runInstallGCP(kubeCtx, namespace, karpVer string) error {
 // ─────────────────────────────────────────────────────────────────────────────
 
 func upgradeCmd() *cobra.Command {
-	var kubeCtx, targetVer string
-	var reuseVals bool
+	var kubeCtx, targetVer, chartDigest string
+	var reuseVals, skipPreflight, ackManual, crdsOnly bool
+	var preflightPaths []string
 	cmd := &cobra.Command{
 		Use:     "upgrade",
 		Short:   "Upgrade Karpenter to a specific or latest compatible version",
-		Example: "  karpx upgrade -c my-cluster\n  karpx upgrade -c my-cluster --version v1.3.0",
+		Example: "  karpx upgrade -c my-cluster\n  karpx upgrade -c my-cluster --version v1.3.0\n  karpx upgrade -c my-cluster --version v1.0.0 --path ./gitops/karpenter\n  karpx upgrade -c my-cluster --crds-only",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUpgrade(kubeCtx, targetVer, reuseVals)
+			return runUpgrade(kubeCtx, targetVer, chartDigest, reuseVals, preflightPaths, skipPreflight, ackManual, crdsOnly)
 		},
 	}
-	cmd.Flags().StringVarP(&kubeCtx,  "context",      "c", "",   "kubeconfig context")
-	cmd.Flags().StringVar(&targetVer, "version",           "",   "target Karpenter version (default: latest compatible)")
-	cmd.Flags().BoolVar(&reuseVals,   "reuse-values",     true, "pass --reuse-values to helm upgrade")
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVar(&targetVer, "version", "", "target Karpenter version (default: latest compatible)")
+	cmd.Flags().BoolVar(&reuseVals, "reuse-values", true, "pass --reuse-values to helm upgrade")
+	cmd.Flags().StringSliceVar(&preflightPaths, "path", nil, "also scan manifests in these files/directories during preflight")
+	cmd.Flags().BoolVar(&skipPreflight, "skip-preflight", false, "upgrade even if the deprecated-API preflight finds blockers")
+	cmd.Flags().BoolVar(&ackManual, "acknowledge-manual-steps", false, "confirm the manual migration steps on the upgrade path are done (required with --yes)")
+	cmd.Flags().StringVar(&chartDigest, "chart-digest", "", "require the target chart to have this sha256 digest (use with --version)")
+	cmd.Flags().BoolVar(&crdsOnly, "crds-only", false, "only bring the CRDs (or the karpenter-crd release) to the installed controller version")
 	return cmd
 }
 
-func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
+func runUpgrade(kubeCtx, targetVer, chartDigest string, reuseVals bool, preflightPaths []string, skipPreflight, ackManual, crdsOnly bool) error {
 	fmt.Printf("\n  ▲ karpx upgrade  context:%s\n\n", contextOrCurrent(kubeCtx))
 
 	// ── Detect installed Karpenter ────────────────────────────────────────
@@ -609,6 +1838,10 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 		fmt.Printf("    Run `karpx install` to install it.\n\n")
 		return nil
 	}
+	if err := info.ManagedError(); err != nil {
+		fmt.Printf("  ✗ %v\n\n", err)
+		return err
+	}
 
 	// info.Chart is only set for Helm-managed installs; empty means raw manifests.
 	viaHelm := info.Chart != ""
@@ -619,10 +1852,28 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 	} else {
 		fmt.Printf("  Installed version : v%s\n", installed)
 	}
-	if !viaHelm {
+	switch {
+	case info.Manifests:
+		fmt.Printf("  Install method    : karpx manifests (%s) — will re-render and server-side apply\n", helm.ReleaseConfigMap)
+	case !viaHelm:
 		fmt.Printf("  Install method    : manifests (not Helm) — will use kubectl image update\n")
 	}
 
+	// ── CRDs ──────────────────────────────────────────────────────────────
+	crds, err := helm.DetectCRDs(kubeCtx)
+	if err != nil {
+		fmt.Printf("  CRDs              : ⚠  could not read (%v)\n", err)
+	}
+	if crds != nil && crds.Release != "" {
+		fmt.Printf("  CRDs              : %s release — upgraded in lockstep\n", crds.Release)
+	}
+	if msg := helm.CRDMismatch(crds, installed); msg != "" {
+		fmt.Printf("  CRDs              : ⚠  %s\n", msg)
+	}
+	if crdsOnly {
+		return syncCRDs(kubeCtx, crds, installed)
+	}
+
 	// ── Resolve namespace and deployment name ─────────────────────────────
 	ns := info.Namespace
 	if ns == "" {
@@ -680,6 +1931,7 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 	}
 
 	// ── Show upgrade plan ─────────────────────────────────────────────────
+	hops := 1
 	if installed == "" {
 		// Unknown current version — can't determine hop path; go direct.
 		fmt.Printf("\n  Upgrade       : unknown → v%s (direct, version unknown)\n", target)
@@ -688,6 +1940,7 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 		if pathErr != nil {
 			return pathErr
 		}
+		hops = max(1, len(path))
 		if len(path) > 1 {
 			fmt.Printf("\n  Upgrade path  : v%s → %s\n", installed, "v"+strings.Join(path, " → v"))
 			fmt.Printf("  (upgrading one minor version at a time for safety)\n")
@@ -696,6 +1949,115 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 		}
 	}
 
+	// ── Manual migration steps on the path ───────────────────────────────
+	// These cannot be automated, so an unattended run (--yes or no terminal)
+	// must say explicitly that they are done.
+	if steps := karpupgrade.ManualSteps(installed, target); len(steps) > 0 {
+		fmt.Printf("\n  Manual steps required on this path:\n")
+		for _, s := range steps {
+			fmt.Printf("  ⚠  v%-7s %s\n", s.Version, s.Title)
+			fmt.Printf("       %s\n", s.Detail)
+		}
+		if ackManual {
+			fmt.Printf("\n  ✓  Acknowledged (--acknowledge-manual-steps).\n")
+		} else if prompt.AssumeYes() || !prompt.Interactive() {
+			fmt.Printf("\n  ✗ %d manual step(s) apply to v%s and this run is unattended.\n", len(steps), target)
+			fmt.Printf("    Complete them, then re-run with --acknowledge-manual-steps.\n\n")
+			return fmt.Errorf("%d manual step(s) not acknowledged", len(steps))
+		} else if !confirmPrompt("\n  Have these steps been completed? [y/N] ") {
+			fmt.Printf("  Cancelled.\n\n")
+			return nil
+		}
+	}
+
+	// ── Chart provenance ──────────────────────────────────────────────────
+	// What karpx deployed last must still be what runs, and the registry
+	// must still serve the same artifact for it. With --chart-digest the run
+	// is change-controlled, so any doubt stops it.
+	if viaHelm {
+		prov, err := helm.RecordedProvenance(kubeCtx, ns)
+		switch {
+		case err != nil:
+			fmt.Printf("\n  ⚠  Chart provenance: %v\n", err)
+		case prov == nil:
+			fmt.Printf("\n  ℹ  Chart provenance: none recorded — this upgrade will record it.\n")
+		default:
+			fmt.Printf("\n  Chart provenance  : v%s  %s  (deployed %s)\n", prov.Version, prov.Digest, prov.DeployedAt.Local().Format("2006-01-02 15:04"))
+			problems := helm.VerifyProvenance(prov, installed)
+			for _, pr := range problems {
+				fmt.Printf("  ⚠  %s\n", pr)
+			}
+			if len(problems) == 0 {
+				fmt.Printf("  ✓  Release and registry match the recorded digest.\n")
+			} else if chartDigest != "" {
+				fmt.Printf("\n  ✗ Provenance does not verify and --chart-digest requires a controlled change.\n")
+				fmt.Printf("    Investigate, then re-run without --chart-digest to proceed anyway.\n\n")
+				return fmt.Errorf("chart provenance check failed (%d problem(s))", len(problems))
+			}
+		}
+	}
+	if chartDigest != "" {
+		fmt.Printf("  Pinned digest     : %s  (v%s)\n", chartDigest, target)
+	}
+
+	// ── Preflight: APIs removed across the v1beta1 → v1 boundary ──────────
+	// Five steps per hop (pull, CRDs, scale, upgrade, rollout), one more for
+	// the provenance record on Helm installs, plus preflight and the values
+	// check.
+	perHop := 5
+	if viaHelm {
+		perHop = 6
+	}
+	progress.Expect(perHop*hops + 2)
+	if preflight.CrossesV1(installed, target) {
+		progress.Start("Preflight", "scan for APIs removed in Karpenter v1")
+		fmt.Printf("\n  Preflight: scanning for APIs removed in Karpenter v1…\n")
+		findings, err := preflight.Scan(preflight.Params{KubeCtx: kubeCtx, Target: target, Paths: preflightPaths})
+		if err != nil {
+			fmt.Printf("  ⚠  Preflight scan failed: %v\n", err)
+		} else if blockers := printPreflightFindings(findings); blockers > 0 {
+			if !skipPreflight {
+				fmt.Printf("\n  ✗ %d blocker(s) must be fixed before upgrading to v%s.\n", blockers, target)
+				fmt.Printf("    Re-run with --skip-preflight to upgrade anyway.\n\n")
+				err := fmt.Errorf("preflight found %d blocker(s)", blockers)
+				progress.Fail("Preflight", err)
+				return err
+			}
+			fmt.Printf("\n  ⚠  Continuing despite %d blocker(s) (--skip-preflight).\n", blockers)
+		}
+		progress.Complete("Preflight", "")
+	}
+
+	// ── Helm values the target chart no longer reads ──────────────────────
+	var extraArgs []string
+	if viaHelm && reuseVals || info.Manifests {
+		progress.Start("Values check", "compare deployed values with the target chart")
+		fmt.Printf("\n  Checking deployed Helm values against the v%s chart…\n", target)
+		var changes []karpupgrade.ValueChange
+		var err error
+		if info.Manifests {
+			// Manifest installs keep their values in the release record.
+			var rel *helm.StaticRelease
+			if rel, err = helm.RecordedStatic(kubeCtx); err == nil && rel == nil {
+				err = fmt.Errorf("%s ConfigMap not found", helm.ReleaseConfigMap)
+			}
+			if err == nil {
+				changes, err = karpupgrade.CheckStaticValues(rel, installed, target)
+			}
+		} else {
+			changes, err = karpupgrade.CheckValues(kubeCtx, ns, releaseName, installed, target)
+		}
+		if err != nil {
+			fmt.Printf("  ⚠  Values check skipped: %v\n", err)
+		} else {
+			printValueChanges(changes)
+			if extraArgs = karpupgrade.SetArgs(changes); len(extraArgs) > 0 {
+				fmt.Printf("\n  Renamed values will be carried over to their new keys during the upgrade.\n")
+			}
+		}
+		progress.Complete("Values check", "")
+	}
+
 	fmt.Printf("\n  Strategy        : zero-downtime (scale to 2 replicas, rolling update)\n")
 	if !viaHelm {
 		fmt.Printf("  Note            : Karpenter was not installed via Helm;\n")
@@ -712,10 +2074,12 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 	stepNum := 0
 	reporter := func(s karpupgrade.Step) {
 		if s.Err != "" {
+			progress.Fail(s.Name, errors.New(s.Err))
 			fmt.Printf("  ✗ %s\n    %s\n", s.Name, s.Err)
 			return
 		}
 		if !s.OK {
+			progress.Start(s.Name, s.Detail)
 			stepNum++
 			if s.Detail != "" {
 				fmt.Printf("  [%d] %s  (%s)\n", stepNum, s.Name, s.Detail)
@@ -724,6 +2088,7 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 			}
 			return
 		}
+		progress.Complete(s.Name, s.Detail)
 		if s.Detail != "" {
 			fmt.Printf("      ✓ %s\n", s.Detail)
 		} else {
@@ -731,17 +2096,29 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 		}
 	}
 
-	if err := karpupgrade.Run(karpupgrade.Params{
-		KubeCtx:        kubeCtx,
-		Namespace:      ns,
-		ReleaseName:    releaseName,
-		DeploymentName: deploymentName,
-		Current:        installed,
-		Target:         target,
-		AllVersions:    allVersions,
-		ReuseValues:    reuseVals,
-		ViaHelm:        viaHelm,
-	}, reporter); err != nil {
+	hookVars := map[string]string{
+		"KARPX_VERSION":      target,
+		"KARPX_FROM_VERSION": installed,
+		"KARPX_NAMESPACE":    ns,
+		"KARPX_RELEASE":      releaseName,
+	}
+	if err := hooks.Around(hooks.Upgrade, kubeCtx, hookVars, os.Stdout, func() error {
+		return karpupgrade.Run(karpupgrade.Params{
+			KubeCtx:        kubeCtx,
+			Namespace:      ns,
+			ReleaseName:    releaseName,
+			DeploymentName: deploymentName,
+			Current:        installed,
+			Target:         target,
+			AllVersions:    allVersions,
+			ReuseValues:    reuseVals,
+			ViaHelm:        viaHelm,
+			Manifests:      info.Manifests,
+			ExtraArgs:      extraArgs,
+			ChartDigest:    chartDigest,
+			CRDs:           crds,
+		}, reporter)
+	}); err != nil {
 		fmt.Printf("\n  ✗ Upgrade failed: %v\n\n", err)
 		return err
 	}
@@ -750,362 +2127,3994 @@ func runUpgrade(kubeCtx, targetVer string, reuseVals bool) error {
 	return nil
 }
 
+// printValueChanges lists deployed Helm values the target chart ignores.
+func printValueChanges(changes []karpupgrade.ValueChange) {
+	if len(changes) == 0 {
+		fmt.Printf("  ✓  Every deployed value is read by the target chart.\n")
+		return
+	}
+	for _, c := range changes {
+		switch c.Kind {
+		case "renamed":
+			fmt.Printf("  ⚠  %s → %s\n", c.Key, c.To)
+		case "removed":
+			fmt.Printf("  ✗  %s removed\n", c.Key)
+		default:
+			fmt.Printf("  ℹ  %s unknown\n", c.Key)
+		}
+		if c.Advice != "" {
+			fmt.Printf("       %s\n", c.Advice)
+		}
+	}
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
-// uninstall command — remove Karpenter from a cluster via helm
+// fleet command — operate on the clusters listed in fleet.yaml
 // ─────────────────────────────────────────────────────────────────────────────
 
-func uninstallCmd() *cobra.Command {
-	var kubeCtx  string
-	var deleteNs bool
+func fleetCmd() *cobra.Command {
+	var file, env string
+	var tags []string
+
 	cmd := &cobra.Command{
-		Use:   "uninstall",
-		Short: "Uninstall Karpenter from a cluster",
-		Long: `Uninstall Karpenter from a Kubernetes cluster using Helm.
+		Use:   "fleet",
+		Short: "Check and upgrade every cluster listed in a fleet.yaml",
+		Long: `
+  Operate on a declared fleet of clusters instead of one context at a time.
 
-The Karpenter controller is removed, but NodePool and EC2NodeClass custom
-resources are NOT deleted automatically — remove them manually if needed.`,
-		Example: "  karpx uninstall -c my-cluster\n  karpx uninstall -c my-cluster --delete-namespace",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUninstall(kubeCtx, deleteNs)
-		},
+  fleet.yaml lists each cluster's context and the Karpenter version it should
+  run — an exact version, or a channel ("latest", or a minor line like "1.2"):
+
+    clusters:
+      - context: dev-eu
+        environment: dev
+        channel: latest
+      - context: prod-eu
+        environment: prod
+        provider: aws
+        version: "1.1.3"
+
+  The file is ./fleet.yaml, $KARPX_FLEET, or -f.
+
+  Examples:
+    karpx fleet status
+    karpx fleet status --env prod -o json
+    karpx fleet detect -f ~/fleets/platform.yaml
+    karpx fleet upgrade --env dev
+    karpx fleet status --tag team=payments
+`,
 	}
-	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
-	cmd.Flags().BoolVar(&deleteNs, "delete-namespace", false, "also delete the Karpenter namespace after uninstall")
+	cmd.PersistentFlags().StringVarP(&file, "file", "f", "", "fleet file (default: ./fleet.yaml or $KARPX_FLEET)")
+	cmd.PersistentFlags().StringVar(&env, "env", "", "only clusters in this environment")
+	cmd.PersistentFlags().StringSliceVar(&tags, "tag", nil, "only clusters whose config-file tags match (key=value, key!=value, key)")
+
+	cmd.AddCommand(fleetStatusCmd(&file, &env, &tags), fleetDetectCmd(&file, &env, &tags), fleetUpgradeCmd(&file, &env, &tags))
 	return cmd
 }
 
-func runUninstall(kubeCtx string, deleteNs bool) error {
-	fmt.Printf("\n  karpx uninstall  context:%s\n\n", contextOrCurrent(kubeCtx))
-
-	// ── Detect installed Karpenter ────────────────────────────────────────
-	info, err := helm.DetectKarpenter(kubeCtx)
-	if err != nil || !info.Installed {
-		fmt.Printf("  Karpenter is not installed on this cluster.\n\n")
-		return nil
+// loadFleet reads the fleet file and selects the clusters in env whose
+// config-file tags match tags.
+func loadFleet(file, env string, tags []string) ([]fleet.Cluster, error) {
+	f, err := fleet.Load(fleet.Path(file))
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := filterFleetTags(f.Select(env), tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no fleet cluster matches the --env/--tag filter")
 	}
+	return clusters, nil
+}
 
-	releaseName := info.ReleaseName
-	if releaseName == "" {
-		releaseName = "karpenter"
+// filterFleetTags keeps the fleet clusters whose contexts match the --tag
+// selector.
+func filterFleetTags(clusters []fleet.Cluster, tags []string) ([]fleet.Cluster, error) {
+	sel, err := config.ParseSelector(tags)
+	if err != nil || len(sel) == 0 {
+		return clusters, err
+	}
+	contexts := make([]string, len(clusters))
+	for i, c := range clusters {
+		contexts[i] = c.Context
+	}
+	keep := map[string]bool{}
+	for _, ctx := range status.Filter(contexts, sel) {
+		keep[ctx] = true
 	}
+	var out []fleet.Cluster
+	for _, c := range clusters {
+		if keep[c.Context] {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
 
-	fmt.Printf("  Release     : %s\n", releaseName)
-	fmt.Printf("  Version     : v%s\n", info.Version)
-	fmt.Printf("  Namespace   : %s\n\n", info.Namespace)
-	fmt.Printf("  ⚠  This removes the Karpenter controller.\n")
-	fmt.Printf("     NodePool / EC2NodeClass resources are NOT deleted automatically.\n\n")
+func fleetStatusCmd(file, env *string, tags *[]string) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Compare every cluster's Karpenter version with the fleet file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			clusters, err := loadFleet(*file, *env, *tags)
+			if err != nil {
+				return err
+			}
+			if output == "table" {
+				fmt.Printf("\n  Checking %d cluster(s)…\n\n", len(clusters))
+			}
+			results := fleet.Evaluate(clusters)
+			if output == "json" {
+				return printJSON(results)
+			}
+			printFleetStatus(results)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	return cmd
+}
 
-	if !confirmPrompt("  Proceed with uninstall? [y/N] ") {
+// printFleetStatus prints one row per cluster and a per-environment summary.
+func printFleetStatus(results []fleet.Result) {
+	nameW, envW := len("CLUSTER"), len("ENV")
+	for _, r := range results {
+		nameW = max(nameW, len(r.Cluster.DisplayName()))
+		envW = max(envW, len(r.Cluster.Environment))
+	}
+	fmt.Printf("  %-*s  %-*s  %-10s  %-8s  %-10s  %-10s  %s\n",
+		nameW, "CLUSTER", envW, "ENV", "PROVIDER", "K8S", "KARPENTER", "DESIRED", "STATE")
+
+	type tally struct{ ok, behind, other int }
+	var envs []string
+	byEnv := map[string]*tally{}
+	for _, r := range results {
+		e := r.Cluster.Environment
+		if e == "" {
+			e = "—"
+		}
+		if byEnv[e] == nil {
+			byEnv[e] = &tally{}
+			envs = append(envs, e)
+		}
+		t := byEnv[e]
+
+		karp := "—"
+		if r.Status.KarpenterInstalled {
+			karp = "unknown"
+			if r.Status.KarpenterVersion != "" {
+				karp = "v" + r.Status.KarpenterVersion
+			}
+		}
+		desired := "—"
+		if r.Desired != "" {
+			desired = "v" + r.Desired
+		}
+		state := string(r.State)
+		switch r.State {
+		case fleet.StateOK:
+			state = "✓ ok"
+			t.ok++
+		case fleet.StateBehind:
+			state = "▲ behind"
+			t.behind++
+		case fleet.StateUnreachable:
+			state = "✗ " + r.Status.Error
+			t.other++
+		default:
+			state = "⚠ " + state
+			t.other++
+		}
+		if r.Note != "" {
+			state += "  (" + r.Note + ")"
+		}
+		fmt.Printf("  %-*s  %-*s  %-10s  %-8s  %-10s  %-10s  %s\n",
+			nameW, r.Cluster.DisplayName(), envW, r.Cluster.Environment, r.Status.Provider,
+			r.Status.K8sVersion, karp, desired, state)
+	}
+
+	fmt.Println()
+	for _, e := range envs {
+		t := byEnv[e]
+		fmt.Printf("  %-*s  %d ok · %d behind", envW, e, t.ok, t.behind)
+		if t.other > 0 {
+			fmt.Printf(" · %d need attention", t.other)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+}
+
+func fleetDetectCmd(file, env *string, tags *[]string) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "detect",
+		Short: "Run detect across every cluster in the fleet file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			clusters, err := loadFleet(*file, *env, *tags)
+			if err != nil {
+				return err
+			}
+			contexts := make([]string, len(clusters))
+			for i, c := range clusters {
+				contexts[i] = c.Context
+			}
+			return runDetectContexts(contexts, output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	return cmd
+}
+
+func fleetUpgradeCmd(file, env *string, tags *[]string) *cobra.Command {
+	var dryRun, keepGoing bool
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade every cluster that is behind its fleet version",
+		Long: `Upgrade, one at a time, every cluster whose Karpenter version is behind the
+version the fleet file asks for. Each cluster goes through the normal
+zero-downtime upgrade, with its own plan, checks and confirmation (or --yes).
+
+The run stops at the first failed cluster unless --continue-on-error is set.
+
+When the fleet file has a rollout section (and --env is not given) clusters
+are upgraded stage by stage: after each stage karpx watches the upgraded
+clusters for the stage's bake time and halts the rollout on a failed upgrade,
+new controller restarts, or provisioning errors over the configured limits.`,
+		Example: "  karpx fleet upgrade --env dev\n  karpx fleet upgrade --dry-run\n  karpx fleet upgrade --env staging --yes --continue-on-error",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := fleet.Load(fleet.Path(*file))
+			if err != nil {
+				return err
+			}
+			if f.Rollout != nil && *env == "" {
+				if f.Clusters, err = filterFleetTags(f.Clusters, *tags); err != nil {
+					return err
+				}
+				return runFleetRollout(f, dryRun)
+			}
+			clusters, err := loadFleet(*file, *env, *tags)
+			if err != nil {
+				return err
+			}
+			return runFleetUpgrade(clusters, dryRun, keepGoing)
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show which clusters would be upgraded and stop")
+	cmd.Flags().BoolVar(&keepGoing, "continue-on-error", false, "upgrade the remaining clusters after one fails")
+	return cmd
+}
+
+func runFleetUpgrade(clusters []fleet.Cluster, dryRun, keepGoing bool) error {
+	fmt.Printf("\n  ▲ karpx fleet upgrade  %d cluster(s)\n\n", len(clusters))
+	results := fleet.Evaluate(clusters)
+	printFleetStatus(results)
+
+	due := fleetDue(results)
+	if len(due) == 0 {
+		fmt.Printf("  ✓  No cluster is behind its fleet version.\n\n")
+		return nil
+	}
+
+	fmt.Printf("  Upgrade plan:\n")
+	for i, r := range due {
+		fmt.Printf("  %d. %-30s v%s → v%s\n", i+1, r.Cluster.DisplayName(), r.Status.KarpenterVersion, r.Desired)
+	}
+	if dryRun {
+		fmt.Printf("\n  Dry run — nothing upgraded.\n\n")
+		return nil
+	}
+	if !confirmPrompt(fmt.Sprintf("\n  Upgrade %d cluster(s), one at a time? [y/N] ", len(due))) {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+
+	var failed []string
+	upgraded := 0
+	for i, r := range due {
+		fmt.Println()
+		printSection(fmt.Sprintf("Cluster %d/%d: %s", i+1, len(due), r.Cluster.DisplayName()))
+		if err := runUpgrade(r.Cluster.Context, "v"+r.Desired, "", true, nil, false, false, false); err != nil {
+			failed = append(failed, r.Cluster.DisplayName())
+			if !keepGoing {
+				fmt.Printf("  ✗ Stopping fleet upgrade after %s failed (%d of %d not attempted).\n\n", r.Cluster.DisplayName(), len(due)-i-1, len(due))
+				return fmt.Errorf("fleet upgrade stopped: %s: %w", r.Cluster.DisplayName(), err)
+			}
+			continue
+		}
+		upgraded++
+	}
+
+	// runUpgrade returns nil when a cluster's upgrade is cancelled, so
+	// re-check rather than count those as done.
+	after := fleet.Evaluate(clusters)
+	behind := 0
+	for _, r := range after {
+		if r.State == fleet.StateBehind {
+			behind++
+		}
+	}
+	fmt.Printf("\n  Fleet upgrade finished: %d attempted · %d failed · %d still behind\n", upgraded+len(failed), len(failed), behind)
+	if len(failed) > 0 {
+		fmt.Printf("  ✗ Failed: %s\n\n", strings.Join(failed, ", "))
+		return fmt.Errorf("%d cluster(s) failed to upgrade", len(failed))
+	}
+	fmt.Println()
+	return nil
+}
+
+// fleetDue returns the results karpx can upgrade: behind their fleet version
+// and on AWS, whose compatibility matrix and chart upgrades use.
+func fleetDue(results []fleet.Result) []fleet.Result {
+	var due []fleet.Result
+	for _, r := range results {
+		if r.State != fleet.StateBehind {
+			continue
+		}
+		if r.Status.Provider != string(kube.ProviderAWS) {
+			fmt.Printf("  ⚠  %s: %s clusters are not upgraded by karpx — skipped.\n", r.Cluster.DisplayName(), r.Status.Provider)
+			continue
+		}
+		due = append(due, r)
+	}
+	return due
+}
+
+// installedVersion re-detects the Karpenter version running in kubeCtx,
+// without a leading "v"; "" when it cannot be read.
+func installedVersion(kubeCtx string) string {
+	return status.Check([]string{kubeCtx})[0].KarpenterVersion
+}
+
+// bakePoll is how often regression signals are sampled while a stage bakes.
+const bakePoll = 30 * time.Second
+
+// runFleetRollout upgrades the fleet stage by stage, baking each stage and
+// halting on the first failure or regression.
+func runFleetRollout(f *fleet.File, dryRun bool) error {
+	fmt.Printf("\n  ▲ karpx fleet upgrade  staged rollout · %d stage(s)\n\n", len(f.Rollout.Stages))
+	results := fleet.Evaluate(f.Clusters)
+	printFleetStatus(results)
+
+	plans, unassigned := f.Rollout.Assign(fleetDue(results))
+	for _, r := range unassigned {
+		fmt.Printf("  ⚠  %s: environment %q is in no rollout stage — skipped.\n", r.Cluster.DisplayName(), r.Cluster.Environment)
+	}
+	total := 0
+	fmt.Printf("  Rollout plan:\n")
+	for i, p := range plans {
+		bake := "no bake"
+		if p.Stage.Bake.Duration > 0 {
+			bake = "bake " + p.Stage.Bake.String()
+		}
+		fmt.Printf("  %d. %-12s %d cluster(s), %s\n", i+1, p.Stage.Name, len(p.Clusters), bake)
+		for _, r := range p.Clusters {
+			fmt.Printf("       %-30s v%s → v%s\n", r.Cluster.DisplayName(), r.Status.KarpenterVersion, r.Desired)
+		}
+		total += len(p.Clusters)
+	}
+	sig := f.Rollout.Signals
+	fmt.Printf("  Halt on: a failed upgrade · > %d controller restart(s) · > %d provisioning error(s) per cluster\n",
+		sig.MaxControllerRestarts, sig.MaxProvisioningErrors)
+	fmt.Printf("           · signals that cannot be read during a bake\n")
+	if total == 0 {
+		fmt.Printf("\n  ✓  No cluster is behind its fleet version.\n\n")
+		return nil
+	}
+	if dryRun {
+		fmt.Printf("\n  Dry run — nothing upgraded.\n\n")
+		return nil
+	}
+	if !confirmPrompt(fmt.Sprintf("\n  Start the rollout (%d cluster(s))? [y/N] ", total)) {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+
+	type baked struct {
+		r        fleet.Result
+		baseline fleet.Health
+	}
+	for i, p := range plans {
+		if len(p.Clusters) == 0 {
+			continue
+		}
+		fmt.Println()
+		printSection(fmt.Sprintf("Stage %d/%d: %s", i+1, len(plans), p.Stage.Name))
+
+		bake := p.Stage.Bake.Duration
+		var watch []baked
+		for _, r := range p.Clusters {
+			fmt.Printf("\n  ── %s ──\n", r.Cluster.DisplayName())
+			if err := runUpgrade(r.Cluster.Context, "v"+r.Desired, "", true, nil, false, false, false); err != nil {
+				fmt.Printf("  ✗ Rollout halted in stage %s: %s failed to upgrade.\n\n", p.Stage.Name, r.Cluster.DisplayName())
+				return fmt.Errorf("rollout halted at stage %s: %s: %w", p.Stage.Name, r.Cluster.DisplayName(), err)
+			}
+			// runUpgrade returns nil when the upgrade is cancelled or a guard
+			// skips it, so confirm the cluster really runs the target.
+			if got := installedVersion(r.Cluster.Context); got != r.Desired {
+				if got == "" {
+					got = "unknown"
+				} else {
+					got = "v" + got
+				}
+				fmt.Printf("  ✗ Rollout halted in stage %s: %s runs %s, not v%s.\n\n", p.Stage.Name, r.Cluster.DisplayName(), got, r.Desired)
+				return fmt.Errorf("rollout halted at stage %s: %s was not upgraded to v%s", p.Stage.Name, r.Cluster.DisplayName(), r.Desired)
+			}
+			if bake <= 0 {
+				continue
+			}
+			// A cluster that cannot be watched cannot pass the bake.
+			h, err := fleet.SampleHealth(r.Cluster.Context, r.Status.KarpenterNamespace)
+			if err != nil {
+				err = fmt.Errorf("rollout halted at stage %s: cannot read regression signals of %s: %w", p.Stage.Name, r.Cluster.DisplayName(), err)
+				progress.Fail("Bake "+p.Stage.Name, err)
+				fmt.Printf("  ✗ %v\n\n  ✗ Rollout halted — later stages were not started.\n\n", err)
+				return err
+			}
+			watch = append(watch, baked{r, h})
+		}
+
+		if len(watch) == 0 {
+			continue
+		}
+		fmt.Printf("\n  Baking stage %s for %s — watching controller restarts and provisioning errors…\n", p.Stage.Name, bake)
+		progress.Start("Bake "+p.Stage.Name, bake.String())
+		deadline := time.Now().Add(bake)
+		for poll := 0; ; poll++ {
+			for _, w := range watch {
+				regressions, err := fleet.Regressions(w.r.Cluster.Context, w.r.Status.KarpenterNamespace, w.baseline, sig)
+				if err != nil {
+					err = fmt.Errorf("rollout halted at stage %s: cannot read regression signals of %s: %w", p.Stage.Name, w.r.Cluster.DisplayName(), err)
+					progress.Fail("Bake "+p.Stage.Name, err)
+					fmt.Printf("  ✗ %v\n\n  ✗ Rollout halted — later stages were not started.\n\n", err)
+					return err
+				}
+				if len(regressions) > 0 {
+					for _, line := range regressions {
+						fmt.Printf("  ✗ %s: %s\n", w.r.Cluster.DisplayName(), line)
+					}
+					err := fmt.Errorf("rollout halted at stage %s: regression on %s", p.Stage.Name, w.r.Cluster.DisplayName())
+					progress.Fail("Bake "+p.Stage.Name, err)
+					fmt.Printf("\n  ✗ Rollout halted — later stages were not started.\n\n")
+					return err
+				}
+			}
+			left := time.Until(deadline)
+			if left <= 0 {
+				break
+			}
+			if poll%10 == 0 {
+				fmt.Printf("  … %s left\n", left.Round(time.Minute))
+			}
+			time.Sleep(min(bakePoll, left))
+		}
+		progress.Complete("Bake "+p.Stage.Name, "no regressions")
+		fmt.Printf("  ✓  Stage %s baked with no regressions.\n", p.Stage.Name)
+	}
+
+	fmt.Printf("\n  ✓  Rollout complete: %d cluster(s) upgraded across %d stage(s).\n\n", total, len(plans))
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// preflight command — deprecated Karpenter APIs
+// ─────────────────────────────────────────────────────────────────────────────
+
+func preflightCmd() *cobra.Command {
+	var kubeCtx, targetVer string
+	var paths []string
+	var noCluster bool
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Scan NodePools/NodeClasses and manifests for APIs removed by an upgrade",
+		Long: `Scan existing NodePools, EC2NodeClasses, legacy Provisioners and pod
+annotations — in the cluster and in manifests stored on disk (--path) — for
+fields and annotations removed across the v1beta1 → v1 boundary, and list
+exactly what must change before the upgrade can proceed.
+
+'karpx upgrade' runs the same scan automatically when it crosses v1.`,
+		Example: `  karpx preflight -c my-cluster --version v1.0.0
+  karpx preflight -c my-cluster --path ./gitops/karpenter
+  karpx preflight --no-cluster --path ./charts/platform`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPreflight(kubeCtx, targetVer, paths, noCluster)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVar(&targetVer, "version", "", "target Karpenter version (default: latest compatible, or v1.0.0)")
+	cmd.Flags().StringSliceVar(&paths, "path", nil, "files or directories of manifests to scan (repeatable)")
+	cmd.Flags().BoolVar(&noCluster, "no-cluster", false, "only scan --path, do not contact the cluster")
+	return cmd
+}
+
+func runPreflight(kubeCtx, targetVer string, paths []string, noCluster bool) error {
+	fmt.Printf("\n  karpx preflight  context:%s\n\n", contextOrCurrent(kubeCtx))
+	if noCluster && len(paths) == 0 {
+		return fmt.Errorf("--no-cluster requires at least one --path")
+	}
+
+	target := strings.TrimPrefix(targetVer, "v")
+	if target == "" && !noCluster {
+		if k8sVer, err := kube.GetServerVersion(kubeCtx); err == nil {
+			if latest, _, err := compat.LatestCompatible(k8sVer); err == nil {
+				target = latest
+			}
+		}
+	}
+	if target == "" {
+		target = "1.0.0"
+	}
+	fmt.Printf("  Target version : v%s\n", target)
+	if len(paths) > 0 {
+		fmt.Printf("  Manifests      : %s\n", strings.Join(paths, ", "))
+	}
+	fmt.Println()
+
+	findings, err := preflight.Scan(preflight.Params{
+		KubeCtx:     kubeCtx,
+		Target:      target,
+		Paths:       paths,
+		SkipCluster: noCluster,
+	})
+	if err != nil {
+		fmt.Printf("  ✗ Scan failed: %v\n\n", err)
+		return err
+	}
+	blockers := printPreflightFindings(findings)
+	fmt.Println()
+	if blockers > 0 {
+		return fmt.Errorf("preflight found %d blocker(s)", blockers)
+	}
+	return nil
+}
+
+// printPreflightFindings prints findings grouped by resource and returns the
+// number of blockers.
+func printPreflightFindings(findings []preflight.Finding) int {
+	if len(findings) == 0 {
+		fmt.Printf("  ✓  No deprecated or removed APIs found.\n")
+		return 0
+	}
+	blockers := 0
+	for _, f := range findings {
+		glyph := "⚠ "
+		if f.Severity == preflight.Blocker {
+			glyph = "✗ "
+			blockers++
+		}
+		fmt.Printf("  %s %s/%s  (%s)\n", glyph, f.Kind, f.Name, f.Source)
+		fmt.Printf("       %s: %s\n", f.Field, f.Message)
+		fmt.Printf("       → %s\n", f.Fix)
+	}
+	fmt.Printf("\n  %d blocker(s), %d warning(s).\n", blockers, len(findings)-blockers)
+	return blockers
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// lint command — NodePool / EC2NodeClass anti-patterns
+// ─────────────────────────────────────────────────────────────────────────────
+
+func lintCmd() *cobra.Command {
+	var kubeCtx, output, failOn string
+	var rules bool
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check live NodePools and EC2NodeClasses for anti-patterns",
+		Long: `Check the NodePools and EC2NodeClasses in the cluster for configurations
+that work but cost money, hurt availability or block upgrades: no limits, a
+single instance type, consolidation turned off, no disruption budgets,
+unconstrained requirements, deprecated fields, and Spot-only pools while
+PodDisruptionBudgets allow no disruptions.
+
+Findings are error, warning or info. The command exits non-zero when any
+finding is at least --fail-on (default error), so it can gate CI; use
+-o json for machine-readable output and --rules to list every rule.`,
+		Example: "  karpx lint -c my-cluster\n  karpx lint -c my-cluster -o json --fail-on warning\n  karpx lint --rules",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rules {
+				for _, r := range lint.Rules {
+					fmt.Printf("  %-22s  %s\n", r.Name, r.Description)
+				}
+				return nil
+			}
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			threshold, err := lint.ParseSeverity(failOn)
+			if err != nil {
+				return err
+			}
+			return runLint(kubeCtx, output, threshold)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	cmd.Flags().StringVar(&failOn, "fail-on", "error", "exit non-zero on findings of this severity or worse: error | warning | info | none")
+	cmd.Flags().BoolVar(&rules, "rules", false, "list the rules and exit")
+	return cmd
+}
+
+// lintReport is the JSON shape of `karpx lint -o json`.
+type lintReport struct {
+	Context  string         `json:"context"`
+	Findings []lint.Finding `json:"findings"`
+}
+
+func runLint(kubeCtx, output string, failOn lint.Severity) error {
+	c, err := lint.Load(kubeCtx)
+	if err != nil {
+		if output == "table" {
+			fmt.Printf("\n  ✗ Could not read NodePools: %v\n\n", err)
+		}
+		return err
+	}
+	findings := lint.Run(c)
+	counts := map[lint.Severity]int{}
+	failing := 0
+	for _, f := range findings {
+		counts[f.Severity]++
+		if failOn != "" && f.Severity.AtLeast(failOn) {
+			failing++
+		}
+	}
+
+	if output == "json" {
+		if findings == nil {
+			findings = []lint.Finding{}
+		}
+		if err := printJSON(lintReport{Context: contextOrCurrent(kubeCtx), Findings: findings}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("\n  karpx lint  context:%s\n\n", contextOrCurrent(kubeCtx))
+		fmt.Printf("  %d NodePool(s), %d EC2NodeClass(es)\n\n", len(c.NodePools), len(c.NodeClasses))
+		if len(findings) == 0 {
+			fmt.Printf("  ✓  No findings.\n\n")
+			return nil
+		}
+		for _, f := range findings {
+			glyph := "ℹ "
+			switch f.Severity {
+			case lint.Error:
+				glyph = "✗ "
+			case lint.Warning:
+				glyph = "⚠ "
+			}
+			fmt.Printf("  %s %s/%s  [%s]\n", glyph, f.Kind, f.Name, f.Rule)
+			if f.Field != "" {
+				fmt.Printf("       %s: %s\n", f.Field, f.Message)
+			} else {
+				fmt.Printf("       %s\n", f.Message)
+			}
+			if f.Fix != "" {
+				fmt.Printf("       → %s\n", f.Fix)
+			}
+		}
+		fmt.Printf("\n  %d error(s), %d warning(s), %d info.\n\n", counts[lint.Error], counts[lint.Warning], counts[lint.Info])
+	}
+	if failing > 0 {
+		return fmt.Errorf("lint found %d finding(s) at or above %s", failing, failOn)
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// convert command — legacy Provisioner / AWSNodeTemplate → v1
+// ─────────────────────────────────────────────────────────────────────────────
+
+func convertCmd() *cobra.Command {
+	var kubeCtx, outFile, nodeRole string
+	var files []string
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert v1alpha5 Provisioners/AWSNodeTemplates to v1 NodePools/EC2NodeClasses",
+		Long: `Read legacy Karpenter v1alpha5 Provisioners and AWSNodeTemplates — from the
+cluster, or from files with -f — and emit equivalent v1 NodePools and
+EC2NodeClasses.
+
+Mapping highlights:
+  requirements / labels / taints     → spec.template
+  providerRef                        → spec.template.spec.nodeClassRef
+  consolidation.enabled: true        → consolidationPolicy: WhenEmptyOrUnderutilized
+  ttlSecondsAfterEmpty               → consolidationPolicy: WhenEmpty + consolidateAfter
+  ttlSecondsUntilExpired             → spec.template.spec.expireAfter
+  kubeletConfiguration               → EC2NodeClass spec.kubelet
+  subnet/securityGroup/amiSelector   → *SelectorTerms
+
+Anything without a direct mapping is flagged as a comment above the object
+and in the summary. Nothing is applied — review the output first.
+
+Templates without an instanceProfile used the global default instance
+profile, which v1 no longer has: pass its node IAM role with --node-role,
+or replace the ` + convert.RolePlaceholder + ` placeholder in the output.`,
+		Example: `  karpx convert -c my-cluster > karpenter-v1.yaml
+  karpx convert -f ./gitops/karpenter -o karpenter-v1.yaml
+  karpx convert -c my-cluster --node-role KarpenterNodeRole-my-cluster`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConvert(kubeCtx, files, outFile, nodeRole)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context (used when no -f is given)")
+	cmd.Flags().StringSliceVarP(&files, "file", "f", nil, "manifest files or directories to convert (repeatable)")
+	cmd.Flags().StringVarP(&outFile, "output", "o", "", "write the converted YAML to this file instead of stdout")
+	cmd.Flags().StringVar(&nodeRole, "node-role", "", "node IAM role for templates that used the global default instance profile")
+	return cmd
+}
+
+func runConvert(kubeCtx string, files []string, outFile, nodeRole string) error {
+	var objs []manifest.Object
+	if len(files) > 0 {
+		for _, f := range files {
+			found, err := manifest.ReadPath(f)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", f, err)
+			}
+			for _, s := range found {
+				objs = append(objs, s.Object)
+			}
+		}
+	} else {
+		for _, res := range []string{"provisioners.karpenter.sh", "awsnodetemplates.karpenter.k8s.aws"} {
+			items, err := manifest.ListCluster(kubeCtx, res, false)
+			if err != nil {
+				continue // CRD not installed — nothing of this kind to convert
+			}
+			objs = append(objs, items...)
+		}
+	}
+
+	res := convert.Convert(objs, convert.Options{NodeRole: nodeRole})
+	if len(res.Objects) == 0 {
+		fmt.Fprintf(os.Stderr, "\n  No Provisioners or AWSNodeTemplates found — nothing to convert.\n\n")
+		return nil
+	}
+
+	out, err := res.YAML()
+	if err != nil {
+		return err
+	}
+	if outFile != "" {
+		if err := os.WriteFile(outFile, []byte(out), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", outFile, err)
+		}
+	} else {
+		fmt.Print(out)
+	}
+
+	// Summary goes to stderr so stdout stays valid YAML when redirected.
+	fmt.Fprintf(os.Stderr, "\n  ✓  Converted %d object(s)", len(res.Objects))
+	if outFile != "" {
+		fmt.Fprintf(os.Stderr, " → %s", outFile)
+	}
+	fmt.Fprintf(os.Stderr, "\n")
+	if len(res.Notes) > 0 {
+		fmt.Fprintf(os.Stderr, "\n  ⚠  %d item(s) need manual review:\n", len(res.Notes))
+		for _, n := range res.Notes {
+			fmt.Fprintf(os.Stderr, "     %s/%s  %s: %s\n", n.Kind, n.Name, n.Field, n.Text)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "\n")
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// iam-policy command — least-privilege controller policy
+// ─────────────────────────────────────────────────────────────────────────────
+
+func iamPolicyCmd() *cobra.Command {
+	var kubeCtx, clusterName, region, partition, accountID, intQueue, nodeRole, output string
+	var profiles, regionCond bool
+	cmd := &cobra.Command{
+		Use:   "iam-policy",
+		Short: "Generate the least-privilege Karpenter controller IAM policy (AWS)",
+		Long: `Generate the Karpenter controller IAM policy for one cluster, trimmed to
+the features it uses, as an IAM JSON document or Terraform.
+
+  • every create, tag and delete permission is scoped to the cluster's
+    kubernetes.io/cluster/<name>: owned tag
+  • SQS permissions are only included with --interruption-queue
+  • instance profile permissions are only included when Karpenter manages
+    instance profiles (EC2NodeClass spec.role; the default)
+  • --region-condition adds aws:RequestedRegion to every EC2 statement
+
+Cluster name, region, account and partition are read from an EKS ARN context
+(-c) when not given. ARNs use the partition of the region (aws-cn for cn-*,
+aws-us-gov for us-gov-*); set --partition for a policy valid in any region.
+Nothing is created — review the output and apply it yourself.`,
+		Example: `  karpx iam-policy --cluster-name my-cluster -r eu-west-1 --interruption-queue my-cluster
+  karpx iam-policy -c arn:aws:eks:eu-west-1:123456789012:cluster/my-cluster -o terraform > karpenter-iam.tf
+  karpx iam-policy --cluster-name my-cluster -r us-gov-west-1 --interruption-queue my-cluster`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "json" && output != "terraform" {
+				return fmt.Errorf("--output must be json or terraform, got %q", output)
+			}
+			switch partition {
+			case "", awscli.PartitionAWS, awscli.PartitionChina, awscli.PartitionGovCloud:
+			default:
+				return fmt.Errorf("--partition must be aws, aws-cn or aws-us-gov, got %q", partition)
+			}
+			if partition != "" && region != "" && awscli.Partition(region) != partition {
+				return fmt.Errorf("region %s is not in the %s partition", region, partition)
+			}
+			return runIAMPolicy(kubeCtx, iampolicy.Options{
+				ClusterName:       clusterName,
+				Region:            region,
+				Partition:         partition,
+				AccountID:         accountID,
+				InterruptionQueue: intQueue,
+				NodeRoleName:      nodeRole,
+				InstanceProfiles:  profiles,
+				RegionCondition:   regionCond,
+			}, output)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "EKS kubeconfig context to read cluster name, region and account from")
+	cmd.Flags().StringVarP(&clusterName, "cluster-name", "n", "", "EKS cluster name")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region (default: from the context; empty = any region)")
+	cmd.Flags().StringVar(&partition, "partition", "", "aws | aws-cn | aws-us-gov (default: from the region or context)")
+	cmd.Flags().StringVar(&accountID, "account-id", "", "AWS account ID (default: from the context, then aws sts)")
+	cmd.Flags().StringVar(&intQueue, "interruption-queue", "", "SQS interruption queue name (omit for no SQS permissions)")
+	cmd.Flags().StringVar(&nodeRole, "node-role", "", "node IAM role name passed to instances (default: KarpenterNodeRole-<cluster>)")
+	cmd.Flags().BoolVar(&profiles, "instance-profiles", true, "grant instance profile management (set false when EC2NodeClasses use spec.instanceProfile)")
+	cmd.Flags().BoolVar(&regionCond, "region-condition", false, "require aws:RequestedRegion on every EC2 statement")
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "output format: json | terraform")
+	return cmd
+}
+
+func runIAMPolicy(kubeCtx string, opts iampolicy.Options, output string) error {
+	// An EKS context ARN carries region, account and cluster name. The
+	// current context is only consulted when nothing names the cluster.
+	if kubeCtx == "" && opts.ClusterName == "" {
+		kubeCtx = kube.ContextName("")
+	}
+	if parts := strings.Split(kubeCtx, ":"); len(parts) >= 6 && awscli.IsARN(kubeCtx, "eks") {
+		if opts.Partition == "" {
+			opts.Partition = parts[1]
+		}
+		if opts.Region == "" {
+			opts.Region = parts[3]
+		}
+		if opts.AccountID == "" {
+			opts.AccountID = parts[4]
+		}
+	}
+	if opts.ClusterName == "" {
+		opts.ClusterName = eksClusterNameFromContext(kubeCtx)
+	}
+	opts.ClusterName = stripARN(opts.ClusterName)
+	if opts.ClusterName == "" {
+		return fmt.Errorf("--cluster-name (or an EKS context with -c) is required")
+	}
+	if opts.AccountID == "" && awscli.Available() {
+		var id struct {
+			Account string `json:"Account"`
+		}
+		if err := awscli.JSON(&id, opts.Region, "sts", "get-caller-identity"); err == nil {
+			opts.AccountID = id.Account
+		}
+	}
+
+	doc := iampolicy.Generate(opts)
+	switch output {
+	case "terraform":
+		fmt.Print(doc.Terraform("KarpenterControllerPolicy-" + opts.ClusterName))
+	default:
+		out, err := doc.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+	}
+
+	// Notes go to stderr so stdout stays a valid document when redirected.
+	fmt.Fprintf(os.Stderr, "\n  ✓  %d statement(s) for cluster %s", len(doc.Statement), opts.ClusterName)
+	if opts.Region != "" {
+		fmt.Fprintf(os.Stderr, " in %s", opts.Region)
+	}
+	fmt.Fprintf(os.Stderr, "\n")
+	if opts.Region == "" {
+		fmt.Fprintf(os.Stderr, "  ⚠  No region — resources match any region. Pass -r to scope them.\n")
+	}
+	if opts.AccountID == "" {
+		fmt.Fprintf(os.Stderr, "  ⚠  No account ID — IAM, SQS and EKS resources match any account. Pass --account-id.\n")
+	}
+	if opts.InterruptionQueue == "" {
+		fmt.Fprintf(os.Stderr, "  ℹ  No interruption queue — SQS permissions omitted.\n")
+	}
+	fmt.Fprintf(os.Stderr, "\n")
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// plugins command — list provider plugins
+// ─────────────────────────────────────────────────────────────────────────────
+
+func pluginsCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "List provider plugins found in ~/.karpx/plugins",
+		Long: `List the provider plugins karpx found and what each one supplies.
+
+A plugin is an executable in ~/.karpx/plugins (or $KARPX_PLUGINS) that adds a
+Karpenter provider karpx does not ship with. karpx sends it one JSON request
+on stdin per call and reads one JSON response from stdout:
+
+  describe   name, label, chart and docs links, capabilities
+  detect     does this cluster (server URL, node providerID) belong to you?
+  compat     release list and Kubernetes compatibility matrix
+  manifest   NodePool / NodeClass YAML for a node recommendation
+
+Plugin providers then show up in detect, the TUI and the dashboard, and
+nodes --provider accepts their names.`,
+		Example: "  karpx plugins\n  karpx plugins -o json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			return runPlugins(output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	return cmd
+}
+
+func runPlugins(output string) error {
+	plugins, errs := plugin.Load()
+	if output == "json" {
+		type entry struct {
+			Path string      `json:"path"`
+			Info plugin.Info `json:"info"`
+		}
+		out := struct {
+			Dir     string   `json:"dir"`
+			Plugins []entry  `json:"plugins"`
+			Errors  []string `json:"errors,omitempty"`
+		}{Dir: plugin.Dir(), Plugins: []entry{}}
+		for _, p := range plugins {
+			out.Plugins = append(out.Plugins, entry{p.Path, p.Info})
+		}
+		for _, err := range errs {
+			out.Errors = append(out.Errors, err.Error())
+		}
+		return printJSON(out)
+	}
+
+	printSection("Provider plugins — " + plugin.Dir())
+	if len(plugins) == 0 && len(errs) == 0 {
+		fmt.Println("  No plugins installed.")
+		fmt.Println()
+		return nil
+	}
+	if len(plugins) > 0 {
+		fmt.Printf("  %-12s %-22s %-14s %-24s %s\n", "PROVIDER", "LABEL", "SUPPORT", "CAPABILITIES", "PATH")
+		for _, p := range plugins {
+			caps := strings.Join(p.Info.Capabilities, ",")
+			if caps == "" {
+				caps = "—"
+			}
+			fmt.Printf("  %-12s %-22s %-14s %-24s %s\n", p.Info.Name, p.Info.Label, p.Info.SupportLevel, caps, p.Path)
+		}
+	}
+	for _, err := range errs {
+		fmt.Printf("  ✗ %v\n", err)
+	}
+	fmt.Println()
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// migrate-namespace command — move a legacy install to a dedicated namespace
+// ─────────────────────────────────────────────────────────────────────────────
+
+func migrateNamespaceCmd() *cobra.Command {
+	var kubeCtx, to string
+	cmd := &cobra.Command{
+		Use:   "migrate-namespace",
+		Short: "Move Karpenter from kube-system or a custom namespace to a dedicated one",
+		Long: `Move a Karpenter install to a dedicated namespace, keeping its version,
+Helm values, NodePools, NodeClasses and running nodes.
+
+The CRDs are protected from deletion and handed to the new release, the old
+controller is scaled to 0, the old release is removed, and the same chart
+version is installed in the target namespace with the deployed values. The
+old release goes first because its ClusterRoles and webhooks share names with
+the new one; if the install then fails, reinstall the release as the error
+describes. Nothing provisions during the move; running nodes are not touched.
+
+With IRSA the controller role's trust policy names the namespace — update it
+before migrating, or the new controller cannot call AWS.`,
+		Example: "  karpx migrate-namespace -c my-cluster\n  karpx migrate-namespace -c my-cluster --to platform-karpenter",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validNamespace(to); err != nil {
+				return err
+			}
+			return runMigrateNamespace(kubeCtx, to)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVar(&to, "to", karpupgrade.DefaultNamespace, "target namespace")
+	return cmd
+}
+
+func runMigrateNamespace(kubeCtx, to string) error {
+	fmt.Printf("\n  karpx migrate-namespace  context:%s\n\n", contextOrCurrent(kubeCtx))
+
+	info, err := helm.DetectKarpenter(kubeCtx)
+	if err != nil || !info.Installed {
+		fmt.Printf("  Karpenter is not installed on this cluster.\n\n")
+		return nil
+	}
+	if err := info.ManagedError(); err != nil {
+		fmt.Printf("  ✗ %v\n\n", err)
+		return err
+	}
+	if info.Namespace == to {
+		fmt.Printf("  ✓  Karpenter is already in namespace %s.\n\n", to)
+		return nil
+	}
+	if info.Chart == "" && !info.Manifests {
+		fmt.Printf("  ✗ Karpenter in %s was not installed with Helm or karpx, so its configuration\n", info.Namespace)
+		fmt.Printf("    cannot be carried over. Uninstall it and run karpx install --namespace %s.\n\n", to)
+		return fmt.Errorf("unmanaged install cannot be migrated")
+	}
+	version := strings.TrimPrefix(info.Version, "v")
+	if version == "" {
+		return fmt.Errorf("installed Karpenter version is unknown")
+	}
+	releaseName := info.ReleaseName
+	if releaseName == "" {
+		releaseName = "karpenter"
+	}
+	crds, err := helm.DetectCRDs(kubeCtx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Release     : %s (v%s)\n", releaseName, version)
+	fmt.Printf("  Namespace   : %s → %s\n", info.Namespace, to)
+	if crds.Release != "" && crds.Namespace == info.Namespace {
+		fmt.Printf("  CRDs        : %s release moves too\n", crds.Release)
+	}
+	if role := karpupgrade.ServiceAccountRole(kubeCtx, info.Namespace, releaseName); role != "" {
+		fmt.Printf("\n  ⚠  IRSA role %s\n", role)
+		fmt.Printf("     Its trust policy must allow system:serviceaccount:%s:%s before the move.\n", to, releaseName)
+	}
+	fmt.Printf("\n  Nothing is provisioned or consolidated while the controller moves.\n")
+	fmt.Printf("  NodePools, NodeClasses and running nodes are kept.\n")
+
+	if !confirmPrompt("\n  Proceed with the migration? [y/N] ") {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+	fmt.Printf("\n")
+
+	stepNum := 0
+	reporter := func(s karpupgrade.Step) {
+		switch {
+		case s.Err != "":
+			fmt.Printf("  ✗ %s\n    %s\n", s.Name, s.Err)
+		case !s.OK:
+			stepNum++
+			fmt.Printf("  [%d] %s\n", stepNum, s.Name)
+		case s.Detail != "":
+			fmt.Printf("      ✓ %s\n", s.Detail)
+		default:
+			fmt.Printf("      ✓ done\n")
+		}
+	}
+	if err := karpupgrade.MigrateNamespace(karpupgrade.MigrateParams{
+		KubeCtx:   kubeCtx,
+		Release:   releaseName,
+		From:      info.Namespace,
+		To:        to,
+		Version:   version,
+		Manifests: info.Manifests,
+		CRDs:      crds,
+	}, reporter); err != nil {
+		fmt.Printf("\n  ✗ Migration failed: %v\n\n", err)
+		return err
+	}
+	fmt.Printf("\n  ✓  Karpenter v%s now runs in %s.\n", version, to)
+	if info.Namespace != "kube-system" && info.Namespace != "default" {
+		fmt.Printf("     Remove the old namespace when it is empty: kubectl delete namespace %s\n", info.Namespace)
+	}
+	fmt.Printf("\n")
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// uninstall command — remove Karpenter from a cluster via helm
+// ─────────────────────────────────────────────────────────────────────────────
+
+func uninstallCmd() *cobra.Command {
+	var kubeCtx  string
+	var deleteNs bool
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Uninstall Karpenter from a cluster",
+		Long: `Uninstall Karpenter from a Kubernetes cluster using Helm.
+
+The Karpenter controller is removed, but NodePool and EC2NodeClass custom
+resources are NOT deleted automatically — remove them manually if needed.`,
+		Example: "  karpx uninstall -c my-cluster\n  karpx uninstall -c my-cluster --delete-namespace",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUninstall(kubeCtx, deleteNs)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().BoolVar(&deleteNs, "delete-namespace", false, "also delete the Karpenter namespace after uninstall")
+	return cmd
+}
+
+func runUninstall(kubeCtx string, deleteNs bool) error {
+	fmt.Printf("\n  karpx uninstall  context:%s\n\n", contextOrCurrent(kubeCtx))
+
+	// ── Detect installed Karpenter ────────────────────────────────────────
+	info, err := helm.DetectKarpenter(kubeCtx)
+	if err != nil || !info.Installed {
+		fmt.Printf("  Karpenter is not installed on this cluster.\n\n")
+		return nil
+	}
+	if err := info.ManagedError(); err != nil {
+		fmt.Printf("  ✗ %v\n\n", err)
+		return err
+	}
+
+	releaseName := info.ReleaseName
+	if releaseName == "" {
+		releaseName = "karpenter"
+	}
+
+	fmt.Printf("  Release     : %s\n", releaseName)
+	fmt.Printf("  Version     : v%s\n", info.Version)
+	fmt.Printf("  Namespace   : %s\n\n", info.Namespace)
+	fmt.Printf("  ⚠  This removes the Karpenter controller.\n")
+	fmt.Printf("     NodePool / EC2NodeClass resources are NOT deleted automatically.\n\n")
+
+	if !confirmPrompt("  Proceed with uninstall? [y/N] ") {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+
+	fmt.Printf("\n  Uninstalling Karpenter…\n")
+	if info.Manifests {
+		// ── Manifest install: delete the recorded objects ─────────────────
+		rel, err := helm.RecordedStatic(kubeCtx)
+		if err != nil {
+			return err
+		}
+		if rel != nil {
+			if errs := helm.UninstallStatic(kubeCtx, rel, false); len(errs) > 0 {
+				for _, e := range errs {
+					fmt.Printf("  ✗ %v\n", e)
+				}
+				return fmt.Errorf("manifest uninstall failed: %d object(s) could not be deleted", len(errs))
+			}
+		}
+	} else {
+		// ── helm uninstall ────────────────────────────────────────────────
+		args := []string{"uninstall", releaseName, "--namespace", info.Namespace}
+		if kubeCtx != "" {
+			args = append(args, "--kube-context", kubeCtx)
+		}
+
+		helmCmd := exec.Command("helm", args...)
+		helmCmd.Stdout = os.Stdout
+		helmCmd.Stderr = os.Stderr
+		if err := helmCmd.Run(); err != nil {
+			return fmt.Errorf("helm uninstall failed: %w", err)
+		}
+	}
+	fmt.Printf("\n  ✓  Karpenter uninstalled.\n")
+
+	// ── Optionally delete the namespace ───────────────────────────────────
+	if deleteNs {
+		fmt.Printf("  Deleting namespace %q…\n", info.Namespace)
+		nsArgs := []string{"delete", "namespace", info.Namespace}
+		if kubeCtx != "" {
+			nsArgs = append(nsArgs, "--context", kubeCtx)
+		}
+		nsCmd := exec.Command("kubectl", nsArgs...)
+		nsCmd.Stdout = os.Stdout
+		nsCmd.Stderr = os.Stderr
+		if err := nsCmd.Run(); err != nil {
+			fmt.Printf("  ⚠  Could not delete namespace: %v\n", err)
+		} else {
+			fmt.Printf("  ✓  Namespace %q deleted.\n", info.Namespace)
+		}
+	}
+
+	fmt.Printf("\n  To remove remaining CRDs:\n")
+	fmt.Printf("    kubectl delete nodepools,ec2nodeclasses --all\n\n")
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// pause / resume commands — maintenance windows
+// ─────────────────────────────────────────────────────────────────────────────
+
+func pauseCmd() *cobra.Command {
+	var kubeCtx, modeFlag, reason string
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Temporarily stop Karpenter from provisioning or disrupting nodes",
+		Long: `Pause Karpenter for a maintenance window.
+
+Modes:
+  disruption  (default) set every NodePool's disruption budget to nodes: "0".
+              Pending pods still get capacity, but no node is consolidated
+              or drifted until resume. Expiry ignores disruption budgets:
+              nodes that reach expireAfter are still replaced.
+  controller  scale the Karpenter controller to zero replicas.
+              Nothing is provisioned or disrupted until resume.
+
+The previous state is recorded in the karpx-pause-state ConfigMap in the
+Karpenter namespace, so 'karpx resume' restores it exactly.`,
+		Example: "  karpx pause -c my-cluster\n  karpx pause -c my-cluster --mode controller --reason \"AMI rollout\"",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPause(kubeCtx, modeFlag, reason)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVar(&modeFlag, "mode", "disruption", "pause mode: disruption | controller")
+	cmd.Flags().StringVar(&reason, "reason", "", "free-text note stored with the pause state")
+	return cmd
+}
+
+func resumeCmd() *cobra.Command {
+	var kubeCtx string
+	cmd := &cobra.Command{
+		Use:     "resume",
+		Short:   "Restore Karpenter to the state recorded by 'karpx pause'",
+		Example: "  karpx resume -c my-cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runResume(kubeCtx)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	return cmd
+}
+
+func runPause(kubeCtx, modeFlag, reason string) error {
+	fmt.Printf("\n  ⏸  karpx pause  context:%s\n\n", contextOrCurrent(kubeCtx))
+
+	mode, err := pause.ParseMode(modeFlag)
+	if err != nil {
+		return err
+	}
+
+	info, err := helm.DetectKarpenter(kubeCtx)
+	if err != nil || !info.Installed {
+		fmt.Printf("  ✗ Karpenter is not installed on this cluster.\n\n")
+		return nil
+	}
+	if err := info.ManagedError(); err != nil {
+		fmt.Printf("  ✗ %v\n\n", err)
+		return err
+	}
+	ns := info.Namespace
+	if ns == "" {
+		ns = "karpenter"
+	}
+	deploymentName := info.ReleaseName
+	if deploymentName == "" {
+		deploymentName = "karpenter"
+	}
+
+	if st, err := pause.Status(kubeCtx, ns); err != nil {
+		return err
+	} else if st != nil {
+		fmt.Printf("  ℹ  Already paused (%s mode) since %s.\n", st.Mode, st.PausedAt.Local().Format("2006-01-02 15:04"))
+		fmt.Printf("     Run `karpx resume -c %s` to restore.\n\n", contextOrCurrent(kubeCtx))
+		return nil
+	}
+
+	fmt.Printf("  Namespace   : %s\n", ns)
+	fmt.Printf("  Mode        : %s\n", mode)
+	if reason != "" {
+		fmt.Printf("  Reason      : %s\n", reason)
+	}
+	switch mode {
+	case pause.ModeController:
+		fmt.Printf("\n  ⚠  The controller will be scaled to 0 — pending pods will NOT get new nodes.\n")
+	case pause.ModeDisruption:
+		fmt.Printf("\n  ℹ  All NodePool disruption budgets will be set to nodes: \"0\".\n")
+		fmt.Printf("     Provisioning continues; consolidation and drift are blocked.\n")
+		fmt.Printf("     Expiry ignores budgets — nodes past expireAfter are still replaced.\n")
+	}
+
+	if !confirmPrompt("\n  Pause Karpenter? [y/N] ") {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+
+	st, err := pause.Pause(pause.Params{
+		KubeCtx:        kubeCtx,
+		Namespace:      ns,
+		DeploymentName: deploymentName,
+		Mode:           mode,
+		Reason:         reason,
+	})
+	if err != nil {
+		fmt.Printf("\n  ✗ Pause failed: %v\n", err)
+		if st != nil {
+			fmt.Printf("    State was recorded — run `karpx resume -c %s` to roll back.\n", contextOrCurrent(kubeCtx))
+		}
+		fmt.Println()
+		return err
+	}
+
+	switch st.Mode {
+	case pause.ModeController:
+		fmt.Printf("\n  ✓  Controller %s scaled to 0 (was %d).\n", st.Deployment, st.Replicas)
+	case pause.ModeDisruption:
+		fmt.Printf("\n  ✓  Disruption blocked on %d NodePool(s).\n", len(st.Budgets))
+	}
+	fmt.Printf("  ► Run `karpx resume -c %s` when the window ends.\n\n", contextOrCurrent(kubeCtx))
+	return nil
+}
+
+func runResume(kubeCtx string) error {
+	fmt.Printf("\n  ▶  karpx resume  context:%s\n\n", contextOrCurrent(kubeCtx))
+
+	ns := "karpenter"
+	if info, err := helm.DetectKarpenter(kubeCtx); err == nil && info.Installed && info.Namespace != "" {
+		ns = info.Namespace
+	}
+
+	st, err := pause.Status(kubeCtx, ns)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		fmt.Printf("  ✓  Karpenter is not paused — nothing to do.\n\n")
+		return nil
+	}
+
+	fmt.Printf("  Paused since : %s\n", st.PausedAt.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("  Mode         : %s\n", st.Mode)
+	if st.Reason != "" {
+		fmt.Printf("  Reason       : %s\n", st.Reason)
+	}
+	switch st.Mode {
+	case pause.ModeController:
+		fmt.Printf("  Restore      : scale %s back to %d replica(s)\n", st.Deployment, st.Replicas)
+	case pause.ModeDisruption:
+		fmt.Printf("  Restore      : original budgets on %d NodePool(s)\n", len(st.Budgets))
+	}
+
+	if !confirmPrompt("\n  Resume Karpenter? [y/N] ") {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+
+	if _, err := pause.Resume(kubeCtx, ns); err != nil {
+		fmt.Printf("\n  ✗ Resume failed: %v\n\n", err)
+		return err
+	}
+	fmt.Printf("\n  ✓  Karpenter resumed.\n\n")
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// cleanup command — orphaned cloud resources
+// ─────────────────────────────────────────────────────────────────────────────
+
+func cleanupCmd() *cobra.Command {
+	var kubeCtx, clusterName, region string
+	var minAge time.Duration
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Find and delete cloud resources Karpenter left behind (AWS)",
+		Long: `Find EC2 instances, launch templates, and ENIs tagged by Karpenter for this
+cluster that no longer correspond to any Node or NodeClaim — for example after
+a botched uninstall — and offer to delete them.
+
+The context must be the cluster named by --cluster-name: karpx compares its
+API server with the EKS endpoint and refuses to continue when they differ.
+--min-age applies to ENIs too, for VPC CNI versions that tag their creation
+time.
+
+The orphan listing is always printed first. Nothing is deleted without
+confirmation; pass --dry-run to only list.`,
+		Example: "  karpx cleanup -c my-cluster --dry-run\n  karpx cleanup -c my-cluster -n my-cluster -r us-east-1",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCleanup(kubeCtx, clusterName, region, minAge, dryRun)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&clusterName, "cluster-name", "n", "", "EKS cluster name (default: from context)")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region (default: from context)")
+	cmd.Flags().DurationVar(&minAge, "min-age", 15*time.Minute, "ignore resources younger than this (still joining)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list orphaned resources without deleting")
+	return cmd
+}
+
+func runCleanup(kubeCtx, clusterName, region string, minAge time.Duration, dryRun bool) error {
+	fmt.Printf("\n  karpx cleanup  context:%s\n\n", contextOrCurrent(kubeCtx))
+
+	if provider := kube.DetectProvider(kubeCtx); provider != kube.ProviderAWS && provider != kube.ProviderUnknown {
+		fmt.Printf("  ✗ cleanup currently supports AWS EKS only (detected %s).\n\n", provider.Meta().Label)
+		return nil
+	}
+
+	clusterName = stripARN(askIfEmpty(clusterName, "EKS cluster name", eksClusterNameFromContext(kubeCtx)))
+	if clusterName == "" {
+		return fmt.Errorf("cluster name is required")
+	}
+	if region == "" {
+		region = awscli.RegionFromContext(kubeCtx)
+	}
+	region = askIfEmpty(region, "AWS region", "us-east-1")
+
+	fmt.Printf("  Cluster     : %s\n", clusterName)
+	fmt.Printf("  Region      : %s\n", region)
+	fmt.Printf("  Min age     : %s\n\n", minAge)
+	fmt.Printf("  Scanning EC2 for Karpenter resources with no matching Node/NodeClaim…\n\n")
+
+	orphans, err := cleanup.Find(cleanup.Params{
+		KubeCtx:     kubeCtx,
+		ClusterName: clusterName,
+		Region:      region,
+		MinAge:      minAge,
+	})
+	if err != nil {
+		fmt.Printf("  ✗ Scan failed: %v\n\n", err)
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Printf("  ✓  No orphaned resources found.\n\n")
+		return nil
+	}
+
+	fmt.Printf("  %-18s  %-22s  %-28s  %s\n", "KIND", "ID", "NAME", "DETAIL")
+	fmt.Printf("  %s\n", strings.Repeat("─", 96))
+	for _, r := range orphans {
+		name := r.Name
+		if len(name) > 28 {
+			name = name[:27] + "…"
+		}
+		fmt.Printf("  %-18s  %-22s  %-28s  %s\n", r.Kind, r.ID, name, r.Detail)
+	}
+	fmt.Printf("\n  %d orphaned resource(s).\n", len(orphans))
+
+	if dryRun {
+		fmt.Printf("  (dry run — nothing deleted)\n\n")
+		return nil
+	}
+
+	fmt.Printf("\n  ⚠  Deletion is permanent. Instances are terminated, not stopped.\n")
+	if !confirmPrompt("  Delete all resources listed above? [y/N] ") {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+	fmt.Println()
+
+	failed := 0
+	for _, r := range orphans {
+		if err := cleanup.Delete(region, r); err != nil {
+			failed++
+			fmt.Printf("  ✗ %s %s: %v\n", r.Kind, r.ID, err)
+			continue
+		}
+		fmt.Printf("  ✓  deleted %s %s\n", r.Kind, r.ID)
+	}
+	if failed > 0 {
+		fmt.Printf("\n  ⚠  %d of %d deletions failed.\n\n", failed, len(orphans))
+		return fmt.Errorf("%d deletions failed", failed)
+	}
+	fmt.Printf("\n  ✓  Cleanup complete.\n\n")
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// drift command — nodes on outdated AMIs
+// ─────────────────────────────────────────────────────────────────────────────
+
+func driftCmd() *cobra.Command {
+	var kubeCtx, region, output string
+	var repin, rotate bool
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Find nodes on outdated AMIs and repin or rotate them (AWS)",
+		Long: `Compare the AMI each Karpenter node runs with what its EC2NodeClass
+selects today, and check pinned alias versions (al2023@v20240807) against the
+latest EKS-optimized release for the cluster's Kubernetes version.
+
+Remediation, offered after the report or chosen with flags:
+  --repin   point outdated alias pins at the latest release. Karpenter marks
+            the old nodes drifted and replaces them within the NodePool
+            disruption budgets.
+  --rotate  replace nodes on a stale AMI one at a time: each NodeClaim is
+            deleted, Karpenter drains it (honouring PodDisruptionBudgets),
+            and karpx waits for it to terminate before the next one.`,
+		Example: "  karpx drift -c my-cluster\n  karpx drift -c my-cluster --repin --yes\n  karpx drift -c my-cluster --rotate\n  karpx drift -c my-cluster -o json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			return runDrift(kubeCtx, region, output, repin, rotate, timeout)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region (default: from context)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	cmd.Flags().BoolVar(&repin, "repin", false, "update outdated alias pins to the latest release")
+	cmd.Flags().BoolVar(&rotate, "rotate", false, "replace nodes on a stale AMI one at a time")
+	cmd.Flags().DurationVar(&timeout, "node-timeout", 15*time.Minute, "how long --rotate waits for each node to drain and terminate")
+	return cmd
+}
+
+func runDrift(kubeCtx, region, output string, repin, rotate bool, timeout time.Duration) error {
+	quiet := output == "json"
+	if !quiet {
+		fmt.Printf("\n  karpx drift  context:%s\n\n", contextOrCurrent(kubeCtx))
+	}
+	if provider := kube.DetectProvider(kubeCtx); provider != kube.ProviderAWS && provider != kube.ProviderUnknown {
+		fmt.Printf("  ✗ drift currently supports AWS EKS only (detected %s).\n\n", provider.Meta().Label)
+		return nil
+	}
+	if region == "" {
+		region = awscli.RegionFromContext(kubeCtx)
+	}
+	k8sVer, err := kube.GetServerVersion(kubeCtx)
+	if err != nil {
+		return err
+	}
+	if region == "" && !quiet {
+		fmt.Printf("  ℹ  No AWS region (--region) — pinned AMI versions are not checked.\n\n")
+	} else if !awscli.Available() {
+		region = ""
+		if !quiet {
+			fmt.Printf("  ℹ  aws CLI not found — pinned AMI versions are not checked.\n\n")
+		}
+	}
+
+	classes, err := amidrift.Check(kubeCtx, region, k8sVer)
+	if err != nil {
+		if !quiet {
+			fmt.Printf("  ✗ %v\n\n", err)
+		}
+		return err
+	}
+	if quiet {
+		return printJSON(classes)
+	}
+	if len(classes) == 0 {
+		fmt.Printf("  No EC2NodeClasses found.\n\n")
+		return nil
+	}
+
+	var stale []amidrift.NodeAMI
+	var outdated []amidrift.ClassDrift
+	fmt.Printf("  %-24s  %-34s  %5s  %5s  %s\n", "EC2NODECLASS", "AMI SELECTOR", "NODES", "STALE", "PIN")
+	fmt.Printf("  %s\n", strings.Repeat("─", 96))
+	for _, c := range classes {
+		pin := "—"
+		switch {
+		case c.Outdated:
+			pin = "⚠ " + c.LatestVersion + " available"
+			outdated = append(outdated, c)
+		case c.LatestVersion != "":
+			pin = "✓ latest"
+		}
+		sel := c.Selector
+		if len(sel) > 34 {
+			sel = sel[:33] + "…"
+		}
+		fmt.Printf("  %-24s  %-34s  %5d  %5d  %s\n", c.Name, sel, c.Nodes, len(c.Stale), pin)
+		stale = append(stale, c.Stale...)
+	}
+
+	if len(stale) > 0 {
+		fmt.Println()
+		printSection("Nodes on a stale AMI")
+		fmt.Println()
+		fmt.Printf("  %-30s  %-44s  %-22s  %s\n", "NODECLAIM", "NODE", "AMI", "DRIFTED")
+		for _, n := range stale {
+			drifted := "no"
+			if n.Drifted {
+				drifted = "yes — held by budgets or do-not-disrupt"
+			}
+			node := n.Node
+			if node == "" {
+				node = "—"
+			}
+			fmt.Printf("  %-30s  %-44s  %-22s  %s\n", n.NodeClaim, node, n.ImageID, drifted)
+		}
+	}
+	fmt.Println()
+	if len(stale) == 0 && len(outdated) == 0 {
+		fmt.Printf("  ✓  Every node runs the AMI its EC2NodeClass selects.\n\n")
+		return nil
+	}
+
+	// ── Repin outdated aliases ────────────────────────────────────────────
+	// With --yes only the remediation asked for by flag runs.
+	yes := prompt.AssumeYes()
+	for _, c := range outdated {
+		ok := repin && yes
+		if !yes {
+			ok = confirmPrompt(fmt.Sprintf("  Repin %s from %s to %s? [y/N] ", c.Name, c.Alias, c.LatestAlias()))
+		}
+		if !ok {
+			fmt.Printf("  ► karpx drift -c %s --repin\n", contextOrCurrent(kubeCtx))
+			continue
+		}
+		if err := amidrift.Repin(kubeCtx, c.Name, c.LatestAlias()); err != nil {
+			fmt.Printf("  ✗ %s: %v\n\n", c.Name, err)
+			return err
+		}
+		fmt.Printf("  ✓  %s now selects %s — Karpenter will drift its %d node(s) within the disruption budgets.\n", c.Name, c.LatestAlias(), c.Nodes)
+	}
+
+	// ── Rotate stale nodes ────────────────────────────────────────────────
+	if len(stale) > 0 {
+		ok := rotate && yes
+		if !yes {
+			ok = confirmPrompt(fmt.Sprintf("\n  Rotate the %d stale node(s) one at a time? [y/N] ", len(stale)))
+		}
+		if !ok {
+			fmt.Printf("  ► karpx drift -c %s --rotate\n\n", contextOrCurrent(kubeCtx))
+			return nil
+		}
+		fmt.Println()
+		err := amidrift.Rotate(kubeCtx, stale, timeout, func(n amidrift.NodeAMI, done bool, err error) {
+			switch {
+			case !done:
+				fmt.Printf("  … draining %s %s\n", n.NodeClaim, n.Node)
+			case err != nil:
+				fmt.Printf("  ✗ %v\n", err)
+			default:
+				fmt.Printf("  ✓  %s replaced\n", n.NodeClaim)
+			}
+		})
+		if err != nil {
+			fmt.Println()
+			return err
+		}
+		fmt.Printf("\n  ✓  %d node(s) rotated onto the current AMI.\n", len(stale))
+	}
+	fmt.Println()
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// benchmark command — provisioning latency
+// ─────────────────────────────────────────────────────────────────────────────
+
+func benchmarkCmd() *cobra.Command {
+	var kubeCtx, nodePool, cpu, memory, output string
+	var pods int
+	var timeout time.Duration
+	var keep, cleanupOnly bool
+	cmd := &cobra.Command{
+		Use:   "benchmark",
+		Short: "Measure how fast Karpenter provisions nodes for a burst of pending pods",
+		Long: `Create a burst of pending pods on one NodePool and time each one from
+creation to scheduled, node Ready and container running. karpx reports
+p50/p95/max for each milestone and the instance and capacity types
+Karpenter launched, then deletes the pods — Karpenter consolidates the nodes
+afterwards.
+
+Run it before and after changing the Karpenter version or NodePool settings
+to compare them. The pods are pause containers in the karpx-benchmark
+namespace; they tolerate every taint and select the NodePool with
+karpenter.sh/nodepool. New nodes are billed while they run.`,
+		Example: "  karpx benchmark -c my-cluster\n  karpx benchmark -c my-cluster --nodepool batch --pods 50 --cpu 2 --memory 4Gi\n  karpx benchmark -c my-cluster -o json > v1.5.json\n  karpx benchmark -c my-cluster --cleanup",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			if cleanupOnly {
+				if err := benchmark.Cleanup(kubeCtx, ""); err != nil {
+					return err
+				}
+				fmt.Printf("  ✓  Namespace %s deleted.\n", benchmark.DefaultNamespace)
+				return nil
+			}
+			p := benchmark.Params{KubeCtx: kubeCtx, NodePool: nodePool, Pods: pods, CPU: cpu, Memory: memory, Timeout: timeout}
+			return runBenchmark(p, output, keep)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVar(&nodePool, "nodepool", "karpx-default", "NodePool the pods select")
+	cmd.Flags().IntVar(&pods, "pods", 20, "number of pods in the burst")
+	cmd.Flags().StringVar(&cpu, "cpu", "1", "CPU request per pod")
+	cmd.Flags().StringVar(&memory, "memory", "1Gi", "memory request per pod")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "stop waiting for pods after this long")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	cmd.Flags().BoolVar(&keep, "keep", false, "leave the pods running after the measurement")
+	cmd.Flags().BoolVar(&cleanupOnly, "cleanup", false, "only delete the pods of an earlier --keep or interrupted run")
+	return cmd
+}
+
+func runBenchmark(p benchmark.Params, output string, keep bool) error {
+	quiet := output == "json"
+	w := io.Writer(os.Stdout)
+	if quiet {
+		// stdout carries only the JSON result.
+		w = os.Stderr
+		prompt.SetOutput(w)
+	} else {
+		fmt.Printf("\n  ⏱  karpx benchmark  context:%s\n\n", contextOrCurrent(p.KubeCtx))
+		fmt.Printf("  NodePool    : %s\n", p.NodePool)
+		fmt.Printf("  Burst       : %d pods × %s CPU, %s memory\n", p.Pods, p.CPU, p.Memory)
+		fmt.Printf("  Timeout     : %s\n", p.Timeout)
+		fmt.Printf("\n  ⚠  Karpenter will launch real nodes for these pods; they are billed until consolidated.\n")
+	}
+	if !confirmPrompt("\n  Start the benchmark? [y/N] ") {
+		fmt.Fprintf(w, "  Cancelled.\n\n")
+		return nil
+	}
+
+	progress := func(running, total int) {
+		if !quiet {
+			fmt.Printf("\r  Running: %d/%d pods", running, total)
+		}
+	}
+	res, err := benchmark.Run(p, progress)
+	if !quiet {
+		fmt.Println()
+	}
+	if !keep {
+		// Also after a failed run: the namespace may already hold pods.
+		if cerr := benchmark.Cleanup(p.KubeCtx, ""); cerr != nil && !quiet {
+			fmt.Printf("  ⚠  %v — delete it with `karpx benchmark --cleanup`\n", cerr)
+		}
+	}
+	if err != nil {
+		if !quiet {
+			fmt.Printf("  ✗ %v\n\n", err)
+		}
+		return err
+	}
+	if quiet {
+		return printJSON(res)
+	}
+
+	fmt.Println()
+	printSection("Latency from pod creation")
+	fmt.Println()
+	fmt.Printf("  %-14s  %5s  %8s  %8s  %8s\n", "MILESTONE", "PODS", "P50", "P95", "MAX")
+	for _, row := range []struct {
+		name string
+		p    benchmark.Percentiles
+	}{{"scheduled", res.Scheduled}, {"node ready", res.NodeReady}, {"running", res.Running}} {
+		if row.p.Count == 0 {
+			fmt.Printf("  %-14s  %5d  %8s  %8s  %8s\n", row.name, 0, "—", "—", "—")
+			continue
+		}
+		fmt.Printf("  %-14s  %5d  %8s  %8s  %8s\n", row.name, row.p.Count,
+			row.p.P50.Round(time.Second), row.p.P95.Round(time.Second), row.p.Max.Round(time.Second))
+	}
+	fmt.Printf("\n  New nodes   : %d\n", res.NewNodes)
+	if len(res.InstanceTypes) > 0 {
+		var types []string
+		for t, n := range res.InstanceTypes {
+			types = append(types, fmt.Sprintf("%s×%d", t, n))
+		}
+		sort.Strings(types)
+		fmt.Printf("  Instances   : %s\n", strings.Join(types, ", "))
+		var caps []string
+		for t, n := range res.CapacityTypes {
+			caps = append(caps, fmt.Sprintf("%s×%d", t, n))
+		}
+		sort.Strings(caps)
+		fmt.Printf("  Capacity    : %s\n", strings.Join(caps, ", "))
+	}
+	if reused := len(res.Pods) - res.TimedOut - res.NodeReady.Count; reused > 0 {
+		fmt.Printf("  ℹ  %d pod(s) landed on existing nodes and are left out of node ready.\n", reused)
+	}
+	if res.TimedOut > 0 {
+		fmt.Printf("  ⚠  %d pod(s) were not running after %s.\n", res.TimedOut, p.Timeout)
+	}
+	if keep {
+		fmt.Printf("\n  ℹ  Pods kept in %s — remove them with `karpx benchmark -c %s --cleanup`.\n\n", benchmark.DefaultNamespace, contextOrCurrent(p.KubeCtx))
+	} else {
+		fmt.Printf("\n  ✓  Benchmark pods deleted — Karpenter will consolidate the new nodes.\n\n")
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// nodes command — analyse workloads and generate/apply a NodePool config
+// ─────────────────────────────────────────────────────────────────────────────
+
+func nodesCmd() *cobra.Command {
+	var kubeCtx, providerFlag, modeFlag, promURL, tenancyFlag, teamLabel, cniFlag, amiFamily, gpuSharing string
+	var fromFiles, userDataFiles []string
+	var window, sampleInterval time.Duration
+	var growth nodes.Growth
+	var volume nodes.VolumeOptions
+	var gpu nodes.GPUOptions
+	var out manifestOutput
+	cmd := &cobra.Command{
+		Use:   "nodes",
+		Short: "Analyse workloads and generate an optimised Karpenter NodePool",
+		Long: `Analyse all running workloads in the cluster, ask how you want to
+optimise node provisioning, then generate (and optionally apply) the ideal
+Karpenter NodePool + NodeClass configuration.
+
+Optimisation modes:
+  cost        — Spot instances, Graviton (arm64) where available, lowest $/hour
+  balanced    — Mixed Spot + On-Demand, multiple families
+  performance — On-Demand only, latest-gen instances, maximum throughput
+  freetier    — Free-tier eligible instances only (m7i-flex, c7i-flex, t3, t4g)
+
+By default the profile is a snapshot of the pods running right now. With
+--window, demand is taken at p95 across the window instead — from Prometheus
+(kube-state-metrics) when --prometheus is set, otherwise by sampling the
+cluster every --sample-interval until the window has elapsed.
+
+GPU workloads get their own NodePool, tainted nvidia.com/gpu:NoSchedule so
+nothing else lands on expensive GPU nodes. karpx asks how much GPU memory a
+pod needs and whether pods share GPUs (time-slicing or MIG), picks the GPU
+families that fit, and checks that the NVIDIA device plugin or GPU operator
+is installed. The --gpu-* flags answer those questions up front (AWS).
+
+After generation karpx asks whether to apply, save or skip the manifest.
+--apply, --save and --print answer that up front for scripts and CI; with
+--print only the YAML goes to stdout and everything else to stderr.
+`,
+		Example: `  karpx nodes -c my-cluster
+  karpx nodes -c my-cluster --mode cost
+  karpx nodes -c my-cluster --provider aws --mode performance
+
+  # Size for the last week's p95 demand, including nightly batch peaks:
+  karpx nodes -c my-cluster --prometheus http://localhost:9090 --window 168h
+
+  # No Prometheus — sample the cluster every 5 minutes for 2 hours:
+  karpx nodes -c my-cluster --window 2h --sample-interval 5m
+
+  # Single-tenant hardware for regulated workloads (AWS):
+  karpx nodes -c my-cluster --mode performance --tenancy dedicated
+
+  # One tainted NodePool per team, from the "team" namespace label:
+  karpx nodes -c my-cluster --mode balanced --team-label team
+
+  # A tainted GPU pool where four 5 GiB pods time-slice each GPU:
+  karpx nodes -c my-cluster --gpu-memory 5 --gpu-sharing time-slicing --gpu-replicas 4
+
+  # A100/H100 nodes split into isolated MIG slices:
+  karpx nodes -c my-cluster --gpu-sharing mig --mig-profile 1g.10gb --gpu-limit 16
+
+  # Plan for 40% growth and keep 20% of every node free:
+  karpx nodes -c my-cluster --growth-factor 1.4 --headroom-percent 20
+
+  # Room for large images, with faster gp3 root volumes (AWS):
+  karpx nodes -c my-cluster --min-volume-size 100 --volume-iops 6000 --volume-throughput 250
+
+  # Plan pod density for a VPC CNI with prefix delegation (detected when live):
+  karpx nodes --from-file ./k8s --provider aws --cni prefix
+
+  # Bottlerocket nodes with registry mirror settings merged into userData:
+  karpx nodes -c my-cluster --ami-family bottlerocket --user-data ./mirrors.toml
+
+  # Plan for a cluster that does not exist yet:
+  karpx nodes --from-file ./k8s --provider aws --mode cost
+  helm template my-app ./chart | karpx nodes --from-file - --provider aws
+
+  # No menu — for scripts and CI:
+  karpx nodes -c my-cluster --mode balanced --apply
+  karpx nodes -c my-cluster --mode cost --save ./gitops/nodepool.yaml
+  karpx nodes -c my-cluster --mode cost --print > nodepool.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(fromFiles) > 0 && (window > 0 || promURL != "" || teamLabel != "") {
+				return fmt.Errorf("--window, --prometheus and --team-label need a live cluster; they cannot be combined with --from-file")
+			}
+			// The spec read from stdin used it up: later questions would
+			// only see EOF, so take their defaults without asking.
+			for _, f := range fromFiles {
+				if f == "-" {
+					prompt.SetNoInput(true)
+				}
+			}
+			if err := out.Validate(); err != nil {
+				return err
+			}
+			if out.Print {
+				prompt.SetOutput(os.Stderr)
+			}
+			if promURL != "" && window == 0 {
+				window = 7 * 24 * time.Hour
+			}
+			tenancy, err := nodes.ParseTenancy(tenancyFlag)
+			if err != nil {
+				return err
+			}
+			if err := growthDefaults(cmd, &growth); err != nil {
+				return err
+			}
+			if err := volume.Validate(); err != nil {
+				return err
+			}
+			cni, err := kube.ParseCNI(cniFlag)
+			if err != nil {
+				return err
+			}
+			userData, err := userDataOption(amiFamily, userDataFiles)
+			if err != nil {
+				return err
+			}
+			opts := nodeOptions{Tenancy: tenancy, Growth: growth, Volume: volume, CNI: cni, UserData: userData,
+				Currency: awscli.Currency(awscli.RegionFromContext(kubeCtx))}
+			for _, f := range []string{"gpu-memory", "gpu-sharing", "gpu-replicas", "mig-profile", "gpu-limit"} {
+				if cmd.Flags().Changed(f) {
+					if gpu.Sharing, err = nodes.ParseGPUSharing(gpuSharing); err != nil {
+						return err
+					}
+					if err := gpu.Validate(); err != nil {
+						return err
+					}
+					opts.GPU = &gpu
+					break
+				}
+			}
+			return runNodes(kubeCtx, providerFlag, modeFlag, fromFiles, promURL, window, sampleInterval, opts, teamLabel, out)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVar(&providerFlag, "provider", "", "cloud provider: aws | azure | gcp (default: auto-detect)")
+	cmd.Flags().StringVar(&modeFlag, "mode", "", "optimisation mode: cost | balanced | performance | freetier (default: ask)")
+	cmd.Flags().StringSliceVar(&fromFiles, "from-file", nil, "build the workload profile from manifest files/directories (or - for stdin) instead of the cluster")
+	cmd.Flags().StringVar(&promURL, "prometheus", "", "Prometheus URL to read p95 demand from (kube-state-metrics; default window 168h)")
+	cmd.Flags().DurationVar(&window, "window", 0, "size for p95 demand over this window instead of a point-in-time snapshot")
+	cmd.Flags().DurationVar(&sampleInterval, "sample-interval", 5*time.Minute, "how often to sample the cluster when --window is set without --prometheus")
+	cmd.Flags().StringVar(&tenancyFlag, "tenancy", "", "dedicated — single-tenant hardware for compliance workloads (AWS)")
+	cmd.Flags().StringVar(&teamLabel, "team-label", "", "namespace label key (e.g. team) — also generate one isolated NodePool per team")
+	cmd.Flags().Float64Var(&growth.Factor, "growth-factor", 0, "plan for this multiple of today's demand, e.g. 1.3 (default: config file, else 1)")
+	cmd.Flags().Float64Var(&growth.HeadroomPercent, "headroom-percent", 0, "percent of every node to keep free (default: config file, else 0)")
+	cmd.Flags().StringVar(&cniFlag, "cni", "", "pod networking: vpc-cni | prefix | ipv6 | overlay (AWS; default: detected from kube-system)")
+	cmd.Flags().StringVar(&amiFamily, "ami-family", "al2023", "EC2NodeClass AMI family: al2023 | bottlerocket (AWS)")
+	cmd.Flags().StringSliceVar(&userDataFiles, "user-data", nil, "userData snippet to merge into the EC2NodeClass — script, cloud-config, NodeConfig or MIME for al2023, TOML for bottlerocket (repeatable; AWS)")
+	cmd.Flags().IntVar(&volume.MinGiB, "min-volume-size", 0, "minimum root volume size in GiB (AWS; default: sized from images and ephemeral-storage requests)")
+	cmd.Flags().IntVar(&volume.IOPS, "volume-iops", 0, "gp3 root volume IOPS, 3000–16000 (AWS; default: 3000)")
+	cmd.Flags().IntVar(&volume.Throughput, "volume-throughput", 0, "gp3 root volume throughput in MiB/s, 125–1000 (AWS; default: 125)")
+	cmd.Flags().IntVar(&gpu.MemoryGiB, "gpu-memory", 0, "GPU memory one pod needs in GiB — picks the GPU families (AWS; default: ask when GPU pods are found)")
+	cmd.Flags().StringVar(&gpuSharing, "gpu-sharing", "none", "how pods share a GPU: none | time-slicing | mig (AWS)")
+	cmd.Flags().IntVar(&gpu.Replicas, "gpu-replicas", 0, "pods per GPU with --gpu-sharing time-slicing (default: 4)")
+	cmd.Flags().StringVar(&gpu.MIGProfile, "mig-profile", "", "MIG slice with --gpu-sharing mig, e.g. 1g.10gb (default: smallest that fits --gpu-memory)")
+	cmd.Flags().IntVar(&gpu.Limit, "gpu-limit", 0, "GPUs the GPU NodePool may launch (default: 8)")
+	cmd.Flags().BoolVar(&out.Apply, "apply", false, "apply the generated manifest without asking")
+	cmd.Flags().StringVar(&out.Save, "save", "", "write the generated manifest to this path without asking")
+	cmd.Flags().BoolVar(&out.Print, "print", false, "print only the manifest to stdout (progress goes to stderr) and exit")
+	return cmd
+}
+
+// manifestOutput is what to do with a generated manifest instead of asking:
+// at most one field is set.
+type manifestOutput struct {
+	Apply bool
+	Save  string
+	Print bool
+}
+
+func (o manifestOutput) Validate() error {
+	n := 0
+	for _, set := range []bool{o.Apply, o.Save != "", o.Print} {
+		if set {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("--apply, --save and --print cannot be combined")
+	}
+	return nil
+}
+
+func runNodes(kubeCtx, providerFlag, modeFlag string, fromFiles []string, promURL string, window, sampleInterval time.Duration, opts nodeOptions, teamLabel string, out manifestOutput) error {
+	w := io.Writer(os.Stdout)
+	if out.Print {
+		// Keep stdout clean for the YAML; questions and progress still show.
+		w = os.Stderr
+	}
+	offline := len(fromFiles) > 0
+	if offline {
+		fmt.Fprintf(w, "\n  ⚡ karpx nodes  from:%s\n", strings.Join(fromFiles, ", "))
+	} else {
+		fmt.Fprintf(w, "\n  ⚡ karpx nodes  context:%s\n", contextOrCurrent(kubeCtx))
+	}
+
+	// Resolve provider.
+	var provider kube.Provider
+	if providerFlag != "" {
+		provider = kube.ParseProvider(providerFlag)
+	} else if offline {
+		provider = askProviderMenu(w)
+	} else {
+		provider = kube.DetectProvider(kubeCtx)
+		if provider == kube.ProviderUnknown {
+			provider = askProviderMenu(w)
+		}
+	}
+	if !provider.Supported() {
+		printUnsupportedProvider(w)
+		return nil
+	}
+	fmt.Fprintf(w, "  Provider : %s\n\n", provider.Meta().Label)
+
+	// Resolve mode (skip asking if passed via flag).
+	mode := nodes.ParseMode(modeFlag)
+
+	var rec *nodes.Recommendation
+	if offline {
+		profile, err := profileFromFiles(fromFiles)
+		if err != nil {
+			return err
+		}
+		fprintSection(w, "Step 6: Node type optimisation")
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "  Reading declared workloads from manifests…\n")
+		rec = recommendForProfile(w, profile, provider, mode, opts)
+	} else if window > 0 {
+		fprintSection(w, "Step 6: Node type optimisation")
+		fmt.Fprintln(w)
+		profile, err := windowedProfile(w, kubeCtx, promURL, window, sampleInterval)
+		if err != nil {
+			return err
+		}
+		rec = recommendForProfile(w, profile, provider, mode, opts)
+	} else {
+		rec = runNodeRecommendationWithMode(w, kubeCtx, provider, mode, opts)
+	}
+	if rec == nil {
+		return nil
+	}
+	if teamLabel != "" {
+		if err := addTeamPools(w, rec, kubeCtx, teamLabel); err != nil {
+			return err
+		}
+	}
+	if rec.GPU != nil {
+		checkGPUPool(w, rec, kubeCtx, offline)
+	}
+
+	manifest := nodes.GenerateManifest(*rec, "", "")
+	if out.Print {
+		_, err := fmt.Fprintln(os.Stdout, manifest)
+		return err
+	}
+
+	// Print the manifest.
+	fmt.Fprintln(w)
+	fprintSection(w, "Generated NodePool manifest")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, manifest)
+
+	switch {
+	case out.Apply:
+		return applyManifest(manifest, kubeCtx)
+	case out.Save != "":
+		return saveManifest(manifest, out.Save)
+	}
+	applyOrSaveManifest(manifest, kubeCtx, offline)
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Shared node recommendation logic (used by both install and nodes command)
+// ─────────────────────────────────────────────────────────────────────────────
+
+// runNodeRecommendation runs workload analysis + asks optimisation preference.
+// Returns nil if the user declines or no useful recommendation can be made.
+func runNodeRecommendation(kubeCtx string, provider kube.Provider) *nodes.Recommendation {
+	var opts nodeOptions
+	if cfg, err := config.Load(); err == nil {
+		g := nodes.Growth{Factor: cfg.Sizing.GrowthFactor, HeadroomPercent: cfg.Sizing.HeadroomPercent}
+		if g.Validate() == nil {
+			opts.Growth = g
+		}
+	}
+	return runNodeRecommendationWithMode(os.Stdout, kubeCtx, provider, "", opts)
+}
+
+func runNodeRecommendationWithMode(w io.Writer, kubeCtx string, provider kube.Provider, mode nodes.OptimizationMode, opts nodeOptions) *nodes.Recommendation {
+	fprintSection(w, "Step 6: Node type optimisation")
+	fmt.Fprintln(w)
+
+	// ── Analyse workloads ──────────────────────────────────────────────────
+	fmt.Fprintf(w, "  Analysing running workloads in the cluster…\n")
+	profile, err := kube.AnalyzeWorkloads(kubeCtx)
+	if err != nil {
+		fmt.Fprintf(w, "  ⚠  Could not read workloads (%v)\n", err)
+		fmt.Fprintf(w, "     Continuing with defaults — you can re-run `karpx nodes` later.\n\n")
+		profile = &kube.WorkloadProfile{NoRequests: true}
+	}
+	return recommendForProfile(w, profile, provider, mode, opts)
+}
+
+// growthDefaults fills growth settings not given as flags from the config
+// file, then validates the result.
+func growthDefaults(cmd *cobra.Command, g *nodes.Growth) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed("growth-factor") {
+		g.Factor = cfg.Sizing.GrowthFactor
+	}
+	if !cmd.Flags().Changed("headroom-percent") {
+		g.HeadroomPercent = cfg.Sizing.HeadroomPercent
+	}
+	return g.Validate()
+}
+
+// addTeamPools adds one NodePool per team found under the namespace label
+// teamLabel and prints the snippet each team needs to land on its pool.
+func addTeamPools(w io.Writer, rec *nodes.Recommendation, kubeCtx, teamLabel string) error {
+	teams, err := kube.UsageByTeam(kubeCtx, teamLabel)
+	if err != nil {
+		fmt.Fprintf(w, "  ✗ %v\n\n", err)
+		return err
+	}
+	nodes.AddTeams(rec, teamLabel, teams)
+
+	fmt.Fprintln(w)
+	fprintSection(w, "Team NodePools")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %-20s  %-24s  %5s  %9s  %s\n", "POOL", "TEAM", "PODS", "CPU", "LIMITS")
+	for i, t := range rec.Teams {
+		fmt.Fprintf(w, "  %-20s  %-24s  %5d  %8.1fc  %d cores, %d GiB\n",
+			t.PoolName(), t.Team, teams[i].Pods,
+			float64(teams[i].CPUm)/1000.0, t.LimitCPU, t.LimitMemGiB)
+	}
+	fmt.Fprintf(w, "\n  Unlabelled namespaces stay on karpx-default. Each team adds this to its\n")
+	fmt.Fprintf(w, "  pod templates to run on its own pool:\n\n")
+	for _, t := range rec.Teams {
+		fmt.Fprintf(w, "  # %s (%s)\n", t.Team, strings.Join(t.Namespaces, ", "))
+		for _, line := range strings.Split(strings.TrimRight(nodes.TeamSnippet(t), "\n"), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// askGPUOptions runs the GPU wizard when GPU pods were found. It returns nil
+// when the user wants no separate GPU pool.
+func askGPUOptions(w io.Writer) *nodes.GPUOptions {
+	fmt.Fprintln(w)
+	if !confirmPrompt("  GPU workloads found — generate a dedicated, tainted GPU NodePool? [y/N] ") {
+		return nil
+	}
+	var o nodes.GPUOptions
+	mem := prompt.String("GPU memory one pod needs (GiB)", "16", func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 80 {
+			return fmt.Errorf("enter a number of GiB between 1 and 80")
+		}
+		return nil
+	})
+	o.MemoryGiB, _ = strconv.Atoi(mem)
+	switch prompt.Select("How do pods share a GPU?", []prompt.Option{
+		{Label: "Whole GPUs", Detail: "one pod per GPU (training, large models)"},
+		{Label: "Time-slicing", Detail: "pods take turns on a GPU, no memory isolation; cheap for bursty inference, notebooks and dev"},
+		{Label: "MIG", Detail: "A100/H100 split into isolated slices (needs the GPU operator)"},
+	}, 0) {
+	case 1:
+		o.Sharing = nodes.GPUSharingTimeSlicing
+		replicas := prompt.String("Pods per GPU", "4", func(v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 2 || n > 48 {
+				return fmt.Errorf("enter a number between 2 and 48")
+			}
+			return nil
+		})
+		o.Replicas, _ = strconv.Atoi(replicas)
+	case 2:
+		o.Sharing = nodes.GPUSharingMIG
+	default:
+		o.Sharing = nodes.GPUSharingNone
+	}
+	return &o
+}
+
+// checkGPUPool checks that the NVIDIA device plugin or GPU operator is
+// installed, points the time-slicing config at its namespace and prints the
+// snippet GPU workloads need to land on the GPU pool.
+func checkGPUPool(w io.Writer, rec *nodes.Recommendation, kubeCtx string, offline bool) {
+	g := rec.GPU
+	fmt.Fprintln(w)
+	fprintSection(w, "GPU NodePool")
+	fmt.Fprintln(w)
+	if offline {
+		fmt.Fprintf(w, "  ℹ  No cluster to check — install the NVIDIA device plugin or GPU operator before GPU nodes join.\n")
+	} else if stack, err := kube.DetectGPUStack(kubeCtx); err != nil {
+		fmt.Fprintf(w, "  ⚠  Could not check for the NVIDIA device plugin: %v\n", err)
+	} else {
+		if stack.Namespace != "" {
+			g.Namespace = stack.Namespace
+		}
+		switch {
+		case stack.Operator != "":
+			fmt.Fprintf(w, "  ✓ NVIDIA GPU operator  %s\n", stack.Operator)
+		case stack.DevicePlugin != "":
+			fmt.Fprintf(w, "  ✓ NVIDIA device plugin  %s\n", stack.DevicePlugin)
+		}
+		switch {
+		case !stack.Installed():
+			fmt.Fprintf(w, "  ✗ No NVIDIA device plugin or GPU operator — GPU nodes will not advertise\n")
+			fmt.Fprintf(w, "    nvidia.com/gpu and GPU pods stay pending. Install one:\n\n")
+			fmt.Fprintf(w, "      helm repo add nvidia https://helm.ngc.nvidia.com/nvidia\n")
+			fmt.Fprintf(w, "      helm upgrade -i gpu-operator nvidia/gpu-operator -n gpu-operator --create-namespace \\\n")
+			fmt.Fprintf(w, "        --kube-context %s --set driver.enabled=false --set toolkit.enabled=false\n\n", contextOrCurrent(kubeCtx))
+			fmt.Fprintf(w, "    (the EKS NVIDIA AMIs already ship the driver and container toolkit)\n")
+		case g.Sharing == nodes.GPUSharingMIG && stack.Operator == "":
+			fmt.Fprintf(w, "  ⚠  MIG needs the GPU operator's MIG manager to apply the nvidia.com/mig.config\n")
+			fmt.Fprintf(w, "     label — the standalone device plugin cannot partition GPUs.\n")
+		}
+	}
+	switch g.Sharing {
+	case nodes.GPUSharingTimeSlicing:
+		fmt.Fprintf(w, "  ℹ  The manifest includes ConfigMap %s/karpx-gpu-sharing with the time-slicing\n", g.Namespace)
+		fmt.Fprintf(w, "     config; set devicePlugin.config.name=karpx-gpu-sharing in the ClusterPolicy\n")
+		fmt.Fprintf(w, "     (or --set config.name=karpx-gpu-sharing on the device plugin chart).\n")
+	case nodes.GPUSharingMIG:
+		fmt.Fprintf(w, "  ℹ  Nodes are labelled nvidia.com/mig.config=all-%s; keep the ClusterPolicy\n", g.MIGProfile)
+		fmt.Fprintf(w, "     at mig.strategy=single so each slice is requested as nvidia.com/gpu.\n")
+	}
+
+	fmt.Fprintf(w, "\n  GPU workloads add this to their pod templates to run on %s:\n\n", nodes.GPUPoolName)
+	for _, line := range strings.Split(strings.TrimRight(nodes.GPUSnippet(), "\n"), "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// windowedProfile builds a p95 workload profile over window, from Prometheus
+// when promURL is set and by sampling the cluster every interval otherwise.
+func windowedProfile(w io.Writer, kubeCtx, promURL string, window, interval time.Duration) (*kube.WorkloadProfile, error) {
+	if promURL != "" {
+		fmt.Fprintf(w, "  Reading %s of workload demand from %s…\n", window, promURL)
+		// The live snapshot fills in what kube-state-metrics does not carry
+		// (GPU requests, batch jobs, namespaces); it is optional.
+		base, _ := kube.AnalyzeWorkloads(kubeCtx)
+		return kube.ProfileFromPrometheus(promURL, window, base)
+	}
+	fmt.Fprintf(w, "  Sampling workloads every %s for %s — leave this running…\n", interval, window)
+	profile, err := kube.SampleWorkloads(kubeCtx, interval, window, func(n, total int) {
+		fmt.Fprintf(w, "\r    sample %d/%d", n, total)
+	})
+	fmt.Fprintln(w)
+	return profile, err
+}
+
+// nodeOptions are the recommendation settings beyond the optimisation mode.
+type nodeOptions struct {
+	Tenancy  nodes.Tenancy
+	Growth   nodes.Growth
+	Volume   nodes.VolumeOptions
+	CNI      kube.CNIMode // overrides the detected CNI when set
+	UserData nodes.UserData
+	GPU      *nodes.GPUOptions // from the --gpu-* flags; nil asks when GPU pods are found
+	Currency string            // of the context's region, for the --tenancy cost note
+}
+
+// userDataOption reads and merges the --user-data snippets for the AMI family.
+func userDataOption(family string, files []string) (nodes.UserData, error) {
+	f, err := nodes.ParseAMIFamily(family)
+	if err != nil {
+		return nodes.UserData{}, err
+	}
+	var snippets []nodes.Snippet
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nodes.UserData{}, fmt.Errorf("--user-data: %w", err)
+		}
+		snippets = append(snippets, nodes.Snippet{Name: path, Content: string(data)})
+	}
+	return nodes.MergeUserData(f, snippets)
+}
+
+// profileFromFiles decodes manifests from files, directories, or stdin ("-")
+// and builds a WorkloadProfile from their declared requests.
+func profileFromFiles(paths []string) (*kube.WorkloadProfile, error) {
+	var objs []manifest.Object
+	for _, path := range paths {
+		if path == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, fmt.Errorf("read stdin: %w", err)
+			}
+			found, err := manifest.Decode(data)
+			if err != nil {
+				return nil, fmt.Errorf("decode stdin: %w", err)
+			}
+			objs = append(objs, found...)
+			continue
+		}
+		found, err := manifest.ReadPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		for _, f := range found {
+			objs = append(objs, f.Object)
+		}
+	}
+	return kube.ProfileFromObjects(objs), nil
+}
+
+// recommendForProfile prints the workload summary, asks for the optimisation
+// mode if needed, and prints the resulting recommendation.
+func recommendForProfile(w io.Writer, profile *kube.WorkloadProfile, provider kube.Provider, mode nodes.OptimizationMode, opts nodeOptions) *nodes.Recommendation {
+	tenancy := opts.Tenancy
+	if opts.CNI != kube.CNIUnknown {
+		profile.CNI = opts.CNI
+	}
+	wtype := kube.ClassifyWorkload(profile)
+
+	// ── Print analysis summary ─────────────────────────────────────────────
+	if profile.TotalPods > 0 {
+		fmt.Fprintf(w, "  Discovered workloads:\n")
+		if profile.Window != "" {
+			fmt.Fprintf(w, "    Demand         : %s\n", profile.Window)
+		}
+		fmt.Fprintf(w, "    Pods           : %d  (across %d namespace(s))\n", profile.TotalPods, profile.Namespaces)
+		fmt.Fprintf(w, "    CPU requested  : %.1f cores total   (largest pod: %.1f cores)\n",
+			float64(profile.TotalCPUm)/1000.0, float64(profile.MaxPodCPUm)/1000.0)
+		fmt.Fprintf(w, "    Memory         : %.1f GiB total     (largest pod: %.0f MiB)\n",
+			float64(profile.TotalMemMiB)/1024.0, float64(profile.MaxPodMemMiB))
+		if h := profile.Headroom; h.HPAs > 0 || h.VPAs > 0 {
+			fmt.Fprintf(w, "    Autoscalers    : +%.1f cores, +%.1f GiB at max  (%d HPA, %d VPA)\n",
+				float64(h.CPUm)/1000.0, float64(h.MemMiB)/1024.0, h.HPAs, h.VPAs)
+		}
+		if profile.DoNotDisrupt > 0 {
+			fmt.Fprintf(w, "    Do-not-disrupt : %d pod(s)  (run `karpx audit` for details)\n", profile.DoNotDisrupt)
+		}
+		if profile.ZonalStateful > 0 {
+			fmt.Fprintf(w, "    Zonal volumes  : %d StatefulSet(s) in %s\n", profile.ZonalStateful, strings.Join(profile.VolumeZones, ", "))
+		}
+		if profile.HasGPU {
+			fmt.Fprintf(w, "    GPU workloads  : detected\n")
+		}
+		if profile.HasBatchJobs {
+			fmt.Fprintf(w, "    Batch jobs     : detected\n")
+		}
+		if profile.ImageMiB > 0 || profile.TotalEphemeralMiB > 0 {
+			fmt.Fprintf(w, "    Disk           : %.1f GiB of images per node, %.1f GiB ephemeral-storage requested\n",
+				float64(profile.ImageMiB)/1024.0, float64(profile.TotalEphemeralMiB)/1024.0)
+		}
+		if profile.CNI != kube.CNIUnknown {
+			fmt.Fprintf(w, "    Pod networking : %s\n", profile.CNI)
+		}
+		fmt.Fprintf(w, "    Workload type  : %s", string(wtype))
+		switch wtype {
+		case kube.WorkloadMemory:
+			fmt.Fprintf(w, "  (%.1f GiB/core — memory-heavy)\n", profile.MemPerCPUGiB)
+		case kube.WorkloadCPU:
+			fmt.Fprintf(w, "  (%.1f GiB/core — compute-heavy)\n", profile.MemPerCPUGiB)
+		case kube.WorkloadGPU:
+			fmt.Fprintf(w, "  (GPU resources requested)\n")
+		default:
+			if profile.MemPerCPUGiB > 0 {
+				fmt.Fprintf(w, "  (%.1f GiB/core)\n", profile.MemPerCPUGiB)
+			} else {
+				fmt.Fprintf(w, "  (no resource requests set)\n")
+			}
+		}
+	} else {
+		fmt.Fprintf(w, "  No running pods found — using defaults.\n")
+	}
+
+	// ── Ask optimisation preference if not already known ───────────────────
+	if mode == "" {
+		fmt.Fprintln(w)
+		mode = askOptimizationMode(w)
+		if mode == "" {
+			return nil
+		}
+	}
+
+	// ── Build recommendation ───────────────────────────────────────────────
+	// ── Dedicated tenancy for regulated namespaces ─────────────────────────
+	if tenancy == nodes.TenancyDefault && provider == kube.ProviderAWS && len(profile.Compliance) > 0 {
+		// Not a prompt: --yes must never move a pool onto dedicated hardware.
+		fmt.Fprintf(w, "\n  ℹ  Compliance-labelled namespace(s): %s\n", strings.Join(profile.Compliance, ", "))
+		fmt.Fprintf(w, "     Re-run with --tenancy dedicated for single-tenant hardware (on-demand only, no Spot).\n")
+	}
+
+	// ── Dedicated GPU pool ─────────────────────────────────────────────────
+	gpu := opts.GPU
+	if gpu == nil && profile.HasGPU && provider == kube.ProviderAWS {
+		gpu = askGPUOptions(w)
+	}
+	sized := opts.Growth.Apply(profile)
+	if gpu != nil && provider == kube.ProviderAWS {
+		// GPU pods get their own pool; the shared one is sized for the rest.
+		shared := *sized
+		shared.HasGPU = false
+		sized = &shared
+	}
+
+	rec := nodes.Build(sized, mode, provider)
+	opts.Growth.Explain(&rec)
+	if gpu != nil {
+		if err := nodes.AddGPU(&rec, *gpu); err != nil {
+			fmt.Fprintf(w, "\n  ⚠  %v — no GPU NodePool generated.\n", err)
+		}
+	}
+	nodes.ApplyTenancy(&rec, tenancy, opts.Currency)
+	nodes.SizeVolume(&rec, profile, opts.Volume)
+	nodes.ApplyUserData(&rec, opts.UserData)
+
+	// ── Print recommendation ───────────────────────────────────────────────
+	fmt.Fprintln(w)
+	fprintSection(w, "Recommended node configuration")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Mode              : %s\n", modeLabelShort(mode))
+	fmt.Fprintf(w, "  Instance families : %s\n", strings.Join(rec.InstanceFamilies, ", "))
+	fmt.Fprintf(w, "  Capacity types    : %s\n", strings.Join(rec.CapacityTypes, ", "))
+	fmt.Fprintf(w, "  Architectures     : %s\n", strings.Join(rec.Architectures, ", "))
+	fmt.Fprintf(w, "  CPU sizes (vCPU)  : %s\n", strings.Join(rec.CPUSizes, ", "))
+	fmt.Fprintf(w, "  Min node memory   : %d MiB\n", rec.MinNodeMiB)
+	if rec.Tenancy != nodes.TenancyDefault {
+		fmt.Fprintf(w, "  Tenancy           : %s\n", rec.Tenancy)
+	}
+	fmt.Fprintf(w, "  NodePool limits   : %d cores, %d GiB\n", rec.LimitCPU, rec.LimitMemGiB)
+	if g := rec.GPU; g != nil {
+		fmt.Fprintf(w, "  GPU families      : %s  (%s, max %d GPUs)\n", strings.Join(g.Families, ", "), g.Sharing, g.Limit)
+	}
+	if rec.ExpectedNodes > 0 {
+		fmt.Fprintf(w, "  Nodes at peak     : ~%d\n", rec.ExpectedNodes)
+	}
+	if len(rec.Zones) > 0 {
+		fmt.Fprintf(w, "  Zones             : %s\n", strings.Join(rec.Zones, ", "))
+	}
+	if v := rec.Volume; v.SizeGiB > 0 {
+		fmt.Fprintf(w, "  Root volume       : %d GiB gp3, %d IOPS, %d MiB/s\n", v.SizeGiB, v.IOPS, v.Throughput)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Why:\n")
+	for _, r := range rec.Reasoning {
+		fmt.Fprintf(w, "    • %s\n", r)
+	}
+
+	return &rec
+}
+
+// askOptimizationMode shows the cost vs performance question.
+func askOptimizationMode(w io.Writer) nodes.OptimizationMode {
+	switch prompt.Select("What is your node provisioning priority?", []prompt.Option{
+		{Label: "Cost-Optimized", Detail: "Spot + Graviton (arm64) where available; saves 60-80% vs on-demand for fault-tolerant workloads"},
+		{Label: "Balanced", Detail: "mixed Spot + On-Demand, several instance families; good price/performance for most production"},
+		{Label: "High-Performance", Detail: "On-Demand only, latest-gen instances; for latency-sensitive or stateful services"},
+		{Label: "Free-Tier", Detail: "free-tier eligible instances only (m7i-flex, c7i-flex, t3, t4g)"},
+	}, -1) {
+	case 0:
+		return nodes.ModeCostOptimized
+	case 1:
+		return nodes.ModeBalanced
+	case 2:
+		return nodes.ModeHighPerformance
+	case 3:
+		return nodes.ModeFreeTier
+	}
+	fmt.Fprintf(w, "  No choice — skipping node optimisation.\n\n")
+	return ""
+}
+
+// applyOrSaveManifest asks whether to kubectl-apply or save to a file.
+// applyOrSaveManifest asks what to do with manifest. offline manifests were
+// built from files, not the cluster, so applying is not offered.
+func applyOrSaveManifest(manifest, kubeCtx string, offline bool) {
+	fmt.Println()
+	if offline {
+		if prompt.Select("What would you like to do with this NodePool manifest?", []prompt.Option{
+			{Label: "Save to file", Detail: "write karpx-nodepool.yaml in the current directory"},
+			{Label: "Skip", Detail: "I'll handle it manually"},
+		}, 1) == 0 {
+			_ = saveManifest(manifest, "karpx-nodepool.yaml")
+			return
+		}
+		fmt.Printf("\n  Skipped — copy the YAML above and apply it to the target cluster.\n\n")
+		return
+	}
+	switch prompt.Select("What would you like to do with this NodePool manifest?", []prompt.Option{
+		{Label: "Apply now", Detail: "kubectl apply -f - (applies to current cluster)"},
+		{Label: "Save to file", Detail: "write karpx-nodepool.yaml in the current directory"},
+		{Label: "Skip", Detail: "I'll handle it manually"},
+	}, 2) {
+	case 0:
+		_ = applyManifest(manifest, kubeCtx)
+	case 1:
+		_ = saveManifest(manifest, "karpx-nodepool.yaml")
+	default:
+		fmt.Printf("\n  Skipped — copy the YAML above and run:\n")
+		fmt.Printf("    kubectl apply -f karpx-nodepool.yaml\n\n")
+	}
+}
+
+func applyManifest(manifest, kubeCtx string) error {
+	args := []string{"apply", "-f", "-"}
+	if kubeCtx != "" {
+		args = append(args, "--context", kubeCtx)
+	}
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stdin  = strings.NewReader(manifest)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	fmt.Printf("\n  Applying NodePool manifest…\n\n")
+	if err := hooks.Around(hooks.Apply, kubeCtx, nil, os.Stdout, cmd.Run); err != nil {
+		fmt.Printf("\n  ✗ kubectl apply failed: %v\n\n", err)
+		return err
+	}
+	fmt.Printf("\n  ✓  NodePool applied successfully.\n\n")
+	return nil
+}
+
+func saveManifest(manifest, filename string) error {
+	if err := os.WriteFile(filename, []byte(manifest), 0644); err != nil {
+		fmt.Printf("\n  ✗ Could not write file: %v\n\n", err)
+		return err
+	}
+	fmt.Printf("\n  ✓  Saved to %s\n", filename)
+	fmt.Printf("     Review and apply with:\n")
+	fmt.Printf("     kubectl apply -f %s\n\n", filename)
+	return nil
+}
+
+func modeLabelShort(m nodes.OptimizationMode) string {
+	switch m {
+	case nodes.ModeCostOptimized:
+		return "Cost-Optimized (Spot + Graviton)"
+	case nodes.ModeHighPerformance:
+		return "High-Performance (On-Demand, latest-gen)"
+	case nodes.ModeFreeTier:
+		return "Free-Tier (free-tier eligible instances)"
+	default:
+		return "Balanced (Spot + On-Demand, mixed families)"
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// pricing command — real EC2 prices for the recommended families
+// ─────────────────────────────────────────────────────────────────────────────
+
+func pricingCmd() *cobra.Command {
+	var kubeCtx, region, modeFlag, familiesFlag, sizesFlag, tenancyFlag string
+	cmd := &cobra.Command{
+		Use:   "pricing",
+		Short: "Show on-demand and spot prices for recommended instance families (AWS)",
+		Long: `Print current on-demand and spot prices per instance size — plus price per
+vCPU and per GiB — for the instance families karpx recommends, so the
+recommendation can be checked against real prices before applying it.
+
+Without --families the cluster's workloads are analysed and the families from
+the recommendation for --mode are priced.`,
+		Example: `  karpx pricing -c my-cluster --mode cost
+  karpx pricing -r us-east-1 --families m7g,m7i,c7g --sizes 2,4,8
+  karpx pricing -c my-cluster --mode performance --tenancy dedicated`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tenancy, err := nodes.ParseTenancy(tenancyFlag)
+			if err != nil {
+				return err
+			}
+			return runPricing(kubeCtx, region, modeFlag, familiesFlag, sizesFlag, tenancy)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region (default: from context)")
+	cmd.Flags().StringVar(&modeFlag, "mode", "cost", "optimisation mode used when analysing the cluster")
+	cmd.Flags().StringVar(&familiesFlag, "families", "", "comma-separated instance families (skips cluster analysis)")
+	cmd.Flags().StringVar(&sizesFlag, "sizes", "", "comma-separated vCPU sizes to include (default: recommended sizes)")
+	cmd.Flags().StringVar(&tenancyFlag, "tenancy", "", "price dedicated tenancy instead of shared: dedicated")
+	return cmd
+}
+
+func runPricing(kubeCtx, region, modeFlag, familiesFlag, sizesFlag string, tenancy nodes.Tenancy) error {
+	fmt.Printf("\n  $ karpx pricing  context:%s\n\n", contextOrCurrent(kubeCtx))
+
+	families := splitList(familiesFlag)
+	sizes := splitList(sizesFlag)
+
+	if len(families) == 0 {
+		if p := kube.DetectProvider(kubeCtx); p != kube.ProviderAWS && p != kube.ProviderUnknown {
+			fmt.Printf("  ✗ Pricing lookups currently support AWS only (detected %s).\n\n", p.Meta().Label)
+			return nil
+		}
+		mode := nodes.ParseMode(modeFlag)
+		if mode == "" {
+			mode = nodes.ModeCostOptimized
+		}
+		fmt.Printf("  Analysing running workloads in the cluster…\n")
+		profile, err := kube.AnalyzeWorkloads(kubeCtx)
+		if err != nil {
+			fmt.Printf("  ⚠  Could not read workloads (%v) — using defaults.\n", err)
+			profile = &kube.WorkloadProfile{NoRequests: true}
+		}
+		rec := nodes.Build(profile, mode, kube.ProviderAWS)
+		nodes.ApplyTenancy(&rec, tenancy, awscli.Currency(awscli.RegionFromContext(kubeCtx)))
+		families = rec.InstanceFamilies
+		if len(sizes) == 0 {
+			sizes = rec.CPUSizes
+		}
+		fmt.Printf("  Mode              : %s\n", modeLabelShort(mode))
+		fmt.Printf("  Capacity types    : %s\n", strings.Join(rec.CapacityTypes, ", "))
+	}
+
+	if region == "" {
+		region = awscli.RegionFromContext(kubeCtx)
+	}
+	region = askIfEmpty(region, "AWS region", "us-east-1")
+
+	fmt.Printf("  Region            : %s\n", region)
+	fmt.Printf("  Instance families : %s\n", strings.Join(families, ", "))
+	if len(sizes) > 0 {
+		fmt.Printf("  CPU sizes (vCPU)  : %s\n", strings.Join(sizes, ", "))
+	}
+	fmt.Printf("\n  Fetching prices from AWS…\n\n")
+
+	prices, err := pricing.Lookup(region, string(tenancy), families, sizes)
+	if err != nil {
+		fmt.Printf("  ✗ Price lookup failed: %v\n\n", err)
+		return err
+	}
+	if len(prices) == 0 {
+		fmt.Printf("  No matching instance types offered in %s.\n\n", region)
+		return nil
+	}
+
+	currency := awscli.Currency(region)
+	fmt.Printf("  %-16s %5s %8s %11s %11s %6s %10s %10s %10s\n",
+		"INSTANCE", "vCPU", "MEM GiB", "ON-DEMAND/h", "SPOT/h", "SAVE", "OD/vCPU·h", "SPOT/vCPU", "OD/GiB·h")
+	fmt.Printf("  %s\n", strings.Repeat("─", 96))
+	for _, p := range prices {
+		odCPU, spotCPU := p.PerVCPU()
+		odGiB, _ := p.PerGiB()
+		save := "—"
+		if s := p.SpotSaving(); s > 0 {
+			save = fmt.Sprintf("%.0f%%", s)
+		}
+		fmt.Printf("  %-16s %5d %8.1f %11s %11s %6s %10s %10s %10s\n",
+			p.InstanceType, p.VCPU, p.MemoryGiB,
+			money(currency, p.OnDemand), money(currency, p.Spot), save,
+			money(currency, odCPU), money(currency, spotCPU), money(currency, odGiB))
+	}
+
+	printUnpriced(prices)
+
+	// Cheapest per vCPU helps spot a family that is out of line with the rest.
+	var best pricing.Price
+	for _, p := range prices {
+		_, s := p.PerVCPU()
+		_, b := best.PerVCPU()
+		if s > 0 && (b == 0 || s < b) {
+			best = p
+		}
+	}
+	if best.InstanceType != "" {
+		_, s := best.PerVCPU()
+		fmt.Printf("\n  Cheapest spot per vCPU : %s at %s/vCPU·h (≈ %s%.0f/month per node)\n",
+			best.InstanceType, money(currency, s), pricing.Symbol(currency), best.Spot*pricing.HoursPerMonth)
+	}
+	if tenancy != nodes.TenancyDefault {
+		fmt.Printf("  Prices are %s, Linux, dedicated tenancy (no Spot); add USD %.0f/hour per region\n", currency, nodes.DedicatedRegionFee)
+		fmt.Printf("  while any dedicated instance runs (≈ USD %.0f/month).\n\n", nodes.DedicatedRegionFee*pricing.HoursPerMonth)
+		return nil
+	}
+	fmt.Printf("  Prices are %s, Linux, shared tenancy; spot is the cheapest AZ right now.\n\n", currency)
+	return nil
+}
+
+// printUnpriced lists the instance types without an on-demand price, grouped
+// by the reason the lookup failed.
+func printUnpriced(prices []pricing.Price) {
+	byReason := map[string][]string{}
+	var reasons []string
+	for _, p := range prices {
+		if p.Unpriced == "" {
+			continue
+		}
+		if _, ok := byReason[p.Unpriced]; !ok {
+			reasons = append(reasons, p.Unpriced)
+		}
+		byReason[p.Unpriced] = append(byReason[p.Unpriced], p.InstanceType)
+	}
+	for _, r := range reasons {
+		fmt.Printf("\n  ⚠  No on-demand price for %s: %s", strings.Join(byReason[r], ", "), r)
+	}
+	if len(reasons) > 0 {
+		fmt.Println()
+	}
+}
+
+// money formats an hourly price in currency, or "—" when unknown.
+func money(currency string, v float64) string {
+	if v == 0 {
+		return "—"
+	}
+	if v < 0.01 {
+		return fmt.Sprintf("%s%.5f", pricing.Symbol(currency), v)
+	}
+	return fmt.Sprintf("%s%.4f", pricing.Symbol(currency), v)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// explain command — the instance types a NodePool can launch
+// ─────────────────────────────────────────────────────────────────────────────
+
+func explainCmd() *cobra.Command {
+	var kubeCtx, region, output string
+	var noPrices bool
+	var top int
+	cmd := &cobra.Command{
+		Use:   "explain <nodepool>",
+		Short: "List the instance types a NodePool can launch, with prices (AWS)",
+		Long: `Enumerate exactly which instance types a NodePool can launch: every type
+offered in the region, intersected with the NodePool requirements — instance
+types, categories, families, generations, sizes, CPU, memory, GPUs,
+architectures, capacity types and zones — and the zones of the EC2NodeClass
+subnets.
+
+Candidates are listed cheapest first, which is the order Karpenter prefers
+when several fit the pending pods. Each requirement shows how many offered
+types it rules out on its own, so an over- or under-constrained NodePool is
+easy to spot. Custom labels are listed but not evaluated.`,
+		Example: "  karpx explain default -c my-cluster\n  karpx explain gpu -c my-cluster --top 20\n  karpx explain default -c my-cluster --no-prices -o json",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			return runExplain(kubeCtx, region, args[0], output, top, !noPrices)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region (default: from context)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	cmd.Flags().IntVar(&top, "top", 0, "show only the first N candidates (0 = all)")
+	cmd.Flags().BoolVar(&noPrices, "no-prices", false, "skip the price lookup (one Pricing API call per candidate)")
+	return cmd
+}
+
+func runExplain(kubeCtx, region, nodePool, output string, top int, prices bool) error {
+	quiet := output == "json"
+	if !quiet {
+		fmt.Printf("\n  karpx explain %s  context:%s\n\n", nodePool, contextOrCurrent(kubeCtx))
+	}
+	if p := kube.DetectProvider(kubeCtx); p != kube.ProviderAWS && p != kube.ProviderUnknown {
+		fmt.Printf("  ✗ explain currently supports AWS only (detected %s).\n\n", p.Meta().Label)
+		return nil
+	}
+	if region == "" {
+		region = awscli.RegionFromContext(kubeCtx)
+	}
+	if quiet && region == "" {
+		return fmt.Errorf("--region is required with -o json when it cannot be read from the context")
+	}
+	region = askIfEmpty(region, "AWS region", "us-east-1")
+
+	if !quiet {
+		fmt.Printf("  Listing instance types offered in %s…\n\n", region)
+	}
+	r, err := explain.Run(explain.Params{KubeCtx: kubeCtx, Region: region, NodePool: nodePool, Prices: prices})
+	if err != nil {
+		if !quiet {
+			fmt.Printf("  ✗ %v\n\n", err)
+		}
+		return err
+	}
+	if top > 0 && len(r.Candidates) > top {
+		r.Candidates = r.Candidates[:top]
+	}
+	if quiet {
+		return printJSON(r)
+	}
+
+	fmt.Printf("  NodePool       : %s\n", r.NodePool)
+	if r.NodeClass != "" {
+		fmt.Printf("  EC2NodeClass   : %s\n", r.NodeClass)
+	}
+	fmt.Printf("  Capacity types : %s\n", strings.Join(r.CapacityTypes, ", "))
+	fmt.Printf("  Zones          : %s\n\n", strings.Join(r.Zones, ", "))
+
+	printSection("Requirements")
+	if len(r.Requirements) == 0 {
+		fmt.Printf("  (none — every offered type is a candidate; capacity type defaults to on-demand)\n")
+	}
+	for _, req := range r.Requirements {
+		note := fmt.Sprintf("excludes %d", req.Excluded)
+		if req.Unsupported {
+			note = "not evaluated"
+		}
+		if req.MinValues > 0 {
+			note += fmt.Sprintf(", minValues %d", req.MinValues)
+		}
+		fmt.Printf("  • %-60s  %s\n", req, note)
+	}
+	fmt.Println()
+
+	printSection(fmt.Sprintf("Candidates — %d of %d instance types offered in %s", r.Total, r.Offered, r.Region))
+	if len(r.Candidates) == 0 {
+		fmt.Printf("  ✗ No instance type satisfies every requirement — this NodePool cannot launch nodes.\n\n")
+		return nil
+	}
+	// Prices of capacity types the NodePool does not allow are not shown.
+	currency := awscli.Currency(r.Region)
+	allowOD, allowSpot := false, false
+	for _, ct := range r.CapacityTypes {
+		allowOD = allowOD || ct == "on-demand"
+		allowSpot = allowSpot || ct == "spot"
+	}
+	fmt.Printf("  %-20s %-6s %5s %8s %4s %11s %11s  %s\n",
+		"INSTANCE", "ARCH", "vCPU", "MEM GiB", "GPU", "ON-DEMAND/h", "SPOT/h", "ZONES")
+	fmt.Printf("  %s\n", strings.Repeat("─", 96))
+	for _, c := range r.Candidates {
+		gpu := "—"
+		if c.GPUs > 0 {
+			gpu = fmt.Sprint(c.GPUs)
+		}
+		od, spot := c.OnDemand, c.Spot
+		if !allowOD {
+			od = 0
+		}
+		if !allowSpot {
+			spot = 0
+		}
+		fmt.Printf("  %-20s %-6s %5d %8.1f %4s %11s %11s  %s\n",
+			c.InstanceType, c.Arch, c.VCPU, c.MemoryGiB, gpu, money(currency, od), money(currency, spot), strings.Join(c.Zones, ","))
+	}
+	if len(r.Candidates) < r.Total {
+		fmt.Printf("  … %d more (--top %d)\n", r.Total-len(r.Candidates), top)
+	}
+	if r.Priced {
+		fmt.Printf("\n  Sorted cheapest first. Prices are %s, Linux, shared tenancy; spot is the cheapest AZ right now.\n\n", currency)
+	} else {
+		fmt.Printf("\n  Sorted by size.\n\n")
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// why-pending command — why a pod gets no node
+// ─────────────────────────────────────────────────────────────────────────────
+
+func whyPendingCmd() *cobra.Command {
+	var kubeCtx, region, namespace, output string
+	cmd := &cobra.Command{
+		Use:   "why-pending <pod>",
+		Short: "Explain why a pending pod gets no node from any NodePool",
+		Long: `Check an unschedulable pod against every NodePool and explain, in plain
+language, what keeps each one from launching a node for it:
+
+  taints          the NodePool taints nodes the pod does not tolerate
+  requirements    nodeSelector or required node affinity conflicts with the
+                  NodePool labels and requirements
+  limits          the NodePool has reached spec.limits
+  instance-types  no allowed instance type has room for the pod's requests
+                  after kube-reserved and DaemonSets (AWS)
+
+Capacity errors from NodeClaims that failed to launch in the last hour and
+the scheduler's and Karpenter's events on the pod are shown alongside. The
+pod may be given as <namespace>/<pod>.`,
+		Example: "  karpx why-pending web-7d9f8-abcde -n shop -c my-cluster\n  karpx why-pending shop/web-7d9f8-abcde -o json",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			pod := args[0]
+			if ns, name, ok := strings.Cut(pod, "/"); ok {
+				namespace, pod = ns, name
+			}
+			return runWhyPending(kubeCtx, region, namespace, pod, output)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region for the instance type check (default: from context)")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "namespace of the pod")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	return cmd
+}
+
+func runWhyPending(kubeCtx, region, namespace, pod, output string) error {
+	quiet := output == "json"
+	if !quiet {
+		fmt.Printf("\n  karpx why-pending %s/%s  context:%s\n\n", namespace, pod, contextOrCurrent(kubeCtx))
+	}
+	// The instance type check needs the EC2 catalog; elsewhere it is skipped.
+	if region == "" {
+		if p := kube.DetectProvider(kubeCtx); p == kube.ProviderAWS && awscli.Available() {
+			region = awscli.RegionFromContext(kubeCtx)
+		}
+	}
+	d, err := whypending.Diagnose(whypending.Params{KubeCtx: kubeCtx, Namespace: namespace, Pod: pod, Region: region})
+	if err != nil {
+		if !quiet {
+			fmt.Printf("  ✗ %v\n\n", err)
+		}
+		return err
+	}
+	if quiet {
+		return printJSON(d)
+	}
+
+	fmt.Printf("  Phase    : %s\n", d.Phase)
+	fmt.Printf("  Requests : %.2f vCPU, %.2f GiB", float64(d.CPUm)/1000, float64(d.MemMiB)/1024)
+	if d.GPUs > 0 {
+		fmt.Printf(", %d GPU", d.GPUs)
+	}
+	fmt.Printf("\n\n")
+	if !d.Pending() {
+		if d.Node != "" {
+			fmt.Printf("  ✓  Scheduled on %s — nothing to explain.\n\n", d.Node)
+		} else {
+			fmt.Printf("  ✓  Not pending — nothing to explain.\n\n")
+		}
+		return nil
+	}
+
+	if len(d.Events) > 0 {
+		printSection("Events (newest first)")
+		for i, e := range d.Events {
+			if i == 5 {
+				fmt.Printf("  … %d older\n", len(d.Events)-5)
+				break
+			}
+			fmt.Printf("  • %s\n", e)
+		}
+		fmt.Println()
+	}
+
+	printSection("NodePools")
+	if len(d.NodePools) == 0 {
+		fmt.Printf("  ✗ No NodePools — Karpenter has nothing to launch the pod on.\n")
+		fmt.Printf("    → create one with karpx nodes -c %s --apply\n\n", contextOrCurrent(kubeCtx))
+		return nil
+	}
+	compatible := 0
+	for _, v := range d.NodePools {
+		if v.Compatible() {
+			compatible++
+			fmt.Printf("  ✓  %s — no conflict found\n", v.NodePool)
+			continue
+		}
+		fmt.Printf("  ✗  %s\n", v.NodePool)
+		for _, r := range v.Reasons {
+			fmt.Printf("       [%s] %s\n", r.Check, r.Message)
+			if r.Fix != "" {
+				fmt.Printf("       → %s\n", r.Fix)
+			}
+		}
+	}
+	fmt.Println()
+
+	if len(d.CapacityErrors) > 0 {
+		printSection("NodeClaims that failed to launch (last hour)")
+		for _, e := range d.CapacityErrors {
+			fmt.Printf("  ⚠  %s\n", e)
+		}
+		fmt.Println()
+	}
+
+	switch {
+	case compatible == 0:
+		fmt.Printf("  No NodePool can launch a node for this pod — fix one of the conflicts above.\n")
+	case len(d.CapacityErrors) > 0:
+		fmt.Printf("  %d NodePool(s) fit the pod, but recent launches failed — capacity is the likely cause;\n", compatible)
+		fmt.Printf("  allow more instance types, zones or capacity types.\n")
+	default:
+		fmt.Printf("  %d NodePool(s) fit the pod. If it stays pending, check the events above for\n", compatible)
+		fmt.Printf("  topology spread, pod (anti-)affinity or volume zone constraints.\n")
+	}
+	if !d.InstanceTypesChecked {
+		fmt.Printf("  ℹ  Instance types were not checked (AWS only; pass --region if it cannot be read from the context).\n")
+	}
+	fmt.Println()
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// savings command — consolidation savings estimate
+// ─────────────────────────────────────────────────────────────────────────────
+
+func savingsCmd() *cobra.Command {
+	var kubeCtx, region, modeFlag string
+	cmd := &cobra.Command{
+		Use:   "savings",
+		Short: "Estimate the monthly saving from Karpenter consolidation (AWS)",
+		Long: `Analyse current node utilisation and simulate Karpenter consolidation —
+repacking today's pods onto fewer or cheaper nodes of the recommended
+instance families — to estimate the monthly saving.
+
+Nodes running pods that must not move (karpenter.sh/do-not-disrupt or bare
+pods without a controller) are kept at their current price. The estimate
+packs onto a single instance type, so real consolidation usually does a
+little better.`,
+		Example: "  karpx savings -c my-cluster\n  karpx savings -c my-cluster --mode balanced -r eu-west-1",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSavings(kubeCtx, region, modeFlag)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region (default: from context)")
+	cmd.Flags().StringVar(&modeFlag, "mode", "cost", "optimisation mode for the target families: cost | balanced | performance")
+	return cmd
+}
+
+func runSavings(kubeCtx, region, modeFlag string) error {
+	fmt.Printf("\n  $ karpx savings  context:%s\n\n", contextOrCurrent(kubeCtx))
+
+	if p := kube.DetectProvider(kubeCtx); p != kube.ProviderAWS && p != kube.ProviderUnknown {
+		fmt.Printf("  ✗ Savings estimates currently support AWS only (detected %s).\n\n", p.Meta().Label)
+		return nil
+	}
+	if region == "" {
+		region = awscli.RegionFromContext(kubeCtx)
+	}
+	region = askIfEmpty(region, "AWS region", "us-east-1")
+	mode := nodes.ParseMode(modeFlag)
+	if mode == "" {
+		mode = nodes.ModeCostOptimized
+	}
+
+	// ── Current state ─────────────────────────────────────────────────────
+	fmt.Printf("  Reading nodes and pod requests…\n")
+	usage, err := kube.NodeUtilisation(kubeCtx)
+	if err != nil {
+		fmt.Printf("  ✗ Could not read nodes: %v\n\n", err)
+		return err
+	}
+	if len(usage) == 0 {
+		fmt.Printf("  No schedulable nodes found.\n\n")
+		return nil
+	}
+	profile, err := kube.AnalyzeWorkloads(kubeCtx)
+	if err != nil {
+		profile = &kube.WorkloadProfile{NoRequests: true}
+	}
+	rec := nodes.Build(profile, mode, kube.ProviderAWS)
+
+	capacity := "on-demand"
+	for _, ct := range rec.CapacityTypes {
+		if ct == "spot" {
+			capacity = "spot"
+		}
+	}
+
+	typeSet := map[string]bool{}
+	for _, n := range usage {
+		if n.InstanceType != "" {
+			typeSet[n.InstanceType] = true
+		}
+	}
+	types := make([]string, 0, len(typeSet))
+	for t := range typeSet {
+		types = append(types, t)
+	}
+
+	fmt.Printf("  Fetching prices for %d current and %d candidate families in %s…\n\n",
+		len(types), len(rec.InstanceFamilies), region)
+	current, err := pricing.ForTypes(region, types)
+	if err != nil {
+		fmt.Printf("  ✗ Price lookup failed: %v\n\n", err)
+		return err
+	}
+	candidates, err := pricing.Lookup(region, "", rec.InstanceFamilies, nil)
+	if err != nil {
+		fmt.Printf("  ✗ Price lookup failed: %v\n\n", err)
+		return err
+	}
+
+	res := savings.Simulate(usage, current, candidates, capacity, profile.CNI)
+	sym := pricing.Symbol(res.Currency)
+
+	// ── Report ────────────────────────────────────────────────────────────
+	printSection("Today")
+	fmt.Printf("  Nodes             : %d\n", res.CurrentNodes)
+	fmt.Printf("  Requested / alloc : CPU %.0f%%   memory %.0f%%\n", res.CPUUtil*100, res.MemUtil*100)
+	fmt.Printf("  Cost              : %s%.2f/h  (≈ %s%.0f/month)\n", sym, res.CurrentHourly, sym, res.CurrentHourly*pricing.HoursPerMonth)
+	if len(res.Unpriced) > 0 {
+		fmt.Printf("  ⚠  No price for %s — those nodes count as %s0.\n", strings.Join(res.Unpriced, ", "), sym)
+	}
+
+	fmt.Println()
+	printSection("After consolidation (simulated)")
+	if res.PinnedNodes > 0 {
+		fmt.Printf("  Kept as-is        : %d node(s) with pods that must not move  (%s%.2f/h)\n", res.PinnedNodes, sym, res.PinnedHourly)
+	}
+	if res.MovablePods == 0 {
+		fmt.Printf("  No movable pods — nothing to consolidate.\n\n")
+		return nil
+	}
+	if res.TargetNodes == 0 {
+		fmt.Printf("  ✗ No candidate instance type fits the largest pod — try --mode balanced.\n\n")
+		return nil
+	}
+	fmt.Printf("  Repacked pods     : %d\n", res.MovablePods)
+	fmt.Printf("  Target nodes      : %d × %s (%s, %d vCPU / %.0f GiB)\n",
+		res.TargetNodes, res.Target.InstanceType, res.TargetCapacity, res.Target.VCPU, res.Target.MemoryGiB)
+	fmt.Printf("  Cost              : %s%.2f/h  (≈ %s%.0f/month)\n", sym, res.ProjectedHourly(), sym, res.ProjectedHourly()*pricing.HoursPerMonth)
+
+	fmt.Println()
+	saving := res.MonthlySaving()
+	if saving > 0 {
+		fmt.Printf("  ✓  Estimated saving : %s%.0f/month  (%.0f%%)\n", sym, saving, res.SavingPercent())
+		fmt.Printf("     Enable consolidation with `karpx nodes -c %s --mode %s`.\n\n", contextOrCurrent(kubeCtx), mode)
+	} else {
+		fmt.Printf("  ℹ  No saving expected — the cluster is already tightly packed.\n\n")
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// showback command — Karpenter node cost by namespace / team
+// ─────────────────────────────────────────────────────────────────────────────
+
+func showbackCmd() *cobra.Command {
+	var kubeCtx, region, teamLabel, output string
+	var spread bool
+	cmd := &cobra.Command{
+		Use:   "showback",
+		Short: "Attribute the cost of Karpenter nodes to namespaces or teams (AWS)",
+		Long: `Estimate what each namespace's workloads cost on the Karpenter-managed
+nodes under the current NodePool configuration. Each node's price is split
+between its pods in proportion to their requests (half by CPU, half by
+memory); DaemonSets and capacity nobody requested are shown as their own
+rows, or spread over the namespaces with --spread-overhead.
+
+With --team-label, namespaces are grouped by that namespace label — the same
+label karpx nodes --team-label uses — and unlabelled namespaces are charged
+to (unassigned). The same table is available in the web dashboard.`,
+		Example: "  karpx showback -c my-cluster\n  karpx showback -c my-cluster --team-label team --spread-overhead\n  karpx showback -c my-cluster -o json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			return runShowback(kubeCtx, region, teamLabel, output, spread)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region (default: from context)")
+	cmd.Flags().StringVar(&teamLabel, "team-label", "", "namespace label key to group namespaces into teams")
+	cmd.Flags().BoolVar(&spread, "spread-overhead", false, "charge DaemonSet and idle cost to the namespaces in proportion")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	return cmd
+}
+
+func runShowback(kubeCtx, region, teamLabel, output string, spread bool) error {
+	quiet := output == "json"
+	if !quiet {
+		fmt.Printf("\n  karpx showback  context:%s\n\n", contextOrCurrent(kubeCtx))
+	}
+	if p := kube.DetectProvider(kubeCtx); p != kube.ProviderAWS && p != kube.ProviderUnknown {
+		fmt.Printf("  ✗ showback currently supports AWS only (detected %s).\n\n", p.Meta().Label)
+		return nil
+	}
+	if region == "" {
+		region = awscli.RegionFromContext(kubeCtx)
+	}
+	if quiet && region == "" {
+		return fmt.Errorf("--region is required with -o json when it cannot be read from the context")
+	}
+	region = askIfEmpty(region, "AWS region", "us-east-1")
+
+	if !quiet {
+		fmt.Printf("  Reading nodes and pod requests, fetching prices in %s…\n\n", region)
+	}
+	rep, err := showback.Load(kubeCtx, region, teamLabel, spread)
+	if err != nil {
+		if !quiet {
+			fmt.Printf("  ✗ %v\n\n", err)
+		}
+		return err
+	}
+	if quiet {
+		return printJSON(rep)
+	}
+	if rep.Nodes == 0 {
+		fmt.Printf("  No Karpenter-managed nodes — nothing to attribute.\n\n")
+		return nil
+	}
+
+	label := "NAMESPACE"
+	if teamLabel != "" {
+		label = "TEAM (" + teamLabel + ")"
+	}
+	sym := strings.TrimSpace(pricing.Symbol(rep.Currency))
+	fmt.Printf("  %-28s %5s %8s %9s %10s %11s %6s\n", label, "PODS", "CPU", "MEM GiB", sym+"/hour", sym+"/month", "SHARE")
+	fmt.Printf("  %s\n", strings.Repeat("─", 84))
+	for _, r := range rep.Rows {
+		fmt.Printf("  %-28s %5d %8.2f %9.1f %10.4f %11.0f %5.1f%%\n",
+			r.Name, r.Pods, float64(r.CPUm)/1000, float64(r.MemMiB)/1024, r.Hourly, r.Monthly(), r.Percent)
+		if len(r.Namespaces) > 0 {
+			fmt.Printf("    └ %s\n", strings.Join(r.Namespaces, ", "))
+		}
+	}
+	fmt.Printf("  %s\n", strings.Repeat("─", 84))
+	fmt.Printf("  %-28s %5s %8s %9s %10.4f %11.0f\n", fmt.Sprintf("total (%d nodes)", rep.Nodes), "", "", "", rep.Hourly, rep.Monthly())
+	if len(rep.Unpriced) > 0 {
+		fmt.Printf("\n  ⚠  No price for %s — those nodes count as %s0.\n", strings.Join(rep.Unpriced, ", "), sym)
+	}
+	fmt.Printf("\n  Estimates from current on-demand / spot prices (%s, Linux) and pod requests,\n", rep.Currency)
+	fmt.Printf("  not from the bill — savings plans, RIs and data transfer are not included.\n\n")
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// audit command — disruption safety checks
+// ─────────────────────────────────────────────────────────────────────────────
+
+func auditCmd() *cobra.Command {
+	var kubeCtx, output string
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Report which workloads and nodes Karpenter disruption will (and will not) touch",
+		Long: `Audit how the cluster is prepared for Karpenter disruption before enabling
+consolidation or rolling out a new NodePool.
+
+Disruption coverage lists:
+  • workloads whose pods carry karpenter.sh/do-not-disrupt
+  • critical workloads without it — StatefulSets, single-replica workloads,
+    and pods with a priority class (kube-system and DaemonSets are skipped)
+  • nodes fully blocked from consolidation and drift by an annotated pod
+    or by the annotation on the node itself`,
+		Example: "  karpx audit -c my-cluster\n  karpx audit -c my-cluster -o json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			return runAudit(kubeCtx, output)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	return cmd
+}
+
+// auditReport is the JSON shape of `karpx audit -o json`.
+type auditReport struct {
+	Context    string                   `json:"context"`
+	Disruption *kube.DisruptionCoverage `json:"disruption"`
+}
+
+func runAudit(kubeCtx, output string) error {
+	cov, err := kube.AnalyzeDisruption(kubeCtx)
+	if err != nil {
+		if output == "table" {
+			fmt.Printf("\n  ✗ Could not read the cluster: %v\n\n", err)
+		}
+		return err
+	}
+	if output == "json" {
+		return printJSON(auditReport{Context: contextOrCurrent(kubeCtx), Disruption: cov})
+	}
+
+	fmt.Printf("\n  ⚡ karpx audit  context:%s\n\n", contextOrCurrent(kubeCtx))
+	printSection("Disruption coverage (" + kube.DoNotDisrupt + ")")
+	fmt.Println()
+
+	if len(cov.Protected) == 0 {
+		fmt.Printf("  ℹ  No workloads use %s.\n", kube.DoNotDisrupt)
+	} else {
+		fmt.Printf("  Protected workloads:\n")
+		for _, w := range cov.Protected {
+			fmt.Printf("    ✓  %-50s  %d/%d pod(s)\n", w.Key(), w.Protected, w.Pods)
+		}
+	}
+
+	fmt.Println()
+	if len(cov.Unprotected) == 0 {
+		fmt.Printf("  ✓  Every critical workload is protected.\n")
+	} else {
+		fmt.Printf("  Critical workloads without it (%d):\n", len(cov.Unprotected))
+		for _, w := range cov.Unprotected {
+			fmt.Printf("    ⚠  %-50s  %s\n", w.Key(), w.Reason)
+		}
+		fmt.Printf("     Consolidation may evict these — add the annotation to the pod template\n")
+		fmt.Printf("     or a PodDisruptionBudget if that is not acceptable.\n")
+	}
+
+	fmt.Println()
+	if len(cov.BlockedNodes) == 0 {
+		fmt.Printf("  ✓  No nodes are blocked from disruption.\n\n")
+		return nil
+	}
+	karpBlocked := 0
+	fmt.Printf("  Nodes blocked from consolidation and drift:\n")
+	for _, b := range cov.BlockedNodes {
+		by := strings.Join(b.Pods, ", ")
+		if b.Node {
+			by = "node annotation"
+			if len(b.Pods) > 0 {
+				by += ", " + strings.Join(b.Pods, ", ")
+			}
+		}
+		owner := "—"
+		if b.Karpenter {
+			owner = "karpenter"
+			karpBlocked++
+		}
+		fmt.Printf("    ✗  %-40s  %-9s  %s\n", b.Name, owner, by)
+	}
+	fmt.Printf("\n  %d node(s) blocked, %d of them launched by Karpenter.\n", len(cov.BlockedNodes), karpBlocked)
+	fmt.Printf("  Blocked Karpenter nodes are never consolidated or drifted until the pods move.\n")
+	fmt.Printf("  Expiry still replaces them: set terminationGracePeriod to bound how long the pods hold it.\n\n")
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// report command — scheduled Karpenter health report
+// ─────────────────────────────────────────────────────────────────────────────
+
+func reportCmd() *cobra.Command {
+	var kubeCtx, format, out, statePath string
+	var tags []string
+	var noCost bool
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Write a Karpenter health report (HTML or Markdown) for cron",
+		Long: `Write one report covering every kubeconfig context (or -c / --tag):
+
+  • fleet compatibility — Kubernetes and Karpenter versions, compatibility,
+    available upgrades and version-policy violations
+  • cost summary — estimated monthly cost of the Karpenter nodes and the most
+    expensive namespaces (AWS; see karpx showback)
+  • lint findings — NodePool and EC2NodeClass anti-patterns (see karpx lint)
+  • changes since the last report — upgrades, new findings, cost swings
+
+What the previous report saw is kept in --state, so scheduling the command
+weekly produces the week's changes with no manual work. The state is only
+updated once the report has been written.`,
+		Example: `  karpx report --format html --out /var/reports/karpenter.html
+  karpx report --format md --out - --tag env=prod
+  # crontab: every Monday at 07:00
+  0 7 * * 1  karpx report --format html --out ~/reports/karpenter-$(date +\%F).html`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "html" && format != "md" {
+				return fmt.Errorf("--format must be html or md, got %q", format)
+			}
+			if out == "" {
+				return fmt.Errorf("--out is required (a file path, or - for stdout)")
+			}
+			sel, err := config.ParseSelector(tags)
+			if err != nil {
+				return err
+			}
+			return runReport(kubeCtx, format, out, statePath, sel, !noCost)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context (default: all contexts)")
+	cmd.Flags().StringVar(&format, "format", "html", "report format: html | md")
+	cmd.Flags().StringVar(&out, "out", "", "file to write (- for stdout)")
+	cmd.Flags().StringVar(&statePath, "state", report.DefaultStatePath(), "where the previous report is remembered (\"\" to disable changes)")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "only clusters whose config-file tags match (key=value, key!=value, key)")
+	cmd.Flags().BoolVar(&noCost, "no-cost", false, "skip the cost summary (no AWS price lookups)")
+	return cmd
+}
+
+func runReport(kubeCtx, format, out, statePath string, sel config.Selector, withCost bool) error {
+	fmt.Fprintf(os.Stderr, "\n  Collecting cluster status…\n")
+	rep, err := report.Build(report.Params{
+		KubeCtx:  kubeCtx,
+		Selector: sel,
+		Version:  version,
+		Cost:     withCost,
+		State:    statePath,
+	}, func(ctx string) { fmt.Fprintf(os.Stderr, "  • %s\n", ctx) })
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ %v\n\n", err)
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := rep.Render(&buf, format); err != nil {
+		return err
+	}
+	text := redact.String(buf.String())
+	if out == "-" {
+		fmt.Print(text)
+	} else {
+		// Write then rename, so a reader (or a mail step in the same cron
+		// job) never sees half a report.
+		tmp := out + ".tmp"
+		if err := os.WriteFile(tmp, []byte(text), 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, out); err != nil {
+			return err
+		}
+	}
+	if statePath != "" {
+		if err := rep.SaveState(statePath); err != nil {
+			return fmt.Errorf("save report state: %w", err)
+		}
+	}
+	if out != "-" {
+		t := rep.Totals()
+		fmt.Fprintf(os.Stderr, "  ✓  Report written to %s — %d cluster(s), %d changed, %d lint error(s)\n\n",
+			out, len(rep.Clusters), t.Changed, t.LintErrors)
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// tune command — controller sizing and batching
+// ─────────────────────────────────────────────────────────────────────────────
+
+func tuneCmd() *cobra.Command {
+	var kubeCtx, output string
+	var apply bool
+	cmd := &cobra.Command{
+		Use:   "tune",
+		Short: "Recommend Karpenter controller replicas, requests and batching for the cluster",
+		Long: `Size the Karpenter controller for the cluster it runs in.
+
+The starting point is a tier picked from the node and pod counts:
+
+  small    ≤ 50 nodes,   ≤ 1,500 pods    250m CPU  512Mi   batch 1s / 10s
+  medium   ≤ 250 nodes,  ≤ 7,500 pods    1 CPU     1Gi     batch 1s / 10s
+  large    ≤ 1000 nodes, ≤ 30,000 pods   2 CPU     4Gi     batch 2s / 15s
+  xlarge   larger                        4 CPU     8Gi     batch 3s / 20s
+
+then adjusted by what the controller actually does: peak CPU and memory
+from metrics-server (plus headroom), OOM kills, and the average scheduling
+round from the controller's own metrics. The memory limit is set equal to
+the request, and replicas to 2 (a leader and a standby).
+
+--apply upgrades the Helm release in place with just these values on top of
+the deployed ones (same chart version, --reuse-values); -o values prints the
+patch for GitOps.`,
+		Example: `  karpx tune -c prod
+  karpx tune -c prod -o values > karpenter-tuning.yaml
+  karpx tune -c prod --apply`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" && output != "values" {
+				return fmt.Errorf("--output must be table, json or values, got %q", output)
+			}
+			return runTune(kubeCtx, output, apply)
+		},
+	}
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context (default: current context)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json | values")
+	cmd.Flags().BoolVar(&apply, "apply", false, "apply the recommendation with helm upgrade --reuse-values")
+	return cmd
+}
+
+func runTune(kubeCtx, output string, apply bool) error {
+	info, err := helm.DetectKarpenter(kubeCtx)
+	if err != nil || !info.Installed {
+		return fmt.Errorf("Karpenter is not installed on %s", contextOrCurrent(kubeCtx))
+	}
+	if err := info.ManagedError(); err != nil {
+		return err
+	}
+	ns := info.Namespace
+	if ns == "" {
+		ns = "karpenter"
+	}
+	deploymentName := info.ReleaseName
+	if deploymentName == "" {
+		deploymentName = "karpenter"
+	}
+
+	obs, err := tuning.Observe(kubeCtx, ns, deploymentName)
+	if err != nil {
+		return err
+	}
+	plan := tuning.Recommend(obs)
+
+	switch output {
+	case "json":
+		return printJSON(plan)
+	case "values":
+		vals, err := plan.Values()
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(vals))
+		return nil
+	}
+
+	orDash := func(s string) string {
+		if s == "" {
+			return "—"
+		}
+		return s
+	}
+	fmt.Printf("\n  🎛  karpx tune  context:%s\n\n", contextOrCurrent(kubeCtx))
+	printSection("Observed")
+	fmt.Printf("  Cluster     : %d nodes (%d by Karpenter), %d pods — %s tier\n", obs.Nodes, obs.KarpenterNodes, obs.Pods, plan.Tier)
+	fmt.Printf("  Controller  : %d replica(s), requests cpu=%s memory=%s, memory limit %s\n",
+		obs.Replicas, orDash(obs.CPURequest), orDash(obs.MemoryRequest), orDash(obs.MemoryLimit))
+	fmt.Printf("  Batching    : idle %s, max %s\n", obs.BatchIdle, obs.BatchMax)
+	if obs.CPUUsageMilli > 0 || obs.MemoryUsage > 0 {
+		fmt.Printf("  Peak usage  : %dm CPU, %dMi memory\n", obs.CPUUsageMilli, obs.MemoryUsage>>20)
+	} else {
+		fmt.Printf("  Peak usage  : unknown (metrics-server not installed)\n")
+	}
+	if obs.Scheduling > 0 {
+		fmt.Printf("  Scheduling  : %s per round on average\n", obs.Scheduling.Round(time.Millisecond))
+	}
+	if obs.OOMKilled {
+		fmt.Printf("  ⚠  The controller was OOM-killed (%d restart(s)).\n", obs.Restarts)
+	}
+	fmt.Println()
+
+	if len(plan.Changes) == 0 {
+		fmt.Printf("  ✓  The controller is already sized for this cluster.\n\n")
+		return nil
+	}
+	printSection("Recommended")
+	for _, c := range plan.Changes {
+		fmt.Printf("  •  %-38s  %-8s → %-8s  %s\n", c.Key, c.Current, c.Recommended, c.Reason)
+	}
+	fmt.Println()
+
+	if !apply {
+		fmt.Printf("  ℹ  Apply with --apply, or -o values for a values file.\n\n")
+		return nil
+	}
+	if info.Manifests {
+		return fmt.Errorf("Karpenter was installed as manifests; re-render it with these values (karpx tune -o values)")
+	}
+	chart := releaseChart(kubeCtx)
+	if !confirmPrompt(fmt.Sprintf("  Upgrade release %s/%s with these values? [y/N] ", ns, info.ReleaseName)) {
 		fmt.Printf("  Cancelled.\n\n")
 		return nil
 	}
+	hookVars := map[string]string{
+		"KARPX_VERSION":      info.Version,
+		"KARPX_FROM_VERSION": info.Version,
+		"KARPX_NAMESPACE":    ns,
+		"KARPX_RELEASE":      info.ReleaseName,
+	}
+	if err := hooks.Around(hooks.Upgrade, kubeCtx, hookVars, os.Stdout, func() error {
+		return tuning.Apply(kubeCtx, ns, info.ReleaseName, chart, info.Version, plan)
+	}); err != nil {
+		fmt.Printf("  ✗ %v\n\n", err)
+		return err
+	}
+	fmt.Printf("  ✓  Controller retuned; the Deployment has rolled out.\n\n")
+	return nil
+}
+
+// releaseChart is the chart to upgrade an installed release with in place:
+// the AWS chart (or its --registry-mirror copy), or the provider's.
+func releaseChart(kubeCtx string) string {
+	if p := kube.DetectProvider(kubeCtx); p != kube.ProviderAWS && p.Meta().ChartRepo != "" {
+		return p.Meta().ChartRepo
+	}
+	return helm.KarpenterChart
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// featuregates command — list and toggle Karpenter feature gates
+// ─────────────────────────────────────────────────────────────────────────────
+
+func featureGatesCmd() *cobra.Command {
+	var kubeCtx, output string
+	cmd := &cobra.Command{
+		Use:   "featuregates",
+		Short: "List and toggle Karpenter feature gates",
+		Long: `List the feature gates of the installed Karpenter version with their deployed
+values (settings.featureGates in the Helm values, or FEATURE_GATES for
+manifest installs), and toggle them in place.
+
+Gates only exist in a range of releases, and a chart silently ignores a
+gate it does not know, so every change is checked against the installed
+version first. The gate table follows the AWS provider's releases, so only
+AWS clusters are supported; set settings.featureGates with your own values
+elsewhere.`,
+		Example: `  karpx featuregates -c prod
+  karpx featuregates set SpotToSpotConsolidation=true -c prod
+  karpx featuregates set NodeRepair=true ReservedCapacity=false -c prod`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			return runFeatureGates(kubeCtx, output)
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context (default: current context)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
+	cmd.AddCommand(featureGatesSetCmd(&kubeCtx))
+	return cmd
+}
+
+func featureGatesSetCmd(kubeCtx *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set NAME=true|false...",
+		Short: "Toggle feature gates with helm upgrade --reuse-values",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFeatureGatesSet(*kubeCtx, args)
+		},
+	}
+}
+
+// featureGateRelease resolves the installed Karpenter for the featuregates
+// commands.
+func featureGateRelease(kubeCtx string) (featuregates.Release, error) {
+	if provider := kube.DetectProvider(kubeCtx); provider != kube.ProviderAWS && provider != kube.ProviderUnknown {
+		return featuregates.Release{}, fmt.Errorf("feature gates are known for AWS EKS only (detected %s); its release numbers differ from other providers'", provider.Meta().Label)
+	}
+	info, err := helm.DetectKarpenter(kubeCtx)
+	if err != nil || !info.Installed {
+		return featuregates.Release{}, fmt.Errorf("Karpenter is not installed on %s", contextOrCurrent(kubeCtx))
+	}
+	if err := info.ManagedError(); err != nil {
+		return featuregates.Release{}, err
+	}
+	if info.Version == "" {
+		return featuregates.Release{}, fmt.Errorf("cannot tell which Karpenter version is installed on %s", contextOrCurrent(kubeCtx))
+	}
+	r := featuregates.Release{KubeCtx: kubeCtx, Namespace: info.Namespace, Name: info.ReleaseName, Version: info.Version, Manifests: info.Manifests}
+	if r.Namespace == "" {
+		r.Namespace = "karpenter"
+	}
+	if r.Name == "" {
+		r.Name = "karpenter"
+	}
+	return r, nil
+}
+
+func runFeatureGates(kubeCtx, output string) error {
+	r, err := featureGateRelease(kubeCtx)
+	if err != nil {
+		return err
+	}
+	states, err := featuregates.Current(r)
+	if err != nil {
+		return err
+	}
+	if output == "json" {
+		return printJSON(states)
+	}
+
+	fmt.Printf("\n  Karpenter v%s  context:%s\n\n", strings.TrimPrefix(r.Version, "v"), contextOrCurrent(kubeCtx))
+	fmt.Printf("  %-26s  %-6s  %-13s  %-8s  %s\n", "GATE", "STAGE", "VALUE", "SINCE", "NOTE")
+	for _, st := range states {
+		value := "off"
+		if st.Enabled {
+			value = "on"
+		}
+		if !st.Explicit {
+			value += " (default)"
+		}
+		since := "v" + st.Since
+		if !st.Supported {
+			why := "not in this version"
+			if st.Removed != "" {
+				why = "removed in v" + st.Removed
+			}
+			fmt.Printf("  %-26s  %-6s  %-13s  %-8s  %s\n", st.Name, st.Stage, "—", since, why)
+			continue
+		}
+		fmt.Printf("  %-26s  %-6s  %-13s  %-8s  %s\n", st.Name, st.Stage, value, since, st.Note)
+	}
+	fmt.Printf("\n  Toggle with: karpx featuregates set <Gate>=true|false -c %s\n\n", contextOrCurrent(kubeCtx))
+	return nil
+}
 
-	// ── helm uninstall ────────────────────────────────────────────────────
-	args := []string{"uninstall", releaseName, "--namespace", info.Namespace}
-	if kubeCtx != "" {
-		args = append(args, "--kube-context", kubeCtx)
+func runFeatureGatesSet(kubeCtx string, args []string) error {
+	r, err := featureGateRelease(kubeCtx)
+	if err != nil {
+		return err
 	}
-
-	fmt.Printf("\n  Uninstalling Karpenter…\n")
-	helmCmd := exec.Command("helm", args...)
-	helmCmd.Stdout = os.Stdout
-	helmCmd.Stderr = os.Stderr
-	if err := helmCmd.Run(); err != nil {
-		return fmt.Errorf("helm uninstall failed: %w", err)
+	gates, err := featuregates.Parse(args, r.Version)
+	if err != nil {
+		return err
+	}
+	states, err := featuregates.Current(r)
+	if err != nil {
+		return err
 	}
-	fmt.Printf("\n  ✓  Karpenter uninstalled.\n")
 
-	// ── Optionally delete the namespace ───────────────────────────────────
-	if deleteNs {
-		fmt.Printf("  Deleting namespace %q…\n", info.Namespace)
-		nsArgs := []string{"delete", "namespace", info.Namespace}
-		if kubeCtx != "" {
-			nsArgs = append(nsArgs, "--context", kubeCtx)
+	fmt.Printf("\n  Karpenter v%s  context:%s\n\n", strings.TrimPrefix(r.Version, "v"), contextOrCurrent(kubeCtx))
+	changed := map[featuregates.Gate]bool{}
+	for _, st := range states {
+		want, ok := gates[st.Gate]
+		if !ok {
+			continue
 		}
-		nsCmd := exec.Command("kubectl", nsArgs...)
-		nsCmd.Stdout = os.Stdout
-		nsCmd.Stderr = os.Stderr
-		if err := nsCmd.Run(); err != nil {
-			fmt.Printf("  ⚠  Could not delete namespace: %v\n", err)
-		} else {
-			fmt.Printf("  ✓  Namespace %q deleted.\n", info.Namespace)
+		if want == st.Enabled && st.Explicit {
+			fmt.Printf("  •  %-26s  already %t\n", st.Name, want)
+			continue
+		}
+		fmt.Printf("  •  %-26s  %t → %t\n", st.Name, st.Enabled, want)
+		if want && st.Stage == "alpha" {
+			fmt.Printf("     ⚠  alpha: %s\n", st.Note)
 		}
+		changed[st.Gate] = want
 	}
-
-	fmt.Printf("\n  To remove remaining CRDs:\n")
-	fmt.Printf("    kubectl delete nodepools,ec2nodeclasses --all\n\n")
+	fmt.Println()
+	if len(changed) == 0 {
+		fmt.Printf("  ✓  Nothing to change.\n\n")
+		return nil
+	}
+	if err := r.Settable(); err != nil {
+		return err
+	}
+	if !confirmPrompt(fmt.Sprintf("  Upgrade release %s/%s in place? [y/N] ", r.Namespace, r.Name)) {
+		fmt.Printf("  Cancelled.\n\n")
+		return nil
+	}
+	hookVars := map[string]string{
+		"KARPX_VERSION":      r.Version,
+		"KARPX_FROM_VERSION": r.Version,
+		"KARPX_NAMESPACE":    r.Namespace,
+		"KARPX_RELEASE":      r.Name,
+	}
+	if err := hooks.Around(hooks.Upgrade, kubeCtx, hookVars, os.Stdout, func() error {
+		return featuregates.Set(r, releaseChart(kubeCtx), changed)
+	}); err != nil {
+		fmt.Printf("  ✗ %v\n\n", err)
+		return err
+	}
+	fmt.Printf("  ✓  Feature gates updated; the controller has rolled out.\n\n")
 	return nil
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
-// nodes command — analyse workloads and generate/apply a NodePool config
+// doctor command — connectivity through proxies and TLS inspection
 // ─────────────────────────────────────────────────────────────────────────────
 
-func nodesCmd() *cobra.Command {
-	var kubeCtx, providerFlag, modeFlag string
+func doctorCmd() *cobra.Command {
+	var kubeCtx, region, output string
 	cmd := &cobra.Command{
-		Use:   "nodes",
-		Short: "Analyse workloads and generate an optimised Karpenter NodePool",
-		Long: `Analyse all running workloads in the cluster, ask how you want to
-optimise node provisioning, then generate (and optionally apply) the ideal
-Karpenter NodePool + NodeClass configuration.
-
-Optimisation modes:
-  cost        — Spot instances, Graviton (arm64) where available, lowest $/hour
-  balanced    — Mixed Spot + On-Demand, multiple families
-  performance — On-Demand only, latest-gen instances, maximum throughput
-  freetier    — Free-tier eligible instances only (m7i-flex, c7i-flex, t3, t4g)
-`,
-		Example: `  karpx nodes -c my-cluster
-  karpx nodes -c my-cluster --mode cost
-  karpx nodes -c my-cluster --provider aws --mode performance`,
+		Use:   "doctor",
+		Short: "Check that karpx can reach GitHub, registries, AWS and the cluster",
+		Long: `Check every endpoint karpx talks to, through the same proxy and CA bundle
+karpx and the tools it runs use:
+
+  • GitHub releases (compatibility matrix)
+  • the chart registry (public.ecr.aws or --registry-mirror)
+  • the Kubernetes API server of the context
+  • the AWS STS and Pricing endpoints of the region
+  • kubectl, helm and aws on PATH
+
+The proxy comes from HTTPS_PROXY / NO_PROXY, or network.httpsProxy and
+network.noProxy in the config file. Proxies that inspect TLS re-sign
+traffic with their own CA: pass it with --ca-bundle, $KARPX_CA_BUNDLE or
+network.caBundle. karpx trusts it on top of the system roots and hands the
+combined bundle to helm, kubectl and aws.`,
+		Example: `  karpx doctor
+  karpx doctor --ca-bundle /etc/pki/corp-root.pem
+  HTTPS_PROXY=http://proxy.corp:3128 NO_PROXY=.eks.amazonaws.com karpx doctor -o json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runNodes(kubeCtx, providerFlag, modeFlag)
+			if output != "table" && output != "json" {
+				return fmt.Errorf("--output must be table or json, got %q", output)
+			}
+			return runDoctor(kubeCtx, region, output)
 		},
 	}
-	cmd.Flags().StringVarP(&kubeCtx,     "context",  "c", "", "kubeconfig context")
-	cmd.Flags().StringVar(&providerFlag, "provider",     "", "cloud provider: aws | azure | gcp (default: auto-detect)")
-	cmd.Flags().StringVar(&modeFlag,     "mode",         "", "optimisation mode: cost | balanced | performance | freetier (default: ask)")
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context (default: current context)")
+	cmd.Flags().StringVarP(&region, "region", "r", "", "AWS region (default: from the context, then us-east-1)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json")
 	return cmd
 }
 
-func runNodes(kubeCtx, providerFlag, modeFlag string) error {
-	fmt.Printf("\n  ⚡ karpx nodes  context:%s\n", contextOrCurrent(kubeCtx))
-
-	// Resolve provider.
-	var provider kube.Provider
-	if providerFlag != "" {
-		provider = kube.ParseProvider(providerFlag)
+func runDoctor(kubeCtx, region, output string) error {
+	settings := outbound.Current()
+	checks := doctor.Run(doctor.Params{KubeCtx: kubeCtx, Region: region})
+	failed := doctor.Failed(checks)
+
+	if output == "json" {
+		if err := printJSON(struct {
+			Network outbound.Info  `json:"network"`
+			Checks  []doctor.Check `json:"checks"`
+		}{settings, checks}); err != nil {
+			return err
+		}
 	} else {
-		provider = kube.DetectProvider(kubeCtx)
-		if provider == kube.ProviderUnknown {
-			provider = askProviderMenu()
+		fmt.Println()
+		printSection("Network")
+		orNone := func(s string) string {
+			if s == "" {
+				return "(none)"
+			}
+			return s
+		}
+		fmt.Printf("  HTTPS proxy : %s\n", orNone(settings.HTTPSProxy))
+		fmt.Printf("  NO_PROXY    : %s\n", orNone(settings.NoProxy))
+		if settings.CABundle != "" {
+			fmt.Printf("  CA bundle   : %s (%d certificate(s))\n", settings.CABundle, settings.CACerts)
+			if len(settings.Exported) > 0 {
+				fmt.Printf("                exported to tools as %s\n", strings.Join(settings.Exported, ", "))
+			}
+		} else {
+			fmt.Printf("  CA bundle   : system roots only\n")
 		}
-	}
-	if !provider.Supported() {
-		printUnsupportedProvider()
-		return nil
-	}
-	fmt.Printf("  Provider : %s\n\n", provider.Meta().Label)
 
-	// Resolve mode (skip asking if passed via flag).
-	var mode nodes.OptimizationMode
-	if modeFlag != "" {
-		switch strings.ToLower(modeFlag) {
-		case "cost":
-			mode = nodes.ModeCostOptimized
-		case "performance", "perf":
-			mode = nodes.ModeHighPerformance
-		case "freetier", "free-tier", "free":
-			mode = nodes.ModeFreeTier
-		default:
-			mode = nodes.ModeBalanced
+		fmt.Println()
+		printSection("Checks")
+		for _, c := range checks {
+			glyph := "✓"
+			switch c.Status {
+			case doctor.Warn:
+				glyph = "⚠"
+			case doctor.Fail:
+				glyph = "✗"
+			}
+			via := "direct"
+			if c.Via != "" {
+				via = "via proxy"
+			}
+			if c.Latency == 0 {
+				via = ""
+			}
+			fmt.Printf("  %s  %-16s  %-9s  %s\n", glyph, c.Name, via, c.Target)
+			if c.Detail != "" {
+				fmt.Printf("       %s\n", c.Detail)
+			}
+			if c.Fix != "" {
+				fmt.Printf("       → %s\n", c.Fix)
+			}
+		}
+		fmt.Println()
+		if failed == 0 {
+			fmt.Printf("  ✓  All endpoints reachable.\n\n")
 		}
 	}
-
-	rec := runNodeRecommendationWithMode(kubeCtx, provider, mode)
-	if rec == nil {
-		return nil
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
 	}
-
-	// Print the manifest.
-	manifest := nodes.GenerateManifest(*rec, "", "")
-	fmt.Println()
-	printSection("Generated NodePool manifest")
-	fmt.Println()
-	fmt.Println(manifest)
-
-	applyOrSaveManifest(manifest, kubeCtx)
 	return nil
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
-// Shared node recommendation logic (used by both install and nodes command)
+// images command — what to mirror for air-gapped installs
 // ─────────────────────────────────────────────────────────────────────────────
 
-// runNodeRecommendation runs workload analysis + asks optimisation preference.
-// Returns nil if the user declines or no useful recommendation can be made.
-func runNodeRecommendation(kubeCtx string, provider kube.Provider) *nodes.Recommendation {
-	return runNodeRecommendationWithMode(kubeCtx, provider, "")
-}
-
-func runNodeRecommendationWithMode(kubeCtx string, provider kube.Provider, mode nodes.OptimizationMode) *nodes.Recommendation {
-	printSection("Step 6: Node type optimisation")
-	fmt.Println()
-
-	// ── Analyse workloads ──────────────────────────────────────────────────
-	fmt.Printf("  Analysing running workloads in the cluster…\n")
-	profile, err := kube.AnalyzeWorkloads(kubeCtx)
-	if err != nil {
-		fmt.Printf("  ⚠  Could not read workloads (%v)\n", err)
-		fmt.Printf("     Continuing with defaults — you can re-run `karpx nodes` later.\n\n")
-		profile = &kube.WorkloadProfile{NoRequests: true}
+func imagesCmd() *cobra.Command {
+	var providerFlag, ver, output string
+	cmd := &cobra.Command{
+		Use:   "images",
+		Short: "List the charts and images of a Karpenter version for air-gapped mirroring",
+		Long: `List the exact chart and container image references, pinned by digest, that
+a Karpenter version deploys, so they can be copied into a private registry.
+The controller image also serves the webhooks, so it is the only image on
+current releases.
+
+With --registry-mirror the copy commands and a values file pointing the
+chart at the mirror are printed too (-o values prints just the values). The
+chart pins images by digest: copy with a digest-preserving tool such as
+crane or skopeo --all.`,
+		Example: `  karpx images --version 1.0.8
+  karpx images --version 1.0.8 --registry-mirror registry.corp/karpenter
+  karpx images --version 1.0.8 --registry-mirror registry.corp/karpenter -o values > mirror-values.yaml
+  karpx images --provider azure --version 1.4.0 -o json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" && output != "values" {
+				return fmt.Errorf("--output must be table, json or values, got %q", output)
+			}
+			if output == "values" && !helm.Mirrored() {
+				return fmt.Errorf("-o values needs --registry-mirror")
+			}
+			provider := kube.ParseProvider(providerFlag)
+			if !provider.Supported() || provider.Meta().ChartRepo == "" {
+				return fmt.Errorf("--provider must be aws, azure or gcp, got %q", providerFlag)
+			}
+			return runImages(provider, ver, output)
+		},
 	}
+	cmd.Flags().StringVar(&providerFlag, "provider", "aws", "cloud provider: aws | azure | gcp")
+	cmd.Flags().StringVar(&ver, "version", "", "Karpenter version (default: latest release; required for azure and gcp)")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table | json | values")
+	return cmd
+}
 
-	wtype := kube.ClassifyWorkload(profile)
-
-	// ── Print analysis summary ─────────────────────────────────────────────
-	if profile.TotalPods > 0 {
-		fmt.Printf("  Discovered workloads:\n")
-		fmt.Printf("    Pods           : %d  (across %d namespace(s))\n", profile.TotalPods, profile.Namespaces)
-		fmt.Printf("    CPU requested  : %.1f cores total   (largest pod: %.1f cores)\n",
-			float64(profile.TotalCPUm)/1000.0, float64(profile.MaxPodCPUm)/1000.0)
-		fmt.Printf("    Memory         : %.1f GiB total     (largest pod: %.0f MiB)\n",
-			float64(profile.TotalMemMiB)/1024.0, float64(profile.MaxPodMemMiB))
-		if profile.HasGPU {
-			fmt.Printf("    GPU workloads  : detected\n")
+func runImages(provider kube.Provider, ver, output string) error {
+	if ver == "" {
+		if provider != kube.ProviderAWS {
+			return fmt.Errorf("--version is required for %s", provider.Meta().Label)
 		}
-		if profile.HasBatchJobs {
-			fmt.Printf("    Batch jobs     : detected\n")
+		versions, err := compat.FetchAvailableVersions()
+		if err != nil {
+			return fmt.Errorf("find the latest release (pass --version): %w", err)
 		}
-		fmt.Printf("    Workload type  : %s", string(wtype))
-		switch wtype {
-		case kube.WorkloadMemory:
-			fmt.Printf("  (%.1f GiB/core — memory-heavy)\n", profile.MemPerCPUGiB)
-		case kube.WorkloadCPU:
-			fmt.Printf("  (%.1f GiB/core — compute-heavy)\n", profile.MemPerCPUGiB)
-		case kube.WorkloadGPU:
-			fmt.Printf("  (GPU resources requested)\n")
-		default:
-			if profile.MemPerCPUGiB > 0 {
-				fmt.Printf("  (%.1f GiB/core)\n", profile.MemPerCPUGiB)
-			} else {
-				fmt.Printf("  (no resource requests set)\n")
-			}
+		if len(versions) == 0 {
+			return fmt.Errorf("no Karpenter releases found; pass --version")
 		}
-	} else {
-		fmt.Printf("  No running pods found — using defaults.\n")
+		ver = versions[0]
 	}
+	ver = strings.TrimPrefix(ver, "v")
 
-	// ── Ask optimisation preference if not already known ───────────────────
-	if mode == "" {
-		fmt.Println()
-		mode = askOptimizationMode()
-		if mode == "" {
-			return nil
+	// Always list the public artifacts, even when --registry-mirror points
+	// installs elsewhere: they are what has to be copied.
+	charts := []string{provider.Meta().ChartRepo}
+	if provider == kube.ProviderAWS {
+		charts = []string{"oci://" + helm.PublicRegistry + "/karpenter", "oci://" + helm.PublicRegistry + "/karpenter-crd"}
+	}
+	if output == "table" {
+		fmt.Printf("\n  Resolving Karpenter v%s (%s)…\n", ver, provider.Meta().Label)
+	}
+	var artifacts []*helm.Artifact
+	for _, chart := range charts {
+		a, err := helm.Images(chart, ver)
+		if err != nil {
+			return err
 		}
+		artifacts = append(artifacts, a)
 	}
 
-	// ── Build recommendation ───────────────────────────────────────────────
-	rec := nodes.Build(profile, mode, provider)
-
-	// ── Print recommendation ───────────────────────────────────────────────
-	fmt.Println()
-	printSection("Recommended node configuration")
-	fmt.Println()
-	fmt.Printf("  Mode              : %s\n", modeLabelShort(mode))
-	fmt.Printf("  Instance families : %s\n", strings.Join(rec.InstanceFamilies, ", "))
-	fmt.Printf("  Capacity types    : %s\n", strings.Join(rec.CapacityTypes, ", "))
-	fmt.Printf("  Architectures     : %s\n", strings.Join(rec.Architectures, ", "))
-	fmt.Printf("  CPU sizes (vCPU)  : %s\n", strings.Join(rec.CPUSizes, ", "))
-	fmt.Printf("  Min node memory   : %d MiB\n", rec.MinNodeMiB)
-	fmt.Println()
-	fmt.Printf("  Why:\n")
-	for _, r := range rec.Reasoning {
-		fmt.Printf("    • %s\n", r)
+	var values []byte
+	if helm.Mirrored() {
+		var err error
+		if values, err = helm.MirrorValues(artifacts[0], helm.Registry()); err != nil {
+			return err
+		}
 	}
 
-	return &rec
-}
-
-// askOptimizationMode shows the cost vs performance question.
-func askOptimizationMode() nodes.OptimizationMode {
-	fmt.Printf(`  What is your node provisioning priority?
-
-    [1]  Cost-Optimized   — Spot instances + Graviton (arm64) where available
-                            Saves 60-80%% vs on-demand; ideal for fault-tolerant workloads
-
-    [2]  Balanced         — Mixed Spot + On-Demand, multiple instance families
-                            Good price/performance for most production workloads
-
-    [3]  High-Performance — On-Demand only, latest-gen instances, no Spot interruptions
-                            Best for latency-sensitive or stateful services
-
-    [4]  Free-Tier        — Free-tier eligible instances only (m7i-flex, c7i-flex, t3, t4g)
-                            For AWS accounts with free-tier or instance-type restrictions
-
-`)
-	fmt.Print("  Choice [1-4]: ")
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		switch strings.TrimSpace(scanner.Text()) {
-		case "1":
-			return nodes.ModeCostOptimized
-		case "2":
-			return nodes.ModeBalanced
-		case "3":
-			return nodes.ModeHighPerformance
-		case "4":
-			return nodes.ModeFreeTier
-		}
-	}
-	fmt.Printf("  Invalid choice — skipping node optimisation.\n\n")
-	return ""
-}
+	switch output {
+	case "values":
+		fmt.Print(string(values))
+		return nil
+	case "json":
+		out := struct {
+			Provider kube.Provider    `json:"provider"`
+			Version  string           `json:"version"`
+			Charts   []*helm.Artifact `json:"charts"`
+			Mirror   string           `json:"mirror,omitempty"`
+			Values   string           `json:"values,omitempty"`
+		}{Provider: provider, Version: ver, Charts: artifacts, Values: string(values)}
+		if helm.Mirrored() {
+			out.Mirror = helm.Registry()
+		}
+		return printJSON(out)
+	}
 
-// applyOrSaveManifest asks whether to kubectl-apply or save to a file.
-func applyOrSaveManifest(manifest, kubeCtx string) {
 	fmt.Println()
-	fmt.Printf("  What would you like to do with this NodePool manifest?\n\n")
-	fmt.Printf("    [1]  Apply now    — kubectl apply -f - (applies to current cluster)\n")
-	fmt.Printf("    [2]  Save to file — write karpx-nodepool.yaml in the current directory\n")
-	fmt.Printf("    [3]  Skip         — I'll handle it manually\n\n")
-	fmt.Print("  Choice [1-3]: ")
-
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
-		return
-	}
-	switch strings.TrimSpace(scanner.Text()) {
-	case "1":
-		applyManifest(manifest, kubeCtx)
-	case "2":
-		saveManifest(manifest)
-	default:
-		fmt.Printf("\n  Skipped — copy the YAML above and run:\n")
-		fmt.Printf("    kubectl apply -f karpx-nodepool.yaml\n\n")
+	printSection("Charts")
+	for _, a := range artifacts {
+		fmt.Printf("  •  %s:%s\n", strings.TrimPrefix(a.Chart, "oci://"), a.Version)
+		if a.Digest != "" {
+			fmt.Printf("       %s\n", a.Digest)
+		}
 	}
-}
-
-func applyManifest(manifest, kubeCtx string) {
-	args := []string{"apply", "-f", "-"}
-	if kubeCtx != "" {
-		args = append(args, "--context", kubeCtx)
+	fmt.Println()
+	printSection("Images")
+	n := 0
+	for _, a := range artifacts {
+		for _, img := range a.Images {
+			fmt.Printf("  •  %-20s  %s\n", img.Values, img.Ref())
+			n++
+		}
 	}
-	cmd := exec.Command("kubectl", args...)
-	cmd.Stdin  = strings.NewReader(manifest)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	fmt.Printf("\n  Applying NodePool manifest…\n\n")
-	if err := cmd.Run(); err != nil {
-		fmt.Printf("\n  ✗ kubectl apply failed: %v\n\n", err)
-	} else {
-		fmt.Printf("\n  ✓  NodePool applied successfully.\n\n")
+	if n == 0 {
+		fmt.Printf("  ⚠  The chart values name no images — check the rendered manifests with karpx install --dry-run.\n")
 	}
-}
+	fmt.Println()
 
-func saveManifest(manifest string) {
-	const filename = "karpx-nodepool.yaml"
-	if err := os.WriteFile(filename, []byte(manifest), 0644); err != nil {
-		fmt.Printf("\n  ✗ Could not write file: %v\n\n", err)
-		return
+	if !helm.Mirrored() {
+		fmt.Printf("  ℹ  Add --registry-mirror <registry> for copy commands and a values file.\n\n")
+		return nil
 	}
-	fmt.Printf("\n  ✓  Saved to %s\n", filename)
-	fmt.Printf("     Review and apply with:\n")
-	fmt.Printf("     kubectl apply -f %s\n\n", filename)
-}
-
-func modeLabelShort(m nodes.OptimizationMode) string {
-	switch m {
-	case nodes.ModeCostOptimized:
-		return "Cost-Optimized (Spot + Graviton)"
-	case nodes.ModeHighPerformance:
-		return "High-Performance (On-Demand, latest-gen)"
-	case nodes.ModeFreeTier:
-		return "Free-Tier (free-tier eligible instances)"
-	default:
-		return "Balanced (Spot + On-Demand, mixed families)"
+	mirror := helm.Registry()
+	printSection("Copy to " + mirror)
+	for _, a := range artifacts {
+		src := strings.TrimPrefix(a.Chart, "oci://")
+		dst := helm.MirrorRepository(src, helm.ChartSource(a.Chart), mirror)
+		fmt.Printf("  crane copy %s:%s %s:%s\n", src, a.Version, dst, a.Version)
+		for _, img := range a.Images {
+			src := img.Repository + ":" + img.Tag
+			if img.Digest != "" {
+				src = img.Repository + "@" + img.Digest
+			}
+			fmt.Printf("  crane copy %s %s:%s\n", src, helm.MirrorRepository(img.Repository, helm.ChartSource(a.Chart), mirror), img.Tag)
+		}
+	}
+	fmt.Println()
+	printSection("Values")
+	for _, line := range strings.Split(strings.TrimRight(string(values), "\n"), "\n") {
+		fmt.Printf("  %s\n", line)
 	}
+	fmt.Printf("\n  Install from the mirror with --registry-mirror %s, or pass these values to helm.\n\n", mirror)
+	return nil
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -1114,7 +6123,9 @@ func modeLabelShort(m nodes.OptimizationMode) string {
 
 func uiCmd() *cobra.Command {
 	var kubeCtx string
-	var port    int
+	var port int
+	var snapshot string
+	var tags []string
 	cmd := &cobra.Command{
 		Use:   "ui",
 		Short: "Open the karpx web dashboard in your browser",
@@ -1124,19 +6135,58 @@ The dashboard shows all kubeconfig contexts with their cloud provider,
 Kubernetes version, Karpenter installation status, and compatibility badges.
 It refreshes automatically every 30 seconds.
 
-Press Ctrl+C to stop the server.`,
+Press Ctrl+C to stop the server.
+
+With --snapshot the dashboard data (clusters plus NodePool summaries) is
+collected once and written to a single self-contained HTML file instead — no
+server, nothing to install to view it, ready to attach to a ticket.`,
 		Example: `  karpx ui                    # all kubeconfig contexts, port 7654
   karpx ui -c my-cluster      # single cluster
-  karpx ui --port 9000         # custom port`,
+  karpx ui --port 9000         # custom port
+  karpx ui --tag team=payments  # only clusters tagged in the karpx config
+  karpx ui --snapshot fleet.html
+  karpx ui --demo             # fake clusters, no kubeconfig needed`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return ui.Serve(port, kubeCtx)
+			sel, err := config.ParseSelector(tags)
+			if err != nil {
+				return err
+			}
+			if snapshot != "" {
+				return runSnapshot(kubeCtx, snapshot, sel)
+			}
+			return ui.Serve(port, kubeCtx, sel)
 		},
 	}
-	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "",   "kubeconfig context (default: all contexts)")
-	cmd.Flags().IntVar(&port,        "port",        7654,  "local port for the dashboard server")
+	cmd.Flags().StringVarP(&kubeCtx, "context", "c", "", "kubeconfig context (default: all contexts)")
+	cmd.Flags().IntVar(&port, "port", 7654, "local port for the dashboard server")
+	cmd.Flags().StringVar(&snapshot, "snapshot", "", "write a static HTML snapshot to this file (- for stdout) and exit")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "only clusters whose config-file tags match (key=value, key!=value, key)")
 	return cmd
 }
 
+// runSnapshot writes the dashboard snapshot to path, or stdout for "-".
+func runSnapshot(kubeCtx, path string, sel config.Selector) error {
+	if path == "-" {
+		return ui.Snapshot(os.Stdout, kubeCtx, version, sel)
+	}
+	target := "every kubeconfig context"
+	if kubeCtx != "" {
+		target = kubeCtx
+	} else if len(sel) > 0 {
+		target = "contexts tagged " + sel.String()
+	}
+	fmt.Fprintf(os.Stderr, "\n  Collecting dashboard data for %s…\n", target)
+	var buf bytes.Buffer
+	if err := ui.Snapshot(&buf, kubeCtx, version, sel); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(redact.String(buf.String())), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "  ✓  Snapshot written to %s\n\n", path)
+	return nil
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // nodepools / version
 // ─────────────────────────────────────────────────────────────────────────────
@@ -1309,84 +6359,108 @@ func versionCmd() *cobra.Command {
 // Interactive helpers
 // ─────────────────────────────────────────────────────────────────────────────
 
-// askProviderMenu shows a numbered menu and returns the chosen provider.
-func askProviderMenu() kube.Provider {
-	fmt.Printf(`
-  Which cloud provider is this cluster running on?
-
-    [1]  AWS EKS        — Karpenter fully supported  (production ready)
-    [2]  Azure AKS      — Preview  (karpenter-provider-azure-aks)
-    [3]  GCP GKE        — Experimental  (karpenter-provider-gcp)
-    [4]  On-prem/Other  — No official Karpenter provider
-
-`)
-	fmt.Print("  Choice [1-4]: ")
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		switch strings.TrimSpace(scanner.Text()) {
-		case "1":
-			return kube.ProviderAWS
-		case "2":
-			return kube.ProviderAzure
-		case "3":
-			return kube.ProviderGCP
-		case "4":
-			return kube.ProviderUnknown
-		}
+// askProviderMenu shows a provider menu and returns the chosen provider.
+func askProviderMenu(w io.Writer) kube.Provider {
+	fmt.Fprintln(w)
+	switch prompt.Select("Which cloud provider is this cluster running on?", []prompt.Option{
+		{Label: "AWS EKS", Detail: "Karpenter fully supported (production ready)"},
+		{Label: "Azure AKS", Detail: "Preview (karpenter-provider-azure-aks)"},
+		{Label: "GCP GKE", Detail: "Experimental (karpenter-provider-gcp)"},
+		{Label: "On-prem/Other", Detail: "No official Karpenter provider"},
+	}, -1) {
+	case 0:
+		return kube.ProviderAWS
+	case 1:
+		return kube.ProviderAzure
+	case 2:
+		return kube.ProviderGCP
 	}
 	return kube.ProviderUnknown
 }
 
 // askIfEmpty prompts the user for a value only when v is empty.
-func askIfEmpty(v, prompt, defaultVal string) string {
+func askIfEmpty(v, label, defaultVal string) string {
+	return askIfEmptyValid(v, label, defaultVal, nil)
+}
+
+// askIfEmptyValid is askIfEmpty with validation of typed answers. Values
+// passed via flags are not re-validated here — the caller owns those.
+func askIfEmptyValid(v, label, defaultVal string, validate func(string) error) string {
 	if v != "" {
 		return v
 	}
-	if defaultVal != "" {
-		fmt.Printf("  %s [%s]: ", prompt, defaultVal)
-	} else {
-		fmt.Printf("  %s: ", prompt)
-	}
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		answer := strings.TrimSpace(scanner.Text())
-		if answer != "" {
-			return answer
-		}
+	return prompt.String(label, defaultVal, validate)
+}
+
+// validNamespace rejects names Kubernetes would refuse for a namespace.
+func validNamespace(s string) error {
+	if errs := validation.IsDNS1123Label(s); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", s, strings.Join(errs, "; "))
 	}
-	return defaultVal
+	return nil
 }
 
-// confirmPrompt reads y/yes from stdin.
-func confirmPrompt(prompt string) bool {
-	fmt.Print(prompt)
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		answer := strings.TrimSpace(strings.ToLower(scanner.Text()))
-		return answer == "y" || answer == "yes"
+// validRoleARN catches the common mistake of pasting a role name or a policy
+// ARN instead of the role ARN.
+func validRoleARN(s string) error {
+	if !awscli.IsARN(s, "iam") || !strings.Contains(s, ":role/") {
+		return fmt.Errorf("expected an IAM role ARN like arn:aws:iam::123456789012:role/KarpenterController (arn:aws-cn:… or arn:aws-us-gov:… outside the commercial partition)")
 	}
-	return false
+	return nil
 }
 
-// confirmDefaultPrompt reads Enter (default yes) or n/no.
-func confirmDefaultPrompt(prompt string) bool {
-	fmt.Print(prompt)
-	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		answer := strings.TrimSpace(strings.ToLower(scanner.Text()))
-		return answer == "" || answer == "y" || answer == "yes"
+// checkPartition rejects a role ARN from another partition than region, and
+// a China install that would pull from public ECR, which is not reachable
+// there.
+func checkPartition(region, roleARN string) error {
+	part := awscli.Partition(region)
+	if p := awscli.ARNPartition(roleARN); p != "" && p != part {
+		return fmt.Errorf("role %s is in the %s partition but region %s is in %s — use a role from the cluster's account", roleARN, p, region, part)
 	}
-	return true
+	if part != awscli.PartitionAWS {
+		fmt.Printf("  ℹ  Partition %s — IAM and SQS ARNs use arn:%s:…\n", part, part)
+	}
+	if awscli.PublicECRReachable(region) || helm.Mirrored() {
+		return nil
+	}
+	account := "<ACCOUNT_ID>"
+	if parts := strings.Split(roleARN, ":"); len(parts) >= 5 && parts[4] != "" {
+		account = parts[4]
+	}
+	mirror := awscli.ECRRegistry(account, region) + "/karpenter"
+	fmt.Printf("  ✗ public.ecr.aws is not reachable from %s. Copy the Karpenter chart and\n", region)
+	fmt.Printf("    image into ECR in this region (from a machine that can reach both):\n\n")
+	fmt.Printf("      crane copy --all-tags public.ecr.aws/karpenter/karpenter %s/karpenter\n", mirror)
+	fmt.Printf("      crane copy --all-tags public.ecr.aws/karpenter/karpenter-crd %s/karpenter-crd\n", mirror)
+	fmt.Printf("      crane copy --all-tags public.ecr.aws/karpenter/controller %s/controller\n\n", mirror)
+	fmt.Printf("    then re-run with --registry-mirror %s\n", mirror)
+	fmt.Printf("    (karpx images --version <v> --registry-mirror %s lists exact digests)\n\n", mirror)
+	return fmt.Errorf("the %s partition needs --registry-mirror", part)
+}
+
+// confirmPrompt asks a yes/no question that defaults to no.
+func confirmPrompt(label string) bool {
+	return prompt.Confirm(label, false)
+}
+
+// confirmDefaultPrompt asks a yes/no question that defaults to yes.
+func confirmDefaultPrompt(label string) bool {
+	return prompt.Confirm(label, true)
 }
 
-// printSection prints a styled section header.
-func printSection(label string) {
-	fmt.Printf("  ── %s %s\n", label, strings.Repeat("─", max(0, 60-len(label))))
+// printSection prints a styled section header. Each section is also a
+// progress step for --progress json.
+func printSection(label string) { fprintSection(os.Stdout, label) }
+
+// fprintSection is printSection to w.
+func fprintSection(w io.Writer, label string) {
+	progress.Step(label)
+	fmt.Fprintf(w, "  ── %s %s\n", label, strings.Repeat("─", max(0, 60-len(label))))
 }
 
 // printUnsupportedProvider explains why on-prem/other clusters aren't supported.
-func printUnsupportedProvider() {
-	fmt.Printf(`
+func printUnsupportedProvider(w io.Writer) {
+	fmt.Fprintf(w, `
   ✗ Karpenter does not currently have an official provider for
     on-prem or non-cloud Kubernetes clusters.
 
@@ -1556,6 +6630,24 @@ func formatVersionList(versions []string, limit int) string {
 		fmt.Sprintf(", … (%d more)", len(versions)-limit)
 }
 
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a